@@ -1,14 +1,22 @@
 package main
 
 import (
+	"bufio"
+	"flag"
+	"fmt"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
+	userDto "github.com/suryaadi44/eAD-System/internal/user/dto"
 	"github.com/suryaadi44/eAD-System/pkg/bootsrapper"
 	"github.com/suryaadi44/eAD-System/pkg/config"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/suryaadi44/eAD-System/pkg/database"
+	qrPkg "github.com/suryaadi44/eAD-System/pkg/utils/qr/impl"
+	"golang.org/x/term"
+	"gorm.io/gorm"
 )
 
 func init() {
@@ -26,6 +34,11 @@ func init() {
 func main() {
 	env := config.LoadConfig()
 
+	if len(os.Args) > 1 && os.Args[1] == "generate-qr-key" {
+		generateQRKey(env, os.Args[2:])
+		return
+	}
+
 	db, err := database.Connect(
 		env["DB_HOST"],
 		env["DB_PORT"],
@@ -43,8 +56,80 @@ func main() {
 		log.Fatalf(err.Error())
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "create-admin" {
+		createAdmin(db, env, os.Args[2:])
+		return
+	}
+
 	e := echo.New()
 	bootsrapper.InitController(e, db, env)
 
 	e.Logger.Fatal(e.Start(":" + env["PORT"]))
 }
+
+// generateQRKey create the office key that sign the QR token, it is run once and the file is shared by every instance
+// since a new key invalidate every printed QR
+//
+//	main generate-qr-key -path ./keystore/qr_signing_key.pem
+func generateQRKey(env map[string]string, args []string) {
+	flags := flag.NewFlagSet("generate-qr-key", flag.ExitOnError)
+	path := flags.String("path", env["QR_SIGNING_KEY"], "path of the key file")
+	_ = flags.Parse(args)
+
+	if _, err := qrPkg.CreateSigningKey(*path); err != nil {
+		log.Fatalf(err.Error())
+	}
+
+	log.Printf("qr signing key is created in %s", *path)
+}
+
+// createAdmin create the first admin from the command line, the password is read from ADMIN_PASSWORD or the standard
+// input so it didn't end up in the shell history
+//
+//	main create-admin -username root
+func createAdmin(db *gorm.DB, env map[string]string, args []string) {
+	flags := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	username := flags.String("username", env["ADMIN_USERNAME"], "username of the admin")
+	_ = flags.Parse(args)
+
+	password := env["ADMIN_PASSWORD"]
+	if password == "" {
+		var err error
+		if password, err = readPassword(); err != nil {
+			log.Fatalf(err.Error())
+		}
+	}
+
+	id, err := bootsrapper.CreateInitialAdmin(db, env, &userDto.AdminCreateRequest{
+		Username: *username,
+		Password: password,
+	})
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+
+	log.Printf("admin %s is created with id %s, the password must be changed on the first login", *username, id)
+}
+
+// readPassword read the password without echoing it when the standard input is a terminal, the piped input is read as
+// it is
+func readPassword() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Print("Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+
+	return string(password), nil
+}
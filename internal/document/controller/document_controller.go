@@ -1,10 +1,16 @@
 package controller
 
 import (
+	"fmt"
 	"github.com/suryaadi44/eAD-System/pkg/utils"
 	"github.com/suryaadi44/eAD-System/pkg/utils/jwt_service"
+	"github.com/suryaadi44/eAD-System/pkg/utils/qr"
+	"github.com/suryaadi44/eAD-System/pkg/utils/rbac"
+	"github.com/suryaadi44/eAD-System/pkg/utils/renderer"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/suryaadi44/eAD-System/internal/document/dto"
@@ -42,6 +48,10 @@ func (d *DocumentController) AddDocument(c echo.Context) error {
 		case utils.ErrTemplateNotFound:
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
 		case utils.ErrFieldNotMatch:
+			fallthrough
+		case utils.ErrInvalidTableRow:
+			fallthrough
+		case utils.ErrExpressionEvaluation:
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		case utils.ErrDuplicateRegister:
 			return echo.NewHTTPError(http.StatusConflict, err.Error())
@@ -71,10 +81,9 @@ func (d *DocumentController) GetDocument(c echo.Context) error {
 
 	claims := d.jwtService.GetClaims(&c)
 	userID := claims["user_id"].(string)
-	role := claims["role"].(float64)
 
 	switch {
-	case role > 1:
+	case rbac.HasPermission(claims, rbac.PermissionDocumentReadAll):
 		fallthrough
 	case document.Applicant.ID == userID:
 		return c.JSON(http.StatusOK, echo.Map{
@@ -88,8 +97,8 @@ func (d *DocumentController) GetDocument(c echo.Context) error {
 
 func (d *DocumentController) GetBriefDocument(c echo.Context) error {
 	claims := d.jwtService.GetClaims(&c)
-	role := claims["role"].(float64)
 	userID := claims["user_id"].(string)
+	ownerOnly := !rbac.HasPermission(claims, rbac.PermissionDocumentReadAll)
 
 	page := c.QueryParam("page")
 	if page == "" {
@@ -109,7 +118,7 @@ func (d *DocumentController) GetBriefDocument(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrInvalidNumber.Error())
 	}
 
-	documents, err := d.documentService.GetBriefDocuments(c.Request().Context(), userID, int(role), int(pageInt), int(limitInt))
+	documents, err := d.documentService.GetBriefDocuments(c.Request().Context(), userID, ownerOnly, int(pageInt), int(limitInt))
 	if err != nil {
 		if err == utils.ErrDocumentNotFound {
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
@@ -130,13 +139,24 @@ func (d *DocumentController) GetBriefDocument(c echo.Context) error {
 
 func (d *DocumentController) GetDocumentStatus(c echo.Context) error {
 	documentID := c.Param("document_id")
-	status, err := d.documentService.GetDocumentStatus(c.Request().Context(), documentID)
+	token := c.QueryParam("token")
+	status, err := d.documentService.GetDocumentStatus(c.Request().Context(), documentID, token)
+
+	// the QR code is mostly opened by phone camera, so browser get the verification page instead of JSON
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+	if acceptsHTML(c.Request().Header.Get(echo.HeaderAccept)) {
+		return d.renderStatusPage(c, status, err)
+	}
+
 	if err != nil {
-		if err == utils.ErrDocumentNotFound {
+		switch err {
+		case utils.ErrDocumentNotFound:
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case utils.ErrInvalidQRToken:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
-
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
 	return c.JSON(http.StatusOK, echo.Map{
@@ -145,48 +165,288 @@ func (d *DocumentController) GetDocumentStatus(c echo.Context) error {
 	})
 }
 
+func (d *DocumentController) renderStatusPage(c echo.Context, status *dto.DocumentStatusResponse, statusErr error) error {
+	page, err := d.documentService.GenerateStatusPage(status, statusErr)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	code := http.StatusOK
+	switch statusErr {
+	case utils.ErrDocumentNotFound:
+		code = http.StatusNotFound
+	case utils.ErrInvalidQRToken:
+		code = http.StatusBadRequest
+	}
+
+	return c.HTMLBlob(code, page.Bytes())
+}
+
+// acceptsHTML tell if the client prefer HTML over JSON from its Accept header, wildcard is answered with JSON so the
+// API client that didn't set the header keep getting JSON
+func acceptsHTML(accept string) bool {
+	var htmlQuality, jsonQuality float64
+	for _, mediaRange := range strings.Split(accept, ",") {
+		params := strings.Split(mediaRange, ";")
+		quality := 1.0
+		for _, param := range params[1:] {
+			if value := strings.TrimSpace(param); strings.HasPrefix(value, "q=") {
+				if q, err := strconv.ParseFloat(value[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		switch strings.ToLower(strings.TrimSpace(params[0])) {
+		case echo.MIMETextHTML:
+			htmlQuality = quality
+		case echo.MIMEApplicationJSON:
+			jsonQuality = quality
+		}
+	}
+
+	return htmlQuality > 0 && htmlQuality > jsonQuality
+}
+
+// GetQRPublicKey publish the public key of the QR token as JSON Web Key Set
+func (d *DocumentController) GetQRPublicKey(c echo.Context) error {
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "success getting qr public key",
+		"data": echo.Map{
+			"keys": []*qr.PublicKey{d.documentService.GetQRPublicKey()},
+		},
+	})
+}
+
 func (d *DocumentController) GetPDFDocument(c echo.Context) error {
 	documentID := c.Param("document_id")
 
-	claims := d.jwtService.GetClaims(&c)
-	userID := claims["user_id"].(string)
-	role := claims["role"].(float64)
+	if err := d.checkDocumentOwner(c, documentID); err != nil {
+		return err
+	}
 
-	if role == 1 {
-		applicantID, err := d.documentService.GetApplicantID(c.Request().Context(), documentID)
-		if err != nil {
-			if err == utils.ErrDocumentNotFound {
-				return echo.NewHTTPError(http.StatusNotFound, err.Error())
-			}
+	async := c.QueryParam("async") == "true"
+	pdf, job, err := d.documentService.RequestPDFDocument(c.Request().Context(), documentID, async)
+	if err != nil {
+		switch err {
+		case utils.ErrDocumentNotFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case utils.ErrRenderQueueFull:
+			return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// the document is still rendering, the pdf can be downloaded from the job once it is done
+	if job != nil {
+		c.Response().Header().Set(echo.HeaderLocation, "/v1/documents/jobs/"+job.ID+"/")
+		return c.JSON(http.StatusAccepted, echo.Map{
+			"message": "document is being rendered",
+			"data":    job,
+		})
+	}
 
+	return c.Blob(http.StatusOK, "application/pdf", pdf)
+}
+
+// RenderDocument return the document in the requested format, the request wait for the PDF render job instead of
+// returning it since the other format is only asked for one document at a time
+func (d *DocumentController) RenderDocument(c echo.Context) error {
+	documentID := c.Param("document_id")
+	format := c.QueryParam("format")
+	if format == "" {
+		format = renderer.FormatPDF
+	}
+
+	if err := d.checkDocumentOwner(c, documentID); err != nil {
+		return err
+	}
+
+	rendered, err := d.documentService.RenderDocument(c.Request().Context(), documentID, format)
+	if err != nil {
+		switch err {
+		case utils.ErrUnsupportedFormat:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case utils.ErrSignedSnapshotNotFound:
+			fallthrough
+		case utils.ErrDocumentNotFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s.%s"`, documentID, strings.ToLower(format)))
+	return c.Blob(http.StatusOK, rendered.ContentType, rendered.Content)
+}
+
+// ExportDocuments stream the PDF of the selected document as one merged PDF or as a zip. The response is only started
+// when the first byte of the file is ready, so an error found before it still get its own status code. An error after
+// that abort the connection, so the client see a broken download instead of a short file with a success status
+func (d *DocumentController) ExportDocuments(c echo.Context) error {
+	exportRequest := new(dto.ExportDocumentsRequest)
+	if err := c.Bind(exportRequest); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
+	}
+
+	if err := c.Validate(exportRequest); err != nil {
+		return err
+	}
+
+	documentIDs, err := d.documentService.GetExportDocumentIDs(c.Request().Context(), exportRequest)
+	if err != nil {
+		switch err {
+		case utils.ErrDocumentNotFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case utils.ErrBadRequestBody:
+			fallthrough
+		case utils.ErrTooManyDocuments:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	format := exportRequest.Format
+	contentType := "application/pdf"
+	if format == "" {
+		format = renderer.FormatPDF
+	} else if format == "zip" {
+		contentType = "application/zip"
+	}
+
+	w := &exportWriter{
+		response:           c.Response(),
+		contentType:        contentType,
+		contentDisposition: fmt.Sprintf(`attachment; filename="documents.%s"`, format),
+	}
+	err = d.documentService.WriteDocumentExport(c.Request().Context(), documentIDs, format, w)
+	if err != nil && w.started {
+		log.Printf("export documents: %v", err)
+		panic(http.ErrAbortHandler)
+	} else if err != nil {
+		switch err {
+		case utils.ErrUnsupportedFormat:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case utils.ErrDocumentNotFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case utils.ErrRenderQueueFull:
+			return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+		default:
 			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
+	}
+
+	return nil
+}
+
+// exportWriter write the response header right before the first byte of the exported file
+type exportWriter struct {
+	response           *echo.Response
+	contentType        string
+	contentDisposition string
+	started            bool
+}
+
+func (w *exportWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.response.Header().Set(echo.HeaderContentType, w.contentType)
+		w.response.Header().Set(echo.HeaderContentDisposition, w.contentDisposition)
+		w.response.WriteHeader(http.StatusOK)
+	}
 
-		if *applicantID != userID {
-			return echo.NewHTTPError(http.StatusForbidden, utils.ErrDidntHavePermission.Error())
+	return w.response.Write(b)
+}
+
+func (d *DocumentController) GetRenderJob(c echo.Context) error {
+	jobID := c.Param("job_id")
+
+	job, err := d.documentService.GetRenderJob(c.Request().Context(), jobID)
+	if err != nil {
+		if err == utils.ErrRenderJobNotFound {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
 		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	pdf, err := d.documentService.GeneratePDFDocument(c.Request().Context(), documentID)
+	if err = d.checkDocumentOwner(c, job.DocumentID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "success getting render job",
+		"data":    job,
+	})
+}
+
+func (d *DocumentController) GetRenderJobPDF(c echo.Context) error {
+	jobID := c.Param("job_id")
+
+	job, err := d.documentService.GetRenderJob(c.Request().Context(), jobID)
 	if err != nil {
-		if err == utils.ErrDocumentNotFound {
+		if err == utils.ErrRenderJobNotFound {
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
 		}
 
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	if err = d.checkDocumentOwner(c, job.DocumentID); err != nil {
+		return err
+	}
+
+	pdf, err := d.documentService.GetRenderJobPDF(c.Request().Context(), jobID)
+	if err != nil {
+		switch err {
+		case utils.ErrRenderJobNotFound:
+			fallthrough
+		case utils.ErrArtifactNotFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case utils.ErrRenderJobNotDone:
+			fallthrough
+		case utils.ErrRenderJobFailed:
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
 	return c.Blob(http.StatusOK, "application/pdf", pdf)
 }
 
-func (d *DocumentController) VerifyDocument(c echo.Context) error {
+// checkDocumentOwner only let the applicant access their own document, user with the read all permission can access
+// every document
+func (d *DocumentController) checkDocumentOwner(c echo.Context, documentID string) error {
 	claims := d.jwtService.GetClaims(&c)
-	role := claims["role"].(float64)
 	userID := claims["user_id"].(string)
-	if role < 2 { // role 2 or above are employee
+
+	if rbac.HasPermission(claims, rbac.PermissionDocumentReadAll) {
+		return nil
+	}
+
+	applicantID, err := d.documentService.GetApplicantID(c.Request().Context(), documentID)
+	if err != nil {
+		if err == utils.ErrDocumentNotFound {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if *applicantID != userID {
 		return echo.NewHTTPError(http.StatusForbidden, utils.ErrDidntHavePermission.Error())
 	}
 
+	return nil
+}
+
+func (d *DocumentController) VerifyDocument(c echo.Context) error {
+	claims := d.jwtService.GetClaims(&c)
+	userID := claims["user_id"].(string)
+
 	verifyRequest := new(dto.VerifyDocumentRequest)
 	if err := c.Bind(verifyRequest); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
@@ -209,11 +469,7 @@ func (d *DocumentController) VerifyDocument(c echo.Context) error {
 
 func (d *DocumentController) SignDocument(c echo.Context) error {
 	claims := d.jwtService.GetClaims(&c)
-	role := claims["role"].(float64)
 	userID := claims["user_id"].(string)
-	if role < 3 { // role 2 or above are employee
-		return echo.NewHTTPError(http.StatusForbidden, utils.ErrDidntHavePermission.Error())
-	}
 
 	documentID := c.Param("document_id")
 	err := d.documentService.SignDocument(c.Request().Context(), documentID, userID)
@@ -222,7 +478,15 @@ func (d *DocumentController) SignDocument(c echo.Context) error {
 		case utils.ErrDocumentNotFound:
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
 		case utils.ErrNotVerifiedYet:
+			fallthrough
+		case utils.ErrAlreadySigned:
 			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		case utils.ErrSigningKeyNotFound:
+			fallthrough
+		case utils.ErrSigningCertificateExpired:
+			fallthrough
+		case utils.ErrInvalidSignaturePlacement:
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
 		default:
 			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
@@ -233,13 +497,75 @@ func (d *DocumentController) SignDocument(c echo.Context) error {
 	})
 }
 
+func (d *DocumentController) RevokeDocument(c echo.Context) error {
+	revokeRequest := new(dto.RevokeDocumentRequest)
+	if err := c.Bind(revokeRequest); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
+	}
+
+	if err := c.Validate(revokeRequest); err != nil {
+		return err
+	}
+
+	documentID := c.Param("document_id")
+	err := d.documentService.RevokeDocument(c.Request().Context(), documentID, revokeRequest)
+	if err != nil {
+		switch err {
+		case utils.ErrDocumentNotFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case utils.ErrNotSignedYet:
+			fallthrough
+		case utils.ErrAlreadyRevoked:
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "success revoking document",
+	})
+}
+
+// VerifyDocumentFile check whether the uploaded PDF is an issued letter, it is public so anyone who receive the
+// letter can check it
+func (d *DocumentController) VerifyDocumentFile(c echo.Context) error {
+	file, err := c.FormFile("document")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
+	}
+
+	fileSrc, err := file.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer fileSrc.Close()
+
+	result, err := d.documentService.VerifyDocumentFile(c.Request().Context(), fileSrc)
+	if err != nil {
+		switch err {
+		case utils.ErrPDFTooLarge:
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, err.Error())
+		case utils.ErrUnsupportedPDF:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "success verifying document file",
+		"data":    result,
+	})
+}
+
 func (d *DocumentController) DeleteDocument(c echo.Context) error {
 	claims := d.jwtService.GetClaims(&c)
-	role := claims["role"].(float64)
 	userID := claims["user_id"].(string)
+	ownerOnly := !rbac.HasPermission(claims, rbac.PermissionDocumentDelete)
 
 	documentID := c.Param("document_id")
-	err := d.documentService.DeleteDocument(c.Request().Context(), userID, int(role), documentID)
+	err := d.documentService.DeleteDocument(c.Request().Context(), userID, ownerOnly, documentID)
 	if err != nil {
 		switch err {
 		case utils.ErrDocumentNotFound:
@@ -259,12 +585,6 @@ func (d *DocumentController) DeleteDocument(c echo.Context) error {
 }
 
 func (d *DocumentController) UpdateDocument(c echo.Context) error {
-	claims := d.jwtService.GetClaims(&c)
-	role := claims["role"].(float64)
-	if role < 2 {
-		return echo.NewHTTPError(http.StatusForbidden, utils.ErrDidntHavePermission.Error())
-	}
-
 	documentID := c.Param("document_id")
 	var document dto.DocumentUpdateRequest
 	if err := c.Bind(&document); err != nil {
@@ -292,8 +612,8 @@ func (d *DocumentController) UpdateDocument(c echo.Context) error {
 
 func (d *DocumentController) UpdateDocumentFields(c echo.Context) error {
 	claims := d.jwtService.GetClaims(&c)
-	role := claims["role"].(float64)
 	userID := claims["user_id"].(string)
+	ownerOnly := !rbac.HasPermission(claims, rbac.PermissionDocumentUpdate)
 
 	documentID := c.Param("document_id")
 	var fields dto.FieldsUpdateRequest
@@ -305,11 +625,19 @@ func (d *DocumentController) UpdateDocumentFields(c echo.Context) error {
 		return err
 	}
 
-	err := d.documentService.UpdateDocumentFields(c.Request().Context(), userID, int(role), documentID, &fields)
+	err := d.documentService.UpdateDocumentFields(c.Request().Context(), userID, ownerOnly, documentID, &fields)
 	if err != nil {
 		switch err {
 		case utils.ErrDocumentNotFound:
+			fallthrough
+		case utils.ErrFieldNotFound:
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case utils.ErrFieldNotMatch:
+			fallthrough
+		case utils.ErrInvalidTableRow:
+			fallthrough
+		case utils.ErrApplicantFieldReadOnly:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		case utils.ErrAlreadyVerified:
 			fallthrough
 		case utils.ErrAlreadySigned:
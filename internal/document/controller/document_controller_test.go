@@ -7,7 +7,11 @@ import (
 	mockDocumentServicePkg "github.com/suryaadi44/eAD-System/internal/document/service/mock"
 	"github.com/suryaadi44/eAD-System/pkg/utils"
 	mockJwtServicePkg "github.com/suryaadi44/eAD-System/pkg/utils/jwt_service/mock"
+	"github.com/suryaadi44/eAD-System/pkg/utils/qr"
+	"github.com/suryaadi44/eAD-System/pkg/utils/rbac"
 	mockValidatorPkg "github.com/suryaadi44/eAD-System/pkg/utils/validation/mock"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -31,6 +35,13 @@ type TestSuiteDocumentController struct {
 	echoApp             *echo.Echo
 }
 
+// Permission carried by the token of the default role
+var (
+	applicantPermissions = []interface{}{rbac.PermissionDocumentCreate}
+	verifierPermissions  = []interface{}{rbac.PermissionDocumentCreate, rbac.PermissionDocumentReadAll, rbac.PermissionDocumentUpdate, rbac.PermissionDocumentDelete, rbac.PermissionDocumentVerify}
+	adminPermissions     = []interface{}{rbac.PermissionDocumentCreate, rbac.PermissionDocumentReadAll, rbac.PermissionDocumentUpdate, rbac.PermissionDocumentDelete, rbac.PermissionDocumentVerify, rbac.PermissionDocumentSign, rbac.PermissionDocumentRevoke}
+)
+
 func (s *TestSuiteDocumentController) SetupTest() {
 	s.mockDocumentService = new(mockDocumentServicePkg.MockDocumentService)
 	s.mockJWTService = new(mockJwtServicePkg.MockJWTService)
@@ -256,8 +267,8 @@ func (s *TestSuiteDocumentController) TestGetDocument() {
 			},
 
 			JWTReturn: jwt.MapClaims{
-				"user_id": "1",
-				"role":    float64(1),
+				"user_id":             "1",
+				rbac.ClaimPermissions: applicantPermissions,
 			},
 			ExpectedStatus: http.StatusOK,
 			ExpectedBody: echo.Map{
@@ -272,13 +283,33 @@ func (s *TestSuiteDocumentController) TestGetDocument() {
 						"name":     "",
 					},
 					"template": map[string]interface{}{
-						"id":            float64(0),
-						"name":          "",
-						"margin_top":    float64(0),
-						"margin_bottom": float64(0),
-						"margin_left":   float64(0),
-						"margin_right":  float64(0),
-						"keys":          interface{}(nil),
+						"id":               float64(0),
+						"name":             "",
+						"margin_top":       float64(0),
+						"margin_bottom":    float64(0),
+						"margin_left":      float64(0),
+						"margin_right":     float64(0),
+						"page_size":        "",
+						"page_width":       float64(0),
+						"page_height":      float64(0),
+						"orientation":      "",
+						"dpi":              float64(0),
+						"grayscale":        false,
+						"zoom":             float64(0),
+						"has_header":       false,
+						"has_footer":       false,
+						"qr_on_every_page": false,
+						"signature_field": map[string]interface{}{
+							"page":   float64(0),
+							"x":      float64(0),
+							"y":      float64(0),
+							"width":  float64(0),
+							"height": float64(0),
+						},
+						"watermark": map[string]interface{}{
+							"opacity": float64(0),
+						},
+						"keys": interface{}(nil),
 					},
 					"fields":      interface{}(nil),
 					"stage":       "",
@@ -336,8 +367,8 @@ func (s *TestSuiteDocumentController) TestGetDocument() {
 				UpdatedAt:  time.Time{},
 			},
 			JWTReturn: jwt.MapClaims{
-				"user_id": "2",
-				"role":    float64(1),
+				"user_id":             "2",
+				rbac.ClaimPermissions: applicantPermissions,
 			},
 			ExpectedStatus: http.StatusForbidden,
 			ExpectedBody:   nil,
@@ -367,8 +398,8 @@ func (s *TestSuiteDocumentController) TestGetDocument() {
 				UpdatedAt:  time.Time{},
 			},
 			JWTReturn: jwt.MapClaims{
-				"user_id": "2",
-				"role":    float64(2),
+				"user_id":             "2",
+				rbac.ClaimPermissions: verifierPermissions,
 			},
 			ExpectedStatus: http.StatusOK,
 			ExpectedBody: echo.Map{
@@ -383,13 +414,33 @@ func (s *TestSuiteDocumentController) TestGetDocument() {
 						"name":     "",
 					},
 					"template": map[string]interface{}{
-						"id":            float64(0),
-						"name":          "",
-						"margin_top":    float64(0),
-						"margin_bottom": float64(0),
-						"margin_left":   float64(0),
-						"margin_right":  float64(0),
-						"keys":          interface{}(nil),
+						"id":               float64(0),
+						"name":             "",
+						"margin_top":       float64(0),
+						"margin_bottom":    float64(0),
+						"margin_left":      float64(0),
+						"margin_right":     float64(0),
+						"page_size":        "",
+						"page_width":       float64(0),
+						"page_height":      float64(0),
+						"orientation":      "",
+						"dpi":              float64(0),
+						"grayscale":        false,
+						"zoom":             float64(0),
+						"has_header":       false,
+						"has_footer":       false,
+						"qr_on_every_page": false,
+						"signature_field": map[string]interface{}{
+							"page":   float64(0),
+							"x":      float64(0),
+							"y":      float64(0),
+							"width":  float64(0),
+							"height": float64(0),
+						},
+						"watermark": map[string]interface{}{
+							"opacity": float64(0),
+						},
+						"keys": interface{}(nil),
 					},
 					"fields":      interface{}(nil),
 					"stage":       "",
@@ -469,8 +520,8 @@ func (s *TestSuiteDocumentController) TestGetBriefDocument() {
 				},
 			},
 			JWTReturn: jwt.MapClaims{
-				"user_id": "1",
-				"role":    float64(2),
+				"user_id":             "1",
+				rbac.ClaimPermissions: verifierPermissions,
 			},
 			ExpectedStatus: http.StatusOK,
 			ExpectedBody: echo.Map{
@@ -516,8 +567,8 @@ func (s *TestSuiteDocumentController) TestGetBriefDocument() {
 				},
 			},
 			JWTReturn: jwt.MapClaims{
-				"user_id": "1",
-				"role":    float64(2),
+				"user_id":             "1",
+				rbac.ClaimPermissions: verifierPermissions,
 			},
 			ExpectedStatus: http.StatusOK,
 			ExpectedBody: echo.Map{
@@ -550,8 +601,8 @@ func (s *TestSuiteDocumentController) TestGetBriefDocument() {
 			FunctionError:  nil,
 			FunctionReturn: nil,
 			JWTReturn: jwt.MapClaims{
-				"user_id": "1",
-				"role":    float64(2),
+				"user_id":             "1",
+				rbac.ClaimPermissions: verifierPermissions,
 			},
 			ExpectedStatus: http.StatusBadRequest,
 			ExpectedBody:   nil,
@@ -564,8 +615,8 @@ func (s *TestSuiteDocumentController) TestGetBriefDocument() {
 			FunctionError:  nil,
 			FunctionReturn: nil,
 			JWTReturn: jwt.MapClaims{
-				"user_id": "1",
-				"role":    float64(2),
+				"user_id":             "1",
+				rbac.ClaimPermissions: verifierPermissions,
 			},
 			ExpectedStatus: http.StatusBadRequest,
 			ExpectedBody:   nil,
@@ -578,8 +629,8 @@ func (s *TestSuiteDocumentController) TestGetBriefDocument() {
 			FunctionError:  utils.ErrDocumentNotFound,
 			FunctionReturn: nil,
 			JWTReturn: jwt.MapClaims{
-				"user_id": "1",
-				"role":    float64(2),
+				"user_id":             "1",
+				rbac.ClaimPermissions: verifierPermissions,
 			},
 			ExpectedStatus: http.StatusNotFound,
 			ExpectedBody:   nil,
@@ -592,8 +643,8 @@ func (s *TestSuiteDocumentController) TestGetBriefDocument() {
 			FunctionError:  errors.New("generic service error"),
 			FunctionReturn: nil,
 			JWTReturn: jwt.MapClaims{
-				"user_id": "1",
-				"role":    float64(2),
+				"user_id":             "1",
+				rbac.ClaimPermissions: verifierPermissions,
 			},
 			ExpectedStatus: http.StatusInternalServerError,
 			ExpectedBody:   nil,
@@ -668,11 +719,13 @@ func (s *TestSuiteDocumentController) TestGetDocumentStatus() {
 					"id":          "1",
 					"description": "description",
 					"register":    float64(123),
+					"applicant":   "",
 					"stage":       "applied",
 					"verifier":    map[string]interface{}{},
 					"verified_at": "0001-01-01T00:00:00Z",
 					"signer":      map[string]interface{}{},
 					"signed_at":   "0001-01-01T00:00:00Z",
+					"revoked":     false,
 					"created_at":  "0001-01-01T00:00:00Z",
 					"updated_at":  "0001-01-01T00:00:00Z",
 				},
@@ -687,6 +740,14 @@ func (s *TestSuiteDocumentController) TestGetDocumentStatus() {
 			ExpectedBody:   nil,
 			ExpectedError:  utils.ErrDocumentNotFound,
 		},
+		{
+			Name:           "Failed to get document status : invalid qr token",
+			FunctionError:  utils.ErrInvalidQRToken,
+			FunctionReturn: nil,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   nil,
+			ExpectedError:  utils.ErrInvalidQRToken,
+		},
 		{
 			Name:           "Failed to get document status : generic error from service",
 			FunctionError:  errors.New("generic error"),
@@ -699,14 +760,14 @@ func (s *TestSuiteDocumentController) TestGetDocumentStatus() {
 		s.Run(tc.Name, func() {
 			s.SetupTest()
 
-			r := httptest.NewRequest("GET", "/documents", nil)
+			r := httptest.NewRequest("GET", "/documents?token=token", nil)
 			w := httptest.NewRecorder()
 
 			c := s.echoApp.NewContext(r, w)
 			c.SetParamNames("document_id")
 			c.SetParamValues("1")
 
-			s.mockDocumentService.On("GetDocumentStatus", mock.Anything, "1").Return(tc.FunctionReturn, tc.FunctionError)
+			s.mockDocumentService.On("GetDocumentStatus", mock.Anything, "1", "token").Return(tc.FunctionReturn, tc.FunctionError)
 
 			err := s.documentController.GetDocumentStatus(c)
 
@@ -728,6 +789,107 @@ func (s *TestSuiteDocumentController) TestGetDocumentStatus() {
 	}
 }
 
+func (s *TestSuiteDocumentController) TestGetDocumentStatus_HTML() {
+	status := &dto.DocumentStatusResponse{ID: "1", Description: "description"}
+
+	for _, tc := range []struct {
+		Name           string
+		FunctionError  error
+		FunctionReturn *dto.DocumentStatusResponse
+		PageError      error
+		ExpectedStatus int
+		ExpectedError  error
+	}{
+		{
+			Name:           "Success to render status page",
+			FunctionReturn: status,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Success to render status page : document not found",
+			FunctionError:  utils.ErrDocumentNotFound,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Success to render status page : invalid qr token",
+			FunctionError:  utils.ErrInvalidQRToken,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Failed to render status page : generic error from service",
+			FunctionError:  errors.New("generic error"),
+			PageError:      errors.New("generic error"),
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			r := httptest.NewRequest("GET", "/documents?token=token", nil)
+			r.Header.Set(echo.HeaderAccept, "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+			c.SetParamNames("document_id")
+			c.SetParamValues("1")
+
+			s.mockDocumentService.On("GetDocumentStatus", mock.Anything, "1", "token").Return(tc.FunctionReturn, tc.FunctionError)
+			s.mockDocumentService.On("GenerateStatusPage", tc.FunctionReturn, tc.FunctionError).Return(bytes.NewBufferString("<html></html>"), tc.PageError)
+
+			err := s.documentController.GetDocumentStatus(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(echo.MIMETextHTMLCharsetUTF8, w.Header().Get(echo.HeaderContentType))
+				s.Equal(echo.HeaderAccept, w.Header().Get(echo.HeaderVary))
+				s.Equal("<html></html>", w.Body.String())
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteDocumentController) TestAcceptsHTML() {
+	for accept, expected := range map[string]bool{
+		"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8": true,
+		"application/json, text/html;q=0.5":                               false,
+		"application/json;q=0.5, TEXT/HTML":                               true,
+		"text/html;q=0":                                                   false,
+		"*/*":                                                             false,
+		"":                                                                false,
+	} {
+		s.Equal(expected, acceptsHTML(accept), accept)
+	}
+}
+
+func (s *TestSuiteDocumentController) TestGetQRPublicKey() {
+	r := httptest.NewRequest("GET", "/documents/qr/keys", nil)
+	w := httptest.NewRecorder()
+	c := s.echoApp.NewContext(r, w)
+
+	s.mockDocumentService.On("GetQRPublicKey").Return(&qr.PublicKey{KeyType: "OKP", Curve: "Ed25519", X: "x", KeyID: "kid", Algorithm: "EdDSA", Use: "sig"})
+
+	err := s.documentController.GetQRPublicKey(c)
+	s.NoError(err)
+
+	var response echo.Map
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	s.Equal(http.StatusOK, w.Result().StatusCode)
+	s.Equal(echo.Map{
+		"message": "success getting qr public key",
+		"data": map[string]interface{}{
+			"keys": []interface{}{
+				map[string]interface{}{"kty": "OKP", "crv": "Ed25519", "x": "x", "kid": "kid", "alg": "EdDSA", "use": "sig"},
+			},
+		},
+	}, response)
+}
+
 func (s *TestSuiteDocumentController) TestGetPDFDocument() {
 	for _, tc := range []struct {
 		Name           string
@@ -744,8 +906,8 @@ func (s *TestSuiteDocumentController) TestGetPDFDocument() {
 			ServiceReturn: "1",
 			PDFError:      nil,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(1),
-				"user_id": "1",
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusOK,
 			ExpectedError:  nil,
@@ -756,8 +918,8 @@ func (s *TestSuiteDocumentController) TestGetPDFDocument() {
 			ServiceReturn: "",
 			PDFError:      nil,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(1),
-				"user_id": "1",
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusNotFound,
 			ExpectedError:  utils.ErrDocumentNotFound,
@@ -768,8 +930,8 @@ func (s *TestSuiteDocumentController) TestGetPDFDocument() {
 			ServiceReturn: "",
 			PDFError:      nil,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(1),
-				"user_id": "1",
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusInternalServerError,
 			ExpectedError:  errors.New("generic error"),
@@ -780,8 +942,8 @@ func (s *TestSuiteDocumentController) TestGetPDFDocument() {
 			ServiceReturn: "2",
 			PDFError:      nil,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(1),
-				"user_id": "1",
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusForbidden,
 			ExpectedError:  utils.ErrDidntHavePermission,
@@ -792,8 +954,8 @@ func (s *TestSuiteDocumentController) TestGetPDFDocument() {
 			ServiceReturn: "",
 			PDFError:      utils.ErrDocumentNotFound,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(2),
-				"user_id": "1",
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusNotFound,
 			ExpectedError:  utils.ErrDocumentNotFound,
@@ -804,12 +966,24 @@ func (s *TestSuiteDocumentController) TestGetPDFDocument() {
 			ServiceReturn: "",
 			PDFError:      errors.New("generic error"),
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(2),
-				"user_id": "1",
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusInternalServerError,
 			ExpectedError:  errors.New("generic error"),
 		},
+		{
+			Name:          "Failed to get pdf document : render queue is full",
+			ServiceError:  nil,
+			ServiceReturn: "",
+			PDFError:      utils.ErrRenderQueueFull,
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusServiceUnavailable,
+			ExpectedError:  utils.ErrRenderQueueFull,
+		},
 	} {
 		s.Run(tc.Name, func() {
 			s.SetupTest()
@@ -823,7 +997,7 @@ func (s *TestSuiteDocumentController) TestGetPDFDocument() {
 
 			s.mockJWTService.On("GetClaims", mock.Anything).Return(tc.JWTReturn)
 			s.mockDocumentService.On("GetApplicantID", mock.Anything, "1").Return(&tc.ServiceReturn, tc.ServiceError)
-			s.mockDocumentService.On("GeneratePDFDocument", mock.Anything, "1").Return([]byte(nil), tc.PDFError)
+			s.mockDocumentService.On("RequestPDFDocument", mock.Anything, "1", false).Return([]byte(nil), (*dto.RenderJobResponse)(nil), tc.PDFError)
 
 			err := s.documentController.GetPDFDocument(c)
 
@@ -838,71 +1012,139 @@ func (s *TestSuiteDocumentController) TestGetPDFDocument() {
 	}
 }
 
-func (s *TestSuiteDocumentController) TestVerifyDocument() {
+func (s *TestSuiteDocumentController) TestGetPDFDocument_Async() {
+	r := httptest.NewRequest("GET", "/documents?async=true", nil)
+	w := httptest.NewRecorder()
+
+	c := s.echoApp.NewContext(r, w)
+	c.SetParamNames("document_id")
+	c.SetParamValues("1")
+
+	job := &dto.RenderJobResponse{ID: "job1", DocumentID: "1", Status: "queued"}
+	s.mockJWTService.On("GetClaims", mock.Anything).Return(jwt.MapClaims{
+		rbac.ClaimPermissions: verifierPermissions,
+		"user_id":             "1",
+	})
+	s.mockDocumentService.On("RequestPDFDocument", mock.Anything, "1", true).Return([]byte(nil), job, nil)
+
+	err := s.documentController.GetPDFDocument(c)
+	s.NoError(err)
+	s.Equal(http.StatusAccepted, w.Code)
+	s.Equal("/v1/documents/jobs/job1/", w.Header().Get(echo.HeaderLocation))
+
+	var body echo.Map
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &body))
+	s.Equal("document is being rendered", body["message"])
+}
+
+func (s *TestSuiteDocumentController) TestRenderDocument() {
 	for _, tc := range []struct {
-		Name           string
-		ServiceError   error
-		ServiceReturn  string
-		JWTReturn      jwt.MapClaims
-		ExpectedStatus int
-		ExpectedBody   echo.Map
-		ExpectedError  error
+		Name            string
+		Query           string
+		Format          string
+		ApplicantID     string
+		RenderReturn    *dto.RenderedDocument
+		RenderError     error
+		JWTReturn       jwt.MapClaims
+		ExpectedStatus  int
+		ExpectedHeaders map[string]string
+		ExpectedError   error
 	}{
 		{
-			Name:          "Success to verify document",
-			ServiceError:  nil,
-			ServiceReturn: "1",
+			Name:         "Success to render document",
+			Query:        "?format=docx",
+			Format:       "docx",
+			ApplicantID:  "1",
+			RenderReturn: &dto.RenderedDocument{Content: []byte("docx"), ContentType: "application/docx"},
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(2),
-				"user_id": "1",
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusOK,
-			ExpectedBody: echo.Map{
-				"message": "success verifying document",
+			ExpectedHeaders: map[string]string{
+				echo.HeaderContentType:        "application/docx",
+				echo.HeaderContentDisposition: `inline; filename="1.docx"`,
 			},
-			ExpectedError: nil,
 		},
 		{
-			Name:          "Failed to verify document : document not found",
-			ServiceError:  utils.ErrDocumentNotFound,
-			ServiceReturn: "",
+			Name:         "Success to render document : default to pdf",
+			Format:       "pdf",
+			RenderReturn: &dto.RenderedDocument{Content: []byte("pdf"), ContentType: "application/pdf"},
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(2),
-				"user_id": "1",
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedHeaders: map[string]string{
+				echo.HeaderContentType:        "application/pdf",
+				echo.HeaderContentDisposition: `inline; filename="1.pdf"`,
+			},
+		},
+		{
+			Name:        "Failed to render document : role not sufficient to render other user document",
+			Query:       "?format=html",
+			Format:      "html",
+			ApplicantID: "2",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedError:  utils.ErrDidntHavePermission,
+		},
+		{
+			Name:        "Failed to render document : unsupported format",
+			Query:       "?format=xlsx",
+			Format:      "xlsx",
+			RenderError: utils.ErrUnsupportedFormat,
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrUnsupportedFormat,
+		},
+		{
+			Name:        "Failed to render document : document not found",
+			Query:       "?format=png",
+			Format:      "png",
+			RenderError: utils.ErrDocumentNotFound,
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusNotFound,
-			ExpectedBody:   nil,
 			ExpectedError:  utils.ErrDocumentNotFound,
 		},
 		{
-			Name:          "Failed to verify document : generic service error",
-			ServiceError:  errors.New("generic error"),
-			ServiceReturn: "",
+			Name:        "Failed to render document : signed document without snapshot",
+			Query:       "?format=docx",
+			Format:      "docx",
+			RenderError: utils.ErrSignedSnapshotNotFound,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(2),
-				"user_id": "1",
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
 			},
-			ExpectedStatus: http.StatusInternalServerError,
-			ExpectedBody:   nil,
-			ExpectedError:  errors.New("generic error"),
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedError:  utils.ErrSignedSnapshotNotFound,
 		},
 		{
-			Name:          "Failed to verify document : role not sufficient to verify document",
-			ServiceError:  nil,
-			ServiceReturn: "2",
+			Name:        "Failed to render document : generic service error",
+			Query:       "?format=png",
+			Format:      "png",
+			RenderError: errors.New("generic error"),
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(1),
-				"user_id": "1",
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
 			},
-			ExpectedStatus: http.StatusForbidden,
-			ExpectedBody:   nil,
-			ExpectedError:  utils.ErrDidntHavePermission,
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
 		},
 	} {
 		s.Run(tc.Name, func() {
 			s.SetupTest()
 
-			r := httptest.NewRequest("GET", "/documents", nil)
+			r := httptest.NewRequest("GET", "/documents"+tc.Query, nil)
 			w := httptest.NewRecorder()
 
 			c := s.echoApp.NewContext(r, w)
@@ -910,171 +1152,856 @@ func (s *TestSuiteDocumentController) TestVerifyDocument() {
 			c.SetParamValues("1")
 
 			s.mockJWTService.On("GetClaims", mock.Anything).Return(tc.JWTReturn)
-			s.mockDocumentService.On("VerifyDocument", mock.Anything, "1", mock.Anything, mock.Anything).Return(tc.ServiceError)
+			s.mockDocumentService.On("GetApplicantID", mock.Anything, "1").Return(&tc.ApplicantID, nil)
+			s.mockDocumentService.On("RenderDocument", mock.Anything, "1", tc.Format).Return(tc.RenderReturn, tc.RenderError)
 
-			err := s.documentController.VerifyDocument(c)
+			err := s.documentController.RenderDocument(c)
 
 			if tc.ExpectedError != nil {
 				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
 			} else {
 				s.NoError(err)
-
-				var response echo.Map
-				err := json.Unmarshal(w.Body.Bytes(), &response)
-				s.NoError(err)
-
-				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
-				s.Equal(tc.ExpectedBody, response)
+				s.Equal(tc.ExpectedStatus, w.Code)
+				s.Equal(tc.RenderReturn.Content, w.Body.Bytes())
+				for key, value := range tc.ExpectedHeaders {
+					s.Equal(value, w.Header().Get(key))
+				}
 			}
+
 			s.TearDownTest()
 		})
 	}
 }
 
-func (s *TestSuiteDocumentController) TestSignDocument() {
+func (s *TestSuiteDocumentController) TestExportDocuments() {
 	for _, tc := range []struct {
-		Name           string
-		ServiceError   error
-		ServiceReturn  string
-		JWTReturn      jwt.MapClaims
-		ExpectedStatus int
-		ExpectedBody   echo.Map
-		ExpectedError  error
+		Name            string
+		RequestBody     *dto.ExportDocumentsRequest
+		Format          string
+		ValidationErr   error
+		IDsReturn       []string
+		IDsError        error
+		WriteError      error
+		WrittenBefore   bool
+		JWTReturn       jwt.MapClaims
+		ExpectedStatus  int
+		ExpectedHeaders map[string]string
+		ExpectedError   error
 	}{
 		{
-			Name:          "Success to sign document",
-			ServiceError:  nil,
-			ServiceReturn: "1",
+			Name:        "Success to export merged PDF",
+			RequestBody: &dto.ExportDocumentsRequest{DocumentIDs: []string{"1", "2"}},
+			Format:      "pdf",
+			IDsReturn:   []string{"1", "2"},
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusOK,
-			ExpectedBody: echo.Map{
-				"message": "success signing document",
+			ExpectedHeaders: map[string]string{
+				echo.HeaderContentType:        "application/pdf",
+				echo.HeaderContentDisposition: `attachment; filename="documents.pdf"`,
 			},
-			ExpectedError: nil,
 		},
 		{
-			Name:          "Failed to sign document : document not found",
-			ServiceError:  utils.ErrDocumentNotFound,
-			ServiceReturn: "",
+			Name:        "Success to export zip",
+			RequestBody: &dto.ExportDocumentsRequest{StageID: 3, Format: "zip"},
+			Format:      "zip",
+			IDsReturn:   []string{"1", "2"},
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedHeaders: map[string]string{
+				echo.HeaderContentType:        "application/zip",
+				echo.HeaderContentDisposition: `attachment; filename="documents.zip"`,
 			},
-			ExpectedStatus: http.StatusNotFound,
-			ExpectedBody:   nil,
-			ExpectedError:  utils.ErrDocumentNotFound,
 		},
 		{
-			Name:          "Failed to sign document : generic service error",
-			ServiceError:  errors.New("generic error"),
-			ServiceReturn: "",
+			Name:          "Failed to export documents : validation error",
+			RequestBody:   &dto.ExportDocumentsRequest{},
+			ValidationErr: echo.NewHTTPError(http.StatusBadRequest, "validation error"),
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
 			},
-			ExpectedStatus: http.StatusInternalServerError,
-			ExpectedBody:   nil,
-			ExpectedError:  errors.New("generic error"),
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  errors.New("validation error"),
 		},
 		{
-			Name:          "Failed to sign document : role not sufficient to verify document",
-			ServiceError:  nil,
-			ServiceReturn: "2",
+			Name:        "Failed to export documents : too many documents",
+			RequestBody: &dto.ExportDocumentsRequest{StageID: 1},
+			IDsError:    utils.ErrTooManyDocuments,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(2),
-				"user_id": "1",
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
 			},
-			ExpectedStatus: http.StatusForbidden,
-			ExpectedBody:   nil,
-			ExpectedError:  utils.ErrDidntHavePermission,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrTooManyDocuments,
 		},
 		{
-			Name:          "Failed to sign document : document not verified yet",
-			ServiceError:  utils.ErrNotVerifiedYet,
-			ServiceReturn: "",
+			Name:        "Failed to export documents : document not found",
+			RequestBody: &dto.ExportDocumentsRequest{DocumentIDs: []string{"1"}},
+			IDsError:    utils.ErrDocumentNotFound,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
 			},
-			ExpectedStatus: http.StatusConflict,
-			ExpectedBody:   nil,
-			ExpectedError:  utils.ErrNotVerifiedYet,
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedError:  utils.ErrDocumentNotFound,
+		},
+		{
+			Name:        "Failed to export documents : render failed before the first byte",
+			RequestBody: &dto.ExportDocumentsRequest{DocumentIDs: []string{"1"}},
+			Format:      "pdf",
+			IDsReturn:   []string{"1"},
+			WriteError:  utils.ErrRenderQueueFull,
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusServiceUnavailable,
+			ExpectedError:  utils.ErrRenderQueueFull,
+		},
+		{
+			Name:        "Failed to export documents : generic service error",
+			RequestBody: &dto.ExportDocumentsRequest{DocumentIDs: []string{"1"}},
+			IDsError:    errors.New("generic error"),
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
 		},
 	} {
 		s.Run(tc.Name, func() {
 			s.SetupTest()
 
-			r := httptest.NewRequest("GET", "/documents", nil)
+			jsonBody, err := json.Marshal(tc.RequestBody)
+			s.NoError(err)
+
+			r := httptest.NewRequest("POST", "/documents/export", bytes.NewBuffer(jsonBody))
+			r.Header.Set("Content-Type", "application/json")
 			w := httptest.NewRecorder()
 
 			c := s.echoApp.NewContext(r, w)
-			c.SetParamNames("document_id")
-			c.SetParamValues("1")
 
 			s.mockJWTService.On("GetClaims", mock.Anything).Return(tc.JWTReturn)
-			s.mockDocumentService.On("SignDocument", mock.Anything, "1", mock.Anything).Return(tc.ServiceError)
+			s.mockValidator.On("Validate", mock.Anything).Return(tc.ValidationErr)
+			s.mockDocumentService.On("GetExportDocumentIDs", mock.Anything, tc.RequestBody).Return(tc.IDsReturn, tc.IDsError)
+			s.mockDocumentService.On("WriteDocumentExport", mock.Anything, tc.IDsReturn, tc.Format, mock.Anything).Run(func(args mock.Arguments) {
+				if tc.WriteError == nil {
+					_, _ = args.Get(3).(io.Writer).Write([]byte("export"))
+				}
+			}).Return(tc.WriteError)
 
-			err := s.documentController.SignDocument(c)
+			err = s.documentController.ExportDocuments(c)
 
 			if tc.ExpectedError != nil {
 				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
 			} else {
 				s.NoError(err)
-
-				var response echo.Map
-				err := json.Unmarshal(w.Body.Bytes(), &response)
-				s.NoError(err)
-
-				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
-				s.Equal(tc.ExpectedBody, response)
+				s.Equal(tc.ExpectedStatus, w.Code)
+				s.Equal("export", w.Body.String())
+				for key, value := range tc.ExpectedHeaders {
+					s.Equal(value, w.Header().Get(key))
+				}
 			}
+
 			s.TearDownTest()
 		})
 	}
 }
 
-func (s *TestSuiteDocumentController) TestDeleteDocument() {
+func (s *TestSuiteDocumentController) TestExportDocuments_ErrorAfterFirstByte() {
+	r := httptest.NewRequest("POST", "/documents/export", bytes.NewBufferString(`{"document_ids":["1","2"]}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c := s.echoApp.NewContext(r, w)
+
+	s.mockJWTService.On("GetClaims", mock.Anything).Return(jwt.MapClaims{
+		rbac.ClaimPermissions: verifierPermissions,
+		"user_id":             "1",
+	})
+	s.mockValidator.On("Validate", mock.Anything).Return(nil)
+	s.mockDocumentService.On("GetExportDocumentIDs", mock.Anything, mock.Anything).Return([]string{"1", "2"}, nil)
+	s.mockDocumentService.On("WriteDocumentExport", mock.Anything, []string{"1", "2"}, "pdf", mock.Anything).Run(func(args mock.Arguments) {
+		_, _ = args.Get(3).(io.Writer).Write([]byte("%PDF-"))
+	}).Return(errors.New("generic error"))
+
+	s.PanicsWithValue(http.ErrAbortHandler, func() {
+		_ = s.documentController.ExportDocuments(c)
+	})
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal("%PDF-", w.Body.String())
+}
+
+func (s *TestSuiteDocumentController) TestGetRenderJob() {
 	for _, tc := range []struct {
 		Name           string
-		ServiceError   error
+		JobError       error
+		ApplicantID    string
 		JWTReturn      jwt.MapClaims
 		ExpectedStatus int
-		ExpectedBody   echo.Map
 		ExpectedError  error
 	}{
 		{
-			Name:         "Success to delete document",
-			ServiceError: nil,
+			Name:        "Success to get render job",
+			ApplicantID: "1",
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusOK,
-			ExpectedBody: echo.Map{
-				"message": "success deleting document",
+		},
+		{
+			Name:     "Failed to get render job : job not found",
+			JobError: utils.ErrRenderJobNotFound,
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
 			},
-			ExpectedError: nil,
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedError:  utils.ErrRenderJobNotFound,
 		},
 		{
-			Name:         "Failed to delete document : document already signed",
-			ServiceError: utils.ErrAlreadySigned,
+			Name:        "Failed to get render job : role not sufficient to get other user render job",
+			ApplicantID: "2",
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusForbidden,
-			ExpectedBody:   nil,
-			ExpectedError:  utils.ErrAlreadySigned,
+			ExpectedError:  utils.ErrDidntHavePermission,
 		},
 		{
-			Name:         "Failed to delete document : document not found",
-			ServiceError: utils.ErrDocumentNotFound,
+			Name:     "Failed to get render job : generic service error",
+			JobError: errors.New("generic error"),
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			r := httptest.NewRequest("GET", "/documents/jobs", nil)
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+			c.SetParamNames("job_id")
+			c.SetParamValues("job1")
+
+			s.mockJWTService.On("GetClaims", mock.Anything).Return(tc.JWTReturn)
+			s.mockDocumentService.On("GetRenderJob", mock.Anything, "job1").Return(&dto.RenderJobResponse{ID: "job1", DocumentID: "1"}, tc.JobError)
+			s.mockDocumentService.On("GetApplicantID", mock.Anything, "1").Return(&tc.ApplicantID, nil)
+
+			err := s.documentController.GetRenderJob(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+				s.Equal(tc.ExpectedStatus, w.Code)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteDocumentController) TestGetRenderJobPDF() {
+	for _, tc := range []struct {
+		Name           string
+		JobError       error
+		PDFError       error
+		ApplicantID    string
+		JWTReturn      jwt.MapClaims
+		ExpectedStatus int
+		ExpectedError  error
+	}{
+		{
+			Name:        "Success to get render job pdf",
+			ApplicantID: "1",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:     "Failed to get render job pdf : job not found",
+			JobError: utils.ErrRenderJobNotFound,
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedError:  utils.ErrRenderJobNotFound,
+		},
+		{
+			Name:        "Failed to get render job pdf : role not sufficient to get other user render job",
+			ApplicantID: "2",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedError:  utils.ErrDidntHavePermission,
+		},
+		{
+			Name:     "Failed to get render job pdf : job not done",
+			PDFError: utils.ErrRenderJobNotDone,
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusConflict,
+			ExpectedError:  utils.ErrRenderJobNotDone,
+		},
+		{
+			Name:     "Failed to get render job pdf : job failed",
+			PDFError: utils.ErrRenderJobFailed,
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusConflict,
+			ExpectedError:  utils.ErrRenderJobFailed,
+		},
+		{
+			Name:     "Failed to get render job pdf : generic service error",
+			PDFError: errors.New("generic error"),
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			r := httptest.NewRequest("GET", "/documents/jobs", nil)
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+			c.SetParamNames("job_id")
+			c.SetParamValues("job1")
+
+			s.mockJWTService.On("GetClaims", mock.Anything).Return(tc.JWTReturn)
+			s.mockDocumentService.On("GetRenderJob", mock.Anything, "job1").Return(&dto.RenderJobResponse{ID: "job1", DocumentID: "1"}, tc.JobError)
+			s.mockDocumentService.On("GetApplicantID", mock.Anything, "1").Return(&tc.ApplicantID, nil)
+			s.mockDocumentService.On("GetRenderJobPDF", mock.Anything, "job1").Return([]byte("pdf"), tc.PDFError)
+
+			err := s.documentController.GetRenderJobPDF(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+				s.Equal(tc.ExpectedStatus, w.Code)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteDocumentController) TestVerifyDocument() {
+	for _, tc := range []struct {
+		Name           string
+		ServiceError   error
+		ServiceReturn  string
+		JWTReturn      jwt.MapClaims
+		ExpectedStatus int
+		ExpectedBody   echo.Map
+		ExpectedError  error
+	}{
+		{
+			Name:          "Success to verify document",
+			ServiceError:  nil,
+			ServiceReturn: "1",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success verifying document",
+			},
+			ExpectedError: nil,
+		},
+		{
+			Name:          "Failed to verify document : document not found",
+			ServiceError:  utils.ErrDocumentNotFound,
+			ServiceReturn: "",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   nil,
+			ExpectedError:  utils.ErrDocumentNotFound,
+		},
+		{
+			Name:          "Failed to verify document : generic service error",
+			ServiceError:  errors.New("generic error"),
+			ServiceReturn: "",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: verifierPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedBody:   nil,
+			ExpectedError:  errors.New("generic error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			r := httptest.NewRequest("GET", "/documents", nil)
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+			c.SetParamNames("document_id")
+			c.SetParamValues("1")
+
+			s.mockJWTService.On("GetClaims", mock.Anything).Return(tc.JWTReturn)
+			s.mockDocumentService.On("VerifyDocument", mock.Anything, "1", mock.Anything, mock.Anything).Return(tc.ServiceError)
+
+			err := s.documentController.VerifyDocument(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+			}
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteDocumentController) TestSignDocument() {
+	for _, tc := range []struct {
+		Name           string
+		ServiceError   error
+		ServiceReturn  string
+		JWTReturn      jwt.MapClaims
+		ExpectedStatus int
+		ExpectedBody   echo.Map
+		ExpectedError  error
+	}{
+		{
+			Name:          "Success to sign document",
+			ServiceError:  nil,
+			ServiceReturn: "1",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success signing document",
+			},
+			ExpectedError: nil,
+		},
+		{
+			Name:          "Failed to sign document : document not found",
+			ServiceError:  utils.ErrDocumentNotFound,
+			ServiceReturn: "",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedBody:   nil,
+			ExpectedError:  utils.ErrDocumentNotFound,
+		},
+		{
+			Name:          "Failed to sign document : generic service error",
+			ServiceError:  errors.New("generic error"),
+			ServiceReturn: "",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedBody:   nil,
+			ExpectedError:  errors.New("generic error"),
+		},
+		{
+			Name:          "Failed to sign document : document not verified yet",
+			ServiceError:  utils.ErrNotVerifiedYet,
+			ServiceReturn: "",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusConflict,
+			ExpectedBody:   nil,
+			ExpectedError:  utils.ErrNotVerifiedYet,
+		},
+		{
+			Name:          "Failed to sign document : document already signed",
+			ServiceError:  utils.ErrAlreadySigned,
+			ServiceReturn: "",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusConflict,
+			ExpectedBody:   nil,
+			ExpectedError:  utils.ErrAlreadySigned,
+		},
+		{
+			Name:          "Failed to sign document : signer didn't have signing key",
+			ServiceError:  utils.ErrSigningKeyNotFound,
+			ServiceReturn: "",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody:   nil,
+			ExpectedError:  utils.ErrSigningKeyNotFound,
+		},
+		{
+			Name:          "Failed to sign document : signing certificate expired",
+			ServiceError:  utils.ErrSigningCertificateExpired,
+			ServiceReturn: "",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody:   nil,
+			ExpectedError:  utils.ErrSigningCertificateExpired,
+		},
+		{
+			Name:          "Failed to sign document : signature field outside the page",
+			ServiceError:  utils.ErrInvalidSignaturePlacement,
+			ServiceReturn: "",
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectedBody:   nil,
+			ExpectedError:  utils.ErrInvalidSignaturePlacement,
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			r := httptest.NewRequest("GET", "/documents", nil)
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+			c.SetParamNames("document_id")
+			c.SetParamValues("1")
+
+			s.mockJWTService.On("GetClaims", mock.Anything).Return(tc.JWTReturn)
+			s.mockDocumentService.On("SignDocument", mock.Anything, "1", mock.Anything).Return(tc.ServiceError)
+
+			err := s.documentController.SignDocument(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+			}
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteDocumentController) TestRevokeDocument() {
+	for _, tc := range []struct {
+		Name           string
+		RequestBody    interface{}
+		ServiceError   error
+		JWTReturn      jwt.MapClaims
+		ValidationErr  error
+		ExpectedStatus int
+		ExpectedBody   echo.Map
+		ExpectedError  error
+	}{
+		{
+			Name:           "Success to revoke document",
+			RequestBody:    dto.RevokeDocumentRequest{Reason: "salah penerima"},
+			JWTReturn:      jwt.MapClaims{rbac.ClaimPermissions: adminPermissions, "user_id": "1"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success revoking document",
+			},
+		},
+		{
+			Name:           "Failed to revoke document : invalid request body",
+			RequestBody:    "invalid",
+			JWTReturn:      jwt.MapClaims{rbac.ClaimPermissions: adminPermissions, "user_id": "1"},
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrBadRequestBody,
+		},
+		{
+			Name:           "Failed to revoke document : validation error",
+			RequestBody:    dto.RevokeDocumentRequest{},
+			JWTReturn:      jwt.MapClaims{rbac.ClaimPermissions: adminPermissions, "user_id": "1"},
+			ValidationErr:  utils.ErrBadRequestBody,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrBadRequestBody,
+		},
+		{
+			Name:           "Failed to revoke document : document not found",
+			RequestBody:    dto.RevokeDocumentRequest{Reason: "salah penerima"},
+			ServiceError:   utils.ErrDocumentNotFound,
+			JWTReturn:      jwt.MapClaims{rbac.ClaimPermissions: adminPermissions, "user_id": "1"},
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedError:  utils.ErrDocumentNotFound,
+		},
+		{
+			Name:           "Failed to revoke document : document not signed yet",
+			RequestBody:    dto.RevokeDocumentRequest{Reason: "salah penerima"},
+			ServiceError:   utils.ErrNotSignedYet,
+			JWTReturn:      jwt.MapClaims{rbac.ClaimPermissions: adminPermissions, "user_id": "1"},
+			ExpectedStatus: http.StatusConflict,
+			ExpectedError:  utils.ErrNotSignedYet,
+		},
+		{
+			Name:           "Failed to revoke document : document already revoked",
+			RequestBody:    dto.RevokeDocumentRequest{Reason: "salah penerima"},
+			ServiceError:   utils.ErrAlreadyRevoked,
+			JWTReturn:      jwt.MapClaims{rbac.ClaimPermissions: adminPermissions, "user_id": "1"},
+			ExpectedStatus: http.StatusConflict,
+			ExpectedError:  utils.ErrAlreadyRevoked,
+		},
+		{
+			Name:           "Failed to revoke document : generic error from service",
+			RequestBody:    dto.RevokeDocumentRequest{Reason: "salah penerima"},
+			ServiceError:   errors.New("generic error"),
+			JWTReturn:      jwt.MapClaims{rbac.ClaimPermissions: adminPermissions, "user_id": "1"},
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			jsonBody, err := json.Marshal(tc.RequestBody)
+			s.NoError(err)
+
+			r := httptest.NewRequest("PATCH", "/documents", bytes.NewReader(jsonBody))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+			c.SetParamNames("document_id")
+			c.SetParamValues("1")
+
+			s.mockJWTService.On("GetClaims", mock.Anything).Return(tc.JWTReturn)
+			if tc.ValidationErr != nil {
+				s.mockValidator.On("Validate", mock.Anything).Return(echo.NewHTTPError(http.StatusBadRequest, tc.ValidationErr.Error()))
+			} else {
+				s.mockValidator.On("Validate", mock.Anything).Return(nil)
+			}
+			s.mockDocumentService.On("RevokeDocument", mock.Anything, "1", &dto.RevokeDocumentRequest{Reason: "salah penerima"}).Return(tc.ServiceError)
+
+			err = s.documentController.RevokeDocument(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+			}
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteDocumentController) TestVerifyDocumentFile() {
+	for _, tc := range []struct {
+		Name           string
+		WithFile       bool
+		ServiceError   error
+		ServiceReturn  *dto.DocumentVerificationResponse
+		ExpectedStatus int
+		ExpectedBody   echo.Map
+		ExpectedError  error
+	}{
+		{
+			Name:     "Success to verify document file",
+			WithFile: true,
+			ServiceReturn: &dto.DocumentVerificationResponse{
+				Hash:       "hash",
+				Authentic:  true,
+				Document:   &dto.VerifiedDocumentResponse{ID: "1", RegisterID: 123, Template: "Surat Keterangan"},
+				Signatures: []dto.EmbeddedSignatureResponse{{Certificate: "Budi", Intact: true, Trusted: true, CoversDocument: true}},
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success verifying document file",
+				"data": map[string]interface{}{
+					"hash":      "hash",
+					"authentic": true,
+					"modified":  false,
+					"document": map[string]interface{}{
+						"id":         "1",
+						"register":   float64(123),
+						"template":   "Surat Keterangan",
+						"signer":     map[string]interface{}{},
+						"signed_at":  "0001-01-01T00:00:00Z",
+						"revoked":    false,
+						"revoked_at": "0001-01-01T00:00:00Z",
+					},
+					"signatures": []interface{}{
+						map[string]interface{}{
+							"certificate":     "Budi",
+							"name":            "",
+							"reason":          "",
+							"signing_time":    "0001-01-01T00:00:00Z",
+							"intact":          true,
+							"trusted":         true,
+							"expired":         false,
+							"covers_document": true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:           "Failed to verify document file : no file uploaded",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrBadRequestBody,
+		},
+		{
+			Name:           "Failed to verify document file : file too large",
+			WithFile:       true,
+			ServiceError:   utils.ErrPDFTooLarge,
+			ExpectedStatus: http.StatusRequestEntityTooLarge,
+			ExpectedError:  utils.ErrPDFTooLarge,
+		},
+		{
+			Name:           "Failed to verify document file : not a pdf",
+			WithFile:       true,
+			ServiceError:   utils.ErrUnsupportedPDF,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrUnsupportedPDF,
+		},
+		{
+			Name:           "Failed to verify document file : generic error from service",
+			WithFile:       true,
+			ServiceError:   errors.New("generic error"),
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			if tc.WithFile {
+				part, err := writer.CreateFormFile("document", "surat.pdf")
+				s.Require().NoError(err)
+				_, err = part.Write([]byte("%PDF-1.4"))
+				s.Require().NoError(err)
+			}
+			s.Require().NoError(writer.Close())
+
+			r := httptest.NewRequest("POST", "/documents/verify", body)
+			r.Header.Set("Content-Type", writer.FormDataContentType())
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+
+			s.mockDocumentService.On("VerifyDocumentFile", mock.Anything, mock.Anything).Return(tc.ServiceReturn, tc.ServiceError)
+
+			err := s.documentController.VerifyDocumentFile(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+			}
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteDocumentController) TestDeleteDocument() {
+	for _, tc := range []struct {
+		Name           string
+		ServiceError   error
+		JWTReturn      jwt.MapClaims
+		ExpectedStatus int
+		ExpectedBody   echo.Map
+		ExpectedError  error
+	}{
+		{
+			Name:         "Success to delete document",
+			ServiceError: nil,
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success deleting document",
+			},
+			ExpectedError: nil,
+		},
+		{
+			Name:         "Failed to delete document : document already signed",
+			ServiceError: utils.ErrAlreadySigned,
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
+			},
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedBody:   nil,
+			ExpectedError:  utils.ErrAlreadySigned,
+		},
+		{
+			Name:         "Failed to delete document : document not found",
+			ServiceError: utils.ErrDocumentNotFound,
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusNotFound,
 			ExpectedBody:   nil,
@@ -1084,8 +2011,8 @@ func (s *TestSuiteDocumentController) TestDeleteDocument() {
 			Name:         "Failed to delete document : generic service error",
 			ServiceError: errors.New("generic error"),
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusInternalServerError,
 			ExpectedBody:   nil,
@@ -1095,8 +2022,8 @@ func (s *TestSuiteDocumentController) TestDeleteDocument() {
 			Name:         "Failed to delete document : role not sufficient to delete other user document",
 			ServiceError: utils.ErrDidntHavePermission,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(1),
-				"user_id": "1",
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
 			},
 			ExpectedStatus: http.StatusForbidden,
 			ExpectedBody:   nil,
@@ -1155,7 +2082,7 @@ func (s *TestSuiteDocumentController) TestUpdateDocument() {
 			RequestContentTypes: "application/json",
 			ServiceError:        nil,
 			JWTReturn: jwt.MapClaims{
-				"role": float64(3),
+				rbac.ClaimPermissions: adminPermissions,
 			},
 			ExpectedStatus: http.StatusOK,
 			ExpectedBody: echo.Map{
@@ -1163,28 +2090,13 @@ func (s *TestSuiteDocumentController) TestUpdateDocument() {
 			},
 			ExpectedError: nil,
 		},
-		{
-			Name: "Failed to update document : role not sufficient to update document",
-			RequestBody: &dto.DocumentUpdateRequest{
-				RegisterID:  123,
-				Description: "description",
-			},
-			RequestContentTypes: "application/json",
-			ServiceError:        utils.ErrDidntHavePermission,
-			JWTReturn: jwt.MapClaims{
-				"role": float64(1),
-			},
-			ExpectedStatus: http.StatusForbidden,
-			ExpectedBody:   nil,
-			ExpectedError:  utils.ErrDidntHavePermission,
-		},
 		{
 			Name:                "Failed to update document : invalid request body",
 			RequestBody:         nil,
 			RequestContentTypes: "",
 			ServiceError:        utils.ErrBadRequestBody,
 			JWTReturn: jwt.MapClaims{
-				"role": float64(3),
+				rbac.ClaimPermissions: adminPermissions,
 			},
 			ExpectedStatus: http.StatusBadRequest,
 			ExpectedBody:   nil,
@@ -1199,7 +2111,7 @@ func (s *TestSuiteDocumentController) TestUpdateDocument() {
 			RequestContentTypes: "application/json",
 			ServiceError:        errors.New("generic error"),
 			JWTReturn: jwt.MapClaims{
-				"role": float64(3),
+				rbac.ClaimPermissions: adminPermissions,
 			},
 			ExpectedStatus: http.StatusInternalServerError,
 			ExpectedBody:   nil,
@@ -1214,7 +2126,7 @@ func (s *TestSuiteDocumentController) TestUpdateDocument() {
 			RequestContentTypes: "application/json",
 			ServiceError:        utils.ErrDocumentNotFound,
 			JWTReturn: jwt.MapClaims{
-				"role": float64(3),
+				rbac.ClaimPermissions: adminPermissions,
 			},
 			ExpectedStatus: http.StatusNotFound,
 			ExpectedBody:   nil,
@@ -1229,7 +2141,7 @@ func (s *TestSuiteDocumentController) TestUpdateDocument() {
 			RequestContentTypes: "application/json",
 			ServiceError:        utils.ErrAlreadySigned,
 			JWTReturn: jwt.MapClaims{
-				"role": float64(3),
+				rbac.ClaimPermissions: adminPermissions,
 			},
 			ExpectedStatus: http.StatusForbidden,
 			ExpectedBody:   nil,
@@ -1244,7 +2156,7 @@ func (s *TestSuiteDocumentController) TestUpdateDocument() {
 			RequestContentTypes: "application/json",
 			ServiceError:        utils.ErrAlreadyVerified,
 			JWTReturn: jwt.MapClaims{
-				"role": float64(3),
+				rbac.ClaimPermissions: adminPermissions,
 			},
 			ExpectedStatus: http.StatusForbidden,
 			ExpectedBody:   nil,
@@ -1312,8 +2224,8 @@ func (s *TestSuiteDocumentController) TestUpdateDocumentFields() {
 			RequestContentTypes: "application/json",
 			ServiceError:        nil,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
 			},
 			ValidationErr:  nil,
 			ExpectedStatus: http.StatusOK,
@@ -1328,8 +2240,8 @@ func (s *TestSuiteDocumentController) TestUpdateDocumentFields() {
 			RequestContentTypes: "",
 			ServiceError:        nil,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
 			},
 			ValidationErr:  nil,
 			ExpectedStatus: http.StatusBadRequest,
@@ -1348,8 +2260,8 @@ func (s *TestSuiteDocumentController) TestUpdateDocumentFields() {
 			RequestContentTypes: "application/json",
 			ServiceError:        nil,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
 			},
 			ValidationErr:  echo.NewHTTPError(http.StatusBadRequest, "Value is required"),
 			ExpectedStatus: http.StatusBadRequest,
@@ -1369,8 +2281,8 @@ func (s *TestSuiteDocumentController) TestUpdateDocumentFields() {
 			RequestContentTypes: "application/json",
 			ServiceError:        utils.ErrDocumentNotFound,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
 			},
 			ValidationErr:  nil,
 			ExpectedStatus: http.StatusNotFound,
@@ -1390,8 +2302,8 @@ func (s *TestSuiteDocumentController) TestUpdateDocumentFields() {
 			RequestContentTypes: "application/json",
 			ServiceError:        utils.ErrAlreadyVerified,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
 			},
 			ValidationErr:  nil,
 			ExpectedStatus: http.StatusForbidden,
@@ -1411,14 +2323,35 @@ func (s *TestSuiteDocumentController) TestUpdateDocumentFields() {
 			RequestContentTypes: "application/json",
 			ServiceError:        utils.ErrAlreadySigned,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(3),
-				"user_id": "1",
+				rbac.ClaimPermissions: adminPermissions,
+				"user_id":             "1",
 			},
 			ValidationErr:  nil,
 			ExpectedStatus: http.StatusForbidden,
 			ExpectedBody:   nil,
 			ExpectedError:  utils.ErrAlreadySigned,
 		},
+		{
+			Name: "Failed to update document fields : err applicant field read only",
+			RequestBody: &dto.FieldsUpdateRequest{
+				Fields: []dto.FieldUpdateRequest{
+					{
+						ID:    1,
+						Value: "value1",
+					},
+				},
+			},
+			RequestContentTypes: "application/json",
+			ServiceError:        utils.ErrApplicantFieldReadOnly,
+			JWTReturn: jwt.MapClaims{
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
+			},
+			ValidationErr:  nil,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   nil,
+			ExpectedError:  utils.ErrApplicantFieldReadOnly,
+		},
 		{
 			Name: "Failed to update document fields : err user role not sufficient to update document fields of other user",
 			RequestBody: &dto.FieldsUpdateRequest{
@@ -1432,8 +2365,8 @@ func (s *TestSuiteDocumentController) TestUpdateDocumentFields() {
 			RequestContentTypes: "application/json",
 			ServiceError:        utils.ErrDidntHavePermission,
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(1),
-				"user_id": "1",
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
 			},
 			ValidationErr:  nil,
 			ExpectedStatus: http.StatusForbidden,
@@ -1453,8 +2386,8 @@ func (s *TestSuiteDocumentController) TestUpdateDocumentFields() {
 			RequestContentTypes: "application/json",
 			ServiceError:        errors.New("generic error"),
 			JWTReturn: jwt.MapClaims{
-				"role":    float64(1),
-				"user_id": "1",
+				rbac.ClaimPermissions: applicantPermissions,
+				"user_id":             "1",
 			},
 			ValidationErr:  nil,
 			ExpectedStatus: http.StatusInternalServerError,
@@ -1,12 +1,18 @@
 package dto
 
 import (
+	"fmt"
+
 	dto2 "github.com/suryaadi44/eAD-System/internal/template/dto"
 	"gorm.io/gorm"
+	"strconv"
 	"time"
 
 	"github.com/suryaadi44/eAD-System/internal/user/dto"
 	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils/binding"
+	"github.com/suryaadi44/eAD-System/pkg/utils/indonesian"
+	"github.com/suryaadi44/eAD-System/pkg/utils/signature"
 )
 
 type DocumentRequest struct {
@@ -15,8 +21,9 @@ type DocumentRequest struct {
 }
 
 type FieldRequest struct {
-	FieldID uint   `json:"field_id" validate:"required"`
-	Value   string `json:"value" validate:"required"`
+	FieldID uint             `json:"field_id" validate:"required"`
+	Value   string           `json:"value" validate:"required_without=Rows"`
+	Rows    entity.TableRows `json:"rows"`
 }
 
 type FieldsRequest []FieldRequest
@@ -27,6 +34,7 @@ func (d *DocumentRequest) ToEntity() *entity.Document {
 		fields = append(fields, entity.DocumentField{
 			TemplateFieldID: field.FieldID,
 			Value:           field.Value,
+			Rows:            field.Rows,
 		})
 	}
 
@@ -48,6 +56,7 @@ type DocumentResponse struct {
 	VerifiedAt  time.Time             `json:"verified_at"`
 	Signer      dto.EmployeeResponse  `json:"signer"`
 	SignedAt    time.Time             `json:"signed_at"`
+	SignedHash  string                `json:"signed_pdf_hash,omitempty"`
 	CreatedAt   time.Time             `json:"created_at"`
 	UpdatedAt   time.Time             `json:"updated_at"`
 }
@@ -65,15 +74,17 @@ func NewDocumentResponse(document *entity.Document) *DocumentResponse {
 		VerifiedAt:  document.VerifiedAt,
 		Signer:      *dto.NewEmployeeResponse(&document.Signer),
 		SignedAt:    document.SignedAt,
+		SignedHash:  document.SignedPDFHash,
 		CreatedAt:   document.CreatedAt,
 		UpdatedAt:   document.UpdatedAt,
 	}
 }
 
 type FieldResponse struct {
-	ID    uint   `json:"id"`
-	Key   string `json:"key"`
-	Value string `json:"value"`
+	ID    uint             `json:"id"`
+	Key   string           `json:"key"`
+	Value string           `json:"value"`
+	Rows  entity.TableRows `json:"rows,omitempty"`
 }
 
 func NewFieldResponse(fields *entity.DocumentField) *FieldResponse {
@@ -81,6 +92,7 @@ func NewFieldResponse(fields *entity.DocumentField) *FieldResponse {
 		ID:    fields.ID,
 		Key:   fields.TemplateField.Key,
 		Value: fields.Value,
+		Rows:  fields.Rows,
 	}
 }
 
@@ -98,37 +110,83 @@ func NewFieldsResponse(fields *entity.DocumentFields) *FieldsResponse {
 func NewFieldsMapResponse(fields *entity.DocumentFields) map[string]interface{} {
 	var fieldsResponse = make(map[string]interface{})
 	for _, field := range *fields {
-		fieldsResponse[field.TemplateField.Key] = field.Value
+		if field.TemplateField.Type == entity.FieldTypeTable {
+			fieldsResponse[field.TemplateField.Key] = NewTableValue(field.TemplateField.Columns, field.Rows)
+			continue
+		}
+
+		binding.SetNested(fieldsResponse, field.TemplateField.Key, field.Value)
 	}
 
 	return fieldsResponse
 }
 
+// NewTableValue convert the table rows into slice that can be ranged in template, number column is converted
+// into int64 or float64 so it can be used with the number functions
+func NewTableValue(columns entity.TableColumns, rows entity.TableRows) []map[string]interface{} {
+	table := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		value := make(map[string]interface{}, len(columns))
+		for _, column := range columns {
+			value[column.Key] = row[column.Key]
+			if column.Type != entity.ColumnTypeNumber {
+				continue
+			}
+
+			if number, err := strconv.ParseInt(row[column.Key], 10, 64); err == nil {
+				value[column.Key] = number
+			} else if number, err := strconv.ParseFloat(row[column.Key], 64); err == nil {
+				value[column.Key] = number
+			}
+		}
+		table = append(table, value)
+	}
+
+	return table
+}
+
 type DocumentStatusResponse struct {
-	ID          string               `json:"id"`
-	Description string               `json:"description"`
-	RegisterID  uint                 `json:"register"`
-	Stage       string               `json:"stage"`
-	Verifier    dto.EmployeeResponse `json:"verifier"`
-	VerifiedAt  time.Time            `json:"verified_at"`
-	Signer      dto.EmployeeResponse `json:"signer"`
-	SignedAt    time.Time            `json:"signed_at"`
-	CreatedAt   time.Time            `json:"created_at"`
-	UpdatedAt   time.Time            `json:"updated_at"`
+	ID            string               `json:"id"`
+	Description   string               `json:"description"`
+	RegisterID    uint                 `json:"register"`
+	Applicant     string               `json:"applicant"`
+	Stage         string               `json:"stage"`
+	Verifier      dto.EmployeeResponse `json:"verifier"`
+	VerifiedAt    time.Time            `json:"verified_at"`
+	Signer        dto.EmployeeResponse `json:"signer"`
+	SignedAt      time.Time            `json:"signed_at"`
+	SignedHash    string               `json:"signed_pdf_hash,omitempty"`
+	Revoked       bool                 `json:"revoked"`
+	RevokeReason  string               `json:"revoke_reason,omitempty"`
+	TokenVerified bool                 `json:"token_verified,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
 }
 
+// NewDocumentStatusResponse build the public status of the document, the stored description is not used since it is
+// written with the full name of the applicant, the description is made from the template and the masked name instead
 func NewDocumentStatusResponse(document *entity.Document) *DocumentStatusResponse {
+	applicant := indonesian.MaskName(document.Applicant.Name)
+	description := document.Template.Name
+	if applicant != "" {
+		description = fmt.Sprintf("%s a.n %s", document.Template.Name, applicant)
+	}
+
 	return &DocumentStatusResponse{
-		ID:          document.ID,
-		Description: document.Description,
-		RegisterID:  document.RegisterID,
-		Stage:       document.Stage.Status,
-		Verifier:    *dto.NewEmployeeResponse(&document.Verifier),
-		VerifiedAt:  document.VerifiedAt,
-		Signer:      *dto.NewEmployeeResponse(&document.Signer),
-		SignedAt:    document.SignedAt,
-		CreatedAt:   document.CreatedAt,
-		UpdatedAt:   document.UpdatedAt,
+		ID:           document.ID,
+		Description:  description,
+		RegisterID:   document.RegisterID,
+		Applicant:    applicant,
+		Stage:        document.Stage.Status,
+		Verifier:     *dto.NewEmployeeResponse(&document.Verifier),
+		VerifiedAt:   document.VerifiedAt,
+		Signer:       *dto.NewEmployeeResponse(&document.Signer),
+		SignedAt:     document.SignedAt,
+		SignedHash:   document.SignedPDFHash,
+		Revoked:      !document.RevokedAt.IsZero(),
+		RevokeReason: document.RevokeReason,
+		CreatedAt:    document.CreatedAt,
+		UpdatedAt:    document.UpdatedAt,
 	}
 }
 
@@ -187,9 +245,20 @@ func (v *VerifyDocumentRequest) ToEntity() *entity.Document {
 	}
 }
 
+type RevokeDocumentRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+func (r *RevokeDocumentRequest) ToEntity() *entity.Document {
+	return &entity.Document{
+		RevokeReason: r.Reason,
+	}
+}
+
 type FieldUpdateRequest struct {
-	ID    uint   `json:"id" validate:"required"`
-	Value string `json:"value" validate:"required"`
+	ID    uint             `json:"id" validate:"required"`
+	Value string           `json:"value" validate:"required_without=Rows"`
+	Rows  entity.TableRows `json:"rows"`
 }
 
 func (f *FieldUpdateRequest) ToEntity(docID string) *entity.DocumentField {
@@ -199,6 +268,7 @@ func (f *FieldUpdateRequest) ToEntity(docID string) *entity.DocumentField {
 		},
 		DocumentID: docID,
 		Value:      f.Value,
+		Rows:       f.Rows,
 	}
 }
 
@@ -214,3 +284,113 @@ func (f *FieldsUpdateRequest) ToEntity(docID string) *entity.DocumentFields {
 
 	return &fields
 }
+
+type RenderJobResponse struct {
+	ID         string    `json:"id"`
+	DocumentID string    `json:"document_id"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	FinishedAt time.Time `json:"finished_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func NewRenderJobResponse(job *entity.RenderJob) *RenderJobResponse {
+	return &RenderJobResponse{
+		ID:         job.ID,
+		DocumentID: job.DocumentID,
+		Status:     job.Status,
+		Error:      job.Error,
+		FinishedAt: job.FinishedAt,
+		CreatedAt:  job.CreatedAt,
+		UpdatedAt:  job.UpdatedAt,
+	}
+}
+
+// RenderedDocument is the document converted into the requested output format
+type RenderedDocument struct {
+	Content     []byte
+	ContentType string
+}
+
+// ExportDocumentsRequest select the document to export, either by id or by filter. The date is matched against the time
+// the document entered the filtered stage, or its creation time when the stage is not filtered
+type ExportDocumentsRequest struct {
+	DocumentIDs []string `json:"document_ids" validate:"required_without_all=StageID TemplateID DateFrom DateTo,excluded_with=StageID TemplateID DateFrom DateTo,omitempty,min=1,max=500,unique,dive,required"`
+	StageID     int      `json:"stage" validate:"omitempty,min=1,max=3"`
+	TemplateID  uint     `json:"template_id"`
+	DateFrom    string   `json:"date_from" validate:"omitempty,datetime=2006-01-02"`
+	DateTo      string   `json:"date_to" validate:"omitempty,datetime=2006-01-02"`
+	Format      string   `json:"format" validate:"omitempty,oneof=pdf zip"`
+}
+
+// DocumentVerificationResponse is the result of checking an uploaded PDF. Authentic is only true when the PDF is
+// exactly the stored signed letter, Modified is true when the signed letter is found inside a PDF that was changed
+// after it was signed
+type DocumentVerificationResponse struct {
+	Hash       string                      `json:"hash"`
+	Authentic  bool                        `json:"authentic"`
+	Modified   bool                        `json:"modified"`
+	Document   *VerifiedDocumentResponse   `json:"document"`
+	Signatures []EmbeddedSignatureResponse `json:"signatures"`
+}
+
+func NewDocumentVerificationResponse(hash string, signatures []signature.SignatureInfo) *DocumentVerificationResponse {
+	response := &DocumentVerificationResponse{
+		Hash:       hash,
+		Signatures: []EmbeddedSignatureResponse{},
+	}
+	for _, info := range signatures {
+		response.Signatures = append(response.Signatures, *NewEmbeddedSignatureResponse(&info))
+	}
+
+	return response
+}
+
+type VerifiedDocumentResponse struct {
+	ID           string               `json:"id"`
+	RegisterID   uint                 `json:"register"`
+	Template     string               `json:"template"`
+	Signer       dto.EmployeeResponse `json:"signer"`
+	SignedAt     time.Time            `json:"signed_at"`
+	Revoked      bool                 `json:"revoked"`
+	RevokedAt    time.Time            `json:"revoked_at"`
+	RevokeReason string               `json:"revoke_reason,omitempty"`
+}
+
+func NewVerifiedDocumentResponse(document *entity.Document) *VerifiedDocumentResponse {
+	return &VerifiedDocumentResponse{
+		ID:           document.ID,
+		RegisterID:   document.RegisterID,
+		Template:     document.Template.Name,
+		Signer:       *dto.NewEmployeeResponse(&document.Signer),
+		SignedAt:     document.SignedAt,
+		Revoked:      !document.RevokedAt.IsZero(),
+		RevokedAt:    document.RevokedAt,
+		RevokeReason: document.RevokeReason,
+	}
+}
+
+type EmbeddedSignatureResponse struct {
+	Certificate    string    `json:"certificate"`
+	Name           string    `json:"name"`
+	Reason         string    `json:"reason"`
+	SigningTime    time.Time `json:"signing_time"`
+	Intact         bool      `json:"intact"`
+	Trusted        bool      `json:"trusted"`
+	Expired        bool      `json:"expired"`
+	CoversDocument bool      `json:"covers_document"`
+}
+
+func NewEmbeddedSignatureResponse(info *signature.SignatureInfo) *EmbeddedSignatureResponse {
+	return &EmbeddedSignatureResponse{
+		Certificate:    info.Signer,
+		Name:           info.Name,
+		Reason:         info.Reason,
+		SigningTime:    info.SigningTime,
+		Intact:         info.Intact,
+		Trusted:        info.Trusted,
+		Expired:        info.Expired,
+		CoversDocument: info.CoversDocument,
+	}
+}
@@ -2,10 +2,21 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/suryaadi44/eAD-System/pkg/entity"
 )
 
+// DocumentFilter select the document to export, the zero value of a field means it is not filtered. The date range is
+// matched against the time the document entered the filtered stage, DateUntil is exclusive
+type DocumentFilter struct {
+	IDs        []string
+	StageID    int
+	TemplateID uint
+	DateFrom   time.Time
+	DateUntil  time.Time
+}
+
 type DocumentRepository interface {
 	AddDocument(ctx context.Context, document *entity.Document) (string, error)
 	GetDocument(ctx context.Context, documentID string) (*entity.Document, error)
@@ -13,13 +24,24 @@ type DocumentRepository interface {
 	GetBriefDocuments(ctx context.Context, limit int, offset int) (*entity.Documents, error)
 	GetBriefDocumentsByApplicant(ctx context.Context, applicantID string, limit int, offset int) (*entity.Documents, error)
 	GetDocumentStatus(ctx context.Context, documentID string) (*entity.Document, error)
+	GetDocumentBySignedPDFHash(ctx context.Context, hash string) (*entity.Document, error)
+	GetDocumentIDs(ctx context.Context, filter *DocumentFilter, limit int) ([]string, error)
 	GetApplicantID(ctx context.Context, documentID string) (*string, error)
 	GetDocumentStage(ctx context.Context, documentID string) (*int, error)
 	VerifyDocument(ctx context.Context, document *entity.Document) error
 	SignDocument(ctx context.Context, document *entity.Document) error
+	RevokeDocument(ctx context.Context, document *entity.Document) error
 	DeleteDocument(ctx context.Context, documentID string) error
 	UpdateDocument(ctx context.Context, document *entity.Document) error
+	GetDocumentFields(ctx context.Context, documentID string) (*entity.DocumentFields, error)
 	UpdateDocumentFields(ctx context.Context, documentFields *entity.DocumentFields) error
 
 	AddDocumentRegister(ctx context.Context, register *entity.Register) (uint, error)
+
+	AddRenderJob(ctx context.Context, job *entity.RenderJob) error
+	GetRenderJob(ctx context.Context, jobID string) (*entity.RenderJob, error)
+	GetActiveRenderJob(ctx context.Context, documentID string) (*entity.RenderJob, error)
+	GetUnfinishedRenderJobs(ctx context.Context) (*entity.RenderJobs, error)
+	UpdateRenderJob(ctx context.Context, job *entity.RenderJob) error
+	DeleteFinishedRenderJobs(ctx context.Context, before time.Time) (int64, error)
 }
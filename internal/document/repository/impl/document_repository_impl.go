@@ -5,6 +5,7 @@ import (
 	"github.com/suryaadi44/eAD-System/internal/document/repository"
 	"github.com/suryaadi44/eAD-System/pkg/utils"
 	"strings"
+	"time"
 
 	"github.com/suryaadi44/eAD-System/pkg/config"
 	"github.com/suryaadi44/eAD-System/pkg/entity"
@@ -69,13 +70,14 @@ func (d *DocumentRepositoryImpl) GetDocument(ctx context.Context, documentID str
 	var document entity.Document
 	err := d.db.WithContext(ctx).
 		Preload("Applicant", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id, username, name")
+			return db.Select("id, username, name, nik, n_ip, position, telp, sex, address")
 		}).
 		Preload("Verifier", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id, username, name, n_ip, position")
 		}).
+		// the signature and stamp image are printed on the signature block
 		Preload("Signer", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id, username, name, n_ip, position")
+			return db.Select("id, username, name, n_ip, position, signature_image_key, signature_height, signature_offset_x, signature_offset_y, stamp_image_key, stamp_height, stamp_offset_x, stamp_offset_y")
 		}).
 		Preload("Template").
 		Preload("Fields").
@@ -176,12 +178,18 @@ func (d *DocumentRepositoryImpl) GetDocumentStatus(ctx context.Context, document
 	var document entity.Document
 	err := d.db.WithContext(ctx).
 		Preload("Stage").
+		Preload("Applicant", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, name")
+		}).
 		Preload("Verifier", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id, username, name, n_ip, position")
 		}).
 		Preload("Signer", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id, username, name, n_ip, position")
 		}).
+		Preload("Template", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, name")
+		}).
 		First(&document, "id = ?", documentID).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -194,6 +202,72 @@ func (d *DocumentRepositoryImpl) GetDocumentStatus(ctx context.Context, document
 	return &document, nil
 }
 
+// GetDocumentBySignedPDFHash find the signed document whose stored PDF has the SHA-256 hash
+func (d *DocumentRepositoryImpl) GetDocumentBySignedPDFHash(ctx context.Context, hash string) (*entity.Document, error) {
+	var document entity.Document
+	err := d.db.WithContext(ctx).
+		Preload("Signer", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, username, name, n_ip, position")
+		}).
+		Preload("Template").
+		First(&document, "signed_pdf_hash = ?", hash).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.ErrDocumentNotFound
+		}
+
+		return nil, err
+	}
+
+	return &document, nil
+}
+
+// GetDocumentIDs find the id of the document matching the filter, oldest first
+func (d *DocumentRepositoryImpl) GetDocumentIDs(ctx context.Context, filter *repository.DocumentFilter, limit int) ([]string, error) {
+	query := d.db.WithContext(ctx).Model(&entity.Document{})
+	if len(filter.IDs) > 0 {
+		query = query.Where("id IN ?", filter.IDs)
+	}
+	if filter.StageID != 0 {
+		query = query.Where("stage_id = ?", filter.StageID)
+	}
+	if filter.TemplateID != 0 {
+		query = query.Where("template_id = ?", filter.TemplateID)
+	}
+
+	dateColumn := stageDateColumn(filter.StageID)
+	if !filter.DateFrom.IsZero() {
+		query = query.Where(dateColumn+" >= ?", filter.DateFrom)
+	}
+	if !filter.DateUntil.IsZero() {
+		query = query.Where(dateColumn+" < ?", filter.DateUntil)
+	}
+
+	var documentIDs []string
+	err := query.Order("created_at asc").Limit(limit).Pluck("id", &documentIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(documentIDs) == 0 {
+		return nil, utils.ErrDocumentNotFound
+	}
+
+	return documentIDs, nil
+}
+
+// stageDateColumn is the column holding the time the document entered the stage
+func stageDateColumn(stageID int) string {
+	switch stageID {
+	case 2:
+		return "verified_at"
+	case 3:
+		return "signed_at"
+	default:
+		return "created_at"
+	}
+}
+
 func (d *DocumentRepositoryImpl) GetApplicantID(ctx context.Context, documentID string) (*string, error) {
 	var applicantID string
 	err := d.db.WithContext(ctx).
@@ -245,11 +319,29 @@ func (d *DocumentRepositoryImpl) VerifyDocument(ctx context.Context, document *e
 	return nil
 }
 
+// SignDocument only update a verified document, so when two request sign at the same time only the first one is stored
 func (d *DocumentRepositoryImpl) SignDocument(ctx context.Context, document *entity.Document) error {
+	result := d.db.WithContext(ctx).
+		Model(&entity.Document{}).
+		Where("id = ? AND stage_id = ?", document.ID, 2).
+		Select("SignerID", "SignedAt", "StageID", "SignedPDFKey", "SignedPDFHash").
+		Updates(document)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return utils.ErrAlreadySigned
+	}
+
+	return nil
+}
+
+func (d *DocumentRepositoryImpl) RevokeDocument(ctx context.Context, document *entity.Document) error {
 	result := d.db.WithContext(ctx).
 		Model(&entity.Document{}).
 		Where("id = ?", document.ID).
-		Select("SignerID", "SignedAt", "StageID").
+		Select("RevokedAt", "RevokeReason").
 		Updates(document)
 	if result.Error != nil {
 		return result.Error
@@ -293,12 +385,31 @@ func (d *DocumentRepositoryImpl) UpdateDocument(ctx context.Context, document *e
 	return nil
 }
 
+func (d *DocumentRepositoryImpl) GetDocumentFields(ctx context.Context, documentID string) (*entity.DocumentFields, error) {
+	var documentFields entity.DocumentFields
+	err := d.db.WithContext(ctx).
+		Preload("TemplateField").
+		Where("document_id = ?", documentID).
+		Find(&documentFields).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(documentFields) == 0 {
+		return nil, utils.ErrFieldNotFound
+	}
+
+	return &documentFields, nil
+}
+
 func (d *DocumentRepositoryImpl) UpdateDocumentFields(ctx context.Context, documentFields *entity.DocumentFields) error {
 	for _, documentField := range *documentFields {
 		result := d.db.WithContext(ctx).
 			Model(&entity.DocumentField{}).
 			Where("id = ?", documentField.ID).
 			Where("document_id = ?", documentField.DocumentID).
+			// value and rows are always written, so the field can be cleared
+			Select("value", "rows").
 			Updates(documentField)
 		if result.Error != nil {
 			return result.Error
@@ -320,3 +431,103 @@ func (d *DocumentRepositoryImpl) AddDocumentRegister(ctx context.Context, regist
 
 	return register.ID, nil
 }
+
+// AddRenderJob save the new job, ErrRenderJobAlreadyActive is returned when the document already has an active job
+func (d *DocumentRepositoryImpl) AddRenderJob(ctx context.Context, job *entity.RenderJob) error {
+	job.SetActive()
+	err := d.db.WithContext(ctx).Create(job).Error
+	if err != nil {
+		if strings.Contains(err.Error(), "Error 1062: Duplicate entry") {
+			return utils.ErrRenderJobAlreadyActive
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (d *DocumentRepositoryImpl) GetRenderJob(ctx context.Context, jobID string) (*entity.RenderJob, error) {
+	var job entity.RenderJob
+	err := d.db.WithContext(ctx).First(&job, "id = ?", jobID).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.ErrRenderJobNotFound
+		}
+
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// GetActiveRenderJob return the queued or running job of the document, so the same document isn't rendered twice
+func (d *DocumentRepositoryImpl) GetActiveRenderJob(ctx context.Context, documentID string) (*entity.RenderJob, error) {
+	var job entity.RenderJob
+	err := d.db.WithContext(ctx).
+		Where("document_id = ?", documentID).
+		Where("status IN ?", []string{entity.RenderJobQueued, entity.RenderJobRunning}).
+		Order("created_at desc").
+		First(&job).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.ErrRenderJobNotFound
+		}
+
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// GetUnfinishedRenderJobs return the queued or running job in the order they are created, used to resume the job after restart
+func (d *DocumentRepositoryImpl) GetUnfinishedRenderJobs(ctx context.Context) (*entity.RenderJobs, error) {
+	var jobs entity.RenderJobs
+	err := d.db.WithContext(ctx).
+		Where("status IN ?", []string{entity.RenderJobQueued, entity.RenderJobRunning}).
+		Order("created_at asc").
+		Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &jobs, nil
+}
+
+// UpdateRenderJob save the progress of the job, ErrRenderJobAlreadyActive is returned when the finished job is queued
+// again while the document has another active job
+func (d *DocumentRepositoryImpl) UpdateRenderJob(ctx context.Context, job *entity.RenderJob) error {
+	job.SetActive()
+	result := d.db.WithContext(ctx).
+		Model(&entity.RenderJob{}).
+		Where("id = ?", job.ID).
+		Select("Status", "ArtifactKey", "Error", "Active", "FinishedAt").
+		Updates(job)
+	if result.Error != nil {
+		if strings.Contains(result.Error.Error(), "Error 1062: Duplicate entry") {
+			return utils.ErrRenderJobAlreadyActive
+		}
+
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return utils.ErrRenderJobNotFound
+	}
+
+	return nil
+}
+
+// DeleteFinishedRenderJobs remove the done or failed job finished before the given time, return the number of
+// removed job
+func (d *DocumentRepositoryImpl) DeleteFinishedRenderJobs(ctx context.Context, before time.Time) (int64, error) {
+	result := d.db.WithContext(ctx).
+		Where("status IN ?", []string{entity.RenderJobDone, entity.RenderJobFailed}).
+		Where("finished_at < ?", before).
+		Delete(&entity.RenderJob{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
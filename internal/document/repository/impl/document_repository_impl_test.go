@@ -2,7 +2,9 @@ package impl
 
 import (
 	"context"
+	"database/sql/driver"
 	"errors"
+	"github.com/suryaadi44/eAD-System/internal/document/repository"
 	"github.com/suryaadi44/eAD-System/pkg/utils"
 	"regexp"
 	"testing"
@@ -82,12 +84,13 @@ func (s *TestSuiteDocumentRepository) TestAddDocument() {
 
 func (s *TestSuiteDocumentRepository) TestGetDocument() {
 	query := regexp.QuoteMeta("SELECT * FROM `documents` WHERE id = ? AND `documents`.`deleted_at` IS NULL ORDER BY `documents`.`id` LIMIT 1")
-	queryPreloadUser := regexp.QuoteMeta("SELECT id, username, name FROM `users` WHERE `users`.`id` = ? AND `users`.`deleted_at` IS NULL")
+	queryPreloadUser := regexp.QuoteMeta("SELECT id, username, name, nik, n_ip, position, telp, sex, address FROM `users` WHERE `users`.`id` = ? AND `users`.`deleted_at` IS NULL")
 	queryPreloadStage := regexp.QuoteMeta("SELECT * FROM `stages` WHERE `stages`.`id` = ?")
 	queryPreloadTemplate := regexp.QuoteMeta("SELECT * FROM `templates` WHERE `templates`.`id` = ? AND `templates`.`deleted_at` IS NULL")
 	queryPreloadTemplateFields := regexp.QuoteMeta("SELECT * FROM `template_fields` WHERE `template_fields`.`id` = ? AND `template_fields`.`deleted_at` IS NULL")
 	queryPreloadDocumentFields := regexp.QuoteMeta("SELECT * FROM `document_fields` WHERE `document_fields`.`document_id` = ? AND `document_fields`.`deleted_at` IS NULL")
 	queryPreloadEmployee := regexp.QuoteMeta("SELECT id, username, name, n_ip, position FROM `users` WHERE `users`.`id` = ? AND `users`.`deleted_at` IS NULL")
+	queryPreloadSigner := regexp.QuoteMeta("SELECT id, username, name, n_ip, position, signature_image_key, signature_height, signature_offset_x, signature_offset_y, stamp_image_key, stamp_height, stamp_offset_x, stamp_offset_y FROM `users` WHERE `users`.`id` = ? AND `users`.`deleted_at` IS NULL")
 
 	for _, tc := range []struct {
 		Name           string
@@ -162,6 +165,10 @@ func (s *TestSuiteDocumentRepository) TestGetDocument() {
 					Name:     "name",
 					NIP:      "123",
 					Position: "position",
+					Signature: entity.SignatureImage{
+						ImageKey: "images/signature/1",
+						Height:   25,
+					},
 				},
 				SignedAt:  time.Time{},
 				CreatedAt: time.Time{},
@@ -190,7 +197,7 @@ func (s *TestSuiteDocumentRepository) TestGetDocument() {
 				s.mock.ExpectQuery(queryPreloadUser).WillReturnRows(sqlmock.NewRows([]string{"id", "username", "name"}).AddRow(1, "username", "name"))
 				s.mock.ExpectQuery(queryPreloadDocumentFields).WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "template_field_id", "value"}).AddRow(1, 1, 1, "value"))
 				s.mock.ExpectQuery(queryPreloadTemplateFields).WillReturnRows(sqlmock.NewRows([]string{"id", "template_id", "key"}).AddRow(1, 1, "key"))
-				s.mock.ExpectQuery(queryPreloadEmployee).WillReturnRows(sqlmock.NewRows([]string{"id", "username", "name", "n_ip", "position"}).AddRow(1, "username", "name", "123", "position"))
+				s.mock.ExpectQuery(queryPreloadSigner).WillReturnRows(sqlmock.NewRows([]string{"id", "username", "name", "n_ip", "position", "signature_image_key", "signature_height"}).AddRow(1, "username", "name", "123", "position", "images/signature/1", 25))
 				s.mock.ExpectQuery(queryPreloadStage).WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(3, "approved"))
 				s.mock.ExpectQuery(queryPreloadTemplate).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "path", "margin_top", "margin_bottom", "margin_left", "margin_right", "is_active"}).AddRow(1, "template", "path", 0, 0, 0, 0, false))
 				s.mock.ExpectQuery(queryPreloadEmployee).WillReturnRows(sqlmock.NewRows([]string{"id", "username", "name", "n_ip", "position"}).AddRow(1, "username", "name", "123", "position"))
@@ -429,7 +436,9 @@ func (s *TestSuiteDocumentRepository) TestGetBriefDocumentsByApplicant() {
 func (s *TestSuiteDocumentRepository) TestGetDocumentStatus() {
 	query := regexp.QuoteMeta("SELECT * FROM `documents` WHERE id = ? AND `documents`.`deleted_at` IS NULL ORDER BY `documents`.`id` LIMIT 1")
 	queryPreloadStage := regexp.QuoteMeta("SELECT * FROM `stages` WHERE `stages`.`id` = ?")
+	queryPreloadApplicant := regexp.QuoteMeta("SELECT id, name FROM `users` WHERE `users`.`id` = ? AND `users`.`deleted_at` IS NULL")
 	queryPreloadEmployee := regexp.QuoteMeta("SELECT id, username, name, n_ip, position FROM `users` WHERE `users`.`id` = ? AND `users`.`deleted_at` IS NULL")
+	queryPreloadTemplate := regexp.QuoteMeta("SELECT id, name FROM `templates` WHERE `templates`.`id` = ? AND `templates`.`deleted_at` IS NULL")
 
 	for _, tc := range []struct {
 		Name           string
@@ -445,8 +454,13 @@ func (s *TestSuiteDocumentRepository) TestGetDocumentStatus() {
 				RegisterID:  123,
 				Description: "description",
 				ApplicantID: "1",
-				TemplateID:  1,
-				StageID:     3,
+				Applicant: entity.User{
+					ID:   "1",
+					Name: "applicant",
+				},
+				TemplateID: 1,
+				Template:   entity.Template{Model: gorm.Model{ID: 1}, Name: "template"},
+				StageID:    3,
 				Stage: entity.Stage{
 					ID:     3,
 					Status: "approved",
@@ -492,8 +506,10 @@ func (s *TestSuiteDocumentRepository) TestGetDocumentStatus() {
 			} else {
 				s.mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id", "register_id", "description", "applicant_id", "template_id", "stage_id", "verifier_id", "signer_id"}).
 					AddRow(1, 123, "description", "1", 1, 3, "1", "1"))
+				s.mock.ExpectQuery(queryPreloadApplicant).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "applicant"))
 				s.mock.ExpectQuery(queryPreloadEmployee).WillReturnRows(sqlmock.NewRows([]string{"id", "username", "name", "n_ip", "position"}).AddRow(1, "username", "name", "123", "position"))
 				s.mock.ExpectQuery(queryPreloadStage).WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(3, "approved"))
+				s.mock.ExpectQuery(queryPreloadTemplate).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "template"))
 				s.mock.ExpectQuery(queryPreloadEmployee).WillReturnRows(sqlmock.NewRows([]string{"id", "username", "name", "n_ip", "position"}).AddRow(1, "username", "name", "123", "position"))
 			}
 
@@ -509,6 +525,140 @@ func (s *TestSuiteDocumentRepository) TestGetDocumentStatus() {
 	}
 }
 
+func (s *TestSuiteDocumentRepository) TestGetDocumentBySignedPDFHash() {
+	query := regexp.QuoteMeta("SELECT * FROM `documents` WHERE signed_pdf_hash = ? AND `documents`.`deleted_at` IS NULL ORDER BY `documents`.`id` LIMIT 1")
+	queryPreloadEmployee := regexp.QuoteMeta("SELECT id, username, name, n_ip, position FROM `users` WHERE `users`.`id` = ? AND `users`.`deleted_at` IS NULL")
+	queryPreloadTemplate := regexp.QuoteMeta("SELECT * FROM `templates` WHERE `templates`.`id` = ? AND `templates`.`deleted_at` IS NULL")
+
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn *entity.Document
+	}{
+		{
+			Name: "Success",
+			ExpectedReturn: &entity.Document{
+				ID:            "1",
+				RegisterID:    123,
+				TemplateID:    1,
+				Template:      entity.Template{Model: gorm.Model{ID: 1}, Name: "template"},
+				StageID:       3,
+				SignerID:      "1",
+				Signer:        entity.User{ID: "1", Username: "username", Name: "name", NIP: "123", Position: "position"},
+				SignedPDFHash: "hash",
+			},
+		},
+		{
+			Name:        "Error No rows in result set",
+			Err:         gorm.ErrRecordNotFound,
+			ExpectedErr: utils.ErrDocumentNotFound,
+		},
+		{
+			Name:        "Error generic error",
+			Err:         errors.New("generic error"),
+			ExpectedErr: errors.New("generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WithArgs("hash").WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).WithArgs("hash").WillReturnRows(sqlmock.NewRows([]string{"id", "register_id", "template_id", "stage_id", "signer_id", "signed_pdf_hash"}).
+					AddRow("1", 123, 1, 3, "1", "hash"))
+				s.mock.ExpectQuery(queryPreloadEmployee).WillReturnRows(sqlmock.NewRows([]string{"id", "username", "name", "n_ip", "position"}).AddRow("1", "username", "name", "123", "position"))
+				s.mock.ExpectQuery(queryPreloadTemplate).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "template"))
+			}
+
+			result, err := s.documentRepository.GetDocumentBySignedPDFHash(context.Background(), "hash")
+
+			if tc.ExpectedErr != nil {
+				s.Equal(tc.ExpectedErr, err)
+			} else {
+				s.Equal(tc.ExpectedReturn, result)
+			}
+		})
+		s.TearDownTest()
+	}
+}
+
+func (s *TestSuiteDocumentRepository) TestGetDocumentIDs() {
+	from := time.Date(2022, time.December, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2022, time.December, 2, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		Name           string
+		Filter         *repository.DocumentFilter
+		Query          string
+		Args           []driver.Value
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn []string
+		ReturnedRows   *sqlmock.Rows
+	}{
+		{
+			Name:           "Success by id",
+			Filter:         &repository.DocumentFilter{IDs: []string{"1", "2"}},
+			Query:          "SELECT `id` FROM `documents` WHERE id IN (?,?) AND `documents`.`deleted_at` IS NULL ORDER BY created_at asc LIMIT 10",
+			Args:           []driver.Value{"1", "2"},
+			ExpectedReturn: []string{"1", "2"},
+			ReturnedRows:   sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"),
+		},
+		{
+			Name:           "Success by signed date",
+			Filter:         &repository.DocumentFilter{StageID: 3, TemplateID: 1, DateFrom: from, DateUntil: until},
+			Query:          "SELECT `id` FROM `documents` WHERE stage_id = ? AND template_id = ? AND signed_at >= ? AND signed_at < ? AND `documents`.`deleted_at` IS NULL ORDER BY created_at asc LIMIT 10",
+			Args:           []driver.Value{3, 1, from, until},
+			ExpectedReturn: []string{"1"},
+			ReturnedRows:   sqlmock.NewRows([]string{"id"}).AddRow("1"),
+		},
+		{
+			Name:           "Success by created date",
+			Filter:         &repository.DocumentFilter{DateFrom: from},
+			Query:          "SELECT `id` FROM `documents` WHERE created_at >= ? AND `documents`.`deleted_at` IS NULL ORDER BY created_at asc LIMIT 10",
+			Args:           []driver.Value{from},
+			ExpectedReturn: []string{"1"},
+			ReturnedRows:   sqlmock.NewRows([]string{"id"}).AddRow("1"),
+		},
+		{
+			Name:         "Error no document",
+			Filter:       &repository.DocumentFilter{StageID: 2},
+			Query:        "SELECT `id` FROM `documents` WHERE stage_id = ? AND `documents`.`deleted_at` IS NULL ORDER BY created_at asc LIMIT 10",
+			Args:         []driver.Value{2},
+			ExpectedErr:  utils.ErrDocumentNotFound,
+			ReturnedRows: sqlmock.NewRows([]string{"id"}),
+		},
+		{
+			Name:        "Error generic error",
+			Filter:      &repository.DocumentFilter{StageID: 2},
+			Query:       "SELECT `id` FROM `documents` WHERE stage_id = ? AND `documents`.`deleted_at` IS NULL ORDER BY created_at asc LIMIT 10",
+			Args:        []driver.Value{2},
+			Err:         errors.New("generic error"),
+			ExpectedErr: errors.New("generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(regexp.QuoteMeta(tc.Query)).WithArgs(tc.Args...).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(regexp.QuoteMeta(tc.Query)).WithArgs(tc.Args...).WillReturnRows(tc.ReturnedRows)
+			}
+
+			result, err := s.documentRepository.GetDocumentIDs(context.Background(), tc.Filter, 10)
+
+			if tc.ExpectedErr != nil {
+				s.Equal(tc.ExpectedErr, err)
+			} else {
+				s.NoError(err)
+				s.Equal(tc.ExpectedReturn, result)
+			}
+		})
+		s.TearDownTest()
+	}
+}
+
 func (s *TestSuiteDocumentRepository) TestGetApplicantID() {
 	query := regexp.QuoteMeta("SELECT `applicant_id` FROM `documents` WHERE id = ? AND `documents`.`deleted_at` IS NULL ORDER BY `documents`.`id` LIMIT 1")
 
@@ -646,7 +796,7 @@ func (s *TestSuiteDocumentRepository) TestVerifyDocument() {
 }
 
 func (s *TestSuiteDocumentRepository) TestSignDocument() {
-	query := regexp.QuoteMeta("UPDATE `documents` SET `stage_id`=?,`signer_id`=?,`signed_at`=?,`updated_at`=? WHERE id = ? AND `documents`.`deleted_at` IS NULL")
+	query := regexp.QuoteMeta("UPDATE `documents` SET `stage_id`=?,`signer_id`=?,`signed_at`=?,`signed_pdf_key`=?,`signed_pdf_hash`=?,`updated_at`=? WHERE (id = ? AND stage_id = ?) AND `documents`.`deleted_at` IS NULL")
 
 	for _, tc := range []struct {
 		Name         string
@@ -659,9 +809,9 @@ func (s *TestSuiteDocumentRepository) TestSignDocument() {
 			RowsAffected: 1,
 		},
 		{
-			Name:         "Error No rows affected",
+			Name:         "Error not in verified stage",
 			RowsAffected: 0,
-			ExpectedErr:  utils.ErrDocumentNotFound,
+			ExpectedErr:  utils.ErrAlreadySigned,
 		},
 		{
 			Name:        "Error generic error",
@@ -687,6 +837,46 @@ func (s *TestSuiteDocumentRepository) TestSignDocument() {
 	}
 }
 
+func (s *TestSuiteDocumentRepository) TestRevokeDocument() {
+	query := regexp.QuoteMeta("UPDATE `documents` SET `revoked_at`=?,`revoke_reason`=?,`updated_at`=? WHERE id = ? AND `documents`.`deleted_at` IS NULL")
+
+	for _, tc := range []struct {
+		Name         string
+		Err          error
+		ExpectedErr  error
+		RowsAffected int64
+	}{
+		{
+			Name:         "Success",
+			RowsAffected: 1,
+		},
+		{
+			Name:         "Error No rows affected",
+			RowsAffected: 0,
+			ExpectedErr:  utils.ErrDocumentNotFound,
+		},
+		{
+			Name:        "Error generic error",
+			Err:         errors.New("generic error"),
+			ExpectedErr: errors.New("generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectExec(query).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(1, tc.RowsAffected))
+			}
+
+			err := s.documentRepository.RevokeDocument(context.Background(), &entity.Document{ID: "1", RevokedAt: time.Now(), RevokeReason: "reason"})
+
+			s.Equal(tc.ExpectedErr, err)
+		})
+		s.TearDownTest()
+	}
+}
+
 func (s *TestSuiteDocumentRepository) TestDeleteDocument() {
 	query := regexp.QuoteMeta("UPDATE `documents` SET `deleted_at`=? WHERE id = ? AND `documents`.`deleted_at` IS NULL")
 
@@ -775,8 +965,77 @@ func (s *TestSuiteDocumentRepository) TestUpdateDocument() {
 	}
 }
 
+func (s *TestSuiteDocumentRepository) TestGetDocumentFields() {
+	query := regexp.QuoteMeta("SELECT * FROM `document_fields` WHERE document_id = ? AND `document_fields`.`deleted_at` IS NULL")
+	queryPreloadTemplateFields := regexp.QuoteMeta("SELECT * FROM `template_fields` WHERE `template_fields`.`id` = ? AND `template_fields`.`deleted_at` IS NULL")
+
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn *entity.DocumentFields
+		ReturnedRow    *sqlmock.Rows
+	}{
+		{
+			Name: "Success",
+			ExpectedReturn: &entity.DocumentFields{
+				{
+					Model: gorm.Model{
+						ID: 1,
+					},
+					DocumentID:      "1",
+					TemplateFieldID: 1,
+					TemplateField: entity.TemplateField{
+						Model: gorm.Model{
+							ID: 1,
+						},
+						TemplateID: 1,
+						Key:        "family",
+						Type:       "table",
+						Columns: entity.TableColumns{
+							{Key: "name", Type: "text"},
+						},
+					},
+					Rows: entity.TableRows{
+						{"name": "Budi"},
+					},
+				},
+			},
+			ReturnedRow: sqlmock.NewRows([]string{"id", "document_id", "template_field_id", "value", "rows"}).
+				AddRow(1, "1", 1, "", `[{"name":"Budi"}]`),
+		},
+		{
+			Name:        "Error No rows in result set",
+			ExpectedErr: utils.ErrFieldNotFound,
+			ReturnedRow: sqlmock.NewRows([]string{"id", "document_id", "template_field_id", "value", "rows"}),
+		},
+		{
+			Name:        "Error generic error",
+			Err:         errors.New("generic error"),
+			ExpectedErr: errors.New("generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).WillReturnRows(tc.ReturnedRow)
+				s.mock.ExpectQuery(queryPreloadTemplateFields).WillReturnRows(sqlmock.NewRows([]string{"id", "template_id", "key", "type", "columns"}).
+					AddRow(1, 1, "family", "table", `[{"key":"name","label":"","type":"text","required":false}]`))
+			}
+
+			result, err := s.documentRepository.GetDocumentFields(context.Background(), "1")
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, result)
+		})
+		s.TearDownTest()
+	}
+}
+
 func (s *TestSuiteDocumentRepository) TestUpdateDocumentFields() {
-	query := regexp.QuoteMeta("UPDATE `document_fields` SET `id`=?,`updated_at`=?,`document_id`=?,`template_field_id`=?,`value`=? WHERE id = ? AND document_id = ? AND `document_fields`.`deleted_at` IS NULL")
+	query := regexp.QuoteMeta("UPDATE `document_fields` SET `updated_at`=?,`value`=?,`rows`=? WHERE id = ? AND document_id = ? AND `document_fields`.`deleted_at` IS NULL")
 
 	for _, tc := range []struct {
 		Name         string
@@ -870,6 +1129,287 @@ func (s *TestSuiteDocumentRepository) TestAddDocumentRegister() {
 	}
 }
 
+func (s *TestSuiteDocumentRepository) TestAddRenderJob() {
+	query := regexp.QuoteMeta("INSERT INTO `render_jobs` (`id`,`document_id`,`status`,`artifact_key`,`error`,`active`,`created_at`,`updated_at`) VALUES (?,?,?,?,?,?,?,?)")
+
+	for _, tc := range []struct {
+		Name        string
+		Err         error
+		ExpectedErr error
+	}{
+		{
+			Name: "Success",
+		},
+		{
+			Name:        "Error document already has an active job",
+			Err:         errors.New("Error 1062: Duplicate entry '1-1' for key 'idx_render_jobs_document_active'"),
+			ExpectedErr: utils.ErrRenderJobAlreadyActive,
+		},
+		{
+			Name:        "Error generic error",
+			Err:         errors.New("generic error"),
+			ExpectedErr: errors.New("generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			expectation := s.mock.ExpectExec(query).WithArgs("job1", "1", entity.RenderJobQueued, "", "", true, sqlmock.AnyArg(), sqlmock.AnyArg())
+			if tc.Err != nil {
+				expectation.WillReturnError(tc.Err)
+			} else {
+				expectation.WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			err := s.documentRepository.AddRenderJob(context.Background(), &entity.RenderJob{
+				ID:         "job1",
+				DocumentID: "1",
+				Status:     entity.RenderJobQueued,
+			})
+
+			s.Equal(tc.ExpectedErr, err)
+		})
+		s.TearDownTest()
+	}
+}
+
+func (s *TestSuiteDocumentRepository) TestGetRenderJob() {
+	query := regexp.QuoteMeta("SELECT * FROM `render_jobs` WHERE id = ? ORDER BY `render_jobs`.`id` LIMIT 1")
+
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn *entity.RenderJob
+	}{
+		{
+			Name: "Success",
+			ExpectedReturn: &entity.RenderJob{
+				ID:          "job1",
+				DocumentID:  "1",
+				Status:      entity.RenderJobDone,
+				ArtifactKey: "cache/1/abc.pdf",
+			},
+		},
+		{
+			Name:        "Error job not found",
+			Err:         gorm.ErrRecordNotFound,
+			ExpectedErr: utils.ErrRenderJobNotFound,
+		},
+		{
+			Name:        "Error generic error",
+			Err:         errors.New("generic error"),
+			ExpectedErr: errors.New("generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "status", "artifact_key"}).
+					AddRow("job1", "1", entity.RenderJobDone, "cache/1/abc.pdf"))
+			}
+
+			result, err := s.documentRepository.GetRenderJob(context.Background(), "job1")
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, result)
+		})
+		s.TearDownTest()
+	}
+}
+
+func (s *TestSuiteDocumentRepository) TestGetActiveRenderJob() {
+	query := regexp.QuoteMeta("SELECT * FROM `render_jobs` WHERE document_id = ? AND status IN (?,?) ORDER BY created_at desc,`render_jobs`.`id` LIMIT 1")
+
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn *entity.RenderJob
+	}{
+		{
+			Name: "Success",
+			ExpectedReturn: &entity.RenderJob{
+				ID:         "job1",
+				DocumentID: "1",
+				Status:     entity.RenderJobRunning,
+			},
+		},
+		{
+			Name:        "Error no active job",
+			Err:         gorm.ErrRecordNotFound,
+			ExpectedErr: utils.ErrRenderJobNotFound,
+		},
+		{
+			Name:        "Error generic error",
+			Err:         errors.New("generic error"),
+			ExpectedErr: errors.New("generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).
+					WithArgs("1", entity.RenderJobQueued, entity.RenderJobRunning).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "status"}).
+						AddRow("job1", "1", entity.RenderJobRunning))
+			}
+
+			result, err := s.documentRepository.GetActiveRenderJob(context.Background(), "1")
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, result)
+		})
+		s.TearDownTest()
+	}
+}
+
+func (s *TestSuiteDocumentRepository) TestGetUnfinishedRenderJobs() {
+	query := regexp.QuoteMeta("SELECT * FROM `render_jobs` WHERE status IN (?,?) ORDER BY created_at asc")
+
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn *entity.RenderJobs
+	}{
+		{
+			Name: "Success",
+			ExpectedReturn: &entity.RenderJobs{
+				{ID: "job1", DocumentID: "1", Status: entity.RenderJobRunning},
+				{ID: "job2", DocumentID: "2", Status: entity.RenderJobQueued},
+			},
+		},
+		{
+			Name:        "Error generic error",
+			Err:         errors.New("generic error"),
+			ExpectedErr: errors.New("generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "status"}).
+					AddRow("job1", "1", entity.RenderJobRunning).
+					AddRow("job2", "2", entity.RenderJobQueued))
+			}
+
+			result, err := s.documentRepository.GetUnfinishedRenderJobs(context.Background())
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, result)
+		})
+		s.TearDownTest()
+	}
+}
+
+func (s *TestSuiteDocumentRepository) TestUpdateRenderJob() {
+	query := regexp.QuoteMeta("UPDATE `render_jobs` SET `status`=?,`artifact_key`=?,`error`=?,`active`=?,`finished_at`=?,`updated_at`=? WHERE id = ?")
+
+	for _, tc := range []struct {
+		Name         string
+		Status       string
+		Active       interface{}
+		Err          error
+		ExpectedErr  error
+		RowsAffected int64
+	}{
+		{
+			Name:         "Success finished job is no longer active",
+			Status:       entity.RenderJobDone,
+			Active:       nil,
+			RowsAffected: 1,
+		},
+		{
+			Name:         "Success queued again job is active",
+			Status:       entity.RenderJobQueued,
+			Active:       true,
+			RowsAffected: 1,
+		},
+		{
+			Name:         "Error No rows affected",
+			Status:       entity.RenderJobDone,
+			Active:       nil,
+			RowsAffected: 0,
+			ExpectedErr:  utils.ErrRenderJobNotFound,
+		},
+		{
+			Name:        "Error document already has an active job",
+			Status:      entity.RenderJobQueued,
+			Active:      true,
+			Err:         errors.New("Error 1062: Duplicate entry '1-1' for key 'idx_render_jobs_document_active'"),
+			ExpectedErr: utils.ErrRenderJobAlreadyActive,
+		},
+		{
+			Name:        "Error generic error",
+			Status:      entity.RenderJobDone,
+			Active:      nil,
+			Err:         errors.New("generic error"),
+			ExpectedErr: errors.New("generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			expectation := s.mock.ExpectExec(query).WithArgs(tc.Status, "cache/1/abc.pdf", "", tc.Active, sqlmock.AnyArg(), sqlmock.AnyArg(), "job1")
+			if tc.Err != nil {
+				expectation.WillReturnError(tc.Err)
+			} else {
+				expectation.WillReturnResult(sqlmock.NewResult(1, tc.RowsAffected))
+			}
+
+			err := s.documentRepository.UpdateRenderJob(context.Background(), &entity.RenderJob{
+				ID:          "job1",
+				Status:      tc.Status,
+				ArtifactKey: "cache/1/abc.pdf",
+			})
+
+			s.Equal(tc.ExpectedErr, err)
+		})
+		s.TearDownTest()
+	}
+}
+
 func TestDocumentRepository(t *testing.T) {
 	suite.Run(t, new(TestSuiteDocumentRepository))
 }
+
+func (s *TestSuiteDocumentRepository) TestDeleteFinishedRenderJobs() {
+	query := regexp.QuoteMeta("DELETE FROM `render_jobs` WHERE status IN (?,?) AND finished_at < ?")
+
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn int64
+	}{
+		{
+			Name:           "Success",
+			ExpectedReturn: 3,
+		},
+		{
+			Name:        "Error generic error",
+			Err:         errors.New("generic error"),
+			ExpectedErr: errors.New("generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectExec(query).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, tc.ExpectedReturn))
+			}
+
+			removed, err := s.documentRepository.DeleteFinishedRenderJobs(context.Background(), time.Now())
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, removed)
+		})
+		s.TearDownTest()
+	}
+}
@@ -2,7 +2,10 @@ package mock
 
 import (
 	"context"
+	"time"
+
 	"github.com/stretchr/testify/mock"
+	"github.com/suryaadi44/eAD-System/internal/document/repository"
 	"github.com/suryaadi44/eAD-System/pkg/entity"
 )
 
@@ -40,6 +43,11 @@ func (m *MockDocumentRepository) GetDocumentStatus(ctx context.Context, document
 	return args.Get(0).(*entity.Document), args.Error(1)
 }
 
+func (m *MockDocumentRepository) GetDocumentBySignedPDFHash(ctx context.Context, hash string) (*entity.Document, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(*entity.Document), args.Error(1)
+}
+
 func (m *MockDocumentRepository) GetApplicantID(ctx context.Context, documentID string) (*string, error) {
 	args := m.Called(ctx, documentID)
 	return args.Get(0).(*string), args.Error(1)
@@ -50,6 +58,11 @@ func (m *MockDocumentRepository) GetApplicant(ctx context.Context, documentID st
 	return args.Get(0).(*entity.User), args.Error(1)
 }
 
+func (m *MockDocumentRepository) GetDocumentIDs(ctx context.Context, filter *repository.DocumentFilter, limit int) ([]string, error) {
+	args := m.Called(ctx, filter, limit)
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func (m *MockDocumentRepository) GetDocumentStage(ctx context.Context, documentID string) (*int, error) {
 	args := m.Called(ctx, documentID)
 	return args.Get(0).(*int), args.Error(1)
@@ -65,6 +78,11 @@ func (m *MockDocumentRepository) SignDocument(ctx context.Context, document *ent
 	return args.Error(0)
 }
 
+func (m *MockDocumentRepository) RevokeDocument(ctx context.Context, document *entity.Document) error {
+	args := m.Called(ctx, document)
+	return args.Error(0)
+}
+
 func (m *MockDocumentRepository) DeleteDocument(ctx context.Context, documentID string) error {
 	args := m.Called(ctx, documentID)
 	return args.Error(0)
@@ -75,6 +93,11 @@ func (m *MockDocumentRepository) UpdateDocument(ctx context.Context, document *e
 	return args.Error(0)
 }
 
+func (m *MockDocumentRepository) GetDocumentFields(ctx context.Context, documentID string) (*entity.DocumentFields, error) {
+	args := m.Called(ctx, documentID)
+	return args.Get(0).(*entity.DocumentFields), args.Error(1)
+}
+
 func (m *MockDocumentRepository) UpdateDocumentFields(ctx context.Context, documentFields *entity.DocumentFields) error {
 	args := m.Called(ctx, documentFields)
 	return args.Error(0)
@@ -84,3 +107,33 @@ func (m *MockDocumentRepository) AddDocumentRegister(ctx context.Context, regist
 	args := m.Called(ctx, register)
 	return args.Get(0).(uint), args.Error(1)
 }
+
+func (m *MockDocumentRepository) AddRenderJob(ctx context.Context, job *entity.RenderJob) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockDocumentRepository) GetRenderJob(ctx context.Context, jobID string) (*entity.RenderJob, error) {
+	args := m.Called(ctx, jobID)
+	return args.Get(0).(*entity.RenderJob), args.Error(1)
+}
+
+func (m *MockDocumentRepository) GetActiveRenderJob(ctx context.Context, documentID string) (*entity.RenderJob, error) {
+	args := m.Called(ctx, documentID)
+	return args.Get(0).(*entity.RenderJob), args.Error(1)
+}
+
+func (m *MockDocumentRepository) GetUnfinishedRenderJobs(ctx context.Context) (*entity.RenderJobs, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*entity.RenderJobs), args.Error(1)
+}
+
+func (m *MockDocumentRepository) UpdateRenderJob(ctx context.Context, job *entity.RenderJob) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockDocumentRepository) DeleteFinishedRenderJobs(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
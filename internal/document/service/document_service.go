@@ -1,20 +1,34 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"io"
+
 	"github.com/suryaadi44/eAD-System/internal/document/dto"
+	"github.com/suryaadi44/eAD-System/pkg/utils/qr"
 )
 
 type DocumentService interface {
 	AddDocument(ctx context.Context, document *dto.DocumentRequest, userID string) (string, error)
 	GetDocument(ctx context.Context, documentID string) (*dto.DocumentResponse, error)
-	GetBriefDocuments(ctx context.Context, applicantID string, role int, page int, limit int) (*dto.BriefDocumentsResponse, error)
-	GetDocumentStatus(ctx context.Context, documentID string) (*dto.DocumentStatusResponse, error)
-	GeneratePDFDocument(ctx context.Context, documentID string) ([]byte, error)
+	GetBriefDocuments(ctx context.Context, applicantID string, ownerOnly bool, page int, limit int) (*dto.BriefDocumentsResponse, error)
+	GetDocumentStatus(ctx context.Context, documentID string, token string) (*dto.DocumentStatusResponse, error)
+	GenerateStatusPage(status *dto.DocumentStatusResponse, statusErr error) (*bytes.Buffer, error)
+	GetQRPublicKey() *qr.PublicKey
+	RenderDocument(ctx context.Context, documentID string, format string) (*dto.RenderedDocument, error)
+	GetExportDocumentIDs(ctx context.Context, request *dto.ExportDocumentsRequest) ([]string, error)
+	WriteDocumentExport(ctx context.Context, documentIDs []string, format string, w io.Writer) error
+	RequestPDFDocument(ctx context.Context, documentID string, async bool) ([]byte, *dto.RenderJobResponse, error)
+	GetRenderJob(ctx context.Context, jobID string) (*dto.RenderJobResponse, error)
+	GetRenderJobPDF(ctx context.Context, jobID string) ([]byte, error)
+	StartRenderWorkers(ctx context.Context) error
 	GetApplicantID(ctx context.Context, documentID string) (*string, error)
 	VerifyDocument(ctx context.Context, documentID string, verifierID string, verifyRequest *dto.VerifyDocumentRequest) error
 	SignDocument(ctx context.Context, documentID string, signerID string) error
-	DeleteDocument(ctx context.Context, userID string, role int, documentID string) error
+	RevokeDocument(ctx context.Context, documentID string, revokeRequest *dto.RevokeDocumentRequest) error
+	VerifyDocumentFile(ctx context.Context, file io.Reader) (*dto.DocumentVerificationResponse, error)
+	DeleteDocument(ctx context.Context, userID string, ownerOnly bool, documentID string) error
 	UpdateDocument(ctx context.Context, document *dto.DocumentUpdateRequest, documentID string) error
-	UpdateDocumentFields(ctx context.Context, userID string, role int, documentID string, fields *dto.FieldsUpdateRequest) error
+	UpdateDocumentFields(ctx context.Context, userID string, ownerOnly bool, documentID string, fields *dto.FieldsUpdateRequest) error
 }
@@ -0,0 +1,238 @@
+package impl
+
+import (
+	"sort"
+	"time"
+
+	"github.com/suryaadi44/eAD-System/internal/document/dto"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/binding"
+	"github.com/suryaadi44/eAD-System/pkg/utils/expression"
+)
+
+// newExpressionEnv return the variables that can be used in field expression, the field values and the document metadata
+func newExpressionEnv(values map[string]interface{}, document *entity.Document) map[string]interface{} {
+	env := make(map[string]interface{}, len(values)+5)
+	for key, value := range values {
+		env[key] = value
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	createdAt := document.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = now
+	}
+
+	env["today"] = today
+	env["register"] = document.RegisterID
+	env["created_at"] = createdAt
+	env["verified_at"] = document.VerifiedAt
+	env["signed_at"] = document.SignedAt
+
+	return env
+}
+
+// evaluateComputedField evaluate the field expression and put the result into env, so the next computed field or
+// condition can use it
+func evaluateComputedField(field *entity.TemplateField, env map[string]interface{}) (interface{}, error) {
+	expr, err := expression.Parse(field.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := expr.Evaluate(env)
+	if err != nil {
+		return nil, err
+	}
+	binding.SetNested(env, field.Key, value)
+
+	return value, nil
+}
+
+// isFieldRequired evaluate the field condition, field without condition is always required
+func isFieldRequired(field *entity.TemplateField, env map[string]interface{}) (bool, error) {
+	if field.Condition == "" {
+		return true, nil
+	}
+
+	expr, err := expression.Parse(field.Condition)
+	if err != nil {
+		return false, err
+	}
+
+	return expr.EvaluateBool(env)
+}
+
+// applyFieldRules check the conditional fields that is required by the submitted values, and add empty field for the
+// computed field and the conditional field whose condition didn't hold. Rules are evaluated in the template field order, so a condition
+// can use computed field declared before it
+func applyFieldRules(document *entity.Document, keys entity.TemplateFields) (entity.DocumentFields, error) {
+	submitted := make(map[uint]entity.DocumentField, len(document.Fields))
+	for _, field := range document.Fields {
+		submitted[field.TemplateFieldID] = field
+	}
+
+	values := make(map[string]interface{})
+	for _, key := range keys {
+		field, ok := submitted[key.ID]
+		if !ok || key.IsComputed() {
+			continue
+		}
+
+		if key.Type == entity.FieldTypeTable {
+			values[key.Key] = dto.NewTableValue(key.Columns, field.Rows)
+		} else {
+			binding.SetNested(values, key.Key, field.Value)
+		}
+	}
+	env := newExpressionEnv(values, document)
+
+	var fields entity.DocumentFields
+	for i := range keys {
+		key := &keys[i]
+		field, ok := submitted[key.ID]
+
+		switch {
+		case key.IsComputed():
+			if _, err := evaluateComputedField(key, env); err != nil {
+				return nil, err
+			}
+
+			// computed value is evaluated again when rendered, as it may use the register and sign date
+			field = entity.DocumentField{TemplateFieldID: key.ID}
+		case key.Condition != "":
+			required, err := isFieldRequired(key, env)
+			if err != nil {
+				return nil, err
+			}
+
+			if required && !ok {
+				return nil, utils.ErrFieldNotMatch
+			}
+
+			// field whose condition didn't hold is stored empty, the same as when it is cleared by update
+			if !required {
+				field = entity.DocumentField{TemplateFieldID: key.ID}
+			}
+		case !ok:
+			continue
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// hasConditionalField report whether any of the document fields has condition
+func hasConditionalField(fields entity.DocumentFields) bool {
+	for _, field := range fields {
+		if field.TemplateField.Condition != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyUpdateRules evaluate the conditional fields again with the updated values merged into the stored fields.
+// Updated field whose condition didn't hold is rejected, stored field whose condition no longer hold is cleared,
+// and text field that become required must be filled in the same update. Return the updated and cleared fields
+func applyUpdateRules(document *entity.Document, stored entity.DocumentFields, updated entity.DocumentFields) (entity.DocumentFields, error) {
+	changed := make(map[uint]int, len(updated))
+	for i, field := range updated {
+		changed[field.ID] = i
+	}
+
+	fields := make(entity.DocumentFields, len(stored))
+	copy(fields, stored)
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].TemplateFieldID < fields[j].TemplateFieldID
+	})
+
+	values := make(map[string]interface{})
+	for i := range fields {
+		field := &fields[i]
+		if idx, ok := changed[field.ID]; ok {
+			field.Value = updated[idx].Value
+			field.Rows = updated[idx].Rows
+		}
+
+		if field.TemplateField.IsComputed() {
+			continue
+		}
+
+		if field.TemplateField.Type == entity.FieldTypeTable {
+			values[field.TemplateField.Key] = dto.NewTableValue(field.TemplateField.Columns, field.Rows)
+		} else {
+			binding.SetNested(values, field.TemplateField.Key, field.Value)
+		}
+	}
+	env := newExpressionEnv(values, document)
+
+	result := append(entity.DocumentFields{}, updated...)
+	for i := range fields {
+		field := &fields[i]
+		key := &field.TemplateField
+
+		switch {
+		case key.IsComputed():
+			if _, err := evaluateComputedField(key, env); err != nil {
+				return nil, err
+			}
+		case key.Condition != "":
+			required, err := isFieldRequired(key, env)
+			if err != nil {
+				return nil, err
+			}
+
+			if required {
+				if key.Type != entity.FieldTypeTable && field.Value == "" {
+					return nil, utils.ErrFieldNotMatch
+				}
+				continue
+			}
+
+			if field.Value == "" && len(field.Rows) == 0 {
+				continue
+			}
+
+			if _, ok := changed[field.ID]; ok {
+				return nil, utils.ErrFieldNotMatch
+			}
+
+			cleared := entity.DocumentField{Model: field.Model, DocumentID: field.DocumentID, TemplateFieldID: field.TemplateFieldID}
+			if key.Type == entity.FieldTypeTable {
+				cleared.Rows = entity.TableRows{}
+				env[key.Key] = dto.NewTableValue(key.Columns, nil)
+			} else {
+				binding.SetNested(env, key.Key, "")
+			}
+			result = append(result, cleared)
+		}
+	}
+
+	return result, nil
+}
+
+// fillComputedFields evaluate every computed field of the document and put the printable value into the fields map
+func fillComputedFields(document *entity.Document, fieldsMap map[string]interface{}) error {
+	env := newExpressionEnv(fieldsMap, document)
+	for _, field := range document.Fields {
+		if !field.TemplateField.IsComputed() {
+			continue
+		}
+
+		value, err := evaluateComputedField(&field.TemplateField, env)
+		if err != nil {
+			return err
+		}
+
+		binding.SetNested(fieldsMap, field.TemplateField.Key, expression.Display(value))
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package impl
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/suryaadi44/eAD-System/internal/document/dto"
+	"github.com/suryaadi44/eAD-System/internal/document/repository"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+)
+
+// maxExportDocuments is the number of document that can be exported in one request
+const maxExportDocuments = 500
+
+// Output format of the export
+const (
+	exportFormatPDF = "pdf"
+	exportFormatZip = "zip"
+)
+
+// GetExportDocumentIDs find the document selected by the export request. Document requested by id keep the requested
+// order of its first occurrence and must all exist, document found by filter is ordered from the oldest
+func (d *DocumentServiceImpl) GetExportDocumentIDs(ctx context.Context, request *dto.ExportDocumentsRequest) ([]string, error) {
+	requestedIDs := uniqueIDs(request.DocumentIDs)
+	filter := &repository.DocumentFilter{
+		IDs:        requestedIDs,
+		StageID:    request.StageID,
+		TemplateID: request.TemplateID,
+	}
+
+	if request.DateFrom != "" {
+		dateFrom, err := time.ParseInLocation("2006-01-02", request.DateFrom, time.Local)
+		if err != nil {
+			return nil, utils.ErrBadRequestBody
+		}
+		filter.DateFrom = dateFrom
+	}
+	if request.DateTo != "" {
+		dateTo, err := time.ParseInLocation("2006-01-02", request.DateTo, time.Local)
+		if err != nil {
+			return nil, utils.ErrBadRequestBody
+		}
+		// the whole last day is included
+		filter.DateUntil = dateTo.AddDate(0, 0, 1)
+	}
+
+	documentIDs, err := d.documentRepository.GetDocumentIDs(ctx, filter, maxExportDocuments+1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(documentIDs) > maxExportDocuments {
+		return nil, utils.ErrTooManyDocuments
+	}
+
+	if len(requestedIDs) == 0 {
+		return documentIDs, nil
+	}
+
+	if len(documentIDs) != len(requestedIDs) {
+		return nil, utils.ErrDocumentNotFound
+	}
+
+	return requestedIDs, nil
+}
+
+// uniqueIDs remove the repeated id while keeping the order, so the document listed twice is exported once
+func uniqueIDs(ids []string) []string {
+	if len(ids) == 0 {
+		return ids
+	}
+
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return unique
+}
+
+// WriteDocumentExport write the PDF of every document into one merged PDF with a bookmark for each document, or into a
+// zip of the individual PDF. Only one document is held in memory at a time, the rest is written as soon as it's ready
+func (d *DocumentServiceImpl) WriteDocumentExport(ctx context.Context, documentIDs []string, format string, w io.Writer) error {
+	switch format {
+	case "", exportFormatPDF:
+		i := 0
+		return d.pdfMerger.Merge(w, func() (*pdf.MergeDocument, error) {
+			if i == len(documentIDs) {
+				return nil, nil
+			}
+
+			document, content, err := d.exportPDF(ctx, documentIDs[i])
+			if err != nil {
+				return nil, err
+			}
+			i++
+
+			return &pdf.MergeDocument{
+				Title:   exportTitle(document),
+				Content: content,
+			}, nil
+		})
+	case exportFormatZip:
+		archive := zip.NewWriter(w)
+		for i, documentID := range documentIDs {
+			_, content, err := d.exportPDF(ctx, documentID)
+			if err != nil {
+				return err
+			}
+
+			entry, err := archive.Create(fmt.Sprintf("%03d-%s.pdf", i+1, documentID))
+			if err != nil {
+				return err
+			}
+
+			if _, err = entry.Write(content); err != nil {
+				return err
+			}
+		}
+
+		return archive.Close()
+	default:
+		return utils.ErrUnsupportedFormat
+	}
+}
+
+// exportPDF get the stored signed PDF of the document, or render the PDF through the render queue when it has none, so
+// a large export share the render worker with the other request instead of rendering beside them
+func (d *DocumentServiceImpl) exportPDF(ctx context.Context, documentID string) (*entity.Document, []byte, error) {
+	document, err := d.documentRepository.GetDocument(ctx, documentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if servesSignedPDF(document) {
+		content, err := d.getSignedPDF(ctx, document)
+		return document, content, err
+	}
+
+	content, err := d.queuedPDF(ctx, documentID)
+	return document, content, err
+}
+
+// exportTitle is the bookmark title of the document in the merged PDF
+func exportTitle(document *entity.Document) string {
+	parts := make([]string, 0, 3)
+	if document.RegisterID != 0 {
+		parts = append(parts, fmt.Sprintf("No. %d", document.RegisterID))
+	}
+	parts = append(parts, document.Template.Name, document.Applicant.Name)
+
+	return strings.Join(parts, " - ")
+}
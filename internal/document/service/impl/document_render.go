@@ -0,0 +1,56 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/suryaadi44/eAD-System/internal/document/dto"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+	"github.com/suryaadi44/eAD-System/pkg/utils/renderer"
+)
+
+// RenderDocument convert the document into the requested format using the registered renderer. The PDF is only
+// generated when the renderer ask for it, so the other format never wait for the PDF backend. The signed document is
+// converted from the snapshot stored at signing time, so every format show the issued letter
+func (d *DocumentServiceImpl) RenderDocument(ctx context.Context, documentID string, format string) (*dto.RenderedDocument, error) {
+	documentRenderer, err := d.rendererRegistry.Get(format)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := d.documentRepository.GetDocument(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var generatedHTML *bytes.Buffer
+	var pdfOptions *pdf.PDFOptions
+	if servesSignedPDF(document) {
+		generatedHTML, pdfOptions, err = d.getSignedSnapshot(ctx, document)
+	} else {
+		generatedHTML, pdfOptions, err = d.renderHTML(ctx, document)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := documentRenderer.Render(ctx, &renderer.Document{
+		HTML:    generatedHTML,
+		Options: pdfOptions,
+		PDF: func(ctx context.Context) ([]byte, error) {
+			if servesSignedPDF(document) {
+				return d.getSignedPDF(ctx, document)
+			}
+
+			return d.queuedPDF(ctx, document.ID)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.RenderedDocument{
+		Content:     content,
+		ContentType: documentRenderer.ContentType(),
+	}, nil
+}
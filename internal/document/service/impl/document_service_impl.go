@@ -1,35 +1,63 @@
 package impl
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"github.com/suryaadi44/eAD-System/internal/document/service"
 	"github.com/suryaadi44/eAD-System/pkg/utils"
 	"time"
 
+	"github.com/suryaadi44/eAD-System/pkg/utils/binding"
 	"github.com/suryaadi44/eAD-System/pkg/utils/html"
+	"github.com/suryaadi44/eAD-System/pkg/utils/indonesian"
 	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+	"github.com/suryaadi44/eAD-System/pkg/utils/qr"
+	"github.com/suryaadi44/eAD-System/pkg/utils/renderer"
+	"github.com/suryaadi44/eAD-System/pkg/utils/signature"
+	"github.com/suryaadi44/eAD-System/pkg/utils/storage"
 
 	"github.com/google/uuid"
 	"github.com/suryaadi44/eAD-System/internal/document/dto"
 	"github.com/suryaadi44/eAD-System/internal/document/repository"
 	tmpRepo "github.com/suryaadi44/eAD-System/internal/template/repository"
+	userRepo "github.com/suryaadi44/eAD-System/internal/user/repository"
 	"github.com/suryaadi44/eAD-System/pkg/entity"
 )
 
 type DocumentServiceImpl struct {
 	documentRepository repository.DocumentRepository
 	templateRepository tmpRepo.TemplateRepository
+	userRepository     userRepo.UserRepository
 	pdfService         pdf.PDFService
 	renderService      html.RenderService
+	artifactStorage    storage.ArtifactStorage
+	pdfSigner          signature.PDFSigner
+	pdfVerifier        signature.PDFVerifier
+	codeService        qr.CodeService
+	renderQueue        *renderQueue
+	rendererRegistry   renderer.Registry
+	pdfMerger          pdf.PDFMerger
+	pdfCacheTTL        time.Duration
+	renderJobRetention time.Duration
 }
 
-func NewDocumentServiceImpl(documentRepository repository.DocumentRepository, templateRepository tmpRepo.TemplateRepository, pdfgService pdf.PDFService, renderService html.RenderService) service.DocumentService {
+func NewDocumentServiceImpl(documentRepository repository.DocumentRepository, templateRepository tmpRepo.TemplateRepository, userRepository userRepo.UserRepository, pdfgService pdf.PDFService, renderService html.RenderService, artifactStorage storage.ArtifactStorage, pdfSigner signature.PDFSigner, pdfVerifier signature.PDFVerifier, codeService qr.CodeService, rendererRegistry renderer.Registry, pdfMerger pdf.PDFMerger, renderWorkers int, renderQueueSize int, renderWait time.Duration, pdfCacheTTL time.Duration, renderJobRetention time.Duration) service.DocumentService {
 	return &DocumentServiceImpl{
 		documentRepository: documentRepository,
 		templateRepository: templateRepository,
+		userRepository:     userRepository,
 		pdfService:         pdfgService,
 		renderService:      renderService,
+		artifactStorage:    artifactStorage,
+		pdfSigner:          pdfSigner,
+		pdfVerifier:        pdfVerifier,
+		codeService:        codeService,
+		renderQueue:        newRenderQueue(renderWorkers, renderQueueSize, renderWait),
+		rendererRegistry:   rendererRegistry,
+		pdfMerger:          pdfMerger,
+		pdfCacheTTL:        pdfCacheTTL,
+		renderJobRetention: renderJobRetention,
 	}
 }
 
@@ -39,17 +67,38 @@ func (d *DocumentServiceImpl) AddDocument(ctx context.Context, document *dto.Doc
 		return "", err
 	}
 
-	// validate document fields with template fields
+	var boundFields entity.TemplateFields
+	hasRules := false
+
+	// validate document fields with template fields, bound field is filled from the applicant profile instead,
+	// computed field is never submitted and conditional field is checked after every value is known
 	for _, key := range *keyList {
+		if binding.IsApplicantField(key.Key) {
+			boundFields = append(boundFields, key)
+			continue
+		}
+
+		if key.IsComputed() || key.Condition != "" {
+			hasRules = true
+		}
+
+		if key.IsComputed() {
+			continue
+		}
+
 		match := false
 		for _, field := range document.Fields {
 			if key.ID == field.FieldID {
+				if err := validateFieldValue(&key, field.Value, field.Rows); err != nil {
+					return "", err
+				}
+
 				match = true
 				break
 			}
 		}
 
-		if !match {
+		if !match && key.Condition == "" {
 			return "", utils.ErrFieldNotMatch
 		}
 	}
@@ -57,6 +106,20 @@ func (d *DocumentServiceImpl) AddDocument(ctx context.Context, document *dto.Doc
 	var documentEntity = document.ToEntity()
 	documentEntity.ID = uuid.New().String()
 	documentEntity.ApplicantID = userID
+	if len(boundFields) != 0 {
+		documentEntity.Fields, err = d.snapshotApplicantFields(ctx, documentEntity.Fields, boundFields, userID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if hasRules {
+		documentEntity.Fields, err = applyFieldRules(documentEntity, *keyList)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	id, err := d.documentRepository.AddDocument(ctx, documentEntity)
 	if err != nil {
 		return "", err
@@ -65,6 +128,40 @@ func (d *DocumentServiceImpl) AddDocument(ctx context.Context, document *dto.Doc
 	return id, nil
 }
 
+// snapshotApplicantFields replace the bound fields value with the current applicant profile, so later profile
+// changes didn't alter the issued document
+func (d *DocumentServiceImpl) snapshotApplicantFields(ctx context.Context, fields entity.DocumentFields, boundFields entity.TemplateFields, applicantID string) (entity.DocumentFields, error) {
+	applicant, err := d.userRepository.FindByID(ctx, applicantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot entity.DocumentFields
+	for _, field := range fields {
+		bound := false
+		for _, key := range boundFields {
+			if key.ID == field.TemplateFieldID {
+				bound = true
+				break
+			}
+		}
+
+		if !bound {
+			snapshot = append(snapshot, field)
+		}
+	}
+
+	for _, key := range boundFields {
+		value, _ := binding.ResolveApplicant(key.Key, applicant)
+		snapshot = append(snapshot, entity.DocumentField{
+			TemplateFieldID: key.ID,
+			Value:           value,
+		})
+	}
+
+	return snapshot, nil
+}
+
 func (d *DocumentServiceImpl) GetDocument(ctx context.Context, documentID string) (*dto.DocumentResponse, error) {
 	document, err := d.documentRepository.GetDocument(ctx, documentID)
 	if err != nil {
@@ -76,13 +173,13 @@ func (d *DocumentServiceImpl) GetDocument(ctx context.Context, documentID string
 	return documentResponse, nil
 }
 
-func (d *DocumentServiceImpl) GetBriefDocuments(ctx context.Context, applicantID string, role int, page int, limit int) (*dto.BriefDocumentsResponse, error) {
+func (d *DocumentServiceImpl) GetBriefDocuments(ctx context.Context, applicantID string, ownerOnly bool, page int, limit int) (*dto.BriefDocumentsResponse, error) {
 	offset := (page - 1) * limit
 
 	var documents *entity.Documents
 	var err error
 
-	if role == 1 {
+	if ownerOnly {
 		documents, err = d.documentRepository.GetBriefDocumentsByApplicant(ctx, applicantID, limit, offset)
 	} else {
 		documents, err = d.documentRepository.GetBriefDocuments(ctx, limit, offset)
@@ -97,7 +194,9 @@ func (d *DocumentServiceImpl) GetBriefDocuments(ctx context.Context, applicantID
 	return response, nil
 }
 
-func (d *DocumentServiceImpl) GetDocumentStatus(ctx context.Context, documentID string) (*dto.DocumentStatusResponse, error) {
+// GetDocumentStatus return the document status, the token from the QR code is checked against the document when
+// it is given, so a forged QR pointing to a real document is rejected
+func (d *DocumentServiceImpl) GetDocumentStatus(ctx context.Context, documentID string, token string) (*dto.DocumentStatusResponse, error) {
 	document, err := d.documentRepository.GetDocumentStatus(ctx, documentID)
 	if err != nil {
 		return nil, err
@@ -105,37 +204,192 @@ func (d *DocumentServiceImpl) GetDocumentStatus(ctx context.Context, documentID
 
 	var documentStatusResponse = dto.NewDocumentStatusResponse(document)
 
+	if token != "" {
+		if err = d.verifyStatusToken(ctx, documentID, token); err != nil {
+			return nil, err
+		}
+		documentStatusResponse.TokenVerified = true
+	}
+
 	return documentStatusResponse, nil
 }
 
-func (d *DocumentServiceImpl) GeneratePDFDocument(ctx context.Context, documentID string) ([]byte, error) {
+func (d *DocumentServiceImpl) verifyStatusToken(ctx context.Context, documentID string, token string) error {
+	payload, err := d.codeService.ParseToken(token)
+	if err != nil {
+		return err
+	}
+
+	if payload.DocumentID != documentID {
+		return utils.ErrInvalidQRToken
+	}
+
 	document, err := d.documentRepository.GetDocument(ctx, documentID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	if document.SignedAt.IsZero() || payload.SignedAt != document.SignedAt.Unix() ||
+		payload.RegisterID != document.RegisterID || payload.Hash != qr.DocumentHash(document) {
+		return utils.ErrInvalidQRToken
 	}
 
-	fieldsMap, err := d.fillMapFields(document)
+	return nil
+}
+
+// GetQRPublicKey return the public key that verify the QR token
+func (d *DocumentServiceImpl) GetQRPublicKey() *qr.PublicKey {
+	return d.codeService.PublicKey()
+}
+
+// servesSignedPDF tell whether the PDF stored at signing time is served for the document. The revoked letter is
+// rendered again with the watermark, the stored one would still look valid when printed
+func servesSignedPDF(document *entity.Document) bool {
+	return document.SignedPDFKey != "" && document.RevokedAt.IsZero()
+}
+
+func (d *DocumentServiceImpl) renderPDF(ctx context.Context, document *entity.Document, useCache bool) ([]byte, string, error) {
+	generatedHTML, pdfOptions, err := d.renderHTML(ctx, document)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	generatedHTML, err := d.renderService.GenerateHTMLDocument(&document.Template, fieldsMap)
+	return d.generatePDF(ctx, document.ID, generatedHTML, pdfOptions, useCache)
+}
+
+// generatePDF convert the rendered html into PDF, the cached render is used when the same html was already converted
+func (d *DocumentServiceImpl) generatePDF(ctx context.Context, documentID string, generatedHTML *bytes.Buffer, pdfOptions *pdf.PDFOptions, useCache bool) ([]byte, string, error) {
+	if !useCache {
+		generatedPDF, err := d.pdfService.GeneratePDF(ctx, generatedHTML, pdfOptions)
+		return generatedPDF, "", err
+	}
+
+	cacheKey, err := pdfCacheKey(documentID, generatedHTML, pdfOptions)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	generatedPDF, err := d.pdfService.GeneratePDF(generatedHTML, document.Template.MarginTop, document.Template.MarginBottom, document.Template.MarginLeft, document.Template.MarginRight)
+	cachedPDF, err := d.artifactStorage.Get(ctx, cacheKey)
+	if err == nil {
+		return cachedPDF, cacheKey, nil
+	} else if err != utils.ErrArtifactNotFound {
+		return nil, "", err
+	}
+
+	generatedPDF, err := d.pdfService.GeneratePDF(ctx, generatedHTML, pdfOptions)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	if err = d.artifactStorage.Put(ctx, cacheKey, generatedPDF); err != nil {
+		return nil, "", err
 	}
 
-	return generatedPDF, nil
+	return generatedPDF, cacheKey, nil
 }
 
-func (d *DocumentServiceImpl) fillMapFields(document *entity.Document) (*map[string]interface{}, error) {
+// renderHTML fill the template of the document and return it with the page setup, running header and footer, and
+// watermark, it is the input of every output format
+func (d *DocumentServiceImpl) renderHTML(ctx context.Context, document *entity.Document) (*bytes.Buffer, *pdf.PDFOptions, error) {
+	fieldsMap, err := d.fillMapFields(ctx, document)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pdfOptions := newPDFOptions(&document.Template)
+	pdfOptions.Watermark = newWatermark(document)
+	if pdfOptions.HeaderHTML, err = d.renderService.GenerateHeader(&document.Template, fieldsMap); err != nil {
+		return nil, nil, err
+	}
+
+	if pdfOptions.FooterHTML, err = d.renderService.GeneratePageFooter(&document.Template, fieldsMap); err != nil {
+		return nil, nil, err
+	}
+
+	// the QR already printed on every page by the running footer
+	if document.Template.QROnEveryPage {
+		(*fieldsMap)["footer"] = ""
+	}
+
+	generatedHTML, err := d.renderService.GenerateHTMLDocument(&document.Template, fieldsMap)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return generatedHTML, pdfOptions, nil
+}
+
+func newPDFOptions(template *entity.Template) *pdf.PDFOptions {
+	return &pdf.PDFOptions{
+		PageSize:     template.PageSize,
+		PageWidth:    template.PageWidth,
+		PageHeight:   template.PageHeight,
+		Orientation:  template.Orientation,
+		DPI:          template.DPI,
+		Grayscale:    template.Grayscale,
+		Zoom:         template.Zoom,
+		MarginTop:    template.MarginTop,
+		MarginBottom: template.MarginBottom,
+		MarginLeft:   template.MarginLeft,
+		MarginRight:  template.MarginRight,
+	}
+}
+
+// Default watermark text of the document state
+const (
+	watermarkDraft    = "DRAFT"
+	watermarkUnsigned = "BELUM DITANDATANGANI"
+	watermarkRevoked  = "DICABUT"
+)
+
+// newWatermark return the watermark of the document state, the text set by the template replace the default one.
+// Signed document that is not revoked has no watermark
+func newWatermark(document *entity.Document) *pdf.Watermark {
+	template := &document.Template
+
+	var text string
+	switch {
+	case !document.RevokedAt.IsZero():
+		text = defaultString(template.WatermarkRevoked, watermarkRevoked)
+	case document.StageID < 2:
+		text = defaultString(template.WatermarkDraft, watermarkDraft)
+	case document.StageID < 3:
+		text = defaultString(template.WatermarkUnsigned, watermarkUnsigned)
+	default:
+		return nil
+	}
+
+	watermark := &pdf.Watermark{
+		Text:    text,
+		Opacity: template.WatermarkOpacity,
+		Angle:   pdf.DefaultWatermarkAngle,
+	}
+	if watermark.Opacity <= 0 {
+		watermark.Opacity = pdf.DefaultWatermarkOpacity
+	}
+	if template.WatermarkAngle != nil {
+		watermark.Angle = float64(*template.WatermarkAngle)
+	}
+
+	return watermark
+}
+
+func defaultString(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+func (d *DocumentServiceImpl) fillMapFields(ctx context.Context, document *entity.Document) (*map[string]interface{}, error) {
 	fieldsMap := dto.NewFieldsMapResponse(&document.Fields)
 	fieldsMap["register"] = document.RegisterID
 
+	if err := fillComputedFields(document, fieldsMap); err != nil {
+		return nil, err
+	}
+
 	if document.SignedAt.IsZero() {
 		fieldsMap["signedDate"] = ""
 		fieldsMap["signature"] = ""
@@ -143,7 +397,7 @@ func (d *DocumentServiceImpl) fillMapFields(document *entity.Document) (*map[str
 		return &fieldsMap, nil
 	}
 
-	signature, err := d.renderService.GenerateSignature(document.Signer)
+	signature, err := d.renderService.GenerateSignature(ctx, document.Signer)
 	if err != nil {
 		return nil, err
 	}
@@ -155,7 +409,7 @@ func (d *DocumentServiceImpl) fillMapFields(document *entity.Document) (*map[str
 	}
 	fieldsMap["footer"] = footer
 
-	fieldsMap["signedDate"] = document.SignedAt.Format("02 January 2006")
+	fieldsMap["signedDate"] = indonesian.FormatDate(document.SignedAt)
 
 	return &fieldsMap, nil
 }
@@ -224,17 +478,103 @@ func (d *DocumentServiceImpl) SignDocument(ctx context.Context, documentID strin
 		return utils.ErrNotVerifiedYet
 	}
 
+	document, err := d.documentRepository.GetDocument(ctx, documentID)
+	if err != nil {
+		return err
+	}
+
+	signer, err := d.userRepository.FindByID(ctx, signerID)
+	if err != nil {
+		return err
+	}
+
 	var documentEntity = entity.Document{}
 	documentEntity.ID = documentID
 	documentEntity.SignerID = signerID
-	documentEntity.SignedAt = time.Now()
+	// the signing time is printed in the QR token with second precision, so keep it the same as the stored one
+	documentEntity.SignedAt = time.Now().Truncate(time.Second)
 	documentEntity.StageID = 3
 
-	return d.documentRepository.SignDocument(ctx, &documentEntity)
+	// render the signed document once, so later template or signer profile change didn't alter the issued letter
+	document.Signer = *signer
+	document.SignerID = documentEntity.SignerID
+	document.SignedAt = documentEntity.SignedAt
+	document.StageID = documentEntity.StageID
+
+	generatedHTML, pdfOptions, err := d.renderHTML(ctx, document)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := newSignedSnapshot(generatedHTML, pdfOptions)
+	if err != nil {
+		return err
+	}
+
+	renderedPDF, _, err := d.generatePDF(ctx, documentID, generatedHTML, pdfOptions, false)
+	if err != nil {
+		return err
+	}
+
+	signedPDF, err := d.pdfSigner.SignPDF(ctx, renderedPDF, &signature.SignOptions{
+		SignerID:    signerID,
+		Name:        signer.Name,
+		Reason:      document.Template.Name,
+		SigningTime: documentEntity.SignedAt,
+		Page:        document.Template.SignaturePage,
+		X:           document.Template.SignatureX,
+		Y:           document.Template.SignatureY,
+		Width:       document.Template.SignatureWidth,
+		Height:      document.Template.SignatureHeight,
+	})
+	if err != nil {
+		return err
+	}
+
+	documentEntity.SignedPDFKey = signedPDFKey(documentID)
+	documentEntity.SignedPDFHash = hashPDF(signedPDF)
+	if err = d.artifactStorage.Put(ctx, documentEntity.SignedPDFKey, signedPDF); err != nil {
+		return err
+	}
+
+	if err = d.artifactStorage.Put(ctx, signedSnapshotKey(documentEntity.SignedPDFKey), snapshot); err != nil {
+		d.deleteSignedArtifacts(ctx, documentEntity.SignedPDFKey)
+		return err
+	}
+
+	if err = d.documentRepository.SignDocument(ctx, &documentEntity); err != nil {
+		// the document was not signed with this file, eg: signed by another request first
+		d.deleteSignedArtifacts(ctx, documentEntity.SignedPDFKey)
+		return err
+	}
+
+	return d.invalidatePDFCache(ctx, documentID)
+}
+
+// RevokeDocument withdraw the signed document, the stored PDF is kept so the revoked letter can still be recognized
+func (d *DocumentServiceImpl) RevokeDocument(ctx context.Context, documentID string, revokeRequest *dto.RevokeDocumentRequest) error {
+	document, err := d.documentRepository.GetDocumentStatus(ctx, documentID)
+	if err != nil {
+		return err
+	}
+
+	if document.StageID < 3 {
+		return utils.ErrNotSignedYet
+	}
+
+	if !document.RevokedAt.IsZero() {
+		return utils.ErrAlreadyRevoked
+	}
+
+	documentEntity := revokeRequest.ToEntity()
+	documentEntity.ID = documentID
+	documentEntity.RevokedAt = time.Now()
+
+	return d.documentRepository.RevokeDocument(ctx, documentEntity)
 }
 
-func (d *DocumentServiceImpl) DeleteDocument(ctx context.Context, userID string, role int, documentID string) error {
-	if role == 1 {
+func (d *DocumentServiceImpl) DeleteDocument(ctx context.Context, userID string, ownerOnly bool, documentID string) error {
+	if ownerOnly {
 		applicantID, err := d.documentRepository.GetApplicantID(ctx, documentID)
 		if err != nil {
 			return err
@@ -254,7 +594,11 @@ func (d *DocumentServiceImpl) DeleteDocument(ctx context.Context, userID string,
 		return utils.ErrAlreadySigned
 	}
 
-	return d.documentRepository.DeleteDocument(ctx, documentID)
+	if err = d.documentRepository.DeleteDocument(ctx, documentID); err != nil {
+		return err
+	}
+
+	return d.invalidatePDFCache(ctx, documentID)
 }
 
 func (d *DocumentServiceImpl) UpdateDocument(ctx context.Context, document *dto.DocumentUpdateRequest, documentID string) error {
@@ -274,11 +618,15 @@ func (d *DocumentServiceImpl) UpdateDocument(ctx context.Context, document *dto.
 	documentEntity := document.ToEntity()
 	documentEntity.ID = documentID
 
-	return d.documentRepository.UpdateDocument(ctx, documentEntity)
+	if err = d.documentRepository.UpdateDocument(ctx, documentEntity); err != nil {
+		return err
+	}
+
+	return d.invalidatePDFCache(ctx, documentID)
 }
 
-func (d *DocumentServiceImpl) UpdateDocumentFields(ctx context.Context, userID string, role int, documentID string, fields *dto.FieldsUpdateRequest) error {
-	if role == 1 {
+func (d *DocumentServiceImpl) UpdateDocumentFields(ctx context.Context, userID string, ownerOnly bool, documentID string, fields *dto.FieldsUpdateRequest) error {
+	if ownerOnly {
 		applicantID, err := d.documentRepository.GetApplicantID(ctx, documentID)
 		if err != nil {
 			return err
@@ -302,6 +650,58 @@ func (d *DocumentServiceImpl) UpdateDocumentFields(ctx context.Context, userID s
 		return utils.ErrAlreadySigned
 	}
 
+	documentFields, err := d.documentRepository.GetDocumentFields(ctx, documentID)
+	if err != nil {
+		return err
+	}
+
 	fieldsEntity := fields.ToEntity(documentID)
-	return d.documentRepository.UpdateDocumentFields(ctx, fieldsEntity)
+	for idx, field := range *fieldsEntity {
+		var current *entity.DocumentField
+		for i := range *documentFields {
+			if (*documentFields)[i].ID == field.ID {
+				current = &(*documentFields)[i]
+				break
+			}
+		}
+
+		if current == nil {
+			return utils.ErrFieldNotFound
+		}
+
+		// bound field keep the profile snapshot taken when the document is created
+		if binding.IsApplicantField(current.TemplateField.Key) {
+			return utils.ErrApplicantFieldReadOnly
+		}
+
+		if err := validateFieldValue(&current.TemplateField, field.Value, field.Rows); err != nil {
+			return err
+		}
+
+		// rows are replaced as a whole, so row can be added, removed, and reordered. Empty rows is kept
+		// non nil so it is still written
+		if current.TemplateField.Type == entity.FieldTypeTable && field.Rows == nil {
+			(*fieldsEntity)[idx].Rows = entity.TableRows{}
+		}
+	}
+
+	// conditional field is checked again since the update may change the value its condition use
+	if hasConditionalField(*documentFields) {
+		document, err := d.documentRepository.GetBriefDocument(ctx, documentID)
+		if err != nil {
+			return err
+		}
+
+		updated, err := applyUpdateRules(document, *documentFields, *fieldsEntity)
+		if err != nil {
+			return err
+		}
+		fieldsEntity = &updated
+	}
+
+	if err = d.documentRepository.UpdateDocumentFields(ctx, fieldsEntity); err != nil {
+		return err
+	}
+
+	return d.invalidatePDFCache(ctx, documentID)
 }
@@ -1,15 +1,30 @@
 package impl
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"github.com/suryaadi44/eAD-System/internal/document/repository"
 	mockDocumentRepoPkg "github.com/suryaadi44/eAD-System/internal/document/repository/mock"
 	mockTemplateRepoPkg "github.com/suryaadi44/eAD-System/internal/template/repository/mock"
+	mockUserRepoPkg "github.com/suryaadi44/eAD-System/internal/user/repository/mock"
 	"github.com/suryaadi44/eAD-System/pkg/utils"
 	mockHtmlService "github.com/suryaadi44/eAD-System/pkg/utils/html/mock"
+	"github.com/suryaadi44/eAD-System/pkg/utils/indonesian"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
 	mockPdfServicePkg "github.com/suryaadi44/eAD-System/pkg/utils/pdf/mock"
+	"github.com/suryaadi44/eAD-System/pkg/utils/qr"
+	mockQrPkg "github.com/suryaadi44/eAD-System/pkg/utils/qr/mock"
+	"github.com/suryaadi44/eAD-System/pkg/utils/renderer"
+	mockRendererPkg "github.com/suryaadi44/eAD-System/pkg/utils/renderer/mock"
+	"github.com/suryaadi44/eAD-System/pkg/utils/signature"
+	mockSignaturePkg "github.com/suryaadi44/eAD-System/pkg/utils/signature/mock"
+	mockStoragePkg "github.com/suryaadi44/eAD-System/pkg/utils/storage/mock"
 	"html/template"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,21 +42,43 @@ type TestSuiteDocumentService struct {
 	suite.Suite
 	mockDocumentRepository *mockDocumentRepoPkg.MockDocumentRepository
 	mockTemplateRepository *mockTemplateRepoPkg.MockTemplateRepository
+	mockUserRepository     *mockUserRepoPkg.MockUserRepository
 	mockPDFService         *mockPdfServicePkg.MockPDFService
 	mockRenderService      *mockHtmlService.MockRenderService
+	mockArtifactStorage    *mockStoragePkg.MockArtifactStorage
+	mockPDFSigner          *mockSignaturePkg.MockPDFSigner
+	mockPDFVerifier        *mockSignaturePkg.MockPDFVerifier
+	mockCodeService        *mockQrPkg.MockCodeService
+	mockRendererRegistry   *mockRendererPkg.MockRegistry
+	mockPDFMerger          *mockPdfServicePkg.MockPDFMerger
 	documentService        *DocumentServiceImpl
 }
 
 func (s *TestSuiteDocumentService) SetupTest() {
 	s.mockDocumentRepository = new(mockDocumentRepoPkg.MockDocumentRepository)
 	s.mockTemplateRepository = new(mockTemplateRepoPkg.MockTemplateRepository)
+	s.mockUserRepository = new(mockUserRepoPkg.MockUserRepository)
 	s.mockPDFService = new(mockPdfServicePkg.MockPDFService)
 	s.mockRenderService = new(mockHtmlService.MockRenderService)
+	s.mockArtifactStorage = new(mockStoragePkg.MockArtifactStorage)
+	s.mockPDFSigner = new(mockSignaturePkg.MockPDFSigner)
+	s.mockPDFVerifier = new(mockSignaturePkg.MockPDFVerifier)
+	s.mockCodeService = new(mockQrPkg.MockCodeService)
+	s.mockRendererRegistry = new(mockRendererPkg.MockRegistry)
+	s.mockPDFMerger = new(mockPdfServicePkg.MockPDFMerger)
 	s.documentService = &DocumentServiceImpl{
 		documentRepository: s.mockDocumentRepository,
 		templateRepository: s.mockTemplateRepository,
+		userRepository:     s.mockUserRepository,
 		pdfService:         s.mockPDFService,
 		renderService:      s.mockRenderService,
+		artifactStorage:    s.mockArtifactStorage,
+		pdfSigner:          s.mockPDFSigner,
+		pdfVerifier:        s.mockPDFVerifier,
+		codeService:        s.mockCodeService,
+		renderQueue:        newRenderQueue(1, 1, time.Second),
+		rendererRegistry:   s.mockRendererRegistry,
+		pdfMerger:          s.mockPDFMerger,
 	}
 }
 
@@ -49,11 +86,17 @@ func (s *TestSuiteDocumentService) TearDownTest() {
 	s.mockDocumentRepository = nil
 	s.mockPDFService = nil
 	s.mockRenderService = nil
+	s.mockArtifactStorage = nil
+	s.mockPDFSigner = nil
+	s.mockPDFVerifier = nil
+	s.mockCodeService = nil
+	s.mockRendererRegistry = nil
+	s.mockPDFMerger = nil
 	s.documentService = nil
 }
 
 func (s *TestSuiteDocumentService) TestNewDocumentServiceImpl() {
-	s.NotNil(NewDocumentServiceImpl(s.mockDocumentRepository, s.mockTemplateRepository, s.mockPDFService, s.mockRenderService))
+	s.NotNil(NewDocumentServiceImpl(s.mockDocumentRepository, s.mockTemplateRepository, s.mockUserRepository, s.mockPDFService, s.mockRenderService, s.mockArtifactStorage, s.mockPDFSigner, s.mockPDFVerifier, s.mockCodeService, s.mockRendererRegistry, s.mockPDFMerger, 2, 32, time.Second, time.Hour, time.Hour))
 }
 
 func (s *TestSuiteDocumentService) TestAddDocument_Success() {
@@ -83,6 +126,199 @@ func (s *TestSuiteDocumentService) TestAddDocument_Success() {
 	s.Equal(id, "123")
 }
 
+func (s *TestSuiteDocumentService) TestAddDocument_SuccessWithApplicantField() {
+	doc := &dto.DocumentRequest{
+		TemplateID: 1,
+		Fields: dto.FieldsRequest{
+			{
+				FieldID: 1,
+				Value:   "value1",
+			},
+			{
+				FieldID: 2,
+				Value:   "forged name",
+			},
+		},
+	}
+
+	s.mockTemplateRepository.On("GetTemplateFields", mock.Anything, uint(1)).Return(&entity.TemplateFields{
+		{
+			Model: gorm.Model{
+				ID: 1,
+			},
+			TemplateID: 1,
+			Key:        "field1",
+		},
+		{
+			Model: gorm.Model{
+				ID: 2,
+			},
+			TemplateID: 1,
+			Key:        "applicant.name",
+		},
+		{
+			Model: gorm.Model{
+				ID: 3,
+			},
+			TemplateID: 1,
+			Key:        "applicant.nik",
+		},
+	}, nil)
+	s.mockUserRepository.On("FindByID", mock.Anything, "123").Return(&entity.User{
+		ID:   "123",
+		Name: "Budi",
+		NIK:  "3201234567890001",
+	}, nil)
+	s.mockDocumentRepository.On("AddDocument", mock.Anything, mock.MatchedBy(func(document *entity.Document) bool {
+		return s.Equal(entity.DocumentFields{
+			{TemplateFieldID: 1, Value: "value1"},
+			{TemplateFieldID: 2, Value: "Budi"},
+			{TemplateFieldID: 3, Value: "3201234567890001"},
+		}, document.Fields)
+	})).Return("123", nil)
+
+	id, err := s.documentService.AddDocument(context.Background(), doc, "123")
+	s.NoError(err)
+	s.Equal(id, "123")
+}
+
+func (s *TestSuiteDocumentService) TestAddDocument_ErrorApplicantNotFound() {
+	doc := &dto.DocumentRequest{
+		TemplateID: 1,
+	}
+
+	s.mockTemplateRepository.On("GetTemplateFields", mock.Anything, uint(1)).Return(&entity.TemplateFields{
+		{
+			Model: gorm.Model{
+				ID: 1,
+			},
+			TemplateID: 1,
+			Key:        "applicant.name",
+		},
+	}, nil)
+	s.mockUserRepository.On("FindByID", mock.Anything, "123").Return((*entity.User)(nil), utils.ErrUserNotFound)
+
+	id, err := s.documentService.AddDocument(context.Background(), doc, "123")
+	s.Equal(utils.ErrUserNotFound, err)
+	s.Equal(id, "")
+}
+
+func (s *TestSuiteDocumentService) TestAddDocument_ErrorInvalidTableRow() {
+	doc := &dto.DocumentRequest{
+		TemplateID: 1,
+		Fields: dto.FieldsRequest{
+			{
+				FieldID: 1,
+				Rows:    entity.TableRows{{"name": "Budi", "birth_date": "kemarin"}},
+			},
+		},
+	}
+
+	s.mockTemplateRepository.On("GetTemplateFields", mock.Anything, uint(1)).Return(&entity.TemplateFields{
+		{
+			Model: gorm.Model{
+				ID: 1,
+			},
+			TemplateID: 1,
+			Key:        "family",
+			Type:       entity.FieldTypeTable,
+			Columns: entity.TableColumns{
+				{Key: "name", Type: entity.ColumnTypeText},
+				{Key: "birth_date", Type: entity.ColumnTypeDate},
+			},
+		},
+	}, nil)
+
+	id, err := s.documentService.AddDocument(context.Background(), doc, "123")
+	s.Equal(utils.ErrInvalidTableRow, err)
+	s.Equal(id, "")
+	s.mockDocumentRepository.AssertNotCalled(s.T(), "AddDocument", mock.Anything, mock.Anything)
+}
+
+func (s *TestSuiteDocumentService) TestAddDocument_SuccessWithFieldRules() {
+	for _, tc := range []struct {
+		Name     string
+		Fields   dto.FieldsRequest
+		Expected entity.DocumentFields
+		Err      error
+	}{
+		{
+			Name: "Condition not met",
+			Fields: dto.FieldsRequest{
+				{FieldID: 1, Value: "Belum Kawin"},
+				{FieldID: 2, Value: "forged"},
+			},
+			Expected: entity.DocumentFields{
+				{TemplateFieldID: 1, Value: "Belum Kawin"},
+				{TemplateFieldID: 2},
+				{TemplateFieldID: 3},
+			},
+		},
+		{
+			Name: "Condition not met with value",
+			Fields: dto.FieldsRequest{
+				{FieldID: 1, Value: "Belum Kawin"},
+				{FieldID: 3, Value: "Ani"},
+			},
+			Expected: entity.DocumentFields{
+				{TemplateFieldID: 1, Value: "Belum Kawin"},
+				{TemplateFieldID: 2},
+				{TemplateFieldID: 3},
+			},
+		},
+		{
+			Name: "Condition met",
+			Fields: dto.FieldsRequest{
+				{FieldID: 1, Value: "Kawin"},
+				{FieldID: 3, Value: "Ani"},
+			},
+			Expected: entity.DocumentFields{
+				{TemplateFieldID: 1, Value: "Kawin"},
+				{TemplateFieldID: 2},
+				{TemplateFieldID: 3, Value: "Ani"},
+			},
+		},
+		{
+			Name: "Condition met without value",
+			Fields: dto.FieldsRequest{
+				{FieldID: 1, Value: "Kawin"},
+			},
+			Err: utils.ErrFieldNotMatch,
+		},
+		{
+			Name: "Expression can't be evaluated",
+			Fields: dto.FieldsRequest{
+				{FieldID: 1, Value: "Cerai"},
+				{FieldID: 3, Value: "Ani"},
+			},
+			Err: utils.ErrExpressionEvaluation,
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+			doc := &dto.DocumentRequest{
+				TemplateID: 1,
+				Fields:     tc.Fields,
+			}
+
+			s.mockTemplateRepository.On("GetTemplateFields", mock.Anything, uint(1)).Return(&entity.TemplateFields{
+				{Model: gorm.Model{ID: 1}, TemplateID: 1, Key: "marital_status"},
+				{Model: gorm.Model{ID: 2}, TemplateID: 1, Key: "status", Expression: `cond(marital_status == "Cerai", 1 / 0, upper(marital_status))`},
+				{Model: gorm.Model{ID: 3}, TemplateID: 1, Key: "spouse_name", Condition: `status == "KAWIN"`},
+			}, nil)
+			s.mockDocumentRepository.On("AddDocument", mock.Anything, mock.MatchedBy(func(document *entity.Document) bool {
+				return s.Equal(tc.Expected, document.Fields)
+			})).Return("123", nil)
+
+			_, err := s.documentService.AddDocument(context.Background(), doc, "123")
+			s.Equal(tc.Err, err)
+			if tc.Err != nil {
+				s.mockDocumentRepository.AssertNotCalled(s.T(), "AddDocument", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
 func (s *TestSuiteDocumentService) TestAddDocument_ErrorNoTemplate() {
 	doc := &dto.DocumentRequest{
 		TemplateID: 1,
@@ -280,7 +516,7 @@ func (s *TestSuiteDocumentService) TestGetBriefDocuments_Success() {
 		},
 	}
 
-	docs, err := s.documentService.GetBriefDocuments(context.Background(), "1", 3, 0, 0)
+	docs, err := s.documentService.GetBriefDocuments(context.Background(), "1", false, 0, 0)
 	s.NoError(err)
 	s.Equal(expectedReturn, docs)
 }
@@ -322,7 +558,7 @@ func (s *TestSuiteDocumentService) TestGetBriefDocuments_SuccessWithUserRole() {
 		},
 	}
 
-	docs, err := s.documentService.GetBriefDocuments(context.Background(), "1", 1, 0, 0)
+	docs, err := s.documentService.GetBriefDocuments(context.Background(), "1", true, 0, 0)
 	s.NoError(err)
 	s.Equal(expectedReturn, docs)
 }
@@ -330,7 +566,7 @@ func (s *TestSuiteDocumentService) TestGetBriefDocuments_SuccessWithUserRole() {
 func (s *TestSuiteDocumentService) TestGetBriefDocuments_ErrorRepository() {
 	s.mockDocumentRepository.On("GetBriefDocuments", mock.Anything, mock.Anything, mock.Anything).Return(&entity.Documents{}, errors.New("error"))
 
-	docs, err := s.documentService.GetBriefDocuments(context.Background(), "1", 3, 0, 0)
+	docs, err := s.documentService.GetBriefDocuments(context.Background(), "1", false, 0, 0)
 	s.Equal(err, errors.New("error"))
 	s.Nil(docs)
 }
@@ -381,7 +617,7 @@ func (s *TestSuiteDocumentService) TestGetDocumentStatus_Success() {
 
 	expectedReturn := &dto.DocumentStatusResponse{
 		ID:          "1",
-		Description: "",
+		Description: "Test Template",
 		RegisterID:  123,
 		Stage:       "",
 		Verifier:    userDto.EmployeeResponse{},
@@ -392,7 +628,7 @@ func (s *TestSuiteDocumentService) TestGetDocumentStatus_Success() {
 		UpdatedAt:   time.Time{},
 	}
 
-	doc, err := s.documentService.GetDocumentStatus(context.Background(), "1")
+	doc, err := s.documentService.GetDocumentStatus(context.Background(), "1", "")
 	s.NoError(err)
 	s.Equal(expectedReturn, doc)
 }
@@ -400,12 +636,159 @@ func (s *TestSuiteDocumentService) TestGetDocumentStatus_Success() {
 func (s *TestSuiteDocumentService) TestGetDocumentStatus_ErrorRepository() {
 	s.mockDocumentRepository.On("GetDocumentStatus", mock.Anything, mock.Anything).Return(&entity.Document{}, errors.New("error"))
 
-	doc, err := s.documentService.GetDocumentStatus(context.Background(), "1")
+	doc, err := s.documentService.GetDocumentStatus(context.Background(), "1", "")
 	s.Equal(err, errors.New("error"))
 	s.Nil(doc)
 }
 
-func (s *TestSuiteDocumentService) TestGeneratePDFDocument_Success() {
+func (s *TestSuiteDocumentService) TestGetDocumentStatus_Token() {
+	signedAt := time.Date(2022, 10, 10, 9, 30, 0, 0, time.UTC)
+	document := &entity.Document{
+		ID:         "1",
+		RegisterID: 123,
+		TemplateID: 1,
+		Fields:     entity.DocumentFields{{TemplateFieldID: 1, Value: "value1"}},
+		StageID:    3,
+		SignedAt:   signedAt,
+	}
+	validPayload := &qr.Payload{DocumentID: "1", RegisterID: 123, Hash: qr.DocumentHash(document), SignedAt: signedAt.Unix()}
+
+	for _, tc := range []struct {
+		Name          string
+		Payload       *qr.Payload
+		ParseErr      error
+		DocumentErr   error
+		ExpectedErr   error
+		ExpectedValid bool
+	}{
+		{
+			Name:          "Success with valid token",
+			Payload:       validPayload,
+			ExpectedValid: true,
+		},
+		{
+			Name:        "Error token not signed by the office key",
+			ParseErr:    utils.ErrInvalidQRToken,
+			ExpectedErr: utils.ErrInvalidQRToken,
+		},
+		{
+			Name:        "Error token for another document",
+			Payload:     &qr.Payload{DocumentID: "2", RegisterID: 123, Hash: validPayload.Hash, SignedAt: signedAt.Unix()},
+			ExpectedErr: utils.ErrInvalidQRToken,
+		},
+		{
+			Name:        "Error token with different register",
+			Payload:     &qr.Payload{DocumentID: "1", RegisterID: 124, Hash: validPayload.Hash, SignedAt: signedAt.Unix()},
+			ExpectedErr: utils.ErrInvalidQRToken,
+		},
+		{
+			Name:        "Error token with different content hash",
+			Payload:     &qr.Payload{DocumentID: "1", RegisterID: 123, Hash: "hash", SignedAt: signedAt.Unix()},
+			ExpectedErr: utils.ErrInvalidQRToken,
+		},
+		{
+			Name:        "Error token with different signing date",
+			Payload:     &qr.Payload{DocumentID: "1", RegisterID: 123, Hash: validPayload.Hash, SignedAt: signedAt.Unix() + 1},
+			ExpectedErr: utils.ErrInvalidQRToken,
+		},
+		{
+			Name:        "Error getting document",
+			Payload:     validPayload,
+			DocumentErr: errors.New("error"),
+			ExpectedErr: errors.New("error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			s.mockDocumentRepository.On("GetDocumentStatus", mock.Anything, "1").Return(&entity.Document{ID: "1", RegisterID: 123, SignedAt: signedAt}, nil)
+			s.mockCodeService.On("ParseToken", "token").Return(tc.Payload, tc.ParseErr)
+			s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(document, tc.DocumentErr)
+
+			status, err := s.documentService.GetDocumentStatus(context.Background(), "1", "token")
+
+			s.Equal(tc.ExpectedErr, err)
+			if tc.ExpectedValid {
+				s.True(status.TokenVerified)
+			} else {
+				s.Nil(status)
+			}
+		})
+		s.TearDownTest()
+	}
+}
+
+func (s *TestSuiteDocumentService) TestGetQRPublicKey() {
+	key := &qr.PublicKey{KeyType: "OKP", Curve: "Ed25519", X: "x", KeyID: "kid", Algorithm: "EdDSA", Use: "sig"}
+	s.mockCodeService.On("PublicKey").Return(key)
+
+	s.Equal(key, s.documentService.GetQRPublicKey())
+}
+
+func (s *TestSuiteDocumentService) TestGenerateStatusPage() {
+	for _, tc := range []struct {
+		Name          string
+		Status        *dto.DocumentStatusResponse
+		StatusErr     error
+		ExpectedState string
+		ExpectedErr   error
+	}{
+		{
+			Name:          "Success with signed document",
+			Status:        &dto.DocumentStatusResponse{ID: "1", SignedAt: time.Now(), TokenVerified: true},
+			ExpectedState: "valid",
+		},
+		{
+			Name:          "Success with signed document without token",
+			Status:        &dto.DocumentStatusResponse{ID: "1", SignedAt: time.Now()},
+			ExpectedState: "unverified",
+		},
+		{
+			Name:          "Success with revoked document",
+			Status:        &dto.DocumentStatusResponse{ID: "1", SignedAt: time.Now(), Revoked: true},
+			ExpectedState: "revoked",
+		},
+		{
+			Name:          "Success with unsigned document",
+			Status:        &dto.DocumentStatusResponse{ID: "1"},
+			ExpectedState: "unsigned",
+		},
+		{
+			Name:          "Success with document not found",
+			StatusErr:     utils.ErrDocumentNotFound,
+			ExpectedState: "notfound",
+		},
+		{
+			Name:          "Success with invalid qr token",
+			StatusErr:     utils.ErrInvalidQRToken,
+			ExpectedState: "invalid",
+		},
+		{
+			Name:        "Error generic error",
+			StatusErr:   errors.New("generic error"),
+			ExpectedErr: errors.New("generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			s.mockRenderService.On("GenerateStatusPage", mock.MatchedBy(func(data *map[string]interface{}) bool {
+				return (*data)["state"] == tc.ExpectedState && (*data)["title"] == statusPageMessages[tc.ExpectedState][0]
+			})).Return(bytes.NewBufferString("page"), nil)
+
+			page, err := s.documentService.GenerateStatusPage(tc.Status, tc.StatusErr)
+
+			if tc.ExpectedErr != nil {
+				s.Equal(tc.ExpectedErr, err)
+				s.mockRenderService.AssertNotCalled(s.T(), "GenerateStatusPage", mock.Anything)
+			} else {
+				s.NoError(err)
+				s.Equal("page", page.String())
+			}
+		})
+		s.TearDownTest()
+	}
+}
+
+func (s *TestSuiteDocumentService) TestRenderJobPDF_Success() {
 	s.mockDocumentRepository.On("GetDocument", mock.Anything, mock.Anything).Return(&entity.Document{
 		ID:          "1",
 		RegisterID:  123,
@@ -452,23 +835,519 @@ func (s *TestSuiteDocumentService) TestGeneratePDFDocument_Success() {
 	html := `<!DOCTYPE html>`
 	buf := bytes.NewBufferString(html)
 
+	s.mockRenderService.On("GenerateHeader", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+	s.mockRenderService.On("GeneratePageFooter", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
 	s.mockRenderService.On("GenerateHTMLDocument", mock.Anything, mock.Anything).Return(buf, nil)
-	s.mockPDFService.On("GeneratePDF", buf, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]byte("pdf"), nil)
+	s.mockPDFService.On("GeneratePDF", mock.Anything, buf, mock.Anything).Return([]byte("pdf"), nil)
+	s.mockArtifactStorage.On("Get", mock.Anything, mock.Anything).Return([]byte(nil), utils.ErrArtifactNotFound)
+	s.mockArtifactStorage.On("Put", mock.Anything, mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, "cache/1/") && strings.HasSuffix(key, ".pdf")
+	}), []byte("pdf")).Return(nil)
 
-	doc, err := s.documentService.GeneratePDFDocument(context.Background(), "1")
+	key, err := s.documentService.renderJobPDF(context.Background(), "1")
 	s.NoError(err)
-	s.Equal([]byte("pdf"), doc)
+	s.True(strings.HasPrefix(key, "cache/1/"))
+	s.mockArtifactStorage.AssertExpectations(s.T())
 }
 
-func (s *TestSuiteDocumentService) TestGeneratePDFDocument_ErrorDocumentNotFound() {
+func (s *TestSuiteDocumentService) TestRenderJobPDF_CachedPDF() {
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{ID: "1"}, nil)
+
+	buf := bytes.NewBufferString(`<!DOCTYPE html>`)
+	s.mockRenderService.On("GenerateHeader", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+	s.mockRenderService.On("GeneratePageFooter", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+	s.mockRenderService.On("GenerateHTMLDocument", mock.Anything, mock.Anything).Return(buf, nil)
+	s.mockArtifactStorage.On("Get", mock.Anything, mock.Anything).Return([]byte("cached pdf"), nil)
+
+	key, err := s.documentService.renderJobPDF(context.Background(), "1")
+	s.NoError(err)
+	s.True(strings.HasPrefix(key, "cache/1/"))
+	s.mockArtifactStorage.AssertNotCalled(s.T(), "Put", mock.Anything, mock.Anything, mock.Anything)
+	s.mockPDFService.AssertNotCalled(s.T(), "GeneratePDF", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TestSuiteDocumentService) TestRequestPDFDocument_SignedPDFHash() {
+	signedPDF := []byte("signed pdf")
+
+	for _, tc := range []struct {
+		Name        string
+		Hash        string
+		Expected    []byte
+		ExpectedErr error
+	}{
+		{
+			Name:     "Success",
+			Hash:     hashPDF(signedPDF),
+			Expected: signedPDF,
+		},
+		{
+			Name:        "Error hash mismatch",
+			Hash:        hashPDF([]byte("another pdf")),
+			ExpectedErr: utils.ErrSignedPDFMismatch,
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+			s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{
+				ID:            "1",
+				StageID:       3,
+				SignedPDFKey:  "signed/1.pdf",
+				SignedPDFHash: tc.Hash,
+			}, nil)
+			s.mockArtifactStorage.On("Get", mock.Anything, "signed/1.pdf").Return(signedPDF, nil)
+
+			doc, job, err := s.documentService.RequestPDFDocument(context.Background(), "1", false)
+			s.Equal(tc.ExpectedErr, err)
+			s.Nil(job)
+			s.Equal(tc.Expected, doc)
+			s.mockRenderService.AssertNotCalled(s.T(), "GenerateHTMLDocument", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func (s *TestSuiteDocumentService) TestRenderJobPDF_RevokedPDF() {
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{
+		ID:            "1",
+		StageID:       3,
+		SignedAt:      time.Now(),
+		SignedPDFKey:  "signed/1.pdf",
+		SignedPDFHash: hashPDF([]byte("signed pdf")),
+		RevokedAt:     time.Now(),
+	}, nil)
+
+	signature := template.HTML("signature")
+	buf := bytes.NewBufferString(`<!DOCTYPE html>`)
+	s.mockRenderService.On("GenerateSignature", mock.Anything, mock.Anything).Return(&signature, nil)
+	s.mockRenderService.On("GenerateFooter", mock.Anything).Return(&signature, nil)
+	s.mockRenderService.On("GenerateHeader", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+	s.mockRenderService.On("GeneratePageFooter", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+	s.mockRenderService.On("GenerateHTMLDocument", mock.Anything, mock.Anything).Return(buf, nil)
+	s.mockArtifactStorage.On("Get", mock.Anything, mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, "cache/1/")
+	})).Return([]byte(nil), utils.ErrArtifactNotFound)
+	s.mockPDFService.On("GeneratePDF", mock.Anything, buf, mock.MatchedBy(func(options *pdf.PDFOptions) bool {
+		return options.Watermark != nil && options.Watermark.Text == "DICABUT"
+	})).Return([]byte("revoked pdf"), nil)
+	s.mockArtifactStorage.On("Put", mock.Anything, mock.Anything, []byte("revoked pdf")).Return(nil)
+
+	key, err := s.documentService.renderJobPDF(context.Background(), "1")
+	s.NoError(err)
+	s.True(strings.HasPrefix(key, "cache/1/"))
+	s.mockArtifactStorage.AssertNotCalled(s.T(), "Get", mock.Anything, "signed/1.pdf")
+}
+
+func (s *TestSuiteDocumentService) TestRenderDocument() {
+	signedPDF := []byte("signed pdf")
+	snapshot, err := newSignedSnapshot(bytes.NewBufferString(`<!DOCTYPE html><p>issued</p>`), &pdf.PDFOptions{
+		PageSize:   pdf.PageSizeA4,
+		HeaderHTML: bytes.NewBufferString(`<p>kop</p>`),
+	})
+	s.NoError(err)
+	signedDocument := &entity.Document{
+		ID:            "1",
+		StageID:       3,
+		SignedAt:      time.Now(),
+		SignedPDFKey:  "signed/1.pdf",
+		SignedPDFHash: hashPDF(signedPDF),
+	}
+
+	for _, tc := range []struct {
+		Name         string
+		Document     *entity.Document
+		RendererErr  error
+		DocumentErr  error
+		SnapshotErr  error
+		UsePDF       bool
+		ExpectedHTML string
+		ExpectedPDF  []byte
+		Expected     *dto.RenderedDocument
+		ExpectedErr  error
+	}{
+		{
+			Name:         "Success",
+			Document:     &entity.Document{ID: "1"},
+			ExpectedHTML: `<!DOCTYPE html>`,
+			Expected:     &dto.RenderedDocument{Content: []byte("docx"), ContentType: "application/docx"},
+		},
+		{
+			Name:         "Success with queued PDF",
+			Document:     &entity.Document{ID: "1"},
+			UsePDF:       true,
+			ExpectedHTML: `<!DOCTYPE html>`,
+			ExpectedPDF:  []byte("cached pdf"),
+			Expected:     &dto.RenderedDocument{Content: []byte("docx"), ContentType: "application/docx"},
+		},
+		{
+			Name:         "Success with signed snapshot",
+			Document:     signedDocument,
+			UsePDF:       true,
+			ExpectedHTML: `<!DOCTYPE html><p>issued</p>`,
+			ExpectedPDF:  signedPDF,
+			Expected:     &dto.RenderedDocument{Content: []byte("docx"), ContentType: "application/docx"},
+		},
+		{
+			Name:        "Error signed snapshot not found",
+			Document:    signedDocument,
+			SnapshotErr: utils.ErrArtifactNotFound,
+			ExpectedErr: utils.ErrSignedSnapshotNotFound,
+		},
+		{
+			Name:        "Error unsupported format",
+			RendererErr: utils.ErrUnsupportedFormat,
+			ExpectedErr: utils.ErrUnsupportedFormat,
+		},
+		{
+			Name:        "Error document not found",
+			DocumentErr: utils.ErrDocumentNotFound,
+			ExpectedErr: utils.ErrDocumentNotFound,
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+			documentRenderer := new(mockRendererPkg.MockRenderer)
+			if tc.RendererErr != nil {
+				s.mockRendererRegistry.On("Get", "xlsx").Return((*mockRendererPkg.MockRenderer)(nil), tc.RendererErr)
+			} else {
+				s.mockRendererRegistry.On("Get", "xlsx").Return(documentRenderer, nil)
+			}
+			s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(tc.Document, tc.DocumentErr)
+
+			buf := bytes.NewBufferString(`<!DOCTYPE html>`)
+			signature := template.HTML("signature")
+			s.mockRenderService.On("GenerateSignature", mock.Anything, mock.Anything).Return(&signature, nil)
+			s.mockRenderService.On("GenerateFooter", mock.Anything).Return(&signature, nil)
+			s.mockRenderService.On("GenerateHeader", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+			s.mockRenderService.On("GeneratePageFooter", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+			s.mockRenderService.On("GenerateHTMLDocument", mock.Anything, mock.Anything).Return(buf, nil)
+			s.mockArtifactStorage.On("Get", mock.Anything, "signed/1.pdf").Return(signedPDF, nil)
+			if tc.SnapshotErr != nil {
+				s.mockArtifactStorage.On("Get", mock.Anything, "signed/1.json").Return([]byte(nil), tc.SnapshotErr)
+			} else {
+				s.mockArtifactStorage.On("Get", mock.Anything, "signed/1.json").Return(snapshot, nil)
+			}
+			s.mockDocumentRepository.On("GetActiveRenderJob", mock.Anything, "1").Return(&entity.RenderJob{
+				ID:         "job1",
+				DocumentID: "1",
+				Status:     entity.RenderJobRunning,
+			}, nil)
+			s.mockDocumentRepository.On("GetRenderJob", mock.Anything, "job1").Return(&entity.RenderJob{
+				ID:          "job1",
+				DocumentID:  "1",
+				Status:      entity.RenderJobDone,
+				ArtifactKey: "cache/1/abc.pdf",
+			}, nil)
+			s.mockArtifactStorage.On("Get", mock.Anything, "cache/1/abc.pdf").Return([]byte("cached pdf"), nil)
+
+			documentRenderer.On("Render", mock.Anything, mock.MatchedBy(func(document *renderer.Document) bool {
+				if document.HTML.String() != tc.ExpectedHTML || document.Options == nil {
+					return false
+				}
+				if !tc.UsePDF {
+					return true
+				}
+
+				content, err := document.PDF(context.Background())
+				return err == nil && bytes.Equal(tc.ExpectedPDF, content)
+			})).Return([]byte("docx"), nil)
+			documentRenderer.On("ContentType").Return("application/docx")
+
+			rendered, err := s.documentService.RenderDocument(context.Background(), "1", "xlsx")
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.Expected, rendered)
+			s.mockPDFService.AssertNotCalled(s.T(), "GeneratePDF", mock.Anything, mock.Anything, mock.Anything)
+			if tc.Document == signedDocument {
+				s.mockRenderService.AssertNotCalled(s.T(), "GenerateHTMLDocument", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func (s *TestSuiteDocumentService) TestNewSignedSnapshot() {
+	header := bytes.NewBufferString(`<p>kop</p>`)
+	options := &pdf.PDFOptions{
+		PageSize:   pdf.PageSizeA4,
+		MarginTop:  10,
+		HeaderHTML: header,
+		Watermark:  &pdf.Watermark{Text: "DICABUT"},
+	}
+
+	snapshot, err := newSignedSnapshot(bytes.NewBufferString(`<p>surat</p>`), options)
+	s.NoError(err)
+	s.Equal(`<p>kop</p>`, header.String(), "snapshot must not consume the header")
+
+	s.mockArtifactStorage.On("Get", mock.Anything, "signed/1/abc.json").Return(snapshot, nil)
+	html, restored, err := s.documentService.getSignedSnapshot(context.Background(), &entity.Document{SignedPDFKey: "signed/1/abc.pdf"})
+	s.NoError(err)
+	s.Equal(`<p>surat</p>`, html.String())
+	s.Equal(`<p>kop</p>`, restored.HeaderHTML.String())
+	s.Nil(restored.FooterHTML)
+	s.Equal(options.PageSize, restored.PageSize)
+	s.Equal(options.MarginTop, restored.MarginTop)
+	s.Equal(options.Watermark, restored.Watermark)
+}
+
+func (s *TestSuiteDocumentService) TestGetExportDocumentIDs() {
+	tooMany := make([]string, maxExportDocuments+1)
+
+	for _, tc := range []struct {
+		Name           string
+		Request        *dto.ExportDocumentsRequest
+		ExpectedFilter *repository.DocumentFilter
+		ReturnedIDs    []string
+		RepositoryErr  error
+		Expected       []string
+		ExpectedErr    error
+	}{
+		{
+			Name:           "Success by id keep the requested order",
+			Request:        &dto.ExportDocumentsRequest{DocumentIDs: []string{"2", "1"}},
+			ExpectedFilter: &repository.DocumentFilter{IDs: []string{"2", "1"}},
+			ReturnedIDs:    []string{"1", "2"},
+			Expected:       []string{"2", "1"},
+		},
+		{
+			Name:           "Success by id listed twice",
+			Request:        &dto.ExportDocumentsRequest{DocumentIDs: []string{"2", "1", "2"}},
+			ExpectedFilter: &repository.DocumentFilter{IDs: []string{"2", "1"}},
+			ReturnedIDs:    []string{"1", "2"},
+			Expected:       []string{"2", "1"},
+		},
+		{
+			Name:    "Success by filter",
+			Request: &dto.ExportDocumentsRequest{StageID: 3, TemplateID: 1, DateFrom: "2022-12-01", DateTo: "2022-12-31"},
+			ExpectedFilter: &repository.DocumentFilter{
+				StageID:    3,
+				TemplateID: 1,
+				DateFrom:   time.Date(2022, time.December, 1, 0, 0, 0, 0, time.Local),
+				DateUntil:  time.Date(2023, time.January, 1, 0, 0, 0, 0, time.Local),
+			},
+			ReturnedIDs: []string{"1", "2"},
+			Expected:    []string{"1", "2"},
+		},
+		{
+			Name:           "Error requested document not found",
+			Request:        &dto.ExportDocumentsRequest{DocumentIDs: []string{"1", "2"}},
+			ExpectedFilter: &repository.DocumentFilter{IDs: []string{"1", "2"}},
+			ReturnedIDs:    []string{"1"},
+			ExpectedErr:    utils.ErrDocumentNotFound,
+		},
+		{
+			Name:           "Error too many documents",
+			Request:        &dto.ExportDocumentsRequest{StageID: 1},
+			ExpectedFilter: &repository.DocumentFilter{StageID: 1},
+			ReturnedIDs:    tooMany,
+			ExpectedErr:    utils.ErrTooManyDocuments,
+		},
+		{
+			Name:           "Error repository",
+			Request:        &dto.ExportDocumentsRequest{StageID: 1},
+			ExpectedFilter: &repository.DocumentFilter{StageID: 1},
+			RepositoryErr:  utils.ErrDocumentNotFound,
+			ExpectedErr:    utils.ErrDocumentNotFound,
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+			s.mockDocumentRepository.On("GetDocumentIDs", mock.Anything, tc.ExpectedFilter, maxExportDocuments+1).Return(tc.ReturnedIDs, tc.RepositoryErr)
+
+			documentIDs, err := s.documentService.GetExportDocumentIDs(context.Background(), tc.Request)
+			s.Equal(tc.ExpectedErr, err)
+			if tc.ExpectedErr == nil {
+				s.Equal(tc.Expected, documentIDs)
+			}
+		})
+	}
+}
+
+func (s *TestSuiteDocumentService) TestWriteDocumentExport() {
+	documents := map[string]*entity.Document{
+		"1": {
+			ID:            "1",
+			RegisterID:    10,
+			StageID:       3,
+			SignedPDFKey:  "signed/1.pdf",
+			SignedPDFHash: hashPDF([]byte("first pdf")),
+			Template:      entity.Template{Name: "Surat Domisili"},
+			Applicant:     entity.User{Name: "Budi"},
+		},
+		"2": {
+			ID:            "2",
+			RegisterID:    11,
+			StageID:       3,
+			SignedPDFKey:  "signed/2.pdf",
+			SignedPDFHash: hashPDF([]byte("second pdf")),
+			Template:      entity.Template{Name: "Surat Usaha"},
+			Applicant:     entity.User{Name: "Sari"},
+		},
+	}
+
+	s.Run("Success merged PDF", func() {
+		s.SetupTest()
+		for id, document := range documents {
+			s.mockDocumentRepository.On("GetDocument", mock.Anything, id).Return(document, nil)
+		}
+		s.mockArtifactStorage.On("Get", mock.Anything, "signed/1.pdf").Return([]byte("first pdf"), nil)
+		s.mockArtifactStorage.On("Get", mock.Anything, "signed/2.pdf").Return([]byte("second pdf"), nil)
+
+		var merged []pdf.MergeDocument
+		s.mockPDFMerger.On("Merge", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			next := args.Get(1).(func() (*pdf.MergeDocument, error))
+			for {
+				document, err := next()
+				s.NoError(err)
+				if document == nil {
+					break
+				}
+				merged = append(merged, *document)
+			}
+		}).Return(nil)
+
+		err := s.documentService.WriteDocumentExport(context.Background(), []string{"2", "1"}, "pdf", new(bytes.Buffer))
+		s.NoError(err)
+		s.Equal([]pdf.MergeDocument{
+			{Title: "No. 11 - Surat Usaha - Sari", Content: []byte("second pdf")},
+			{Title: "No. 10 - Surat Domisili - Budi", Content: []byte("first pdf")},
+		}, merged)
+	})
+
+	s.Run("Success zip", func() {
+		s.SetupTest()
+		for id, document := range documents {
+			s.mockDocumentRepository.On("GetDocument", mock.Anything, id).Return(document, nil)
+		}
+		s.mockArtifactStorage.On("Get", mock.Anything, "signed/1.pdf").Return([]byte("first pdf"), nil)
+		s.mockArtifactStorage.On("Get", mock.Anything, "signed/2.pdf").Return([]byte("second pdf"), nil)
+
+		buf := new(bytes.Buffer)
+		err := s.documentService.WriteDocumentExport(context.Background(), []string{"1", "2"}, "zip", buf)
+		s.NoError(err)
+
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		s.NoError(err)
+		files := map[string]string{}
+		for _, file := range archive.File {
+			reader, err := file.Open()
+			s.NoError(err)
+			content, err := io.ReadAll(reader)
+			s.NoError(err)
+			files[file.Name] = string(content)
+		}
+		s.Equal(map[string]string{"001-1.pdf": "first pdf", "002-2.pdf": "second pdf"}, files)
+		s.mockPDFMerger.AssertNotCalled(s.T(), "Merge", mock.Anything, mock.Anything)
+	})
+
+	s.Run("Success unsigned document through render queue", func() {
+		s.SetupTest()
+		s.mockDocumentRepository.On("GetDocument", mock.Anything, "3").Return(&entity.Document{ID: "3", StageID: 2}, nil)
+		s.mockDocumentRepository.On("GetActiveRenderJob", mock.Anything, "3").Return(&entity.RenderJob{
+			ID:         "job3",
+			DocumentID: "3",
+			Status:     entity.RenderJobQueued,
+		}, nil)
+		s.mockDocumentRepository.On("GetRenderJob", mock.Anything, "job3").Return(&entity.RenderJob{
+			ID:          "job3",
+			DocumentID:  "3",
+			Status:      entity.RenderJobDone,
+			ArtifactKey: "cache/3/abc.pdf",
+		}, nil)
+		s.mockArtifactStorage.On("Get", mock.Anything, "cache/3/abc.pdf").Return([]byte("third pdf"), nil)
+
+		buf := new(bytes.Buffer)
+		err := s.documentService.WriteDocumentExport(context.Background(), []string{"3"}, "zip", buf)
+		s.NoError(err)
+
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		s.NoError(err)
+		s.Len(archive.File, 1)
+		s.Equal("001-3.pdf", archive.File[0].Name)
+		s.mockPDFService.AssertNotCalled(s.T(), "GeneratePDF", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	s.Run("Error document not found", func() {
+		s.SetupTest()
+		s.mockDocumentRepository.On("GetDocument", mock.Anything, "3").Return((*entity.Document)(nil), utils.ErrDocumentNotFound)
+
+		err := s.documentService.WriteDocumentExport(context.Background(), []string{"3"}, "zip", new(bytes.Buffer))
+		s.Equal(utils.ErrDocumentNotFound, err)
+	})
+
+	s.Run("Error unsupported format", func() {
+		s.SetupTest()
+
+		err := s.documentService.WriteDocumentExport(context.Background(), []string{"1"}, "docx", new(bytes.Buffer))
+		s.Equal(utils.ErrUnsupportedFormat, err)
+	})
+}
+
+func (s *TestSuiteDocumentService) TestNewWatermark() {
+	angle, horizontal := -30, 0
+	configured := entity.Template{
+		WatermarkDraft:    "KONSEP",
+		WatermarkUnsigned: "BELUM SAH",
+		WatermarkRevoked:  "TIDAK BERLAKU",
+		WatermarkOpacity:  0.3,
+		WatermarkAngle:    &angle,
+	}
+
+	for _, tc := range []struct {
+		Name     string
+		Document *entity.Document
+		Expected *pdf.Watermark
+	}{
+		{
+			Name:     "Draft",
+			Document: &entity.Document{StageID: 1},
+			Expected: &pdf.Watermark{Text: "DRAFT", Opacity: pdf.DefaultWatermarkOpacity, Angle: pdf.DefaultWatermarkAngle},
+		},
+		{
+			Name:     "Verified but not signed",
+			Document: &entity.Document{StageID: 2},
+			Expected: &pdf.Watermark{Text: "BELUM DITANDATANGANI", Opacity: pdf.DefaultWatermarkOpacity, Angle: pdf.DefaultWatermarkAngle},
+		},
+		{
+			Name:     "Signed",
+			Document: &entity.Document{StageID: 3},
+			Expected: nil,
+		},
+		{
+			Name:     "Revoked",
+			Document: &entity.Document{StageID: 3, RevokedAt: time.Now()},
+			Expected: &pdf.Watermark{Text: "DICABUT", Opacity: pdf.DefaultWatermarkOpacity, Angle: pdf.DefaultWatermarkAngle},
+		},
+		{
+			Name:     "Draft with template setting",
+			Document: &entity.Document{StageID: 1, Template: configured},
+			Expected: &pdf.Watermark{Text: "KONSEP", Opacity: 0.3, Angle: -30},
+		},
+		{
+			Name:     "Verified with template setting",
+			Document: &entity.Document{StageID: 2, Template: configured},
+			Expected: &pdf.Watermark{Text: "BELUM SAH", Opacity: 0.3, Angle: -30},
+		},
+		{
+			Name:     "Draft with horizontal watermark",
+			Document: &entity.Document{StageID: 1, Template: entity.Template{WatermarkAngle: &horizontal}},
+			Expected: &pdf.Watermark{Text: "DRAFT", Opacity: pdf.DefaultWatermarkOpacity, Angle: 0},
+		},
+		{
+			Name:     "Revoked with template setting",
+			Document: &entity.Document{StageID: 3, RevokedAt: time.Now(), Template: configured},
+			Expected: &pdf.Watermark{Text: "TIDAK BERLAKU", Opacity: 0.3, Angle: -30},
+		},
+	} {
+		s.Equal(tc.Expected, newWatermark(tc.Document), tc.Name)
+	}
+}
+
+func (s *TestSuiteDocumentService) TestRenderJobPDF_ErrorDocumentNotFound() {
 	s.mockDocumentRepository.On("GetDocument", mock.Anything, mock.Anything).Return(&entity.Document{}, utils.ErrDocumentNotFound)
 
-	doc, err := s.documentService.GeneratePDFDocument(context.Background(), "1")
+	key, err := s.documentService.renderJobPDF(context.Background(), "1")
 	s.Equal(err, utils.ErrDocumentNotFound)
-	s.Nil(doc)
+	s.Empty(key)
 }
 
-func (s *TestSuiteDocumentService) TestGeneratePDFDocument_ErrorGenerateHTMLDocument() {
+func (s *TestSuiteDocumentService) TestRenderJobPDF_ErrorGenerateHTMLDocument() {
 	s.mockDocumentRepository.On("GetDocument", mock.Anything, mock.Anything).Return(&entity.Document{
 		ID:          "1",
 		RegisterID:  123,
@@ -512,14 +1391,16 @@ func (s *TestSuiteDocumentService) TestGeneratePDFDocument_ErrorGenerateHTMLDocu
 		DeletedAt:  gorm.DeletedAt{},
 	}, nil)
 
+	s.mockRenderService.On("GenerateHeader", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+	s.mockRenderService.On("GeneratePageFooter", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
 	s.mockRenderService.On("GenerateHTMLDocument", mock.Anything, mock.Anything).Return(&bytes.Buffer{}, errors.New("error"))
 
-	doc, err := s.documentService.GeneratePDFDocument(context.Background(), "1")
+	key, err := s.documentService.renderJobPDF(context.Background(), "1")
 	s.Equal(errors.New("error"), err)
-	s.Nil(doc)
+	s.Empty(key)
 }
 
-func (s *TestSuiteDocumentService) TestGeneratePDFDocument_ErrorGeneratePDF() {
+func (s *TestSuiteDocumentService) TestRenderJobPDF_ErrorGeneratePDF() {
 	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{
 		ID:          "1",
 		RegisterID:  123,
@@ -566,12 +1447,478 @@ func (s *TestSuiteDocumentService) TestGeneratePDFDocument_ErrorGeneratePDF() {
 	html := `<!DOCTYPE html>`
 	buf := bytes.NewBufferString(html)
 
-	s.mockRenderService.On("GenerateHTMLDocument", mock.Anything, mock.Anything).Return(buf, nil)
-	s.mockPDFService.On("GeneratePDF", buf, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]byte(nil), errors.New("error"))
+	s.mockRenderService.On("GenerateHeader", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+	s.mockRenderService.On("GeneratePageFooter", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+	s.mockRenderService.On("GenerateHTMLDocument", mock.Anything, mock.Anything).Return(buf, nil)
+	s.mockPDFService.On("GeneratePDF", mock.Anything, buf, mock.Anything).Return([]byte(nil), errors.New("error"))
+	s.mockArtifactStorage.On("Get", mock.Anything, mock.Anything).Return([]byte(nil), utils.ErrArtifactNotFound)
+
+	key, err := s.documentService.renderJobPDF(context.Background(), "1")
+	s.Equal(errors.New("error"), err)
+	s.Empty(key)
+}
+
+func (s *TestSuiteDocumentService) TestRenderJobPDF_SuccessWithPageSection() {
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{
+		ID:         "1",
+		TemplateID: 1,
+		Template: entity.Template{
+			Model: gorm.Model{
+				ID: 1,
+			},
+			Name:          "Test Template",
+			HeaderPath:    "header.html",
+			QROnEveryPage: true,
+		},
+	}, nil)
+
+	header := bytes.NewBufferString("header")
+	footer := bytes.NewBufferString("footer")
+	buf := bytes.NewBufferString(`<!DOCTYPE html>`)
+
+	s.mockRenderService.On("GenerateHeader", mock.Anything, mock.Anything).Return(header, nil)
+	s.mockRenderService.On("GeneratePageFooter", mock.Anything, mock.Anything).Return(footer, nil)
+	s.mockRenderService.On("GenerateHTMLDocument", mock.Anything, mock.MatchedBy(func(data *map[string]interface{}) bool {
+		return (*data)["footer"] == ""
+	})).Return(buf, nil)
+	s.mockPDFService.On("GeneratePDF", mock.Anything, buf, mock.MatchedBy(func(options *pdf.PDFOptions) bool {
+		return options.HeaderHTML == header && options.FooterHTML == footer
+	})).Return([]byte("pdf"), nil)
+	s.mockArtifactStorage.On("Get", mock.Anything, mock.Anything).Return([]byte(nil), utils.ErrArtifactNotFound)
+	s.mockArtifactStorage.On("Put", mock.Anything, mock.Anything, []byte("pdf")).Return(nil)
+
+	key, err := s.documentService.renderJobPDF(context.Background(), "1")
+	s.NoError(err)
+	s.True(strings.HasPrefix(key, "cache/1/"))
+}
+
+func (s *TestSuiteDocumentService) TestRenderJobPDF_ErrorGenerateHeader() {
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{
+		ID: "1",
+		Template: entity.Template{
+			HeaderPath: "header.html",
+		},
+	}, nil)
+
+	s.mockRenderService.On("GenerateHeader", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), errors.New("error"))
+
+	key, err := s.documentService.renderJobPDF(context.Background(), "1")
+	s.Equal(errors.New("error"), err)
+	s.Empty(key)
+}
+
+func (s *TestSuiteDocumentService) TestRequestPDFDocument_Async() {
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{ID: "1"}, nil)
+	s.mockDocumentRepository.On("GetActiveRenderJob", mock.Anything, "1").Return((*entity.RenderJob)(nil), utils.ErrRenderJobNotFound)
+	s.mockDocumentRepository.On("AddRenderJob", mock.Anything, mock.AnythingOfType("*entity.RenderJob")).Return(nil)
+
+	doc, job, err := s.documentService.RequestPDFDocument(context.Background(), "1", true)
+	s.NoError(err)
+	s.Nil(doc)
+	s.Equal("1", job.DocumentID)
+	s.Equal(entity.RenderJobQueued, job.Status)
+	s.Equal(job.ID, <-s.documentService.renderQueue.jobs)
+}
+
+func (s *TestSuiteDocumentService) TestRequestPDFDocument_WaitNewJob() {
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{ID: "1"}, nil)
+	s.mockDocumentRepository.On("GetActiveRenderJob", mock.Anything, "1").Return((*entity.RenderJob)(nil), utils.ErrRenderJobNotFound)
+
+	// the job is finished by the worker right after it is pushed, before the request read it again
+	var jobID string
+	s.mockDocumentRepository.On("AddRenderJob", mock.Anything, mock.AnythingOfType("*entity.RenderJob")).Run(func(args mock.Arguments) {
+		jobID = args.Get(1).(*entity.RenderJob).ID
+		go func() {
+			s.documentService.renderQueue.finish(<-s.documentService.renderQueue.jobs)
+		}()
+	}).Return(nil)
+	s.mockDocumentRepository.On("GetRenderJob", mock.Anything, mock.Anything).Return(&entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobRunning}, nil).Once()
+	s.mockDocumentRepository.On("GetRenderJob", mock.Anything, mock.Anything).Return(&entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobDone, ArtifactKey: "cache/1/abc.pdf"}, nil)
+	s.mockArtifactStorage.On("Get", mock.Anything, "cache/1/abc.pdf").Return([]byte("pdf"), nil)
+
+	s.documentService.renderQueue.wait = time.Minute
+	doc, job, err := s.documentService.RequestPDFDocument(context.Background(), "1", false)
+	s.NoError(err)
+	s.Nil(job)
+	s.Equal([]byte("pdf"), doc)
+	s.NotEmpty(jobID)
+	s.Empty(s.documentService.renderQueue.waiters)
+}
+
+func (s *TestSuiteDocumentService) TestRenderQueue_Waiter() {
+	queue := newRenderQueue(1, 1, time.Second)
+
+	done, release := queue.done("job1")
+	_, releaseOther := queue.done("job1")
+	release()
+	s.Contains(queue.waiters, "job1")
+	releaseOther()
+	s.NotContains(queue.waiters, "job1")
+
+	done, release = queue.done("job1")
+	queue.finish("job1")
+	_, open := <-done
+	s.False(open)
+	release()
+	s.Empty(queue.waiters)
+}
+
+func (s *TestSuiteDocumentService) TestRequestPDFDocument_ReuseActiveJob() {
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{ID: "1"}, nil)
+	s.mockDocumentRepository.On("GetActiveRenderJob", mock.Anything, "1").Return(&entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobRunning}, nil)
+
+	_, job, err := s.documentService.RequestPDFDocument(context.Background(), "1", true)
+	s.NoError(err)
+	s.Equal("job1", job.ID)
+	s.mockDocumentRepository.AssertNotCalled(s.T(), "AddRenderJob", mock.Anything, mock.Anything)
+}
+
+func (s *TestSuiteDocumentService) TestRequestPDFDocument_JoinConcurrentJob() {
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{ID: "1"}, nil)
+	s.mockDocumentRepository.On("GetActiveRenderJob", mock.Anything, "1").Return((*entity.RenderJob)(nil), utils.ErrRenderJobNotFound).Once()
+	s.mockDocumentRepository.On("AddRenderJob", mock.Anything, mock.AnythingOfType("*entity.RenderJob")).Return(utils.ErrRenderJobAlreadyActive)
+	s.mockDocumentRepository.On("GetActiveRenderJob", mock.Anything, "1").Return(&entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobQueued}, nil)
+
+	_, job, err := s.documentService.RequestPDFDocument(context.Background(), "1", true)
+	s.NoError(err)
+	s.Equal("job1", job.ID)
+	s.Empty(s.documentService.renderQueue.jobs)
+	s.Empty(s.documentService.renderQueue.waiters)
+}
+
+func (s *TestSuiteDocumentService) TestRequestPDFDocument_ErrorQueueFull() {
+	s.documentService.renderQueue = newRenderQueue(1, 0, time.Second)
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{ID: "1"}, nil)
+	s.mockDocumentRepository.On("GetActiveRenderJob", mock.Anything, "1").Return((*entity.RenderJob)(nil), utils.ErrRenderJobNotFound)
+	s.mockDocumentRepository.On("AddRenderJob", mock.Anything, mock.Anything).Return(nil)
+	s.mockDocumentRepository.On("UpdateRenderJob", mock.Anything, mock.MatchedBy(func(job *entity.RenderJob) bool {
+		return job.Status == entity.RenderJobFailed && job.Error == utils.ErrRenderQueueFull.Error()
+	})).Return(nil)
+
+	doc, job, err := s.documentService.RequestPDFDocument(context.Background(), "1", false)
+	s.Equal(utils.ErrRenderQueueFull, err)
+	s.Nil(doc)
+	s.Nil(job)
+}
+
+func (s *TestSuiteDocumentService) TestRequestPDFDocument_Wait() {
+	for _, tc := range []struct {
+		Name        string
+		Job         *entity.RenderJob
+		ExpectedPDF []byte
+		ExpectedJob *dto.RenderJobResponse
+		ExpectedErr error
+	}{
+		{
+			Name:        "Success job done",
+			Job:         &entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobDone, ArtifactKey: "cache/1/abc.pdf"},
+			ExpectedPDF: []byte("pdf"),
+		},
+		{
+			Name:        "Success cached file evicted",
+			Job:         &entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobDone, ArtifactKey: "cache/1/evicted.pdf"},
+			ExpectedJob: &dto.RenderJobResponse{ID: "job1", DocumentID: "1", Status: entity.RenderJobQueued},
+		},
+		{
+			Name:        "Success wait timeout",
+			Job:         &entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobRunning},
+			ExpectedJob: &dto.RenderJobResponse{ID: "job1", DocumentID: "1", Status: entity.RenderJobRunning},
+		},
+		{
+			Name:        "Error job failed",
+			Job:         &entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobFailed, Error: "render error"},
+			ExpectedErr: utils.ErrRenderJobFailed,
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+			s.documentService.renderQueue.wait = time.Millisecond
+			s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{ID: "1"}, nil)
+			s.mockDocumentRepository.On("GetActiveRenderJob", mock.Anything, "1").Return(&entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobQueued}, nil)
+			s.mockDocumentRepository.On("GetRenderJob", mock.Anything, "job1").Return(tc.Job, nil)
+			s.mockArtifactStorage.On("Get", mock.Anything, "cache/1/abc.pdf").Return([]byte("pdf"), nil)
+			s.mockArtifactStorage.On("Get", mock.Anything, "cache/1/evicted.pdf").Return([]byte(nil), utils.ErrArtifactNotFound)
+			s.mockDocumentRepository.On("UpdateRenderJob", mock.Anything, mock.Anything).Return(nil)
+
+			doc, job, err := s.documentService.RequestPDFDocument(context.Background(), "1", false)
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedPDF, doc)
+			s.Equal(tc.ExpectedJob, job)
+			s.Empty(s.documentService.renderQueue.waiters)
+		})
+	}
+}
+
+func (s *TestSuiteDocumentService) TestRequestPDFDocument_SignedPDF() {
+	signedPDF := []byte("signed pdf")
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{
+		ID:            "1",
+		StageID:       3,
+		SignedPDFKey:  "signed/1.pdf",
+		SignedPDFHash: hashPDF(signedPDF),
+	}, nil)
+	s.mockArtifactStorage.On("Get", mock.Anything, "signed/1.pdf").Return(signedPDF, nil)
+
+	doc, job, err := s.documentService.RequestPDFDocument(context.Background(), "1", true)
+	s.NoError(err)
+	s.Equal(signedPDF, doc)
+	s.Nil(job)
+	s.mockDocumentRepository.AssertNotCalled(s.T(), "GetActiveRenderJob", mock.Anything, mock.Anything)
+}
+
+func (s *TestSuiteDocumentService) TestRequestPDFDocument_RevokedPDF() {
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{
+		ID:           "1",
+		StageID:      3,
+		SignedPDFKey: "signed/1.pdf",
+		RevokedAt:    time.Now(),
+	}, nil)
+	s.mockDocumentRepository.On("GetActiveRenderJob", mock.Anything, "1").Return((*entity.RenderJob)(nil), utils.ErrRenderJobNotFound)
+	s.mockDocumentRepository.On("AddRenderJob", mock.Anything, mock.AnythingOfType("*entity.RenderJob")).Return(nil)
+
+	doc, job, err := s.documentService.RequestPDFDocument(context.Background(), "1", true)
+	s.NoError(err)
+	s.Nil(doc)
+	s.Equal(entity.RenderJobQueued, job.Status)
+	s.mockArtifactStorage.AssertNotCalled(s.T(), "Get", mock.Anything, "signed/1.pdf")
+}
+
+func (s *TestSuiteDocumentService) TestRequestPDFDocument_ErrorDocumentNotFound() {
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return((*entity.Document)(nil), utils.ErrDocumentNotFound)
+
+	doc, job, err := s.documentService.RequestPDFDocument(context.Background(), "1", true)
+	s.Equal(utils.ErrDocumentNotFound, err)
+	s.Nil(doc)
+	s.Nil(job)
+}
+
+func (s *TestSuiteDocumentService) TestGetRenderJob() {
+	for _, tc := range []struct {
+		Name        string
+		Job         *entity.RenderJob
+		Err         error
+		Expected    *dto.RenderJobResponse
+		ExpectedErr error
+	}{
+		{
+			Name:     "Success",
+			Job:      &entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobQueued},
+			Expected: &dto.RenderJobResponse{ID: "job1", DocumentID: "1", Status: entity.RenderJobQueued},
+		},
+		{
+			Name:        "Error job not found",
+			Job:         (*entity.RenderJob)(nil),
+			Err:         utils.ErrRenderJobNotFound,
+			ExpectedErr: utils.ErrRenderJobNotFound,
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+			s.mockDocumentRepository.On("GetRenderJob", mock.Anything, "job1").Return(tc.Job, tc.Err)
+
+			job, err := s.documentService.GetRenderJob(context.Background(), "job1")
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.Expected, job)
+		})
+	}
+}
+
+func (s *TestSuiteDocumentService) TestGetRenderJobPDF() {
+	signedPDF := []byte("signed pdf")
+	for _, tc := range []struct {
+		Name        string
+		Job         *entity.RenderJob
+		Err         error
+		Document    *entity.Document
+		UpdateErr   error
+		Expected    []byte
+		ExpectedErr error
+	}{
+		{
+			Name:     "Success",
+			Job:      &entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobDone, ArtifactKey: "cache/1/abc.pdf"},
+			Document: &entity.Document{ID: "1"},
+			Expected: []byte("pdf"),
+		},
+		{
+			Name:     "Success document signed after the job finished",
+			Job:      &entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobDone, ArtifactKey: "cache/1/abc.pdf"},
+			Document: &entity.Document{ID: "1", SignedPDFKey: "signed/1.pdf", SignedPDFHash: hashPDF(signedPDF)},
+			Expected: signedPDF,
+		},
+		{
+			Name:        "Error job not done",
+			Job:         &entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobRunning},
+			ExpectedErr: utils.ErrRenderJobNotDone,
+		},
+		{
+			Name:        "Error cached file evicted queue the job again",
+			Job:         &entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobDone, ArtifactKey: "cache/1/evicted.pdf"},
+			Document:    &entity.Document{ID: "1"},
+			ExpectedErr: utils.ErrRenderJobNotDone,
+		},
+		{
+			Name:        "Error document revoked after the job finished queue the job again",
+			Job:         &entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobDone, ArtifactKey: "signed/1.pdf"},
+			Document:    &entity.Document{ID: "1", SignedPDFKey: "signed/1.pdf", SignedPDFHash: hashPDF(signedPDF), RevokedAt: time.Now()},
+			ExpectedErr: utils.ErrRenderJobNotDone,
+		},
+		{
+			Name:        "Error cached file evicted while another job is active",
+			Job:         &entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobDone, ArtifactKey: "cache/1/evicted.pdf"},
+			Document:    &entity.Document{ID: "1"},
+			UpdateErr:   utils.ErrRenderJobAlreadyActive,
+			ExpectedErr: utils.ErrRenderJobNotDone,
+		},
+		{
+			Name:        "Error job failed",
+			Job:         &entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobFailed},
+			ExpectedErr: utils.ErrRenderJobFailed,
+		},
+		{
+			Name:        "Error job not found",
+			Job:         (*entity.RenderJob)(nil),
+			Err:         utils.ErrRenderJobNotFound,
+			ExpectedErr: utils.ErrRenderJobNotFound,
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+			s.mockDocumentRepository.On("GetRenderJob", mock.Anything, "job1").Return(tc.Job, tc.Err)
+			s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(tc.Document, nil)
+			s.mockArtifactStorage.On("Get", mock.Anything, "cache/1/abc.pdf").Return([]byte("pdf"), nil)
+			s.mockArtifactStorage.On("Get", mock.Anything, "cache/1/evicted.pdf").Return([]byte(nil), utils.ErrArtifactNotFound)
+			s.mockArtifactStorage.On("Get", mock.Anything, "signed/1.pdf").Return(signedPDF, nil)
+			s.mockDocumentRepository.On("UpdateRenderJob", mock.Anything, mock.MatchedBy(func(job *entity.RenderJob) bool {
+				return job.Status == entity.RenderJobQueued && job.ArtifactKey == ""
+			})).Return(tc.UpdateErr)
+
+			doc, err := s.documentService.GetRenderJobPDF(context.Background(), "job1")
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.Expected, doc)
+		})
+	}
+}
+
+func (s *TestSuiteDocumentService) TestGetRenderJobPDF_RevokeThenDownload() {
+	signedPDF := []byte("signed pdf")
+	document := &entity.Document{ID: "1", StageID: 3, SignedPDFKey: "signed/1.pdf", SignedPDFHash: hashPDF(signedPDF)}
+	job := &entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobDone, ArtifactKey: "signed/1.pdf"}
+	s.mockDocumentRepository.On("GetRenderJob", mock.Anything, "job1").Return(job, nil)
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(document, nil)
+	s.mockArtifactStorage.On("Get", mock.Anything, "signed/1.pdf").Return(signedPDF, nil)
+	s.mockDocumentRepository.On("UpdateRenderJob", mock.Anything, mock.Anything).Return(nil)
 
-	doc, err := s.documentService.GeneratePDFDocument(context.Background(), "1")
-	s.Equal(errors.New("error"), err)
+	doc, err := s.documentService.GetRenderJobPDF(context.Background(), "job1")
+	s.NoError(err)
+	s.Equal(signedPDF, doc)
+
+	document.RevokedAt = time.Now()
+	doc, err = s.documentService.GetRenderJobPDF(context.Background(), "job1")
+	s.Equal(utils.ErrRenderJobNotDone, err)
 	s.Nil(doc)
+	s.Equal(entity.RenderJobQueued, job.Status)
+	s.Empty(job.ArtifactKey)
+	s.Equal("job1", <-s.documentService.renderQueue.jobs)
+}
+
+func (s *TestSuiteDocumentService) TestProcessRenderJob() {
+	for _, tc := range []struct {
+		Name           string
+		PDFErr         error
+		ExpectedStatus string
+		ExpectedError  string
+	}{
+		{
+			Name:           "Success",
+			ExpectedStatus: entity.RenderJobDone,
+		},
+		{
+			Name:           "Error render",
+			PDFErr:         errors.New("render error"),
+			ExpectedStatus: entity.RenderJobFailed,
+			ExpectedError:  "render error",
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+			s.mockDocumentRepository.On("GetRenderJob", mock.Anything, "job1").Return(&entity.RenderJob{ID: "job1", DocumentID: "1", Status: entity.RenderJobQueued}, nil)
+			s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{ID: "1"}, nil)
+			s.mockRenderService.On("GenerateHeader", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+			s.mockRenderService.On("GeneratePageFooter", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+			s.mockRenderService.On("GenerateHTMLDocument", mock.Anything, mock.Anything).Return(bytes.NewBufferString(`<!DOCTYPE html>`), nil)
+			s.mockArtifactStorage.On("Get", mock.Anything, mock.Anything).Return([]byte(nil), utils.ErrArtifactNotFound)
+			s.mockArtifactStorage.On("Put", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			s.mockPDFService.On("GeneratePDF", mock.Anything, mock.Anything, mock.Anything).Return([]byte("pdf"), tc.PDFErr)
+
+			var updated []entity.RenderJob
+			s.mockDocumentRepository.On("UpdateRenderJob", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				updated = append(updated, *args.Get(1).(*entity.RenderJob))
+			}).Return(nil)
+
+			done, release := s.documentService.renderQueue.done("job1")
+			defer release()
+			s.documentService.processRenderJob(context.Background(), "job1")
+
+			s.Len(updated, 2)
+			s.Equal(entity.RenderJobRunning, updated[0].Status)
+			s.Equal(tc.ExpectedStatus, updated[1].Status)
+			s.Equal(tc.ExpectedError, updated[1].Error)
+			s.False(updated[1].FinishedAt.IsZero())
+			if tc.PDFErr == nil {
+				s.True(strings.HasPrefix(updated[1].ArtifactKey, "cache/1/"))
+			}
+
+			_, open := <-done
+			s.False(open)
+		})
+	}
+}
+
+func (s *TestSuiteDocumentService) TestStartRenderWorkers() {
+	s.Run("Success resume unfinished job", func() {
+		s.SetupTest()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		processed := make(chan string, 1)
+		s.mockDocumentRepository.On("GetUnfinishedRenderJobs", mock.Anything).Return(&entity.RenderJobs{{ID: "job1", DocumentID: "1", Status: entity.RenderJobRunning}}, nil)
+		s.mockDocumentRepository.On("GetRenderJob", mock.Anything, "job1").Run(func(args mock.Arguments) {
+			processed <- args.String(1)
+		}).Return((*entity.RenderJob)(nil), utils.ErrRenderJobNotFound)
+
+		s.NoError(s.documentService.StartRenderWorkers(ctx))
+		s.Equal("job1", <-processed)
+	})
+
+	s.Run("Error repository", func() {
+		s.SetupTest()
+		s.mockDocumentRepository.On("GetUnfinishedRenderJobs", mock.Anything).Return((*entity.RenderJobs)(nil), errors.New("repository error"))
+
+		s.Equal(errors.New("repository error"), s.documentService.StartRenderWorkers(context.Background()))
+	})
+}
+
+func (s *TestSuiteDocumentService) TestEvictPDFCache() {
+	s.documentService.pdfCacheTTL = time.Hour
+	s.mockArtifactStorage.On("DeleteOlderThan", mock.Anything, "cache/", mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= time.Hour && time.Since(before) < time.Hour+time.Minute
+	})).Return(2, nil)
+
+	s.documentService.evictPDFCache(context.Background())
+
+	s.mockArtifactStorage.AssertExpectations(s.T())
+}
+
+func (s *TestSuiteDocumentService) TestDeleteFinishedRenderJobs() {
+	s.documentService.renderJobRetention = 24 * time.Hour
+	s.mockDocumentRepository.On("DeleteFinishedRenderJobs", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= 24*time.Hour && time.Since(before) < 24*time.Hour+time.Minute
+	})).Return(int64(3), nil)
+
+	s.documentService.deleteFinishedRenderJobs(context.Background())
+
+	s.mockDocumentRepository.AssertExpectations(s.T())
 }
 
 func (s *TestSuiteDocumentService) TestFillMapFields_NotSignedYet() {
@@ -635,12 +1982,152 @@ func (s *TestSuiteDocumentService) TestFillMapFields_NotSignedYet() {
 		"footer":     "",
 	}
 
-	m, err := s.documentService.fillMapFields(doc)
+	m, err := s.documentService.fillMapFields(context.Background(), doc)
+
+	s.Equal(expectedMap, m)
+	s.NoError(err)
+}
+
+func (s *TestSuiteDocumentService) TestFillMapFields_ApplicantField() {
+	doc := &entity.Document{
+		ID:         "1",
+		RegisterID: 123,
+		Applicant: entity.User{
+			ID:      "1",
+			Name:    "Budi Baru",
+			Address: "Jl. Merdeka No. 1",
+		},
+		Fields: []entity.DocumentField{
+			{
+				TemplateField: entity.TemplateField{
+					Key: "applicant.name",
+				},
+				Value: "Budi",
+			},
+			{
+				TemplateField: entity.TemplateField{
+					Key: "applicant.address",
+				},
+				Value: "",
+			},
+		},
+	}
+
+	expectedMap := &map[string]interface{}{
+		"applicant": map[string]interface{}{
+			"name":    "Budi",
+			"address": "",
+		},
+		"register":   uint(123),
+		"signedDate": "",
+		"signature":  "",
+		"footer":     "",
+	}
+
+	m, err := s.documentService.fillMapFields(context.Background(), doc)
+
+	s.Equal(expectedMap, m)
+	s.NoError(err)
+}
+
+func (s *TestSuiteDocumentService) TestFillMapFields_TableField() {
+	doc := &entity.Document{
+		ID:         "1",
+		RegisterID: 123,
+		Fields: []entity.DocumentField{
+			{
+				TemplateField: entity.TemplateField{
+					Key:  "family",
+					Type: entity.FieldTypeTable,
+					Columns: entity.TableColumns{
+						{Key: "name", Type: entity.ColumnTypeText},
+						{Key: "age", Type: entity.ColumnTypeNumber},
+					},
+				},
+				Rows: entity.TableRows{
+					{"name": "Budi", "age": "12"},
+					{"name": "Siti", "age": "7.5"},
+				},
+			},
+		},
+	}
+
+	expectedMap := &map[string]interface{}{
+		"family": []map[string]interface{}{
+			{"name": "Budi", "age": int64(12)},
+			{"name": "Siti", "age": 7.5},
+		},
+		"register":   uint(123),
+		"signedDate": "",
+		"signature":  "",
+		"footer":     "",
+	}
+
+	m, err := s.documentService.fillMapFields(context.Background(), doc)
+
+	s.Equal(expectedMap, m)
+	s.NoError(err)
+}
+
+func (s *TestSuiteDocumentService) TestFillMapFields_ComputedField() {
+	doc := &entity.Document{
+		ID:         "1",
+		RegisterID: 123,
+		Fields: []entity.DocumentField{
+			{
+				TemplateField: entity.TemplateField{Key: "issue_date"},
+				Value:         "2022-08-01",
+			},
+			{
+				TemplateField: entity.TemplateField{Key: "applicant.sex"},
+				Value:         "L",
+			},
+			{
+				TemplateField: entity.TemplateField{Key: "valid_until", Expression: "addMonths(issue_date, 6)"},
+			},
+			{
+				TemplateField: entity.TemplateField{Key: "salutation", Expression: `cond(applicant.sex == "L", "Bapak", "Ibu")`},
+			},
+			{
+				TemplateField: entity.TemplateField{Key: "number", Expression: `"470/" + register`},
+			},
+		},
+	}
+
+	expectedMap := &map[string]interface{}{
+		"issue_date":  "2022-08-01",
+		"applicant":   map[string]interface{}{"sex": "L"},
+		"valid_until": "01 Februari 2023",
+		"salutation":  "Bapak",
+		"number":      "470/123",
+		"register":    uint(123),
+		"signedDate":  "",
+		"signature":   "",
+		"footer":      "",
+	}
+
+	m, err := s.documentService.fillMapFields(context.Background(), doc)
 
 	s.Equal(expectedMap, m)
 	s.NoError(err)
 }
 
+func (s *TestSuiteDocumentService) TestFillMapFields_ComputedFieldError() {
+	doc := &entity.Document{
+		ID: "1",
+		Fields: []entity.DocumentField{
+			{
+				TemplateField: entity.TemplateField{Key: "valid_until", Expression: "addMonths(issue_date, 6)"},
+			},
+		},
+	}
+
+	m, err := s.documentService.fillMapFields(context.Background(), doc)
+
+	s.Nil(m)
+	s.Equal(utils.ErrExpressionEvaluation, err)
+}
+
 func (s *TestSuiteDocumentService) TestFillMapFields_SignatureError() {
 	now := time.Now()
 
@@ -704,7 +2191,7 @@ func (s *TestSuiteDocumentService) TestFillMapFields_SignatureError() {
 
 	s.mockRenderService.On("GenerateSignature", mock.Anything, mock.Anything).Return((*template.HTML)(nil), errors.New("error"))
 
-	m, err := s.documentService.fillMapFields(doc)
+	m, err := s.documentService.fillMapFields(context.Background(), doc)
 	s.Nil(m)
 	s.Equal(errors.New("error"), err)
 }
@@ -773,7 +2260,7 @@ func (s *TestSuiteDocumentService) TestFillMapFields_FooterError() {
 	s.mockRenderService.On("GenerateSignature", mock.Anything, mock.Anything).Return(&templateHtml, nil)
 	s.mockRenderService.On("GenerateFooter", mock.Anything, mock.Anything).Return((*template.HTML)(nil), errors.New("error"))
 
-	m, err := s.documentService.fillMapFields(doc)
+	m, err := s.documentService.fillMapFields(context.Background(), doc)
 	s.Nil(m)
 	s.Equal(errors.New("error"), err)
 }
@@ -843,7 +2330,7 @@ func (s *TestSuiteDocumentService) TestFillMapFields_Success() {
 	expectedMap := &map[string]interface{}{
 		"field1":     "value1",
 		"register":   uint(123),
-		"signedDate": now.Format("02 January 2006"),
+		"signedDate": indonesian.FormatDate(now),
 		"signature":  &templateHtml,
 		"footer":     &templateHtml,
 	}
@@ -851,7 +2338,7 @@ func (s *TestSuiteDocumentService) TestFillMapFields_Success() {
 	s.mockRenderService.On("GenerateSignature", mock.Anything, mock.Anything).Return(&templateHtml, nil)
 	s.mockRenderService.On("GenerateFooter", mock.Anything, mock.Anything).Return(&templateHtml, nil)
 
-	m, err := s.documentService.fillMapFields(doc)
+	m, err := s.documentService.fillMapFields(context.Background(), doc)
 
 	s.Equal(expectedMap, m)
 	s.NoError(err)
@@ -1002,14 +2489,129 @@ func (s *TestSuiteDocumentService) TestVerifyDocument_RepositoryError() {
 	s.Equal(errors.New("error"), err)
 }
 
-func (s *TestSuiteDocumentService) TestSignDocument_Success() {
+// mockSignRender mock the render of the signed document, return the rendered pdf
+func (s *TestSuiteDocumentService) mockSignRender(pdfErr error) []byte {
 	returnedStage := 2
 	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "1").Return(&returnedStage, nil)
-	s.mockDocumentRepository.On("SignDocument", mock.Anything, mock.Anything).Return(nil)
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{
+		ID:         "1",
+		RegisterID: 123,
+		StageID:    2,
+		Template: entity.Template{
+			Name:            "Surat Keterangan",
+			SignaturePage:   1,
+			SignatureX:      120,
+			SignatureY:      230,
+			SignatureWidth:  60,
+			SignatureHeight: 20,
+		},
+	}, nil)
+	s.mockUserRepository.On("FindByID", mock.Anything, "2").Return(&entity.User{
+		ID:       "2",
+		Name:     "Signer",
+		Position: "Lurah",
+	}, nil)
 
-	err := s.documentService.SignDocument(context.Background(), "1", "1")
+	signatureHTML := template.HTML("signature")
+	footer := template.HTML("footer")
+	s.mockRenderService.On("GenerateSignature", mock.Anything, entity.User{ID: "2", Name: "Signer", Position: "Lurah"}).Return(&signatureHTML, nil)
+	s.mockRenderService.On("GenerateFooter", mock.MatchedBy(func(document *entity.Document) bool {
+		return document.StageID == 3 && document.SignerID == "2" && !document.SignedAt.IsZero()
+	})).Return(&footer, nil)
+	s.mockRenderService.On("GenerateHeader", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+	s.mockRenderService.On("GeneratePageFooter", mock.Anything, mock.Anything).Return((*bytes.Buffer)(nil), nil)
+	s.mockRenderService.On("GenerateHTMLDocument", mock.Anything, mock.MatchedBy(func(data *map[string]interface{}) bool {
+		return (*data)["signature"] == &signatureHTML
+	})).Return(bytes.NewBufferString("<html></html>"), nil)
+
+	renderedPDF := []byte("rendered pdf")
+	s.mockPDFService.On("GeneratePDF", mock.Anything, mock.Anything, mock.Anything).Return(renderedPDF, pdfErr)
+
+	return renderedPDF
+}
+
+// mockSignPDF mock the digital signature of the rendered pdf using the template signature field, return the signed pdf
+func (s *TestSuiteDocumentService) mockSignPDF(renderedPDF []byte, signErr error) []byte {
+	signedPDF := []byte("signed pdf")
+	s.mockPDFSigner.On("SignPDF", mock.Anything, renderedPDF, mock.MatchedBy(func(options *signature.SignOptions) bool {
+		return options.SignerID == "2" && options.Name == "Signer" && options.Reason == "Surat Keterangan" && !options.SigningTime.IsZero() &&
+			options.Page == 1 && options.X == 120 && options.Y == 230 && options.Width == 60 && options.Height == 20
+	})).Return(signedPDF, signErr)
+
+	return signedPDF
+}
+
+func (s *TestSuiteDocumentService) TestSignDocument_Success() {
+	signedPDF := s.mockSignPDF(s.mockSignRender(nil), nil)
+	var key string
+	s.mockArtifactStorage.On("Put", mock.Anything, mock.MatchedBy(func(k string) bool {
+		return strings.HasPrefix(k, "signed/1/") && strings.HasSuffix(k, ".pdf")
+	}), signedPDF).Run(func(args mock.Arguments) {
+		key = args.String(1)
+	}).Return(nil)
+	s.mockArtifactStorage.On("Put", mock.Anything, mock.MatchedBy(func(k string) bool {
+		return k == signedSnapshotKey(key)
+	}), mock.MatchedBy(func(content []byte) bool {
+		var snapshot signedSnapshot
+		return json.Unmarshal(content, &snapshot) == nil && snapshot.HTML == "<html></html>"
+	})).Return(nil)
+	s.mockDocumentRepository.On("SignDocument", mock.Anything, mock.MatchedBy(func(document *entity.Document) bool {
+		return document.SignedPDFKey == key &&
+			document.SignedPDFHash == "e665557ce39ded5926281b3344d88bbe476b1d67d17908323b4573389c3e04ae"
+	})).Return(nil)
+	s.mockArtifactStorage.On("DeletePrefix", mock.Anything, "cache/1/").Return(nil)
+
+	err := s.documentService.SignDocument(context.Background(), "1", "2")
 
 	s.NoError(err)
+	s.mockArtifactStorage.AssertNotCalled(s.T(), "DeletePrefix", mock.Anything, key)
+}
+
+func (s *TestSuiteDocumentService) TestSignDocument_ErrorRender() {
+	s.mockSignRender(errors.New("error"))
+
+	err := s.documentService.SignDocument(context.Background(), "1", "2")
+
+	s.Equal(errors.New("error"), err)
+	s.mockArtifactStorage.AssertNotCalled(s.T(), "Put", mock.Anything, mock.Anything, mock.Anything)
+	s.mockDocumentRepository.AssertNotCalled(s.T(), "SignDocument", mock.Anything, mock.Anything)
+}
+
+func (s *TestSuiteDocumentService) TestSignDocument_ErrorSigningPDF() {
+	s.mockSignPDF(s.mockSignRender(nil), utils.ErrSigningKeyNotFound)
+
+	err := s.documentService.SignDocument(context.Background(), "1", "2")
+
+	s.Equal(utils.ErrSigningKeyNotFound, err)
+	s.mockArtifactStorage.AssertNotCalled(s.T(), "Put", mock.Anything, mock.Anything, mock.Anything)
+	s.mockDocumentRepository.AssertNotCalled(s.T(), "SignDocument", mock.Anything, mock.Anything)
+}
+
+func (s *TestSuiteDocumentService) TestSignDocument_ErrorStoringSnapshot() {
+	signedPDF := s.mockSignPDF(s.mockSignRender(nil), nil)
+	var key string
+	s.mockArtifactStorage.On("Put", mock.Anything, mock.Anything, signedPDF).Run(func(args mock.Arguments) {
+		key = args.String(1)
+	}).Return(nil)
+	s.mockArtifactStorage.On("Put", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("error"))
+	s.mockArtifactStorage.On("DeletePrefix", mock.Anything, mock.Anything).Return(nil)
+
+	err := s.documentService.SignDocument(context.Background(), "1", "2")
+
+	s.Equal(errors.New("error"), err)
+	s.mockArtifactStorage.AssertCalled(s.T(), "DeletePrefix", mock.Anything, key)
+	s.mockDocumentRepository.AssertNotCalled(s.T(), "SignDocument", mock.Anything, mock.Anything)
+}
+
+func (s *TestSuiteDocumentService) TestSignDocument_ErrorSignerNotFound() {
+	returnedStage := 2
+	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "1").Return(&returnedStage, nil)
+	s.mockDocumentRepository.On("GetDocument", mock.Anything, "1").Return(&entity.Document{ID: "1"}, nil)
+	s.mockUserRepository.On("FindByID", mock.Anything, "2").Return((*entity.User)(nil), utils.ErrUserNotFound)
+
+	err := s.documentService.SignDocument(context.Background(), "1", "2")
+
+	s.Equal(utils.ErrUserNotFound, err)
 }
 
 func (s *TestSuiteDocumentService) TestSignDocument_ErrorGettingStage() {
@@ -1039,13 +2641,216 @@ func (s *TestSuiteDocumentService) TestSignDocument_ErrorNotVerified() {
 }
 
 func (s *TestSuiteDocumentService) TestSignDocument_RepositoryError() {
-	returnedStage := 2
-	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "1").Return(&returnedStage, nil)
-	s.mockDocumentRepository.On("SignDocument", mock.Anything, mock.Anything).Return(errors.New("error"))
+	for _, tc := range []struct {
+		Name string
+		Err  error
+	}{
+		{
+			Name: "Signed by another request",
+			Err:  utils.ErrAlreadySigned,
+		},
+		{
+			Name: "Generic error",
+			Err:  errors.New("error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			signedPDF := s.mockSignPDF(s.mockSignRender(nil), nil)
+
+			var key string
+			s.mockArtifactStorage.On("Put", mock.Anything, mock.Anything, signedPDF).Run(func(args mock.Arguments) {
+				key = args.String(1)
+			}).Return(nil)
+			s.mockArtifactStorage.On("Put", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			s.mockDocumentRepository.On("SignDocument", mock.Anything, mock.Anything).Return(tc.Err)
+			s.mockArtifactStorage.On("DeletePrefix", mock.Anything, mock.Anything).Return(nil)
+
+			err := s.documentService.SignDocument(context.Background(), "1", "2")
+
+			s.Equal(tc.Err, err)
+			s.mockArtifactStorage.AssertCalled(s.T(), "DeletePrefix", mock.Anything, key)
+			s.mockArtifactStorage.AssertCalled(s.T(), "DeletePrefix", mock.Anything, signedSnapshotKey(key))
+			s.mockArtifactStorage.AssertNotCalled(s.T(), "DeletePrefix", mock.Anything, "cache/1/")
+		})
+		s.TearDownTest()
+	}
+}
 
-	err := s.documentService.SignDocument(context.Background(), "1", "1")
+func (s *TestSuiteDocumentService) TestRevokeDocument() {
+	for _, tc := range []struct {
+		Name           string
+		Document       *entity.Document
+		StatusErr      error
+		RevokeErr      error
+		ExpectedRevoke bool
+		ExpectedErr    error
+	}{
+		{
+			Name:           "Success",
+			Document:       &entity.Document{ID: "1", StageID: 3},
+			ExpectedRevoke: true,
+		},
+		{
+			Name:        "Error document not found",
+			StatusErr:   utils.ErrDocumentNotFound,
+			ExpectedErr: utils.ErrDocumentNotFound,
+		},
+		{
+			Name:        "Error document not signed yet",
+			Document:    &entity.Document{ID: "1", StageID: 2},
+			ExpectedErr: utils.ErrNotSignedYet,
+		},
+		{
+			Name:        "Error document already revoked",
+			Document:    &entity.Document{ID: "1", StageID: 3, RevokedAt: time.Now()},
+			ExpectedErr: utils.ErrAlreadyRevoked,
+		},
+		{
+			Name:           "Error repository",
+			Document:       &entity.Document{ID: "1", StageID: 3},
+			RevokeErr:      errors.New("error"),
+			ExpectedRevoke: true,
+			ExpectedErr:    errors.New("error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			s.mockDocumentRepository.On("GetDocumentStatus", mock.Anything, "1").Return(tc.Document, tc.StatusErr)
+			s.mockDocumentRepository.On("RevokeDocument", mock.Anything, mock.MatchedBy(func(document *entity.Document) bool {
+				return document.ID == "1" && document.RevokeReason == "salah penerima" && !document.RevokedAt.IsZero()
+			})).Return(tc.RevokeErr)
+
+			err := s.documentService.RevokeDocument(context.Background(), "1", &dto.RevokeDocumentRequest{Reason: "salah penerima"})
+
+			s.Equal(tc.ExpectedErr, err)
+			if tc.ExpectedRevoke {
+				s.mockDocumentRepository.AssertCalled(s.T(), "RevokeDocument", mock.Anything, mock.Anything)
+			} else {
+				s.mockDocumentRepository.AssertNotCalled(s.T(), "RevokeDocument", mock.Anything, mock.Anything)
+			}
+		})
+		s.TearDownTest()
+	}
+}
 
-	s.Equal(errors.New("error"), err)
+func (s *TestSuiteDocumentService) TestVerifyDocumentFile() {
+	signedAt := time.Date(2022, 10, 10, 9, 30, 0, 0, time.UTC)
+	revokedAt := time.Date(2022, 11, 1, 8, 0, 0, 0, time.UTC)
+	issued := []byte("%PDF-1.4 signed letter")
+	modified := append(append([]byte{}, issued...), []byte(" appended")...)
+	document := &entity.Document{
+		ID:         "1",
+		RegisterID: 123,
+		Template:   entity.Template{Name: "Surat Keterangan"},
+		Signer:     entity.User{ID: "2", Name: "Budi"},
+		SignedAt:   signedAt,
+	}
+	revoked := *document
+	revoked.RevokedAt = revokedAt
+	revoked.RevokeReason = "salah penerima"
+
+	signedInfo := signature.SignatureInfo{Signer: "Budi", Name: "Budi", Reason: "Surat Keterangan", SigningTime: signedAt, Intact: true, Trusted: true, SignedLength: len(issued)}
+	coveringInfo := signedInfo
+	coveringInfo.CoversDocument = true
+	signatureResponse := dto.EmbeddedSignatureResponse{Certificate: "Budi", Name: "Budi", Reason: "Surat Keterangan", SigningTime: signedAt, Intact: true, Trusted: true}
+	coveringResponse := signatureResponse
+	coveringResponse.CoversDocument = true
+
+	for _, tc := range []struct {
+		Name             string
+		Content          []byte
+		Signatures       []signature.SignatureInfo
+		VerifyErr        error
+		Documents        map[string]*entity.Document
+		RepositoryErr    error
+		ExpectedResponse *dto.DocumentVerificationResponse
+		ExpectedErr      error
+	}{
+		{
+			Name:       "Success with the issued letter",
+			Content:    issued,
+			Signatures: []signature.SignatureInfo{coveringInfo},
+			Documents:  map[string]*entity.Document{hashPDF(issued): document},
+			ExpectedResponse: &dto.DocumentVerificationResponse{
+				Hash:       hashPDF(issued),
+				Authentic:  true,
+				Document:   &dto.VerifiedDocumentResponse{ID: "1", RegisterID: 123, Template: "Surat Keterangan", Signer: userDto.EmployeeResponse{ID: "2", Name: "Budi"}, SignedAt: signedAt},
+				Signatures: []dto.EmbeddedSignatureResponse{coveringResponse},
+			},
+		},
+		{
+			Name:       "Success with revoked letter",
+			Content:    issued,
+			Signatures: []signature.SignatureInfo{coveringInfo},
+			Documents:  map[string]*entity.Document{hashPDF(issued): &revoked},
+			ExpectedResponse: &dto.DocumentVerificationResponse{
+				Hash:      hashPDF(issued),
+				Authentic: true,
+				Document: &dto.VerifiedDocumentResponse{ID: "1", RegisterID: 123, Template: "Surat Keterangan", Signer: userDto.EmployeeResponse{ID: "2", Name: "Budi"}, SignedAt: signedAt,
+					Revoked: true, RevokedAt: revokedAt, RevokeReason: "salah penerima"},
+				Signatures: []dto.EmbeddedSignatureResponse{coveringResponse},
+			},
+		},
+		{
+			Name:       "Success with letter changed after signing",
+			Content:    modified,
+			Signatures: []signature.SignatureInfo{signedInfo},
+			Documents:  map[string]*entity.Document{hashPDF(issued): document},
+			ExpectedResponse: &dto.DocumentVerificationResponse{
+				Hash:       hashPDF(modified),
+				Modified:   true,
+				Document:   &dto.VerifiedDocumentResponse{ID: "1", RegisterID: 123, Template: "Surat Keterangan", Signer: userDto.EmployeeResponse{ID: "2", Name: "Budi"}, SignedAt: signedAt},
+				Signatures: []dto.EmbeddedSignatureResponse{signatureResponse},
+			},
+		},
+		{
+			Name:       "Success with unknown PDF",
+			Content:    modified,
+			Signatures: []signature.SignatureInfo{},
+			Documents:  map[string]*entity.Document{},
+			ExpectedResponse: &dto.DocumentVerificationResponse{
+				Hash:       hashPDF(modified),
+				Signatures: []dto.EmbeddedSignatureResponse{},
+			},
+		},
+		{
+			Name:        "Error not a PDF",
+			Content:     []byte("not a pdf"),
+			VerifyErr:   utils.ErrUnsupportedPDF,
+			ExpectedErr: utils.ErrUnsupportedPDF,
+		},
+		{
+			Name:        "Error PDF too large",
+			Content:     make([]byte, maxVerifiedPDFSize+1),
+			ExpectedErr: utils.ErrPDFTooLarge,
+		},
+		{
+			Name:          "Error repository",
+			Content:       issued,
+			Signatures:    []signature.SignatureInfo{coveringInfo},
+			RepositoryErr: errors.New("error"),
+			ExpectedErr:   errors.New("error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			s.mockPDFVerifier.On("VerifyPDF", mock.Anything, tc.Content).Return(tc.Signatures, tc.VerifyErr)
+			for hash, document := range tc.Documents {
+				s.mockDocumentRepository.On("GetDocumentBySignedPDFHash", mock.Anything, hash).Return(document, nil)
+			}
+			if tc.RepositoryErr != nil {
+				s.mockDocumentRepository.On("GetDocumentBySignedPDFHash", mock.Anything, mock.Anything).Return((*entity.Document)(nil), tc.RepositoryErr)
+			}
+			s.mockDocumentRepository.On("GetDocumentBySignedPDFHash", mock.Anything, mock.Anything).Return((*entity.Document)(nil), utils.ErrDocumentNotFound)
+
+			response, err := s.documentService.VerifyDocumentFile(context.Background(), bytes.NewReader(tc.Content))
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedResponse, response)
+		})
+		s.TearDownTest()
+	}
 }
 
 func (s *TestSuiteDocumentService) TestDeleteDocument_SuccesWitUserRole() {
@@ -1056,8 +2861,9 @@ func (s *TestSuiteDocumentService) TestDeleteDocument_SuccesWitUserRole() {
 	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "documentid").Return(&stageReturned, nil)
 
 	s.mockDocumentRepository.On("DeleteDocument", mock.Anything, "documentid").Return(nil)
+	s.mockArtifactStorage.On("DeletePrefix", mock.Anything, "cache/documentid/").Return(nil)
 
-	err := s.documentService.DeleteDocument(context.Background(), "userid", 1, "documentid")
+	err := s.documentService.DeleteDocument(context.Background(), "userid", true, "documentid")
 
 	s.NoError(err)
 }
@@ -1067,8 +2873,9 @@ func (s *TestSuiteDocumentService) TestDeleteDocument_SuccesWithAdminRole() {
 	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "documentid").Return(&stageReturned, nil)
 
 	s.mockDocumentRepository.On("DeleteDocument", mock.Anything, "documentid").Return(nil)
+	s.mockArtifactStorage.On("DeletePrefix", mock.Anything, "cache/documentid/").Return(nil)
 
-	err := s.documentService.DeleteDocument(context.Background(), "userid", 2, "documentid")
+	err := s.documentService.DeleteDocument(context.Background(), "userid", false, "documentid")
 
 	s.NoError(err)
 }
@@ -1076,7 +2883,7 @@ func (s *TestSuiteDocumentService) TestDeleteDocument_SuccesWithAdminRole() {
 func (s *TestSuiteDocumentService) TestDeleteDocument_ErrorGettingApplicantID() {
 	s.mockDocumentRepository.On("GetApplicantID", mock.Anything, "documentid").Return((*string)(nil), errors.New("error"))
 
-	err := s.documentService.DeleteDocument(context.Background(), "userid", 1, "documentid")
+	err := s.documentService.DeleteDocument(context.Background(), "userid", true, "documentid")
 
 	s.Equal(errors.New("error"), err)
 }
@@ -1085,7 +2892,7 @@ func (s *TestSuiteDocumentService) TestDeleteDocument_ErrorRoleNotSufficentToDel
 	userIDReturned := "userid2"
 	s.mockDocumentRepository.On("GetApplicantID", mock.Anything, "documentid").Return(&userIDReturned, nil)
 
-	err := s.documentService.DeleteDocument(context.Background(), "userid", 1, "documentid")
+	err := s.documentService.DeleteDocument(context.Background(), "userid", true, "documentid")
 
 	s.Equal(utils.ErrDidntHavePermission, err)
 }
@@ -1096,7 +2903,7 @@ func (s *TestSuiteDocumentService) TestDeleteDocument_ErrorGettingDocumentStage(
 
 	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "documentid").Return((*int)(nil), errors.New("error"))
 
-	err := s.documentService.DeleteDocument(context.Background(), "userid", 1, "documentid")
+	err := s.documentService.DeleteDocument(context.Background(), "userid", true, "documentid")
 
 	s.Equal(errors.New("error"), err)
 }
@@ -1108,7 +2915,7 @@ func (s *TestSuiteDocumentService) TestDeleteDocument_ErrorDocumentAlreadySigned
 	stageReturned := 3
 	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "documentid").Return(&stageReturned, nil)
 
-	err := s.documentService.DeleteDocument(context.Background(), "userid", 1, "documentid")
+	err := s.documentService.DeleteDocument(context.Background(), "userid", true, "documentid")
 
 	s.Equal(utils.ErrAlreadySigned, err)
 }
@@ -1118,6 +2925,7 @@ func (s *TestSuiteDocumentService) TestUpdateDocument_Success() {
 	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "documentid").Return(&stageReturned, nil)
 
 	s.mockDocumentRepository.On("UpdateDocument", mock.Anything, mock.Anything).Return(nil)
+	s.mockArtifactStorage.On("DeletePrefix", mock.Anything, "cache/documentid/").Return(nil)
 
 	err := s.documentService.UpdateDocument(context.Background(), &dto.DocumentUpdateRequest{}, "documentid")
 
@@ -1164,10 +2972,12 @@ func (s *TestSuiteDocumentService) TestUpdateDocument_RepositoryError() {
 func (s *TestSuiteDocumentService) TestUpdateDocumentFields_SuccessWithAdminAccess() {
 	stageReturned := 1
 	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "documentid").Return(&stageReturned, nil)
+	s.mockDocumentRepository.On("GetDocumentFields", mock.Anything, "documentid").Return(&entity.DocumentFields{}, nil)
 
 	s.mockDocumentRepository.On("UpdateDocumentFields", mock.Anything, mock.Anything).Return(nil)
+	s.mockArtifactStorage.On("DeletePrefix", mock.Anything, "cache/documentid/").Return(nil)
 
-	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", 3, "documentid", &dto.FieldsUpdateRequest{})
+	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", false, "documentid", &dto.FieldsUpdateRequest{})
 
 	s.NoError(err)
 }
@@ -1178,18 +2988,230 @@ func (s *TestSuiteDocumentService) TestUpdateDocumentFields_SuccessWithApplicant
 
 	stageReturned := 1
 	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "documentid").Return(&stageReturned, nil)
+	s.mockDocumentRepository.On("GetDocumentFields", mock.Anything, "documentid").Return(&entity.DocumentFields{}, nil)
 
 	s.mockDocumentRepository.On("UpdateDocumentFields", mock.Anything, mock.Anything).Return(nil)
+	s.mockArtifactStorage.On("DeletePrefix", mock.Anything, "cache/documentid/").Return(nil)
 
-	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", 1, "documentid", &dto.FieldsUpdateRequest{})
+	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", true, "documentid", &dto.FieldsUpdateRequest{})
 
 	s.NoError(err)
 }
 
+func (s *TestSuiteDocumentService) TestUpdateDocumentFields_TableRows() {
+	familyField := entity.TemplateField{
+		Key:  "family",
+		Type: entity.FieldTypeTable,
+		Columns: entity.TableColumns{
+			{Key: "name", Type: entity.ColumnTypeText, Required: true},
+			{Key: "age", Type: entity.ColumnTypeNumber},
+		},
+	}
+
+	for _, tc := range []struct {
+		Name        string
+		Request     dto.FieldUpdateRequest
+		ExpectedErr error
+	}{
+		{
+			Name: "Success reorder rows",
+			Request: dto.FieldUpdateRequest{
+				ID:   1,
+				Rows: entity.TableRows{{"name": "Siti", "age": "12"}, {"name": "Budi"}},
+			},
+		},
+		{
+			Name:    "Success remove all rows",
+			Request: dto.FieldUpdateRequest{ID: 1},
+		},
+		{
+			Name: "Error missing required column",
+			Request: dto.FieldUpdateRequest{
+				ID:   1,
+				Rows: entity.TableRows{{"age": "12"}},
+			},
+			ExpectedErr: utils.ErrInvalidTableRow,
+		},
+		{
+			Name: "Error invalid number",
+			Request: dto.FieldUpdateRequest{
+				ID:   1,
+				Rows: entity.TableRows{{"name": "Budi", "age": "dua belas"}},
+			},
+			ExpectedErr: utils.ErrInvalidTableRow,
+		},
+		{
+			Name: "Error not a finite number",
+			Request: dto.FieldUpdateRequest{
+				ID:   1,
+				Rows: entity.TableRows{{"name": "Budi", "age": "NaN"}, {"name": "Siti", "age": "-Inf"}},
+			},
+			ExpectedErr: utils.ErrInvalidTableRow,
+		},
+		{
+			Name: "Error number out of range",
+			Request: dto.FieldUpdateRequest{
+				ID:   1,
+				Rows: entity.TableRows{{"name": "Budi", "age": "1e300"}},
+			},
+			ExpectedErr: utils.ErrInvalidTableRow,
+		},
+		{
+			Name: "Error unknown column",
+			Request: dto.FieldUpdateRequest{
+				ID:   1,
+				Rows: entity.TableRows{{"name": "Budi", "nik": "123"}},
+			},
+			ExpectedErr: utils.ErrInvalidTableRow,
+		},
+		{
+			Name:        "Error value on table field",
+			Request:     dto.FieldUpdateRequest{ID: 1, Value: "Budi"},
+			ExpectedErr: utils.ErrFieldNotMatch,
+		},
+		{
+			Name:        "Error field not found",
+			Request:     dto.FieldUpdateRequest{ID: 2, Value: "Budi"},
+			ExpectedErr: utils.ErrFieldNotFound,
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			stageReturned := 1
+			s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "documentid").Return(&stageReturned, nil)
+			s.mockDocumentRepository.On("GetDocumentFields", mock.Anything, "documentid").Return(&entity.DocumentFields{
+				{
+					Model:         gorm.Model{ID: 1},
+					TemplateField: familyField,
+				},
+			}, nil)
+			s.mockDocumentRepository.On("UpdateDocumentFields", mock.Anything, mock.MatchedBy(func(fields *entity.DocumentFields) bool {
+				return (*fields)[0].Rows != nil
+			})).Return(nil)
+			s.mockArtifactStorage.On("DeletePrefix", mock.Anything, "cache/documentid/").Return(nil)
+
+			err := s.documentService.UpdateDocumentFields(context.Background(), "userid", false, "documentid", &dto.FieldsUpdateRequest{
+				Fields: []dto.FieldUpdateRequest{tc.Request},
+			})
+
+			s.Equal(tc.ExpectedErr, err)
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteDocumentService) TestUpdateDocumentFields_FieldRules() {
+	for _, tc := range []struct {
+		Name          string
+		MaritalStatus string
+		SpouseName    string
+		Request       []dto.FieldUpdateRequest
+		Expected      entity.DocumentFields
+		ExpectedErr   error
+	}{
+		{
+			Name:          "Success clear field whose condition no longer met",
+			MaritalStatus: "Kawin",
+			SpouseName:    "Ani",
+			Request:       []dto.FieldUpdateRequest{{ID: 1, Value: "Belum Kawin"}},
+			Expected: entity.DocumentFields{
+				{Model: gorm.Model{ID: 1}, DocumentID: "documentid", Value: "Belum Kawin"},
+				{Model: gorm.Model{ID: 3}, DocumentID: "documentid", TemplateFieldID: 3},
+			},
+		},
+		{
+			Name:          "Success condition met with value",
+			MaritalStatus: "Belum Kawin",
+			Request:       []dto.FieldUpdateRequest{{ID: 1, Value: "Kawin"}, {ID: 3, Value: "Ani"}},
+			Expected: entity.DocumentFields{
+				{Model: gorm.Model{ID: 1}, DocumentID: "documentid", Value: "Kawin"},
+				{Model: gorm.Model{ID: 3}, DocumentID: "documentid", Value: "Ani"},
+			},
+		},
+		{
+			Name:          "Error condition met without value",
+			MaritalStatus: "Belum Kawin",
+			Request:       []dto.FieldUpdateRequest{{ID: 1, Value: "Kawin"}},
+			ExpectedErr:   utils.ErrFieldNotMatch,
+		},
+		{
+			Name:          "Error update field whose condition didn't hold",
+			MaritalStatus: "Belum Kawin",
+			Request:       []dto.FieldUpdateRequest{{ID: 3, Value: "Ani"}},
+			ExpectedErr:   utils.ErrFieldNotMatch,
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			stageReturned := 1
+			s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "documentid").Return(&stageReturned, nil)
+			s.mockDocumentRepository.On("GetDocumentFields", mock.Anything, "documentid").Return(&entity.DocumentFields{
+				{
+					Model:           gorm.Model{ID: 1},
+					DocumentID:      "documentid",
+					TemplateFieldID: 1,
+					TemplateField:   entity.TemplateField{Model: gorm.Model{ID: 1}, Key: "marital_status"},
+					Value:           tc.MaritalStatus,
+				},
+				{
+					Model:           gorm.Model{ID: 2},
+					DocumentID:      "documentid",
+					TemplateFieldID: 2,
+					TemplateField:   entity.TemplateField{Model: gorm.Model{ID: 2}, Key: "status", Expression: "upper(marital_status)"},
+				},
+				{
+					Model:           gorm.Model{ID: 3},
+					DocumentID:      "documentid",
+					TemplateFieldID: 3,
+					TemplateField:   entity.TemplateField{Model: gorm.Model{ID: 3}, Key: "spouse_name", Condition: `status == "KAWIN"`},
+					Value:           tc.SpouseName,
+				},
+			}, nil)
+			s.mockDocumentRepository.On("GetBriefDocument", mock.Anything, "documentid").Return(&entity.Document{ID: "documentid"}, nil)
+			s.mockDocumentRepository.On("UpdateDocumentFields", mock.Anything, mock.MatchedBy(func(fields *entity.DocumentFields) bool {
+				return s.Equal(tc.Expected, *fields)
+			})).Return(nil)
+			s.mockArtifactStorage.On("DeletePrefix", mock.Anything, "cache/documentid/").Return(nil)
+
+			err := s.documentService.UpdateDocumentFields(context.Background(), "userid", false, "documentid", &dto.FieldsUpdateRequest{
+				Fields: tc.Request,
+			})
+
+			s.Equal(tc.ExpectedErr, err)
+			if tc.ExpectedErr != nil {
+				s.mockDocumentRepository.AssertNotCalled(s.T(), "UpdateDocumentFields", mock.Anything, mock.Anything)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteDocumentService) TestUpdateDocumentFields_ErrorApplicantFieldReadOnly() {
+	stageReturned := 1
+	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "documentid").Return(&stageReturned, nil)
+	s.mockDocumentRepository.On("GetDocumentFields", mock.Anything, "documentid").Return(&entity.DocumentFields{
+		{
+			Model:         gorm.Model{ID: 1},
+			TemplateField: entity.TemplateField{Key: "applicant.name"},
+			Value:         "Budi",
+		},
+	}, nil)
+
+	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", false, "documentid", &dto.FieldsUpdateRequest{
+		Fields: []dto.FieldUpdateRequest{{ID: 1, Value: "Joko"}},
+	})
+
+	s.Equal(utils.ErrApplicantFieldReadOnly, err)
+	s.mockDocumentRepository.AssertNotCalled(s.T(), "UpdateDocumentFields", mock.Anything, mock.Anything)
+}
+
 func (s *TestSuiteDocumentService) TestUpdateDocumentFields_ErrorGettingApplicantID() {
 	s.mockDocumentRepository.On("GetApplicantID", mock.Anything, "documentid").Return((*string)(nil), errors.New("error"))
 
-	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", 1, "documentid", &dto.FieldsUpdateRequest{})
+	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", true, "documentid", &dto.FieldsUpdateRequest{})
 
 	s.Equal(errors.New("error"), err)
 }
@@ -1198,7 +3220,7 @@ func (s *TestSuiteDocumentService) TestUpdateDocumentFields_ErrorRoleNotSufficen
 	userIDReturned := "userid2"
 	s.mockDocumentRepository.On("GetApplicantID", mock.Anything, "documentid").Return(&userIDReturned, nil)
 
-	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", 1, "documentid", &dto.FieldsUpdateRequest{})
+	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", true, "documentid", &dto.FieldsUpdateRequest{})
 
 	s.Equal(utils.ErrDidntHavePermission, err)
 }
@@ -1209,7 +3231,7 @@ func (s *TestSuiteDocumentService) TestUpdateDocumentFields_ErrorGettingDocument
 
 	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "documentid").Return((*int)(nil), errors.New("error"))
 
-	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", 1, "documentid", &dto.FieldsUpdateRequest{})
+	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", true, "documentid", &dto.FieldsUpdateRequest{})
 
 	s.Equal(errors.New("error"), err)
 }
@@ -1221,7 +3243,7 @@ func (s *TestSuiteDocumentService) TestUpdateDocumentFields_ErrorDocumentAlready
 	stageReturned := 3
 	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "documentid").Return(&stageReturned, nil)
 
-	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", 1, "documentid", &dto.FieldsUpdateRequest{})
+	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", true, "documentid", &dto.FieldsUpdateRequest{})
 
 	s.Equal(utils.ErrAlreadySigned, err)
 }
@@ -1233,7 +3255,7 @@ func (s *TestSuiteDocumentService) TestUpdateDocumentFields_ErrorDocumentAlready
 	stageReturned := 2
 	s.mockDocumentRepository.On("GetDocumentStage", mock.Anything, "documentid").Return(&stageReturned, nil)
 
-	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", 1, "documentid", &dto.FieldsUpdateRequest{})
+	err := s.documentService.UpdateDocumentFields(context.Background(), "userid", true, "documentid", &dto.FieldsUpdateRequest{})
 
 	s.Equal(utils.ErrAlreadyVerified, err)
 }
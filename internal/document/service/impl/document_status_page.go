@@ -0,0 +1,54 @@
+package impl
+
+import (
+	"bytes"
+
+	"github.com/suryaadi44/eAD-System/internal/document/dto"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+// Headline of the verification page for each state of the scanned document
+var statusPageMessages = map[string][2]string{
+	"valid":      {"Dokumen Sah", "Dokumen ini terdaftar dan telah ditandatangani secara elektronik."},
+	"unverified": {"QR Code Belum Terverifikasi", "Dokumen ini terdaftar, namun QR code tidak memuat tanda verifikasi kantor. Cocokkan isi dokumen dengan keterangan di bawah."},
+	"revoked":    {"Dokumen Dicabut", "Dokumen ini telah dicabut dan tidak berlaku lagi."},
+	"unsigned":   {"Dokumen Belum Ditandatangani", "Dokumen ini terdaftar namun belum ditandatangani, sehingga belum berlaku."},
+	"invalid":    {"QR Code Tidak Valid", "QR code ini tidak diterbitkan untuk dokumen tersebut, dokumen kemungkinan dipalsukan."},
+	"notfound":   {"Dokumen Tidak Ditemukan", "Dokumen ini tidak terdaftar di sistem kami."},
+}
+
+// GenerateStatusPage render the verification page of the document status for the citizen scanning the QR code, the
+// signed document is only shown as valid when the QR token is verified. The error of getting the status is shown on
+// the page instead, the unexpected one is returned as is
+func (d *DocumentServiceImpl) GenerateStatusPage(status *dto.DocumentStatusResponse, statusErr error) (*bytes.Buffer, error) {
+	var state string
+	switch {
+	case statusErr == utils.ErrDocumentNotFound:
+		state = "notfound"
+	case statusErr == utils.ErrInvalidQRToken:
+		state = "invalid"
+	case statusErr != nil:
+		return nil, statusErr
+	case status.Revoked:
+		state = "revoked"
+	case status.SignedAt.IsZero():
+		state = "unsigned"
+	case !status.TokenVerified:
+		// anyone can print a QR pointing to a real document id, only the signed token prove the QR is genuine
+		state = "unverified"
+	default:
+		state = "valid"
+	}
+
+	data := map[string]interface{}{
+		"state":   state,
+		"title":   statusPageMessages[state][0],
+		"message": statusPageMessages[state][1],
+	}
+	if status != nil {
+		data["document"] = status
+		data["tokenVerified"] = status.TokenVerified
+	}
+
+	return d.renderService.GenerateStatusPage(&data)
+}
@@ -0,0 +1,76 @@
+package impl
+
+import (
+	"context"
+	"io"
+
+	"github.com/suryaadi44/eAD-System/internal/document/dto"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/signature"
+)
+
+// maxVerifiedPDFSize limit the uploaded PDF, the issued letter is only a few pages
+const maxVerifiedPDFSize = 20 << 20
+
+// VerifyDocumentFile check the uploaded PDF against the stored signed letter and its embedded signature. The PDF
+// that was changed after signing is traced back to the letter through the revision covered by its signature
+func (d *DocumentServiceImpl) VerifyDocumentFile(ctx context.Context, file io.Reader) (*dto.DocumentVerificationResponse, error) {
+	// read one byte past the limit to know if the file exceed it
+	content, err := io.ReadAll(io.LimitReader(file, maxVerifiedPDFSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) > maxVerifiedPDFSize {
+		return nil, utils.ErrPDFTooLarge
+	}
+
+	signatures, err := d.pdfVerifier.VerifyPDF(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := hashPDF(content)
+	response := dto.NewDocumentVerificationResponse(hash, signatures)
+
+	document, err := d.documentRepository.GetDocumentBySignedPDFHash(ctx, hash)
+	if err == nil {
+		response.Authentic = true
+		response.Document = dto.NewVerifiedDocumentResponse(document)
+		return response, nil
+	} else if err != utils.ErrDocumentNotFound {
+		return nil, err
+	}
+
+	document, err = d.findSignedRevision(ctx, content, signatures)
+	if err != nil {
+		return nil, err
+	}
+
+	if document != nil {
+		response.Modified = true
+		response.Document = dto.NewVerifiedDocumentResponse(document)
+	}
+
+	return response, nil
+}
+
+// findSignedRevision look for the stored signed letter among the revision covered by the intact signature, starting
+// from the latest one, it return nil when none of them is an issued letter
+func (d *DocumentServiceImpl) findSignedRevision(ctx context.Context, content []byte, signatures []signature.SignatureInfo) (*entity.Document, error) {
+	for i := len(signatures) - 1; i >= 0; i-- {
+		if !signatures[i].Intact || signatures[i].CoversDocument {
+			continue
+		}
+
+		document, err := d.documentRepository.GetDocumentBySignedPDFHash(ctx, hashPDF(content[:signatures[i].SignedLength]))
+		if err == nil {
+			return document, nil
+		} else if err != utils.ErrDocumentNotFound {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,163 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+)
+
+// pdfCacheRoot is the storage prefix of the PDF cache of every document
+const pdfCacheRoot = "cache/"
+
+// cleanupInterval is how often the expired PDF cache and render job are removed
+const cleanupInterval = time.Hour
+
+// signedPDFKey is a new storage key for the PDF rendered at signing time, every signing attempt write its own file so
+// a concurrent attempt can't overwrite the file of the one that was stored
+func signedPDFKey(documentID string) string {
+	return "signed/" + documentID + "/" + uuid.NewString() + ".pdf"
+}
+
+// signedSnapshotKey is the storage key of the snapshot stored next to the signed PDF
+func signedSnapshotKey(signedPDFKey string) string {
+	return strings.TrimSuffix(signedPDFKey, ".pdf") + ".json"
+}
+
+// signedSnapshot is the rendered html and page options of the issued letter, the other format of the signed document
+// is converted from it so they show the same letter as the signed PDF instead of the live template and profile
+type signedSnapshot struct {
+	HTML       string         `json:"html"`
+	HeaderHTML string         `json:"header_html"`
+	FooterHTML string         `json:"footer_html"`
+	Options    pdf.PDFOptions `json:"options"`
+}
+
+// newSignedSnapshot encode the rendered document, it must be called before the PDF is generated since the PDF
+// service consume the header and footer buffer
+func newSignedSnapshot(html *bytes.Buffer, options *pdf.PDFOptions) ([]byte, error) {
+	snapshot := signedSnapshot{
+		HTML:    html.String(),
+		Options: *options,
+	}
+	if options.HeaderHTML != nil {
+		snapshot.HeaderHTML = options.HeaderHTML.String()
+	}
+	if options.FooterHTML != nil {
+		snapshot.FooterHTML = options.FooterHTML.String()
+	}
+	snapshot.Options.HeaderHTML = nil
+	snapshot.Options.FooterHTML = nil
+
+	return json.Marshal(snapshot)
+}
+
+// getSignedSnapshot return the rendered html and page options stored at signing time
+func (d *DocumentServiceImpl) getSignedSnapshot(ctx context.Context, document *entity.Document) (*bytes.Buffer, *pdf.PDFOptions, error) {
+	content, err := d.artifactStorage.Get(ctx, signedSnapshotKey(document.SignedPDFKey))
+	if err == utils.ErrArtifactNotFound {
+		return nil, nil, utils.ErrSignedSnapshotNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	var snapshot signedSnapshot
+	if err = json.Unmarshal(content, &snapshot); err != nil {
+		return nil, nil, err
+	}
+
+	options := snapshot.Options
+	if snapshot.HeaderHTML != "" {
+		options.HeaderHTML = bytes.NewBufferString(snapshot.HeaderHTML)
+	}
+	if snapshot.FooterHTML != "" {
+		options.FooterHTML = bytes.NewBufferString(snapshot.FooterHTML)
+	}
+
+	return bytes.NewBufferString(snapshot.HTML), &options, nil
+}
+
+// deleteSignedArtifacts remove the signed PDF and its snapshot that was not recorded to the document
+func (d *DocumentServiceImpl) deleteSignedArtifacts(ctx context.Context, signedPDFKey string) {
+	for _, key := range []string{signedPDFKey, signedSnapshotKey(signedPDFKey)} {
+		if err := d.artifactStorage.DeletePrefix(ctx, key); err != nil {
+			log.Printf("delete unused signed artifact %s: %v", key, err)
+		}
+	}
+}
+
+// pdfCachePrefix is the storage prefix of every cached render of the unsigned document
+func pdfCachePrefix(documentID string) string {
+	return pdfCacheRoot + documentID + "/"
+}
+
+// pdfCacheKey address the cached render by the rendered html and the page options, so any change that alter the
+// output, eg: updated field, edited template file, or computed field that use today date, produce another key
+func pdfCacheKey(documentID string, html *bytes.Buffer, options *pdf.PDFOptions) (string, error) {
+	pageOptions := *options
+	pageOptions.HeaderHTML = nil
+	pageOptions.FooterHTML = nil
+
+	encodedOptions, err := json.Marshal(pageOptions)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write(encodedOptions)
+	for _, section := range []*bytes.Buffer{options.HeaderHTML, options.FooterHTML, html} {
+		hash.Write([]byte{0})
+		if section != nil {
+			hash.Write(section.Bytes())
+		}
+	}
+
+	return pdfCachePrefix(documentID) + hex.EncodeToString(hash.Sum(nil)) + ".pdf", nil
+}
+
+func hashPDF(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// getSignedPDF return the PDF stored at signing time after checking it against the recorded hash
+func (d *DocumentServiceImpl) getSignedPDF(ctx context.Context, document *entity.Document) ([]byte, error) {
+	content, err := d.artifactStorage.Get(ctx, document.SignedPDFKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if hashPDF(content) != document.SignedPDFHash {
+		return nil, utils.ErrSignedPDFMismatch
+	}
+
+	return content, nil
+}
+
+// invalidatePDFCache remove the cached render of the unsigned document
+func (d *DocumentServiceImpl) invalidatePDFCache(ctx context.Context, documentID string) error {
+	return d.artifactStorage.DeletePrefix(ctx, pdfCachePrefix(documentID))
+}
+
+// evictPDFCache remove the cached render written before the cache ttl, so the cache didn't grow forever. Render job
+// pointing to the removed file render it again when it is requested
+func (d *DocumentServiceImpl) evictPDFCache(ctx context.Context) {
+	removed, err := d.artifactStorage.DeleteOlderThan(ctx, pdfCacheRoot, time.Now().Add(-d.pdfCacheTTL))
+	if err != nil {
+		log.Printf("evict pdf cache: %v", err)
+		return
+	}
+
+	if removed > 0 {
+		log.Printf("evict pdf cache: %d file removed", removed)
+	}
+}
@@ -0,0 +1,419 @@
+package impl
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/suryaadi44/eAD-System/internal/document/dto"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+// maxRenderJobError is the length of the render_jobs error column
+const maxRenderJobError = 255
+
+// renderQueue hold the id of the job waiting for a worker, its capacity bound how many render can pile up before
+// the request is rejected, so a burst of request didn't spawn a wkhtmltopdf process each
+type renderQueue struct {
+	jobs    chan string
+	workers int
+	wait    time.Duration
+
+	mu      sync.Mutex
+	waiters map[string]*renderWaiter
+}
+
+// renderWaiter is shared by every request waiting for the same job, it is removed when the job finish or the last
+// request stop waiting
+type renderWaiter struct {
+	done  chan struct{}
+	count int
+}
+
+func newRenderQueue(workers int, size int, wait time.Duration) *renderQueue {
+	if workers < 1 {
+		workers = 1
+	}
+
+	if size < 0 {
+		size = 0
+	}
+
+	return &renderQueue{
+		jobs:    make(chan string, size),
+		workers: workers,
+		wait:    wait,
+		waiters: make(map[string]*renderWaiter),
+	}
+}
+
+// push add the job to the queue without blocking, return false when the queue is full
+func (q *renderQueue) push(jobID string) bool {
+	select {
+	case q.jobs <- jobID:
+		return true
+	default:
+		return false
+	}
+}
+
+// done return channel that is closed when the job is finished, and the function that must be called once the
+// caller stop waiting. It must be called before the job is pushed or read, so the finish in between is not missed
+func (q *renderQueue) done(jobID string) (<-chan struct{}, func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	waiter, ok := q.waiters[jobID]
+	if !ok {
+		waiter = &renderWaiter{done: make(chan struct{})}
+		q.waiters[jobID] = waiter
+	}
+	waiter.count++
+
+	release := func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+
+		waiter.count--
+		if waiter.count == 0 && q.waiters[jobID] == waiter {
+			delete(q.waiters, jobID)
+		}
+	}
+
+	return waiter.done, release
+}
+
+// finish wake up every request waiting for the job
+func (q *renderQueue) finish(jobID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if waiter, ok := q.waiters[jobID]; ok {
+		close(waiter.done)
+		delete(q.waiters, jobID)
+	}
+}
+
+func (d *DocumentServiceImpl) RequestPDFDocument(ctx context.Context, documentID string, async bool) ([]byte, *dto.RenderJobResponse, error) {
+	document, err := d.documentRepository.GetDocument(ctx, documentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// signed document is already rendered, no need to queue it
+	if servesSignedPDF(document) {
+		signedPDF, err := d.getSignedPDF(ctx, document)
+		return signedPDF, nil, err
+	}
+
+	submit := false
+	job, err := d.documentRepository.GetActiveRenderJob(ctx, documentID)
+	if err == utils.ErrRenderJobNotFound {
+		job = &entity.RenderJob{
+			ID:         uuid.New().String(),
+			DocumentID: documentID,
+			Status:     entity.RenderJobQueued,
+		}
+		submit = true
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	// the waiter is registered before the job is submitted or read again, so a job finished in between is not missed
+	done, release := d.renderQueue.done(job.ID)
+	defer func() { release() }()
+
+	if submit {
+		err = d.submitRenderJob(ctx, job)
+		if err == utils.ErrRenderJobAlreadyActive {
+			// a concurrent request added its job first and the unique index refused this one, join that job instead
+			release()
+			job, err = d.documentRepository.GetActiveRenderJob(ctx, documentID)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			done, release = d.renderQueue.done(job.ID)
+		} else if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if async {
+		return nil, dto.NewRenderJobResponse(job), nil
+	}
+
+	job, err = d.awaitRenderJob(ctx, job.ID, done)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch job.Status {
+	case entity.RenderJobDone:
+		generatedPDF, err := d.getRenderJobArtifact(ctx, job)
+		if err == utils.ErrRenderJobNotDone {
+			return nil, dto.NewRenderJobResponse(job), nil
+		}
+		return generatedPDF, nil, err
+	case entity.RenderJobFailed:
+		return nil, nil, utils.ErrRenderJobFailed
+	default:
+		// still rendering after the wait timeout, let the caller poll the job
+		return nil, dto.NewRenderJobResponse(job), nil
+	}
+}
+
+// queuedPDF render the document through the render queue and wait until the PDF is ready, it is used by the caller
+// that need the PDF itself instead of the render job, eg: other format that is converted from the PDF
+func (d *DocumentServiceImpl) queuedPDF(ctx context.Context, documentID string) ([]byte, error) {
+	for {
+		generatedPDF, job, err := d.RequestPDFDocument(ctx, documentID, false)
+		if err != nil || job == nil {
+			return generatedPDF, err
+		}
+
+		// still queued after the wait timeout, wait again for the same job unless the request is gone
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// awaitRenderJob return the job once it is finished, or as it is when it is still not finished after the wait timeout
+func (d *DocumentServiceImpl) awaitRenderJob(ctx context.Context, jobID string, done <-chan struct{}) (*entity.RenderJob, error) {
+	job, err := d.documentRepository.GetRenderJob(ctx, jobID)
+	if err != nil || job.IsFinished() {
+		return job, err
+	}
+
+	timer := time.NewTimer(d.renderQueue.wait)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return d.documentRepository.GetRenderJob(ctx, jobID)
+}
+
+// submitRenderJob save the new job and put it into the queue
+func (d *DocumentServiceImpl) submitRenderJob(ctx context.Context, job *entity.RenderJob) error {
+	if err := d.documentRepository.AddRenderJob(ctx, job); err != nil {
+		return err
+	}
+
+	return d.pushRenderJob(ctx, job)
+}
+
+// pushRenderJob put the saved job into the queue, the job is marked failed when the queue is full
+func (d *DocumentServiceImpl) pushRenderJob(ctx context.Context, job *entity.RenderJob) error {
+	if d.renderQueue.push(job.ID) {
+		return nil
+	}
+
+	job.Status = entity.RenderJobFailed
+	job.Error = utils.ErrRenderQueueFull.Error()
+	job.FinishedAt = time.Now()
+	if err := d.documentRepository.UpdateRenderJob(ctx, job); err != nil {
+		return err
+	}
+
+	return utils.ErrRenderQueueFull
+}
+
+// getRenderJobArtifact return the PDF of the done job. The document is read again since it may be signed or revoked
+// after the job finished, the signed PDF saved by the job is not served once the document is revoked. When the
+// artifact is no longer valid or the cached file is evicted, the job is queued again and ErrRenderJobNotDone is
+// returned so the caller poll the job
+func (d *DocumentServiceImpl) getRenderJobArtifact(ctx context.Context, job *entity.RenderJob) ([]byte, error) {
+	document, err := d.documentRepository.GetDocument(ctx, job.DocumentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if servesSignedPDF(document) {
+		return d.getSignedPDF(ctx, document)
+	}
+
+	if job.ArtifactKey != document.SignedPDFKey {
+		generatedPDF, err := d.artifactStorage.Get(ctx, job.ArtifactKey)
+		if err != utils.ErrArtifactNotFound {
+			return generatedPDF, err
+		}
+	}
+
+	// another job of the document may be active already, the caller keep polling until that one finish
+	job.Status = entity.RenderJobQueued
+	job.ArtifactKey = ""
+	if err = d.documentRepository.UpdateRenderJob(ctx, job); err != nil {
+		if err == utils.ErrRenderJobAlreadyActive {
+			return nil, utils.ErrRenderJobNotDone
+		}
+
+		return nil, err
+	}
+
+	if err = d.pushRenderJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return nil, utils.ErrRenderJobNotDone
+}
+
+func (d *DocumentServiceImpl) GetRenderJob(ctx context.Context, jobID string) (*dto.RenderJobResponse, error) {
+	job, err := d.documentRepository.GetRenderJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewRenderJobResponse(job), nil
+}
+
+func (d *DocumentServiceImpl) GetRenderJobPDF(ctx context.Context, jobID string) ([]byte, error) {
+	job, err := d.documentRepository.GetRenderJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.Status {
+	case entity.RenderJobDone:
+		return d.getRenderJobArtifact(ctx, job)
+	case entity.RenderJobFailed:
+		return nil, utils.ErrRenderJobFailed
+	default:
+		return nil, utils.ErrRenderJobNotDone
+	}
+}
+
+// StartRenderWorkers start the render worker pool and queue again the job left unfinished by the previous run,
+// the workers stop when the context is cancelled
+func (d *DocumentServiceImpl) StartRenderWorkers(ctx context.Context) error {
+	jobs, err := d.documentRepository.GetUnfinishedRenderJobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < d.renderQueue.workers; i++ {
+		go d.runRenderWorker(ctx)
+	}
+	go d.runCleanup(ctx)
+
+	// the unfinished job may outnumber the queue capacity, so push them without holding up the startup
+	go func() {
+		for _, job := range *jobs {
+			select {
+			case d.renderQueue.jobs <- job.ID:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (d *DocumentServiceImpl) runRenderWorker(ctx context.Context) {
+	for {
+		select {
+		case jobID := <-d.renderQueue.jobs:
+			d.processRenderJob(ctx, jobID)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *DocumentServiceImpl) processRenderJob(ctx context.Context, jobID string) {
+	defer d.renderQueue.finish(jobID)
+
+	job, err := d.documentRepository.GetRenderJob(ctx, jobID)
+	if err != nil {
+		log.Printf("render job %s: %v", jobID, err)
+		return
+	}
+
+	job.Status = entity.RenderJobRunning
+	if err = d.documentRepository.UpdateRenderJob(ctx, job); err != nil {
+		log.Printf("render job %s: %v", jobID, err)
+		return
+	}
+
+	job.ArtifactKey, err = d.renderJobPDF(ctx, job.DocumentID)
+	if err != nil {
+		job.Status = entity.RenderJobFailed
+		job.Error = err.Error()
+		if len(job.Error) > maxRenderJobError {
+			job.Error = job.Error[:maxRenderJobError]
+		}
+	} else {
+		job.Status = entity.RenderJobDone
+	}
+	job.FinishedAt = time.Now()
+
+	if err = d.documentRepository.UpdateRenderJob(ctx, job); err != nil {
+		log.Printf("render job %s: %v", jobID, err)
+	}
+}
+
+// renderJobPDF render the document into the PDF cache and return its storage key
+func (d *DocumentServiceImpl) renderJobPDF(ctx context.Context, documentID string) (string, error) {
+	document, err := d.documentRepository.GetDocument(ctx, documentID)
+	if err != nil {
+		return "", err
+	}
+
+	// signed while waiting in the queue, the stored copy is the one that must be served
+	if servesSignedPDF(document) {
+		return document.SignedPDFKey, nil
+	}
+
+	_, cacheKey, err := d.renderPDF(ctx, document, true)
+	return cacheKey, err
+}
+
+// deleteFinishedRenderJobs remove the finished job older than the retention, every synchronous PDF request create
+// a job so they would otherwise pile up
+func (d *DocumentServiceImpl) deleteFinishedRenderJobs(ctx context.Context) {
+	removed, err := d.documentRepository.DeleteFinishedRenderJobs(ctx, time.Now().Add(-d.renderJobRetention))
+	if err != nil {
+		log.Printf("delete finished render job: %v", err)
+		return
+	}
+
+	if removed > 0 {
+		log.Printf("delete finished render job: %d job removed", removed)
+	}
+}
+
+// runCleanup remove the expired PDF cache and finished render job once on start and then every cleanupInterval until
+// the context is cancelled, zero ttl or retention keep them forever
+func (d *DocumentServiceImpl) runCleanup(ctx context.Context) {
+	if d.pdfCacheTTL <= 0 && d.renderJobRetention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		if d.pdfCacheTTL > 0 {
+			d.evictPDFCache(ctx)
+		}
+
+		if d.renderJobRetention > 0 {
+			d.deleteFinishedRenderJobs(ctx)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package impl
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/indonesian"
+)
+
+// maxTableNumber is the largest magnitude accepted in number column, it is kept below 2^53 so the value is exact
+// as float64 and still fit int64 when it is spelled or formatted
+const maxTableNumber = 1e15
+
+// validateFieldValue check the submitted value against the template field type, table field only accept rows
+// and text field only accept value. Computed field can't be submitted
+func validateFieldValue(templateField *entity.TemplateField, value string, rows entity.TableRows) error {
+	if templateField.IsComputed() {
+		return utils.ErrFieldNotMatch
+	}
+
+	if templateField.Type == entity.FieldTypeTable {
+		if value != "" {
+			return utils.ErrFieldNotMatch
+		}
+
+		return validateTableRows(templateField.Columns, rows)
+	}
+
+	if value == "" || len(rows) != 0 {
+		return utils.ErrFieldNotMatch
+	}
+
+	return nil
+}
+
+// validateTableRows check every row only contain known column, required column is filled, and typed column
+// can be parsed. Number column must be finite and not larger than maxTableNumber
+func validateTableRows(columns entity.TableColumns, rows entity.TableRows) error {
+	for _, row := range rows {
+		for key := range row {
+			known := false
+			for _, column := range columns {
+				if column.Key == key {
+					known = true
+					break
+				}
+			}
+
+			if !known {
+				return utils.ErrInvalidTableRow
+			}
+		}
+
+		for _, column := range columns {
+			value := strings.TrimSpace(row[column.Key])
+			if value == "" {
+				if column.Required {
+					return utils.ErrInvalidTableRow
+				}
+				continue
+			}
+
+			switch column.Type {
+			case entity.ColumnTypeNumber:
+				number, err := strconv.ParseFloat(value, 64)
+				if err != nil || math.IsNaN(number) || math.Abs(number) > maxTableNumber {
+					return utils.ErrInvalidTableRow
+				}
+			case entity.ColumnTypeDate:
+				if _, ok := indonesian.ParseDate(value); !ok {
+					return utils.ErrInvalidTableRow
+				}
+			}
+		}
+	}
+
+	return nil
+}
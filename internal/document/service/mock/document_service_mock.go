@@ -1,9 +1,13 @@
 package mock
 
 import (
+	"bytes"
 	"context"
+	"io"
+
 	"github.com/stretchr/testify/mock"
 	"github.com/suryaadi44/eAD-System/internal/document/dto"
+	"github.com/suryaadi44/eAD-System/pkg/utils/qr"
 )
 
 type MockDocumentService struct {
@@ -20,21 +24,61 @@ func (m *MockDocumentService) GetDocument(ctx context.Context, documentID string
 	return args.Get(0).(*dto.DocumentResponse), args.Error(1)
 }
 
-func (m *MockDocumentService) GetBriefDocuments(ctx context.Context, applicantID string, role int, page int, limit int) (*dto.BriefDocumentsResponse, error) {
-	args := m.Called(ctx, applicantID, role, page, limit)
+func (m *MockDocumentService) GetBriefDocuments(ctx context.Context, applicantID string, ownerOnly bool, page int, limit int) (*dto.BriefDocumentsResponse, error) {
+	args := m.Called(ctx, applicantID, ownerOnly, page, limit)
 	return args.Get(0).(*dto.BriefDocumentsResponse), args.Error(1)
 }
 
-func (m *MockDocumentService) GetDocumentStatus(ctx context.Context, documentID string) (*dto.DocumentStatusResponse, error) {
-	args := m.Called(ctx, documentID)
+func (m *MockDocumentService) GetDocumentStatus(ctx context.Context, documentID string, token string) (*dto.DocumentStatusResponse, error) {
+	args := m.Called(ctx, documentID, token)
 	return args.Get(0).(*dto.DocumentStatusResponse), args.Error(1)
 }
 
-func (m *MockDocumentService) GeneratePDFDocument(ctx context.Context, documentID string) ([]byte, error) {
-	args := m.Called(ctx, documentID)
+func (m *MockDocumentService) GenerateStatusPage(status *dto.DocumentStatusResponse, statusErr error) (*bytes.Buffer, error) {
+	args := m.Called(status, statusErr)
+	return args.Get(0).(*bytes.Buffer), args.Error(1)
+}
+
+func (m *MockDocumentService) GetQRPublicKey() *qr.PublicKey {
+	args := m.Called()
+	return args.Get(0).(*qr.PublicKey)
+}
+
+func (m *MockDocumentService) RenderDocument(ctx context.Context, documentID string, format string) (*dto.RenderedDocument, error) {
+	args := m.Called(ctx, documentID, format)
+	return args.Get(0).(*dto.RenderedDocument), args.Error(1)
+}
+
+func (m *MockDocumentService) GetExportDocumentIDs(ctx context.Context, request *dto.ExportDocumentsRequest) ([]string, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockDocumentService) WriteDocumentExport(ctx context.Context, documentIDs []string, format string, w io.Writer) error {
+	args := m.Called(ctx, documentIDs, format, w)
+	return args.Error(0)
+}
+
+func (m *MockDocumentService) RequestPDFDocument(ctx context.Context, documentID string, async bool) ([]byte, *dto.RenderJobResponse, error) {
+	args := m.Called(ctx, documentID, async)
+	return args.Get(0).([]byte), args.Get(1).(*dto.RenderJobResponse), args.Error(2)
+}
+
+func (m *MockDocumentService) GetRenderJob(ctx context.Context, jobID string) (*dto.RenderJobResponse, error) {
+	args := m.Called(ctx, jobID)
+	return args.Get(0).(*dto.RenderJobResponse), args.Error(1)
+}
+
+func (m *MockDocumentService) GetRenderJobPDF(ctx context.Context, jobID string) ([]byte, error) {
+	args := m.Called(ctx, jobID)
 	return args.Get(0).([]byte), args.Error(1)
 }
 
+func (m *MockDocumentService) StartRenderWorkers(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
 func (m *MockDocumentService) GetApplicantID(ctx context.Context, documentID string) (*string, error) {
 	args := m.Called(ctx, documentID)
 	return args.Get(0).(*string), args.Error(1)
@@ -50,8 +94,18 @@ func (m *MockDocumentService) SignDocument(ctx context.Context, documentID strin
 	return args.Error(0)
 }
 
-func (m *MockDocumentService) DeleteDocument(ctx context.Context, userID string, role int, documentID string) error {
-	args := m.Called(ctx, userID, role, documentID)
+func (m *MockDocumentService) RevokeDocument(ctx context.Context, documentID string, revokeRequest *dto.RevokeDocumentRequest) error {
+	args := m.Called(ctx, documentID, revokeRequest)
+	return args.Error(0)
+}
+
+func (m *MockDocumentService) VerifyDocumentFile(ctx context.Context, file io.Reader) (*dto.DocumentVerificationResponse, error) {
+	args := m.Called(ctx, file)
+	return args.Get(0).(*dto.DocumentVerificationResponse), args.Error(1)
+}
+
+func (m *MockDocumentService) DeleteDocument(ctx context.Context, userID string, ownerOnly bool, documentID string) error {
+	args := m.Called(ctx, userID, ownerOnly, documentID)
 	return args.Error(0)
 }
 
@@ -60,7 +114,7 @@ func (m *MockDocumentService) UpdateDocument(ctx context.Context, document *dto.
 	return args.Error(0)
 }
 
-func (m *MockDocumentService) UpdateDocumentFields(ctx context.Context, userID string, role int, documentID string, fields *dto.FieldsUpdateRequest) error {
-	args := m.Called(ctx, userID, role, documentID, fields)
+func (m *MockDocumentService) UpdateDocumentFields(ctx context.Context, userID string, ownerOnly bool, documentID string, fields *dto.FieldsUpdateRequest) error {
+	args := m.Called(ctx, userID, ownerOnly, documentID, fields)
 	return args.Error(0)
 }
@@ -24,12 +24,6 @@ func NewTemplateController(templateService service.TemplateService, jwtService j
 }
 
 func (t *TemplateController) AddTemplate(c echo.Context) error {
-	claims := t.jwtService.GetClaims(&c)
-	role := claims["role"].(float64)
-	if role < 2 { // role 2 or above are employee
-		return echo.NewHTTPError(http.StatusForbidden, utils.ErrDidntHavePermission.Error())
-	}
-
 	template := new(dto.TemplateRequest)
 	if err := c.Bind(template); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
@@ -52,10 +46,26 @@ func (t *TemplateController) AddTemplate(c echo.Context) error {
 
 	err = t.templateService.AddTemplate(c.Request().Context(), template, fileSrc, file.Filename)
 	if err != nil {
-		if err == utils.ErrDuplicateTemplateName {
+		switch err {
+		case utils.ErrDuplicateTemplateName:
 			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		case utils.ErrTemplateTooLarge:
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, err.Error())
+		case utils.ErrTemplateContainsScript:
+			fallthrough
+		case utils.ErrInvalidTemplateBundle:
+			fallthrough
+		case utils.ErrUnknownApplicantField:
+			fallthrough
+		case utils.ErrInvalidTableDefinition:
+			fallthrough
+		case utils.ErrInvalidExpression:
+			fallthrough
+		case utils.ErrTemplateExternalResource:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
 	return c.JSON(http.StatusOK, echo.Map{
@@ -74,20 +74,6 @@ func (s *TestSuiteTemplateController) TestAddTemplate() {
 			ExpectedStatus: 200,
 			ExpectedBody:   echo.Map{"message": "success adding template"},
 		},
-		{
-			Name: "Failed adding template : insufficient role",
-			RequestBody: dto.TemplateRequest{
-				Name:         "Template 1",
-				MarginTop:    10,
-				MarginBottom: 10,
-				MarginLeft:   10,
-				MarginRight:  10,
-				Keys:         []string{"key1", "key2"},
-			},
-			JWTReturn:      jwt.MapClaims{"role": float64(1)},
-			ExpectedStatus: http.StatusForbidden,
-			ExpectedError:  utils.ErrDidntHavePermission,
-		},
 		{
 			Name:           "Failed adding template : invalid request body",
 			RequestBody:    "invalid request body",
@@ -115,6 +101,46 @@ func (s *TestSuiteTemplateController) TestAddTemplate() {
 			ExpectedStatus: http.StatusConflict,
 			ExpectedError:  utils.ErrDuplicateTemplateName,
 		},
+		{
+			Name: "Failed adding template : template too large",
+			RequestBody: dto.TemplateRequest{
+				Name: "Template 1",
+			},
+			JWTReturn:      jwt.MapClaims{"role": float64(3)},
+			FunctionError:  utils.ErrTemplateTooLarge,
+			ExpectedStatus: http.StatusRequestEntityTooLarge,
+			ExpectedError:  utils.ErrTemplateTooLarge,
+		},
+		{
+			Name: "Failed adding template : template contains script",
+			RequestBody: dto.TemplateRequest{
+				Name: "Template 1",
+			},
+			JWTReturn:      jwt.MapClaims{"role": float64(3)},
+			FunctionError:  utils.ErrTemplateContainsScript,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrTemplateContainsScript,
+		},
+		{
+			Name: "Failed adding template : template references external resource",
+			RequestBody: dto.TemplateRequest{
+				Name: "Template 1",
+			},
+			JWTReturn:      jwt.MapClaims{"role": float64(3)},
+			FunctionError:  utils.ErrTemplateExternalResource,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrTemplateExternalResource,
+		},
+		{
+			Name: "Failed adding template : unknown applicant field",
+			RequestBody: dto.TemplateRequest{
+				Name: "Template 1",
+			},
+			JWTReturn:      jwt.MapClaims{"role": float64(3)},
+			FunctionError:  utils.ErrUnknownApplicantField,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrUnknownApplicantField,
+		},
 		{
 			Name: "Failed adding template : service error",
 			RequestBody: dto.TemplateRequest{
@@ -276,12 +302,32 @@ func (s *TestSuiteTemplateController) TestGetAllTemplate() {
 				"message": "success getting all template",
 				"data": []interface{}{
 					map[string]interface{}{
-						"id":            float64(1),
-						"name":          "test",
-						"margin_top":    float64(1),
-						"margin_bottom": float64(1),
-						"margin_left":   float64(1),
-						"margin_right":  float64(1),
+						"id":               float64(1),
+						"name":             "test",
+						"margin_top":       float64(1),
+						"margin_bottom":    float64(1),
+						"margin_left":      float64(1),
+						"margin_right":     float64(1),
+						"page_size":        "",
+						"page_width":       float64(0),
+						"page_height":      float64(0),
+						"orientation":      "",
+						"dpi":              float64(0),
+						"grayscale":        false,
+						"zoom":             float64(0),
+						"has_header":       false,
+						"has_footer":       false,
+						"qr_on_every_page": false,
+						"signature_field": map[string]interface{}{
+							"page":   float64(0),
+							"x":      float64(0),
+							"y":      float64(0),
+							"width":  float64(0),
+							"height": float64(0),
+						},
+						"watermark": map[string]interface{}{
+							"opacity": float64(0),
+						},
 						"keys": []interface{}{
 							map[string]interface{}{
 								"id":  float64(1),
@@ -365,13 +411,33 @@ func (s *TestSuiteTemplateController) TestGetTemplateDetail() {
 			ExpectedBody: echo.Map{
 				"message": "success getting template detail",
 				"data": map[string]interface{}{
-					"id":            float64(1),
-					"name":          "name",
-					"margin_top":    float64(0),
-					"margin_bottom": float64(0),
-					"margin_left":   float64(0),
-					"margin_right":  float64(0),
-					"keys":          nil,
+					"id":               float64(1),
+					"name":             "name",
+					"margin_top":       float64(0),
+					"margin_bottom":    float64(0),
+					"margin_left":      float64(0),
+					"margin_right":     float64(0),
+					"page_size":        "",
+					"page_width":       float64(0),
+					"page_height":      float64(0),
+					"orientation":      "",
+					"dpi":              float64(0),
+					"grayscale":        false,
+					"zoom":             float64(0),
+					"has_header":       false,
+					"has_footer":       false,
+					"qr_on_every_page": false,
+					"signature_field": map[string]interface{}{
+						"page":   float64(0),
+						"x":      float64(0),
+						"y":      float64(0),
+						"width":  float64(0),
+						"height": float64(0),
+					},
+					"watermark": map[string]interface{}{
+						"opacity": float64(0),
+					},
+					"keys": nil,
 				},
 			},
 			ExpectedError: nil,
@@ -1,23 +1,91 @@
 package dto
 
-import "github.com/suryaadi44/eAD-System/pkg/entity"
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/binding"
+	"github.com/suryaadi44/eAD-System/pkg/utils/expression"
+)
 
 type TemplateRequest struct {
-	Name         string   `form:"name" validate:"required"`
-	MarginTop    uint     `form:"margin_top" validate:"gte=0"`
-	MarginBottom uint     `form:"margin_bottom" validate:"gte=0"`
-	MarginLeft   uint     `form:"margin_left" validate:"gte=0"`
-	MarginRight  uint     `form:"margin_right" validate:"gte=0"`
-	Keys         []string `form:"keys[]" validate:"required"`
+	Name              string   `form:"name" validate:"required"`
+	MarginTop         uint     `form:"margin_top" validate:"gte=0"`
+	MarginBottom      uint     `form:"margin_bottom" validate:"gte=0"`
+	MarginLeft        uint     `form:"margin_left" validate:"gte=0"`
+	MarginRight       uint     `form:"margin_right" validate:"gte=0"`
+	PageSize          string   `form:"page_size" validate:"omitempty,oneof=A3 A4 A5 B5 Letter Legal F4 Custom"`
+	PageWidth         uint     `form:"page_width" validate:"required_if=PageSize Custom"`
+	PageHeight        uint     `form:"page_height" validate:"required_if=PageSize Custom"`
+	Orientation       string   `form:"orientation" validate:"omitempty,oneof=Portrait Landscape"`
+	DPI               uint     `form:"dpi" validate:"omitempty,gte=72,lte=1200"`
+	Grayscale         bool     `form:"grayscale"`
+	Zoom              float64  `form:"zoom" validate:"omitempty,gt=0,lte=5"`
+	HeaderHTML        string   `form:"header_html"`
+	FooterHTML        string   `form:"footer_html"`
+	QROnEveryPage     bool     `form:"qr_on_every_page"`
+	SignaturePage     uint     `form:"signature_page"`
+	SignatureX        uint     `form:"signature_x"`
+	SignatureY        uint     `form:"signature_y"`
+	SignatureWidth    uint     `form:"signature_width" validate:"required_with=SignatureHeight"`
+	SignatureHeight   uint     `form:"signature_height" validate:"required_with=SignatureWidth"`
+	WatermarkDraft    string   `form:"watermark_draft" validate:"max=64"`
+	WatermarkUnsigned string   `form:"watermark_unsigned" validate:"max=64"`
+	WatermarkRevoked  string   `form:"watermark_revoked" validate:"max=64"`
+	WatermarkOpacity  float64  `form:"watermark_opacity" validate:"omitempty,gt=0,lte=1"`
+	WatermarkAngle    *int     `form:"watermark_angle" validate:"omitempty,gte=-90,lte=90"`
+	Keys              []string `form:"keys[]" validate:"required"`
+	Tables            string   `form:"tables"`
+	Rules             string   `form:"rules"`
+}
+
+// TableFieldRequest is the definition of repeating table field, sent as json array in the tables form field
+type TableFieldRequest struct {
+	Key     string               `json:"key"`
+	Columns []TableColumnRequest `json:"columns"`
+}
+
+type TableColumnRequest struct {
+	Key      string `json:"key"`
+	Label    string `json:"label"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+}
+
+// FieldRuleRequest is the computed expression or required condition of a field, sent as json array in the rules form field
+type FieldRuleRequest struct {
+	Key        string `json:"key"`
+	Expression string `json:"expression"`
+	Condition  string `json:"condition"`
 }
 
 func (t TemplateRequest) ToEntity() *entity.Template {
 	template := entity.Template{
-		Name:         t.Name,
-		MarginTop:    t.MarginTop,
-		MarginBottom: t.MarginBottom,
-		MarginLeft:   t.MarginLeft,
-		MarginRight:  t.MarginRight,
+		Name:              t.Name,
+		MarginTop:         t.MarginTop,
+		MarginBottom:      t.MarginBottom,
+		MarginLeft:        t.MarginLeft,
+		MarginRight:       t.MarginRight,
+		PageSize:          t.PageSize,
+		PageWidth:         t.PageWidth,
+		PageHeight:        t.PageHeight,
+		Orientation:       t.Orientation,
+		DPI:               t.DPI,
+		Grayscale:         t.Grayscale,
+		Zoom:              t.Zoom,
+		QROnEveryPage:     t.QROnEveryPage,
+		SignaturePage:     t.SignaturePage,
+		SignatureX:        t.SignatureX,
+		SignatureY:        t.SignatureY,
+		SignatureWidth:    t.SignatureWidth,
+		SignatureHeight:   t.SignatureHeight,
+		WatermarkDraft:    t.WatermarkDraft,
+		WatermarkUnsigned: t.WatermarkUnsigned,
+		WatermarkRevoked:  t.WatermarkRevoked,
+		WatermarkOpacity:  t.WatermarkOpacity,
+		WatermarkAngle:    t.WatermarkAngle,
 	}
 
 	var fields entity.TemplateFields
@@ -32,21 +100,156 @@ func (t TemplateRequest) ToEntity() *entity.Template {
 	return &template
 }
 
+// ParseTables parse the table field definitions, column type default to text
+func (t TemplateRequest) ParseTables() (entity.TemplateFields, error) {
+	if t.Tables == "" {
+		return nil, nil
+	}
+
+	var tables []TableFieldRequest
+	if err := json.Unmarshal([]byte(t.Tables), &tables); err != nil {
+		return nil, utils.ErrInvalidTableDefinition
+	}
+
+	keys := make(map[string]bool)
+	for _, key := range t.Keys {
+		keys[key] = true
+	}
+
+	var fields entity.TemplateFields
+	for _, table := range tables {
+		if table.Key == "" || keys[table.Key] || strings.Contains(table.Key, ".") || len(table.Columns) == 0 {
+			return nil, utils.ErrInvalidTableDefinition
+		}
+		keys[table.Key] = true
+
+		columnKeys := make(map[string]bool)
+		var columns entity.TableColumns
+		for _, column := range table.Columns {
+			if column.Type == "" {
+				column.Type = entity.ColumnTypeText
+			}
+
+			if column.Key == "" || columnKeys[column.Key] {
+				return nil, utils.ErrInvalidTableDefinition
+			}
+			columnKeys[column.Key] = true
+
+			switch column.Type {
+			case entity.ColumnTypeText, entity.ColumnTypeNumber, entity.ColumnTypeDate:
+			default:
+				return nil, utils.ErrInvalidTableDefinition
+			}
+
+			columns = append(columns, entity.TableColumn{
+				Key:      column.Key,
+				Label:    column.Label,
+				Type:     column.Type,
+				Required: column.Required,
+			})
+		}
+
+		fields = append(fields, entity.TemplateField{
+			Key:     table.Key,
+			Type:    entity.FieldTypeTable,
+			Columns: columns,
+		})
+	}
+
+	return fields, nil
+}
+
+// ApplyRules parse the field rules and set them to the matching text field, a rule must have either expression or
+// condition and can't target applicant bound field
+func (t TemplateRequest) ApplyRules(fields entity.TemplateFields) error {
+	if t.Rules == "" {
+		return nil
+	}
+
+	var rules []FieldRuleRequest
+	if err := json.Unmarshal([]byte(t.Rules), &rules); err != nil {
+		return utils.ErrInvalidExpression
+	}
+
+	applied := make(map[string]bool)
+	for _, rule := range rules {
+		if applied[rule.Key] || binding.IsApplicantField(rule.Key) || (rule.Expression == "") == (rule.Condition == "") {
+			return utils.ErrInvalidExpression
+		}
+		applied[rule.Key] = true
+
+		source := rule.Expression + rule.Condition
+		if _, err := expression.Parse(source); err != nil {
+			return err
+		}
+
+		found := false
+		for i := range fields {
+			if fields[i].Key != rule.Key || fields[i].Type == entity.FieldTypeTable {
+				continue
+			}
+
+			fields[i].Expression = rule.Expression
+			fields[i].Condition = rule.Condition
+			found = true
+			break
+		}
+
+		if !found {
+			return utils.ErrInvalidExpression
+		}
+	}
+
+	return nil
+}
+
 type TemplateResponse struct {
-	ID           uint         `json:"id"`
-	Name         string       `json:"name"`
-	MarginTop    uint         `json:"margin_top"`
-	MarginBottom uint         `json:"margin_bottom"`
-	MarginLeft   uint         `json:"margin_left"`
-	MarginRight  uint         `json:"margin_right"`
-	Keys         KeysResponse `json:"keys"`
+	ID             uint                   `json:"id"`
+	Name           string                 `json:"name"`
+	MarginTop      uint                   `json:"margin_top"`
+	MarginBottom   uint                   `json:"margin_bottom"`
+	MarginLeft     uint                   `json:"margin_left"`
+	MarginRight    uint                   `json:"margin_right"`
+	PageSize       string                 `json:"page_size"`
+	PageWidth      uint                   `json:"page_width"`
+	PageHeight     uint                   `json:"page_height"`
+	Orientation    string                 `json:"orientation"`
+	DPI            uint                   `json:"dpi"`
+	Grayscale      bool                   `json:"grayscale"`
+	Zoom           float64                `json:"zoom"`
+	HasHeader      bool                   `json:"has_header"`
+	HasFooter      bool                   `json:"has_footer"`
+	QROnEveryPage  bool                   `json:"qr_on_every_page"`
+	SignatureField SignatureFieldResponse `json:"signature_field"`
+	Watermark      WatermarkResponse      `json:"watermark"`
+	Keys           KeysResponse           `json:"keys"`
 }
 
 type TemplatesResponse []TemplateResponse
 
+type SignatureFieldResponse struct {
+	Page   uint `json:"page"`
+	X      uint `json:"x"`
+	Y      uint `json:"y"`
+	Width  uint `json:"width"`
+	Height uint `json:"height"`
+}
+
+type WatermarkResponse struct {
+	Draft    string  `json:"draft,omitempty"`
+	Unsigned string  `json:"unsigned,omitempty"`
+	Revoked  string  `json:"revoked,omitempty"`
+	Opacity  float64 `json:"opacity"`
+	Angle    *int    `json:"angle,omitempty"`
+}
+
 type KeyResponse struct {
-	ID  uint   `json:"id"`
-	Key string `json:"key"`
+	ID         uint                `json:"id"`
+	Key        string              `json:"key"`
+	Type       string              `json:"type,omitempty"`
+	Columns    entity.TableColumns `json:"columns,omitempty"`
+	Expression string              `json:"expression,omitempty"`
+	Condition  string              `json:"condition,omitempty"`
 }
 
 type KeysResponse []KeyResponse
@@ -55,19 +258,47 @@ func NewTemplateResponse(template *entity.Template) *TemplateResponse {
 	var keys KeysResponse
 	for _, field := range template.Fields {
 		keys = append(keys, KeyResponse{
-			ID:  field.ID,
-			Key: field.Key,
+			ID:         field.ID,
+			Key:        field.Key,
+			Type:       field.Type,
+			Columns:    field.Columns,
+			Expression: field.Expression,
+			Condition:  field.Condition,
 		})
 	}
 
 	return &TemplateResponse{
-		ID:           template.ID,
-		Name:         template.Name,
-		MarginTop:    template.MarginTop,
-		MarginBottom: template.MarginBottom,
-		MarginLeft:   template.MarginLeft,
-		MarginRight:  template.MarginRight,
-		Keys:         keys,
+		ID:            template.ID,
+		Name:          template.Name,
+		MarginTop:     template.MarginTop,
+		MarginBottom:  template.MarginBottom,
+		MarginLeft:    template.MarginLeft,
+		MarginRight:   template.MarginRight,
+		PageSize:      template.PageSize,
+		PageWidth:     template.PageWidth,
+		PageHeight:    template.PageHeight,
+		Orientation:   template.Orientation,
+		DPI:           template.DPI,
+		Grayscale:     template.Grayscale,
+		Zoom:          template.Zoom,
+		HasHeader:     template.HeaderPath != "",
+		HasFooter:     template.FooterPath != "",
+		QROnEveryPage: template.QROnEveryPage,
+		SignatureField: SignatureFieldResponse{
+			Page:   template.SignaturePage,
+			X:      template.SignatureX,
+			Y:      template.SignatureY,
+			Width:  template.SignatureWidth,
+			Height: template.SignatureHeight,
+		},
+		Watermark: WatermarkResponse{
+			Draft:    template.WatermarkDraft,
+			Unsigned: template.WatermarkUnsigned,
+			Revoked:  template.WatermarkRevoked,
+			Opacity:  template.WatermarkOpacity,
+			Angle:    template.WatermarkAngle,
+		},
+		Keys: keys,
 	}
 }
 
@@ -6,10 +6,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
 	"gorm.io/gorm"
 )
 
 func TestTemplateRequest_ToEntity(t *testing.T) {
+	angle, horizontal := 30, 0
 	tests := []struct {
 		name string
 		tr   TemplateRequest
@@ -18,19 +20,35 @@ func TestTemplateRequest_ToEntity(t *testing.T) {
 		{
 			name: "All fields are filled",
 			tr: TemplateRequest{
-				Name:         "Template 1",
-				MarginTop:    10,
-				MarginBottom: 10,
-				MarginLeft:   10,
-				MarginRight:  10,
-				Keys:         []string{"key1", "key2"},
+				Name:             "Template 1",
+				MarginTop:        10,
+				MarginBottom:     10,
+				MarginLeft:       10,
+				MarginRight:      10,
+				PageSize:         "F4",
+				Orientation:      "Landscape",
+				DPI:              300,
+				Grayscale:        true,
+				Zoom:             1.2,
+				WatermarkDraft:   "KONSEP",
+				WatermarkOpacity: 0.3,
+				WatermarkAngle:   &angle,
+				Keys:             []string{"key1", "key2"},
 			},
 			want: &entity.Template{
-				Name:         "Template 1",
-				MarginTop:    10,
-				MarginBottom: 10,
-				MarginLeft:   10,
-				MarginRight:  10,
+				Name:             "Template 1",
+				MarginTop:        10,
+				MarginBottom:     10,
+				MarginLeft:       10,
+				MarginRight:      10,
+				PageSize:         "F4",
+				Orientation:      "Landscape",
+				DPI:              300,
+				Grayscale:        true,
+				Zoom:             1.2,
+				WatermarkDraft:   "KONSEP",
+				WatermarkOpacity: 0.3,
+				WatermarkAngle:   &angle,
 				Fields: []entity.TemplateField{
 					{
 						Key: "key1",
@@ -41,6 +59,17 @@ func TestTemplateRequest_ToEntity(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Horizontal watermark",
+			tr: TemplateRequest{
+				Name:           "Template 1",
+				WatermarkAngle: &horizontal,
+			},
+			want: &entity.Template{
+				Name:           "Template 1",
+				WatermarkAngle: &horizontal,
+			},
+		},
 		{
 			name: "Partial fields are filled",
 			tr: TemplateRequest{
@@ -65,6 +94,150 @@ func TestTemplateRequest_ToEntity(t *testing.T) {
 	}
 }
 
+func TestTemplateRequest_ParseTables(t *testing.T) {
+	tests := []struct {
+		name    string
+		tr      TemplateRequest
+		want    entity.TemplateFields
+		wantErr error
+	}{
+		{
+			name: "No table",
+			tr:   TemplateRequest{},
+		},
+		{
+			name: "Valid table",
+			tr: TemplateRequest{
+				Keys:   []string{"name"},
+				Tables: `[{"key":"family","columns":[{"key":"name","label":"Nama","required":true},{"key":"birth_date","label":"Tanggal Lahir","type":"date"}]}]`,
+			},
+			want: entity.TemplateFields{
+				{
+					Key:  "family",
+					Type: entity.FieldTypeTable,
+					Columns: entity.TableColumns{
+						{Key: "name", Label: "Nama", Type: entity.ColumnTypeText, Required: true},
+						{Key: "birth_date", Label: "Tanggal Lahir", Type: entity.ColumnTypeDate},
+					},
+				},
+			},
+		},
+		{
+			name:    "Invalid json",
+			tr:      TemplateRequest{Tables: `{`},
+			wantErr: utils.ErrInvalidTableDefinition,
+		},
+		{
+			name: "Duplicate with keys",
+			tr: TemplateRequest{
+				Keys:   []string{"family"},
+				Tables: `[{"key":"family","columns":[{"key":"name"}]}]`,
+			},
+			wantErr: utils.ErrInvalidTableDefinition,
+		},
+		{
+			name:    "Without column",
+			tr:      TemplateRequest{Tables: `[{"key":"family","columns":[]}]`},
+			wantErr: utils.ErrInvalidTableDefinition,
+		},
+		{
+			name:    "Duplicate column",
+			tr:      TemplateRequest{Tables: `[{"key":"family","columns":[{"key":"name"},{"key":"name"}]}]`},
+			wantErr: utils.ErrInvalidTableDefinition,
+		},
+		{
+			name:    "Unknown column type",
+			tr:      TemplateRequest{Tables: `[{"key":"family","columns":[{"key":"name","type":"image"}]}]`},
+			wantErr: utils.ErrInvalidTableDefinition,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.tr.ParseTables()
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTemplateRequest_ApplyRules(t *testing.T) {
+	newFields := func() entity.TemplateFields {
+		return entity.TemplateFields{
+			{Key: "salutation"},
+			{Key: "spouse_name"},
+			{Key: "applicant.sex"},
+			{Key: "family", Type: entity.FieldTypeTable},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		rules   string
+		want    entity.TemplateFields
+		wantErr error
+	}{
+		{
+			name: "No rule",
+			want: newFields(),
+		},
+		{
+			name:  "Valid rules",
+			rules: `[{"key":"salutation","expression":"cond(applicant.sex == \"L\", \"Bapak\", \"Ibu\")"},{"key":"spouse_name","condition":"marital_status == \"Kawin\""}]`,
+			want: entity.TemplateFields{
+				{Key: "salutation", Expression: `cond(applicant.sex == "L", "Bapak", "Ibu")`},
+				{Key: "spouse_name", Condition: `marital_status == "Kawin"`},
+				{Key: "applicant.sex"},
+				{Key: "family", Type: entity.FieldTypeTable},
+			},
+		},
+		{
+			name:    "Invalid json",
+			rules:   `{`,
+			wantErr: utils.ErrInvalidExpression,
+		},
+		{
+			name:    "Unknown key",
+			rules:   `[{"key":"unknown","expression":"1 + 1"}]`,
+			wantErr: utils.ErrInvalidExpression,
+		},
+		{
+			name:    "Table field",
+			rules:   `[{"key":"family","condition":"true"}]`,
+			wantErr: utils.ErrInvalidExpression,
+		},
+		{
+			name:    "Applicant field",
+			rules:   `[{"key":"applicant.sex","expression":"\"L\""}]`,
+			wantErr: utils.ErrInvalidExpression,
+		},
+		{
+			name:    "Both expression and condition",
+			rules:   `[{"key":"salutation","expression":"1","condition":"true"}]`,
+			wantErr: utils.ErrInvalidExpression,
+		},
+		{
+			name:    "Duplicate rule",
+			rules:   `[{"key":"salutation","expression":"1"},{"key":"salutation","expression":"2"}]`,
+			wantErr: utils.ErrInvalidExpression,
+		},
+		{
+			name:    "Function not allowed",
+			rules:   `[{"key":"salutation","expression":"os.Exit(1)"}]`,
+			wantErr: utils.ErrInvalidExpression,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := newFields()
+			err := TemplateRequest{Rules: tt.rules}.ApplyRules(fields)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, fields)
+			}
+		})
+	}
+}
+
 func TestNewTemplateResponse(t *testing.T) {
 	type args struct {
 		template *entity.Template
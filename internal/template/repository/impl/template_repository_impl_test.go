@@ -40,7 +40,7 @@ func (s *TestSuiteTemplateRepository) TearDownTest() {
 }
 
 func (s *TestSuiteTemplateRepository) TestAddTemplate() {
-	query := regexp.QuoteMeta("INSERT INTO `templates` (`created_at`,`updated_at`,`deleted_at`,`name`,`path`,`margin_top`,`margin_bottom`,`margin_left`,`margin_right`,`is_active`) VALUES (?,?,?,?,?,?,?,?,?,?)")
+	query := regexp.QuoteMeta("INSERT INTO `templates` (`created_at`,`updated_at`,`deleted_at`,`name`,`path`,`bundle_path`,`header_path`,`footer_path`,`version`,`margin_top`,`margin_bottom`,`margin_left`,`margin_right`,`page_size`,`page_width`,`page_height`,`orientation`,`dpi`,`grayscale`,`zoom`,`qr_on_every_page`,`signature_page`,`signature_x`,`signature_y`,`signature_width`,`signature_height`,`watermark_draft`,`watermark_unsigned`,`watermark_revoked`,`watermark_opacity`,`watermark_angle`,`is_active`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)")
 	for _, tc := range []struct {
 		Name        string
 		Err         error
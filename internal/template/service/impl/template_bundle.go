@@ -0,0 +1,203 @@
+package impl
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+// bundleFileTypes is the list of file extension that is allowed inside template bundle
+var bundleFileTypes = map[string]bool{
+	".html":  true,
+	".htm":   true,
+	".css":   true,
+	".png":   true,
+	".jpg":   true,
+	".jpeg":  true,
+	".gif":   true,
+	".svg":   true,
+	".ttf":   true,
+	".otf":   true,
+	".woff":  true,
+	".woff2": true,
+}
+
+type bundleFile struct {
+	name    string
+	content []byte
+}
+
+func isTemplateBundle(fileName string) bool {
+	return strings.EqualFold(filepath.Ext(fileName), ".zip")
+}
+
+// writeTemplateBundle extract the zip bundle into its own directory per template version,
+// it returns the bundle directory and the path of the html entry
+func (t *TemplateServiceImpl) writeTemplateBundle(content []byte, fileName string, version uint) (string, string, error) {
+	files, entry, err := t.readTemplateBundle(content)
+	if err != nil {
+		return "", "", err
+	}
+
+	bundleName := fmt.Sprint(time.Now().UnixNano(), "-", strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName)))
+	bundleDir := filepath.Join("./template", bundleName, fmt.Sprint("v", version))
+
+	if err := t.extractTemplateBundle(bundleDir, files); err != nil {
+		_ = os.RemoveAll(bundleDir)
+		return "", "", err
+	}
+
+	return bundleDir, filepath.Join(bundleDir, filepath.FromSlash(entry)), nil
+}
+
+func (*TemplateServiceImpl) extractTemplateBundle(bundleDir string, files []bundleFile) error {
+	if _, err := os.Stat(bundleDir); err == nil {
+		return fmt.Errorf("directory '%s' already exist", bundleDir)
+	}
+
+	for _, file := range files {
+		dst := filepath.Join(bundleDir, filepath.FromSlash(file.name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(dst, file.content, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readTemplateBundle validate and read every file in the bundle, returning the files and the html entry name
+func (t *TemplateServiceImpl) readTemplateBundle(content []byte) ([]bundleFile, string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, "", utils.ErrInvalidTemplateBundle
+	}
+
+	var files []bundleFile
+	var htmlFiles []string
+	remaining := t.maxBundleSize
+
+	for _, zipFile := range reader.File {
+		if zipFile.FileInfo().IsDir() || isBundleJunk(zipFile.Name) {
+			continue
+		}
+
+		name, ok := cleanBundlePath(zipFile.Name)
+		if !ok || zipFile.Mode()&os.ModeSymlink != 0 {
+			return nil, "", utils.ErrInvalidTemplateBundle
+		}
+
+		ext := strings.ToLower(path.Ext(name))
+		if !bundleFileTypes[ext] {
+			return nil, "", utils.ErrInvalidTemplateBundle
+		}
+
+		fileContent, err := readBundleFile(zipFile, remaining)
+		if err != nil {
+			return nil, "", err
+		}
+		remaining -= int64(len(fileContent))
+
+		if err := t.checkBundleFile(ext, fileContent); err != nil {
+			return nil, "", err
+		}
+
+		if ext == ".html" || ext == ".htm" {
+			htmlFiles = append(htmlFiles, name)
+		}
+		files = append(files, bundleFile{name: name, content: fileContent})
+	}
+
+	entry, ok := findBundleEntry(htmlFiles)
+	if !ok {
+		return nil, "", utils.ErrInvalidTemplateBundle
+	}
+
+	return files, entry, nil
+}
+
+func (t *TemplateServiceImpl) checkBundleFile(ext string, content []byte) error {
+	switch ext {
+	case ".html", ".htm", ".svg":
+		return t.templateSanitizer.CheckTemplate(content)
+	case ".css":
+		return t.templateSanitizer.CheckTemplate([]byte("<style>" + string(content) + "</style>"))
+	default:
+		return nil
+	}
+}
+
+// readBundleFile read the file without trusting the size declared in zip header
+func readBundleFile(zipFile *zip.File, limit int64) ([]byte, error) {
+	src, err := zipFile.Open()
+	if err != nil {
+		return nil, utils.ErrInvalidTemplateBundle
+	}
+	defer src.Close()
+
+	content, err := io.ReadAll(io.LimitReader(src, limit+1))
+	if err != nil {
+		return nil, utils.ErrInvalidTemplateBundle
+	}
+
+	if int64(len(content)) > limit {
+		return nil, utils.ErrTemplateTooLarge
+	}
+
+	return content, nil
+}
+
+// cleanBundlePath reject absolute path and path that escape the bundle directory
+func cleanBundlePath(name string) (string, bool) {
+	if strings.Contains(name, "\\") || strings.HasPrefix(name, "/") {
+		return "", false
+	}
+
+	cleaned := path.Clean(name)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", false
+	}
+
+	return cleaned, true
+}
+
+// isBundleJunk detect metadata file added by archiver, eg: macOS resource fork
+func isBundleJunk(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".")
+}
+
+// findBundleEntry choose the shallowest index.html, or the only html file in the bundle
+func findBundleEntry(htmlFiles []string) (string, bool) {
+	entry := ""
+	for _, name := range htmlFiles {
+		base := strings.ToLower(path.Base(name))
+		if base != "index.html" && base != "index.htm" {
+			continue
+		}
+
+		if entry == "" || strings.Count(name, "/") < strings.Count(entry, "/") {
+			entry = name
+		}
+	}
+
+	if entry != "" {
+		return entry, true
+	}
+
+	if len(htmlFiles) == 1 {
+		return htmlFiles[0], true
+	}
+
+	return "", false
+}
@@ -0,0 +1,110 @@
+package impl
+
+import (
+	"archive/zip"
+	"bytes"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+func createBundle(files map[string]string) []byte {
+	buf := new(bytes.Buffer)
+	writer := zip.NewWriter(buf)
+	for name, content := range files {
+		file, _ := writer.Create(name)
+		_, _ = file.Write([]byte(content))
+	}
+	_ = writer.Close()
+
+	return buf.Bytes()
+}
+
+func (s *TestSuiteTemplateService) TestReadTemplateBundle() {
+	for _, tc := range []struct {
+		Name          string
+		Files         map[string]string
+		SanitizerErr  error
+		ExpectedEntry string
+		ExpectedErr   error
+	}{
+		{
+			Name: "Success with index entry",
+			Files: map[string]string{
+				"surat/index.html":    `<img src="img/logo.png">`,
+				"surat/img/logo.png":  "png",
+				"surat/css/style.css": "body { font-family: Arial }",
+				"surat/other.html":    "<p>other</p>",
+			},
+			ExpectedEntry: "surat/index.html",
+		},
+		{
+			Name: "Success with single html entry",
+			Files: map[string]string{
+				"letter.html":          "<p>letter</p>",
+				"fonts/arial.ttf":      "font",
+				"__MACOSX/._letter.ht": "junk",
+			},
+			ExpectedEntry: "letter.html",
+		},
+		{
+			Name: "Failed no entry",
+			Files: map[string]string{
+				"a.html": "<p>a</p>",
+				"b.html": "<p>b</p>",
+			},
+			ExpectedErr: utils.ErrInvalidTemplateBundle,
+		},
+		{
+			Name: "Failed unsupported file",
+			Files: map[string]string{
+				"index.html": "<p>a</p>",
+				"run.sh":     "rm -rf /",
+			},
+			ExpectedErr: utils.ErrInvalidTemplateBundle,
+		},
+		{
+			Name: "Failed path traversal",
+			Files: map[string]string{
+				"index.html":     "<p>a</p>",
+				"../../evil.css": "body {}",
+			},
+			ExpectedErr: utils.ErrInvalidTemplateBundle,
+		},
+		{
+			Name: "Failed extracted size too large",
+			Files: map[string]string{
+				"index.html": string(bytes.Repeat([]byte("a"), 128)),
+			},
+			ExpectedErr: utils.ErrTemplateTooLarge,
+		},
+		{
+			Name: "Failed unsafe html",
+			Files: map[string]string{
+				"index.html": "<script></script>",
+			},
+			SanitizerErr: utils.ErrTemplateContainsScript,
+			ExpectedErr:  utils.ErrTemplateContainsScript,
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+			s.templateService.maxBundleSize = 96
+			s.mockTemplateSanitizer.On("CheckTemplate", mock.Anything).Return(tc.SanitizerErr)
+
+			files, entry, err := s.templateService.readTemplateBundle(createBundle(tc.Files))
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedEntry, entry)
+			if tc.ExpectedErr == nil {
+				s.NotEmpty(files)
+			}
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteTemplateService) TestReadTemplateBundle_InvalidZip() {
+	_, _, err := s.templateService.readTemplateBundle([]byte("not a zip"))
+	s.Equal(utils.ErrInvalidTemplateBundle, err)
+}
@@ -1,13 +1,18 @@
 package impl
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/suryaadi44/eAD-System/internal/template/service"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/binding"
+	"github.com/suryaadi44/eAD-System/pkg/utils/sanitizer"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/suryaadi44/eAD-System/internal/template/dto"
@@ -17,30 +22,121 @@ import (
 
 type TemplateServiceImpl struct {
 	templateRepository repository.TemplateRepository
+	templateSanitizer  sanitizer.TemplateSanitizer
+	maxTemplateSize    int64
+	maxBundleSize      int64
 }
 
-func NewTemplateServiceImpl(templateRepository repository.TemplateRepository) service.TemplateService {
+func NewTemplateServiceImpl(templateRepository repository.TemplateRepository, templateSanitizer sanitizer.TemplateSanitizer, maxTemplateSize int64, maxBundleSize int64) service.TemplateService {
 	return &TemplateServiceImpl{
 		templateRepository: templateRepository,
+		templateSanitizer:  templateSanitizer,
+		maxTemplateSize:    maxTemplateSize,
+		maxBundleSize:      maxBundleSize,
 	}
 }
 
 func (t *TemplateServiceImpl) AddTemplate(ctx context.Context, template *dto.TemplateRequest, file io.Reader, fileName string) error {
-	path, err := t.writeTemplateFile(file, fileName)
+	content, err := t.readTemplateFile(file)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range template.Keys {
+		if binding.IsApplicantField(key) && !binding.IsKnownApplicantField(key) {
+			return utils.ErrUnknownApplicantField
+		}
+	}
+
+	for _, section := range []string{template.HeaderHTML, template.FooterHTML} {
+		if err = t.checkPageSection(section); err != nil {
+			return err
+		}
+	}
+
+	tables, err := template.ParseTables()
 	if err != nil {
 		return err
 	}
 
 	templateEntity := template.ToEntity()
-	templateEntity.Path = path
+	templateEntity.Fields = append(templateEntity.Fields, tables...)
+	if err = template.ApplyRules(templateEntity.Fields); err != nil {
+		return err
+	}
+	templateEntity.Version = 1
+
+	if isTemplateBundle(fileName) {
+		templateEntity.BundlePath, templateEntity.Path, err = t.writeTemplateBundle(content, fileName, templateEntity.Version)
+		if err != nil {
+			return err
+		}
+	} else {
+		if err = t.templateSanitizer.CheckTemplate(content); err != nil {
+			return err
+		}
+
+		templateEntity.Path, err = t.writeTemplateFile(bytes.NewReader(content), fileName)
+		if err != nil {
+			return err
+		}
+	}
+
+	templateEntity.HeaderPath, err = t.writePageSection(template.HeaderHTML, fileName, "header")
+	if err != nil {
+		return err
+	}
+
+	templateEntity.FooterPath, err = t.writePageSection(template.FooterHTML, fileName, "footer")
+	if err != nil {
+		return err
+	}
 
 	return t.addTemplateToRepo(ctx, templateEntity)
 }
 
+// checkPageSection validate the running header or footer html, empty section is allowed
+func (t *TemplateServiceImpl) checkPageSection(content string) error {
+	if content == "" {
+		return nil
+	}
+
+	if int64(len(content)) > t.maxTemplateSize {
+		return utils.ErrTemplateTooLarge
+	}
+
+	return t.templateSanitizer.CheckTemplate([]byte(content))
+}
+
+// writePageSection save the running header or footer html next to the template file, return empty path for empty section
+func (t *TemplateServiceImpl) writePageSection(content string, fileName string, section string) (string, error) {
+	if content == "" {
+		return "", nil
+	}
+
+	sectionFileName := fmt.Sprint(strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName)), "-", section, ".html")
+
+	return t.writeTemplateFile(strings.NewReader(content), sectionFileName)
+}
+
 func (t *TemplateServiceImpl) addTemplateToRepo(ctx context.Context, template *entity.Template) error {
 	return t.templateRepository.AddTemplate(ctx, template)
 }
 
+func (t *TemplateServiceImpl) readTemplateFile(file io.Reader) ([]byte, error) {
+	// read one byte past the limit to know if the file exceed it
+	content, err := io.ReadAll(io.LimitReader(file, t.maxTemplateSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(content)) > t.maxTemplateSize {
+		return nil, utils.ErrTemplateTooLarge
+	}
+
+	return content, nil
+}
+
 func (*TemplateServiceImpl) writeTemplateFile(file io.Reader, fileName string) (string, error) {
 	newFileName := fmt.Sprint(time.Now().UnixNano(), "-", fileName)
 	path := filepath.Join("./template", newFileName)
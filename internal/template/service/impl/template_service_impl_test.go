@@ -8,29 +8,69 @@ import (
 	"github.com/suryaadi44/eAD-System/internal/template/dto"
 	mockTemplateRepoPkg "github.com/suryaadi44/eAD-System/internal/template/repository/mock"
 	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	mockSanitizerPkg "github.com/suryaadi44/eAD-System/pkg/utils/sanitizer/mock"
 	"gorm.io/gorm"
 	"os"
+	"strings"
 	"testing"
 )
 
 type TestSuiteTemplateService struct {
 	suite.Suite
 	mockTemplateRepository *mockTemplateRepoPkg.MockTemplateRepository
+	mockTemplateSanitizer  *mockSanitizerPkg.MockTemplateSanitizer
 	templateService        *TemplateServiceImpl
 }
 
 func (s *TestSuiteTemplateService) SetupTest() {
 	s.mockTemplateRepository = new(mockTemplateRepoPkg.MockTemplateRepository)
+	s.mockTemplateSanitizer = new(mockSanitizerPkg.MockTemplateSanitizer)
 	s.templateService = &TemplateServiceImpl{
 		templateRepository: s.mockTemplateRepository,
+		templateSanitizer:  s.mockTemplateSanitizer,
+		maxTemplateSize:    32,
 	}
 }
 
 func (s *TestSuiteTemplateService) TearDownTest() {
 	s.mockTemplateRepository = nil
+	s.mockTemplateSanitizer = nil
 	s.templateService = nil
 }
 
+func (s *TestSuiteTemplateService) TestNewTemplateServiceImpl() {
+	s.NotNil(NewTemplateServiceImpl(s.mockTemplateRepository, s.mockTemplateSanitizer, 32, 64))
+}
+
+func (s *TestSuiteTemplateService) TestAddTemplate_FailTemplateTooLarge() {
+	err := s.templateService.AddTemplate(context.Background(), &dto.TemplateRequest{}, strings.NewReader("<p>this is more than thirty two bytes long</p>"), "test.html")
+	s.Equal(utils.ErrTemplateTooLarge, err)
+	s.mockTemplateSanitizer.AssertNotCalled(s.T(), "CheckTemplate", mock.Anything)
+}
+
+func (s *TestSuiteTemplateService) TestAddTemplate_FailUnsafeTemplate() {
+	s.mockTemplateSanitizer.On("CheckTemplate", []byte("<script></script>")).Return(utils.ErrTemplateContainsScript)
+
+	err := s.templateService.AddTemplate(context.Background(), &dto.TemplateRequest{}, strings.NewReader("<script></script>"), "test.html")
+	s.Equal(utils.ErrTemplateContainsScript, err)
+	s.mockTemplateRepository.AssertNotCalled(s.T(), "AddTemplate", mock.Anything, mock.Anything)
+}
+
+func (s *TestSuiteTemplateService) TestAddTemplate_FailUnsafePageSection() {
+	s.mockTemplateSanitizer.On("CheckTemplate", []byte("<script></script>")).Return(utils.ErrTemplateContainsScript)
+
+	err := s.templateService.AddTemplate(context.Background(), &dto.TemplateRequest{HeaderHTML: "<script></script>"}, strings.NewReader("<p>test</p>"), "test.html")
+	s.Equal(utils.ErrTemplateContainsScript, err)
+	s.mockTemplateRepository.AssertNotCalled(s.T(), "AddTemplate", mock.Anything, mock.Anything)
+}
+
+func (s *TestSuiteTemplateService) TestAddTemplate_FailUnknownApplicantField() {
+	err := s.templateService.AddTemplate(context.Background(), &dto.TemplateRequest{Keys: []string{"applicant.password"}}, strings.NewReader("<p>test</p>"), "test.html")
+	s.Equal(utils.ErrUnknownApplicantField, err)
+	s.mockTemplateRepository.AssertNotCalled(s.T(), "AddTemplate", mock.Anything, mock.Anything)
+}
+
 func (s *TestSuiteTemplateService) TestAddTemplateToRepo_Success() {
 	file, err := os.Open("../../../../template/test.html")
 	if err != nil {
@@ -1,13 +1,17 @@
 package controller
 
 import (
+	"context"
 	"github.com/labstack/echo/v4"
 	"github.com/suryaadi44/eAD-System/internal/user/dto"
 	"github.com/suryaadi44/eAD-System/internal/user/service"
 	"github.com/suryaadi44/eAD-System/pkg/utils"
 	"github.com/suryaadi44/eAD-System/pkg/utils/jwt_service"
+	"github.com/suryaadi44/eAD-System/pkg/utils/rbac"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type UserController struct {
@@ -35,6 +39,10 @@ func (u *UserController) SignUpUser(c echo.Context) error {
 	err := u.userService.SignUpUser(c.Request().Context(), user)
 	if err != nil {
 		switch err {
+		case utils.ErrPasswordTooLong:
+			fallthrough
+		case utils.ErrPasswordTooShort:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		case utils.ErrUsernameAlreadyExist:
 			fallthrough
 		case utils.ErrNIKAlreadyExist:
@@ -61,30 +69,103 @@ func (u *UserController) LoginUser(c echo.Context) error {
 		return err
 	}
 
-	token, err := u.userService.LogInUser(c.Request().Context(), user)
+	tokens, err := u.userService.LogInUser(c.Request().Context(), user)
 	if err != nil {
 		switch err {
 		case utils.ErrInvalidCredentials:
 			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		case utils.ErrUserDeactivated:
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
 		default:
 			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
 	}
 
 	return c.JSON(http.StatusOK, echo.Map{
-		"message": "success login",
-		"token":   token,
+		"message":              "success login",
+		"token":                tokens.Token,
+		"refresh_token":        tokens.RefreshToken,
+		"must_change_password": tokens.MustChangePassword,
 	})
 }
 
-func (u *UserController) GetBriefUsers(c echo.Context) error {
+// RefreshToken exchange the refresh token with a new pair, the used refresh token can't be used again
+func (u *UserController) RefreshToken(c echo.Context) error {
+	request := new(dto.RefreshTokenRequest)
+	if err := c.Bind(request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	tokens, err := u.userService.RefreshToken(c.Request().Context(), request)
+	if err != nil {
+		switch err {
+		case utils.ErrInvalidRefreshToken:
+			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		case utils.ErrUserDeactivated:
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message":              "success refresh token",
+		"token":                tokens.Token,
+		"refresh_token":        tokens.RefreshToken,
+		"must_change_password": tokens.MustChangePassword,
+	})
+}
+
+// LogoutUser end the session of the given refresh token, the other session of the user stay logged in
+func (u *UserController) LogoutUser(c echo.Context) error {
+	request := new(dto.RefreshTokenRequest)
+	if err := c.Bind(request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
 	claims := u.jwtService.GetClaims(&c)
-	role := claims["role"].(float64)
+	userID := claims["user_id"].(string)
 
-	if role == 1 {
-		return echo.NewHTTPError(http.StatusForbidden, utils.ErrDidntHavePermission.Error())
+	err := u.userService.LogOutUser(c.Request().Context(), userID, request)
+	if err != nil {
+		if err == utils.ErrInvalidRefreshToken {
+			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "success logout",
+	})
+}
+
+// LogoutAllSessions end every session of the user, eg: after the device is lost
+func (u *UserController) LogoutAllSessions(c echo.Context) error {
+	claims := u.jwtService.GetClaims(&c)
+	userID := claims["user_id"].(string)
+
+	err := u.userService.LogOutAllSessions(c.Request().Context(), userID)
+	if err != nil {
+		if err == utils.ErrUserNotFound {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "success logout from every session",
+	})
+}
+
+func (u *UserController) GetBriefUsers(c echo.Context) error {
 	page := c.QueryParam("page")
 	if page == "" {
 		page = "1"
@@ -139,6 +220,12 @@ func (u *UserController) UpdateUser(c echo.Context) error {
 		switch err {
 		case utils.ErrUserNotFound:
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case utils.ErrPasswordTooLong:
+			fallthrough
+		case utils.ErrPasswordTooShort:
+			fallthrough
+		case utils.ErrPasswordReused:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		case utils.ErrUsernameAlreadyExist:
 			fallthrough
 		case utils.ErrNIKAlreadyExist:
@@ -154,3 +241,347 @@ func (u *UserController) UpdateUser(c echo.Context) error {
 		"message": "success update user",
 	})
 }
+
+// CreateEmployee create the account of verifier, admin or other employee, only user manager can do it
+func (u *UserController) CreateEmployee(c echo.Context) error {
+	employee := new(dto.EmployeeCreateRequest)
+	if err := c.Bind(employee); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
+	}
+
+	if err := c.Validate(employee); err != nil {
+		return err
+	}
+
+	userID, err := u.userService.CreateEmployee(c.Request().Context(), employee)
+	if err != nil {
+		switch err {
+		case utils.ErrRoleNotFound:
+			fallthrough
+		case utils.ErrPasswordTooLong:
+			fallthrough
+		case utils.ErrPasswordTooShort:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case utils.ErrUsernameAlreadyExist:
+			fallthrough
+		case utils.ErrNIKAlreadyExist:
+			fallthrough
+		case utils.ErrNIPAlreadyExist:
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{
+		"message": "success creating employee",
+		"data": echo.Map{
+			"id": userID,
+		},
+	})
+}
+
+func (u *UserController) GetUser(c echo.Context) error {
+	user, err := u.userService.GetUser(c.Request().Context(), c.Param("user_id"))
+	if err != nil {
+		if err == utils.ErrUserNotFound {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "success get user",
+		"data":    user,
+	})
+}
+
+func (u *UserController) ChangeUserRole(c echo.Context) error {
+	request := new(dto.UserRoleRequest)
+	if err := c.Bind(request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	err := u.userService.ChangeUserRole(c.Request().Context(), c.Param("user_id"), request)
+	if err != nil {
+		switch err {
+		case utils.ErrUserNotFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case utils.ErrRoleNotFound:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "success change user role",
+	})
+}
+
+func (u *UserController) ResetUserPassword(c echo.Context) error {
+	request := new(dto.UserPasswordRequest)
+	if err := c.Bind(request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	err := u.userService.ResetUserPassword(c.Request().Context(), c.Param("user_id"), request)
+	if err != nil {
+		switch err {
+		case utils.ErrUserNotFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case utils.ErrPasswordTooLong:
+			fallthrough
+		case utils.ErrPasswordTooShort:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "success reset user password",
+	})
+}
+
+func (u *UserController) DeactivateUser(c echo.Context) error {
+	claims := u.jwtService.GetClaims(&c)
+	requesterID := claims["user_id"].(string)
+
+	err := u.userService.DeactivateUser(c.Request().Context(), requesterID, c.Param("user_id"))
+	if err != nil {
+		switch err {
+		case utils.ErrUserNotFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case utils.ErrSelfDeactivation:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "success deactivate user",
+	})
+}
+
+func (u *UserController) ReactivateUser(c echo.Context) error {
+	err := u.userService.ReactivateUser(c.Request().Context(), c.Param("user_id"))
+	if err != nil {
+		if err == utils.ErrUserNotFound {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "success reactivate user",
+	})
+}
+
+// RequireActiveUser reject the token of deactivated or deleted user and the revoked token, it must be placed after the
+// jwt middleware. The user who must change their password is refused too
+func (u *UserController) RequireActiveUser(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := u.checkActiveUser(c, false); err != nil {
+			return err
+		}
+
+		return next(c)
+	}
+}
+
+// RequireActiveUserAllowPasswordChange is RequireActiveUser for the route used to change the password given by the admin
+func (u *UserController) RequireActiveUserAllowPasswordChange(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := u.checkActiveUser(c, true); err != nil {
+			return err
+		}
+
+		return next(c)
+	}
+}
+
+func (u *UserController) checkActiveUser(c echo.Context, allowPasswordChange bool) error {
+	claims := u.jwtService.GetClaims(&c)
+	userID, _ := claims["user_id"].(string)
+	issuedAtSecond, _ := claims["iat"].(float64)
+	issuedAt := time.Unix(int64(issuedAtSecond), 0)
+	// the token issued before the millisecond claim only carry the second
+	if issuedAtMilli, ok := claims[jwt_service.ClaimIssuedAtMilli].(float64); ok {
+		issuedAt = time.UnixMilli(int64(issuedAtMilli))
+	}
+
+	err := u.userService.CheckUserActive(c.Request().Context(), userID, issuedAt)
+	if err != nil {
+		switch err {
+		case utils.ErrUserNotFound:
+			fallthrough
+		case utils.ErrTokenRevoked:
+			fallthrough
+		case utils.ErrUserDeactivated:
+			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		case utils.ErrPasswordChangeRequired:
+			if allowPasswordChange {
+				return nil
+			}
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// ChangePassword replace the password of the user, it is the only route allowed before the password given by the admin
+// is changed
+func (u *UserController) ChangePassword(c echo.Context) error {
+	claims := u.jwtService.GetClaims(&c)
+	userID := claims["user_id"].(string)
+
+	request := new(dto.UserChangePasswordRequest)
+	if err := c.Bind(request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	err := u.userService.ChangePassword(c.Request().Context(), userID, request)
+	if err != nil {
+		switch err {
+		case utils.ErrUserNotFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case utils.ErrWrongPassword:
+			fallthrough
+		case utils.ErrPasswordTooLong:
+			fallthrough
+		case utils.ErrPasswordTooShort:
+			fallthrough
+		case utils.ErrPasswordReused:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "success change password",
+	})
+}
+
+// ForgotPassword always respond with success, so it can't be used to find out which username exist
+func (u *UserController) ForgotPassword(c echo.Context) error {
+	request := new(dto.ForgotPasswordRequest)
+	if err := c.Bind(request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	u.userService.ForgotPassword(c.Request().Context(), request)
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "password reset instruction is sent if the account exist",
+	})
+}
+
+func (u *UserController) ResetPassword(c echo.Context) error {
+	request := new(dto.ResetPasswordRequest)
+	if err := c.Bind(request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	err := u.userService.ResetPassword(c.Request().Context(), request)
+	if err != nil {
+		switch err {
+		case utils.ErrInvalidResetToken:
+			fallthrough
+		case utils.ErrPasswordTooLong:
+			fallthrough
+		case utils.ErrPasswordTooShort:
+			fallthrough
+		case utils.ErrPasswordReused:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "success reset password",
+	})
+}
+
+// UpdateSignatureImage upload the handwritten signature image of the signer, only the signer or user manager can do it
+func (u *UserController) UpdateSignatureImage(c echo.Context) error {
+	claims := u.jwtService.GetClaims(&c)
+	requesterID := claims["user_id"].(string)
+
+	if !rbac.HasPermission(claims, rbac.PermissionUserManage) && requesterID != c.Param("user_id") {
+		return echo.NewHTTPError(http.StatusForbidden, utils.ErrDidntHavePermission.Error())
+	}
+
+	return u.updateSignatureImage(c, u.userService.UpdateSignatureImage, "success update signature image")
+}
+
+// UpdateStampImage upload the office stamp image printed over the signature of the signer, only user manager can do it
+func (u *UserController) UpdateStampImage(c echo.Context) error {
+	return u.updateSignatureImage(c, u.userService.UpdateStampImage, "success update stamp image")
+}
+
+func (u *UserController) updateSignatureImage(c echo.Context, update func(ctx context.Context, userID string, request *dto.SignatureImageRequest, image io.Reader) error, message string) error {
+	request := new(dto.SignatureImageRequest)
+	if err := c.Bind(request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, utils.ErrBadRequestBody.Error())
+	}
+
+	fileSrc, err := file.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer fileSrc.Close()
+
+	err = update(c.Request().Context(), c.Param("user_id"), request, fileSrc)
+	if err != nil {
+		switch err {
+		case utils.ErrUserNotFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case utils.ErrImageTooLarge:
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, err.Error())
+		case utils.ErrInvalidImage:
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": message,
+	})
+}
@@ -7,10 +7,13 @@ import (
 	mockUserServicePkg "github.com/suryaadi44/eAD-System/internal/user/service/mock"
 	"github.com/suryaadi44/eAD-System/pkg/utils"
 	mockJwtServicePkg "github.com/suryaadi44/eAD-System/pkg/utils/jwt_service/mock"
+	"github.com/suryaadi44/eAD-System/pkg/utils/rbac"
 	mockValidatorPkg "github.com/suryaadi44/eAD-System/pkg/utils/validation/mock"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
@@ -84,6 +87,18 @@ func (s *TestSuiteUserControllers) TestUpdateUser() {
 			ExpectedBody:   nil,
 			ExpectedError:  utils.ErrUserNotFound,
 		},
+		{
+			Name: "Failed updating user : Password reused",
+			RequestBody: &dto.UserSignUpRequest{
+				Password: "password",
+			},
+			FunctionError: utils.ErrPasswordReused,
+			JWTReturn: jwt.MapClaims{
+				"user_id": "1",
+			},
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrPasswordReused,
+		},
 		{
 			Name: "Failed updating user : Username already exist",
 			RequestBody: &dto.UserSignUpRequest{
@@ -206,7 +221,7 @@ func (s *TestSuiteUserControllers) TestLogInUser() {
 		Name            string
 		RequestBody     interface{}
 		FunctionError   error
-		FunctionReturn  string
+		FunctionReturn  *dto.TokenResponse
 		ValidationError error
 		ExpectedStatus  int
 		ExpectedBody    echo.Map
@@ -218,11 +233,13 @@ func (s *TestSuiteUserControllers) TestLogInUser() {
 				Username: "suryaadi",
 				Password: "123456",
 			},
-			FunctionReturn: "token",
+			FunctionReturn: &dto.TokenResponse{Token: "token", RefreshToken: "refresh", MustChangePassword: true},
 			ExpectedStatus: http.StatusOK,
 			ExpectedBody: echo.Map{
-				"message": "success login",
-				"token":   "token",
+				"message":              "success login",
+				"token":                "token",
+				"refresh_token":        "refresh",
+				"must_change_password": true,
 			},
 		},
 		{
@@ -235,6 +252,16 @@ func (s *TestSuiteUserControllers) TestLogInUser() {
 			ExpectedStatus: http.StatusUnauthorized,
 			ExpectedError:  utils.ErrInvalidCredentials,
 		},
+		{
+			Name: "Failed logging in user : User deactivated",
+			RequestBody: &dto.UserLoginRequest{
+				Username: "suryaadi",
+				Password: "123456",
+			},
+			FunctionError:  utils.ErrUserDeactivated,
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedError:  utils.ErrUserDeactivated,
+		},
 		{
 			Name: "Failed creating user : Generic error",
 			RequestBody: &dto.UserLoginRequest{
@@ -401,19 +428,6 @@ func (s *TestSuiteUserControllers) TestGetBriefUsers() {
 			ExpectedBody:   nil,
 			ExpectedError:  utils.ErrInvalidNumber,
 		},
-		{
-			Name:           "Failed getting brief users : role is not employee",
-			Page:           "1",
-			Limit:          "10",
-			FunctionError:  nil,
-			FunctionReturn: nil,
-			JWTReturn: jwt.MapClaims{
-				"role": float64(1),
-			},
-			ExpectedStatus: http.StatusForbidden,
-			ExpectedBody:   nil,
-			ExpectedError:  utils.ErrDidntHavePermission,
-		},
 		{
 			Name:           "Failed getting brief users : error no user found",
 			Page:           "",
@@ -521,6 +535,21 @@ func (s *TestSuiteUserControllers) TestSignUpUser() {
 			ExpectedStatus: http.StatusConflict,
 			ExpectedError:  utils.ErrUsernameAlreadyExist,
 		},
+		{
+			Name: "Failed creating user : Password too short",
+			RequestBody: &dto.UserSignUpRequest{
+				Username: "suryaadi",
+				Password: "123456",
+				NIK:      "1234567890123456",
+				Name:     "Surya Adi",
+				Telp:     "081234567890",
+				Sex:      "L",
+				Address:  "Jl. Jalan",
+			},
+			FunctionError:  utils.ErrPasswordTooShort,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrPasswordTooShort,
+		},
 		{
 			Name: "Failed creating user : NIK already exist",
 			RequestBody: &dto.UserSignUpRequest{
@@ -623,6 +652,989 @@ func (s *TestSuiteUserControllers) TestSignUpUser() {
 	}
 }
 
+func (s *TestSuiteUserControllers) TestUpdateSignatureImage() {
+	for _, tc := range []struct {
+		Name           string
+		Stamp          bool
+		WithFile       bool
+		FunctionError  error
+		JWTReturn      jwt.MapClaims
+		ExpectedStatus int
+		ExpectedBody   echo.Map
+		ExpectedError  error
+	}{
+		{
+			Name:           "Success updating own signature image",
+			WithFile:       true,
+			JWTReturn:      jwt.MapClaims{"user_id": "1", "role": float64(2)},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success update signature image",
+			},
+		},
+		{
+			Name:           "Success updating signature image by admin",
+			WithFile:       true,
+			JWTReturn:      jwt.MapClaims{"user_id": "2", rbac.ClaimPermissions: []interface{}{rbac.PermissionUserManage}},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success update signature image",
+			},
+		},
+		{
+			Name:           "Success updating stamp image by admin",
+			Stamp:          true,
+			WithFile:       true,
+			JWTReturn:      jwt.MapClaims{"user_id": "2", rbac.ClaimPermissions: []interface{}{rbac.PermissionUserManage}},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success update stamp image",
+			},
+		},
+		{
+			Name:           "Failed updating signature image: other user",
+			WithFile:       true,
+			JWTReturn:      jwt.MapClaims{"user_id": "2", "role": float64(2)},
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedError:  utils.ErrDidntHavePermission,
+		},
+		{
+			Name:           "Failed updating signature image: no file uploaded",
+			JWTReturn:      jwt.MapClaims{"user_id": "1", "role": float64(2)},
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrBadRequestBody,
+		},
+		{
+			Name:           "Failed updating signature image: user not found",
+			WithFile:       true,
+			FunctionError:  utils.ErrUserNotFound,
+			JWTReturn:      jwt.MapClaims{"user_id": "2", rbac.ClaimPermissions: []interface{}{rbac.PermissionUserManage}},
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedError:  utils.ErrUserNotFound,
+		},
+		{
+			Name:           "Failed updating signature image: image too large",
+			WithFile:       true,
+			FunctionError:  utils.ErrImageTooLarge,
+			JWTReturn:      jwt.MapClaims{"user_id": "1", "role": float64(2)},
+			ExpectedStatus: http.StatusRequestEntityTooLarge,
+			ExpectedError:  utils.ErrImageTooLarge,
+		},
+		{
+			Name:           "Failed updating stamp image: invalid image",
+			Stamp:          true,
+			WithFile:       true,
+			FunctionError:  utils.ErrInvalidImage,
+			JWTReturn:      jwt.MapClaims{"user_id": "2", rbac.ClaimPermissions: []interface{}{rbac.PermissionUserManage}},
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrInvalidImage,
+		},
+		{
+			Name:           "Failed updating signature image: generic error",
+			WithFile:       true,
+			FunctionError:  errors.New("generic error"),
+			JWTReturn:      jwt.MapClaims{"user_id": "1", "role": float64(2)},
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			s.Require().NoError(writer.WriteField("height", "25"))
+			if tc.WithFile {
+				part, err := writer.CreateFormFile("image", "ttd.png")
+				s.Require().NoError(err)
+				_, err = part.Write([]byte("\x89PNG"))
+				s.Require().NoError(err)
+			}
+			s.Require().NoError(writer.Close())
+
+			r := httptest.NewRequest("PUT", "/", body)
+			r.Header.Set("Content-Type", writer.FormDataContentType())
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+			c.SetPath("/v1/users/:user_id/signature/")
+			c.SetParamNames("user_id")
+			c.SetParamValues("1")
+
+			s.mockJWT.On("GetClaims", mock.Anything).Return(tc.JWTReturn)
+			s.mockValidator.On("Validate", mock.Anything).Return(nil)
+			s.mockUserService.On("UpdateSignatureImage", mock.Anything, "1", &dto.SignatureImageRequest{Height: 25}, mock.Anything).Return(tc.FunctionError)
+			s.mockUserService.On("UpdateStampImage", mock.Anything, "1", &dto.SignatureImageRequest{Height: 25}, mock.Anything).Return(tc.FunctionError)
+
+			var err error
+			if tc.Stamp {
+				err = s.userController.UpdateStampImage(c)
+			} else {
+				err = s.userController.UpdateSignatureImage(c)
+			}
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteUserControllers) TestRefreshToken() {
+	for _, tc := range []struct {
+		Name            string
+		RequestBody     interface{}
+		FunctionError   error
+		FunctionReturn  *dto.TokenResponse
+		ValidationError error
+		ExpectedStatus  int
+		ExpectedBody    echo.Map
+		ExpectedError   error
+	}{
+		{
+			Name:           "Success refreshing token",
+			RequestBody:    &dto.RefreshTokenRequest{RefreshToken: "refresh"},
+			FunctionReturn: &dto.TokenResponse{Token: "token", RefreshToken: "next"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message":              "success refresh token",
+				"token":                "token",
+				"refresh_token":        "next",
+				"must_change_password": false,
+			},
+		},
+		{
+			Name:           "Failed refreshing token : Invalid refresh token",
+			RequestBody:    &dto.RefreshTokenRequest{RefreshToken: "refresh"},
+			FunctionError:  utils.ErrInvalidRefreshToken,
+			ExpectedStatus: http.StatusUnauthorized,
+			ExpectedError:  utils.ErrInvalidRefreshToken,
+		},
+		{
+			Name:           "Failed refreshing token : User deactivated",
+			RequestBody:    &dto.RefreshTokenRequest{RefreshToken: "refresh"},
+			FunctionError:  utils.ErrUserDeactivated,
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedError:  utils.ErrUserDeactivated,
+		},
+		{
+			Name:           "Failed refreshing token : Generic error",
+			RequestBody:    &dto.RefreshTokenRequest{RefreshToken: "refresh"},
+			FunctionError:  errors.New("generic error"),
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+		{
+			Name:           "Failed refreshing token : Invalid request body",
+			RequestBody:    "invalid request body",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrBadRequestBody,
+		},
+		{
+			Name:            "Failed refreshing token : Validation error",
+			RequestBody:     &dto.RefreshTokenRequest{},
+			ValidationError: echo.NewHTTPError(http.StatusBadRequest, "validation error"),
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedError:   errors.New("validation error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			jsonBody, err := json.Marshal(tc.RequestBody)
+			s.NoError(err)
+
+			r := httptest.NewRequest("POST", "/users/refresh", bytes.NewBuffer(jsonBody))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+
+			s.mockValidator.On("Validate", mock.Anything).Return(tc.ValidationError)
+			s.mockUserService.On("RefreshToken", mock.Anything, tc.RequestBody).Return(tc.FunctionReturn, tc.FunctionError)
+
+			err = s.userController.RefreshToken(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteUserControllers) TestLogoutUser() {
+	for _, tc := range []struct {
+		Name            string
+		RequestBody     interface{}
+		ValidationError error
+		FunctionError   error
+		ExpectedStatus  int
+		ExpectedBody    echo.Map
+		ExpectedError   error
+	}{
+		{
+			Name:           "Success logging out user",
+			RequestBody:    &dto.RefreshTokenRequest{RefreshToken: "refresh"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success logout",
+			},
+		},
+		{
+			Name:           "Failed logging out user : Invalid refresh token",
+			RequestBody:    &dto.RefreshTokenRequest{RefreshToken: "refresh"},
+			FunctionError:  utils.ErrInvalidRefreshToken,
+			ExpectedStatus: http.StatusUnauthorized,
+			ExpectedError:  utils.ErrInvalidRefreshToken,
+		},
+		{
+			Name:           "Failed logging out user : Generic error",
+			RequestBody:    &dto.RefreshTokenRequest{RefreshToken: "refresh"},
+			FunctionError:  errors.New("generic error"),
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+		{
+			Name:           "Failed logging out user : Invalid request body",
+			RequestBody:    "invalid request body",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrBadRequestBody,
+		},
+		{
+			Name:            "Failed logging out user : Validation error",
+			RequestBody:     &dto.RefreshTokenRequest{},
+			ValidationError: echo.NewHTTPError(http.StatusBadRequest, "validation error"),
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedError:   errors.New("validation error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			jsonBody, err := json.Marshal(tc.RequestBody)
+			s.NoError(err)
+
+			r := httptest.NewRequest("POST", "/users/logout", bytes.NewBuffer(jsonBody))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+
+			s.mockValidator.On("Validate", mock.Anything).Return(tc.ValidationError)
+			s.mockJWT.On("GetClaims", mock.Anything).Return(jwt.MapClaims{"user_id": "1"})
+			s.mockUserService.On("LogOutUser", mock.Anything, "1", tc.RequestBody).Return(tc.FunctionError)
+
+			err = s.userController.LogoutUser(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+				s.mockUserService.AssertNotCalled(s.T(), "LogOutAllSessions", mock.Anything, mock.Anything)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteUserControllers) TestLogoutAllSessions() {
+	for _, tc := range []struct {
+		Name           string
+		FunctionError  error
+		ExpectedStatus int
+		ExpectedBody   echo.Map
+		ExpectedError  error
+	}{
+		{
+			Name:           "Success logging out every session",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success logout from every session",
+			},
+		},
+		{
+			Name:           "Failed logging out every session : User not found",
+			FunctionError:  utils.ErrUserNotFound,
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedError:  utils.ErrUserNotFound,
+		},
+		{
+			Name:           "Failed logging out every session : Generic error",
+			FunctionError:  errors.New("generic error"),
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			r := httptest.NewRequest("POST", "/users/logout/all", nil)
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+
+			s.mockJWT.On("GetClaims", mock.Anything).Return(jwt.MapClaims{"user_id": "1"})
+			s.mockUserService.On("LogOutAllSessions", mock.Anything, "1").Return(tc.FunctionError)
+
+			err := s.userController.LogoutAllSessions(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteUserControllers) TestCreateEmployee() {
+	for _, tc := range []struct {
+		Name            string
+		RequestBody     interface{}
+		FunctionError   error
+		FunctionReturn  string
+		ValidationError error
+		ExpectedStatus  int
+		ExpectedBody    echo.Map
+		ExpectedError   error
+	}{
+		{
+			Name:           "Success creating employee",
+			RequestBody:    &dto.EmployeeCreateRequest{Username: "verifier", Role: 2},
+			FunctionReturn: "1",
+			ExpectedStatus: http.StatusCreated,
+			ExpectedBody: echo.Map{
+				"message": "success creating employee",
+				"data": map[string]interface{}{
+					"id": "1",
+				},
+			},
+		},
+		{
+			Name:           "Failed creating employee : Role not found",
+			RequestBody:    &dto.EmployeeCreateRequest{Username: "verifier", Role: 9},
+			FunctionError:  utils.ErrRoleNotFound,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrRoleNotFound,
+		},
+		{
+			Name:           "Failed creating employee : Username already exist",
+			RequestBody:    &dto.EmployeeCreateRequest{Username: "verifier", Role: 2},
+			FunctionError:  utils.ErrUsernameAlreadyExist,
+			ExpectedStatus: http.StatusConflict,
+			ExpectedError:  utils.ErrUsernameAlreadyExist,
+		},
+		{
+			Name:           "Failed creating employee : Generic error",
+			RequestBody:    &dto.EmployeeCreateRequest{Username: "verifier", Role: 2},
+			FunctionError:  errors.New("generic error"),
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+		{
+			Name:           "Failed creating employee : Invalid request body",
+			RequestBody:    "invalid request body",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrBadRequestBody,
+		},
+		{
+			Name:            "Failed creating employee : Validation error",
+			RequestBody:     &dto.EmployeeCreateRequest{},
+			ValidationError: echo.NewHTTPError(http.StatusBadRequest, "validation error"),
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedError:   errors.New("validation error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			jsonBody, err := json.Marshal(tc.RequestBody)
+			s.NoError(err)
+
+			r := httptest.NewRequest("POST", "/users/employees", bytes.NewBuffer(jsonBody))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+
+			s.mockValidator.On("Validate", mock.Anything).Return(tc.ValidationError)
+			s.mockUserService.On("CreateEmployee", mock.Anything, tc.RequestBody).Return(tc.FunctionReturn, tc.FunctionError)
+
+			err = s.userController.CreateEmployee(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteUserControllers) TestChangeUserRole() {
+	for _, tc := range []struct {
+		Name            string
+		RequestBody     interface{}
+		FunctionError   error
+		ValidationError error
+		ExpectedStatus  int
+		ExpectedBody    echo.Map
+		ExpectedError   error
+	}{
+		{
+			Name:           "Success changing user role",
+			RequestBody:    &dto.UserRoleRequest{Role: 2},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success change user role",
+			},
+		},
+		{
+			Name:           "Failed changing user role : User not found",
+			RequestBody:    &dto.UserRoleRequest{Role: 2},
+			FunctionError:  utils.ErrUserNotFound,
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedError:  utils.ErrUserNotFound,
+		},
+		{
+			Name:           "Failed changing user role : Role not found",
+			RequestBody:    &dto.UserRoleRequest{Role: 9},
+			FunctionError:  utils.ErrRoleNotFound,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrRoleNotFound,
+		},
+		{
+			Name:           "Failed changing user role : Invalid request body",
+			RequestBody:    "invalid request body",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrBadRequestBody,
+		},
+		{
+			Name:            "Failed changing user role : Validation error",
+			RequestBody:     &dto.UserRoleRequest{},
+			ValidationError: echo.NewHTTPError(http.StatusBadRequest, "validation error"),
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedError:   errors.New("validation error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			jsonBody, err := json.Marshal(tc.RequestBody)
+			s.NoError(err)
+
+			r := httptest.NewRequest("PUT", "/users/1/role", bytes.NewBuffer(jsonBody))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+			c.SetParamNames("user_id")
+			c.SetParamValues("1")
+
+			s.mockValidator.On("Validate", mock.Anything).Return(tc.ValidationError)
+			s.mockUserService.On("ChangeUserRole", mock.Anything, "1", tc.RequestBody).Return(tc.FunctionError)
+
+			err = s.userController.ChangeUserRole(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteUserControllers) TestDeactivateUser() {
+	for _, tc := range []struct {
+		Name           string
+		FunctionError  error
+		ExpectedStatus int
+		ExpectedBody   echo.Map
+		ExpectedError  error
+	}{
+		{
+			Name:           "Success deactivating user",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success deactivate user",
+			},
+		},
+		{
+			Name:           "Failed deactivating user : User not found",
+			FunctionError:  utils.ErrUserNotFound,
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedError:  utils.ErrUserNotFound,
+		},
+		{
+			Name:           "Failed deactivating user : Self deactivation",
+			FunctionError:  utils.ErrSelfDeactivation,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrSelfDeactivation,
+		},
+		{
+			Name:           "Failed deactivating user : Generic error",
+			FunctionError:  errors.New("generic error"),
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			r := httptest.NewRequest("PATCH", "/users/2/deactivate", nil)
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+			c.SetParamNames("user_id")
+			c.SetParamValues("2")
+
+			s.mockJWT.On("GetClaims", mock.Anything).Return(jwt.MapClaims{"user_id": "1"})
+			s.mockUserService.On("DeactivateUser", mock.Anything, "1", "2").Return(tc.FunctionError)
+
+			err := s.userController.DeactivateUser(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteUserControllers) TestRequireActiveUser() {
+	for _, tc := range []struct {
+		Name                string
+		AllowPasswordChange bool
+		FunctionError       error
+		ExpectedStatus      int
+		ExpectedError       error
+	}{
+		{
+			Name:           "Active user",
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:           "Revoked token",
+			FunctionError:  utils.ErrTokenRevoked,
+			ExpectedStatus: http.StatusUnauthorized,
+			ExpectedError:  utils.ErrTokenRevoked,
+		},
+		{
+			Name:           "Deactivated user",
+			FunctionError:  utils.ErrUserDeactivated,
+			ExpectedStatus: http.StatusUnauthorized,
+			ExpectedError:  utils.ErrUserDeactivated,
+		},
+		{
+			Name:                "Deactivated user on password change route",
+			AllowPasswordChange: true,
+			FunctionError:       utils.ErrUserDeactivated,
+			ExpectedStatus:      http.StatusUnauthorized,
+			ExpectedError:       utils.ErrUserDeactivated,
+		},
+		{
+			Name:           "Deleted user",
+			FunctionError:  utils.ErrUserNotFound,
+			ExpectedStatus: http.StatusUnauthorized,
+			ExpectedError:  utils.ErrUserNotFound,
+		},
+		{
+			Name:           "Password change required",
+			FunctionError:  utils.ErrPasswordChangeRequired,
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedError:  utils.ErrPasswordChangeRequired,
+		},
+		{
+			Name:                "Password change required on password change route",
+			AllowPasswordChange: true,
+			FunctionError:       utils.ErrPasswordChangeRequired,
+			ExpectedStatus:      http.StatusNoContent,
+		},
+		{
+			Name:           "Generic error",
+			FunctionError:  errors.New("generic error"),
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			r := httptest.NewRequest("GET", "/users", nil)
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+
+			s.mockJWT.On("GetClaims", mock.Anything).Return(jwt.MapClaims{
+				"user_id": "1",
+				"iat":     float64(1700000000),
+				"iat_ms":  float64(1700000000250),
+			})
+			s.mockUserService.On("CheckUserActive", mock.Anything, "1", time.UnixMilli(1700000000250)).Return(tc.FunctionError)
+
+			middleware := s.userController.RequireActiveUser
+			if tc.AllowPasswordChange {
+				middleware = s.userController.RequireActiveUserAllowPasswordChange
+			}
+
+			err := middleware(func(c echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			})(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteUserControllers) TestRequireActiveUser_TokenWithoutMillisecond() {
+	r := httptest.NewRequest("GET", "/users", nil)
+	w := httptest.NewRecorder()
+
+	c := s.echoApp.NewContext(r, w)
+
+	s.mockJWT.On("GetClaims", mock.Anything).Return(jwt.MapClaims{"user_id": "1", "iat": float64(1700000000)})
+	s.mockUserService.On("CheckUserActive", mock.Anything, "1", time.Unix(1700000000, 0)).Return(nil)
+
+	err := s.userController.RequireActiveUser(func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})(c)
+
+	s.NoError(err)
+	s.Equal(http.StatusNoContent, w.Result().StatusCode)
+}
+
+func (s *TestSuiteUserControllers) TestChangePassword() {
+	for _, tc := range []struct {
+		Name            string
+		RequestBody     interface{}
+		FunctionError   error
+		ValidationError error
+		ExpectedStatus  int
+		ExpectedBody    echo.Map
+		ExpectedError   error
+	}{
+		{
+			Name:           "Success changing password",
+			RequestBody:    &dto.UserChangePasswordRequest{OldPassword: "old", NewPassword: "new"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success change password",
+			},
+		},
+		{
+			Name:           "Failed changing password : Wrong password",
+			RequestBody:    &dto.UserChangePasswordRequest{OldPassword: "old", NewPassword: "new"},
+			FunctionError:  utils.ErrWrongPassword,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrWrongPassword,
+		},
+		{
+			Name:           "Failed changing password : Password reused",
+			RequestBody:    &dto.UserChangePasswordRequest{OldPassword: "old", NewPassword: "new"},
+			FunctionError:  utils.ErrPasswordReused,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrPasswordReused,
+		},
+		{
+			Name:           "Failed changing password : User not found",
+			RequestBody:    &dto.UserChangePasswordRequest{OldPassword: "old", NewPassword: "new"},
+			FunctionError:  utils.ErrUserNotFound,
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedError:  utils.ErrUserNotFound,
+		},
+		{
+			Name:           "Failed changing password : Generic error",
+			RequestBody:    &dto.UserChangePasswordRequest{OldPassword: "old", NewPassword: "new"},
+			FunctionError:  errors.New("generic error"),
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+		{
+			Name:           "Failed changing password : Invalid request body",
+			RequestBody:    "invalid request body",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrBadRequestBody,
+		},
+		{
+			Name:            "Failed changing password : Validation error",
+			RequestBody:     &dto.UserChangePasswordRequest{},
+			ValidationError: echo.NewHTTPError(http.StatusBadRequest, "validation error"),
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedError:   errors.New("validation error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			jsonBody, err := json.Marshal(tc.RequestBody)
+			s.NoError(err)
+
+			r := httptest.NewRequest("PUT", "/users/password", bytes.NewBuffer(jsonBody))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+
+			s.mockJWT.On("GetClaims", mock.Anything).Return(jwt.MapClaims{"user_id": "1"})
+			s.mockValidator.On("Validate", mock.Anything).Return(tc.ValidationError)
+			s.mockUserService.On("ChangePassword", mock.Anything, "1", tc.RequestBody).Return(tc.FunctionError)
+
+			err = s.userController.ChangePassword(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteUserControllers) TestForgotPassword() {
+	for _, tc := range []struct {
+		Name            string
+		RequestBody     interface{}
+		ValidationError error
+		ExpectedStatus  int
+		ExpectedBody    echo.Map
+		ExpectedError   error
+	}{
+		{
+			Name:           "Success requesting password reset",
+			RequestBody:    &dto.ForgotPasswordRequest{Username: "suryaadi"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "password reset instruction is sent if the account exist",
+			},
+		},
+		{
+			Name:           "Failed requesting password reset : Invalid request body",
+			RequestBody:    "invalid request body",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrBadRequestBody,
+		},
+		{
+			Name:            "Failed requesting password reset : Validation error",
+			RequestBody:     &dto.ForgotPasswordRequest{},
+			ValidationError: echo.NewHTTPError(http.StatusBadRequest, "validation error"),
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedError:   errors.New("validation error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			jsonBody, err := json.Marshal(tc.RequestBody)
+			s.NoError(err)
+
+			r := httptest.NewRequest("POST", "/users/password/forgot", bytes.NewBuffer(jsonBody))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+
+			s.mockValidator.On("Validate", mock.Anything).Return(tc.ValidationError)
+			s.mockUserService.On("ForgotPassword", mock.Anything, tc.RequestBody).Return()
+
+			err = s.userController.ForgotPassword(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
+func (s *TestSuiteUserControllers) TestResetPassword() {
+	for _, tc := range []struct {
+		Name            string
+		RequestBody     interface{}
+		FunctionError   error
+		ValidationError error
+		ExpectedStatus  int
+		ExpectedBody    echo.Map
+		ExpectedError   error
+	}{
+		{
+			Name:           "Success resetting password",
+			RequestBody:    &dto.ResetPasswordRequest{Token: "token", NewPassword: "newPassword"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: echo.Map{
+				"message": "success reset password",
+			},
+		},
+		{
+			Name:           "Failed resetting password : Invalid token",
+			RequestBody:    &dto.ResetPasswordRequest{Token: "token", NewPassword: "newPassword"},
+			FunctionError:  utils.ErrInvalidResetToken,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrInvalidResetToken,
+		},
+		{
+			Name:           "Failed resetting password : Password too short",
+			RequestBody:    &dto.ResetPasswordRequest{Token: "token", NewPassword: "new"},
+			FunctionError:  utils.ErrPasswordTooShort,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrPasswordTooShort,
+		},
+		{
+			Name:           "Failed resetting password : Password reused",
+			RequestBody:    &dto.ResetPasswordRequest{Token: "token", NewPassword: "oldPassword"},
+			FunctionError:  utils.ErrPasswordReused,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrPasswordReused,
+		},
+		{
+			Name:           "Failed resetting password : Generic error",
+			RequestBody:    &dto.ResetPasswordRequest{Token: "token", NewPassword: "newPassword"},
+			FunctionError:  errors.New("generic error"),
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  errors.New("generic error"),
+		},
+		{
+			Name:           "Failed resetting password : Invalid request body",
+			RequestBody:    "invalid request body",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError:  utils.ErrBadRequestBody,
+		},
+		{
+			Name:            "Failed resetting password : Validation error",
+			RequestBody:     &dto.ResetPasswordRequest{},
+			ValidationError: echo.NewHTTPError(http.StatusBadRequest, "validation error"),
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedError:   errors.New("validation error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			s.SetupTest()
+
+			jsonBody, err := json.Marshal(tc.RequestBody)
+			s.NoError(err)
+
+			r := httptest.NewRequest("POST", "/users/password/reset", bytes.NewBuffer(jsonBody))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c := s.echoApp.NewContext(r, w)
+
+			s.mockValidator.On("Validate", mock.Anything).Return(tc.ValidationError)
+			s.mockUserService.On("ResetPassword", mock.Anything, tc.RequestBody).Return(tc.FunctionError)
+
+			err = s.userController.ResetPassword(c)
+
+			if tc.ExpectedError != nil {
+				s.Equal(echo.NewHTTPError(tc.ExpectedStatus, tc.ExpectedError.Error()), err)
+			} else {
+				s.NoError(err)
+
+				var response echo.Map
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				s.NoError(err)
+
+				s.Equal(tc.ExpectedStatus, w.Result().StatusCode)
+				s.Equal(tc.ExpectedBody, response)
+			}
+
+			s.TearDownTest()
+		})
+	}
+}
+
 func TestUserControllers(t *testing.T) {
 	suite.Run(t, new(TestSuiteUserControllers))
 }
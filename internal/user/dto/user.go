@@ -1,10 +1,15 @@
 package dto
 
-import "github.com/suryaadi44/eAD-System/pkg/entity"
+import (
+	"time"
+
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+)
 
 type UserSignUpRequest struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
+	Email    string `json:"email" validate:"omitempty,email"`
 	NIP      string `json:"nip" validate:"omitempty,len=18"`
 	NIK      string `json:"nik" validate:"required,len=16"`
 	Name     string `json:"name" validate:"required"`
@@ -18,6 +23,7 @@ func (u *UserSignUpRequest) ToEntity() *entity.User {
 	return &entity.User{
 		Username: u.Username,
 		Password: u.Password,
+		Email:    u.Email,
 		NIP:      u.NIP,
 		NIK:      u.NIK,
 		Name:     u.Name,
@@ -28,14 +34,85 @@ func (u *UserSignUpRequest) ToEntity() *entity.User {
 	}
 }
 
+// EmployeeCreateRequest is the account of the employee created by the admin, unlike the applicant its role is chosen
+type EmployeeCreateRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+	Role     int    `json:"role" validate:"required,min=1"`
+	Email    string `json:"email" validate:"omitempty,email"`
+	NIP      string `json:"nip" validate:"required,len=18"`
+	NIK      string `json:"nik" validate:"required,len=16"`
+	Name     string `json:"name" validate:"required"`
+	Position string `json:"position" validate:"required"`
+	Telp     string `json:"telp" validate:"required"`
+	Sex      string `json:"sex" validate:"required"`
+	Address  string `json:"address" validate:"required"`
+}
+
+func (e *EmployeeCreateRequest) ToEntity() *entity.User {
+	return &entity.User{
+		Username: e.Username,
+		Password: e.Password,
+		Role:     e.Role,
+		Email:    e.Email,
+		NIP:      e.NIP,
+		NIK:      e.NIK,
+		Name:     e.Name,
+		Position: e.Position,
+		Telp:     e.Telp,
+		Sex:      e.Sex,
+		Address:  e.Address,
+	}
+}
+
+// AdminCreateRequest is the initial admin created on start or from the command line
+type AdminCreateRequest struct {
+	Username string `validate:"required"`
+	Password string `validate:"required"`
+}
+
+type UserRoleRequest struct {
+	Role int `json:"role" validate:"required,min=1"`
+}
+
+type UserPasswordRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type UserChangePasswordRequest struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,nefield=OldPassword"`
+}
+
+type ForgotPasswordRequest struct {
+	Username string `json:"username" validate:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
 type UserLoginRequest struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TokenResponse is the short lived access token and the refresh token used to get the next one
+type TokenResponse struct {
+	Token              string `json:"token"`
+	RefreshToken       string `json:"refresh_token"`
+	MustChangePassword bool   `json:"must_change_password"`
+}
+
 type UserUpdateRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Email    string `json:"email" validate:"omitempty,email"`
 	NIP      string `json:"nip" validate:"omitempty,len=18"`
 	NIK      string `json:"nik" validate:"omitempty,len=16"`
 	Name     string `json:"name"`
@@ -49,6 +126,7 @@ func (u *UserUpdateRequest) ToEntity() *entity.User {
 	return &entity.User{
 		Username: u.Username,
 		Password: u.Password,
+		Email:    u.Email,
 		NIP:      u.NIP,
 		NIK:      u.NIK,
 		Name:     u.Name,
@@ -59,6 +137,47 @@ func (u *UserUpdateRequest) ToEntity() *entity.User {
 	}
 }
 
+// UserResponse is the full profile of the user shown to the admin
+type UserResponse struct {
+	ID                 string    `json:"id"`
+	Username           string    `json:"username"`
+	Email              string    `json:"email"`
+	Role               int       `json:"role"`
+	NIP                string    `json:"nip"`
+	NIK                string    `json:"nik"`
+	Name               string    `json:"name"`
+	Position           string    `json:"position"`
+	Telp               string    `json:"telp"`
+	Sex                string    `json:"sex"`
+	Address            string    `json:"address"`
+	Active             bool      `json:"active"`
+	MustChangePassword bool      `json:"must_change_password"`
+	DeactivatedAt      time.Time `json:"deactivated_at"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func NewUserResponse(user *entity.User) *UserResponse {
+	return &UserResponse{
+		ID:                 user.ID,
+		Username:           user.Username,
+		Email:              user.Email,
+		Role:               user.Role,
+		NIP:                user.NIP,
+		NIK:                user.NIK,
+		Name:               user.Name,
+		Position:           user.Position,
+		Telp:               user.Telp,
+		Sex:                user.Sex,
+		Address:            user.Address,
+		Active:             user.DeactivatedAt.IsZero(),
+		MustChangePassword: user.MustChangePassword,
+		DeactivatedAt:      user.DeactivatedAt,
+		CreatedAt:          user.CreatedAt,
+		UpdatedAt:          user.UpdatedAt,
+	}
+}
+
 type ApplicantResponse struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
@@ -114,3 +233,20 @@ func NewBriefUsersResponse(users *entity.Users) *BriefUsersResponse {
 	}
 	return &briefUsersResponse
 }
+
+// SignatureImageRequest is the size and offset of the uploaded signature or stamp image in millimeter, zero height
+// use the default size. The image itself is sent as the image form file
+type SignatureImageRequest struct {
+	Height  uint `form:"height" validate:"lte=100"`
+	OffsetX int  `form:"offset_x" validate:"gte=-100,lte=100"`
+	OffsetY int  `form:"offset_y" validate:"gte=-100,lte=100"`
+}
+
+func (s *SignatureImageRequest) ToEntity(imageKey string) *entity.SignatureImage {
+	return &entity.SignatureImage{
+		ImageKey: imageKey,
+		Height:   s.Height,
+		OffsetX:  s.OffsetX,
+		OffsetY:  s.OffsetY,
+	}
+}
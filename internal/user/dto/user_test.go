@@ -18,6 +18,7 @@ func TestUserSignUpRequest_ToEntity(t *testing.T) {
 			u: &UserSignUpRequest{
 				Username: "username",
 				Password: "password",
+				Email:    "user@example.com",
 				NIP:      "nip",
 				NIK:      "nik",
 				Name:     "name",
@@ -28,6 +29,7 @@ func TestUserSignUpRequest_ToEntity(t *testing.T) {
 			want: &entity.User{
 				Username: "username",
 				Password: "password",
+				Email:    "user@example.com",
 				NIP:      "nip",
 				NIK:      "nik",
 				Name:     "name",
@@ -61,3 +63,53 @@ func TestUserSignUpRequest_ToEntity(t *testing.T) {
 		})
 	}
 }
+
+func TestEmployeeCreateRequest_ToEntity(t *testing.T) {
+	tests := []struct {
+		name string
+		e    *EmployeeCreateRequest
+		want *entity.User
+	}{
+		{
+			name: "All field filled",
+			e: &EmployeeCreateRequest{
+				Username: "username",
+				Password: "password",
+				Role:     2,
+				Email:    "user@example.com",
+				NIP:      "nip",
+				NIK:      "nik",
+				Name:     "name",
+				Position: "position",
+				Telp:     "telp",
+				Sex:      "L",
+				Address:  "address",
+			},
+			want: &entity.User{
+				Username: "username",
+				Password: "password",
+				Role:     2,
+				Email:    "user@example.com",
+				NIP:      "nip",
+				NIK:      "nik",
+				Name:     "name",
+				Position: "position",
+				Telp:     "telp",
+				Sex:      "L",
+				Address:  "address",
+			},
+		},
+		{
+			name: "All field empty",
+			e:    &EmployeeCreateRequest{},
+			want: &entity.User{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.ToEntity(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EmployeeCreateRequest.ToEntity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
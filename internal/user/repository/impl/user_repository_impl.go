@@ -7,7 +7,9 @@ import (
 	"github.com/suryaadi44/eAD-System/pkg/entity"
 	"github.com/suryaadi44/eAD-System/pkg/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"strings"
+	"time"
 )
 
 type UserRepositoryImpl struct {
@@ -19,7 +21,12 @@ func NewUserRepositoryImpl(db *gorm.DB) repository.UserRepository {
 		db: db,
 	}
 
-	err := userRepository.InitDefaultUser()
+	err := userRepository.InitDefaultRole()
+	if err != nil {
+		panic(err)
+	}
+
+	err = userRepository.RemoveLegacyDefaultUser()
 	if err != nil {
 		panic(err)
 	}
@@ -27,25 +34,58 @@ func NewUserRepositoryImpl(db *gorm.DB) repository.UserRepository {
 	return userRepository
 }
 
-func (u *UserRepositoryImpl) InitDefaultUser() error {
-	var count int64
-	err := u.db.Model(&entity.User{}).Count(&count).Error
-	if err != nil {
-		return err
-	}
+// rolePermission is the row of the role and permission join table
+type rolePermission struct {
+	RoleID       int  `gorm:"primaryKey"`
+	PermissionID uint `gorm:"primaryKey"`
+}
 
-	if count != 0 {
-		return nil
-	}
+func (rolePermission) TableName() string {
+	return "role_permissions"
+}
 
-	err = u.db.Create(config.DefaultUser).Error
-	if err != nil {
-		return err
+// InitDefaultRole create the missing default role and grant it every default permission on each start, so permission
+// added by a later release reach the existing database. The existing role and permission granted by hand is kept
+func (u *UserRepositoryImpl) InitDefaultRole() error {
+	for idx, defaultRole := range config.DefaultRoles {
+		role := entity.Role{
+			ID:   idx + 1,
+			Name: defaultRole.Name,
+		}
+		err := u.db.Clauses(clause.OnConflict{DoNothing: true}).Omit("Permissions").Create(&role).Error
+		if err != nil {
+			return err
+		}
+
+		// the permission is shared between role, so it is only created once
+		for _, defaultPermission := range defaultRole.Permissions {
+			var permission entity.Permission
+			err = u.db.Where(entity.Permission{Name: defaultPermission.Name}).FirstOrCreate(&permission).Error
+			if err != nil {
+				return err
+			}
+
+			err = u.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rolePermission{
+				RoleID:       role.ID,
+				PermissionID: permission.ID,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
+// RemoveLegacyDefaultUser delete the admin seeded with unhashed password by the older version, the password is not a
+// valid hash so it never log in. It is deleted permanently so the username can be used by the initial admin
+func (u *UserRepositoryImpl) RemoveLegacyDefaultUser() error {
+	return u.db.Unscoped().
+		Where("username = ? AND password = ?", config.LegacyDefaultUsername, config.LegacyDefaultPassword).
+		Delete(&entity.User{}).Error
+}
+
 func (u *UserRepositoryImpl) CreateUser(ctx context.Context, user *entity.User) error {
 	err := u.db.WithContext(ctx).Create(user).Error
 	if err != nil {
@@ -68,7 +108,7 @@ func (u *UserRepositoryImpl) CreateUser(ctx context.Context, user *entity.User)
 
 func (u *UserRepositoryImpl) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
 	var user entity.User
-	err := u.db.WithContext(ctx).Select([]string{"id", "username", "password", "role"}).Where("username = ?", username).First(&user).Error
+	err := u.db.WithContext(ctx).Select([]string{"id", "username", "email", "password", "role", "deactivated_at", "must_change_password"}).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, utils.ErrUserNotFound
@@ -80,6 +120,74 @@ func (u *UserRepositoryImpl) FindByUsername(ctx context.Context, username string
 	return &user, nil
 }
 
+// GetRolePermissions find the name of every permission given to the role
+func (u *UserRepositoryImpl) GetRolePermissions(ctx context.Context, roleID int) ([]string, error) {
+	var permissions []string
+	err := u.db.WithContext(ctx).
+		Model(&entity.Permission{}).
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Where("role_permissions.role_id = ?", roleID).
+		Order("permissions.name").
+		Pluck("permissions.name", &permissions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (u *UserRepositoryImpl) GetRole(ctx context.Context, roleID int) (*entity.Role, error) {
+	var role entity.Role
+	err := u.db.WithContext(ctx).Where("id = ?", roleID).First(&role).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.ErrRoleNotFound
+		}
+
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (u *UserRepositoryImpl) FindByID(ctx context.Context, id string) (*entity.User, error) {
+	var user entity.User
+	err := u.db.WithContext(ctx).Omit("password").Where("id = ?", id).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (u *UserRepositoryImpl) CountUsersByRole(ctx context.Context, roleID int) (int64, error) {
+	var count int64
+	err := u.db.WithContext(ctx).Model(&entity.User{}).Where("role = ?", roleID).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (u *UserRepositoryImpl) GetPasswordHash(ctx context.Context, userID string) (string, error) {
+	var user entity.User
+	err := u.db.WithContext(ctx).Select("password").Where("id = ?", userID).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", utils.ErrUserNotFound
+		}
+
+		return "", err
+	}
+
+	return user.Password, nil
+}
+
 func (u *UserRepositoryImpl) GetBriefUsers(ctx context.Context, limit int, offset int) (*entity.Users, error) {
 	var users entity.Users
 	err := u.db.WithContext(ctx).
@@ -123,3 +231,202 @@ func (u *UserRepositoryImpl) UpdateUser(ctx context.Context, user *entity.User)
 
 	return nil
 }
+
+// UpdatePassword replace the password hash, the flag is always written so it can be cleared. Every unused password
+// reset token of the user is marked used in the same transaction, so the link sent before the change can't replace
+// the new password
+func (u *UserRepositoryImpl) UpdatePassword(ctx context.Context, userID string, hashedPassword string, mustChangePassword bool, changedAt time.Time) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"password":             hashedPassword,
+			"must_change_password": mustChangePassword,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return utils.ErrUserNotFound
+		}
+
+		return tx.Model(&entity.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", userID).
+			Update("used_at", changedAt).Error
+	})
+}
+
+// UpdateSignature replace the signature image of the user, every column is written so zero offset is saved too
+func (u *UserRepositoryImpl) UpdateSignature(ctx context.Context, userID string, signature *entity.SignatureImage) error {
+	return u.updateSignatureImage(ctx, userID, "signature_", signature)
+}
+
+// UpdateStamp replace the stamp image of the user, every column is written so zero offset is saved too
+func (u *UserRepositoryImpl) UpdateStamp(ctx context.Context, userID string, stamp *entity.SignatureImage) error {
+	return u.updateSignatureImage(ctx, userID, "stamp_", stamp)
+}
+
+func (u *UserRepositoryImpl) updateSignatureImage(ctx context.Context, userID string, prefix string, image *entity.SignatureImage) error {
+	result := u.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		prefix + "image_key": image.ImageKey,
+		prefix + "height":    image.Height,
+		prefix + "offset_x":  image.OffsetX,
+		prefix + "offset_y":  image.OffsetY,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return utils.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// DeactivateUser mark the user as deactivated, the user can't log in until reactivated
+func (u *UserRepositoryImpl) DeactivateUser(ctx context.Context, userID string, deactivatedAt time.Time) error {
+	return u.updateDeactivatedAt(ctx, userID, deactivatedAt)
+}
+
+// ReactivateUser clear the deactivation mark of the user
+func (u *UserRepositoryImpl) ReactivateUser(ctx context.Context, userID string) error {
+	return u.updateDeactivatedAt(ctx, userID, nil)
+}
+
+func (u *UserRepositoryImpl) updateDeactivatedAt(ctx context.Context, userID string, deactivatedAt interface{}) error {
+	result := u.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", userID).Update("deactivated_at", deactivatedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return utils.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (u *UserRepositoryImpl) CreateRefreshToken(ctx context.Context, token *entity.RefreshToken) error {
+	return u.db.WithContext(ctx).Create(token).Error
+}
+
+func (u *UserRepositoryImpl) FindRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	err := u.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.ErrRefreshTokenNotFound
+		}
+
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// RevokeRefreshToken revoke the token only once, so the same refresh token can't be rotated twice by concurrent request
+func (u *UserRepositoryImpl) RevokeRefreshToken(ctx context.Context, tokenID string, revokedAt time.Time) error {
+	result := u.db.WithContext(ctx).
+		Model(&entity.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", tokenID).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return utils.ErrRefreshTokenNotFound
+	}
+
+	return nil
+}
+
+// RevokeUserTokens revoke every refresh token of the user and reject the access token issued before the revocation. The
+// revocation time is truncated to the millisecond of the token issue time, so the database never round it up over the
+// token issued right after it
+func (u *UserRepositoryImpl) RevokeUserTokens(ctx context.Context, userID string, revokedAt time.Time) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.User{}).Where("id = ?", userID).Update("tokens_valid_at", revokedAt.Truncate(time.Millisecond))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return utils.ErrUserNotFound
+		}
+
+		return tx.Model(&entity.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", revokedAt).Error
+	})
+}
+
+func (u *UserRepositoryImpl) CreatePasswordResetToken(ctx context.Context, token *entity.PasswordResetToken) error {
+	return u.db.WithContext(ctx).Create(token).Error
+}
+
+// CountPasswordResetTokens count the reset token created for the user since the given time
+func (u *UserRepositoryImpl) CountPasswordResetTokens(ctx context.Context, userID string, since time.Time) (int64, error) {
+	var count int64
+	err := u.db.WithContext(ctx).
+		Model(&entity.PasswordResetToken{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (u *UserRepositoryImpl) FindPasswordResetToken(ctx context.Context, tokenHash string) (*entity.PasswordResetToken, error) {
+	var token entity.PasswordResetToken
+	err := u.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, utils.ErrPasswordResetTokenNotFound
+		}
+
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// UsePasswordResetToken mark the token as used only once, so the same token can't reset the password twice by
+// concurrent request
+func (u *UserRepositoryImpl) UsePasswordResetToken(ctx context.Context, tokenID string, usedAt time.Time) error {
+	result := u.db.WithContext(ctx).
+		Model(&entity.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", tokenID).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return utils.ErrPasswordResetTokenNotFound
+	}
+
+	return nil
+}
+
+func (u *UserRepositoryImpl) AddPasswordHistory(ctx context.Context, history *entity.PasswordHistory) error {
+	return u.db.WithContext(ctx).Create(history).Error
+}
+
+// GetPasswordHistory find the hash of the last password of the user, the newest first
+func (u *UserRepositoryImpl) GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error) {
+	var passwords []string
+	err := u.db.WithContext(ctx).
+		Model(&entity.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(limit).
+		Pluck("password", &passwords).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return passwords, nil
+}
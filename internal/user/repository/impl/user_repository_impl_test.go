@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/suite"
+	"github.com/suryaadi44/eAD-System/pkg/config"
 	"github.com/suryaadi44/eAD-System/pkg/entity"
 	"github.com/suryaadi44/eAD-System/pkg/utils"
 	"gorm.io/driver/mysql"
@@ -41,7 +42,7 @@ func (s *TestSuiteUserRepository) TeardownTest() {
 }
 
 func (s *TestSuiteUserRepository) TestCreateUser() {
-	query := regexp.QuoteMeta("INSERT INTO `users` (`id`,`n_ip`,`nik`,`username`,`password`,`role`,`position`,`name`,`telp`,`sex`,`address`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?)")
+	query := regexp.QuoteMeta("INSERT INTO `users` (`id`,`n_ip`,`nik`,`username`,`email`,`password`,`role`,`position`,`name`,`telp`,`sex`,`address`,`signature_image_key`,`signature_height`,`signature_offset_x`,`signature_offset_y`,`stamp_image_key`,`stamp_height`,`stamp_offset_x`,`stamp_offset_y`,`must_change_password`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)")
 	for _, tc := range []struct {
 		Name        string
 		Err         error
@@ -90,7 +91,7 @@ func (s *TestSuiteUserRepository) TestCreateUser() {
 }
 
 func (s *TestSuiteUserRepository) TestFindByUsername() {
-	query := regexp.QuoteMeta("SELECT `id`,`username`,`password`,`role` FROM `users` WHERE username = ? AND `users`.`deleted_at` IS NULL ORDER BY `users`.`id` LIMIT 1")
+	query := regexp.QuoteMeta("SELECT `id`,`username`,`email`,`password`,`role`,`deactivated_at`,`must_change_password` FROM `users` WHERE username = ? AND `users`.`deleted_at` IS NULL ORDER BY `users`.`id` LIMIT 1")
 	for _, tc := range []struct {
 		Name        string
 		Err         error
@@ -128,6 +129,300 @@ func (s *TestSuiteUserRepository) TestFindByUsername() {
 	}
 }
 
+func (s *TestSuiteUserRepository) TestGetRolePermissions() {
+	query := regexp.QuoteMeta("SELECT `permissions`.`name` FROM `permissions` JOIN role_permissions ON role_permissions.permission_id = permissions.id WHERE role_permissions.role_id = ? ORDER BY permissions.name")
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn []string
+	}{
+		{
+			Name:           "Success",
+			ExpectedReturn: []string{"document.read_all", "document.verify"},
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WithArgs(2).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("document.read_all").AddRow("document.verify"))
+			}
+
+			result, err := s.userRepository.GetRolePermissions(context.Background(), 2)
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, result)
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestGetRole() {
+	query := regexp.QuoteMeta("SELECT * FROM `roles` WHERE id = ? ORDER BY `roles`.`id` LIMIT 1")
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn *entity.Role
+	}{
+		{
+			Name:           "Success",
+			ExpectedReturn: &entity.Role{ID: 2, Name: "Verifier"},
+		},
+		{
+			Name:        "Error no record found",
+			Err:         gorm.ErrRecordNotFound,
+			ExpectedErr: utils.ErrRoleNotFound,
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WithArgs(2).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "Verifier"))
+			}
+
+			result, err := s.userRepository.GetRole(context.Background(), 2)
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, result)
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestInitDefaultRole() {
+	defaultRoles := config.DefaultRoles
+	config.DefaultRoles = []entity.Role{
+		{
+			Name:        "Applicant",
+			Permissions: []entity.Permission{{Name: "document.create"}},
+		},
+	}
+	defer func() {
+		config.DefaultRoles = defaultRoles
+	}()
+
+	for _, tc := range []struct {
+		Name        string
+		RoleErr     error
+		GrantErr    error
+		ExpectedErr error
+	}{
+		{
+			Name: "Success",
+		},
+		{
+			Name:        "Error creating role",
+			RoleErr:     errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+		{
+			Name:        "Error granting permission",
+			GrantErr:    errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			roleExpectation := s.mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `roles` (`name`,`id`) VALUES (?,?) ON DUPLICATE KEY UPDATE `id`=`id`")).
+				WithArgs("Applicant", 1)
+			if tc.RoleErr != nil {
+				roleExpectation.WillReturnError(tc.RoleErr)
+			} else {
+				roleExpectation.WillReturnResult(sqlmock.NewResult(0, 0))
+
+				s.mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `permissions` WHERE `permissions`.`name` = ? ORDER BY `permissions`.`id` LIMIT 1")).
+					WithArgs("document.create").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(4, "document.create"))
+
+				grantExpectation := s.mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `role_permissions` (`role_id`,`permission_id`) VALUES (?,?) ON DUPLICATE KEY UPDATE `role_id`=`role_id`")).
+					WithArgs(1, 4)
+				if tc.GrantErr != nil {
+					grantExpectation.WillReturnError(tc.GrantErr)
+				} else {
+					grantExpectation.WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+			}
+
+			err := s.userRepository.InitDefaultRole()
+
+			s.Equal(tc.ExpectedErr, err)
+			s.NoError(s.mock.ExpectationsWereMet())
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestRemoveLegacyDefaultUser() {
+	query := regexp.QuoteMeta("DELETE FROM `users` WHERE username = ? AND password = ?")
+	for _, tc := range []struct {
+		Name        string
+		Err         error
+		ExpectedErr error
+	}{
+		{
+			Name: "Success",
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			expectation := s.mock.ExpectExec(query).WithArgs("admin", "admin")
+			if tc.Err != nil {
+				expectation.WillReturnError(tc.Err)
+			} else {
+				expectation.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			err := s.userRepository.RemoveLegacyDefaultUser()
+
+			s.Equal(tc.ExpectedErr, err)
+			s.NoError(s.mock.ExpectationsWereMet())
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestCountUsersByRole() {
+	query := regexp.QuoteMeta("SELECT count(*) FROM `users` WHERE role = ? AND `users`.`deleted_at` IS NULL")
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn int64
+	}{
+		{
+			Name:           "Success",
+			ExpectedReturn: 2,
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WithArgs(3).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(2))
+			}
+
+			result, err := s.userRepository.CountUsersByRole(context.Background(), 3)
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, result)
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestGetPasswordHash() {
+	query := regexp.QuoteMeta("SELECT `password` FROM `users` WHERE id = ? AND `users`.`deleted_at` IS NULL ORDER BY `users`.`id` LIMIT 1")
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn string
+	}{
+		{
+			Name:           "Success",
+			ExpectedReturn: "hashedPassword",
+		},
+		{
+			Name:        "Error no record found",
+			Err:         gorm.ErrRecordNotFound,
+			ExpectedErr: utils.ErrUserNotFound,
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WithArgs("1").WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).WithArgs("1").WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow("hashedPassword"))
+			}
+
+			result, err := s.userRepository.GetPasswordHash(context.Background(), "1")
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, result)
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestFindByID() {
+	query := regexp.QuoteMeta("SELECT `users`.`id`,`users`.`n_ip`,`users`.`nik`,`users`.`username`,`users`.`email`,`users`.`role`,`users`.`position`,`users`.`name`,`users`.`telp`,`users`.`sex`,`users`.`address`,`users`.`signature_image_key`,`users`.`signature_height`,`users`.`signature_offset_x`,`users`.`signature_offset_y`,`users`.`stamp_image_key`,`users`.`stamp_height`,`users`.`stamp_offset_x`,`users`.`stamp_offset_y`,`users`.`deactivated_at`,`users`.`tokens_valid_at`,`users`.`must_change_password`,`users`.`created_at`,`users`.`updated_at`,`users`.`deleted_at` FROM `users` WHERE id = ? AND `users`.`deleted_at` IS NULL ORDER BY `users`.`id` LIMIT 1")
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn *entity.User
+	}{
+		{
+			Name:        "Success",
+			Err:         nil,
+			ExpectedErr: nil,
+			ExpectedReturn: &entity.User{
+				ID:      "1",
+				NIK:     "123",
+				Name:    "name",
+				Address: "address",
+			},
+		},
+		{
+			Name:        "Error no record found",
+			Err:         gorm.ErrRecordNotFound,
+			ExpectedErr: utils.ErrUserNotFound,
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id", "nik", "name", "address"}).AddRow("1", "123", "name", "address"))
+			}
+
+			result, err := s.userRepository.FindByID(context.Background(), "1")
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, result)
+		})
+		s.TeardownTest()
+	}
+}
+
 func (s *TestSuiteUserRepository) TestGetBriefUsers() {
 	query := regexp.QuoteMeta("SELECT `id`,`username`,`name` FROM `users` WHERE `users`.`deleted_at` IS NULL ORDER BY created_at DESC LIMIT 0")
 	for _, tc := range []struct {
@@ -258,6 +553,586 @@ func (s *TestSuiteUserRepository) TestUpdateUser() {
 	}
 }
 
+func (s *TestSuiteUserRepository) TestUpdatePassword() {
+	userQuery := regexp.QuoteMeta("UPDATE `users` SET `must_change_password`=?,`password`=?,`updated_at`=? WHERE id = ? AND `users`.`deleted_at` IS NULL")
+	tokenQuery := regexp.QuoteMeta("UPDATE `password_reset_tokens` SET `used_at`=? WHERE user_id = ? AND used_at IS NULL")
+	changedAt := time.Now()
+	for _, tc := range []struct {
+		Name         string
+		UserErr      error
+		TokenErr     error
+		ExpectedErr  error
+		RowsAffected int64
+	}{
+		{
+			Name:         "Success",
+			RowsAffected: 1,
+		},
+		{
+			Name:         "Error no record found",
+			ExpectedErr:  utils.ErrUserNotFound,
+			RowsAffected: 0,
+		},
+		{
+			Name:        "Generic error",
+			UserErr:     errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+		{
+			Name:         "Error invalidating reset token",
+			RowsAffected: 1,
+			TokenErr:     errors.New("Generic error"),
+			ExpectedErr:  errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			s.mock.ExpectBegin()
+			userExpectation := s.mock.ExpectExec(userQuery).WithArgs(false, "hashedPassword", sqlmock.AnyArg(), "1")
+			if tc.UserErr != nil {
+				userExpectation.WillReturnError(tc.UserErr)
+			} else {
+				userExpectation.WillReturnResult(sqlmock.NewResult(1, tc.RowsAffected))
+			}
+
+			if tc.UserErr == nil && tc.RowsAffected != 0 {
+				tokenExpectation := s.mock.ExpectExec(tokenQuery).WithArgs(changedAt, "1")
+				if tc.TokenErr != nil {
+					tokenExpectation.WillReturnError(tc.TokenErr)
+				} else {
+					tokenExpectation.WillReturnResult(sqlmock.NewResult(1, 2))
+				}
+			}
+
+			if tc.ExpectedErr != nil {
+				s.mock.ExpectRollback()
+			} else {
+				s.mock.ExpectCommit()
+			}
+
+			err := s.userRepository.UpdatePassword(context.Background(), "1", "hashedPassword", false, changedAt)
+
+			s.Equal(tc.ExpectedErr, err)
+			s.NoError(s.mock.ExpectationsWereMet())
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestUpdateSignatureImage() {
+	for _, tc := range []struct {
+		Name         string
+		Query        string
+		Update       func(ctx context.Context, userID string, image *entity.SignatureImage) error
+		Err          error
+		ExpectedErr  error
+		RowsAffected int64
+	}{
+		{
+			Name:         "Success signature",
+			Query:        "UPDATE `users` SET `signature_height`=?,`signature_image_key`=?,`signature_offset_x`=?,`signature_offset_y`=?,`updated_at`=? WHERE id = ? AND `users`.`deleted_at` IS NULL",
+			Update:       s.userRepository.UpdateSignature,
+			RowsAffected: 1,
+		},
+		{
+			Name:         "Success stamp",
+			Query:        "UPDATE `users` SET `stamp_height`=?,`stamp_image_key`=?,`stamp_offset_x`=?,`stamp_offset_y`=?,`updated_at`=? WHERE id = ? AND `users`.`deleted_at` IS NULL",
+			Update:       s.userRepository.UpdateStamp,
+			RowsAffected: 1,
+		},
+		{
+			Name:         "Error no record found",
+			Query:        "UPDATE `users` SET `signature_height`=?,`signature_image_key`=?,`signature_offset_x`=?,`signature_offset_y`=?,`updated_at`=? WHERE id = ? AND `users`.`deleted_at` IS NULL",
+			Update:       s.userRepository.UpdateSignature,
+			ExpectedErr:  utils.ErrUserNotFound,
+			RowsAffected: 0,
+		},
+		{
+			Name:        "Generic error",
+			Query:       "UPDATE `users` SET `stamp_height`=?,`stamp_image_key`=?,`stamp_offset_x`=?,`stamp_offset_y`=?,`updated_at`=? WHERE id = ? AND `users`.`deleted_at` IS NULL",
+			Update:      s.userRepository.UpdateStamp,
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			image := &entity.SignatureImage{ImageKey: "images/signature/1", Height: 20, OffsetX: -5}
+			expectation := s.mock.ExpectExec(regexp.QuoteMeta(tc.Query)).
+				WithArgs(image.Height, image.ImageKey, image.OffsetX, image.OffsetY, sqlmock.AnyArg(), "1")
+			if tc.Err != nil {
+				expectation.WillReturnError(tc.Err)
+			} else {
+				expectation.WillReturnResult(sqlmock.NewResult(1, tc.RowsAffected))
+			}
+
+			err := tc.Update(context.Background(), "1", image)
+
+			s.Equal(tc.ExpectedErr, err)
+			s.NoError(s.mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func (s *TestSuiteUserRepository) TestUpdateDeactivatedAt() {
+	deactivatedAt := time.Now()
+	query := "UPDATE `users` SET `deactivated_at`=?,`updated_at`=? WHERE id = ? AND `users`.`deleted_at` IS NULL"
+	for _, tc := range []struct {
+		Name         string
+		Update       func(ctx context.Context, userID string) error
+		Arg          interface{}
+		Err          error
+		ExpectedErr  error
+		RowsAffected int64
+	}{
+		{
+			Name: "Success deactivate",
+			Update: func(ctx context.Context, userID string) error {
+				return s.userRepository.DeactivateUser(ctx, userID, deactivatedAt)
+			},
+			Arg:          deactivatedAt,
+			RowsAffected: 1,
+		},
+		{
+			Name:         "Success reactivate",
+			Update:       s.userRepository.ReactivateUser,
+			Arg:          nil,
+			RowsAffected: 1,
+		},
+		{
+			Name:         "Error no record found",
+			Update:       s.userRepository.ReactivateUser,
+			Arg:          nil,
+			ExpectedErr:  utils.ErrUserNotFound,
+			RowsAffected: 0,
+		},
+		{
+			Name: "Generic error",
+			Update: func(ctx context.Context, userID string) error {
+				return s.userRepository.DeactivateUser(ctx, userID, deactivatedAt)
+			},
+			Arg:         deactivatedAt,
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			expectation := s.mock.ExpectExec(regexp.QuoteMeta(query)).
+				WithArgs(tc.Arg, sqlmock.AnyArg(), "1")
+			if tc.Err != nil {
+				expectation.WillReturnError(tc.Err)
+			} else {
+				expectation.WillReturnResult(sqlmock.NewResult(1, tc.RowsAffected))
+			}
+
+			err := tc.Update(context.Background(), "1")
+
+			s.Equal(tc.ExpectedErr, err)
+			s.NoError(s.mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func (s *TestSuiteUserRepository) TestCreateRefreshToken() {
+	query := regexp.QuoteMeta("INSERT INTO `refresh_tokens` (`id`,`user_id`,`token_hash`,`expires_at`,`created_at`) VALUES (?,?,?,?,?)")
+	for _, tc := range []struct {
+		Name        string
+		Err         error
+		ExpectedErr error
+	}{
+		{
+			Name: "Success",
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectExec(query).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			err := s.userRepository.CreateRefreshToken(context.Background(), &entity.RefreshToken{ID: "1", UserID: "1", TokenHash: "hash"})
+
+			s.Equal(tc.ExpectedErr, err)
+			s.NoError(s.mock.ExpectationsWereMet())
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestFindRefreshToken() {
+	query := regexp.QuoteMeta("SELECT * FROM `refresh_tokens` WHERE token_hash = ? ORDER BY `refresh_tokens`.`id` LIMIT 1")
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn *entity.RefreshToken
+	}{
+		{
+			Name:           "Success",
+			ExpectedReturn: &entity.RefreshToken{ID: "1", UserID: "2", TokenHash: "hash"},
+		},
+		{
+			Name:        "Error no record found",
+			Err:         gorm.ErrRecordNotFound,
+			ExpectedErr: utils.ErrRefreshTokenNotFound,
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WithArgs("hash").WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).WithArgs("hash").WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash"}).AddRow("1", "2", "hash"))
+			}
+
+			result, err := s.userRepository.FindRefreshToken(context.Background(), "hash")
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, result)
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestRevokeRefreshToken() {
+	query := regexp.QuoteMeta("UPDATE `refresh_tokens` SET `revoked_at`=? WHERE id = ? AND revoked_at IS NULL")
+	revokedAt := time.Now()
+	for _, tc := range []struct {
+		Name         string
+		Err          error
+		ExpectedErr  error
+		RowsAffected int64
+	}{
+		{
+			Name:         "Success",
+			RowsAffected: 1,
+		},
+		{
+			Name:         "Error already revoked",
+			ExpectedErr:  utils.ErrRefreshTokenNotFound,
+			RowsAffected: 0,
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			expectation := s.mock.ExpectExec(query).WithArgs(revokedAt, "1")
+			if tc.Err != nil {
+				expectation.WillReturnError(tc.Err)
+			} else {
+				expectation.WillReturnResult(sqlmock.NewResult(1, tc.RowsAffected))
+			}
+
+			err := s.userRepository.RevokeRefreshToken(context.Background(), "1", revokedAt)
+
+			s.Equal(tc.ExpectedErr, err)
+			s.NoError(s.mock.ExpectationsWereMet())
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestRevokeUserTokens() {
+	userQuery := regexp.QuoteMeta("UPDATE `users` SET `tokens_valid_at`=?,`updated_at`=? WHERE id = ? AND `users`.`deleted_at` IS NULL")
+	tokenQuery := regexp.QuoteMeta("UPDATE `refresh_tokens` SET `revoked_at`=? WHERE user_id = ? AND revoked_at IS NULL")
+	revokedAt := time.Now()
+	for _, tc := range []struct {
+		Name         string
+		UserErr      error
+		TokenErr     error
+		RowsAffected int64
+		ExpectedErr  error
+	}{
+		{
+			Name:         "Success",
+			RowsAffected: 1,
+		},
+		{
+			Name:         "Error no record found",
+			RowsAffected: 0,
+			ExpectedErr:  utils.ErrUserNotFound,
+		},
+		{
+			Name:        "Error updating user",
+			UserErr:     errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+		{
+			Name:         "Error revoking refresh token",
+			RowsAffected: 1,
+			TokenErr:     errors.New("Generic error"),
+			ExpectedErr:  errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			s.mock.ExpectBegin()
+			userExpectation := s.mock.ExpectExec(userQuery).WithArgs(revokedAt.Truncate(time.Millisecond), sqlmock.AnyArg(), "1")
+			if tc.UserErr != nil {
+				userExpectation.WillReturnError(tc.UserErr)
+			} else {
+				userExpectation.WillReturnResult(sqlmock.NewResult(1, tc.RowsAffected))
+			}
+
+			if tc.UserErr == nil && tc.RowsAffected != 0 {
+				tokenExpectation := s.mock.ExpectExec(tokenQuery).WithArgs(revokedAt, "1")
+				if tc.TokenErr != nil {
+					tokenExpectation.WillReturnError(tc.TokenErr)
+				} else {
+					tokenExpectation.WillReturnResult(sqlmock.NewResult(1, 2))
+				}
+			}
+
+			if tc.ExpectedErr != nil {
+				s.mock.ExpectRollback()
+			} else {
+				s.mock.ExpectCommit()
+			}
+
+			err := s.userRepository.RevokeUserTokens(context.Background(), "1", revokedAt)
+
+			s.Equal(tc.ExpectedErr, err)
+			s.NoError(s.mock.ExpectationsWereMet())
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestCreatePasswordResetToken() {
+	query := regexp.QuoteMeta("INSERT INTO `password_reset_tokens` (`id`,`user_id`,`token_hash`,`expires_at`,`created_at`) VALUES (?,?,?,?,?)")
+	for _, tc := range []struct {
+		Name        string
+		Err         error
+		ExpectedErr error
+	}{
+		{
+			Name: "Success",
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectExec(query).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			err := s.userRepository.CreatePasswordResetToken(context.Background(), &entity.PasswordResetToken{ID: "1", UserID: "1", TokenHash: "hash"})
+
+			s.Equal(tc.ExpectedErr, err)
+			s.NoError(s.mock.ExpectationsWereMet())
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestCountPasswordResetTokens() {
+	query := regexp.QuoteMeta("SELECT count(*) FROM `password_reset_tokens` WHERE user_id = ? AND created_at >= ?")
+	since := time.Now()
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn int64
+	}{
+		{
+			Name:           "Success",
+			ExpectedReturn: 2,
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WithArgs("1", since).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).WithArgs("1", since).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(2))
+			}
+
+			count, err := s.userRepository.CountPasswordResetTokens(context.Background(), "1", since)
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, count)
+			s.NoError(s.mock.ExpectationsWereMet())
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestFindPasswordResetToken() {
+	query := regexp.QuoteMeta("SELECT * FROM `password_reset_tokens` WHERE token_hash = ? ORDER BY `password_reset_tokens`.`id` LIMIT 1")
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn *entity.PasswordResetToken
+	}{
+		{
+			Name:           "Success",
+			ExpectedReturn: &entity.PasswordResetToken{ID: "1", UserID: "2", TokenHash: "hash"},
+		},
+		{
+			Name:        "Error no record found",
+			Err:         gorm.ErrRecordNotFound,
+			ExpectedErr: utils.ErrPasswordResetTokenNotFound,
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WithArgs("hash").WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).WithArgs("hash").WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash"}).AddRow("1", "2", "hash"))
+			}
+
+			result, err := s.userRepository.FindPasswordResetToken(context.Background(), "hash")
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, result)
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestUsePasswordResetToken() {
+	query := regexp.QuoteMeta("UPDATE `password_reset_tokens` SET `used_at`=? WHERE id = ? AND used_at IS NULL")
+	usedAt := time.Now()
+	for _, tc := range []struct {
+		Name         string
+		Err          error
+		ExpectedErr  error
+		RowsAffected int64
+	}{
+		{
+			Name:         "Success",
+			RowsAffected: 1,
+		},
+		{
+			Name:         "Error already used",
+			ExpectedErr:  utils.ErrPasswordResetTokenNotFound,
+			RowsAffected: 0,
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			expectation := s.mock.ExpectExec(query).WithArgs(usedAt, "1")
+			if tc.Err != nil {
+				expectation.WillReturnError(tc.Err)
+			} else {
+				expectation.WillReturnResult(sqlmock.NewResult(1, tc.RowsAffected))
+			}
+
+			err := s.userRepository.UsePasswordResetToken(context.Background(), "1", usedAt)
+
+			s.Equal(tc.ExpectedErr, err)
+			s.NoError(s.mock.ExpectationsWereMet())
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestAddPasswordHistory() {
+	query := regexp.QuoteMeta("INSERT INTO `password_histories` (`user_id`,`password`,`created_at`) VALUES (?,?,?)")
+	for _, tc := range []struct {
+		Name        string
+		Err         error
+		ExpectedErr error
+	}{
+		{
+			Name: "Success",
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectExec(query).WithArgs("1", "hash", sqlmock.AnyArg()).WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectExec(query).WithArgs("1", "hash", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			err := s.userRepository.AddPasswordHistory(context.Background(), &entity.PasswordHistory{UserID: "1", Password: "hash"})
+
+			s.Equal(tc.ExpectedErr, err)
+			s.NoError(s.mock.ExpectationsWereMet())
+		})
+		s.TeardownTest()
+	}
+}
+
+func (s *TestSuiteUserRepository) TestGetPasswordHistory() {
+	query := regexp.QuoteMeta("SELECT `password` FROM `password_histories` WHERE user_id = ? ORDER BY id DESC LIMIT 3")
+	for _, tc := range []struct {
+		Name           string
+		Err            error
+		ExpectedErr    error
+		ExpectedReturn []string
+	}{
+		{
+			Name:           "Success",
+			ExpectedReturn: []string{"hash2", "hash1"},
+		},
+		{
+			Name:        "Generic error",
+			Err:         errors.New("Generic error"),
+			ExpectedErr: errors.New("Generic error"),
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			if tc.Err != nil {
+				s.mock.ExpectQuery(query).WithArgs("1").WillReturnError(tc.Err)
+			} else {
+				s.mock.ExpectQuery(query).WithArgs("1").WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow("hash2").AddRow("hash1"))
+			}
+
+			result, err := s.userRepository.GetPasswordHistory(context.Background(), "1", 3)
+
+			s.Equal(tc.ExpectedErr, err)
+			s.Equal(tc.ExpectedReturn, result)
+		})
+		s.TeardownTest()
+	}
+}
+
 func TestUserRepository(t *testing.T) {
 	suite.Run(t, new(TestSuiteUserRepository))
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/stretchr/testify/mock"
 	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"time"
 )
 
 type MockUserRepository struct {
@@ -20,6 +21,31 @@ func (m *MockUserRepository) FindByUsername(ctx context.Context, username string
 	return args.Get(0).(*entity.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetRolePermissions(ctx context.Context, roleID int) ([]string, error) {
+	args := m.Called(ctx, roleID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserRepository) GetRole(ctx context.Context, roleID int) (*entity.Role, error) {
+	args := m.Called(ctx, roleID)
+	return args.Get(0).(*entity.Role), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByID(ctx context.Context, id string) (*entity.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) CountUsersByRole(ctx context.Context, roleID int) (int64, error) {
+	args := m.Called(ctx, roleID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) GetPasswordHash(ctx context.Context, userID string) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockUserRepository) GetBriefUsers(ctx context.Context, limit int, offset int) (*entity.Users, error) {
 	args := m.Called(ctx, limit, offset)
 	return args.Get(0).(*entity.Users), args.Error(1)
@@ -29,3 +55,78 @@ func (m *MockUserRepository) UpdateUser(ctx context.Context, user *entity.User)
 	args := m.Called(ctx, user)
 	return args.Error(0)
 }
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID string, hashedPassword string, mustChangePassword bool, changedAt time.Time) error {
+	args := m.Called(ctx, userID, hashedPassword, mustChangePassword, changedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateSignature(ctx context.Context, userID string, signature *entity.SignatureImage) error {
+	args := m.Called(ctx, userID, signature)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateStamp(ctx context.Context, userID string, stamp *entity.SignatureImage) error {
+	args := m.Called(ctx, userID, stamp)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DeactivateUser(ctx context.Context, userID string, deactivatedAt time.Time) error {
+	args := m.Called(ctx, userID, deactivatedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ReactivateUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CreateRefreshToken(ctx context.Context, token *entity.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(*entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockUserRepository) RevokeRefreshToken(ctx context.Context, tokenID string, revokedAt time.Time) error {
+	args := m.Called(ctx, tokenID, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RevokeUserTokens(ctx context.Context, userID string, revokedAt time.Time) error {
+	args := m.Called(ctx, userID, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CreatePasswordResetToken(ctx context.Context, token *entity.PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CountPasswordResetTokens(ctx context.Context, userID string, since time.Time) (int64, error) {
+	args := m.Called(ctx, userID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) FindPasswordResetToken(ctx context.Context, tokenHash string) (*entity.PasswordResetToken, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(*entity.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockUserRepository) UsePasswordResetToken(ctx context.Context, tokenID string, usedAt time.Time) error {
+	args := m.Called(ctx, tokenID, usedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) AddPasswordHistory(ctx context.Context, history *entity.PasswordHistory) error {
+	args := m.Called(ctx, history)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error) {
+	args := m.Called(ctx, userID, limit)
+	return args.Get(0).([]string), args.Error(1)
+}
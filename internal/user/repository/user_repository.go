@@ -3,11 +3,32 @@ package repository
 import (
 	"context"
 	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"time"
 )
 
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *entity.User) error
 	FindByUsername(ctx context.Context, username string) (*entity.User, error)
+	GetRolePermissions(ctx context.Context, roleID int) ([]string, error)
+	GetRole(ctx context.Context, roleID int) (*entity.Role, error)
+	FindByID(ctx context.Context, id string) (*entity.User, error)
+	CountUsersByRole(ctx context.Context, roleID int) (int64, error)
+	GetPasswordHash(ctx context.Context, userID string) (string, error)
 	GetBriefUsers(ctx context.Context, limit int, offset int) (*entity.Users, error)
 	UpdateUser(ctx context.Context, user *entity.User) error
+	UpdatePassword(ctx context.Context, userID string, hashedPassword string, mustChangePassword bool, changedAt time.Time) error
+	UpdateSignature(ctx context.Context, userID string, signature *entity.SignatureImage) error
+	UpdateStamp(ctx context.Context, userID string, stamp *entity.SignatureImage) error
+	DeactivateUser(ctx context.Context, userID string, deactivatedAt time.Time) error
+	ReactivateUser(ctx context.Context, userID string) error
+	CreateRefreshToken(ctx context.Context, token *entity.RefreshToken) error
+	FindRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, tokenID string, revokedAt time.Time) error
+	RevokeUserTokens(ctx context.Context, userID string, revokedAt time.Time) error
+	CreatePasswordResetToken(ctx context.Context, token *entity.PasswordResetToken) error
+	CountPasswordResetTokens(ctx context.Context, userID string, since time.Time) (int64, error)
+	FindPasswordResetToken(ctx context.Context, tokenHash string) (*entity.PasswordResetToken, error)
+	UsePasswordResetToken(ctx context.Context, tokenID string, usedAt time.Time) error
+	AddPasswordHistory(ctx context.Context, history *entity.PasswordHistory) error
+	GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error)
 }
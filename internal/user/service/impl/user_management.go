@@ -0,0 +1,157 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/suryaadi44/eAD-System/internal/user/dto"
+	"github.com/suryaadi44/eAD-System/pkg/config"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+// CreateInitialAdmin create the first admin, it is refused when there is already an admin so the credential left in the
+// environment can't be used to create another one. The password must be changed on the first login
+func (u *UserServiceImpl) CreateInitialAdmin(ctx context.Context, request *dto.AdminCreateRequest) (string, error) {
+	count, err := u.userRepository.CountUsersByRole(ctx, config.DefaultAdminRole)
+	if err != nil {
+		return "", err
+	}
+
+	if count != 0 {
+		return "", utils.ErrAdminAlreadyExist
+	}
+
+	err = u.passwordPolicy.CheckLength(request.Password)
+	if err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := u.passwordHash.GenerateFromPassword([]byte(request.Password), 10)
+	if err != nil {
+		return "", err
+	}
+
+	userEntity := &entity.User{
+		ID:                 uuid.New().String(),
+		Username:           request.Username,
+		Password:           string(hashedPassword),
+		Role:               config.DefaultAdminRole,
+		MustChangePassword: true,
+	}
+
+	err = u.userRepository.CreateUser(ctx, userEntity)
+	if err == utils.ErrUsernameAlreadyExist {
+		// another instance started at the same time may have created the admin after it was counted
+		count, countErr := u.userRepository.CountUsersByRole(ctx, config.DefaultAdminRole)
+		if countErr == nil && count != 0 {
+			return "", utils.ErrAdminAlreadyExist
+		}
+
+		return "", err
+	} else if err != nil {
+		return "", err
+	}
+
+	return userEntity.ID, nil
+}
+
+// CreateEmployee create the account of an employee with the role chosen by the admin, the password must be changed on
+// the first login
+func (u *UserServiceImpl) CreateEmployee(ctx context.Context, request *dto.EmployeeCreateRequest) (string, error) {
+	_, err := u.userRepository.GetRole(ctx, request.Role)
+	if err != nil {
+		return "", err
+	}
+
+	err = u.passwordPolicy.CheckLength(request.Password)
+	if err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := u.passwordHash.GenerateFromPassword([]byte(request.Password), 10)
+	if err != nil {
+		return "", err
+	}
+
+	request.Password = string(hashedPassword)
+
+	userEntity := request.ToEntity()
+	userEntity.ID = uuid.New().String()
+	userEntity.MustChangePassword = true
+
+	err = u.userRepository.CreateUser(ctx, userEntity)
+	if err != nil {
+		return "", err
+	}
+
+	return userEntity.ID, nil
+}
+
+func (u *UserServiceImpl) GetUser(ctx context.Context, userID string) (*dto.UserResponse, error) {
+	user, err := u.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewUserResponse(user), nil
+}
+
+// ChangeUserRole revoke the session of the user, since the permission of the old role is embedded in the token
+func (u *UserServiceImpl) ChangeUserRole(ctx context.Context, userID string, request *dto.UserRoleRequest) error {
+	_, err := u.userRepository.GetRole(ctx, request.Role)
+	if err != nil {
+		return err
+	}
+
+	user := &entity.User{ID: userID, Role: request.Role}
+
+	err = u.userRepository.UpdateUser(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	return u.userRepository.RevokeUserTokens(ctx, userID, time.Now())
+}
+
+// ResetUserPassword set a temporary password, the user must change it on the next login. The temporary password isn't
+// chosen by the user, so it isn't kept in the password history
+func (u *UserServiceImpl) ResetUserPassword(ctx context.Context, userID string, request *dto.UserPasswordRequest) error {
+	err := u.passwordPolicy.CheckLength(request.Password)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := u.passwordHash.GenerateFromPassword([]byte(request.Password), 10)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = u.userRepository.UpdatePassword(ctx, userID, string(hashedPassword), true, now)
+	if err != nil {
+		return err
+	}
+
+	return u.userRepository.RevokeUserTokens(ctx, userID, now)
+}
+
+// DeactivateUser refuse the user from logging in and from using the token already issued to them
+func (u *UserServiceImpl) DeactivateUser(ctx context.Context, requesterID string, userID string) error {
+	if requesterID == userID {
+		return utils.ErrSelfDeactivation
+	}
+
+	now := time.Now()
+	err := u.userRepository.DeactivateUser(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+
+	return u.userRepository.RevokeUserTokens(ctx, userID, now)
+}
+
+func (u *UserServiceImpl) ReactivateUser(ctx context.Context, userID string) error {
+	return u.userRepository.ReactivateUser(ctx, userID)
+}
@@ -0,0 +1,217 @@
+package impl
+
+import (
+	"context"
+	"errors"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/suryaadi44/eAD-System/internal/user/dto"
+	"github.com/suryaadi44/eAD-System/pkg/config"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+func (t *TestSuiteUserService) TestCreateInitialAdmin_Success() {
+	t.mockUserRepository.On("CountUsersByRole", mock.Anything, config.DefaultAdminRole).Return(int64(0), nil)
+	t.mockPasswordHash.On("GenerateFromPassword", []byte("password"), 10).Return([]byte("hashedPassword"), nil)
+	t.mockUserRepository.On("CreateUser", mock.Anything, mock.MatchedBy(func(user *entity.User) bool {
+		return user.ID != "" && user.Username == "root" && user.Password == "hashedPassword" &&
+			user.Role == config.DefaultAdminRole && user.MustChangePassword
+	})).Return(nil)
+
+	id, err := t.userService.CreateInitialAdmin(context.Background(), &dto.AdminCreateRequest{
+		Username: "root",
+		Password: "password",
+	})
+
+	t.NoError(err)
+	t.NotEmpty(id)
+	t.mockUserRepository.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestCreateInitialAdmin_AlreadyExist() {
+	t.mockUserRepository.On("CountUsersByRole", mock.Anything, config.DefaultAdminRole).Return(int64(1), nil)
+
+	_, err := t.userService.CreateInitialAdmin(context.Background(), &dto.AdminCreateRequest{
+		Username: "root",
+		Password: "password",
+	})
+
+	t.Equal(utils.ErrAdminAlreadyExist, err)
+	t.mockPasswordHash.AssertNotCalled(t.T(), "GenerateFromPassword", mock.Anything, mock.Anything)
+	t.mockUserRepository.AssertNotCalled(t.T(), "CreateUser", mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestCreateInitialAdmin_UsernameTaken() {
+	for _, tc := range []struct {
+		Name        string
+		AdminCount  int64
+		ExpectedErr error
+	}{
+		{
+			Name:        "Created by another instance",
+			AdminCount:  1,
+			ExpectedErr: utils.ErrAdminAlreadyExist,
+		},
+		{
+			Name:        "Taken by another user",
+			AdminCount:  0,
+			ExpectedErr: utils.ErrUsernameAlreadyExist,
+		},
+	} {
+		t.Run(tc.Name, func() {
+			t.SetupTest()
+			t.mockUserRepository.On("CountUsersByRole", mock.Anything, config.DefaultAdminRole).Return(int64(0), nil).Once()
+			t.mockUserRepository.On("CountUsersByRole", mock.Anything, config.DefaultAdminRole).Return(tc.AdminCount, nil).Once()
+			t.mockPasswordHash.On("GenerateFromPassword", []byte("password"), 10).Return([]byte("hashedPassword"), nil)
+			t.mockUserRepository.On("CreateUser", mock.Anything, mock.Anything).Return(utils.ErrUsernameAlreadyExist)
+
+			_, err := t.userService.CreateInitialAdmin(context.Background(), &dto.AdminCreateRequest{
+				Username: "root",
+				Password: "password",
+			})
+
+			t.Equal(tc.ExpectedErr, err)
+			t.mockUserRepository.AssertExpectations(t.T())
+		})
+	}
+}
+
+func (t *TestSuiteUserService) TestCreateInitialAdmin_FailedCount() {
+	t.mockUserRepository.On("CountUsersByRole", mock.Anything, config.DefaultAdminRole).Return(int64(0), errors.New("error"))
+
+	_, err := t.userService.CreateInitialAdmin(context.Background(), &dto.AdminCreateRequest{})
+
+	t.Equal(errors.New("error"), err)
+}
+
+func (t *TestSuiteUserService) TestCreateEmployee_Success() {
+	t.mockUserRepository.On("GetRole", mock.Anything, 2).Return(&entity.Role{ID: 2}, nil)
+	t.mockPasswordHash.On("GenerateFromPassword", []byte("password"), 10).Return([]byte("hashedPassword"), nil)
+	t.mockUserRepository.On("CreateUser", mock.Anything, mock.MatchedBy(func(user *entity.User) bool {
+		return user.ID != "" && user.Role == 2 && user.Password == "hashedPassword" && user.MustChangePassword
+	})).Return(nil)
+
+	id, err := t.userService.CreateEmployee(context.Background(), &dto.EmployeeCreateRequest{
+		Username: "username",
+		Password: "password",
+		Role:     2,
+	})
+
+	t.NoError(err)
+	t.NotEmpty(id)
+	t.mockUserRepository.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestCreateEmployee_RoleNotFound() {
+	t.mockUserRepository.On("GetRole", mock.Anything, 9).Return((*entity.Role)(nil), utils.ErrRoleNotFound)
+
+	_, err := t.userService.CreateEmployee(context.Background(), &dto.EmployeeCreateRequest{Role: 9})
+
+	t.Equal(utils.ErrRoleNotFound, err)
+	t.mockUserRepository.AssertNotCalled(t.T(), "CreateUser", mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestCreateEmployee_FailedCreateUser() {
+	t.mockUserRepository.On("GetRole", mock.Anything, 2).Return(&entity.Role{ID: 2}, nil)
+	t.mockPasswordHash.On("GenerateFromPassword", []byte("password"), 10).Return([]byte("hashedPassword"), nil)
+	t.mockUserRepository.On("CreateUser", mock.Anything, mock.Anything).Return(utils.ErrUsernameAlreadyExist)
+
+	_, err := t.userService.CreateEmployee(context.Background(), &dto.EmployeeCreateRequest{
+		Password: "password",
+		Role:     2,
+	})
+
+	t.Equal(utils.ErrUsernameAlreadyExist, err)
+}
+
+func (t *TestSuiteUserService) TestGetUser_Success() {
+	t.mockUserRepository.On("FindByID", mock.Anything, "userid").Return(&entity.User{ID: "userid", Role: 3}, nil)
+
+	resp, err := t.userService.GetUser(context.Background(), "userid")
+
+	t.NoError(err)
+	t.Equal(&dto.UserResponse{ID: "userid", Role: 3, Active: true}, resp)
+}
+
+func (t *TestSuiteUserService) TestGetUser_NotFound() {
+	t.mockUserRepository.On("FindByID", mock.Anything, "userid").Return((*entity.User)(nil), utils.ErrUserNotFound)
+
+	_, err := t.userService.GetUser(context.Background(), "userid")
+
+	t.Equal(utils.ErrUserNotFound, err)
+}
+
+func (t *TestSuiteUserService) TestChangeUserRole_Success() {
+	t.mockUserRepository.On("GetRole", mock.Anything, 2).Return(&entity.Role{ID: 2}, nil)
+	t.mockUserRepository.On("UpdateUser", mock.Anything, &entity.User{ID: "userid", Role: 2}).Return(nil)
+	t.mockUserRepository.On("RevokeUserTokens", mock.Anything, "userid", mock.AnythingOfType("time.Time")).Return(nil)
+
+	err := t.userService.ChangeUserRole(context.Background(), "userid", &dto.UserRoleRequest{Role: 2})
+
+	t.NoError(err)
+	t.mockUserRepository.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestChangeUserRole_RoleNotFound() {
+	t.mockUserRepository.On("GetRole", mock.Anything, 9).Return((*entity.Role)(nil), utils.ErrRoleNotFound)
+
+	err := t.userService.ChangeUserRole(context.Background(), "userid", &dto.UserRoleRequest{Role: 9})
+
+	t.Equal(utils.ErrRoleNotFound, err)
+	t.mockUserRepository.AssertNotCalled(t.T(), "UpdateUser", mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestResetUserPassword_Success() {
+	t.mockPasswordHash.On("GenerateFromPassword", []byte("password"), 10).Return([]byte("hashedPassword"), nil)
+	t.mockUserRepository.On("UpdatePassword", mock.Anything, "userid", "hashedPassword", true, mock.AnythingOfType("time.Time")).Return(nil)
+	t.mockUserRepository.On("RevokeUserTokens", mock.Anything, "userid", mock.AnythingOfType("time.Time")).Return(nil)
+
+	err := t.userService.ResetUserPassword(context.Background(), "userid", &dto.UserPasswordRequest{Password: "password"})
+
+	t.NoError(err)
+	t.mockUserRepository.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestResetUserPassword_FailedHashing() {
+	t.mockPasswordHash.On("GenerateFromPassword", []byte("password"), 10).Return([]byte(""), errors.New("error"))
+
+	err := t.userService.ResetUserPassword(context.Background(), "userid", &dto.UserPasswordRequest{Password: "password"})
+
+	t.Equal(errors.New("error"), err)
+	t.mockUserRepository.AssertNotCalled(t.T(), "UpdatePassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestDeactivateUser_Success() {
+	t.mockUserRepository.On("DeactivateUser", mock.Anything, "userid", mock.AnythingOfType("time.Time")).Return(nil)
+	t.mockUserRepository.On("RevokeUserTokens", mock.Anything, "userid", mock.AnythingOfType("time.Time")).Return(nil)
+
+	err := t.userService.DeactivateUser(context.Background(), "adminid", "userid")
+
+	t.NoError(err)
+	t.mockUserRepository.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestDeactivateUser_NotFound() {
+	t.mockUserRepository.On("DeactivateUser", mock.Anything, "userid", mock.AnythingOfType("time.Time")).Return(utils.ErrUserNotFound)
+
+	err := t.userService.DeactivateUser(context.Background(), "adminid", "userid")
+
+	t.Equal(utils.ErrUserNotFound, err)
+	t.mockUserRepository.AssertNotCalled(t.T(), "RevokeUserTokens", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestDeactivateUser_Self() {
+	err := t.userService.DeactivateUser(context.Background(), "adminid", "adminid")
+
+	t.Equal(utils.ErrSelfDeactivation, err)
+	t.mockUserRepository.AssertNotCalled(t.T(), "DeactivateUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestReactivateUser_NotFound() {
+	t.mockUserRepository.On("ReactivateUser", mock.Anything, "userid").Return(utils.ErrUserNotFound)
+
+	err := t.userService.ReactivateUser(context.Background(), "userid")
+
+	t.Equal(utils.ErrUserNotFound, err)
+}
@@ -0,0 +1,203 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/suryaadi44/eAD-System/internal/user/dto"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/notifier"
+)
+
+const (
+	passwordResetSubject = "Password Reset Request"
+	// passwordResetLimit is the number of reset token that can be created for a user within passwordResetWindow
+	passwordResetLimit  = 3
+	passwordResetWindow = time.Hour
+	// passwordResetSendTimeout bound the background send, so a relay that stop responding didn't pile up goroutine
+	passwordResetSendTimeout = 30 * time.Second
+)
+
+// ForgotPassword send a single use password reset token to the email of the user. Unknown username, deactivated user,
+// user without email, and user that reached the reset limit is silently ignored. The email is sent in the background
+// and every failure is only logged, so neither the response nor its timing reveal which account exist
+func (u *UserServiceImpl) ForgotPassword(ctx context.Context, request *dto.ForgotPasswordRequest) {
+	err := u.sendPasswordReset(ctx, request.Username)
+	if err != nil {
+		log.Printf("forgot password: %v", err)
+	}
+}
+
+func (u *UserServiceImpl) sendPasswordReset(ctx context.Context, username string) error {
+	user, err := u.userRepository.FindByUsername(ctx, username)
+	if err != nil {
+		if err == utils.ErrUserNotFound {
+			return nil
+		}
+
+		return err
+	}
+
+	if user.Email == "" || !user.DeactivatedAt.IsZero() {
+		return nil
+	}
+
+	now := time.Now()
+	count, err := u.userRepository.CountPasswordResetTokens(ctx, user.ID, now.Add(-passwordResetWindow))
+	if err != nil {
+		return err
+	}
+
+	if count >= passwordResetLimit {
+		return nil
+	}
+
+	token, err := newRandomToken()
+	if err != nil {
+		return err
+	}
+
+	err = u.userRepository.CreatePasswordResetToken(ctx, &entity.PasswordResetToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(u.resetTokenExp),
+	})
+	if err != nil {
+		return err
+	}
+
+	message := &notifier.Message{
+		To:      user.Email,
+		Subject: passwordResetSubject,
+		Body:    u.passwordResetBody(token),
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), passwordResetSendTimeout)
+		defer cancel()
+
+		err := u.notifier.Send(ctx, message)
+		if err != nil {
+			log.Printf("send password reset to user %s: %v", user.ID, err)
+		}
+	}()
+
+	return nil
+}
+
+// ResetPassword replace the password of the owner of the reset token. The token is marked as used only after the new
+// password pass the policy, so a rejected password didn't waste the token. Every session of the user is revoked since
+// the old password might be known by someone else
+func (u *UserServiceImpl) ResetPassword(ctx context.Context, request *dto.ResetPasswordRequest) error {
+	token, err := u.userRepository.FindPasswordResetToken(ctx, hashToken(request.Token))
+	if err != nil {
+		if err == utils.ErrPasswordResetTokenNotFound {
+			return utils.ErrInvalidResetToken
+		}
+
+		return err
+	}
+
+	now := time.Now()
+	if !token.UsedAt.IsZero() || now.After(token.ExpiresAt) {
+		return utils.ErrInvalidResetToken
+	}
+
+	err = u.checkPasswordPolicy(ctx, token.UserID, request.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := u.passwordHash.GenerateFromPassword([]byte(request.NewPassword), 10)
+	if err != nil {
+		return err
+	}
+
+	err = u.userRepository.UsePasswordResetToken(ctx, token.ID, now)
+	if err != nil {
+		if err == utils.ErrPasswordResetTokenNotFound {
+			return utils.ErrInvalidResetToken
+		}
+
+		return err
+	}
+
+	err = u.userRepository.UpdatePassword(ctx, token.UserID, string(hashedPassword), false, now)
+	if err != nil {
+		if err == utils.ErrUserNotFound {
+			return utils.ErrInvalidResetToken
+		}
+
+		return err
+	}
+
+	err = u.addPasswordHistory(ctx, token.UserID, string(hashedPassword))
+	if err != nil {
+		return err
+	}
+
+	return u.userRepository.RevokeUserTokens(ctx, token.UserID, now)
+}
+
+// checkPasswordPolicy check the length of the new password and refuse the last password chosen by the user. A user
+// without history, such as one created before the history is kept, has the history seeded with the current password
+func (u *UserServiceImpl) checkPasswordPolicy(ctx context.Context, userID string, newPassword string) error {
+	err := u.passwordPolicy.CheckLength(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if u.passwordPolicy.History <= 0 {
+		return nil
+	}
+
+	hashedPasswords, err := u.userRepository.GetPasswordHistory(ctx, userID, u.passwordPolicy.History)
+	if err != nil {
+		return err
+	}
+
+	if len(hashedPasswords) == 0 {
+		currentPassword, err := u.userRepository.GetPasswordHash(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		err = u.addPasswordHistory(ctx, userID, currentPassword)
+		if err != nil {
+			return err
+		}
+
+		hashedPasswords = append(hashedPasswords, currentPassword)
+	}
+
+	for _, hashedPassword := range hashedPasswords {
+		if u.passwordHash.CompareHashAndPassword([]byte(hashedPassword), []byte(newPassword)) == nil {
+			return utils.ErrPasswordReused
+		}
+	}
+
+	return nil
+}
+
+func (u *UserServiceImpl) addPasswordHistory(ctx context.Context, userID string, hashedPassword string) error {
+	return u.userRepository.AddPasswordHistory(ctx, &entity.PasswordHistory{
+		UserID:   userID,
+		Password: hashedPassword,
+	})
+}
+
+// passwordResetBody write the reset link when the reset URL is configured, otherwise only the token
+func (u *UserServiceImpl) passwordResetBody(token string) string {
+	link := token
+	if u.resetURL != "" {
+		link = u.resetURL + token
+	}
+
+	return fmt.Sprintf("We received a request to reset your password. Use the following to choose a new password, "+
+		"it can only be used once and expire in %s:\n\n%s\n\nIf you didn't request it, you can ignore this message.",
+		u.resetTokenExp, link)
+}
@@ -0,0 +1,218 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/suryaadi44/eAD-System/internal/user/dto"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/notifier"
+)
+
+func (t *TestSuiteUserService) TestForgotPassword_Success() {
+	var tokenHash string
+	sent := make(chan struct{})
+	t.mockUserRepository.On("FindByUsername", mock.Anything, "username").Return(&entity.User{ID: "userid", Email: "user@example.com"}, nil)
+	t.mockUserRepository.On("CountPasswordResetTokens", mock.Anything, "userid", mock.MatchedBy(func(since time.Time) bool {
+		return since.Before(time.Now().Add(-passwordResetWindow).Add(time.Minute))
+	})).Return(int64(passwordResetLimit-1), nil)
+	t.mockUserRepository.On("CreatePasswordResetToken", mock.Anything, mock.MatchedBy(func(token *entity.PasswordResetToken) bool {
+		tokenHash = token.TokenHash
+		return token.UserID == "userid" && token.ExpiresAt.After(time.Now())
+	})).Return(nil)
+	t.mockNotifier.On("Send", mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	}), mock.MatchedBy(func(message *notifier.Message) bool {
+		index := strings.Index(message.Body, "http://localhost/reset-password?token=")
+		if message.To != "user@example.com" || index == -1 {
+			return false
+		}
+
+		token := strings.Fields(message.Body[index+len("http://localhost/reset-password?token="):])[0]
+		return hashToken(token) == tokenHash
+	})).Return(nil).Run(func(mock.Arguments) { close(sent) })
+
+	t.userService.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Username: "username"})
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fail("password reset is not sent")
+	}
+	t.mockUserRepository.AssertExpectations(t.T())
+	t.mockNotifier.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestForgotPassword_Ignored() {
+	for _, tc := range []struct {
+		Name     string
+		User     *entity.User
+		Err      error
+		Count    int64
+		CountErr error
+	}{
+		{
+			Name: "User not found",
+			User: (*entity.User)(nil),
+			Err:  utils.ErrUserNotFound,
+		},
+		{
+			Name: "User without email",
+			User: &entity.User{ID: "userid"},
+		},
+		{
+			Name: "User deactivated",
+			User: &entity.User{ID: "userid", Email: "user@example.com", DeactivatedAt: time.Now()},
+		},
+		{
+			Name:  "Reset limit reached",
+			User:  &entity.User{ID: "userid", Email: "user@example.com"},
+			Count: passwordResetLimit,
+		},
+		{
+			Name: "Generic error",
+			User: (*entity.User)(nil),
+			Err:  errors.New("error"),
+		},
+		{
+			Name:     "Generic error counting reset token",
+			User:     &entity.User{ID: "userid", Email: "user@example.com"},
+			CountErr: errors.New("error"),
+		},
+	} {
+		t.SetupTest()
+		t.Run(tc.Name, func() {
+			t.mockUserRepository.On("FindByUsername", mock.Anything, "username").Return(tc.User, tc.Err)
+			t.mockUserRepository.On("CountPasswordResetTokens", mock.Anything, "userid", mock.AnythingOfType("time.Time")).Return(tc.Count, tc.CountErr)
+
+			t.userService.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Username: "username"})
+
+			t.mockUserRepository.AssertNotCalled(t.T(), "CreatePasswordResetToken", mock.Anything, mock.Anything)
+			t.mockNotifier.AssertNotCalled(t.T(), "Send", mock.Anything, mock.Anything)
+		})
+		t.TearDownTest()
+	}
+}
+
+func (t *TestSuiteUserService) TestForgotPassword_FailedSend() {
+	sent := make(chan struct{})
+	t.mockUserRepository.On("FindByUsername", mock.Anything, "username").Return(&entity.User{ID: "userid", Email: "user@example.com"}, nil)
+	t.mockUserRepository.On("CountPasswordResetTokens", mock.Anything, "userid", mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	t.mockUserRepository.On("CreatePasswordResetToken", mock.Anything, mock.Anything).Return(nil)
+	t.mockNotifier.On("Send", mock.Anything, mock.Anything).Return(errors.New("error")).Run(func(mock.Arguments) { close(sent) })
+
+	t.userService.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Username: "username"})
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fail("password reset is not sent")
+	}
+}
+
+func (t *TestSuiteUserService) TestResetPassword_Success() {
+	t.mockUserRepository.On("FindPasswordResetToken", mock.Anything, hashToken("reset")).Return(&entity.PasswordResetToken{
+		ID:        "tokenid",
+		UserID:    "userid",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+	t.mockUserRepository.On("GetPasswordHistory", mock.Anything, "userid", 3).Return([]string{"oldHashedPassword"}, nil)
+	t.mockPasswordHash.On("CompareHashAndPassword", []byte("oldHashedPassword"), []byte("newPassword")).Return(errors.New("error"))
+	t.mockPasswordHash.On("GenerateFromPassword", []byte("newPassword"), 10).Return([]byte("newHashedPassword"), nil)
+	t.mockUserRepository.On("UsePasswordResetToken", mock.Anything, "tokenid", mock.AnythingOfType("time.Time")).Return(nil)
+	t.mockUserRepository.On("UpdatePassword", mock.Anything, "userid", "newHashedPassword", false, mock.AnythingOfType("time.Time")).Return(nil)
+	t.mockUserRepository.On("AddPasswordHistory", mock.Anything, &entity.PasswordHistory{UserID: "userid", Password: "newHashedPassword"}).Return(nil)
+	t.mockUserRepository.On("RevokeUserTokens", mock.Anything, "userid", mock.AnythingOfType("time.Time")).Return(nil)
+
+	err := t.userService.ResetPassword(context.Background(), &dto.ResetPasswordRequest{Token: "reset", NewPassword: "newPassword"})
+
+	t.NoError(err)
+	t.mockUserRepository.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestResetPassword_SeedHistory() {
+	t.mockUserRepository.On("FindPasswordResetToken", mock.Anything, hashToken("reset")).Return(&entity.PasswordResetToken{
+		ID:        "tokenid",
+		UserID:    "userid",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+	t.mockUserRepository.On("GetPasswordHistory", mock.Anything, "userid", 3).Return([]string{}, nil)
+	t.mockUserRepository.On("GetPasswordHash", mock.Anything, "userid").Return("currentHashedPassword", nil)
+	t.mockUserRepository.On("AddPasswordHistory", mock.Anything, &entity.PasswordHistory{UserID: "userid", Password: "currentHashedPassword"}).Return(nil)
+	t.mockPasswordHash.On("CompareHashAndPassword", []byte("currentHashedPassword"), []byte("currentPassword")).Return(nil)
+
+	err := t.userService.ResetPassword(context.Background(), &dto.ResetPasswordRequest{Token: "reset", NewPassword: "currentPassword"})
+
+	t.Equal(utils.ErrPasswordReused, err)
+	t.mockUserRepository.AssertExpectations(t.T())
+	t.mockUserRepository.AssertNotCalled(t.T(), "UpdatePassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestResetPassword_Failed() {
+	for _, tc := range []struct {
+		Name        string
+		Token       *entity.PasswordResetToken
+		FindErr     error
+		History     []string
+		UseErr      error
+		ExpectedErr error
+	}{
+		{
+			Name:        "Token not found",
+			Token:       (*entity.PasswordResetToken)(nil),
+			FindErr:     utils.ErrPasswordResetTokenNotFound,
+			ExpectedErr: utils.ErrInvalidResetToken,
+		},
+		{
+			Name:        "Token expired",
+			Token:       &entity.PasswordResetToken{ID: "tokenid", UserID: "userid", ExpiresAt: time.Now().Add(-time.Minute)},
+			ExpectedErr: utils.ErrInvalidResetToken,
+		},
+		{
+			Name:        "Token already used",
+			Token:       &entity.PasswordResetToken{ID: "tokenid", UserID: "userid", ExpiresAt: time.Now().Add(time.Hour), UsedAt: time.Now()},
+			ExpectedErr: utils.ErrInvalidResetToken,
+		},
+		{
+			Name:        "Password reused",
+			Token:       &entity.PasswordResetToken{ID: "tokenid", UserID: "userid", ExpiresAt: time.Now().Add(time.Hour)},
+			History:     []string{"newHashedPassword"},
+			ExpectedErr: utils.ErrPasswordReused,
+		},
+		{
+			Name:        "Token used by concurrent request",
+			Token:       &entity.PasswordResetToken{ID: "tokenid", UserID: "userid", ExpiresAt: time.Now().Add(time.Hour)},
+			UseErr:      utils.ErrPasswordResetTokenNotFound,
+			ExpectedErr: utils.ErrInvalidResetToken,
+		},
+		{
+			Name:        "Generic error",
+			Token:       (*entity.PasswordResetToken)(nil),
+			FindErr:     errors.New("error"),
+			ExpectedErr: errors.New("error"),
+		},
+	} {
+		t.SetupTest()
+		t.Run(tc.Name, func() {
+			t.mockUserRepository.On("FindPasswordResetToken", mock.Anything, hashToken("reset")).Return(tc.Token, tc.FindErr)
+			t.mockUserRepository.On("GetPasswordHistory", mock.Anything, "userid", 3).Return(tc.History, nil)
+			t.mockUserRepository.On("GetPasswordHash", mock.Anything, "userid").Return("oldHashedPassword", nil)
+			t.mockUserRepository.On("AddPasswordHistory", mock.Anything, mock.Anything).Return(nil)
+			t.mockPasswordHash.On("CompareHashAndPassword", []byte("newHashedPassword"), []byte("newPassword")).Return(nil)
+			t.mockPasswordHash.On("CompareHashAndPassword", []byte("oldHashedPassword"), []byte("newPassword")).Return(errors.New("error"))
+			t.mockPasswordHash.On("GenerateFromPassword", []byte("newPassword"), 10).Return([]byte("newHashedPassword"), nil)
+			t.mockUserRepository.On("UsePasswordResetToken", mock.Anything, "tokenid", mock.AnythingOfType("time.Time")).Return(tc.UseErr)
+
+			err := t.userService.ResetPassword(context.Background(), &dto.ResetPasswordRequest{Token: "reset", NewPassword: "newPassword"})
+
+			t.Equal(tc.ExpectedErr, err)
+			t.mockUserRepository.AssertNotCalled(t.T(), "UpdatePassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+		t.TearDownTest()
+	}
+}
@@ -6,28 +6,50 @@ import (
 	"github.com/suryaadi44/eAD-System/internal/user/dto"
 	"github.com/suryaadi44/eAD-System/internal/user/repository"
 	"github.com/suryaadi44/eAD-System/internal/user/service"
+	"github.com/suryaadi44/eAD-System/pkg/config"
 	"github.com/suryaadi44/eAD-System/pkg/utils"
 	"github.com/suryaadi44/eAD-System/pkg/utils/jwt_service"
+	"github.com/suryaadi44/eAD-System/pkg/utils/notifier"
 	"github.com/suryaadi44/eAD-System/pkg/utils/password"
+	"github.com/suryaadi44/eAD-System/pkg/utils/storage"
+	"time"
 )
 
 type (
 	UserServiceImpl struct {
-		userRepository repository.UserRepository
-		passwordHash   password.PasswordFunc
-		jwtService     jwt_service.JWTService
+		userRepository  repository.UserRepository
+		passwordHash    password.PasswordFunc
+		jwtService      jwt_service.JWTService
+		artifactStorage storage.ArtifactStorage
+		refreshTokenExp time.Duration
+		notifier        notifier.Notifier
+		passwordPolicy  password.Policy
+		resetTokenExp   time.Duration
+		resetURL        string
 	}
 )
 
-func NewUserServiceImpl(userRepository repository.UserRepository, function password.PasswordFunc, jwt jwt_service.JWTService) service.UserService {
+func NewUserServiceImpl(userRepository repository.UserRepository, function password.PasswordFunc, jwt jwt_service.JWTService, artifactStorage storage.ArtifactStorage, refreshTokenExp time.Duration, notifier notifier.Notifier, passwordPolicy password.Policy, resetTokenExp time.Duration, resetURL string) service.UserService {
 	return &UserServiceImpl{
-		userRepository: userRepository,
-		passwordHash:   function,
-		jwtService:     jwt,
+		userRepository:  userRepository,
+		passwordHash:    function,
+		jwtService:      jwt,
+		artifactStorage: artifactStorage,
+		refreshTokenExp: refreshTokenExp,
+		notifier:        notifier,
+		passwordPolicy:  passwordPolicy,
+		resetTokenExp:   resetTokenExp,
+		resetURL:        resetURL,
 	}
 }
 
+// SignUpUser only check the length of the password, since the new user has no password history yet
 func (u *UserServiceImpl) SignUpUser(ctx context.Context, user *dto.UserSignUpRequest) error {
+	err := u.passwordPolicy.CheckLength(user.Password)
+	if err != nil {
+		return err
+	}
+
 	hashedPassword, err := u.passwordHash.GenerateFromPassword([]byte(user.Password), 10)
 	if err != nil {
 		return err
@@ -37,37 +59,36 @@ func (u *UserServiceImpl) SignUpUser(ctx context.Context, user *dto.UserSignUpRe
 
 	userEntity := user.ToEntity()
 	userEntity.ID = uuid.New().String()
-	userEntity.Role = 1
+	userEntity.Role = config.DefaultSignUpRole
 
 	err = u.userRepository.CreateUser(ctx, userEntity)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return u.addPasswordHistory(ctx, userEntity.ID, userEntity.Password)
 }
 
-func (u *UserServiceImpl) LogInUser(ctx context.Context, user *dto.UserLoginRequest) (string, error) {
+func (u *UserServiceImpl) LogInUser(ctx context.Context, user *dto.UserLoginRequest) (*dto.TokenResponse, error) {
 	userEntity, err := u.userRepository.FindByUsername(ctx, user.Username)
 	if err != nil {
 		if err == utils.ErrUserNotFound {
-			return "", utils.ErrInvalidCredentials
+			return nil, utils.ErrInvalidCredentials
 		}
 
-		return "", err
+		return nil, err
 	}
 
 	err = u.passwordHash.CompareHashAndPassword([]byte(userEntity.Password), []byte(user.Password))
 	if err != nil {
-		return "", utils.ErrInvalidCredentials
+		return nil, utils.ErrInvalidCredentials
 	}
 
-	token, err := u.jwtService.GenerateToken(userEntity)
-	if err != nil {
-		return "", err
+	if !userEntity.DeactivatedAt.IsZero() {
+		return nil, utils.ErrUserDeactivated
 	}
 
-	return token, nil
+	return u.issueTokens(ctx, userEntity)
 }
 
 func (u *UserServiceImpl) GetBriefUsers(ctx context.Context, page int, limit int) (*dto.BriefUsersResponse, error) {
@@ -85,14 +106,63 @@ func (u *UserServiceImpl) UpdateUser(ctx context.Context, userID string, request
 	user := request.ToEntity()
 	user.ID = userID
 
-	if user.Password != "" {
-		hashedPassword, err := u.passwordHash.GenerateFromPassword([]byte(user.Password), 10)
-		if err != nil {
-			return err
-		}
+	if user.Password == "" {
+		return u.userRepository.UpdateUser(ctx, user)
+	}
+
+	err := u.checkPasswordPolicy(ctx, userID, user.Password)
+	if err != nil {
+		return err
+	}
 
-		user.Password = string(hashedPassword)
+	hashedPassword, err := u.passwordHash.GenerateFromPassword([]byte(user.Password), 10)
+	if err != nil {
+		return err
+	}
+
+	// the password is stored through UpdatePassword, so the outstanding reset token is invalidated with it
+	user.Password = ""
+
+	err = u.userRepository.UpdateUser(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	err = u.userRepository.UpdatePassword(ctx, userID, string(hashedPassword), false, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return u.addPasswordHistory(ctx, userID, string(hashedPassword))
+}
+
+// ChangePassword replace the password of the user after checking the current one, it also clear the password change
+// required after the admin set the password
+func (u *UserServiceImpl) ChangePassword(ctx context.Context, userID string, request *dto.UserChangePasswordRequest) error {
+	currentPassword, err := u.userRepository.GetPasswordHash(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = u.passwordHash.CompareHashAndPassword([]byte(currentPassword), []byte(request.OldPassword))
+	if err != nil {
+		return utils.ErrWrongPassword
+	}
+
+	err = u.checkPasswordPolicy(ctx, userID, request.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := u.passwordHash.GenerateFromPassword([]byte(request.NewPassword), 10)
+	if err != nil {
+		return err
+	}
+
+	err = u.userRepository.UpdatePassword(ctx, userID, string(hashedPassword), false, time.Now())
+	if err != nil {
+		return err
 	}
 
-	return u.userRepository.UpdateUser(ctx, user)
+	return u.addPasswordHistory(ctx, userID, string(hashedPassword))
 }
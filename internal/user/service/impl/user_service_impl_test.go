@@ -7,8 +7,12 @@ import (
 	"github.com/suryaadi44/eAD-System/internal/user/service"
 	"github.com/suryaadi44/eAD-System/pkg/utils"
 	mockJwtServicePkg "github.com/suryaadi44/eAD-System/pkg/utils/jwt_service/mock"
+	mockNotifierPkg "github.com/suryaadi44/eAD-System/pkg/utils/notifier/mock"
+	"github.com/suryaadi44/eAD-System/pkg/utils/password"
 	mockPassFuncPkg "github.com/suryaadi44/eAD-System/pkg/utils/password/mock"
+	mockStoragePkg "github.com/suryaadi44/eAD-System/pkg/utils/storage/mock"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -21,6 +25,8 @@ type TestSuiteUserService struct {
 	mockUserRepository *mockUserRepoPkg.MockUserRepository
 	mockPasswordHash   *mockPassFuncPkg.MockPasswordHashFunction
 	mockJWTService     *mockJwtServicePkg.MockJWTService
+	mockStorage        *mockStoragePkg.MockArtifactStorage
+	mockNotifier       *mockNotifierPkg.MockNotifier
 	userService        service.UserService
 }
 
@@ -28,19 +34,27 @@ func (t *TestSuiteUserService) SetupTest() {
 	t.mockUserRepository = new(mockUserRepoPkg.MockUserRepository)
 	t.mockPasswordHash = new(mockPassFuncPkg.MockPasswordHashFunction)
 	t.mockJWTService = new(mockJwtServicePkg.MockJWTService)
-	t.userService = NewUserServiceImpl(t.mockUserRepository, t.mockPasswordHash, t.mockJWTService)
+	t.mockStorage = new(mockStoragePkg.MockArtifactStorage)
+	t.mockNotifier = new(mockNotifierPkg.MockNotifier)
+	t.userService = NewUserServiceImpl(t.mockUserRepository, t.mockPasswordHash, t.mockJWTService, t.mockStorage, time.Hour,
+		t.mockNotifier, password.Policy{MinLength: 8, History: 3}, time.Hour, "http://localhost/reset-password?token=")
 }
 
 func (t *TestSuiteUserService) TearDownTest() {
 	t.mockUserRepository = nil
 	t.mockPasswordHash = nil
 	t.mockJWTService = nil
+	t.mockStorage = nil
+	t.mockNotifier = nil
 	t.userService = nil
 }
 
 func (t *TestSuiteUserService) TestSignUpUser_Success() {
 	t.mockPasswordHash.On("GenerateFromPassword", []byte("password"), 10).Return([]byte("hashedPassword"), nil)
 	t.mockUserRepository.On("CreateUser", mock.Anything, mock.Anything).Return(nil)
+	t.mockUserRepository.On("AddPasswordHistory", mock.Anything, mock.MatchedBy(func(history *entity.PasswordHistory) bool {
+		return history.UserID != "" && history.Password == "hashedPassword"
+	})).Return(nil)
 
 	err := t.userService.SignUpUser(context.Background(), &dto.UserSignUpRequest{
 		Username: "username",
@@ -48,6 +62,17 @@ func (t *TestSuiteUserService) TestSignUpUser_Success() {
 	})
 
 	t.NoError(err)
+	t.mockUserRepository.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestSignUpUser_PasswordTooShort() {
+	err := t.userService.SignUpUser(context.Background(), &dto.UserSignUpRequest{
+		Username: "username",
+		Password: "pass",
+	})
+
+	t.Equal(utils.ErrPasswordTooShort, err)
+	t.mockUserRepository.AssertNotCalled(t.T(), "CreateUser", mock.Anything, mock.Anything)
 }
 
 func (t *TestSuiteUserService) TestSignUpUser_FailedHashing() {
@@ -77,9 +102,12 @@ func (t *TestSuiteUserService) TestLoginUser_Success() {
 	t.mockUserRepository.On("FindByUsername", mock.Anything, "username").Return(&entity.User{
 		Username: "username",
 		Password: "hashedPassword",
+		Role:     2,
 	}, nil)
 	t.mockPasswordHash.On("CompareHashAndPassword", []byte("hashedPassword"), []byte("password")).Return(nil)
-	t.mockJWTService.On("GenerateToken", mock.Anything).Return("token", nil)
+	t.mockUserRepository.On("GetRolePermissions", mock.Anything, 2).Return([]string{"document.verify"}, nil)
+	t.mockJWTService.On("GenerateToken", mock.Anything, []string{"document.verify"}).Return("token", nil)
+	t.mockUserRepository.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
 
 	resp, err := t.userService.LogInUser(context.Background(), &dto.UserLoginRequest{
 		Username: "username",
@@ -87,7 +115,8 @@ func (t *TestSuiteUserService) TestLoginUser_Success() {
 	})
 
 	t.NoError(err)
-	t.Equal("token", resp)
+	t.Equal("token", resp.Token)
+	t.NotEmpty(resp.RefreshToken)
 }
 
 func (t *TestSuiteUserService) TestLoginUser_FailedFindByUsername() {
@@ -127,13 +156,48 @@ func (t *TestSuiteUserService) TestLoginUser_FailedCompareHashAndPassword() {
 	t.Equal(utils.ErrInvalidCredentials, err)
 }
 
+func (t *TestSuiteUserService) TestLoginUser_FailedUserDeactivated() {
+	t.mockUserRepository.On("FindByUsername", mock.Anything, "username").Return(&entity.User{
+		Username:      "username",
+		Password:      "hashedPassword",
+		DeactivatedAt: time.Now(),
+	}, nil)
+	t.mockPasswordHash.On("CompareHashAndPassword", []byte("hashedPassword"), []byte("password")).Return(nil)
+
+	_, err := t.userService.LogInUser(context.Background(), &dto.UserLoginRequest{
+		Username: "username",
+		Password: "password",
+	})
+
+	t.Equal(utils.ErrUserDeactivated, err)
+	t.mockJWTService.AssertNotCalled(t.T(), "GenerateToken", mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestLoginUser_FailedGetRolePermissions() {
+	t.mockUserRepository.On("FindByUsername", mock.Anything, "username").Return(&entity.User{
+		Username: "username",
+		Password: "hashedPassword",
+	}, nil)
+	t.mockPasswordHash.On("CompareHashAndPassword", []byte("hashedPassword"), []byte("password")).Return(nil)
+	t.mockUserRepository.On("GetRolePermissions", mock.Anything, 0).Return([]string(nil), errors.New("error"))
+
+	_, err := t.userService.LogInUser(context.Background(), &dto.UserLoginRequest{
+		Username: "username",
+		Password: "password",
+	})
+
+	t.Equal(errors.New("error"), err)
+	t.mockJWTService.AssertNotCalled(t.T(), "GenerateToken", mock.Anything, mock.Anything)
+}
+
 func (t *TestSuiteUserService) TestLoginUser_FailedGenerateToken() {
 	t.mockUserRepository.On("FindByUsername", mock.Anything, "username").Return(&entity.User{
 		Username: "username",
 		Password: "hashedPassword",
 	}, nil)
 	t.mockPasswordHash.On("CompareHashAndPassword", []byte("hashedPassword"), []byte("password")).Return(nil)
-	t.mockJWTService.On("GenerateToken", mock.Anything).Return("", errors.New("error"))
+	t.mockUserRepository.On("GetRolePermissions", mock.Anything, 0).Return([]string{}, nil)
+	t.mockJWTService.On("GenerateToken", mock.Anything, mock.Anything).Return("", errors.New("error"))
 
 	_, err := t.userService.LogInUser(context.Background(), &dto.UserLoginRequest{
 		Username: "username",
@@ -179,8 +243,14 @@ func (t *TestSuiteUserService) TestUpdateUser_Success() {
 }
 
 func (t *TestSuiteUserService) TestUpdateUser_SuccessWithPassword() {
-	t.mockUserRepository.On("UpdateUser", mock.Anything, mock.Anything).Return(nil)
+	t.mockUserRepository.On("GetPasswordHistory", mock.Anything, "userid", 3).Return([]string{"oldHashedPassword"}, nil)
+	t.mockPasswordHash.On("CompareHashAndPassword", []byte("oldHashedPassword"), []byte("password")).Return(errors.New("error"))
+	t.mockUserRepository.On("UpdateUser", mock.Anything, mock.MatchedBy(func(user *entity.User) bool {
+		return user.Username == "username" && user.Password == ""
+	})).Return(nil)
 	t.mockPasswordHash.On("GenerateFromPassword", mock.Anything, 10).Return([]byte("hashedPassword"), nil)
+	t.mockUserRepository.On("UpdatePassword", mock.Anything, "userid", "hashedPassword", false, mock.AnythingOfType("time.Time")).Return(nil)
+	t.mockUserRepository.On("AddPasswordHistory", mock.Anything, &entity.PasswordHistory{UserID: "userid", Password: "hashedPassword"}).Return(nil)
 
 	err := t.userService.UpdateUser(context.Background(), "userid", &dto.UserUpdateRequest{
 		Username: "username",
@@ -188,9 +258,48 @@ func (t *TestSuiteUserService) TestUpdateUser_SuccessWithPassword() {
 	})
 
 	t.NoError(err)
+	t.mockUserRepository.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestUpdateUser_PasswordPolicy() {
+	for _, tc := range []struct {
+		Name        string
+		Password    string
+		History     []string
+		ExpectedErr error
+	}{
+		{
+			Name:        "Error password too short",
+			Password:    "pass",
+			ExpectedErr: utils.ErrPasswordTooShort,
+		},
+		{
+			Name:        "Error password reused",
+			Password:    "password",
+			History:     []string{"newestHashedPassword", "oldestHashedPassword"},
+			ExpectedErr: utils.ErrPasswordReused,
+		},
+	} {
+		t.SetupTest()
+		t.Run(tc.Name, func() {
+			t.mockUserRepository.On("GetPasswordHistory", mock.Anything, "userid", 3).Return(tc.History, nil)
+			t.mockPasswordHash.On("CompareHashAndPassword", []byte("newestHashedPassword"), []byte(tc.Password)).Return(errors.New("error"))
+			t.mockPasswordHash.On("CompareHashAndPassword", []byte("oldestHashedPassword"), []byte(tc.Password)).Return(nil)
+
+			err := t.userService.UpdateUser(context.Background(), "userid", &dto.UserUpdateRequest{
+				Password: tc.Password,
+			})
+
+			t.Equal(tc.ExpectedErr, err)
+			t.mockUserRepository.AssertNotCalled(t.T(), "UpdateUser", mock.Anything, mock.Anything)
+		})
+		t.TearDownTest()
+	}
 }
 
 func (t *TestSuiteUserService) TestUpdateUser_PasswordHashError() {
+	t.mockUserRepository.On("GetPasswordHistory", mock.Anything, "userid", 3).Return([]string{"oldHashedPassword"}, nil)
+	t.mockPasswordHash.On("CompareHashAndPassword", []byte("oldHashedPassword"), []byte("password")).Return(errors.New("error"))
 	t.mockUserRepository.On("UpdateUser", mock.Anything, mock.Anything).Return(nil)
 	t.mockPasswordHash.On("GenerateFromPassword", mock.Anything, 10).Return(([]byte)(nil), errors.New("error"))
 
@@ -202,6 +311,82 @@ func (t *TestSuiteUserService) TestUpdateUser_PasswordHashError() {
 	t.Error(err)
 }
 
+func (t *TestSuiteUserService) TestLoginUser_MustChangePassword() {
+	t.mockUserRepository.On("FindByUsername", mock.Anything, "username").Return(&entity.User{
+		Username:           "username",
+		Password:           "hashedPassword",
+		Role:               3,
+		MustChangePassword: true,
+	}, nil)
+	t.mockPasswordHash.On("CompareHashAndPassword", []byte("hashedPassword"), []byte("password")).Return(nil)
+	t.mockUserRepository.On("GetRolePermissions", mock.Anything, 3).Return([]string{"user.manage"}, nil)
+	t.mockJWTService.On("GenerateToken", mock.Anything, []string{"user.manage"}).Return("token", nil)
+	t.mockUserRepository.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := t.userService.LogInUser(context.Background(), &dto.UserLoginRequest{
+		Username: "username",
+		Password: "password",
+	})
+
+	t.NoError(err)
+	t.True(resp.MustChangePassword)
+}
+
+func (t *TestSuiteUserService) TestChangePassword_Success() {
+	t.mockUserRepository.On("GetPasswordHash", mock.Anything, "userid").Return("hashedPassword", nil)
+	t.mockPasswordHash.On("CompareHashAndPassword", []byte("hashedPassword"), []byte("old")).Return(nil)
+	t.mockUserRepository.On("GetPasswordHistory", mock.Anything, "userid", 3).Return([]string{"hashedPassword"}, nil)
+	t.mockPasswordHash.On("CompareHashAndPassword", []byte("hashedPassword"), []byte("newPassword")).Return(errors.New("error"))
+	t.mockPasswordHash.On("GenerateFromPassword", []byte("newPassword"), 10).Return([]byte("newHashedPassword"), nil)
+	t.mockUserRepository.On("UpdatePassword", mock.Anything, "userid", "newHashedPassword", false, mock.AnythingOfType("time.Time")).Return(nil)
+	t.mockUserRepository.On("AddPasswordHistory", mock.Anything, &entity.PasswordHistory{UserID: "userid", Password: "newHashedPassword"}).Return(nil)
+
+	err := t.userService.ChangePassword(context.Background(), "userid", &dto.UserChangePasswordRequest{
+		OldPassword: "old",
+		NewPassword: "newPassword",
+	})
+
+	t.NoError(err)
+	t.mockUserRepository.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestChangePassword_PasswordReused() {
+	t.mockUserRepository.On("GetPasswordHash", mock.Anything, "userid").Return("hashedPassword", nil)
+	t.mockPasswordHash.On("CompareHashAndPassword", []byte("hashedPassword"), []byte("old")).Return(nil)
+	t.mockUserRepository.On("GetPasswordHistory", mock.Anything, "userid", 3).Return([]string{"hashedPassword", "olderHashedPassword"}, nil)
+	t.mockPasswordHash.On("CompareHashAndPassword", []byte("hashedPassword"), []byte("olderPassword")).Return(errors.New("error"))
+	t.mockPasswordHash.On("CompareHashAndPassword", []byte("olderHashedPassword"), []byte("olderPassword")).Return(nil)
+
+	err := t.userService.ChangePassword(context.Background(), "userid", &dto.UserChangePasswordRequest{
+		OldPassword: "old",
+		NewPassword: "olderPassword",
+	})
+
+	t.Equal(utils.ErrPasswordReused, err)
+	t.mockUserRepository.AssertNotCalled(t.T(), "UpdatePassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestChangePassword_WrongPassword() {
+	t.mockUserRepository.On("GetPasswordHash", mock.Anything, "userid").Return("hashedPassword", nil)
+	t.mockPasswordHash.On("CompareHashAndPassword", []byte("hashedPassword"), []byte("old")).Return(errors.New("error"))
+
+	err := t.userService.ChangePassword(context.Background(), "userid", &dto.UserChangePasswordRequest{
+		OldPassword: "old",
+		NewPassword: "new",
+	})
+
+	t.Equal(utils.ErrWrongPassword, err)
+	t.mockUserRepository.AssertNotCalled(t.T(), "UpdatePassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestChangePassword_UserNotFound() {
+	t.mockUserRepository.On("GetPasswordHash", mock.Anything, "userid").Return("", utils.ErrUserNotFound)
+
+	err := t.userService.ChangePassword(context.Background(), "userid", &dto.UserChangePasswordRequest{})
+
+	t.Equal(utils.ErrUserNotFound, err)
+}
+
 func TestUserService(t *testing.T) {
 	suite.Run(t, new(TestSuiteUserService))
 }
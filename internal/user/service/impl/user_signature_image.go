@@ -0,0 +1,86 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+
+	"github.com/suryaadi44/eAD-System/internal/user/dto"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+// maxSignatureImageSize limit the uploaded signature and stamp image, they are embedded in every signed letter
+const maxSignatureImageSize = 2 << 20
+
+// maxSignatureImagePixels limit the decoded size of the image, a small file can declare dimension that take gigabytes
+// once the renderer decode it. A stamp scanned at 600 dpi still fit
+const maxSignatureImagePixels = 2000 * 2000
+
+// UpdateSignatureImage store the handwritten signature image of the signer, it replace the previous one
+func (u *UserServiceImpl) UpdateSignatureImage(ctx context.Context, userID string, request *dto.SignatureImageRequest, file io.Reader) error {
+	signature, err := u.storeSignatureImage(ctx, "images/signature/"+userID, userID, request, file)
+	if err != nil {
+		return err
+	}
+
+	return u.userRepository.UpdateSignature(ctx, userID, signature)
+}
+
+// UpdateStampImage store the stamp image printed over the signature of the signer, it replace the previous one. The
+// stamp is kept per signer rather than per office since each position carry its own stamp, eg: the village head and
+// the secretary, and the application serve a single office
+func (u *UserServiceImpl) UpdateStampImage(ctx context.Context, userID string, request *dto.SignatureImageRequest, file io.Reader) error {
+	stamp, err := u.storeSignatureImage(ctx, "images/stamp/"+userID, userID, request, file)
+	if err != nil {
+		return err
+	}
+
+	return u.userRepository.UpdateStamp(ctx, userID, stamp)
+}
+
+// storeSignatureImage check the uploaded image and save it under the key, the user is looked up first so no image is
+// left in the storage for unknown user
+func (u *UserServiceImpl) storeSignatureImage(ctx context.Context, key string, userID string, request *dto.SignatureImageRequest, file io.Reader) (*entity.SignatureImage, error) {
+	content, err := readSignatureImage(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = u.userRepository.FindByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if err = u.artifactStorage.Put(ctx, key, content); err != nil {
+		return nil, err
+	}
+
+	return request.ToEntity(key), nil
+}
+
+// readSignatureImage read the uploaded image, only PNG and JPEG is accepted since both backend can render them
+func readSignatureImage(file io.Reader) ([]byte, error) {
+	// read one byte past the limit to know if the file exceed it
+	content, err := io.ReadAll(io.LimitReader(file, maxSignatureImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) > maxSignatureImageSize {
+		return nil, utils.ErrImageTooLarge
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil || (format != "png" && format != "jpeg") || config.Width <= 0 || config.Height <= 0 {
+		return nil, utils.ErrInvalidImage
+	}
+
+	if config.Width*config.Height > maxSignatureImagePixels {
+		return nil, utils.ErrImageTooLarge
+	}
+
+	return content, nil
+}
@@ -0,0 +1,102 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"strings"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/suryaadi44/eAD-System/internal/user/dto"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+func newTestImage() []byte {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 4, 2))); err != nil {
+		panic(err)
+	}
+
+	return buf.Bytes()
+}
+
+func (t *TestSuiteUserService) TestUpdateSignatureImage_Success() {
+	content := newTestImage()
+	t.mockUserRepository.On("FindByID", mock.Anything, "userid").Return(&entity.User{ID: "userid"}, nil)
+	t.mockStorage.On("Put", mock.Anything, "images/signature/userid", content).Return(nil)
+	t.mockUserRepository.On("UpdateSignature", mock.Anything, "userid", &entity.SignatureImage{
+		ImageKey: "images/signature/userid",
+		Height:   25,
+		OffsetX:  -3,
+	}).Return(nil)
+
+	err := t.userService.UpdateSignatureImage(context.Background(), "userid", &dto.SignatureImageRequest{Height: 25, OffsetX: -3}, bytes.NewReader(content))
+
+	t.NoError(err)
+	t.mockStorage.AssertExpectations(t.T())
+	t.mockUserRepository.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestUpdateStampImage_Success() {
+	content := newTestImage()
+	t.mockUserRepository.On("FindByID", mock.Anything, "userid").Return(&entity.User{ID: "userid"}, nil)
+	t.mockStorage.On("Put", mock.Anything, "images/stamp/userid", content).Return(nil)
+	t.mockUserRepository.On("UpdateStamp", mock.Anything, "userid", &entity.SignatureImage{
+		ImageKey: "images/stamp/userid",
+		OffsetY:  4,
+	}).Return(nil)
+
+	err := t.userService.UpdateStampImage(context.Background(), "userid", &dto.SignatureImageRequest{OffsetY: 4}, bytes.NewReader(content))
+
+	t.NoError(err)
+	t.mockStorage.AssertExpectations(t.T())
+	t.mockUserRepository.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestUpdateSignatureImage_InvalidImage() {
+	err := t.userService.UpdateSignatureImage(context.Background(), "userid", &dto.SignatureImageRequest{}, strings.NewReader("<svg></svg>"))
+
+	t.Equal(utils.ErrInvalidImage, err)
+	t.mockStorage.AssertNotCalled(t.T(), "Put", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestUpdateSignatureImage_TooLarge() {
+	content := append(newTestImage(), make([]byte, maxSignatureImageSize)...)
+
+	err := t.userService.UpdateSignatureImage(context.Background(), "userid", &dto.SignatureImageRequest{}, bytes.NewReader(content))
+
+	t.Equal(utils.ErrImageTooLarge, err)
+}
+
+func (t *TestSuiteUserService) TestUpdateSignatureImage_TooManyPixels() {
+	buf := new(bytes.Buffer)
+	t.Require().NoError(png.Encode(buf, image.NewGray(image.Rect(0, 0, 3000, 2000))))
+	t.Require().LessOrEqual(buf.Len(), maxSignatureImageSize)
+
+	err := t.userService.UpdateSignatureImage(context.Background(), "userid", &dto.SignatureImageRequest{}, bytes.NewReader(buf.Bytes()))
+
+	t.Equal(utils.ErrImageTooLarge, err)
+	t.mockStorage.AssertNotCalled(t.T(), "Put", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestUpdateStampImage_UserNotFound() {
+	t.mockUserRepository.On("FindByID", mock.Anything, "userid").Return((*entity.User)(nil), utils.ErrUserNotFound)
+
+	err := t.userService.UpdateStampImage(context.Background(), "userid", &dto.SignatureImageRequest{}, bytes.NewReader(newTestImage()))
+
+	t.Equal(utils.ErrUserNotFound, err)
+	t.mockStorage.AssertNotCalled(t.T(), "Put", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestUpdateSignatureImage_StorageError() {
+	t.mockUserRepository.On("FindByID", mock.Anything, "userid").Return(&entity.User{ID: "userid"}, nil)
+	t.mockStorage.On("Put", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("error"))
+
+	err := t.userService.UpdateSignatureImage(context.Background(), "userid", &dto.SignatureImageRequest{}, bytes.NewReader(newTestImage()))
+
+	t.Error(err)
+	t.mockUserRepository.AssertNotCalled(t.T(), "UpdateSignature", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,171 @@
+package impl
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/suryaadi44/eAD-System/internal/user/dto"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+// tokenSize is the number of random byte in the refresh token and the password reset token
+const tokenSize = 32
+
+// RefreshToken rotate the refresh token, the used refresh token is revoked and a new pair is issued with the current
+// permission of the user role. Using a revoked refresh token again means it is leaked, so every session of the user is
+// revoked
+func (u *UserServiceImpl) RefreshToken(ctx context.Context, request *dto.RefreshTokenRequest) (*dto.TokenResponse, error) {
+	token, err := u.userRepository.FindRefreshToken(ctx, hashToken(request.RefreshToken))
+	if err != nil {
+		if err == utils.ErrRefreshTokenNotFound {
+			return nil, utils.ErrInvalidRefreshToken
+		}
+
+		return nil, err
+	}
+
+	now := time.Now()
+	if !token.RevokedAt.IsZero() {
+		err = u.userRepository.RevokeUserTokens(ctx, token.UserID, now)
+		if err != nil && err != utils.ErrUserNotFound {
+			return nil, err
+		}
+
+		return nil, utils.ErrInvalidRefreshToken
+	}
+
+	if now.After(token.ExpiresAt) {
+		return nil, utils.ErrInvalidRefreshToken
+	}
+
+	user, err := u.userRepository.FindByID(ctx, token.UserID)
+	if err != nil {
+		if err == utils.ErrUserNotFound {
+			return nil, utils.ErrInvalidRefreshToken
+		}
+
+		return nil, err
+	}
+
+	if !user.DeactivatedAt.IsZero() {
+		return nil, utils.ErrUserDeactivated
+	}
+
+	err = u.userRepository.RevokeRefreshToken(ctx, token.ID, now)
+	if err != nil {
+		if err == utils.ErrRefreshTokenNotFound {
+			return nil, utils.ErrInvalidRefreshToken
+		}
+
+		return nil, err
+	}
+
+	return u.issueTokens(ctx, user)
+}
+
+// LogOutUser end the session of the presented refresh token, the other session of the user is kept. The refresh token
+// of another user is refused, and logging out an already revoked session again is not an error
+func (u *UserServiceImpl) LogOutUser(ctx context.Context, userID string, request *dto.RefreshTokenRequest) error {
+	token, err := u.userRepository.FindRefreshToken(ctx, hashToken(request.RefreshToken))
+	if err != nil {
+		if err == utils.ErrRefreshTokenNotFound {
+			return utils.ErrInvalidRefreshToken
+		}
+
+		return err
+	}
+
+	if token.UserID != userID {
+		return utils.ErrInvalidRefreshToken
+	}
+
+	err = u.userRepository.RevokeRefreshToken(ctx, token.ID, time.Now())
+	if err != nil && err != utils.ErrRefreshTokenNotFound {
+		return err
+	}
+
+	return nil
+}
+
+// LogOutAllSessions end every session of the user, every refresh token is revoked and the access token is rejected
+func (u *UserServiceImpl) LogOutAllSessions(ctx context.Context, userID string) error {
+	return u.userRepository.RevokeUserTokens(ctx, userID, time.Now())
+}
+
+// CheckUserActive make sure the owner of the token still exist, is not deactivated, and didn't revoke the token. The
+// password change required is checked last, so the other check already passed when it is returned
+func (u *UserServiceImpl) CheckUserActive(ctx context.Context, userID string, issuedAt time.Time) error {
+	user, err := u.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.DeactivatedAt.IsZero() {
+		return utils.ErrUserDeactivated
+	}
+
+	// both time is in millisecond, so the token issued earlier in the same second as the revocation is still rejected
+	if issuedAt.Before(user.TokensValidAt) {
+		return utils.ErrTokenRevoked
+	}
+
+	if user.MustChangePassword {
+		return utils.ErrPasswordChangeRequired
+	}
+
+	return nil
+}
+
+func (u *UserServiceImpl) issueTokens(ctx context.Context, user *entity.User) (*dto.TokenResponse, error) {
+	permissions, err := u.userRepository.GetRolePermissions(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := u.jwtService.GenerateToken(user, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := newRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.userRepository.CreateRefreshToken(ctx, &entity.RefreshToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(u.refreshTokenExp),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TokenResponse{
+		Token:              accessToken,
+		RefreshToken:       refreshToken,
+		MustChangePassword: user.MustChangePassword,
+	}, nil
+}
+
+func newRandomToken() (string, error) {
+	buf := make([]byte, tokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken hash the random token before it is stored, the token is random so it didn't need a slow hash
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
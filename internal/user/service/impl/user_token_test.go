@@ -0,0 +1,235 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/suryaadi44/eAD-System/internal/user/dto"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+func (t *TestSuiteUserService) TestRefreshToken_Success() {
+	t.mockUserRepository.On("FindRefreshToken", mock.Anything, hashToken("refresh")).Return(&entity.RefreshToken{
+		ID:        "tokenid",
+		UserID:    "userid",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+	t.mockUserRepository.On("FindByID", mock.Anything, "userid").Return(&entity.User{ID: "userid", Role: 2}, nil)
+	t.mockUserRepository.On("RevokeRefreshToken", mock.Anything, "tokenid", mock.AnythingOfType("time.Time")).Return(nil)
+	t.mockUserRepository.On("GetRolePermissions", mock.Anything, 2).Return([]string{"document.verify"}, nil)
+	t.mockJWTService.On("GenerateToken", mock.Anything, []string{"document.verify"}).Return("token", nil)
+	t.mockUserRepository.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(token *entity.RefreshToken) bool {
+		return token.UserID == "userid" && token.TokenHash != hashToken("refresh")
+	})).Return(nil)
+
+	resp, err := t.userService.RefreshToken(context.Background(), &dto.RefreshTokenRequest{RefreshToken: "refresh"})
+
+	t.NoError(err)
+	t.Equal("token", resp.Token)
+	t.NotEqual("refresh", resp.RefreshToken)
+	t.mockUserRepository.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestRefreshToken_Failed() {
+	for _, tc := range []struct {
+		Name        string
+		Token       *entity.RefreshToken
+		FindErr     error
+		User        *entity.User
+		FindUserErr error
+		RevokeErr   error
+		ExpectedErr error
+	}{
+		{
+			Name:        "Unknown token",
+			Token:       (*entity.RefreshToken)(nil),
+			FindErr:     utils.ErrRefreshTokenNotFound,
+			ExpectedErr: utils.ErrInvalidRefreshToken,
+		},
+		{
+			Name:        "Generic error",
+			Token:       (*entity.RefreshToken)(nil),
+			FindErr:     errors.New("error"),
+			ExpectedErr: errors.New("error"),
+		},
+		{
+			Name:        "Expired token",
+			Token:       &entity.RefreshToken{ID: "tokenid", UserID: "userid", ExpiresAt: time.Now().Add(-time.Minute)},
+			ExpectedErr: utils.ErrInvalidRefreshToken,
+		},
+		{
+			Name:        "Deleted user",
+			Token:       &entity.RefreshToken{ID: "tokenid", UserID: "userid", ExpiresAt: time.Now().Add(time.Hour)},
+			User:        (*entity.User)(nil),
+			FindUserErr: utils.ErrUserNotFound,
+			ExpectedErr: utils.ErrInvalidRefreshToken,
+		},
+		{
+			Name:        "Deactivated user",
+			Token:       &entity.RefreshToken{ID: "tokenid", UserID: "userid", ExpiresAt: time.Now().Add(time.Hour)},
+			User:        &entity.User{ID: "userid", DeactivatedAt: time.Now()},
+			ExpectedErr: utils.ErrUserDeactivated,
+		},
+		{
+			Name:        "Token rotated by concurrent request",
+			Token:       &entity.RefreshToken{ID: "tokenid", UserID: "userid", ExpiresAt: time.Now().Add(time.Hour)},
+			User:        &entity.User{ID: "userid"},
+			RevokeErr:   utils.ErrRefreshTokenNotFound,
+			ExpectedErr: utils.ErrInvalidRefreshToken,
+		},
+	} {
+		t.Run(tc.Name, func() {
+			t.SetupTest()
+			t.mockUserRepository.On("FindRefreshToken", mock.Anything, hashToken("refresh")).Return(tc.Token, tc.FindErr)
+			t.mockUserRepository.On("FindByID", mock.Anything, "userid").Return(tc.User, tc.FindUserErr)
+			t.mockUserRepository.On("RevokeRefreshToken", mock.Anything, "tokenid", mock.AnythingOfType("time.Time")).Return(tc.RevokeErr)
+
+			_, err := t.userService.RefreshToken(context.Background(), &dto.RefreshTokenRequest{RefreshToken: "refresh"})
+
+			t.Equal(tc.ExpectedErr, err)
+			t.mockJWTService.AssertNotCalled(t.T(), "GenerateToken", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func (t *TestSuiteUserService) TestRefreshToken_ReusedToken() {
+	t.mockUserRepository.On("FindRefreshToken", mock.Anything, hashToken("refresh")).Return(&entity.RefreshToken{
+		ID:        "tokenid",
+		UserID:    "userid",
+		ExpiresAt: time.Now().Add(time.Hour),
+		RevokedAt: time.Now().Add(-time.Minute),
+	}, nil)
+	t.mockUserRepository.On("RevokeUserTokens", mock.Anything, "userid", mock.AnythingOfType("time.Time")).Return(nil)
+
+	_, err := t.userService.RefreshToken(context.Background(), &dto.RefreshTokenRequest{RefreshToken: "refresh"})
+
+	t.Equal(utils.ErrInvalidRefreshToken, err)
+	t.mockUserRepository.AssertExpectations(t.T())
+	t.mockJWTService.AssertNotCalled(t.T(), "GenerateToken", mock.Anything, mock.Anything)
+}
+
+func (t *TestSuiteUserService) TestLogOutUser() {
+	for _, tc := range []struct {
+		Name        string
+		Token       *entity.RefreshToken
+		FindErr     error
+		RevokeErr   error
+		ExpectedErr error
+	}{
+		{
+			Name:  "Success",
+			Token: &entity.RefreshToken{ID: "tokenid", UserID: "userid"},
+		},
+		{
+			Name:      "Already revoked",
+			Token:     &entity.RefreshToken{ID: "tokenid", UserID: "userid"},
+			RevokeErr: utils.ErrRefreshTokenNotFound,
+		},
+		{
+			Name:        "Unknown token",
+			Token:       (*entity.RefreshToken)(nil),
+			FindErr:     utils.ErrRefreshTokenNotFound,
+			ExpectedErr: utils.ErrInvalidRefreshToken,
+		},
+		{
+			Name:        "Token of another user",
+			Token:       &entity.RefreshToken{ID: "tokenid", UserID: "otheruser"},
+			ExpectedErr: utils.ErrInvalidRefreshToken,
+		},
+		{
+			Name:        "Generic error",
+			Token:       &entity.RefreshToken{ID: "tokenid", UserID: "userid"},
+			RevokeErr:   errors.New("error"),
+			ExpectedErr: errors.New("error"),
+		},
+	} {
+		t.Run(tc.Name, func() {
+			t.SetupTest()
+			t.mockUserRepository.On("FindRefreshToken", mock.Anything, hashToken("refresh")).Return(tc.Token, tc.FindErr)
+			t.mockUserRepository.On("RevokeRefreshToken", mock.Anything, "tokenid", mock.AnythingOfType("time.Time")).Return(tc.RevokeErr)
+
+			err := t.userService.LogOutUser(context.Background(), "userid", &dto.RefreshTokenRequest{RefreshToken: "refresh"})
+
+			t.Equal(tc.ExpectedErr, err)
+			t.mockUserRepository.AssertNotCalled(t.T(), "RevokeUserTokens", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func (t *TestSuiteUserService) TestLogOutAllSessions() {
+	t.mockUserRepository.On("RevokeUserTokens", mock.Anything, "userid", mock.AnythingOfType("time.Time")).Return(nil)
+
+	err := t.userService.LogOutAllSessions(context.Background(), "userid")
+
+	t.NoError(err)
+	t.mockUserRepository.AssertExpectations(t.T())
+}
+
+func (t *TestSuiteUserService) TestCheckUserActive() {
+	now := time.Now()
+	for _, tc := range []struct {
+		Name        string
+		User        *entity.User
+		Err         error
+		IssuedAt    time.Time
+		ExpectedErr error
+	}{
+		{
+			Name:     "Active",
+			User:     &entity.User{ID: "userid"},
+			IssuedAt: now,
+		},
+		{
+			Name:     "Issued after revocation",
+			User:     &entity.User{ID: "userid", TokensValidAt: now.Add(-time.Minute)},
+			IssuedAt: now,
+		},
+		{
+			Name:     "Issued in the same millisecond as revocation",
+			User:     &entity.User{ID: "userid", TokensValidAt: now.Truncate(time.Millisecond)},
+			IssuedAt: now.Truncate(time.Millisecond),
+		},
+		{
+			Name:        "Issued earlier in the same second as revocation",
+			User:        &entity.User{ID: "userid", TokensValidAt: now.Truncate(time.Second).Add(500 * time.Millisecond)},
+			IssuedAt:    now.Truncate(time.Second).Add(200 * time.Millisecond),
+			ExpectedErr: utils.ErrTokenRevoked,
+		},
+		{
+			Name:        "Issued before revocation",
+			User:        &entity.User{ID: "userid", TokensValidAt: now},
+			IssuedAt:    now.Add(-time.Minute),
+			ExpectedErr: utils.ErrTokenRevoked,
+		},
+		{
+			Name:        "Password change required",
+			User:        &entity.User{ID: "userid", MustChangePassword: true},
+			IssuedAt:    now,
+			ExpectedErr: utils.ErrPasswordChangeRequired,
+		},
+		{
+			Name:        "Deactivated",
+			User:        &entity.User{ID: "userid", DeactivatedAt: now, MustChangePassword: true},
+			IssuedAt:    now,
+			ExpectedErr: utils.ErrUserDeactivated,
+		},
+		{
+			Name:        "Not found",
+			User:        (*entity.User)(nil),
+			Err:         utils.ErrUserNotFound,
+			ExpectedErr: utils.ErrUserNotFound,
+		},
+	} {
+		t.Run(tc.Name, func() {
+			t.SetupTest()
+			t.mockUserRepository.On("FindByID", mock.Anything, "userid").Return(tc.User, tc.Err)
+
+			err := t.userService.CheckUserActive(context.Background(), "userid", tc.IssuedAt)
+
+			t.Equal(tc.ExpectedErr, err)
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"github.com/stretchr/testify/mock"
 	"github.com/suryaadi44/eAD-System/internal/user/dto"
+	"io"
+	"time"
 )
 
 type MockUserService struct {
@@ -14,9 +16,9 @@ func (m *MockUserService) SignUpUser(ctx context.Context, user *dto.UserSignUpRe
 	args := m.Called(ctx, user)
 	return args.Error(0)
 }
-func (m *MockUserService) LogInUser(ctx context.Context, user *dto.UserLoginRequest) (string, error) {
+func (m *MockUserService) LogInUser(ctx context.Context, user *dto.UserLoginRequest) (*dto.TokenResponse, error) {
 	args := m.Called(ctx, user)
-	return args.String(0), args.Error(1)
+	return args.Get(0).(*dto.TokenResponse), args.Error(1)
 }
 
 func (m *MockUserService) GetBriefUsers(ctx context.Context, page int, limit int) (*dto.BriefUsersResponse, error) {
@@ -28,3 +30,82 @@ func (m *MockUserService) UpdateUser(ctx context.Context, userID string, request
 	args := m.Called(ctx, userID, request)
 	return args.Error(0)
 }
+
+func (m *MockUserService) UpdateSignatureImage(ctx context.Context, userID string, request *dto.SignatureImageRequest, image io.Reader) error {
+	args := m.Called(ctx, userID, request, image)
+	return args.Error(0)
+}
+
+func (m *MockUserService) UpdateStampImage(ctx context.Context, userID string, request *dto.SignatureImageRequest, image io.Reader) error {
+	args := m.Called(ctx, userID, request, image)
+	return args.Error(0)
+}
+
+func (m *MockUserService) CreateEmployee(ctx context.Context, request *dto.EmployeeCreateRequest) (string, error) {
+	args := m.Called(ctx, request)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserService) GetUser(ctx context.Context, userID string) (*dto.UserResponse, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*dto.UserResponse), args.Error(1)
+}
+
+func (m *MockUserService) ChangeUserRole(ctx context.Context, userID string, request *dto.UserRoleRequest) error {
+	args := m.Called(ctx, userID, request)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ResetUserPassword(ctx context.Context, userID string, request *dto.UserPasswordRequest) error {
+	args := m.Called(ctx, userID, request)
+	return args.Error(0)
+}
+
+func (m *MockUserService) DeactivateUser(ctx context.Context, requesterID string, userID string) error {
+	args := m.Called(ctx, requesterID, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ReactivateUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) CheckUserActive(ctx context.Context, userID string, issuedAt time.Time) error {
+	args := m.Called(ctx, userID, issuedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RefreshToken(ctx context.Context, request *dto.RefreshTokenRequest) (*dto.TokenResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(*dto.TokenResponse), args.Error(1)
+}
+
+func (m *MockUserService) LogOutUser(ctx context.Context, userID string, request *dto.RefreshTokenRequest) error {
+	args := m.Called(ctx, userID, request)
+	return args.Error(0)
+}
+
+func (m *MockUserService) LogOutAllSessions(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ChangePassword(ctx context.Context, userID string, request *dto.UserChangePasswordRequest) error {
+	args := m.Called(ctx, userID, request)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ForgotPassword(ctx context.Context, request *dto.ForgotPasswordRequest) {
+	m.Called(ctx, request)
+}
+
+func (m *MockUserService) ResetPassword(ctx context.Context, request *dto.ResetPasswordRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockUserService) CreateInitialAdmin(ctx context.Context, request *dto.AdminCreateRequest) (string, error) {
+	args := m.Called(ctx, request)
+	return args.String(0), args.Error(1)
+}
@@ -3,11 +3,29 @@ package service
 import (
 	"context"
 	"github.com/suryaadi44/eAD-System/internal/user/dto"
+	"io"
+	"time"
 )
 
 type UserService interface {
 	SignUpUser(ctx context.Context, user *dto.UserSignUpRequest) error
-	LogInUser(ctx context.Context, user *dto.UserLoginRequest) (string, error)
+	LogInUser(ctx context.Context, user *dto.UserLoginRequest) (*dto.TokenResponse, error)
+	RefreshToken(ctx context.Context, request *dto.RefreshTokenRequest) (*dto.TokenResponse, error)
+	LogOutUser(ctx context.Context, userID string, request *dto.RefreshTokenRequest) error
+	LogOutAllSessions(ctx context.Context, userID string) error
 	GetBriefUsers(ctx context.Context, page int, limit int) (*dto.BriefUsersResponse, error)
 	UpdateUser(ctx context.Context, userID string, request *dto.UserUpdateRequest) error
+	ChangePassword(ctx context.Context, userID string, request *dto.UserChangePasswordRequest) error
+	ForgotPassword(ctx context.Context, request *dto.ForgotPasswordRequest)
+	ResetPassword(ctx context.Context, request *dto.ResetPasswordRequest) error
+	UpdateSignatureImage(ctx context.Context, userID string, request *dto.SignatureImageRequest, image io.Reader) error
+	UpdateStampImage(ctx context.Context, userID string, request *dto.SignatureImageRequest, image io.Reader) error
+	CreateInitialAdmin(ctx context.Context, request *dto.AdminCreateRequest) (string, error)
+	CreateEmployee(ctx context.Context, request *dto.EmployeeCreateRequest) (string, error)
+	GetUser(ctx context.Context, userID string) (*dto.UserResponse, error)
+	ChangeUserRole(ctx context.Context, userID string, request *dto.UserRoleRequest) error
+	ResetUserPassword(ctx context.Context, userID string, request *dto.UserPasswordRequest) error
+	DeactivateUser(ctx context.Context, requesterID string, userID string) error
+	ReactivateUser(ctx context.Context, userID string) error
+	CheckUserActive(ctx context.Context, userID string, issuedAt time.Time) error
 }
@@ -1,6 +1,8 @@
 package bootsrapper
 
 import (
+	"context"
+	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	documentControllerPkg "github.com/suryaadi44/eAD-System/internal/document/controller"
 	documentRepositoryPkg "github.com/suryaadi44/eAD-System/internal/document/repository/impl"
@@ -9,41 +11,252 @@ import (
 	templateRepositoryPkg "github.com/suryaadi44/eAD-System/internal/template/repository/impl"
 	templateServicePkg "github.com/suryaadi44/eAD-System/internal/template/service/impl"
 	userControllerPkg "github.com/suryaadi44/eAD-System/internal/user/controller"
+	userDto "github.com/suryaadi44/eAD-System/internal/user/dto"
+	userRepo "github.com/suryaadi44/eAD-System/internal/user/repository"
 	userRepositoryPkg "github.com/suryaadi44/eAD-System/internal/user/repository/impl"
+	userSvc "github.com/suryaadi44/eAD-System/internal/user/service"
 	userServicePkg "github.com/suryaadi44/eAD-System/internal/user/service/impl"
 	"github.com/suryaadi44/eAD-System/pkg/routes"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
 	renderServicePkg "github.com/suryaadi44/eAD-System/pkg/utils/html/impl"
+	"github.com/suryaadi44/eAD-System/pkg/utils/jwt_service"
 	jwtPkg "github.com/suryaadi44/eAD-System/pkg/utils/jwt_service/impl"
+	"github.com/suryaadi44/eAD-System/pkg/utils/notifier"
+	notifierPkg "github.com/suryaadi44/eAD-System/pkg/utils/notifier/impl"
+	"github.com/suryaadi44/eAD-System/pkg/utils/password"
 	passwordPkg "github.com/suryaadi44/eAD-System/pkg/utils/password/impl"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
 	pdfPkg "github.com/suryaadi44/eAD-System/pkg/utils/pdf/impl"
 	qrPkg "github.com/suryaadi44/eAD-System/pkg/utils/qr/impl"
+	"github.com/suryaadi44/eAD-System/pkg/utils/renderer"
+	rendererPkg "github.com/suryaadi44/eAD-System/pkg/utils/renderer/impl"
+	sanitizerPkg "github.com/suryaadi44/eAD-System/pkg/utils/sanitizer/impl"
+	signaturePkg "github.com/suryaadi44/eAD-System/pkg/utils/signature/impl"
+	"github.com/suryaadi44/eAD-System/pkg/utils/storage"
+	storagePkg "github.com/suryaadi44/eAD-System/pkg/utils/storage/impl"
+	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 func InitController(e *echo.Echo, db *gorm.DB, conf map[string]string) {
-	qrCodeService := qrPkg.NewCodeServiceImpl(conf["QR_PATH"])
-	renderService := renderServicePkg.NewRenderServiceImpl(qrCodeService)
-	passwordFunc := passwordPkg.NewPasswordFuncImpl()
-	pdfService := pdfPkg.NewPDFService()
-	jwtService := jwtPkg.NewJWTService(conf["JWT_SECRET"], 1*time.Hour)
+	maxTemplateSize, err := strconv.ParseInt(conf["TEMPLATE_MAX_SIZE"], 10, 64)
+	if err != nil {
+		panic(err)
+	}
+
+	maxBundleSize, err := strconv.ParseInt(conf["TEMPLATE_BUNDLE_MAX_SIZE"], 10, 64)
+	if err != nil {
+		panic(err)
+	}
+
+	renderTimeout, err := time.ParseDuration(conf["PDF_RENDER_TIMEOUT"])
+	if err != nil {
+		panic(err)
+	}
+
+	renderWorkers, err := strconv.Atoi(conf["RENDER_WORKERS"])
+	if err != nil {
+		panic(err)
+	}
+
+	renderQueueSize, err := strconv.Atoi(conf["RENDER_QUEUE_SIZE"])
+	if err != nil {
+		panic(err)
+	}
+
+	renderWait, err := time.ParseDuration(conf["RENDER_WAIT_TIMEOUT"])
+	if err != nil {
+		panic(err)
+	}
+
+	renderJobRetention, err := time.ParseDuration(conf["RENDER_JOB_RETENTION"])
+	if err != nil {
+		panic(err)
+	}
+
+	pdfCacheTTL, err := time.ParseDuration(conf["PDF_CACHE_TTL"])
+	if err != nil {
+		panic(err)
+	}
+
+	thumbnailWidth, err := strconv.Atoi(conf["THUMBNAIL_WIDTH"])
+	if err != nil {
+		panic(err)
+	}
+
+	allowedAssetHosts := splitList(conf["ALLOWED_ASSET_HOSTS"])
+
+	trustAnchor, err := signaturePkg.LoadTrustAnchor(conf["SIGNATURE_TRUST_ANCHOR"])
+	if err != nil {
+		panic(err)
+	}
+
+	qrSigningKey, err := qrPkg.LoadSigningKey(conf["QR_SIGNING_KEY"])
+	if err != nil {
+		panic(err)
+	}
+
+	artifactStorage := storagePkg.NewFileStorageImpl(conf["STORAGE_PATH"])
+	qrCodeService := qrPkg.NewCodeServiceImpl(conf["QR_PATH"], qrSigningKey)
+	renderService := renderServicePkg.NewRenderServiceImpl(qrCodeService, artifactStorage, conf["OFFICE_NAME"], conf["OFFICE_LOGO"])
+	pdfService, err := newPDFService(conf["PDF_BACKEND"], renderTimeout, allowedAssetHosts)
+	if err != nil {
+		panic(err)
+	}
+	rendererRegistry := newRendererRegistry(pdfPkg.NewNativeThumbnailService(renderTimeout), thumbnailWidth)
+	templateSanitizer := sanitizerPkg.NewTemplateSanitizerImpl(allowedAssetHosts)
+	pdfSigner := signaturePkg.NewPAdESSignerImpl(conf["KEYSTORE_PATH"], conf["KEYSTORE_PASSWORD"])
+	pdfVerifier := signaturePkg.NewPAdESVerifierImpl(trustAnchor)
 
 	// User
-	userRepository := userRepositoryPkg.NewUserRepositoryImpl(db)
-	userService := userServicePkg.NewUserServiceImpl(userRepository, passwordFunc, jwtService)
+	userRepository, userService, jwtService := newUserService(db, conf, artifactStorage)
+	if conf["ADMIN_USERNAME"] != "" {
+		createInitialAdmin(userService, conf["ADMIN_USERNAME"], conf["ADMIN_PASSWORD"])
+	}
 	userController := userControllerPkg.NewUserController(userService, jwtService)
 
 	// Template
 	templateRepository := templateRepositoryPkg.NewTemplateRepositoryImpl(db)
-	templateService := templateServicePkg.NewTemplateServiceImpl(templateRepository)
+	templateService := templateServicePkg.NewTemplateServiceImpl(templateRepository, templateSanitizer, maxTemplateSize, maxBundleSize)
 	templateController := templateControllerPkg.NewTemplateController(templateService, jwtService)
 
 	// Document
 	documentRepository := documentRepositoryPkg.NewDocumentRepositoryImpl(db)
-	documentService := documentServicePkg.NewDocumentServiceImpl(documentRepository, templateRepository, pdfService, renderService)
+	documentService := documentServicePkg.NewDocumentServiceImpl(documentRepository, templateRepository, userRepository, pdfService, renderService, artifactStorage, pdfSigner, pdfVerifier, qrCodeService, rendererRegistry, pdfPkg.NewPDFMergerImpl(), renderWorkers, renderQueueSize, renderWait, pdfCacheTTL, renderJobRetention)
+	if err = documentService.StartRenderWorkers(context.Background()); err != nil {
+		panic(err)
+	}
 	documentController := documentControllerPkg.NewDocumentController(documentService, jwtService)
 
-	route := routes.NewRoutes(userController, templateController, documentController)
+	route := routes.NewRoutes(userController, templateController, documentController, jwtService)
 	route.Init(e, conf)
 }
+
+// CreateInitialAdmin create the first admin from the command line, it is refused when there is already an admin
+func CreateInitialAdmin(db *gorm.DB, conf map[string]string, request *userDto.AdminCreateRequest) (string, error) {
+	if err := validator.New().Struct(request); err != nil {
+		return "", err
+	}
+
+	_, userService, _ := newUserService(db, conf, storagePkg.NewFileStorageImpl(conf["STORAGE_PATH"]))
+	return userService.CreateInitialAdmin(context.Background(), request)
+}
+
+func newUserService(db *gorm.DB, conf map[string]string, artifactStorage storage.ArtifactStorage) (userRepo.UserRepository, userSvc.UserService, jwt_service.JWTService) {
+	accessTokenExp, err := time.ParseDuration(conf["ACCESS_TOKEN_TTL"])
+	if err != nil {
+		panic(err)
+	}
+
+	refreshTokenExp, err := time.ParseDuration(conf["REFRESH_TOKEN_TTL"])
+	if err != nil {
+		panic(err)
+	}
+
+	resetTokenExp, err := time.ParseDuration(conf["PASSWORD_RESET_TTL"])
+	if err != nil {
+		panic(err)
+	}
+
+	passwordMinLength, err := strconv.Atoi(conf["PASSWORD_MIN_LENGTH"])
+	if err != nil {
+		panic(err)
+	}
+
+	passwordHistory, err := strconv.Atoi(conf["PASSWORD_HISTORY"])
+	if err != nil {
+		panic(err)
+	}
+
+	passwordPolicy := password.Policy{
+		MinLength: passwordMinLength,
+		History:   passwordHistory,
+	}
+
+	userNotifier, err := newNotifier(conf)
+	if err != nil {
+		panic(err)
+	}
+
+	jwtService := jwtPkg.NewJWTService(conf["JWT_SECRET"], accessTokenExp)
+	repository := userRepositoryPkg.NewUserRepositoryImpl(db)
+	service := userServicePkg.NewUserServiceImpl(repository, passwordPkg.NewPasswordFuncImpl(), jwtService, artifactStorage, refreshTokenExp, userNotifier, passwordPolicy, resetTokenExp, conf["PASSWORD_RESET_URL"])
+
+	return repository, service, jwtService
+}
+
+// createInitialAdmin create the first admin from ADMIN_USERNAME and ADMIN_PASSWORD, the variable can stay set since it
+// is ignored once there is an admin
+func createInitialAdmin(userService userSvc.UserService, username string, password string) {
+	request := &userDto.AdminCreateRequest{
+		Username: username,
+		Password: password,
+	}
+	if err := validator.New().Struct(request); err != nil {
+		panic(err)
+	}
+
+	_, err := userService.CreateInitialAdmin(context.Background(), request)
+	switch err {
+	case nil:
+		log.Printf("initial admin %s is created, the password must be changed on the first login", username)
+	case utils.ErrAdminAlreadyExist:
+	default:
+		panic(err)
+	}
+}
+
+// newNotifier create the notifier chosen in the config, the log notifier is the stand in of SMTP on local development.
+// There is no default, so the production deployment never write the reset token to the log without choosing it
+func newNotifier(conf map[string]string) (notifier.Notifier, error) {
+	switch conf["NOTIFIER"] {
+	case "":
+		return nil, utils.ErrNotifierNotSet
+	case notifier.NotifierLog:
+		return notifierPkg.NewLogNotifierImpl(conf["NOTIFIER_LOG_PATH"]), nil
+	case notifier.NotifierSMTP:
+		return notifierPkg.NewSMTPNotifierImpl(conf["SMTP_HOST"], conf["SMTP_PORT"], conf["SMTP_USERNAME"], conf["SMTP_PASSWORD"], conf["SMTP_FROM"]), nil
+	default:
+		return nil, utils.ErrUnknownNotifier
+	}
+}
+
+// newPDFService create the PDF backend chosen in the config, the native backend didn't need wkhtmltopdf installed
+func newPDFService(backend string, timeout time.Duration, allowedAssetHosts []string) (pdf.PDFService, error) {
+	switch backend {
+	case pdf.BackendWkhtmltopdf:
+		return pdfPkg.NewPDFService(timeout, allowedAssetHosts), nil
+	case pdf.BackendNative:
+		return pdfPkg.NewNativePDFService(timeout), nil
+	default:
+		return nil, utils.ErrUnknownPDFBackend
+	}
+}
+
+// newRendererRegistry register the output format served by the render endpoint, the PNG thumbnail is always drawn by
+// the native layout so it didn't depend on the PDF backend
+func newRendererRegistry(thumbnailService pdf.ThumbnailService, thumbnailWidth int) renderer.Registry {
+	registry := rendererPkg.NewRegistryImpl()
+	registry.Register(renderer.FormatPDF, rendererPkg.NewPDFRenderer())
+	registry.Register(renderer.FormatHTML, rendererPkg.NewHTMLRenderer())
+	registry.Register(renderer.FormatDOCX, rendererPkg.NewDOCXRenderer())
+	registry.Register(renderer.FormatPNG, rendererPkg.NewPNGRenderer(thumbnailService, thumbnailWidth))
+
+	return registry
+}
+
+// splitList split comma separated config value, ignoring empty item
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -1,8 +1,8 @@
 package config
 
 import (
-	"github.com/google/uuid"
 	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils/rbac"
 	"os"
 )
 
@@ -13,14 +13,49 @@ var (
 		"Approved",
 	}
 
-	DefaultUser = &entity.User{
-		ID:       uuid.New().String(),
-		Username: "admin",
-		Password: "admin",
-		Role:     3,
+	// DefaultRoles is created on start when it is missing and granted its permission again, the role id follow the order
+	// so the existing user keep their role
+	DefaultRoles = []entity.Role{
+		{
+			Name:        "Applicant",
+			Permissions: newPermissions(rbac.PermissionDocumentCreate),
+		},
+		{
+			Name: "Verifier",
+			Permissions: newPermissions(rbac.PermissionDocumentCreate, rbac.PermissionDocumentReadAll, rbac.PermissionDocumentUpdate,
+				rbac.PermissionDocumentDelete, rbac.PermissionDocumentVerify, rbac.PermissionDocumentExport,
+				rbac.PermissionTemplateManage, rbac.PermissionUserRead),
+		},
+		{
+			Name: "Admin",
+			Permissions: newPermissions(rbac.PermissionDocumentCreate, rbac.PermissionDocumentReadAll, rbac.PermissionDocumentUpdate,
+				rbac.PermissionDocumentDelete, rbac.PermissionDocumentVerify, rbac.PermissionDocumentSign,
+				rbac.PermissionDocumentRevoke, rbac.PermissionDocumentExport, rbac.PermissionTemplateManage,
+				rbac.PermissionUserRead, rbac.PermissionUserManage),
+		},
 	}
+
+	// DefaultSignUpRole is the role of the self registered user, the applicant
+	DefaultSignUpRole = 1
+
+	// DefaultAdminRole is the role of the initial admin created from ADMIN_USERNAME and ADMIN_PASSWORD
+	DefaultAdminRole = 3
+
+	// LegacyDefaultUsername and LegacyDefaultPassword is the admin seeded by the older version with unhashed password,
+	// it can't log in so it is removed on start
+	LegacyDefaultUsername = "admin"
+	LegacyDefaultPassword = "admin"
 )
 
+func newPermissions(names ...string) []entity.Permission {
+	permissions := make([]entity.Permission, 0, len(names))
+	for _, name := range names {
+		permissions = append(permissions, entity.Permission{Name: name})
+	}
+
+	return permissions
+}
+
 func LoadConfig() map[string]string {
 	env := make(map[string]string)
 
@@ -31,7 +66,48 @@ func LoadConfig() map[string]string {
 	env["DB_NAME"] = os.Getenv("DB_NAME")
 	env["PORT"] = os.Getenv("PORT")
 	env["JWT_SECRET"] = os.Getenv("JWT_SECRET")
+	env["ACCESS_TOKEN_TTL"] = getEnvOrDefault("ACCESS_TOKEN_TTL", "15m")
+	env["REFRESH_TOKEN_TTL"] = getEnvOrDefault("REFRESH_TOKEN_TTL", "720h")
+	env["PASSWORD_MIN_LENGTH"] = getEnvOrDefault("PASSWORD_MIN_LENGTH", "8")
+	env["PASSWORD_HISTORY"] = getEnvOrDefault("PASSWORD_HISTORY", "3")
+	env["PASSWORD_RESET_TTL"] = getEnvOrDefault("PASSWORD_RESET_TTL", "1h")
+	env["PASSWORD_RESET_URL"] = os.Getenv("PASSWORD_RESET_URL")
+	env["NOTIFIER"] = os.Getenv("NOTIFIER")
+	env["NOTIFIER_LOG_PATH"] = os.Getenv("NOTIFIER_LOG_PATH")
+	env["SMTP_HOST"] = os.Getenv("SMTP_HOST")
+	env["SMTP_PORT"] = getEnvOrDefault("SMTP_PORT", "587")
+	env["SMTP_USERNAME"] = os.Getenv("SMTP_USERNAME")
+	env["SMTP_PASSWORD"] = os.Getenv("SMTP_PASSWORD")
+	env["SMTP_FROM"] = os.Getenv("SMTP_FROM")
+	env["ADMIN_USERNAME"] = os.Getenv("ADMIN_USERNAME")
+	env["ADMIN_PASSWORD"] = os.Getenv("ADMIN_PASSWORD")
 	env["QR_PATH"] = os.Getenv("QR_PATH")
+	env["OFFICE_NAME"] = getEnvOrDefault("OFFICE_NAME", "eAD System")
+	env["OFFICE_LOGO"] = os.Getenv("OFFICE_LOGO")
+	env["TEMPLATE_MAX_SIZE"] = getEnvOrDefault("TEMPLATE_MAX_SIZE", "1048576")
+	env["TEMPLATE_BUNDLE_MAX_SIZE"] = getEnvOrDefault("TEMPLATE_BUNDLE_MAX_SIZE", "20971520")
+	env["PDF_BACKEND"] = getEnvOrDefault("PDF_BACKEND", "wkhtmltopdf")
+	env["PDF_RENDER_TIMEOUT"] = getEnvOrDefault("PDF_RENDER_TIMEOUT", "30s")
+	env["ALLOWED_ASSET_HOSTS"] = os.Getenv("ALLOWED_ASSET_HOSTS")
+	env["STORAGE_PATH"] = getEnvOrDefault("STORAGE_PATH", "./storage")
+	env["KEYSTORE_PATH"] = getEnvOrDefault("KEYSTORE_PATH", "./keystore")
+	env["KEYSTORE_PASSWORD"] = os.Getenv("KEYSTORE_PASSWORD")
+	env["SIGNATURE_TRUST_ANCHOR"] = os.Getenv("SIGNATURE_TRUST_ANCHOR")
+	env["QR_SIGNING_KEY"] = getEnvOrDefault("QR_SIGNING_KEY", "./keystore/qr_signing_key.pem")
+	env["RENDER_WORKERS"] = getEnvOrDefault("RENDER_WORKERS", "2")
+	env["RENDER_QUEUE_SIZE"] = getEnvOrDefault("RENDER_QUEUE_SIZE", "32")
+	env["RENDER_WAIT_TIMEOUT"] = getEnvOrDefault("RENDER_WAIT_TIMEOUT", "30s")
+	env["RENDER_JOB_RETENTION"] = getEnvOrDefault("RENDER_JOB_RETENTION", "24h")
+	env["PDF_CACHE_TTL"] = getEnvOrDefault("PDF_CACHE_TTL", "168h")
+	env["THUMBNAIL_WIDTH"] = getEnvOrDefault("THUMBNAIL_WIDTH", "320")
 
 	return env
 }
+
+func getEnvOrDefault(key string, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+
+	return defaultValue
+}
@@ -38,12 +38,18 @@ func Connect(dbHost string, dbPort string, dbUsername string, dbPassword string,
 
 func Migrate(db *gorm.DB) error {
 	return db.AutoMigrate(
+		&entity.Permission{},
+		&entity.Role{},
 		&entity.User{},
+		&entity.RefreshToken{},
+		&entity.PasswordResetToken{},
+		&entity.PasswordHistory{},
 		&entity.Template{},
 		&entity.TemplateField{},
 		&entity.Stage{},
 		&entity.Document{},
 		&entity.DocumentField{},
 		&entity.Register{},
+		&entity.RenderJob{},
 	)
 }
@@ -6,26 +6,30 @@ import (
 )
 
 type Document struct {
-	ID          string `gorm:"primaryKey; type:varchar(36)"`
-	RegisterID  uint   `gorm:"type:int;default:null"`
-	Register    Register
-	Description string `gorm:"type:varchar(255)"`
-	ApplicantID string `gorm:"type:varchar(36);not null"`
-	Applicant   User   `gorm:"foreignKey:ApplicantID"`
-	TemplateID  uint
-	Template    Template
-	Fields      DocumentFields
-	StageID     int            `gorm:"type:int;default:1"`
-	Stage       Stage          `gorm:"foreignKey:StageID"`
-	VerifierID  string         `gorm:"type:varchar(36);default:null"`
-	Verifier    User           `gorm:"foreignKey:VerifierID"`
-	VerifiedAt  time.Time      `gorm:"type:datetime;default:null"`
-	SignerID    string         `gorm:"type:varchar(36);default:null"`
-	Signer      User           `gorm:"foreignKey:SignerID"`
-	SignedAt    time.Time      `gorm:"type:datetime;default:null"`
-	CreatedAt   time.Time      `gorm:"autoCreateTime"`
-	UpdatedAt   time.Time      `gorm:"autoUpdateTime"`
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	ID            string `gorm:"primaryKey; type:varchar(36)"`
+	RegisterID    uint   `gorm:"type:int;default:null"`
+	Register      Register
+	Description   string `gorm:"type:varchar(255)"`
+	ApplicantID   string `gorm:"type:varchar(36);not null"`
+	Applicant     User   `gorm:"foreignKey:ApplicantID"`
+	TemplateID    uint
+	Template      Template
+	Fields        DocumentFields
+	StageID       int            `gorm:"type:int;default:1"`
+	Stage         Stage          `gorm:"foreignKey:StageID"`
+	VerifierID    string         `gorm:"type:varchar(36);default:null"`
+	Verifier      User           `gorm:"foreignKey:VerifierID"`
+	VerifiedAt    time.Time      `gorm:"type:datetime;default:null"`
+	SignerID      string         `gorm:"type:varchar(36);default:null"`
+	Signer        User           `gorm:"foreignKey:SignerID"`
+	SignedAt      time.Time      `gorm:"type:datetime;default:null"`
+	SignedPDFKey  string         `gorm:"type:varchar(255);default:null"`
+	SignedPDFHash string         `gorm:"type:varchar(64);default:null;index"`
+	RevokedAt     time.Time      `gorm:"type:datetime;default:null"`
+	RevokeReason  string         `gorm:"type:varchar(255);default:null"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
 }
 
 type Documents []Document
@@ -36,6 +40,7 @@ type DocumentField struct {
 	TemplateFieldID uint
 	TemplateField   TemplateField
 	Value           string
+	Rows            TableRows `gorm:"type:text;serializer:json"`
 }
 
 type DocumentFields []DocumentField
@@ -52,14 +57,39 @@ type Register struct {
 
 type Template struct {
 	gorm.Model
-	Name         string `gorm:"type:varchar(255);not null;uniqueIndex"`
-	Path         string `gorm:"type:varchar(255);not null;uniqueIndex"`
-	MarginTop    uint
-	MarginBottom uint
-	MarginLeft   uint
-	MarginRight  uint
-	IsActive     bool `gorm:"default:true"`
-	Fields       TemplateFields
+	Name          string `gorm:"type:varchar(255);not null;uniqueIndex"`
+	Path          string `gorm:"type:varchar(255);not null;uniqueIndex"`
+	BundlePath    string `gorm:"type:varchar(255)"`
+	HeaderPath    string `gorm:"type:varchar(255)"`
+	FooterPath    string `gorm:"type:varchar(255)"`
+	Version       uint   `gorm:"default:1"`
+	MarginTop     uint
+	MarginBottom  uint
+	MarginLeft    uint
+	MarginRight   uint
+	PageSize      string `gorm:"type:varchar(16);default:A4"`
+	PageWidth     uint
+	PageHeight    uint
+	Orientation   string  `gorm:"type:varchar(16);default:Portrait"`
+	DPI           uint    `gorm:"default:300"`
+	Grayscale     bool    `gorm:"default:false"`
+	Zoom          float64 `gorm:"default:1"`
+	QROnEveryPage bool    `gorm:"default:false"`
+	// signature field placement in millimeter from the top left of the page, zero page is the last page and zero
+	// width or height is an invisible signature
+	SignaturePage   uint
+	SignatureX      uint
+	SignatureY      uint
+	SignatureWidth  uint
+	SignatureHeight uint
+	// watermark printed over the document that is not yet signed or revoked, empty text use the default of the state
+	WatermarkDraft    string  `gorm:"type:varchar(64)"`
+	WatermarkUnsigned string  `gorm:"type:varchar(64)"`
+	WatermarkRevoked  string  `gorm:"type:varchar(64)"`
+	WatermarkOpacity  float64 `gorm:"default:0.15"`
+	WatermarkAngle    *int    `gorm:"default:45"` // nil use the default angle, so 0 can be stored
+	IsActive          bool    `gorm:"default:true"`
+	Fields            TemplateFields
 }
 
 type Templates []Template
@@ -68,6 +98,42 @@ type TemplateField struct {
 	gorm.Model
 	TemplateID uint
 	Key        string
+	Type       string       `gorm:"type:varchar(16);default:text"`
+	Columns    TableColumns `gorm:"type:text;serializer:json"`
+	Expression string       `gorm:"type:text"`
+	Condition  string       `gorm:"type:text"`
+}
+
+// IsComputed report whether the field value is computed from its expression instead of submitted by the applicant
+func (t *TemplateField) IsComputed() bool {
+	return t.Expression != ""
 }
 
 type TemplateFields []TemplateField
+
+// Template field type
+const (
+	FieldTypeText  = "text"
+	FieldTypeTable = "table"
+)
+
+// Table column type
+const (
+	ColumnTypeText   = "text"
+	ColumnTypeNumber = "number"
+	ColumnTypeDate   = "date"
+)
+
+type TableColumn struct {
+	Key      string `json:"key"`
+	Label    string `json:"label"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+}
+
+type TableColumns []TableColumn
+
+// TableRow map the column key to its value
+type TableRow map[string]string
+
+type TableRows []TableRow
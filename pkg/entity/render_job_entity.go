@@ -0,0 +1,41 @@
+package entity
+
+import "time"
+
+type RenderJob struct {
+	ID          string `gorm:"primaryKey; type:varchar(36)"`
+	DocumentID  string `gorm:"type:varchar(36);not null;index;uniqueIndex:idx_render_jobs_document_active"`
+	Status      string `gorm:"type:varchar(16);not null;index"`
+	ArtifactKey string `gorm:"type:varchar(255)"`
+	Error       string `gorm:"type:varchar(255)"`
+	// Active is true while the job is queued or running and null once it is finished, the unique index allow only one
+	// active job for each document since null is never a duplicate
+	Active     *bool     `gorm:"uniqueIndex:idx_render_jobs_document_active"`
+	FinishedAt time.Time `gorm:"type:datetime;default:null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+}
+
+type RenderJobs []RenderJob
+
+// Render job status
+const (
+	RenderJobQueued  = "queued"
+	RenderJobRunning = "running"
+	RenderJobDone    = "done"
+	RenderJobFailed  = "failed"
+)
+
+// IsFinished report whether the job is done or failed
+func (r *RenderJob) IsFinished() bool {
+	return r.Status == RenderJobDone || r.Status == RenderJobFailed
+}
+
+// SetActive set Active from the status of the job, it must be called before the job is saved
+func (r *RenderJob) SetActive() {
+	r.Active = nil
+	if !r.IsFinished() {
+		active := true
+		r.Active = &active
+	}
+}
@@ -6,20 +6,74 @@ import (
 )
 
 type User struct {
-	ID        string `gorm:"primaryKey; type:varchar(36)"`
-	NIP       string `gorm:"type:varchar(18);uniqueIndex"`
-	NIK       string `gorm:"type:varchar(16);uniqueIndex"`
-	Username  string `gorm:"type:varchar(255);not null;uniqueIndex"`
+	ID                 string `gorm:"primaryKey; type:varchar(36)"`
+	NIP                string `gorm:"type:varchar(18);uniqueIndex"`
+	NIK                string `gorm:"type:varchar(16);uniqueIndex"`
+	Username           string `gorm:"type:varchar(255);not null;uniqueIndex"`
+	Email              string `gorm:"type:varchar(255)"` // address the password reset token is sent to
+	Password           string `gorm:"type:varchar(255);not null"`
+	Role               int    // id of the user role
+	Position           string
+	Name               string
+	Telp               string
+	Sex                string         `gorm:"type:varchar(1)"`
+	Address            string         `gorm:"type:varchar(255)"`
+	Signature          SignatureImage `gorm:"embedded;embeddedPrefix:signature_"`
+	Stamp              SignatureImage `gorm:"embedded;embeddedPrefix:stamp_"`
+	DeactivatedAt      time.Time      `gorm:"type:datetime;default:null"`    // zero when the account is active
+	TokensValidAt      time.Time      `gorm:"type:datetime(3);default:null"` // token issued before this time is rejected
+	MustChangePassword bool           // set when the password is chosen by the admin
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	DeletedAt          gorm.DeletedAt `gorm:"index"`
+}
+
+type Users []User
+
+// RefreshToken is the server side session of the user, only the hash of the token is stored
+type RefreshToken struct {
+	ID        string    `gorm:"primaryKey; type:varchar(36)"`
+	UserID    string    `gorm:"type:varchar(36);index"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	RevokedAt time.Time `gorm:"type:datetime;default:null"`
+	CreatedAt time.Time
+}
+
+// PasswordResetToken is the single use token sent to the user who forgot their password, only its hash is stored
+type PasswordResetToken struct {
+	ID        string    `gorm:"primaryKey; type:varchar(36)"`
+	UserID    string    `gorm:"type:varchar(36);index"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    time.Time `gorm:"type:datetime;default:null"`
+	CreatedAt time.Time
+}
+
+// PasswordHistory is the hash of the password chosen by the user, it is kept to refuse the reuse of a recent password
+type PasswordHistory struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    string `gorm:"type:varchar(36);index"`
 	Password  string `gorm:"type:varchar(255);not null"`
-	Role      int
-	Position  string
-	Name      string
-	Telp      string
-	Sex       string `gorm:"type:varchar(1)"`
-	Address   string `gorm:"type:varchar(255)"`
 	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
-type Users []User
+// Role group the permission given to the user
+type Role struct {
+	ID          int          `gorm:"primaryKey; type:int"`
+	Name        string       `gorm:"type:varchar(255);not null;uniqueIndex"`
+	Permissions []Permission `gorm:"many2many:role_permissions"`
+}
+
+type Permission struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"type:varchar(255);not null;uniqueIndex"`
+}
+
+// SignatureImage is the uploaded image printed on the signature block, its size and offset is in millimeter
+type SignatureImage struct {
+	ImageKey string `gorm:"type:varchar(255)"`
+	Height   uint
+	OffsetX  int
+	OffsetY  int
+}
@@ -7,6 +7,8 @@ import (
 	documentControllerPkg "github.com/suryaadi44/eAD-System/internal/document/controller"
 	templateControllerPkg "github.com/suryaadi44/eAD-System/internal/template/controller"
 	userControllerPkg "github.com/suryaadi44/eAD-System/internal/user/controller"
+	"github.com/suryaadi44/eAD-System/pkg/utils/jwt_service"
+	"github.com/suryaadi44/eAD-System/pkg/utils/rbac"
 	"github.com/suryaadi44/eAD-System/pkg/utils/validation"
 )
 
@@ -14,13 +16,15 @@ type Routes struct {
 	userController     *userControllerPkg.UserController
 	templateController *templateControllerPkg.TemplateController
 	documentController *documentControllerPkg.DocumentController
+	jwtService         jwt_service.JWTService
 }
 
-func NewRoutes(userController *userControllerPkg.UserController, templateController *templateControllerPkg.TemplateController, documentController *documentControllerPkg.DocumentController) *Routes {
+func NewRoutes(userController *userControllerPkg.UserController, templateController *templateControllerPkg.TemplateController, documentController *documentControllerPkg.DocumentController, jwtService jwt_service.JWTService) *Routes {
 	return &Routes{
 		userController:     userController,
 		templateController: templateController,
 		documentController: documentController,
+		jwtService:         jwtService,
 	}
 }
 
@@ -34,30 +38,60 @@ func (r *Routes) Init(e *echo.Echo, conf map[string]string) {
 		SigningKey: []byte(conf["JWT_SECRET"]),
 	})
 
+	can := func(permissions ...string) echo.MiddlewareFunc {
+		return rbac.RequirePermission(r.jwtService, permissions...)
+	}
+
+	// the token of deactivated user is refused even before it expire, and the user who must change the password given
+	// by the admin can only change it or log out
+	auth := []echo.MiddlewareFunc{jwtMiddleware, r.userController.RequireActiveUser}
+	authAllowPasswordChange := []echo.MiddlewareFunc{jwtMiddleware, r.userController.RequireActiveUserAllowPasswordChange}
+
 	v1 := e.Group("/v1")
 
 	// Users
 	users := v1.Group("/users")
 	users.POST("/signup/", r.userController.SignUpUser)
 	users.POST("/login/", r.userController.LoginUser)
+	users.POST("/refresh/", r.userController.RefreshToken)
+	users.POST("/password/forgot/", r.userController.ForgotPassword)
+	users.POST("/password/reset/", r.userController.ResetPassword)
+	users.PUT("/password/", r.userController.ChangePassword, authAllowPasswordChange...)
+	users.POST("/logout/", r.userController.LogoutUser, authAllowPasswordChange...)
+	users.POST("/logout/all/", r.userController.LogoutAllSessions, authAllowPasswordChange...)
 
-	usersWithAuth := users.Group("", jwtMiddleware)
-	usersWithAuth.GET("/", r.userController.GetBriefUsers)
+	usersWithAuth := users.Group("", auth...)
+	usersWithAuth.GET("/", r.userController.GetBriefUsers, can(rbac.PermissionUserRead))
 	usersWithAuth.PUT("/", r.userController.UpdateUser)
+	usersWithAuth.POST("/employees/", r.userController.CreateEmployee, can(rbac.PermissionUserManage))
+	usersWithAuth.GET("/:user_id/", r.userController.GetUser, can(rbac.PermissionUserManage))
+	usersWithAuth.PUT("/:user_id/role/", r.userController.ChangeUserRole, can(rbac.PermissionUserManage))
+	usersWithAuth.PUT("/:user_id/password/", r.userController.ResetUserPassword, can(rbac.PermissionUserManage))
+	usersWithAuth.PATCH("/:user_id/deactivate/", r.userController.DeactivateUser, can(rbac.PermissionUserManage))
+	usersWithAuth.PATCH("/:user_id/reactivate/", r.userController.ReactivateUser, can(rbac.PermissionUserManage))
+	usersWithAuth.PUT("/:user_id/signature/", r.userController.UpdateSignatureImage)
+	usersWithAuth.PUT("/:user_id/stamp/", r.userController.UpdateStampImage, can(rbac.PermissionUserManage))
 
 	// Documents
 	documents := v1.Group("/documents")
 	documents.GET("/:document_id/status/", r.documentController.GetDocumentStatus)
+	documents.POST("/verify/", r.documentController.VerifyDocumentFile)
+	documents.GET("/qr/keys/", r.documentController.GetQRPublicKey)
 
-	documentsWithAuth := documents.Group("", jwtMiddleware)
-	documentsWithAuth.POST("/", r.documentController.AddDocument)
+	documentsWithAuth := documents.Group("", auth...)
+	documentsWithAuth.POST("/", r.documentController.AddDocument, can(rbac.PermissionDocumentCreate))
 	documentsWithAuth.GET("/", r.documentController.GetBriefDocument)
+	documentsWithAuth.POST("/export/", r.documentController.ExportDocuments, can(rbac.PermissionDocumentExport))
 	documentsWithAuth.GET("/:document_id/", r.documentController.GetDocument)
 	documentsWithAuth.GET("/:document_id/pdf/", r.documentController.GetPDFDocument)
-	documentsWithAuth.PATCH("/:document_id/verify/", r.documentController.VerifyDocument)
-	documentsWithAuth.PATCH("/:document_id/sign/", r.documentController.SignDocument)
+	documentsWithAuth.GET("/:document_id/render/", r.documentController.RenderDocument)
+	documentsWithAuth.GET("/jobs/:job_id/", r.documentController.GetRenderJob)
+	documentsWithAuth.GET("/jobs/:job_id/pdf/", r.documentController.GetRenderJobPDF)
+	documentsWithAuth.PATCH("/:document_id/verify/", r.documentController.VerifyDocument, can(rbac.PermissionDocumentVerify))
+	documentsWithAuth.PATCH("/:document_id/sign/", r.documentController.SignDocument, can(rbac.PermissionDocumentSign))
+	documentsWithAuth.PATCH("/:document_id/revoke/", r.documentController.RevokeDocument, can(rbac.PermissionDocumentRevoke))
 	documentsWithAuth.DELETE("/:document_id/", r.documentController.DeleteDocument)
-	documentsWithAuth.PUT("/:document_id/", r.documentController.UpdateDocument)
+	documentsWithAuth.PUT("/:document_id/", r.documentController.UpdateDocument, can(rbac.PermissionDocumentUpdate))
 	documentsWithAuth.PUT("/:document_id/fields/", r.documentController.UpdateDocumentFields)
 
 	// Templates
@@ -65,6 +99,6 @@ func (r *Routes) Init(e *echo.Echo, conf map[string]string) {
 	templates.GET("/", r.templateController.GetAllTemplate)
 	templates.GET("/:template_id/", r.templateController.GetTemplateDetail)
 
-	templatesWithAuth := templates.Group("", jwtMiddleware)
-	templatesWithAuth.POST("/", r.templateController.AddTemplate)
+	templatesWithAuth := templates.Group("", auth...)
+	templatesWithAuth.POST("/", r.templateController.AddTemplate, can(rbac.PermissionTemplateManage))
 }
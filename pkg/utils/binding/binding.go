@@ -0,0 +1,78 @@
+package binding
+
+import (
+	"strings"
+
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+)
+
+// ApplicantPrefix mark a template field that is filled from the applicant profile instead of the request
+const ApplicantPrefix = "applicant."
+
+// applicantResolver map the supported bound key to the applicant profile value
+var applicantResolver = map[string]func(applicant *entity.User) string{
+	"name":     func(applicant *entity.User) string { return applicant.Name },
+	"nik":      func(applicant *entity.User) string { return applicant.NIK },
+	"nip":      func(applicant *entity.User) string { return applicant.NIP },
+	"username": func(applicant *entity.User) string { return applicant.Username },
+	"position": func(applicant *entity.User) string { return applicant.Position },
+	"telp":     func(applicant *entity.User) string { return applicant.Telp },
+	"address":  func(applicant *entity.User) string { return applicant.Address },
+	"sex":      func(applicant *entity.User) string { return applicant.Sex },
+	"gender":   func(applicant *entity.User) string { return Gender(applicant.Sex) },
+}
+
+// IsApplicantField report whether the template field key is bound to the applicant profile
+func IsApplicantField(key string) bool {
+	return strings.HasPrefix(key, ApplicantPrefix)
+}
+
+// IsKnownApplicantField report whether the bound key can be resolved, eg: applicant.name
+func IsKnownApplicantField(key string) bool {
+	_, ok := applicantResolver[strings.TrimPrefix(key, ApplicantPrefix)]
+	return IsApplicantField(key) && ok
+}
+
+// ResolveApplicant return the applicant profile value of the bound key
+func ResolveApplicant(key string, applicant *entity.User) (string, bool) {
+	if !IsApplicantField(key) {
+		return "", false
+	}
+
+	resolve, ok := applicantResolver[strings.TrimPrefix(key, ApplicantPrefix)]
+	if !ok {
+		return "", false
+	}
+
+	return resolve(applicant), true
+}
+
+// Gender return the Indonesian name of the sex code stored in user profile
+func Gender(sex string) string {
+	switch strings.ToUpper(sex) {
+	case "L":
+		return "Laki-laki"
+	case "P":
+		return "Perempuan"
+	default:
+		return sex
+	}
+}
+
+// SetNested put the value into the map, bound key is stored as nested map so it can be accessed from template
+// as {{.applicant.name}}, other key is stored as is even if it contain dot
+func SetNested(m map[string]interface{}, key string, value interface{}) {
+	if !IsApplicantField(key) {
+		m[key] = value
+		return
+	}
+
+	prefix := strings.TrimSuffix(ApplicantPrefix, ".")
+	child, ok := m[prefix].(map[string]interface{})
+	if !ok {
+		child = make(map[string]interface{})
+		m[prefix] = child
+	}
+
+	child[strings.TrimPrefix(key, ApplicantPrefix)] = value
+}
@@ -0,0 +1,55 @@
+package binding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+)
+
+func TestResolveApplicant(t *testing.T) {
+	applicant := &entity.User{
+		Name:    "Budi",
+		NIK:     "3201234567890001",
+		Address: "Jl. Merdeka No. 1",
+		Sex:     "L",
+	}
+
+	for _, tc := range []struct {
+		Key      string
+		Expected string
+		Found    bool
+	}{
+		{Key: "applicant.name", Expected: "Budi", Found: true},
+		{Key: "applicant.nik", Expected: "3201234567890001", Found: true},
+		{Key: "applicant.address", Expected: "Jl. Merdeka No. 1", Found: true},
+		{Key: "applicant.sex", Expected: "L", Found: true},
+		{Key: "applicant.gender", Expected: "Laki-laki", Found: true},
+		{Key: "applicant.password", Found: false},
+		{Key: "name", Found: false},
+	} {
+		t.Run(tc.Key, func(t *testing.T) {
+			value, found := ResolveApplicant(tc.Key, applicant)
+			assert.Equal(t, tc.Found, found)
+			assert.Equal(t, tc.Expected, value)
+			assert.Equal(t, tc.Found, IsKnownApplicantField(tc.Key))
+		})
+	}
+}
+
+func TestSetNested(t *testing.T) {
+	m := map[string]interface{}{}
+	SetNested(m, "name", "letter")
+	SetNested(m, "applicant.name", "Budi")
+	SetNested(m, "applicant.nik", "3201")
+	SetNested(m, "office.name", "Kantor Desa")
+
+	assert.Equal(t, map[string]interface{}{
+		"name":        "letter",
+		"office.name": "Kantor Desa",
+		"applicant": map[string]interface{}{
+			"name": "Budi",
+			"nik":  "3201",
+		},
+	}, m)
+}
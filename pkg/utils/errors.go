@@ -18,6 +18,12 @@ var (
 
 	// ErrInvalidNumber is used when the number covertion is invalid
 	ErrInvalidNumber = errors.New("invalid number")
+
+	// ErrPDFTooLarge is used when the uploaded PDF exceed the maximum allowed size
+	ErrPDFTooLarge = errors.New("pdf file is too large")
+
+	// ErrImageTooLarge is used when the uploaded signature or stamp image exceed the maximum allowed size or dimension
+	ErrImageTooLarge = errors.New("image file is too large")
 )
 
 // Service errors
@@ -33,6 +39,155 @@ var (
 
 	// ErrAlreadySigned is used when the document is already signed
 	ErrAlreadySigned = errors.New("already signed")
+
+	// ErrNotSignedYet is used when the document is not signed yet
+	ErrNotSignedYet = errors.New("not signed yet")
+
+	// ErrAlreadyRevoked is used when the signed document is already revoked
+	ErrAlreadyRevoked = errors.New("already revoked")
+
+	// ErrTemplateContainsScript is used when the uploaded template contain script, event handler, or embedded object
+	ErrTemplateContainsScript = errors.New("template must not contain script or embedded object")
+
+	// ErrTemplateTooLarge is used when the uploaded template file exceed the maximum allowed size
+	ErrTemplateTooLarge = errors.New("template file is too large")
+
+	// ErrInvalidTemplateBundle is used when the uploaded template bundle is not a valid zip, contain unsupported file, or didn't have the html entry
+	ErrInvalidTemplateBundle = errors.New("invalid template bundle")
+
+	// ErrUnknownApplicantField is used when the template declare applicant bound field that can't be resolved from the user profile
+	ErrUnknownApplicantField = errors.New("unknown applicant field in template keys")
+
+	// ErrApplicantFieldReadOnly is used when the request try to change the field that is filled from the applicant profile
+	ErrApplicantFieldReadOnly = errors.New("applicant field can't be changed")
+
+	// ErrInvalidTableDefinition is used when the table field definition of the template is not valid json, or has empty, duplicate, or unknown type column
+	ErrInvalidTableDefinition = errors.New("invalid table field definition")
+
+	// ErrInvalidTableRow is used when the submitted table rows didn't match the table columns
+	ErrInvalidTableRow = errors.New("table rows doesn't match with table columns")
+
+	// ErrInvalidExpression is used when the template field expression or condition can't be parsed or use syntax or function that is not allowed
+	ErrInvalidExpression = errors.New("invalid field expression")
+
+	// ErrExpressionEvaluation is used when the template field expression or condition can't be evaluated with the document values
+	ErrExpressionEvaluation = errors.New("failed to evaluate field expression")
+
+	// ErrSignedPDFMismatch is used when the stored signed document didn't match the hash recorded at signing time
+	ErrSignedPDFMismatch = errors.New("stored signed document doesn't match its hash")
+
+	// ErrSignedSnapshotNotFound is used when the signed document has no stored snapshot to render the other format
+	// from, eg: signed before the snapshot was stored
+	ErrSignedSnapshotNotFound = errors.New("signed document is only available as pdf")
+
+	// ErrArtifactNotFound is used when the generated file is not found in the artifact storage
+	ErrArtifactNotFound = errors.New("artifact not found")
+
+	// ErrInvalidArtifactKey is used when the artifact key is empty or point outside the artifact storage
+	ErrInvalidArtifactKey = errors.New("invalid artifact key")
+
+	// ErrRenderQueueFull is used when the PDF render queue is full and can't accept another job
+	ErrRenderQueueFull = errors.New("render queue is full, try again later")
+
+	// ErrRenderJobNotDone is used when the PDF of render job is requested before the job is done
+	ErrRenderJobNotDone = errors.New("render job is not done yet")
+
+	// ErrRenderJobFailed is used when the render job is failed, the reason is saved in the job
+	ErrRenderJobFailed = errors.New("render job failed")
+
+	// ErrInvalidPageSetup is used when the template page size or orientation is not supported, or custom page size didn't have dimension
+	ErrInvalidPageSetup = errors.New("invalid page setup")
+
+	// ErrUnknownPDFBackend is used when the configured PDF backend is not one of the supported backend
+	ErrUnknownPDFBackend = errors.New("unknown pdf backend")
+
+	// ErrSigningKeyNotFound is used when neither the signer nor the office has a keystore to sign the PDF
+	ErrSigningKeyNotFound = errors.New("signing key not found for the signer")
+
+	// ErrInvalidKeystore is used when the keystore can't be opened with the configured password or didn't contain a usable key
+	ErrInvalidKeystore = errors.New("invalid keystore or keystore password")
+
+	// ErrInvalidTrustAnchor is used when the trust anchor file didn't contain any PEM certificate
+	ErrInvalidTrustAnchor = errors.New("invalid signature trust anchor")
+
+	// ErrSigningCertificateExpired is used when the signing certificate is not valid at the signing time
+	ErrSigningCertificateExpired = errors.New("signing certificate is expired or not yet valid")
+
+	// ErrUnsupportedPDF is used when the file is not a PDF, or use PDF structure that can't be signed
+	ErrUnsupportedPDF = errors.New("unsupported pdf structure")
+
+	// ErrInvalidSignaturePlacement is used when the template signature field is outside the page
+	ErrInvalidSignaturePlacement = errors.New("signature field is outside the page")
+
+	// ErrSignatureTooLarge is used when the signature didn't fit the space reserved in the PDF
+	ErrSignatureTooLarge = errors.New("signature is larger than the reserved space")
+
+	// ErrInvalidQRToken is used when the QR token is malformed, not signed by the office key, or didn't match the document
+	ErrInvalidQRToken = errors.New("invalid qr token")
+
+	// ErrInvalidQRSigningKey is used when the QR signing key file is not a PKCS#8 PEM Ed25519 private key
+	ErrInvalidQRSigningKey = errors.New("invalid qr signing key")
+
+	// ErrQRSigningKeyNotFound is used when the QR signing key file didn't exist, it must be created with generate-qr-key
+	ErrQRSigningKeyNotFound = errors.New("qr signing key not found, create it with the generate-qr-key command")
+
+	// ErrQRSigningKeyExists is used when generating the QR signing key over an existing one
+	ErrQRSigningKeyExists = errors.New("qr signing key already exists")
+
+	// ErrTemplateExternalResource is used when the uploaded template reference remote or local file resource that is not allowed
+	ErrTemplateExternalResource = errors.New("template must not reference external resource")
+
+	// ErrUnsupportedFormat is used when no renderer is registered for the requested output format
+	ErrUnsupportedFormat = errors.New("unsupported render format")
+
+	// ErrInvalidImage is used when the uploaded signature or stamp image is not a PNG or JPEG image
+	ErrInvalidImage = errors.New("image must be a png or jpeg file")
+
+	// ErrTooManyDocuments is used when the export filter match more document than can be exported at once
+	ErrTooManyDocuments = errors.New("too many documents to export, narrow down the filter")
+
+	// ErrUserDeactivated is used when the deactivated user log in or use the token issued before the deactivation
+	ErrUserDeactivated = errors.New("user account is deactivated")
+
+	// ErrSelfDeactivation is used when the admin try to deactivate their own account
+	ErrSelfDeactivation = errors.New("can't deactivate your own account")
+
+	// ErrInvalidRefreshToken is used when the refresh token is unknown, expired, or already used
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+	// ErrTokenRevoked is used when the access token is issued before the user log out or their access is changed
+	ErrTokenRevoked = errors.New("token has been revoked, please log in again")
+
+	// ErrPasswordChangeRequired is used when the user must change the password given by the admin before doing anything else
+	ErrPasswordChangeRequired = errors.New("password must be changed before continuing")
+
+	// ErrWrongPassword is used when the current password given on password change didn't match
+	ErrWrongPassword = errors.New("current password is incorrect")
+
+	// ErrAdminAlreadyExist is used when the initial admin is created while there is already an admin
+	ErrAdminAlreadyExist = errors.New("admin account already exist")
+
+	// ErrInvalidResetToken is used when the password reset token is unknown, expired, or already used
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+	// ErrPasswordTooShort is used when the password is shorter than the password policy allow
+	ErrPasswordTooShort = errors.New("password is too short")
+
+	// ErrPasswordTooLong is used when the password is longer than the hash can use
+	ErrPasswordTooLong = errors.New("password is too long, the maximum is 72 byte")
+
+	// ErrPasswordReused is used when the new password is one of the last password of the user
+	ErrPasswordReused = errors.New("password has been used recently, choose a different password")
+
+	// ErrUnknownNotifier is used when the configured notifier is not one of the supported notifier
+	ErrUnknownNotifier = errors.New("unknown notifier")
+
+	// ErrSMTPAuthNotSupported is used when the SMTP username is set but the server didn't offer authentication
+	ErrSMTPAuthNotSupported = errors.New("smtp server doesn't support authentication")
+
+	// ErrNotifierNotSet is used when NOTIFIER is empty, it is required so the reset token never end up in the log by
+	// accident
+	ErrNotifierNotSet = errors.New("NOTIFIER must be set to log or smtp")
 )
 
 // Repository errors
@@ -49,6 +204,15 @@ var (
 	// ErrUserNotFound is used when the user is not found in the database
 	ErrUserNotFound = errors.New("user not found")
 
+	// ErrRoleNotFound is used when the role is not found in the database
+	ErrRoleNotFound = errors.New("role not found")
+
+	// ErrRefreshTokenNotFound is used when the refresh token is not found in the database or already revoked
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+
+	// ErrPasswordResetTokenNotFound is used when the password reset token is not found in the database or already used
+	ErrPasswordResetTokenNotFound = errors.New("password reset token not found")
+
 	// ErrTemplateNotFound is used when the template is not found in the database
 	ErrTemplateNotFound = errors.New("template not found")
 
@@ -66,4 +230,10 @@ var (
 
 	// ErrFieldNotFound is used when document field is not found in the database
 	ErrFieldNotFound = errors.New("field not found")
+
+	// ErrRenderJobNotFound is used when the render job is not found in the database
+	ErrRenderJobNotFound = errors.New("render job not found")
+
+	// ErrRenderJobAlreadyActive is used when the document already has a queued or running render job
+	ErrRenderJobAlreadyActive = errors.New("document already has an active render job")
 )
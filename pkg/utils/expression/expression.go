@@ -0,0 +1,594 @@
+package expression
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/indonesian"
+)
+
+// MaxLength is the maximum length of expression source
+const MaxLength = 1024
+
+// maxInteger is the largest magnitude that is converted into whole number, larger float64 can't hold every
+// integer exactly
+const maxInteger = 1e15
+
+// maxDateOffset is the largest number of days, months, or years that can be added to a date
+const maxDateOffset = 10000
+
+// Expression is a parsed field expression. Only literal, variable, comparison, arithmetic, logical operator and
+// the functions listed in functions are allowed, so the expression can't do anything other than computing a value
+type Expression struct {
+	source string
+	root   ast.Expr
+}
+
+type function struct {
+	minArgs int
+	maxArgs int
+	call    func(args []interface{}, env map[string]interface{}) (interface{}, error)
+}
+
+var functions = map[string]function{
+	"cond":      {3, 3, nil}, // evaluated lazily in evalCall
+	"date":      {1, 1, fnDate},
+	"addDays":   {2, 2, fnAddDate(0, 0, 1)},
+	"addMonths": {2, 2, fnAddDate(0, 1, 0)},
+	"addYears":  {2, 2, fnAddDate(1, 0, 0)},
+	"age":       {1, 2, fnAge},
+	"len":       {1, 1, fnLen},
+	"number":    {1, 1, fnNumber},
+	"empty":     {1, 1, fnEmpty},
+	"upper":     {1, 1, fnString(strings.ToUpper)},
+	"lower":     {1, 1, fnString(strings.ToLower)},
+	"title":     {1, 1, fnString(indonesian.TitleCase)},
+	"tanggal":   {1, 1, fnTanggal},
+	"terbilang": {1, 1, fnTerbilang},
+}
+
+// Parse parse and check the expression source, return ErrInvalidExpression when it use syntax or function
+// that is not allowed
+func Parse(source string) (*Expression, error) {
+	if strings.TrimSpace(source) == "" || len(source) > MaxLength {
+		return nil, utils.ErrInvalidExpression
+	}
+
+	root, err := parser.ParseExpr(source)
+	if err != nil {
+		return nil, utils.ErrInvalidExpression
+	}
+
+	if err := check(root); err != nil {
+		return nil, err
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+// String return the expression source
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Evaluate compute the expression value with the given variables, the result is string, float64, bool, time.Time or nil
+func (e *Expression) Evaluate(env map[string]interface{}) (interface{}, error) {
+	return eval(e.root, env)
+}
+
+// EvaluateBool compute the expression and report whether the result is truthy
+func (e *Expression) EvaluateBool(env map[string]interface{}) (bool, error) {
+	value, err := e.Evaluate(env)
+	if err != nil {
+		return false, err
+	}
+
+	return Truthy(value), nil
+}
+
+func check(node ast.Expr) error {
+	switch n := node.(type) {
+	case *ast.BasicLit:
+		switch n.Kind {
+		case token.INT, token.FLOAT, token.STRING:
+			return nil
+		}
+	case *ast.Ident:
+		return nil
+	case *ast.ParenExpr:
+		return check(n.X)
+	case *ast.SelectorExpr:
+		return check(n.X)
+	case *ast.UnaryExpr:
+		switch n.Op {
+		case token.NOT, token.SUB:
+			return check(n.X)
+		}
+	case *ast.BinaryExpr:
+		switch n.Op {
+		case token.ADD, token.SUB, token.MUL, token.QUO, token.REM,
+			token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ,
+			token.LAND, token.LOR:
+			if err := check(n.X); err != nil {
+				return err
+			}
+			return check(n.Y)
+		}
+	case *ast.CallExpr:
+		name, ok := n.Fun.(*ast.Ident)
+		if !ok || n.Ellipsis.IsValid() {
+			return utils.ErrInvalidExpression
+		}
+
+		fn, ok := functions[name.Name]
+		if !ok || len(n.Args) < fn.minArgs || len(n.Args) > fn.maxArgs {
+			return utils.ErrInvalidExpression
+		}
+
+		for _, arg := range n.Args {
+			if err := check(arg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return utils.ErrInvalidExpression
+}
+
+func eval(node ast.Expr, env map[string]interface{}) (interface{}, error) {
+	switch n := node.(type) {
+	case *ast.BasicLit:
+		return evalLiteral(n)
+	case *ast.Ident:
+		switch n.Name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "nil":
+			return nil, nil
+		}
+		return normalize(env[n.Name]), nil
+	case *ast.ParenExpr:
+		return eval(n.X, env)
+	case *ast.SelectorExpr:
+		parent, err := eval(n.X, env)
+		if err != nil {
+			return nil, err
+		}
+		return lookup(parent, n.Sel.Name), nil
+	case *ast.UnaryExpr:
+		value, err := eval(n.X, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if n.Op == token.NOT {
+			return !Truthy(value), nil
+		}
+
+		number, ok := toNumber(value)
+		if !ok {
+			return nil, utils.ErrExpressionEvaluation
+		}
+		return -number, nil
+	case *ast.BinaryExpr:
+		return evalBinary(n, env)
+	case *ast.CallExpr:
+		return evalCall(n, env)
+	}
+
+	return nil, utils.ErrExpressionEvaluation
+}
+
+func evalLiteral(literal *ast.BasicLit) (interface{}, error) {
+	if literal.Kind == token.STRING {
+		value, err := strconv.Unquote(literal.Value)
+		if err != nil {
+			return nil, utils.ErrExpressionEvaluation
+		}
+		return value, nil
+	}
+
+	value, err := strconv.ParseFloat(literal.Value, 64)
+	if err != nil {
+		return nil, utils.ErrExpressionEvaluation
+	}
+
+	return value, nil
+}
+
+func evalBinary(n *ast.BinaryExpr, env map[string]interface{}) (interface{}, error) {
+	x, err := eval(n.X, env)
+	if err != nil {
+		return nil, err
+	}
+
+	// logical operator is short circuited
+	switch n.Op {
+	case token.LAND:
+		if !Truthy(x) {
+			return false, nil
+		}
+	case token.LOR:
+		if Truthy(x) {
+			return true, nil
+		}
+	}
+
+	y, err := eval(n.Y, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.Op {
+	case token.LAND, token.LOR:
+		return Truthy(y), nil
+	case token.EQL:
+		return equal(x, y), nil
+	case token.NEQ:
+		return !equal(x, y), nil
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+		result, err := compare(x, y)
+		if err != nil {
+			return nil, err
+		}
+
+		switch n.Op {
+		case token.LSS:
+			return result < 0, nil
+		case token.LEQ:
+			return result <= 0, nil
+		case token.GTR:
+			return result > 0, nil
+		default:
+			return result >= 0, nil
+		}
+	}
+
+	a, aOk := toNumber(x)
+	b, bOk := toNumber(y)
+	if n.Op == token.ADD && !(aOk && bOk) {
+		return toString(x) + toString(y), nil
+	}
+
+	if !aOk || !bOk {
+		return nil, utils.ErrExpressionEvaluation
+	}
+
+	switch n.Op {
+	case token.ADD:
+		return a + b, nil
+	case token.SUB:
+		return a - b, nil
+	case token.MUL:
+		return a * b, nil
+	case token.QUO:
+		if b == 0 {
+			return nil, utils.ErrExpressionEvaluation
+		}
+		return a / b, nil
+	case token.REM:
+		if b == 0 {
+			return nil, utils.ErrExpressionEvaluation
+		}
+		return math.Mod(a, b), nil
+	}
+
+	return nil, utils.ErrExpressionEvaluation
+}
+
+func evalCall(n *ast.CallExpr, env map[string]interface{}) (interface{}, error) {
+	name := n.Fun.(*ast.Ident).Name
+
+	// only the chosen branch of cond is evaluated
+	if name == "cond" {
+		condition, err := eval(n.Args[0], env)
+		if err != nil {
+			return nil, err
+		}
+
+		if Truthy(condition) {
+			return eval(n.Args[1], env)
+		}
+		return eval(n.Args[2], env)
+	}
+
+	args := make([]interface{}, 0, len(n.Args))
+	for _, arg := range n.Args {
+		value, err := eval(arg, env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+
+	return functions[name].call(args, env)
+}
+
+func fnDate(args []interface{}, _ map[string]interface{}) (interface{}, error) {
+	t, ok := toTime(args[0])
+	if !ok {
+		return nil, utils.ErrExpressionEvaluation
+	}
+
+	return t, nil
+}
+
+func fnAddDate(years int, months int, days int) func(args []interface{}, env map[string]interface{}) (interface{}, error) {
+	return func(args []interface{}, _ map[string]interface{}) (interface{}, error) {
+		t, ok := toTime(args[0])
+		if !ok {
+			return nil, utils.ErrExpressionEvaluation
+		}
+
+		n, ok := toInteger(args[1], maxDateOffset)
+		if !ok {
+			return nil, utils.ErrExpressionEvaluation
+		}
+
+		return t.AddDate(years*int(n), months*int(n), days*int(n)), nil
+	}
+}
+
+// fnAge calculate age in years at the optional reference date, default to today variable or current time
+func fnAge(args []interface{}, env map[string]interface{}) (interface{}, error) {
+	birth, ok := toTime(args[0])
+	if !ok {
+		return nil, utils.ErrExpressionEvaluation
+	}
+
+	at, ok := toTime(env["today"])
+	if !ok {
+		at = time.Now()
+	}
+
+	if len(args) > 1 {
+		if at, ok = toTime(args[1]); !ok {
+			return nil, utils.ErrExpressionEvaluation
+		}
+	}
+
+	return float64(indonesian.Age(birth, at)), nil
+}
+
+func fnLen(args []interface{}, _ map[string]interface{}) (interface{}, error) {
+	if s, ok := args[0].(string); ok {
+		return float64(len([]rune(s))), nil
+	}
+
+	rv := reflect.ValueOf(args[0])
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(rv.Len()), nil
+	case reflect.Invalid:
+		return float64(0), nil
+	}
+
+	return nil, utils.ErrExpressionEvaluation
+}
+
+func fnNumber(args []interface{}, _ map[string]interface{}) (interface{}, error) {
+	n, ok := toNumber(args[0])
+	if !ok {
+		return nil, utils.ErrExpressionEvaluation
+	}
+
+	return n, nil
+}
+
+func fnEmpty(args []interface{}, _ map[string]interface{}) (interface{}, error) {
+	return !Truthy(args[0]), nil
+}
+
+func fnString(transform func(string) string) func(args []interface{}, env map[string]interface{}) (interface{}, error) {
+	return func(args []interface{}, _ map[string]interface{}) (interface{}, error) {
+		return transform(toString(args[0])), nil
+	}
+}
+
+func fnTanggal(args []interface{}, _ map[string]interface{}) (interface{}, error) {
+	t, ok := toTime(args[0])
+	if !ok {
+		return nil, utils.ErrExpressionEvaluation
+	}
+
+	return indonesian.FormatDate(t), nil
+}
+
+func fnTerbilang(args []interface{}, _ map[string]interface{}) (interface{}, error) {
+	n, ok := toInteger(args[0], maxInteger)
+	if !ok {
+		return nil, utils.ErrExpressionEvaluation
+	}
+
+	return indonesian.Terbilang(n), nil
+}
+
+// Truthy report whether the value is considered true, empty string, zero, zero date, and empty list are false
+func Truthy(value interface{}) bool {
+	switch v := normalize(value).(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return strings.TrimSpace(v) != ""
+	case time.Time:
+		return !v.IsZero()
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() != 0
+	}
+
+	return true
+}
+
+// Display convert the evaluated value into value that is printed nicely in template, date is formatted as
+// "02 Januari 2006" and whole number is printed without decimal
+func Display(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return indonesian.FormatDate(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < maxInteger {
+			return int64(v)
+		}
+		return v
+	case nil:
+		return ""
+	}
+
+	return value
+}
+
+// normalize convert every number into float64 so it can be compared and calculated
+func normalize(value interface{}) interface{} {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+
+	return value
+}
+
+func lookup(parent interface{}, key string) interface{} {
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		return normalize(p[key])
+	case map[string]string:
+		return p[key]
+	}
+
+	return nil
+}
+
+func equal(x interface{}, y interface{}) bool {
+	if a, ok := toNumber(x); ok {
+		if b, ok := toNumber(y); ok {
+			return a == b
+		}
+	}
+
+	if a, ok := x.(bool); ok {
+		return a == Truthy(y)
+	}
+
+	if b, ok := y.(bool); ok {
+		return b == Truthy(x)
+	}
+
+	if a, ok := toTime(x); ok {
+		if b, ok := toTime(y); ok {
+			return a.Equal(b)
+		}
+	}
+
+	return toString(x) == toString(y)
+}
+
+// compare return -1, 0, or 1. Number and date is compared by its value, otherwise by string
+func compare(x interface{}, y interface{}) (int, error) {
+	if a, ok := toNumber(x); ok {
+		if b, ok := toNumber(y); ok {
+			switch {
+			case a < b:
+				return -1, nil
+			case a > b:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+
+	if a, ok := toTime(x); ok {
+		if b, ok := toTime(y); ok {
+			switch {
+			case a.Before(b):
+				return -1, nil
+			case a.After(b):
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+
+	_, xBool := x.(bool)
+	_, yBool := y.(bool)
+	if xBool || yBool {
+		return 0, utils.ErrExpressionEvaluation
+	}
+
+	return strings.Compare(toString(x), toString(y)), nil
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := normalize(value).(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+
+	return 0, false
+}
+
+// toInteger convert the value into whole number, NaN, infinity, and number larger than limit are rejected so the
+// conversion never overflow
+func toInteger(value interface{}, limit float64) (int64, bool) {
+	n, ok := toNumber(value)
+	if !ok || math.IsNaN(n) || math.Abs(n) > limit {
+		return 0, false
+	}
+
+	return int64(n), true
+}
+
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case string:
+		return indonesian.ParseDate(v)
+	}
+
+	return time.Time{}, false
+}
+
+func toString(value interface{}) string {
+	switch v := normalize(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return indonesian.FormatDate(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(value)
+}
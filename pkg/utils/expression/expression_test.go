@@ -0,0 +1,120 @@
+package expression
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		Name   string
+		Source string
+		Err    error
+	}{
+		{Name: "Arithmetic", Source: "price * qty + 1000"},
+		{Name: "Function", Source: `cond(applicant.sex == "L", "Bapak", "Ibu")`},
+		{Name: "Logical", Source: `!empty(spouse) && age(birth_date) >= 17`},
+		{Name: "Empty", Source: " ", Err: utils.ErrInvalidExpression},
+		{Name: "Syntax error", Source: "price *", Err: utils.ErrInvalidExpression},
+		{Name: "Unknown function", Source: `exec("rm -rf /")`, Err: utils.ErrInvalidExpression},
+		{Name: "Method call", Source: `applicant.name.Delete()`, Err: utils.ErrInvalidExpression},
+		{Name: "Function literal", Source: `func() int { return 1 }()`, Err: utils.ErrInvalidExpression},
+		{Name: "Index", Source: `family[0]`, Err: utils.ErrInvalidExpression},
+		{Name: "Wrong arity", Source: `cond(true, 1)`, Err: utils.ErrInvalidExpression},
+		{Name: "Bitwise operator", Source: `a << 2`, Err: utils.ErrInvalidExpression},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := Parse(tc.Source)
+			assert.Equal(t, tc.Err, err)
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	env := map[string]interface{}{
+		"today":      time.Date(2022, time.August, 17, 0, 0, 0, 0, time.Local),
+		"issue_date": "2022-08-01",
+		"birth_date": "17-08-2000",
+		"price":      "1500",
+		"qty":        int64(3),
+		"spouse":     "",
+		"family":     []map[string]interface{}{{"name": "Budi"}, {"name": "Ani"}},
+		"applicant": map[string]interface{}{
+			"name": "budi santoso",
+			"sex":  "P",
+		},
+	}
+
+	for _, tc := range []struct {
+		Source   string
+		Expected interface{}
+	}{
+		{Source: "price * qty", Expected: float64(4500)},
+		{Source: "(price - 500) / 4", Expected: float64(250)},
+		{Source: "qty % 2", Expected: float64(1)},
+		{Source: `"RT " + qty`, Expected: "RT 3"},
+		{Source: `cond(applicant.sex == "L", "Bapak", "Ibu")`, Expected: "Ibu"},
+		{Source: `title(applicant.name)`, Expected: "Budi Santoso"},
+		{Source: `upper(applicant.unknown)`, Expected: ""},
+		{Source: "age(birth_date)", Expected: float64(22)},
+		{Source: `age(birth_date, "2022-08-16")`, Expected: float64(21)},
+		{Source: "addMonths(issue_date, 6)", Expected: time.Date(2023, time.February, 1, 0, 0, 0, 0, time.Local)},
+		{Source: "addDays(issue_date, 30) > today", Expected: true},
+		{Source: `tanggal(addYears(issue_date, 1))`, Expected: "01 Agustus 2023"},
+		{Source: "len(family)", Expected: float64(2)},
+		{Source: "empty(spouse) || spouse == nil", Expected: true},
+		{Source: `!empty(spouse) && upper(spouse) == "ANI"`, Expected: false},
+		{Source: `price >= 1000 && price < 2000`, Expected: true},
+		{Source: `terbilang(qty * 2)`, Expected: "enam"},
+		{Source: `cond(false, 1 / 0, "safe")`, Expected: "safe"},
+	} {
+		t.Run(tc.Source, func(t *testing.T) {
+			expr, err := Parse(tc.Source)
+			assert.NoError(t, err)
+
+			value, err := expr.Evaluate(env)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, value)
+		})
+	}
+}
+
+func TestEvaluateError(t *testing.T) {
+	env := map[string]interface{}{
+		"name": "Budi",
+		"zero": "0",
+	}
+
+	for _, source := range []string{
+		"name * 2",
+		"10 / zero",
+		"-name",
+		"addDays(name, 1)",
+		"true < false",
+		`terbilang(number("NaN"))`,
+		`terbilang(number("-Inf"))`,
+		"terbilang(1e19)",
+		`addYears(date("2022-08-01"), 1e19)`,
+		`addDays(date("2022-08-01"), number("Inf"))`,
+	} {
+		t.Run(source, func(t *testing.T) {
+			expr, err := Parse(source)
+			assert.NoError(t, err)
+
+			_, err = expr.Evaluate(env)
+			assert.Equal(t, utils.ErrExpressionEvaluation, err)
+		})
+	}
+}
+
+func TestDisplay(t *testing.T) {
+	assert.Equal(t, int64(4500), Display(float64(4500)))
+	assert.Equal(t, 2.5, Display(2.5))
+	assert.Equal(t, "17 Agustus 2022", Display(time.Date(2022, time.August, 17, 0, 0, 0, 0, time.Local)))
+	assert.Equal(t, "", Display(nil))
+	assert.Equal(t, "Bapak", Display("Bapak"))
+	assert.Equal(t, true, Display(true))
+}
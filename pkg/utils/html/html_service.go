@@ -2,12 +2,16 @@ package html
 
 import (
 	"bytes"
+	"context"
 	"github.com/suryaadi44/eAD-System/pkg/entity"
 	"html/template"
 )
 
 type RenderService interface {
-	GenerateSignature(signer entity.User) (*template.HTML, error)
+	GenerateSignature(ctx context.Context, signer entity.User) (*template.HTML, error)
 	GenerateFooter(document *entity.Document) (*template.HTML, error)
 	GenerateHTMLDocument(docTemplate *entity.Template, data *map[string]interface{}) (*bytes.Buffer, error)
+	GenerateHeader(docTemplate *entity.Template, data *map[string]interface{}) (*bytes.Buffer, error)
+	GeneratePageFooter(docTemplate *entity.Template, data *map[string]interface{}) (*bytes.Buffer, error)
+	GenerateStatusPage(data *map[string]interface{}) (*bytes.Buffer, error)
 }
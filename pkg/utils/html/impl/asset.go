@@ -0,0 +1,147 @@
+package impl
+
+import (
+	"encoding/base64"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxAssetDepth is the deepest stylesheet that is inlined, counted from the template
+const maxAssetDepth = 8
+
+var (
+	assetAttributePattern = regexp.MustCompile(`(?i)(\s(?:src|href|poster|background)\s*=\s*)("[^"]*"|'[^']*')`)
+	assetCSSURLPattern    = regexp.MustCompile(`(?i)url\(\s*("[^"]*"|'[^']*'|[^)'"]*)\s*\)`)
+
+	// assetTypes cover font type that is not registered in every OS mime database
+	assetTypes = map[string]string{
+		".css":   "text/css",
+		".svg":   "image/svg+xml",
+		".png":   "image/png",
+		".jpg":   "image/jpeg",
+		".jpeg":  "image/jpeg",
+		".gif":   "image/gif",
+		".ttf":   "font/ttf",
+		".otf":   "font/otf",
+		".woff":  "font/woff",
+		".woff2": "font/woff2",
+	}
+)
+
+// assetInliner replace relative asset reference in template bundle with data uri, so the pdf
+// renderer doesn't need access to local file
+type assetInliner struct {
+	rootDir string
+}
+
+func newAssetInliner(rootDir string) *assetInliner {
+	absRoot, err := filepath.Abs(rootDir)
+	if err != nil {
+		absRoot = rootDir
+	}
+
+	return &assetInliner{
+		rootDir: absRoot,
+	}
+}
+
+// inlineHTML inline asset referenced by html attribute and css url() relative to baseDir
+func (a *assetInliner) inlineHTML(content string, baseDir string) string {
+	content = assetAttributePattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := assetAttributePattern.FindStringSubmatch(match)
+		quote := parts[2][:1]
+		value := parts[2][1 : len(parts[2])-1]
+
+		dataURI, ok := a.toDataURI(value, baseDir, nil)
+		if !ok {
+			return match
+		}
+
+		return parts[1] + quote + dataURI + quote
+	})
+
+	return a.inlineCSS(content, baseDir, nil)
+}
+
+// inlineCSS inline asset referenced by url() relative to baseDir, parents is the stylesheet that is being inlined
+// so a stylesheet that reference itself, directly or through another one, is left as is
+func (a *assetInliner) inlineCSS(content string, baseDir string, parents []string) string {
+	return assetCSSURLPattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := assetCSSURLPattern.FindStringSubmatch(match)
+		value := strings.Trim(parts[1], `"'`)
+
+		dataURI, ok := a.toDataURI(value, baseDir, parents)
+		if !ok {
+			return match
+		}
+
+		return `url("` + dataURI + `")`
+	})
+}
+
+func (a *assetInliner) toDataURI(reference string, baseDir string, parents []string) (string, bool) {
+	assetPath, ok := a.resolve(reference, baseDir)
+	if !ok {
+		return "", false
+	}
+
+	if len(parents) >= maxAssetDepth {
+		return "", false
+	}
+
+	for _, parent := range parents {
+		if parent == assetPath {
+			return "", false
+		}
+	}
+
+	content, err := os.ReadFile(assetPath)
+	if err != nil {
+		return "", false
+	}
+
+	ext := strings.ToLower(filepath.Ext(assetPath))
+	mimeType, ok := assetTypes[ext]
+	if !ok {
+		if mimeType = mime.TypeByExtension(ext); mimeType == "" {
+			return "", false
+		}
+	}
+
+	// css can reference another asset relative to its own location
+	if ext == ".css" {
+		content = []byte(a.inlineCSS(string(content), filepath.Dir(assetPath), append(parents[:len(parents):len(parents)], assetPath)))
+	}
+
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(content), true
+}
+
+// resolve convert relative reference to file path, rejecting anything outside the bundle directory
+func (a *assetInliner) resolve(reference string, baseDir string) (string, bool) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" || strings.HasPrefix(reference, "#") {
+		return "", false
+	}
+
+	parsed, err := url.Parse(reference)
+	if err != nil || parsed.Scheme != "" || parsed.Host != "" || strings.HasPrefix(parsed.Path, "/") || parsed.Path == "" {
+		return "", false
+	}
+
+	absBase, err := filepath.Abs(baseDir)
+	if err != nil {
+		return "", false
+	}
+
+	assetPath := filepath.Join(absBase, filepath.FromSlash(parsed.Path))
+	rel, err := filepath.Rel(a.rootDir, assetPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return assetPath, true
+}
@@ -0,0 +1,103 @@
+package impl
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+)
+
+func TestAssetInliner_InlineHTML(t *testing.T) {
+	rootDir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(rootDir, "img"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(rootDir, "fonts"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(rootDir, "img", "logo.png"), []byte("png"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(rootDir, "fonts", "arial.ttf"), []byte("ttf"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(rootDir, "style.css"), []byte(`@font-face { src: url('fonts/arial.ttf') }`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(rootDir), "secret.png"), []byte("secret"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(rootDir, "self.css"), []byte(`a { b: url(self.css) }`), 0644))
+
+	inliner := newAssetInliner(rootDir)
+
+	for _, tc := range []struct {
+		Name     string
+		HTML     string
+		Expected string
+	}{
+		{
+			Name:     "image attribute",
+			HTML:     `<img src="img/logo.png" alt="logo">`,
+			Expected: `<img src="data:image/png;base64,cG5n" alt="logo">`,
+		},
+		{
+			Name:     "css url in style tag",
+			HTML:     `<style>body { background: url(img/logo.png) }</style>`,
+			Expected: `<style>body { background: url("data:image/png;base64,cG5n") }</style>`,
+		},
+		{
+			Name:     "stylesheet with font referenced relative to css",
+			HTML:     `<link rel="stylesheet" href='style.css'>`,
+			Expected: `<link rel="stylesheet" href='data:text/css;base64,QGZvbnQtZmFjZSB7IHNyYzogdXJsKCJkYXRhOmZvbnQvdHRmO2Jhc2U2NCxkSFJtIikgfQ=='>`,
+		},
+		{
+			Name:     "data uri and missing file untouched",
+			HTML:     `<img src="data:image/png;base64,AAAA"><img src="img/missing.png">`,
+			Expected: `<img src="data:image/png;base64,AAAA"><img src="img/missing.png">`,
+		},
+		{
+			Name:     "file outside bundle untouched",
+			HTML:     `<img src="../secret.png"><img src="/etc/passwd">`,
+			Expected: `<img src="../secret.png"><img src="/etc/passwd">`,
+		},
+		{
+			Name:     "stylesheet referencing itself",
+			HTML:     `<link href="self.css">`,
+			Expected: `<link href="data:text/css;base64,YSB7IGI6IHVybChzZWxmLmNzcykgfQ==">`,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, inliner.inlineHTML(tc.HTML, rootDir))
+		})
+	}
+}
+
+func TestAssetInliner_StylesheetCycle(t *testing.T) {
+	rootDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(rootDir, "a.css"), []byte(`a { b: url(b.css) }`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(rootDir, "b.css"), []byte(`b { a: url(a.css) }`), 0644))
+
+	inlined := newAssetInliner(rootDir).inlineHTML(`<link href="a.css">`, rootDir)
+
+	// a.css inline b.css, which keep its reference back to a.css
+	assert.Contains(t, inlined, "data:text/css;base64,")
+	decoded := decodeInlinedCSS(t, decodeInlinedCSS(t, inlined))
+	assert.Equal(t, `b { a: url(a.css) }`, decoded)
+}
+
+func decodeInlinedCSS(t *testing.T, content string) string {
+	start := strings.Index(content, "base64,") + len("base64,")
+	end := start + strings.IndexAny(content[start:], `"'`)
+	decoded, err := base64.StdEncoding.DecodeString(content[start:end])
+	assert.NoError(t, err)
+	return string(decoded)
+}
+
+func TestRenderServiceImpl_GenerateHTMLDocumentBundle(t *testing.T) {
+	rootDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(rootDir, "logo.png"), []byte("png"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(rootDir, "letter.html"), []byte(`<img src="logo.png"><p>{{.note}}</p>`), 0644))
+
+	renderService := &RenderServiceImpl{cache: newTemplateCache(newFuncMap())}
+	docTemplate := &entity.Template{Version: 1, Path: filepath.Join(rootDir, "letter.html"), BundlePath: rootDir}
+
+	// the asset of the template is inlined, but the value filled by the applicant is never resolved as bundle file
+	data := map[string]interface{}{"note": `<div style="background: url(logo.png)">`}
+	buf, err := renderService.GenerateHTMLDocument(docTemplate, &data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `<img src="data:image/png;base64,cG5n"><p>&lt;div style=&#34;background: url(logo.png)&#34;&gt;</p>`, buf.String())
+}
@@ -0,0 +1,195 @@
+package impl
+
+import (
+	"fmt"
+	"html/template"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils/indonesian"
+)
+
+// newFuncMap return function that is available in every template, value that can't be
+// converted is printed as is so a wrong input never break the whole letter
+func newFuncMap() template.FuncMap {
+	return template.FuncMap{
+		"tanggal":     formatDate,
+		"tanggalHari": formatDayDate,
+		"bulan":       monthName,
+		"terbilang":   terbilang,
+		"romawi":      roman,
+		"title":       titleCase,
+		"upper":       upperCase,
+		"lower":       lowerCase,
+		"umur":        age,
+		"maskNIK":     maskNIK,
+		"default":     defaultValue,
+	}
+}
+
+// formatDate print date as "02 Januari 2006"
+func formatDate(value interface{}) string {
+	if t, ok := toTime(value); ok {
+		return indonesian.FormatDate(t)
+	}
+
+	return toString(value)
+}
+
+// formatDayDate print date as "Senin, 02 Januari 2006"
+func formatDayDate(value interface{}) string {
+	if t, ok := toTime(value); ok {
+		return indonesian.FormatDayDate(t)
+	}
+
+	return toString(value)
+}
+
+// monthName accept date or month number, usefull for "bulan {{bulan .date}}"
+func monthName(value interface{}) string {
+	if t, ok := toTime(value); ok {
+		return indonesian.MonthName(t.Month())
+	}
+
+	if n, ok := toInt(value); ok && n >= 1 && n <= 12 {
+		return indonesian.MonthName(time.Month(n))
+	}
+
+	return toString(value)
+}
+
+func terbilang(value interface{}) string {
+	if n, ok := toInt(value); ok {
+		return indonesian.Terbilang(n)
+	}
+
+	return toString(value)
+}
+
+// roman accept number or date, date is converted to its month as used in letter numbering
+func roman(value interface{}) string {
+	if n, ok := toInt(value); ok {
+		return indonesian.Roman(int(n))
+	}
+
+	if t, ok := toTime(value); ok {
+		return indonesian.Roman(int(t.Month()))
+	}
+
+	return toString(value)
+}
+
+func titleCase(value interface{}) string {
+	return indonesian.TitleCase(toString(value))
+}
+
+func upperCase(value interface{}) string {
+	return strings.ToUpper(toString(value))
+}
+
+func lowerCase(value interface{}) string {
+	return strings.ToLower(toString(value))
+}
+
+// age calculate age from birth date, at the optional reference date or today
+func age(birthDate interface{}, at ...interface{}) string {
+	birth, ok := toTime(birthDate)
+	if !ok {
+		return toString(birthDate)
+	}
+
+	reference := time.Now()
+	if len(at) > 0 {
+		if t, ok := toTime(at[0]); ok {
+			reference = t
+		}
+	}
+
+	return strconv.Itoa(indonesian.Age(birth, reference))
+}
+
+func maskNIK(value interface{}) string {
+	return indonesian.MaskNIK(toString(value))
+}
+
+// defaultValue return fallback when value is empty, eg: {{.rt | default "-"}}
+func defaultValue(fallback interface{}, value interface{}) interface{} {
+	if isEmpty(value) {
+		return fallback
+	}
+
+	return value
+}
+
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case time.Time:
+		return v.IsZero()
+	case template.HTML:
+		return strings.TrimSpace(string(v)) == ""
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil() || isEmpty(rv.Elem().Interface())
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	}
+
+	return false
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return indonesian.FormatDate(v)
+	}
+
+	return fmt.Sprint(value)
+}
+
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, false
+		}
+		return *v, !v.IsZero()
+	case string:
+		return indonesian.ParseDate(v)
+	}
+
+	return time.Time{}, false
+}
+
+func toInt(value interface{}) (int64, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return int64(rv.Float()), true
+	case reflect.String:
+		// accept indonesian thousand separator, eg: "1.250.000"
+		cleaned := strings.ReplaceAll(strings.TrimSpace(rv.String()), ".", "")
+		n, err := strconv.ParseInt(cleaned, 10, 64)
+		return n, err == nil
+	}
+
+	return 0, false
+}
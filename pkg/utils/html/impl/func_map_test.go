@@ -0,0 +1,49 @@
+package impl
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuncMap(t *testing.T) {
+	data := map[string]interface{}{
+		"nama":         "BUDI santoso",
+		"nik":          "3201011708000001",
+		"tanggalLahir": "2000-08-17",
+		"nominal":      "1.250.000",
+		"bulanSurat":   8,
+		"rt":           "",
+	}
+
+	for _, tc := range []struct {
+		Name     string
+		Template string
+		Expected string
+	}{
+		{Name: "tanggal", Template: `{{tanggal .tanggalLahir}}`, Expected: "17 Agustus 2000"},
+		{Name: "tanggalHari", Template: `{{tanggalHari .tanggalLahir}}`, Expected: "Kamis, 17 Agustus 2000"},
+		{Name: "bulan", Template: `{{bulan .bulanSurat}}`, Expected: "Agustus"},
+		{Name: "terbilang", Template: `{{terbilang .nominal}} rupiah`, Expected: "satu juta dua ratus lima puluh ribu rupiah"},
+		{Name: "romawi", Template: `470/{{romawi .bulanSurat}}/2022`, Expected: "470/VIII/2022"},
+		{Name: "title", Template: `{{title .nama}}`, Expected: "Budi Santoso"},
+		{Name: "upper", Template: `{{upper .nama}}`, Expected: "BUDI SANTOSO"},
+		{Name: "umur", Template: `{{umur .tanggalLahir "2022-08-16"}}`, Expected: "21"},
+		{Name: "maskNIK", Template: `{{maskNIK .nik}}`, Expected: "320101******0001"},
+		{Name: "default on empty value", Template: `{{.rt | default "-"}}`, Expected: "-"},
+		{Name: "default on missing value", Template: `{{.rw | default "-"}}`, Expected: "-"},
+		{Name: "default on filled value", Template: `{{.nama | default "-"}}`, Expected: "BUDI santoso"},
+		{Name: "invalid input printed as is", Template: `{{tanggal .nama}}`, Expected: "BUDI santoso"},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			tmpl, err := template.New(tc.Name).Funcs(newFuncMap()).Parse(tc.Template)
+			assert.NoError(t, err)
+
+			buf := new(bytes.Buffer)
+			assert.NoError(t, tmpl.Execute(buf, data))
+			assert.Equal(t, tc.Expected, buf.String())
+		})
+	}
+}
@@ -2,33 +2,76 @@ package impl
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"github.com/suryaadi44/eAD-System/pkg/utils/html"
 	"github.com/suryaadi44/eAD-System/pkg/utils/qr"
+	"github.com/suryaadi44/eAD-System/pkg/utils/storage"
 	"html/template"
+	"path/filepath"
 
 	"github.com/suryaadi44/eAD-System/pkg/entity"
 )
 
+// Partial template that is shared by every document
+const (
+	signaturePath  = "./template/signature/signature.html"
+	footerPath     = "./template/signature/footer.html"
+	pageFooterPath = "./template/signature/page_footer.html"
+	statusPagePath = "./template/verification/status.html"
+)
+
 type RenderServiceImpl struct {
-	codeService qr.CodeService
+	codeService     qr.CodeService
+	artifactStorage storage.ArtifactStorage
+	cache           *templateCache
+	officeName      string
+	officeLogo      string
 }
 
-func NewRenderServiceImpl(codeService qr.CodeService) html.RenderService {
-	return &RenderServiceImpl{
-		codeService: codeService,
+func NewRenderServiceImpl(codeService qr.CodeService, artifactStorage storage.ArtifactStorage, officeName string, officeLogo string) html.RenderService {
+	renderService := &RenderServiceImpl{
+		codeService:     codeService,
+		artifactStorage: artifactStorage,
+		cache:           newTemplateCache(newFuncMap()),
+		officeName:      officeName,
+		officeLogo:      officeLogo,
+	}
+
+	// preload the partials so a missing or broken one is known at startup
+	for _, path := range []string{signaturePath, footerPath, pageFooterPath, statusPagePath} {
+		if _, err := renderService.parsePartial(path); err != nil {
+			panic(err)
+		}
 	}
+
+	return renderService
 }
 
-func (_ *RenderServiceImpl) GenerateSignature(signer entity.User) (*template.HTML, error) {
-	tmpl, err := template.ParseFiles("./template/signature/signature.html")
+// GenerateSignature render the signature block of the signer, with its signature and stamp image when uploaded
+func (r *RenderServiceImpl) GenerateSignature(ctx context.Context, signer entity.User) (*template.HTML, error) {
+	tmpl, err := r.parsePartial(signaturePath)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := r.loadSignatureImage(ctx, &signer.Signature, defaultSignatureHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	stamp, err := r.loadSignatureImage(ctx, &signer.Stamp, defaultStampHeight)
 	if err != nil {
 		return nil, err
 	}
 
-	m := map[string]string{
+	m := map[string]interface{}{
 		"signerPosition": signer.Position,
 		"signerName":     signer.Name,
 		"signerNIP":      signer.NIP,
+		"signatureImage": signature,
+		"stampImage":     stamp,
+		"imageHeight":    imageBlockHeight(signature, stamp),
 	}
 
 	buf := new(bytes.Buffer)
@@ -42,12 +85,12 @@ func (_ *RenderServiceImpl) GenerateSignature(signer entity.User) (*template.HTM
 }
 
 func (r *RenderServiceImpl) GenerateFooter(document *entity.Document) (*template.HTML, error) {
-	tmpl, err := template.ParseFiles("./template/signature/footer.html")
+	tmpl, err := r.parsePartial(footerPath)
 	if err != nil {
 		return nil, err
 	}
 
-	qrImage, err := r.codeService.GenerateBase64QRCode(document.ID)
+	qrImage, err := r.codeService.GenerateBase64QRCode(document)
 	if err != nil {
 		return nil, err
 	}
@@ -66,8 +109,8 @@ func (r *RenderServiceImpl) GenerateFooter(document *entity.Document) (*template
 	return &templateHTML, nil
 }
 
-func (_ *RenderServiceImpl) GenerateHTMLDocument(docTemplate *entity.Template, data *map[string]interface{}) (*bytes.Buffer, error) {
-	tmpl, err := template.ParseFiles(docTemplate.Path)
+func (r *RenderServiceImpl) GenerateHTMLDocument(docTemplate *entity.Template, data *map[string]interface{}) (*bytes.Buffer, error) {
+	tmpl, err := r.parseTemplate(docTemplate, "body", docTemplate.Path)
 	if err != nil {
 		return nil, err
 	}
@@ -79,3 +122,83 @@ func (_ *RenderServiceImpl) GenerateHTMLDocument(docTemplate *entity.Template, d
 
 	return buf, nil
 }
+
+// GenerateHeader render the running header of the template, return nil when the template didn't have one
+func (r *RenderServiceImpl) GenerateHeader(docTemplate *entity.Template, data *map[string]interface{}) (*bytes.Buffer, error) {
+	if docTemplate.HeaderPath == "" {
+		return nil, nil
+	}
+
+	tmpl, err := r.parseTemplate(docTemplate, "header", docTemplate.HeaderPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderPageSection(tmpl, data)
+}
+
+// GeneratePageFooter render the running footer of the template, the default page footer is used when the template
+// didn't have one but want the verification QR on every page. Return nil when there is no running footer
+func (r *RenderServiceImpl) GeneratePageFooter(docTemplate *entity.Template, data *map[string]interface{}) (*bytes.Buffer, error) {
+	var tmpl *template.Template
+	var err error
+	switch {
+	case docTemplate.FooterPath != "":
+		tmpl, err = r.parseTemplate(docTemplate, "footer", docTemplate.FooterPath)
+	case docTemplate.QROnEveryPage:
+		tmpl, err = r.parsePartial(pageFooterPath)
+	default:
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return renderPageSection(tmpl, data)
+}
+
+// GenerateStatusPage render the public verification page opened from the QR code, branded with the office name
+// and logo
+func (r *RenderServiceImpl) GenerateStatusPage(data *map[string]interface{}) (*bytes.Buffer, error) {
+	tmpl, err := r.parsePartial(statusPagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]interface{}{
+		"officeName": r.officeName,
+		// the logo come from the configuration, so data URL is trusted
+		"officeLogo": template.URL(r.officeLogo),
+	}
+	for key, value := range *data {
+		m[key] = value
+	}
+
+	buf := new(bytes.Buffer)
+	if err = tmpl.Execute(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// parseTemplate return the parsed file of the document template, cached by its id, version, and section. The asset
+// of template bundle is inlined into the template source, so the value filled at render time is never resolved as
+// a file in the bundle
+func (r *RenderServiceImpl) parseTemplate(docTemplate *entity.Template, section string, path string) (*template.Template, error) {
+	var prepare func(content string) string
+	if docTemplate.BundlePath != "" {
+		inliner := newAssetInliner(docTemplate.BundlePath)
+		prepare = func(content string) string {
+			return inliner.inlineHTML(content, filepath.Dir(path))
+		}
+	}
+
+	return r.cache.getPrepared(fmt.Sprint("template/", docTemplate.ID, "/", docTemplate.Version, "/", section), path, prepare)
+}
+
+// parsePartial return the parsed partial template that is shared by every document
+func (r *RenderServiceImpl) parsePartial(path string) (*template.Template, error) {
+	return r.cache.get("partial/"+path, path)
+}
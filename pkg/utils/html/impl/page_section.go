@@ -0,0 +1,35 @@
+package impl
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// pageSectionLayout wrap the running header or footer into a standalone document as required by wkhtmltopdf.
+// No script is added since javascript stay disabled while rendering, element with class page, topage, etc. is
+// filled by the native backend only
+var pageSectionLayout = template.Must(template.New("page_section").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+</head>
+<body style="margin: 0;">
+{{.}}
+</body>
+</html>
+`))
+
+// renderPageSection execute the section template with the document data and wrap it with pageSectionLayout
+func renderPageSection(tmpl *template.Template, data *map[string]interface{}) (*bytes.Buffer, error) {
+	section := new(bytes.Buffer)
+	if err := tmpl.Execute(section, *data); err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := pageSectionLayout.Execute(buf, template.HTML(section.String())); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
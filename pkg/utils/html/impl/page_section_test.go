@@ -0,0 +1,107 @@
+package impl
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/internal/document/dto"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+)
+
+func TestRenderServiceImpl_GenerateHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "header.html")
+	assert.NoError(t, os.WriteFile(path, []byte(`<p>No. {{.register}} - Halaman <span class="page"></span></p>`), 0644))
+
+	renderService := &RenderServiceImpl{cache: newTemplateCache(newFuncMap())}
+	data := map[string]interface{}{"register": 12}
+
+	header, err := renderService.GenerateHeader(&entity.Template{HeaderPath: path}, &data)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(header.String(), "<!DOCTYPE html>"))
+	assert.Contains(t, header.String(), `<p>No. 12 - Halaman <span class="page"></span></p>`)
+	assert.NotContains(t, header.String(), "<script>")
+
+	header, err = renderService.GenerateHeader(&entity.Template{}, &data)
+	assert.NoError(t, err)
+	assert.Nil(t, header)
+}
+
+func TestRenderServiceImpl_GeneratePageFooter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "footer.html")
+	assert.NoError(t, os.WriteFile(path, []byte(`<p>{{.footer}}</p>`), 0644))
+
+	renderService := &RenderServiceImpl{cache: newTemplateCache(newFuncMap())}
+	data := map[string]interface{}{"footer": "qr"}
+
+	footer, err := renderService.GeneratePageFooter(&entity.Template{FooterPath: path}, &data)
+	assert.NoError(t, err)
+	assert.Contains(t, footer.String(), "<p>qr</p>")
+
+	footer, err = renderService.GeneratePageFooter(&entity.Template{}, &data)
+	assert.NoError(t, err)
+	assert.Nil(t, footer)
+}
+
+func TestRenderServiceImpl_GenerateStatusPage(t *testing.T) {
+	// the partial path is relative to the project root
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir("../../../.."))
+	defer os.Chdir(wd)
+
+	renderService := &RenderServiceImpl{cache: newTemplateCache(newFuncMap()), officeName: "Kantor Desa Sukamaju", officeLogo: "data:image/png;base64,AAAA"}
+	data := map[string]interface{}{
+		"state":   "valid",
+		"title":   "Dokumen Sah",
+		"message": "<script>",
+	}
+
+	page, err := renderService.GenerateStatusPage(&data)
+	assert.NoError(t, err)
+	assert.Contains(t, page.String(), "<h1>Kantor Desa Sukamaju</h1>")
+	assert.Contains(t, page.String(), `<img src="data:image/png;base64,AAAA" alt="">`)
+	assert.Contains(t, page.String(), `<div class="status valid">`)
+	assert.Contains(t, page.String(), "&lt;script&gt;")
+}
+
+func TestRenderServiceImpl_GenerateStatusPage_MaskedApplicant(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir("../../../.."))
+	defer os.Chdir(wd)
+
+	// the stored description is written with the full name when the document is verified
+	status := dto.NewDocumentStatusResponse(&entity.Document{
+		ID:          "1",
+		Description: "Surat Keterangan Domisili a.n Budi Santoso",
+		RegisterID:  12,
+		Applicant:   entity.User{Name: "Budi Santoso"},
+		Template:    entity.Template{Name: "Surat Keterangan Domisili"},
+		SignedAt:    time.Now(),
+	})
+
+	renderService := &RenderServiceImpl{cache: newTemplateCache(newFuncMap()), officeName: "Kantor Desa Sukamaju"}
+	data := map[string]interface{}{
+		"state":    "valid",
+		"title":    "Dokumen Sah",
+		"message":  "",
+		"document": status,
+	}
+
+	page, err := renderService.GenerateStatusPage(&data)
+	assert.NoError(t, err)
+	assert.Contains(t, page.String(), "Surat Keterangan Domisili a.n B*** S******")
+
+	body, err := json.Marshal(status)
+	assert.NoError(t, err)
+
+	for _, name := range []string{"Budi", "Santoso"} {
+		assert.NotContains(t, page.String(), name)
+		assert.NotContains(t, string(body), name)
+	}
+}
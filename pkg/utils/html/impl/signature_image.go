@@ -0,0 +1,70 @@
+package impl
+
+import (
+	"context"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+// Default height of the signature images in millimeter, used when the signer didn't set it
+const (
+	defaultSignatureHeight = 20
+	defaultStampHeight     = 30
+)
+
+// signatureImage is the uploaded image placed on the signature block, its size and offset is in millimeter
+type signatureImage struct {
+	Src     template.URL
+	Height  uint
+	OffsetX int
+	OffsetY int
+}
+
+// loadSignatureImage read the uploaded image from the storage as data URL, so both PDF backend can render it without
+// reading any file. It return nil when the signer didn't upload one
+func (r *RenderServiceImpl) loadSignatureImage(ctx context.Context, image *entity.SignatureImage, defaultHeight uint) (*signatureImage, error) {
+	if image.ImageKey == "" {
+		return nil, nil
+	}
+
+	content, err := r.artifactStorage.Get(ctx, image.ImageKey)
+	if err == utils.ErrArtifactNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	height := image.Height
+	if height == 0 {
+		height = defaultHeight
+	}
+
+	return &signatureImage{
+		// the image is checked to be PNG or JPEG on upload, so the data URL is trusted
+		Src:     template.URL("data:" + http.DetectContentType(content) + ";base64," + base64.StdEncoding.EncodeToString(content)),
+		Height:  height,
+		OffsetX: image.OffsetX,
+		OffsetY: image.OffsetY,
+	}, nil
+}
+
+// imageBlockHeight return the space reserved for the signature and stamp image, they are placed relative to the top of
+// it so the block must be as tall as the lowest image
+func imageBlockHeight(images ...*signatureImage) int {
+	height := 0
+	for _, image := range images {
+		if image == nil {
+			continue
+		}
+
+		if bottom := int(image.Height) + image.OffsetY; bottom > height {
+			height = bottom
+		}
+	}
+
+	return height
+}
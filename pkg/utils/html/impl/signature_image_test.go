@@ -0,0 +1,61 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	mockStoragePkg "github.com/suryaadi44/eAD-System/pkg/utils/storage/mock"
+)
+
+func TestRenderServiceImpl_GenerateSignature(t *testing.T) {
+	// the partial path is relative to the project root
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir("../../../.."))
+	defer os.Chdir(wd)
+
+	png := []byte("\x89PNG\r\n\x1a\n")
+	artifactStorage := new(mockStoragePkg.MockArtifactStorage)
+	artifactStorage.On("Get", mock.Anything, "images/signature/1").Return(png, nil)
+	artifactStorage.On("Get", mock.Anything, "images/stamp/1").Return(png, nil)
+	artifactStorage.On("Get", mock.Anything, "images/stamp/2").Return([]byte(nil), utils.ErrArtifactNotFound)
+	artifactStorage.On("Get", mock.Anything, "images/stamp/3").Return([]byte(nil), errors.New("error"))
+
+	renderService := &RenderServiceImpl{cache: newTemplateCache(newFuncMap()), artifactStorage: artifactStorage}
+
+	signature, err := renderService.GenerateSignature(context.Background(), entity.User{
+		Name:      "Budi",
+		Signature: entity.SignatureImage{ImageKey: "images/signature/1"},
+		Stamp:     entity.SignatureImage{ImageKey: "images/stamp/1", Height: 35, OffsetX: -10, OffsetY: -5},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, string(*signature), `<div style="position: relative; height: 30mm;">`)
+	assert.Contains(t, string(*signature), `<img src="data:image/png;base64,iVBORw0KGgo=" style="position: absolute; left: 0mm; top: 0mm; height: 20mm;">`)
+	assert.Contains(t, string(*signature), `<img src="data:image/png;base64,iVBORw0KGgo=" style="position: absolute; left: -10mm; top: -5mm; height: 35mm;">`)
+	assert.Contains(t, string(*signature), "Budi")
+
+	signature, err = renderService.GenerateSignature(context.Background(), entity.User{
+		Name:  "Budi",
+		Stamp: entity.SignatureImage{ImageKey: "images/stamp/2"},
+	})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(*signature), "position: relative")
+
+	_, err = renderService.GenerateSignature(context.Background(), entity.User{
+		Stamp: entity.SignatureImage{ImageKey: "images/stamp/3"},
+	})
+	assert.Error(t, err)
+}
+
+func TestImageBlockHeight(t *testing.T) {
+	assert.Equal(t, 0, imageBlockHeight(nil, nil))
+	assert.Equal(t, 20, imageBlockHeight(&signatureImage{Height: 20}, nil))
+	assert.Equal(t, 35, imageBlockHeight(&signatureImage{Height: 20}, &signatureImage{Height: 30, OffsetY: 5}))
+	assert.Equal(t, 20, imageBlockHeight(&signatureImage{Height: 20}, &signatureImage{Height: 30, OffsetY: -15}))
+}
@@ -0,0 +1,90 @@
+package impl
+
+import (
+	"crypto/sha256"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// templateCache keep the parsed template so the file is not parsed on every render. The entry is checked against
+// the file modification time and size, and the file is only read again to compare its checksum when they change,
+// so the edited file on disk is picked up without restart. Parsed template is safe to be executed concurrently
+type templateCache struct {
+	mu      sync.RWMutex
+	funcMap template.FuncMap
+	entries map[string]*cachedTemplate
+}
+
+type cachedTemplate struct {
+	tmpl     *template.Template
+	path     string
+	checksum [sha256.Size]byte
+	modTime  time.Time
+	size     int64
+}
+
+func newTemplateCache(funcMap template.FuncMap) *templateCache {
+	return &templateCache{
+		funcMap: funcMap,
+		entries: make(map[string]*cachedTemplate),
+	}
+}
+
+// get return the parsed template of the file stored under key, the file is parsed when it is not cached yet or
+// its content has changed
+func (c *templateCache) get(key string, path string) (*template.Template, error) {
+	return c.getPrepared(key, path, nil)
+}
+
+// getPrepared is get that pass the file content to prepare before it is parsed, eg: to inline the bundle asset
+func (c *templateCache) getPrepared(key string, path string, prepare func(content string) string) (*template.Template, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if ok && entry.path == path && entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+		return entry.tmpl, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	checksum := sha256.Sum256(content)
+
+	var tmpl *template.Template
+	if ok && entry.path == path && entry.checksum == checksum {
+		// only the file stat is changed, eg: touched or copied
+		tmpl = entry.tmpl
+	} else {
+		source := string(content)
+		if prepare != nil {
+			source = prepare(source)
+		}
+
+		tmpl, err = template.New(filepath.Base(path)).Funcs(c.funcMap).Parse(source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	c.entries[key] = &cachedTemplate{
+		tmpl:     tmpl,
+		path:     path,
+		checksum: checksum,
+		modTime:  info.ModTime(),
+		size:     info.Size(),
+	}
+	c.mu.Unlock()
+
+	return tmpl, nil
+}
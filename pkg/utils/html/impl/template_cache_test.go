@@ -0,0 +1,122 @@
+package impl
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+)
+
+func writeTemplate(t testing.TB, path string, content string, modTime time.Time) {
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestTemplateCache_Get(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "letter.html")
+	modTime := time.Now().Add(-time.Hour)
+	writeTemplate(t, path, `<p>{{upper .name}}</p>`, modTime)
+
+	cache := newTemplateCache(newFuncMap())
+
+	first, err := cache.get("template/1/1/body", path)
+	assert.NoError(t, err)
+
+	second, err := cache.get("template/1/1/body", path)
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+
+	// same content with newer modification time is not parsed again
+	writeTemplate(t, path, `<p>{{upper .name}}</p>`, modTime.Add(time.Minute))
+	third, err := cache.get("template/1/1/body", path)
+	assert.NoError(t, err)
+	assert.Same(t, first, third)
+
+	// changed content is parsed again
+	writeTemplate(t, path, `<p>{{lower .name}}</p>`, modTime.Add(2*time.Minute))
+	fourth, err := cache.get("template/1/1/body", path)
+	assert.NoError(t, err)
+	assert.NotSame(t, first, fourth)
+
+	buf := new(strings.Builder)
+	assert.NoError(t, fourth.Execute(buf, map[string]interface{}{"name": "Budi"}))
+	assert.Equal(t, "<p>budi</p>", buf.String())
+
+	_, err = cache.get("template/2/1/body", filepath.Join(t.TempDir(), "missing.html"))
+	assert.Error(t, err)
+}
+
+func TestRenderServiceImpl_GenerateHTMLDocumentConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "letter.html")
+	writeTemplate(t, path, `<p>{{.name}}</p>`, time.Now())
+
+	renderService := &RenderServiceImpl{cache: newTemplateCache(newFuncMap())}
+	docTemplate := &entity.Template{Path: path, Version: 1}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data := map[string]interface{}{"name": "Budi"}
+			buf, err := renderService.GenerateHTMLDocument(docTemplate, &data)
+			assert.NoError(t, err)
+			assert.Equal(t, "<p>Budi</p>", buf.String())
+		}()
+	}
+	wg.Wait()
+}
+
+func newBenchmarkTemplate(b *testing.B) (string, map[string]interface{}) {
+	var content strings.Builder
+	content.WriteString(`<html><body><h1>{{upper .name}}</h1>`)
+	for i := 0; i < 50; i++ {
+		content.WriteString(`<p>Yang bertanda tangan di bawah ini {{title .name}}, lahir {{tanggal .birth_date}}, umur {{umur .birth_date}} tahun.</p>`)
+	}
+	content.WriteString(`{{range .family}}<tr><td>{{.name}}</td></tr>{{end}}</body></html>`)
+
+	path := filepath.Join(b.TempDir(), "letter.html")
+	writeTemplate(b, path, content.String(), time.Now())
+
+	return path, map[string]interface{}{
+		"name":       "budi santoso",
+		"birth_date": "2000-08-17",
+		"family":     []map[string]interface{}{{"name": "Ani"}, {"name": "Siti"}},
+	}
+}
+
+func BenchmarkGenerateHTMLDocument_Uncached(b *testing.B) {
+	path, data := newBenchmarkTemplate(b)
+	funcMap := newFuncMap()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tmpl, err := template.New(filepath.Base(path)).Funcs(funcMap).ParseFiles(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if err = tmpl.Execute(new(strings.Builder), data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateHTMLDocument_Cached(b *testing.B) {
+	path, data := newBenchmarkTemplate(b)
+	renderService := &RenderServiceImpl{cache: newTemplateCache(newFuncMap())}
+	docTemplate := &entity.Template{Path: path, Version: 1}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := renderService.GenerateHTMLDocument(docTemplate, &data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
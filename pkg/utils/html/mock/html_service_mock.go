@@ -2,6 +2,7 @@ package mock
 
 import (
 	"bytes"
+	"context"
 	"github.com/stretchr/testify/mock"
 	"github.com/suryaadi44/eAD-System/pkg/entity"
 	"html/template"
@@ -11,8 +12,8 @@ type MockRenderService struct {
 	mock.Mock
 }
 
-func (m *MockRenderService) GenerateSignature(signer entity.User) (*template.HTML, error) {
-	args := m.Called(signer)
+func (m *MockRenderService) GenerateSignature(ctx context.Context, signer entity.User) (*template.HTML, error) {
+	args := m.Called(ctx, signer)
 	return args.Get(0).(*template.HTML), args.Error(1)
 }
 
@@ -25,3 +26,18 @@ func (m *MockRenderService) GenerateHTMLDocument(docTemplate *entity.Template, d
 	args := m.Called(docTemplate, data)
 	return args.Get(0).(*bytes.Buffer), args.Error(1)
 }
+
+func (m *MockRenderService) GenerateHeader(docTemplate *entity.Template, data *map[string]interface{}) (*bytes.Buffer, error) {
+	args := m.Called(docTemplate, data)
+	return args.Get(0).(*bytes.Buffer), args.Error(1)
+}
+
+func (m *MockRenderService) GeneratePageFooter(docTemplate *entity.Template, data *map[string]interface{}) (*bytes.Buffer, error) {
+	args := m.Called(docTemplate, data)
+	return args.Get(0).(*bytes.Buffer), args.Error(1)
+}
+
+func (m *MockRenderService) GenerateStatusPage(data *map[string]interface{}) (*bytes.Buffer, error) {
+	args := m.Called(data)
+	return args.Get(0).(*bytes.Buffer), args.Error(1)
+}
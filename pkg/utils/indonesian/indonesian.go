@@ -0,0 +1,170 @@
+package indonesian
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	monthNames = [...]string{
+		"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+		"Juli", "Agustus", "September", "Oktober", "November", "Desember",
+	}
+
+	dayNames = [...]string{
+		"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu",
+	}
+
+	digitWords = [...]string{
+		"", "satu", "dua", "tiga", "empat", "lima", "enam", "tujuh", "delapan", "sembilan", "sepuluh", "sebelas",
+	}
+
+	// dateLayouts is the list of date format that is accepted from template field value
+	dateLayouts = []string{
+		"2006-01-02",
+		"02-01-2006",
+		"02/01/2006",
+		"2006/01/02",
+		time.RFC3339,
+		"2006-01-02 15:04:05",
+	}
+)
+
+// MonthName return indonesian name of the month
+func MonthName(month time.Month) string {
+	return monthNames[month-1]
+}
+
+// DayName return indonesian name of the weekday
+func DayName(day time.Weekday) string {
+	return dayNames[day]
+}
+
+// FormatDate format time as "02 Januari 2006"
+func FormatDate(t time.Time) string {
+	return t.Format("02") + " " + MonthName(t.Month()) + " " + t.Format("2006")
+}
+
+// FormatDayDate format time as "Senin, 02 Januari 2006"
+func FormatDayDate(t time.Time) string {
+	return DayName(t.Weekday()) + ", " + FormatDate(t)
+}
+
+// ParseDate parse date from the commonly used date format in form input
+func ParseDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Terbilang spell the number in bahasa indonesia, eg: 1250 become "seribu dua ratus lima puluh"
+func Terbilang(n int64) string {
+	if n == 0 {
+		return "nol"
+	}
+
+	// the magnitude is spelled as unsigned, since the negation of the minimum int64 overflow back to itself
+	if n < 0 {
+		return "minus " + strings.TrimSpace(spell(uint64(-(n+1))+1))
+	}
+
+	return strings.TrimSpace(spell(uint64(n)))
+}
+
+func spell(n uint64) string {
+	switch {
+	case n < 12:
+		return digitWords[n]
+	case n < 20:
+		return spell(n-10) + " belas"
+	case n < 100:
+		return strings.TrimSpace(spell(n/10) + " puluh " + spell(n%10))
+	case n < 200:
+		return strings.TrimSpace("seratus " + spell(n-100))
+	case n < 1000:
+		return strings.TrimSpace(spell(n/100) + " ratus " + spell(n%100))
+	case n < 2000:
+		return strings.TrimSpace("seribu " + spell(n-1000))
+	case n < 1_000_000:
+		return strings.TrimSpace(spell(n/1000) + " ribu " + spell(n%1000))
+	case n < 1_000_000_000:
+		return strings.TrimSpace(spell(n/1_000_000) + " juta " + spell(n%1_000_000))
+	case n < 1_000_000_000_000:
+		return strings.TrimSpace(spell(n/1_000_000_000) + " miliar " + spell(n%1_000_000_000))
+	default:
+		return strings.TrimSpace(spell(n/1_000_000_000_000) + " triliun " + spell(n%1_000_000_000_000))
+	}
+}
+
+// Roman convert number between 1 and 3999 to roman numeral, commonly used for month in letter number
+func Roman(n int) string {
+	if n <= 0 || n >= 4000 {
+		return strconv.Itoa(n)
+	}
+
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+
+	var builder strings.Builder
+	for i, value := range values {
+		for n >= value {
+			builder.WriteString(symbols[i])
+			n -= value
+		}
+	}
+
+	return builder.String()
+}
+
+// Age calculate age in full years from birth date at the given time
+func Age(birthDate time.Time, at time.Time) int {
+	age := at.Year() - birthDate.Year()
+	if at.Month() < birthDate.Month() || (at.Month() == birthDate.Month() && at.Day() < birthDate.Day()) {
+		age--
+	}
+
+	if age < 0 {
+		return 0
+	}
+
+	return age
+}
+
+// MaskNIK hide the middle part of NIK, leaving the region code and the last four digits visible
+func MaskNIK(nik string) string {
+	nik = strings.TrimSpace(nik)
+	if len(nik) <= 10 {
+		return strings.Repeat("*", len(nik))
+	}
+
+	return nik[:6] + strings.Repeat("*", len(nik)-10) + nik[len(nik)-4:]
+}
+
+// MaskName hide every letter of the name except the first one of each word, eg: "Budi Santoso" become "B*** S******"
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		runes := []rune(word)
+		words[i] = string(runes[0]) + strings.Repeat("*", len(runes)-1)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// TitleCase capitalize the first letter of every word, eg: "BUDI santoso" become "Budi Santoso"
+func TitleCase(value string) string {
+	words := strings.Fields(strings.ToLower(value))
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = []rune(strings.ToUpper(string(runes[0])))[0]
+		words[i] = string(runes)
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,88 @@
+package indonesian
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2022, time.August, 17, 0, 0, 0, 0, time.Local)
+
+	assert.Equal(t, "17 Agustus 2022", FormatDate(date))
+	assert.Equal(t, "Rabu, 17 Agustus 2022", FormatDayDate(date))
+}
+
+func TestParseDate(t *testing.T) {
+	for _, value := range []string{"2022-08-17", "17-08-2022", "17/08/2022", "2022/08/17"} {
+		date, ok := ParseDate(value)
+		assert.True(t, ok, value)
+		assert.Equal(t, time.Date(2022, time.August, 17, 0, 0, 0, 0, time.Local), date, value)
+	}
+
+	_, ok := ParseDate("tujuh belas")
+	assert.False(t, ok)
+}
+
+func TestTerbilang(t *testing.T) {
+	for n, expected := range map[int64]string{
+		0:             "nol",
+		1:             "satu",
+		11:            "sebelas",
+		12:            "dua belas",
+		20:            "dua puluh",
+		100:           "seratus",
+		115:           "seratus lima belas",
+		1000:          "seribu",
+		1250:          "seribu dua ratus lima puluh",
+		21000:         "dua puluh satu ribu",
+		100000:        "seratus ribu",
+		1000000:       "satu juta",
+		2500000000:    "dua miliar lima ratus juta",
+		-45:           "minus empat puluh lima",
+		1000000000000: "satu triliun",
+		math.MaxInt64: "sembilan juta dua ratus dua puluh tiga ribu tiga ratus tujuh puluh dua triliun tiga puluh enam miliar delapan ratus lima puluh empat juta tujuh ratus tujuh puluh lima ribu delapan ratus tujuh",
+		math.MinInt64: "minus sembilan juta dua ratus dua puluh tiga ribu tiga ratus tujuh puluh dua triliun tiga puluh enam miliar delapan ratus lima puluh empat juta tujuh ratus tujuh puluh lima ribu delapan ratus delapan",
+	} {
+		assert.Equal(t, expected, Terbilang(n), n)
+	}
+}
+
+func TestRoman(t *testing.T) {
+	for n, expected := range map[int]string{
+		1:    "I",
+		4:    "IV",
+		9:    "IX",
+		12:   "XII",
+		2022: "MMXXII",
+		0:    "0",
+	} {
+		assert.Equal(t, expected, Roman(n), n)
+	}
+}
+
+func TestAge(t *testing.T) {
+	birthDate := time.Date(2000, time.August, 17, 0, 0, 0, 0, time.Local)
+
+	assert.Equal(t, 21, Age(birthDate, time.Date(2022, time.August, 16, 0, 0, 0, 0, time.Local)))
+	assert.Equal(t, 22, Age(birthDate, time.Date(2022, time.August, 17, 0, 0, 0, 0, time.Local)))
+	assert.Equal(t, 0, Age(birthDate, time.Date(1999, time.January, 1, 0, 0, 0, 0, time.Local)))
+}
+
+func TestMaskNIK(t *testing.T) {
+	assert.Equal(t, "320101******0001", MaskNIK("3201011708000001"))
+	assert.Equal(t, "*****", MaskNIK("12345"))
+}
+
+func TestMaskName(t *testing.T) {
+	assert.Equal(t, "B*** S******", MaskName("Budi  Santoso"))
+	assert.Equal(t, "Ś*** A", MaskName("Śiti A"))
+	assert.Equal(t, "", MaskName(""))
+}
+
+func TestTitleCase(t *testing.T) {
+	assert.Equal(t, "Budi Santoso", TitleCase("BUDI  santoso"))
+	assert.Equal(t, "", TitleCase(""))
+}
@@ -2,6 +2,7 @@ package impl
 
 import (
 	"github.com/suryaadi44/eAD-System/pkg/utils/jwt_service"
+	"github.com/suryaadi44/eAD-System/pkg/utils/rbac"
 	"time"
 
 	"github.com/golang-jwt/jwt"
@@ -21,11 +22,17 @@ func NewJWTService(secretKey string, exp time.Duration) jwt_service.JWTService {
 	}
 }
 
-func (j *JWTServiceImpl) GenerateToken(user *entity.User) (string, error) {
+// GenerateToken issue the token of the user, the permission of the user role is carried by the token so the route can
+// be checked without looking up the role. The issue time is compared to the revocation time of the user
+func (j *JWTServiceImpl) GenerateToken(user *entity.User, permissions []string) (string, error) {
+	now := time.Now()
 	claims := &jwt.MapClaims{
-		"user_id": user.ID,
-		"role":    user.Role,
-		"exp":     time.Now().Add(j.exp).Unix(),
+		"user_id":                      user.ID,
+		"role":                         user.Role,
+		rbac.ClaimPermissions:          permissions,
+		"iat":                          now.Unix(),
+		jwt_service.ClaimIssuedAtMilli: now.UnixMilli(),
+		"exp":                          now.Add(j.exp).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(j.secretKey))
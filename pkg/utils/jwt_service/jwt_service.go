@@ -6,7 +6,11 @@ import (
 	"github.com/suryaadi44/eAD-System/pkg/entity"
 )
 
+// ClaimIssuedAtMilli is the token claim holding the issue time in millisecond, the standard iat is only in second and
+// can't tell apart the token issued in the same second as the revocation
+const ClaimIssuedAtMilli = "iat_ms"
+
 type JWTService interface {
-	GenerateToken(user *entity.User) (string, error)
+	GenerateToken(user *entity.User, permissions []string) (string, error)
 	GetClaims(c *echo.Context) jwt.MapClaims
 }
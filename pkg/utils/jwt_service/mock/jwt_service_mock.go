@@ -11,8 +11,8 @@ type MockJWTService struct {
 	mock.Mock
 }
 
-func (m *MockJWTService) GenerateToken(user *entity.User) (string, error) {
-	args := m.Called(user)
+func (m *MockJWTService) GenerateToken(user *entity.User, permissions []string) (string, error) {
+	args := m.Called(user, permissions)
 	return args.String(0), args.Error(1)
 }
 
@@ -0,0 +1,53 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils/notifier"
+)
+
+// LogNotifierImpl write the message instead of sending it, it is the stand in of the SMTP notifier on local development
+type LogNotifierImpl struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLogNotifierImpl create notifier that append the message to the file, the message is written to the standard log
+// when the path is empty
+func NewLogNotifierImpl(path string) notifier.Notifier {
+	return &LogNotifierImpl{path: path}
+}
+
+func (l *LogNotifierImpl) Send(ctx context.Context, message *notifier.Message) error {
+	entry := fmt.Sprintf("[%s] To: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), message.To, message.Subject, message.Body)
+	if l.path == "" {
+		log.Print(entry)
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+
+	// the message contain the reset token, so the file is only readable by the owner
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err = file.WriteString(entry); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
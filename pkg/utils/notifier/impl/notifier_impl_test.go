@@ -0,0 +1,181 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/notifier"
+)
+
+func TestLogNotifierImpl(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail", "outbox.log")
+	logNotifier := NewLogNotifierImpl(path)
+	ctx := context.Background()
+
+	assert.NoError(t, logNotifier.Send(ctx, &notifier.Message{To: "a@example.com", Subject: "First", Body: "token-1"}))
+	assert.NoError(t, logNotifier.Send(ctx, &notifier.Message{To: "b@example.com", Subject: "Second", Body: "token-2"}))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "To: a@example.com\nSubject: First\n\ntoken-1")
+	assert.Contains(t, string(content), "To: b@example.com\nSubject: Second\n\ntoken-2")
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+// fakeSMTPServer answer the SMTP command on the connection and return the received mail, a reply for a command
+// replace the default one
+func fakeSMTPServer(t *testing.T, conn net.Conn, extensions []string, replies map[string]string) <-chan string {
+	received := make(chan string, 1)
+	go func() {
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		var mail strings.Builder
+
+		_ = text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				received <- mail.String()
+				return
+			}
+
+			command := strings.ToUpper(strings.Fields(line)[0])
+			if reply, ok := replies[command]; ok {
+				_ = text.PrintfLine("%s", reply)
+				continue
+			}
+
+			switch command {
+			case "EHLO":
+				lines := append([]string{"localhost"}, extensions...)
+				for i, extension := range lines {
+					separator := "-"
+					if i == len(lines)-1 {
+						separator = " "
+					}
+					_ = text.PrintfLine("250%s%s", separator, extension)
+				}
+			case "AUTH":
+				_ = text.PrintfLine("235 authenticated")
+			case "MAIL", "RCPT":
+				fmt.Fprintf(&mail, "%s\n", line)
+				_ = text.PrintfLine("250 ok")
+			case "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				data, err := text.ReadDotBytes()
+				assert.NoError(t, err)
+				mail.Write(data)
+				_ = text.PrintfLine("250 queued")
+			case "QUIT":
+				_ = text.PrintfLine("221 bye")
+				received <- mail.String()
+				return
+			default:
+				_ = text.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+
+	return received
+}
+
+func TestSMTPNotifierImpl(t *testing.T) {
+	for _, tc := range []struct {
+		Name         string
+		Username     string
+		Extensions   []string
+		Replies      map[string]string
+		DialErr      error
+		Message      *notifier.Message
+		ExpectedErr  string
+		ExpectedMail string
+	}{
+		{
+			Name:         "Success",
+			Username:     "user",
+			Extensions:   []string{"AUTH PLAIN"},
+			Message:      &notifier.Message{To: "a@example.com", Subject: "Reset", Body: "line 1\nline 2"},
+			ExpectedMail: "MAIL FROM:<noreply@example.com>\nRCPT TO:<a@example.com>\nFrom: noreply@example.com\nTo: a@example.com\nSubject: Reset\nMIME-Version: 1.0\nContent-Type: text/plain; charset=\"utf-8\"\n\nline 1\nline 2\n",
+		},
+		{
+			Name:         "Success without authentication and header injection removed",
+			Message:      &notifier.Message{To: "a@example.com", Subject: "Reset\r\nBcc: b@example.com", Body: "body"},
+			ExpectedMail: "MAIL FROM:<noreply@example.com>\nRCPT TO:<a@example.com>\nFrom: noreply@example.com\nTo: a@example.com\nSubject: ResetBcc: b@example.com\nMIME-Version: 1.0\nContent-Type: text/plain; charset=\"utf-8\"\n\nbody\n",
+		},
+		{
+			Name:        "Error server without authentication",
+			Username:    "user",
+			Message:     &notifier.Message{To: "a@example.com", Subject: "Reset", Body: "body"},
+			ExpectedErr: utils.ErrSMTPAuthNotSupported.Error(),
+		},
+		{
+			Name:        "Error recipient refused",
+			Message:     &notifier.Message{To: "a@example.com", Subject: "Reset", Body: "body"},
+			Replies:     map[string]string{"RCPT": "550 no such user"},
+			ExpectedErr: "no such user",
+		},
+		{
+			Name:        "Error dialing",
+			Message:     &notifier.Message{To: "a@example.com", Subject: "Reset", Body: "body"},
+			DialErr:     errors.New("Generic error"),
+			ExpectedErr: "Generic error",
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, server := net.Pipe()
+			received := fakeSMTPServer(t, server, tc.Extensions, tc.Replies)
+
+			smtpNotifier := NewSMTPNotifierImpl("localhost", "25", tc.Username, "pass", "noreply@example.com").(*SMTPNotifierImpl)
+			smtpNotifier.dial = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+				assert.Equal(t, "localhost:25", addr)
+				if tc.DialErr != nil {
+					client.Close()
+					return nil, tc.DialErr
+				}
+				return client, nil
+			}
+
+			err := smtpNotifier.Send(context.Background(), tc.Message)
+
+			if tc.ExpectedErr != "" {
+				assert.ErrorContains(t, err, tc.ExpectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.ExpectedMail, <-received)
+			}
+		})
+	}
+}
+
+func TestSMTPNotifierImpl_Timeout(t *testing.T) {
+	// the relay accept the connection but never greet
+	client, server := net.Pipe()
+	defer server.Close()
+
+	smtpNotifier := NewSMTPNotifierImpl("localhost", "25", "", "", "noreply@example.com").(*SMTPNotifierImpl)
+	smtpNotifier.dial = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		return client, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := smtpNotifier.Send(ctx, &notifier.Message{To: "a@example.com", Subject: "Reset", Body: "body"})
+
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
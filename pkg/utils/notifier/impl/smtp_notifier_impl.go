@@ -0,0 +1,116 @@
+package impl
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/notifier"
+)
+
+// headerReplacer remove the line break from the header value, so it can't inject another header
+var headerReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+type SMTPNotifierImpl struct {
+	host string
+	addr string
+	auth smtp.Auth
+	from string
+	dial func(ctx context.Context, network string, addr string) (net.Conn, error)
+}
+
+// NewSMTPNotifierImpl create notifier that send the message by email, the authentication is skipped when the username is
+// empty, eg: for a local relay
+func NewSMTPNotifierImpl(host string, port string, username string, password string, from string) notifier.Notifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPNotifierImpl{
+		host: host,
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+		dial: (&net.Dialer{}).DialContext,
+	}
+}
+
+// Send deliver the message the same way as smtp.SendMail, but the connection follow the deadline of the context, so
+// a relay that stop responding can't hold the sender forever
+func (s *SMTPNotifierImpl) Send(ctx context.Context, message *notifier.Message) error {
+	conn, err := s.dial(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+
+	if s.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return utils.ErrSMTPAuthNotSupported
+		}
+
+		if err = client.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(s.from); err != nil {
+		return err
+	}
+
+	if err = client.Rcpt(message.To); err != nil {
+		return err
+	}
+
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = data.Write(buildMail(s.from, message)); err != nil {
+		return err
+	}
+
+	if err = data.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// buildMail format the message as a plain text mail, the line of the body is ended with CRLF as required by SMTP
+func buildMail(from string, message *notifier.Message) []byte {
+	var mail strings.Builder
+	fmt.Fprintf(&mail, "From: %s\r\n", headerReplacer.Replace(from))
+	fmt.Fprintf(&mail, "To: %s\r\n", headerReplacer.Replace(message.To))
+	fmt.Fprintf(&mail, "Subject: %s\r\n", headerReplacer.Replace(message.Subject))
+	mail.WriteString("MIME-Version: 1.0\r\n")
+	mail.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	mail.WriteString("\r\n")
+	mail.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+
+	return []byte(mail.String())
+}
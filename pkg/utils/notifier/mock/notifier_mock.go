@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/suryaadi44/eAD-System/pkg/utils/notifier"
+)
+
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) Send(ctx context.Context, message *notifier.Message) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
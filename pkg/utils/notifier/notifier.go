@@ -0,0 +1,21 @@
+package notifier
+
+import "context"
+
+// Supported notifier, log write the message to a file for local development while smtp send it by email
+const (
+	NotifierLog  = "log"
+	NotifierSMTP = "smtp"
+)
+
+// Message is the notification sent to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier deliver the message to the user, eg: the password reset token
+type Notifier interface {
+	Send(ctx context.Context, message *Message) error
+}
@@ -0,0 +1,30 @@
+package password
+
+import (
+	"unicode/utf8"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+// MaxLength is the longest password in byte, bcrypt only use the first 72 byte so the rest would be ignored
+const MaxLength = 72
+
+// Policy is the rule of the password chosen by the user
+type Policy struct {
+	MinLength int // minimum number of character
+	History   int // number of the last password that can't be reused, zero allow any reuse
+}
+
+// CheckLength count the character instead of the byte, so the non ASCII password isn't favoured. The maximum length
+// is in byte since it is the limit of the hash
+func (p Policy) CheckLength(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return utils.ErrPasswordTooShort
+	}
+
+	if len(password) > MaxLength {
+		return utils.ErrPasswordTooLong
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+func TestPolicyCheckLength(t *testing.T) {
+	policy := Policy{MinLength: 8}
+	for _, tc := range []struct {
+		Name        string
+		Password    string
+		ExpectedErr error
+	}{
+		{
+			Name:     "Success",
+			Password: "password",
+		},
+		{
+			Name:        "Error too short",
+			Password:    "passwor",
+			ExpectedErr: utils.ErrPasswordTooShort,
+		},
+		{
+			Name:     "Success maximum length",
+			Password: strings.Repeat("a", MaxLength),
+		},
+		{
+			Name:        "Error too long",
+			Password:    strings.Repeat("a", MaxLength+1),
+			ExpectedErr: utils.ErrPasswordTooLong,
+		},
+		{
+			Name:        "Error multibyte character over the byte limit",
+			Password:    strings.Repeat("ä", MaxLength/2+1),
+			ExpectedErr: utils.ErrPasswordTooLong,
+		},
+		{
+			Name:        "Error multibyte character counted once",
+			Password:    "pässwör",
+			ExpectedErr: utils.ErrPasswordTooShort,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.ExpectedErr, policy.CheckLength(tc.Password))
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package impl
+
+import "strings"
+
+// Font family of the standard PDF font, every PDF reader has them so the font is never embedded
+const (
+	familySans  = "sans"
+	familySerif = "serif"
+	familyMono  = "mono"
+)
+
+// fontFace is one of the standard Type1 font, widths are in 1/1000 of the font size for character 32 to 126
+type fontFace struct {
+	name   string
+	widths *[95]uint16
+}
+
+var helveticaWidths = [95]uint16{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]uint16{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+var timesWidths = [95]uint16{
+	250, 333, 408, 500, 500, 833, 778, 180, 333, 333, 500, 564, 250, 333, 250, 278,
+	500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 278, 278, 564, 564, 564, 444,
+	921, 722, 667, 667, 722, 611, 556, 722, 722, 333, 389, 722, 611, 889, 722, 722,
+	556, 722, 667, 556, 611, 722, 722, 944, 722, 722, 611, 333, 278, 333, 469, 500,
+	333, 444, 500, 444, 500, 444, 333, 500, 500, 278, 278, 500, 278, 778, 500, 500,
+	500, 500, 333, 389, 278, 500, 500, 722, 500, 500, 444, 480, 200, 480, 541,
+}
+
+var timesBoldWidths = [95]uint16{
+	250, 333, 555, 500, 500, 1000, 833, 278, 333, 333, 500, 570, 250, 333, 250, 278,
+	500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 333, 333, 570, 570, 570, 500,
+	930, 722, 667, 722, 722, 667, 611, 778, 778, 389, 500, 778, 667, 944, 722, 778,
+	611, 778, 722, 556, 667, 722, 722, 1000, 722, 722, 667, 333, 278, 333, 581, 500,
+	333, 500, 556, 444, 556, 444, 333, 500, 556, 278, 333, 556, 278, 833, 556, 500,
+	556, 556, 444, 389, 333, 556, 500, 722, 500, 500, 444, 394, 220, 394, 520,
+}
+
+var courierWidths = func() (widths [95]uint16) {
+	for i := range widths {
+		widths[i] = 600
+	}
+	return widths
+}()
+
+// the oblique and italic face use the upright widths, they are close enough to break the line at the same place
+var fontFaces = map[string]*fontFace{
+	"sans":              {name: "Helvetica", widths: &helveticaWidths},
+	"sans-bold":         {name: "Helvetica-Bold", widths: &helveticaBoldWidths},
+	"sans-italic":       {name: "Helvetica-Oblique", widths: &helveticaWidths},
+	"sans-bold-italic":  {name: "Helvetica-BoldOblique", widths: &helveticaBoldWidths},
+	"serif":             {name: "Times-Roman", widths: &timesWidths},
+	"serif-bold":        {name: "Times-Bold", widths: &timesBoldWidths},
+	"serif-italic":      {name: "Times-Italic", widths: &timesWidths},
+	"serif-bold-italic": {name: "Times-BoldItalic", widths: &timesBoldWidths},
+	"mono":              {name: "Courier", widths: &courierWidths},
+	"mono-bold":         {name: "Courier-Bold", widths: &courierWidths},
+	"mono-italic":       {name: "Courier-Oblique", widths: &courierWidths},
+	"mono-bold-italic":  {name: "Courier-BoldOblique", widths: &courierWidths},
+}
+
+func lookupFontFace(family string, bold bool, italic bool) *fontFace {
+	key := family
+	if bold {
+		key += "-bold"
+	}
+	if italic {
+		key += "-italic"
+	}
+
+	return fontFaces[key]
+}
+
+// fontFamily map the css font-family into one of the standard font family
+func fontFamily(value string) string {
+	value = strings.ToLower(value)
+	switch {
+	case strings.Contains(value, "courier"), strings.Contains(value, "mono"):
+		return familyMono
+	case strings.Contains(value, "arial"), strings.Contains(value, "helvetica"), strings.Contains(value, "verdana"),
+		strings.Contains(value, "calibri"), strings.Contains(value, "tahoma"), strings.Contains(value, "sans"):
+		return familySans
+	default:
+		return familySerif
+	}
+}
+
+// winAnsiSpecial is the WinAnsiEncoding code of character outside latin-1 that commonly appear in a letter
+var winAnsiSpecial = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// specialWidths is the width of the WinAnsiEncoding code outside 32 to 126, shared by every font since they only
+// differ slightly
+var specialWidths = map[byte]uint16{
+	0x80: 556, 0x85: 1000, 0x91: 278, 0x92: 278, 0x93: 444, 0x94: 444, 0x95: 350, 0x96: 556, 0x97: 1000, 0x99: 1000,
+	0xA0: 278, 0xB0: 400, 0xB7: 278,
+}
+
+// encodeText convert the text into WinAnsiEncoding, character that can't be encoded is replaced with question mark
+func encodeText(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		switch {
+		case r >= 32 && r <= 126, r >= 0xA0 && r <= 0xFF:
+			sb.WriteByte(byte(r))
+		case r == '\t':
+			sb.WriteByte(' ')
+		default:
+			if code, ok := winAnsiSpecial[r]; ok {
+				sb.WriteByte(code)
+			} else {
+				sb.WriteByte('?')
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// textWidth return the width of the encoded text in point
+func (f *fontFace) textWidth(encoded string, size float64) float64 {
+	var total uint
+	for i := 0; i < len(encoded); i++ {
+		c := encoded[i]
+		switch {
+		case c >= 32 && c <= 126:
+			total += uint(f.widths[c-32])
+		case specialWidths[c] != 0:
+			total += uint(specialWidths[c])
+		default:
+			// accented letter and the rest of latin-1 are about as wide as a lowercase letter
+			total += uint(f.widths['n'-32])
+		}
+	}
+
+	return float64(total) * size / 1000
+}
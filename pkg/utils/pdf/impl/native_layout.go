@@ -0,0 +1,948 @@
+package impl
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Kind of the inline content
+const (
+	atomWord = iota
+	atomSpace
+	atomBreak
+	atomImage
+)
+
+// atom is the smallest inline content, glued atom has no break opportunity before it, eg: `<b>Hal</b>aman`
+type atom struct {
+	kind   int
+	text   string
+	st     *style
+	width  float64
+	height float64
+	image  *pdfImage
+	glued  bool
+}
+
+// item is an atomic horizontal slice of the flow, like a line of text or a table row, that is never split across
+// page. Its operation is positioned from the item top, margin is a spacer that is dropped at the top of the page
+type item struct {
+	height      float64
+	baseline    float64
+	ops         []drawOp
+	spacer      bool
+	breakBefore bool
+	breakAfter  bool
+}
+
+type layouter struct {
+	ctx       context.Context
+	rules     []cssRule
+	images    *imageLoader
+	zoom      float64
+	page      int
+	pageCount int
+}
+
+func newLayouter(ctx context.Context, doc *html.Node, images *imageLoader, zoom float64) *layouter {
+	l := &layouter{ctx: ctx, images: images, zoom: zoom}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "style" && n.FirstChild != nil {
+			l.rules = append(l.rules, parseStylesheet(n.FirstChild.Data, len(l.rules))...)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return l
+}
+
+// layout lay the document out into a single column of item starting at x
+func (l *layouter) layout(doc *html.Node, x float64, width float64) []item {
+	return l.children(doc, newRootStyle(l.zoom), x, width)
+}
+
+// children lay out the content of the block, consecutive inline content is broken into line while block child is
+// laid out recursively
+func (l *layouter) children(n *html.Node, st *style, x float64, width float64) []item {
+	var items []item
+	var atoms []atom
+	indent := st.textIndent
+	flush := func() {
+		lines := l.lines(atoms, st, x, width, indent)
+		if len(lines) > 0 {
+			indent = 0
+		}
+		items = append(items, lines...)
+		atoms = nil
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if l.ctx.Err() != nil {
+			return items
+		}
+
+		switch c.Type {
+		case html.TextNode:
+			atoms = textAtoms(c.Data, st, atoms)
+		case html.ElementNode:
+			if skippedTags[c.Data] {
+				continue
+			}
+
+			cst := computeStyle(st, c, l.rules, l.zoom)
+			switch {
+			case cst.display == displayNone:
+			case cst.display == displayInline:
+				atoms = l.inlineAtoms(c, cst, width, atoms)
+			default:
+				flush()
+				items = appendItems(items, l.block(c, cst, x, width)...)
+			}
+		}
+	}
+	flush()
+
+	return items
+}
+
+// appendItems add the item after collapsing adjacent margin into the larger one, like the browser do
+func appendItems(items []item, added ...item) []item {
+	for _, it := range added {
+		last := len(items) - 1
+		if it.spacer && last >= 0 && items[last].spacer {
+			if it.height > items[last].height {
+				items[last].height = it.height
+			}
+			items[last].breakBefore = items[last].breakBefore || it.breakBefore
+			items[last].breakAfter = items[last].breakAfter || it.breakAfter
+			continue
+		}
+		items = append(items, it)
+	}
+
+	return items
+}
+
+func (l *layouter) block(n *html.Node, st *style, x float64, width float64) []item {
+	if n.Data == "table" || st.display == displayTable {
+		return l.table(n, st, x, width)
+	}
+
+	items := []item{{height: st.marginTop, spacer: true, breakBefore: st.pageBreakBefore}}
+
+	contentX := x + st.marginLeft + st.paddingLeft
+	contentWidth := width - st.marginLeft - st.marginRight - st.paddingLeft - st.paddingRight
+	if w := st.width.resolve(width); st.width.set && w < contentWidth {
+		contentWidth = w
+	}
+
+	if st.paddingTop > 0 {
+		items = append(items, item{height: st.paddingTop})
+	}
+
+	if n.Data == "hr" {
+		if st.border > 0 {
+			items = append(items, item{height: st.border, ops: []drawOp{{
+				kind: opLine, x: contentX, y: st.border / 2, w: contentWidth, size: st.border, color: st.borderColor,
+			}}})
+		}
+	} else {
+		inner := l.children(n, st, contentX, contentWidth)
+		if st.display == displayListItem {
+			l.addListMarker(n, st, contentX, inner)
+		}
+		items = appendItems(items, inner...)
+	}
+
+	if st.paddingBottom > 0 {
+		items = append(items, item{height: st.paddingBottom})
+	}
+
+	return appendItems(items, item{height: st.marginBottom, spacer: true, breakAfter: st.pageBreakAfter})
+}
+
+// addListMarker print the bullet or number on the first line of the list item
+func (l *layouter) addListMarker(n *html.Node, st *style, x float64, items []item) {
+	var marker string
+	switch st.listType {
+	case "none":
+		return
+	case "decimal":
+		number := 1
+		if n.Parent != nil {
+			if start, err := strconv.Atoi(attr(n.Parent, "start")); err == nil {
+				number = start
+			}
+		}
+		for sibling := n.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+			if sibling.Type == html.ElementNode && sibling.Data == "li" {
+				number++
+			}
+		}
+		marker = strconv.Itoa(number) + "."
+	case "lower-alpha", "lower-latin", "upper-alpha", "upper-latin":
+		index := 0
+		for sibling := n.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+			if sibling.Type == html.ElementNode && sibling.Data == "li" {
+				index++
+			}
+		}
+		marker = string(rune('a'+index%26)) + "."
+		if strings.HasPrefix(st.listType, "upper") {
+			marker = strings.ToUpper(marker)
+		}
+	default:
+		marker = "•"
+	}
+
+	for i := range items {
+		if items[i].baseline == 0 {
+			continue
+		}
+
+		encoded := encodeText(marker)
+		face := st.face()
+		markerWidth := face.textWidth(encoded, st.size)
+		items[i].ops = append(items[i].ops, drawOp{
+			kind: opText, x: x - markerWidth - st.size/2, y: items[i].baseline, text: encoded, face: face, size: st.size, color: st.color,
+		})
+		return
+	}
+}
+
+func textAtoms(text string, st *style, atoms []atom) []atom {
+	face := st.face()
+	addWord := func(word string) []atom {
+		encoded := encodeText(word)
+		glued := len(atoms) > 0 && (atoms[len(atoms)-1].kind == atomWord || atoms[len(atoms)-1].kind == atomImage)
+		return append(atoms, atom{kind: atomWord, text: encoded, st: st, width: face.textWidth(encoded, st.size), glued: glued})
+	}
+	addSpace := func() []atom {
+		if len(atoms) == 0 || atoms[len(atoms)-1].kind == atomSpace || atoms[len(atoms)-1].kind == atomBreak {
+			return atoms
+		}
+		return append(atoms, atom{kind: atomSpace, text: " ", st: st, width: face.textWidth(" ", st.size)})
+	}
+
+	if st.pre {
+		for i, line := range strings.Split(strings.ReplaceAll(text, "\t", "    "), "\n") {
+			if i > 0 {
+				atoms = append(atoms, atom{kind: atomBreak, st: st})
+			}
+			if line != "" {
+				atoms = addWord(line)
+			}
+		}
+		return atoms
+	}
+
+	if text != "" && strings.TrimLeft(text, " \t\r\n\f") != text {
+		atoms = addSpace()
+	}
+
+	words := strings.Fields(text)
+	for i, word := range words {
+		if i > 0 {
+			atoms = addSpace()
+		}
+		atoms = addWord(word)
+	}
+
+	if len(words) > 0 && strings.TrimRight(text, " \t\r\n\f") != text {
+		atoms = addSpace()
+	}
+
+	return atoms
+}
+
+// inlineAtoms collect the content of inline element, block nested inside it start on a new line
+func (l *layouter) inlineAtoms(n *html.Node, st *style, width float64, atoms []atom) []atom {
+	switch n.Data {
+	case "br":
+		return append(atoms, atom{kind: atomBreak, st: st})
+	case "img":
+		img := l.images.load(attr(n, "src"))
+		if img == nil {
+			return atoms
+		}
+
+		w, h := l.imageSize(img, st, width)
+		glued := len(atoms) > 0 && atoms[len(atoms)-1].kind == atomWord
+		return append(atoms, atom{kind: atomImage, st: st, image: img, width: w, height: h, glued: glued})
+	}
+
+	if value := l.pageValue(n); value != "" {
+		return textAtoms(value, st, atoms)
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case html.TextNode:
+			atoms = textAtoms(c.Data, st, atoms)
+		case html.ElementNode:
+			if skippedTags[c.Data] {
+				continue
+			}
+
+			cst := computeStyle(st, c, l.rules, l.zoom)
+			switch cst.display {
+			case displayNone:
+			case displayInline:
+				atoms = l.inlineAtoms(c, cst, width, atoms)
+			default:
+				atoms = append(atoms, atom{kind: atomBreak, st: cst})
+				atoms = l.inlineAtoms(c, cst, width, atoms)
+				atoms = append(atoms, atom{kind: atomBreak, st: cst})
+			}
+		}
+	}
+
+	return atoms
+}
+
+// pageValue return the page number for the element that the running header and footer use as placeholder
+func (l *layouter) pageValue(n *html.Node) string {
+	if l.page == 0 {
+		return ""
+	}
+
+	for _, class := range strings.Fields(attr(n, "class")) {
+		switch class {
+		case "page":
+			return strconv.Itoa(l.page)
+		case "topage":
+			return strconv.Itoa(l.pageCount)
+		case "frompage":
+			return "1"
+		}
+	}
+
+	return ""
+}
+
+// imageSize keep the image aspect ratio when only one dimension is set, the natural size is measured at 96 dpi
+func (l *layouter) imageSize(img *pdfImage, st *style, available float64) (float64, float64) {
+	w := float64(img.width) * pxToPt * l.zoom
+	h := float64(img.height) * pxToPt * l.zoom
+	ratio := h / w
+
+	switch {
+	case st.width.set && st.height.set && !st.height.percent:
+		w, h = st.width.resolve(available), st.height.value
+	case st.width.set:
+		w = st.width.resolve(available)
+		h = w * ratio
+	case st.height.set && !st.height.percent:
+		h = st.height.value
+		w = h / ratio
+	}
+
+	if w > available && available > 0 {
+		w, h = available, available*h/w
+	}
+
+	return w, h
+}
+
+// overflowTolerance absorb the rounding of the column width, so a cell sized to its content didn't wrap
+const overflowTolerance = 0.01
+
+// lines break the inline content into line at the space between word, word wider than the line is broken anywhere
+func (l *layouter) lines(atoms []atom, st *style, x float64, width float64, indent float64) []item {
+	visible := false
+	for _, a := range atoms {
+		if a.kind != atomSpace {
+			visible = true
+			break
+		}
+	}
+	if !visible {
+		return nil
+	}
+
+	var items []item
+	var line []atom
+	lineWidth := 0.0
+	available := width - indent
+	finish := func(endsWithBreak bool, last bool) {
+		for len(line) > 0 && line[len(line)-1].kind == atomSpace {
+			lineWidth -= line[len(line)-1].width
+			line = line[:len(line)-1]
+		}
+		items = append(items, l.lineItem(line, st, x+width-available, available, lineWidth, endsWithBreak || last))
+		line, lineWidth, available = nil, 0, width
+	}
+
+	for i := 0; i < len(atoms); {
+		a := atoms[i]
+		switch a.kind {
+		case atomBreak:
+			finish(true, false)
+			i++
+			continue
+		case atomSpace:
+			if len(line) > 0 {
+				line = append(line, a)
+				lineWidth += a.width
+			}
+			i++
+			continue
+		}
+
+		end := i + 1
+		segmentWidth := a.width
+		for end < len(atoms) && atoms[end].glued {
+			segmentWidth += atoms[end].width
+			end++
+		}
+
+		if len(line) > 0 && lineWidth+segmentWidth > available+overflowTolerance {
+			finish(false, false)
+		}
+
+		for _, part := range atoms[i:end] {
+			if part.kind == atomWord && lineWidth+part.width > available+overflowTolerance {
+				for _, chunk := range splitWord(part, available-lineWidth, available) {
+					if lineWidth > 0 && lineWidth+chunk.width > available+overflowTolerance {
+						finish(false, false)
+					}
+					line = append(line, chunk)
+					lineWidth += chunk.width
+				}
+				continue
+			}
+
+			line = append(line, part)
+			lineWidth += part.width
+		}
+		i = end
+	}
+
+	if len(line) > 0 {
+		finish(false, true)
+	}
+
+	return items
+}
+
+// splitWord break the word that is wider than the line, the first chunk fill the rest of the current line
+func splitWord(a atom, first float64, available float64) []atom {
+	face := a.st.face()
+	var chunks []atom
+	limit := first
+	for start := 0; start < len(a.text); {
+		end := start + 1
+		for end < len(a.text) && face.textWidth(a.text[start:end+1], a.st.size) <= limit {
+			end++
+		}
+
+		chunk := a
+		chunk.text = a.text[start:end]
+		chunk.width = face.textWidth(chunk.text, a.st.size)
+		chunks = append(chunks, chunk)
+		start, limit = end, available
+	}
+
+	return chunks
+}
+
+// lineItem position the atom of a single line, the line is as tall as its tallest content
+func (l *layouter) lineItem(line []atom, st *style, x float64, available float64, lineWidth float64, last bool) item {
+	above, below := lineMetrics(st)
+	spaces := 0
+	for _, a := range line {
+		switch a.kind {
+		case atomImage:
+			if a.height > above {
+				above = a.height
+			}
+		case atomSpace:
+			spaces++
+			fallthrough
+		default:
+			up, down := lineMetrics(a.st)
+			if up > above {
+				above = up
+			}
+			if down > below {
+				below = down
+			}
+		}
+	}
+
+	offset, wordSpacing := 0.0, 0.0
+	if extra := available - lineWidth; extra > 0 {
+		switch st.align {
+		case "center":
+			offset = extra / 2
+		case "right":
+			offset = extra
+		case "justify":
+			if !last && spaces > 0 {
+				wordSpacing = extra / float64(spaces)
+			}
+		}
+	}
+
+	it := item{height: above + below, baseline: above}
+	cursor := x + offset
+	for i := 0; i < len(line); {
+		a := line[i]
+		if a.kind == atomImage {
+			it.ops = append(it.ops, drawOp{kind: opImage, x: cursor, y: above - a.height, w: a.width, h: a.height, image: a.image})
+			cursor += a.width
+			i++
+			continue
+		}
+
+		// consecutive word and space of the same style is written as a single text
+		var text strings.Builder
+		runWidth := 0.0
+		for ; i < len(line) && line[i].kind != atomImage && line[i].st == a.st; i++ {
+			text.WriteString(line[i].text)
+			runWidth += line[i].width
+			if line[i].kind == atomSpace {
+				runWidth += wordSpacing
+			}
+		}
+
+		it.ops = append(it.ops, drawOp{
+			kind: opText, x: cursor, y: above, text: text.String(), face: a.st.face(), size: a.st.size, wordSpacing: wordSpacing, color: a.st.color,
+		})
+		if a.st.underline {
+			it.ops = append(it.ops, drawOp{
+				kind: opLine, x: cursor, y: above + a.st.size*0.12, w: runWidth, size: a.st.size * 0.06, color: a.st.color,
+			})
+		}
+		cursor += runWidth
+	}
+
+	return it
+}
+
+// lineMetrics return the height above and below the baseline, the leading is split evenly around the text
+func lineMetrics(st *style) (float64, float64) {
+	halfLeading := (st.leading() - st.size) / 2
+	return halfLeading + st.size*0.8, halfLeading + st.size*0.2
+}
+
+// maxColspan is the largest colspan allowed by the HTML spec, it also bound the number of table column so a crafted
+// table can't make the layout allocate without limit
+const maxColspan = 1000
+
+// tableCell is a cell with its computed style and the column it start from
+type tableCell struct {
+	node    *html.Node
+	st      *style
+	column  int
+	colspan int
+}
+
+// table lay out every row as a single item, column width follow the width of the cell on the first row that set it
+// and the rest share the remaining width by their content
+func (l *layouter) table(n *html.Node, st *style, x float64, width float64) []item {
+	cellBorder := 0.0
+	if border, err := strconv.ParseFloat(attr(n, "border"), 64); err == nil && border > 0 {
+		cellBorder = pxToPt * l.zoom
+	}
+
+	cellPadding := pxToPt * l.zoom
+	if padding, err := strconv.ParseFloat(attr(n, "cellpadding"), 64); err == nil {
+		cellPadding = padding * pxToPt * l.zoom
+	}
+
+	var rows [][]tableCell
+	var collect func(parent *html.Node, parentStyle *style)
+	collect = func(parent *html.Node, parentStyle *style) {
+		for c := parent.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+
+			cst := computeStyle(parentStyle, c, l.rules, l.zoom)
+			switch c.Data {
+			case "thead", "tbody", "tfoot":
+				collect(c, cst)
+			case "tr":
+				var row []tableCell
+				column := 0
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+						continue
+					}
+
+					colspan, err := strconv.Atoi(attr(cell, "colspan"))
+					if err != nil || colspan < 1 {
+						colspan = 1
+					}
+
+					// cell past the column limit is dropped
+					if colspan > maxColspan-column {
+						colspan = maxColspan - column
+					}
+					if colspan < 1 {
+						break
+					}
+
+					cellStyle := computeStyle(cst, cell, l.rules, l.zoom)
+					if !cellStyle.paddingSet {
+						cellStyle.paddingTop, cellStyle.paddingRight, cellStyle.paddingBottom, cellStyle.paddingLeft = cellPadding, cellPadding, cellPadding, cellPadding
+					}
+					if cellStyle.border == 0 {
+						cellStyle.border, cellStyle.borderColor = cellBorder, cellStyle.color
+					}
+
+					row = append(row, tableCell{node: cell, st: cellStyle, column: column, colspan: colspan})
+					column += colspan
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	collect(n, st)
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > 0 {
+			last := row[len(row)-1]
+			if last.column+last.colspan > columns {
+				columns = last.column + last.colspan
+			}
+		}
+	}
+
+	items := []item{{height: st.marginTop, spacer: true, breakBefore: st.pageBreakBefore}}
+	if columns == 0 {
+		return appendItems(items, item{height: st.marginBottom, spacer: true})
+	}
+
+	tableX := x + st.marginLeft
+	available := width - st.marginLeft - st.marginRight
+	widths := l.columnWidths(rows, columns, st, available)
+
+	offsets := make([]float64, columns+1)
+	for i, w := range widths {
+		offsets[i+1] = offsets[i] + w
+	}
+
+	for _, row := range rows {
+		if l.ctx.Err() != nil {
+			return items
+		}
+		items = append(items, l.tableRow(row, tableX, offsets))
+	}
+
+	// outer border of the table itself
+	if st.border > 0 {
+		for i := range items {
+			if items[i].spacer {
+				continue
+			}
+			top := i == 1
+			bottom := i == len(items)-1
+			items[i].ops = append(items[i].ops, borderOps(tableX, 0, offsets[columns], items[i].height, st.border, st.borderColor, top, bottom)...)
+		}
+	}
+
+	return appendItems(items, item{height: st.marginBottom, spacer: true, breakAfter: st.pageBreakAfter})
+}
+
+func (l *layouter) columnWidths(rows [][]tableCell, columns int, st *style, available float64) []float64 {
+	tableWidth := 0.0
+	if st.width.set {
+		tableWidth = st.width.resolve(available)
+		if tableWidth > available {
+			tableWidth = available
+		}
+	}
+
+	reference := tableWidth
+	if reference == 0 {
+		reference = available
+	}
+
+	specified := make([]float64, columns)
+	preferred := make([]float64, columns)
+	for _, row := range rows {
+		for _, cell := range row {
+			if cell.colspan != 1 {
+				continue
+			}
+
+			if cell.st.width.set && specified[cell.column] == 0 {
+				specified[cell.column] = cell.st.width.resolve(reference)
+				if !cell.st.width.percent {
+					specified[cell.column] += cell.st.paddingLeft + cell.st.paddingRight
+				}
+			}
+
+			content := l.measure(cell.node, cell.st, reference) + cell.st.paddingLeft + cell.st.paddingRight + cell.st.border
+			if content > preferred[cell.column] {
+				preferred[cell.column] = content
+			}
+		}
+	}
+
+	fixed, flexible := 0.0, 0.0
+	for i := range specified {
+		if specified[i] > 0 {
+			fixed += specified[i]
+		} else {
+			flexible += preferred[i]
+		}
+	}
+
+	if tableWidth == 0 {
+		tableWidth = fixed + flexible
+		if tableWidth > available {
+			tableWidth = available
+		}
+	}
+
+	widths := make([]float64, columns)
+	remaining := tableWidth - fixed
+	unspecified := 0
+	for i := range specified {
+		if specified[i] == 0 {
+			unspecified++
+		}
+	}
+
+	for i := range widths {
+		switch {
+		case specified[i] > 0:
+			widths[i] = specified[i]
+		case remaining <= 0:
+			widths[i] = 0
+		case flexible > 0:
+			widths[i] = remaining * preferred[i] / flexible
+		default:
+			widths[i] = remaining / float64(unspecified)
+		}
+	}
+
+	// scale every column when the specified width didn't add up to the table width
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	if total > 0 && (total > tableWidth || unspecified == 0) {
+		for i := range widths {
+			widths[i] *= tableWidth / total
+		}
+	}
+
+	return widths
+}
+
+// measure return the width of the content when it is laid out without wrapping
+func (l *layouter) measure(n *html.Node, st *style, available float64) float64 {
+	var atoms []atom
+	var collect func(parent *html.Node, parentStyle *style)
+	collect = func(parent *html.Node, parentStyle *style) {
+		for c := parent.FirstChild; c != nil; c = c.NextSibling {
+			switch c.Type {
+			case html.TextNode:
+				atoms = textAtoms(c.Data, parentStyle, atoms)
+			case html.ElementNode:
+				if skippedTags[c.Data] {
+					continue
+				}
+
+				cst := computeStyle(parentStyle, c, l.rules, l.zoom)
+				switch {
+				case cst.display == displayNone:
+				case cst.display == displayInline:
+					atoms = l.inlineAtoms(c, cst, available, atoms)
+				default:
+					atoms = append(atoms, atom{kind: atomBreak, st: cst})
+					collect(c, cst)
+					atoms = append(atoms, atom{kind: atomBreak, st: cst})
+				}
+			}
+		}
+	}
+	collect(n, st)
+
+	widest, current := 0.0, 0.0
+	for _, a := range atoms {
+		if a.kind == atomBreak {
+			current = 0
+			continue
+		}
+
+		current += a.width
+		if current > widest {
+			widest = current
+		}
+	}
+
+	return widest
+}
+
+func (l *layouter) tableRow(row []tableCell, x float64, offsets []float64) item {
+	type laidCell struct {
+		cell    tableCell
+		items   []item
+		content float64
+	}
+
+	rowHeight := 0.0
+	cells := make([]laidCell, 0, len(row))
+	for _, cell := range row {
+		end := cell.column + cell.colspan
+		if end >= len(offsets) {
+			end = len(offsets) - 1
+		}
+
+		cellX := x + offsets[cell.column]
+		cellWidth := offsets[end] - offsets[cell.column]
+		items := l.children(cell.node, cell.st, cellX+cell.st.paddingLeft, cellWidth-cell.st.paddingLeft-cell.st.paddingRight)
+		items = trimSpacers(items)
+
+		content := 0.0
+		for _, it := range items {
+			content += it.height
+		}
+
+		height := content + cell.st.paddingTop + cell.st.paddingBottom
+		if cell.st.height.set && !cell.st.height.percent && cell.st.height.value > height {
+			height = cell.st.height.value
+		}
+		if height > rowHeight {
+			rowHeight = height
+		}
+
+		cells = append(cells, laidCell{cell: cell, items: items, content: content})
+	}
+
+	rowItem := item{height: rowHeight}
+	for _, laid := range cells {
+		st := laid.cell.st
+		end := laid.cell.column + laid.cell.colspan
+		if end >= len(offsets) {
+			end = len(offsets) - 1
+		}
+		cellX := x + offsets[laid.cell.column]
+		cellWidth := offsets[end] - offsets[laid.cell.column]
+
+		if st.background != nil {
+			rowItem.ops = append(rowItem.ops, drawOp{kind: opRect, x: cellX, y: 0, w: cellWidth, h: rowHeight, color: *st.background})
+		}
+
+		y := st.paddingTop
+		free := rowHeight - laid.content - st.paddingTop - st.paddingBottom
+		switch st.verticalAlign {
+		case "middle":
+			y += free / 2
+		case "bottom":
+			y += free
+		}
+
+		for _, it := range laid.items {
+			for _, op := range it.ops {
+				op.y += y
+				rowItem.ops = append(rowItem.ops, op)
+			}
+			y += it.height
+		}
+
+		if st.border > 0 {
+			rowItem.ops = append(rowItem.ops, borderOps(cellX, 0, cellWidth, rowHeight, st.border, st.borderColor, true, true)...)
+		}
+	}
+
+	return rowItem
+}
+
+// trimSpacers drop the margin at the start and the end of the cell content
+func trimSpacers(items []item) []item {
+	for len(items) > 0 && items[0].spacer {
+		items = items[1:]
+	}
+	for len(items) > 0 && items[len(items)-1].spacer {
+		items = items[:len(items)-1]
+	}
+
+	return items
+}
+
+// borderOps draw the left and right side, and the top and bottom side when they are requested
+func borderOps(x float64, y float64, w float64, h float64, width float64, color rgb, top bool, bottom bool) []drawOp {
+	ops := []drawOp{
+		{kind: opLine, x: x, y: y, w: 0, h: h, size: width, color: color},
+		{kind: opLine, x: x + w, y: y, w: 0, h: h, size: width, color: color},
+	}
+	if top {
+		ops = append(ops, drawOp{kind: opLine, x: x, y: y, w: w, h: 0, size: width, color: color})
+	}
+	if bottom {
+		ops = append(ops, drawOp{kind: opLine, x: x, y: y + h, w: w, h: 0, size: width, color: color})
+	}
+
+	return ops
+}
+
+// paginate place the item on the page from top to bottom, item that didn't fit on the rest of the page start the
+// next page and item taller than the page is clipped
+func paginate(items []item, top float64, height float64) [][]drawOp {
+	pages := [][]drawOp{nil}
+	y := 0.0
+	forceBreak := false
+	newPage := func() {
+		pages = append(pages, nil)
+		y = 0
+	}
+
+	for _, it := range items {
+		if (it.breakBefore || forceBreak) && y > 0 {
+			newPage()
+		}
+		forceBreak = false
+
+		if it.spacer && y == 0 && len(pages) > 1 {
+			forceBreak = it.breakAfter
+			continue
+		}
+
+		if y+it.height > height && y > 0 {
+			newPage()
+			if it.spacer {
+				forceBreak = it.breakAfter
+				continue
+			}
+		}
+
+		current := len(pages) - 1
+		for _, op := range it.ops {
+			op.y += top + y
+			pages[current] = append(pages[current], op)
+		}
+		y += it.height
+		forceBreak = it.breakAfter
+	}
+
+	for len(pages) > 1 && len(pages[len(pages)-1]) == 0 {
+		pages = pages[:len(pages)-1]
+	}
+
+	return pages
+}
+
+// sectionHeight return the total height of the running header or footer
+func sectionHeight(items []item) float64 {
+	total := 0.0
+	for _, it := range items {
+		total += it.height
+	}
+
+	return total
+}
@@ -0,0 +1,135 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+	"golang.org/x/net/html"
+)
+
+// mmToPt convert millimeter into point
+const mmToPt = 72 / 25.4
+
+// NativePDFServiceImpl render the subset of html and css used by the letter template, text, table, image, list,
+// and margin, without any external binary. Text is written with the standard PDF font, and the dpi has no effect
+// since nothing is rasterized
+type NativePDFServiceImpl struct {
+	timeout time.Duration
+}
+
+func NewNativePDFService(timeout time.Duration) pdf.PDFService {
+	return &NativePDFServiceImpl{
+		timeout: timeout,
+	}
+}
+
+func (p *NativePDFServiceImpl) GeneratePDF(ctx context.Context, data *bytes.Buffer, options *pdf.PDFOptions) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	pageWidth, pageHeight, pages, err := layoutDocument(ctx, data, options)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &pdfWriter{grayscale: options.Grayscale, watermark: options.Watermark}
+	return writer.writeDocument(pageWidth, pageHeight, pages)
+}
+
+// layoutDocument lay the document out into pages of drawing operation, with the running header and footer added on
+// every page. The page dimension is returned in point
+func layoutDocument(ctx context.Context, data *bytes.Buffer, options *pdf.PDFOptions) (float64, float64, [][]drawOp, error) {
+	pageWidth, pageHeight, err := pdf.PageDimension(options)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	pageWidth, pageHeight = pageWidth*mmToPt, pageHeight*mmToPt
+	marginTop := float64(options.MarginTop) * mmToPt
+	marginBottom := float64(options.MarginBottom) * mmToPt
+	marginLeft := float64(options.MarginLeft) * mmToPt
+	contentWidth := pageWidth - marginLeft - float64(options.MarginRight)*mmToPt
+	contentHeight := pageHeight - marginTop - marginBottom
+	if contentWidth <= 0 || contentHeight <= 0 {
+		return 0, 0, nil, utils.ErrInvalidPageSetup
+	}
+
+	zoom := options.Zoom
+	if zoom <= 0 {
+		zoom = 1
+	}
+
+	doc, err := html.Parse(bytes.NewReader(data.Bytes()))
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	images := newImageLoader()
+	items := newLayouter(ctx, doc, images, zoom).layout(doc, marginLeft, contentWidth)
+	pages := paginate(items, marginTop, contentHeight)
+	if err := ctx.Err(); err != nil {
+		return 0, 0, nil, err
+	}
+
+	if options.HeaderHTML != nil || options.FooterHTML != nil {
+		if err := addPageSections(ctx, pages, images, options, zoom, marginLeft, contentWidth, marginTop, pageHeight-marginBottom); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+
+	return pageWidth, pageHeight, pages, nil
+}
+
+// addPageSections lay the running header and footer out on every page, so the page number can be filled. The header
+// end where the page content start while the footer start where the page content end
+func addPageSections(ctx context.Context, pages [][]drawOp, images *imageLoader, options *pdf.PDFOptions, zoom float64, x float64, width float64, contentTop float64, contentBottom float64) error {
+	for _, section := range []struct {
+		content *bytes.Buffer
+		header  bool
+	}{
+		{options.HeaderHTML, true},
+		{options.FooterHTML, false},
+	} {
+		if section.content == nil {
+			continue
+		}
+
+		doc, err := html.Parse(bytes.NewReader(section.content.Bytes()))
+		if err != nil {
+			return err
+		}
+
+		l := newLayouter(ctx, doc, images, zoom)
+		l.pageCount = len(pages)
+		for i := range pages {
+			l.page = i + 1
+			items := l.layout(doc, x, width)
+
+			top := contentBottom
+			if section.header {
+				top = contentTop - sectionHeight(items)
+				if top < 0 {
+					top = 0
+				}
+			}
+
+			y := top
+			for _, it := range items {
+				for _, op := range it.ops {
+					op.y += y
+					pages[i] = append(pages[i], op)
+				}
+				y += it.height
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package impl
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+)
+
+var (
+	streamPattern = regexp.MustCompile(`(?s)/FlateDecode /Length \d+ >>\nstream\n(.*?)\nendstream`)
+	textPattern   = regexp.MustCompile(`\(((?:\\.|[^\\)])*)\) Tj`)
+)
+
+// pageTexts inflate every content stream and return the text drawn on each page
+func pageTexts(t *testing.T, result []byte) []string {
+	var texts []string
+	for _, match := range streamPattern.FindAllSubmatch(result, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(match[1]))
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		content, err := io.ReadAll(zr)
+		assert.NoError(t, err)
+
+		if !bytes.Contains(content, []byte("Tj")) {
+			continue
+		}
+
+		var words []string
+		for _, text := range textPattern.FindAllSubmatch(content, -1) {
+			words = append(words, string(text[1]))
+		}
+		texts = append(texts, strings.Join(strings.Fields(strings.Join(words, " ")), " "))
+	}
+
+	return texts
+}
+
+func TestNativePDFServiceImpl_GeneratePDF(t *testing.T) {
+	service := NewNativePDFService(30 * time.Second)
+
+	for _, tc := range []struct {
+		Name     string
+		Body     string
+		Options  *pdf.PDFOptions
+		Expected []string
+	}{
+		{
+			Name:     "Text",
+			Body:     `<p>Hello <b>World</b></p>`,
+			Options:  &pdf.PDFOptions{},
+			Expected: []string{"Hello World"},
+		},
+		{
+			Name:     "Escaped text",
+			Body:     `<p>Nomor (1) \ 2</p>`,
+			Options:  &pdf.PDFOptions{},
+			Expected: []string{`Nomor \(1\) \\ 2`},
+		},
+		{
+			Name:     "Table cell",
+			Body:     `<table><tr><td>Nama</td><td>Budi</td></tr></table>`,
+			Options:  &pdf.PDFOptions{},
+			Expected: []string{"Nama Budi"},
+		},
+		{
+			Name:     "Skipped element",
+			Body:     `<head><title>Judul</title><style>p { color: red }</style><script>var a = 1</script></head><p>Isi</p>`,
+			Options:  &pdf.PDFOptions{},
+			Expected: []string{"Isi"},
+		},
+		{
+			Name: "Page number",
+			Body: `<p>Satu</p><p style="page-break-before: always">Dua</p>`,
+			Options: &pdf.PDFOptions{
+				MarginBottom: 20,
+				FooterHTML:   bytes.NewBufferString(`<p>Halaman <span class="page"></span> dari <span class="topage"></span></p>`),
+			},
+			Expected: []string{"Satu Halaman 1 dari 2", "Dua Halaman 2 dari 2"},
+		},
+		{
+			Name: "Watermark",
+			Body: `<p>Satu</p><p style="page-break-before: always">Dua</p>`,
+			Options: &pdf.PDFOptions{
+				Watermark: &pdf.Watermark{Text: "DRAFT", Opacity: 0.15, Angle: 45},
+			},
+			Expected: []string{"Satu DRAFT", "Dua DRAFT"},
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			result, err := service.GeneratePDF(context.Background(), bytes.NewBufferString(tc.Body), tc.Options)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, pageTexts(t, result))
+		})
+	}
+}
+
+func TestNativePDFServiceImpl_GeneratePDF_Deterministic(t *testing.T) {
+	service := NewNativePDFService(30 * time.Second)
+	body := `<h1>Surat</h1><img src="` + testPNG(t) + `"><table border="1"><tr><td>1</td><td>Budi</td></tr></table>`
+
+	first, err := service.GeneratePDF(context.Background(), bytes.NewBufferString(body), &pdf.PDFOptions{})
+	assert.NoError(t, err)
+	second, err := service.GeneratePDF(context.Background(), bytes.NewBufferString(body), &pdf.PDFOptions{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, first, second)
+}
+
+func TestNativePDFServiceImpl_GeneratePDF_Grayscale(t *testing.T) {
+	service := NewNativePDFService(30 * time.Second)
+
+	result, err := service.GeneratePDF(context.Background(), bytes.NewBufferString(`<img src="`+testPNG(t)+`">`), &pdf.PDFOptions{Grayscale: true})
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "/ColorSpace /DeviceGray")
+	assert.NotContains(t, string(result), "/ColorSpace /DeviceRGB")
+}
+
+func TestNativePDFServiceImpl_GeneratePDF_Watermark(t *testing.T) {
+	service := NewNativePDFService(30 * time.Second)
+
+	result, err := service.GeneratePDF(context.Background(), bytes.NewBufferString(`<p>Isi</p>`), &pdf.PDFOptions{
+		Watermark: &pdf.Watermark{Text: "DICABUT", Opacity: 0.2, Angle: 30},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "/ExtGState << /GSWm << /Type /ExtGState /ca 0.2 >> >>")
+	assert.Contains(t, string(result), "/BaseFont /Helvetica-Bold")
+
+	match := streamPattern.FindSubmatch(result)
+	zr, err := zlib.NewReader(bytes.NewReader(match[1]))
+	assert.NoError(t, err)
+	content, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Regexp(t, `q /GSWm gs BT 0\.5 0\.5 0\.5 rg /F\d+ [\d.]+ Tf 0\.8660 0\.5000 -0\.5000 0\.8660 [\d.]+ [\d.]+ Tm \(DICABUT\) Tj ET Q`, string(content))
+}
+
+func TestNativePDFServiceImpl_GeneratePDF_ColspanOverLimit(t *testing.T) {
+	service := NewNativePDFService(30 * time.Second)
+
+	// the first cell fill every column up to the limit, so the cell after it is dropped
+	body := `<table><tr><td colspan="2147483647">1</td><td>Budi</td></tr><tr><td>2</td></tr></table>`
+
+	result, err := service.GeneratePDF(context.Background(), bytes.NewBufferString(body), &pdf.PDFOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1 2"}, pageTexts(t, result))
+}
+
+func TestNativePDFServiceImpl_GeneratePDF_ImageTooLarge(t *testing.T) {
+	service := NewNativePDFService(30 * time.Second)
+
+	// only the header is written, the decoder must reject it before reading any pixel
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:4], 100000)
+	binary.BigEndian.PutUint32(ihdr[4:8], 100000)
+	ihdr[8], ihdr[9] = 8, 2
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	assert.NoError(t, binary.Write(&buf, binary.BigEndian, uint32(len(ihdr))))
+	chunk := append([]byte("IHDR"), ihdr...)
+	buf.Write(chunk)
+	assert.NoError(t, binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(chunk)))
+
+	src := "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	assert.Nil(t, decodeDataURI(src))
+
+	result, err := service.GeneratePDF(context.Background(), bytes.NewBufferString(`<p>Isi</p><img src="`+src+`">`), &pdf.PDFOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(result), "/Subtype /Image")
+}
+
+func TestNativePDFServiceImpl_GeneratePDF_Canceled(t *testing.T) {
+	service := NewNativePDFService(30 * time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := service.GeneratePDF(ctx, bytes.NewBufferString(`<p>Hello</p>`), &pdf.PDFOptions{})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestNativePDFServiceImpl_GeneratePDF_MarginTooLarge(t *testing.T) {
+	service := NewNativePDFService(30 * time.Second)
+
+	_, err := service.GeneratePDF(context.Background(), bytes.NewBufferString(`<p>Hello</p>`), &pdf.PDFOptions{PageSize: pdf.PageSizeA5, MarginLeft: 100, MarginRight: 100})
+	assert.Equal(t, utils.ErrInvalidPageSetup, err)
+}
@@ -0,0 +1,762 @@
+package impl
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// pxToPt convert css pixel, defined as 1/96 inch, into point
+const pxToPt = 0.75
+
+// Display of the element, other css display value fallback to the default display of the tag
+const (
+	displayBlock    = "block"
+	displayInline   = "inline"
+	displayListItem = "list-item"
+	displayTable    = "table"
+	displayNone     = "none"
+)
+
+type rgb struct {
+	r, g, b float64
+}
+
+// length is css length that can't be resolved until the containing width is known
+type length struct {
+	value   float64
+	percent bool
+	set     bool
+}
+
+func (l length) resolve(containing float64) float64 {
+	if l.percent {
+		return l.value * containing / 100
+	}
+
+	return l.value
+}
+
+// style is the computed style of an element, the text property is inherited by the children while the box property
+// is reset for every element
+type style struct {
+	// inherited
+	family       string
+	size         float64
+	bold         bool
+	italic       bool
+	underline    bool
+	color        rgb
+	align        string
+	lineHeight   float64 // multiplier of the font size when lineHeightPt is zero
+	lineHeightPt float64
+	pre          bool
+	listType     string
+
+	// box
+	display         string
+	marginTop       float64
+	marginRight     float64
+	marginBottom    float64
+	marginLeft      float64
+	paddingTop      float64
+	paddingRight    float64
+	paddingBottom   float64
+	paddingLeft     float64
+	paddingSet      bool
+	border          float64
+	borderColor     rgb
+	background      *rgb
+	width           length
+	height          length
+	verticalAlign   string
+	textIndent      float64
+	pageBreakBefore bool
+	pageBreakAfter  bool
+}
+
+func newRootStyle(zoom float64) *style {
+	return &style{
+		family:     familySerif,
+		size:       12 * zoom,
+		align:      "left",
+		lineHeight: 1.15,
+		listType:   "disc",
+		display:    displayBlock,
+	}
+}
+
+func (s *style) face() *fontFace {
+	return lookupFontFace(s.family, s.bold, s.italic)
+}
+
+func (s *style) leading() float64 {
+	if s.lineHeightPt > 0 {
+		return s.lineHeightPt
+	}
+
+	return s.lineHeight * s.size
+}
+
+// inherit return the style of the child element before its own declaration is applied
+func (s *style) inherit() *style {
+	return &style{
+		family:       s.family,
+		size:         s.size,
+		bold:         s.bold,
+		italic:       s.italic,
+		underline:    s.underline,
+		color:        s.color,
+		align:        s.align,
+		lineHeight:   s.lineHeight,
+		lineHeightPt: s.lineHeightPt,
+		pre:          s.pre,
+		listType:     s.listType,
+		display:      displayInline,
+		borderColor:  s.color,
+	}
+}
+
+// declaration is a single css property
+type declaration struct {
+	property string
+	value    string
+}
+
+func parseDeclarations(source string) []declaration {
+	var declarations []declaration
+	for _, item := range strings.Split(source, ";") {
+		property, value, ok := strings.Cut(item, ":")
+		if !ok {
+			continue
+		}
+
+		property = strings.ToLower(strings.TrimSpace(property))
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		if property != "" && value != "" {
+			declarations = append(declarations, declaration{property: property, value: value})
+		}
+	}
+
+	return declarations
+}
+
+// selector is a compound selector list joined by the descendant combinator, eg: `table.bordered td`
+type selector struct {
+	parts       []compoundSelector
+	specificity int
+	order       int
+}
+
+type compoundSelector struct {
+	tag     string
+	id      string
+	classes []string
+}
+
+type cssRule struct {
+	selector     selector
+	declarations []declaration
+}
+
+// parseStylesheet read the rules of the style element, at-rules and selectors other than type, class, id, and
+// descendant combinator are ignored
+func parseStylesheet(source string, order int) []cssRule {
+	var rules []cssRule
+	source = stripComments(source)
+
+	for len(source) > 0 {
+		open := strings.IndexByte(source, '{')
+		if open < 0 {
+			break
+		}
+
+		prelude := strings.TrimSpace(source[:open])
+		end := matchingBrace(source, open)
+		body := source[open+1 : end]
+		if end < len(source) {
+			source = source[end+1:]
+		} else {
+			source = ""
+		}
+
+		if strings.HasPrefix(prelude, "@") {
+			continue
+		}
+
+		declarations := parseDeclarations(body)
+		for _, item := range strings.Split(prelude, ",") {
+			if sel, ok := parseSelector(item); ok {
+				sel.order = order
+				order++
+				rules = append(rules, cssRule{selector: sel, declarations: declarations})
+			}
+		}
+	}
+
+	return rules
+}
+
+func stripComments(source string) string {
+	for {
+		start := strings.Index(source, "/*")
+		if start < 0 {
+			return source
+		}
+
+		end := strings.Index(source[start+2:], "*/")
+		if end < 0 {
+			return source[:start]
+		}
+		source = source[:start] + source[start+2+end+2:]
+	}
+}
+
+func matchingBrace(source string, open int) int {
+	depth := 0
+	for i := open; i < len(source); i++ {
+		switch source[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return len(source)
+}
+
+func parseSelector(source string) (selector, bool) {
+	var sel selector
+	for _, part := range strings.Fields(strings.ReplaceAll(source, ">", " ")) {
+		var compound compoundSelector
+		for len(part) > 0 {
+			end := strings.IndexAny(part[1:], ".#") + 1
+			if end == 0 {
+				end = len(part)
+			}
+
+			token := part[:end]
+			part = part[end:]
+			switch {
+			case strings.HasPrefix(token, "."):
+				compound.classes = append(compound.classes, token[1:])
+				sel.specificity += 10
+			case strings.HasPrefix(token, "#"):
+				compound.id = token[1:]
+				sel.specificity += 100
+			case token == "*":
+			default:
+				if strings.ContainsAny(token, ":[+~") {
+					return selector{}, false
+				}
+				compound.tag = strings.ToLower(token)
+				sel.specificity++
+			}
+		}
+		sel.parts = append(sel.parts, compound)
+	}
+
+	return sel, len(sel.parts) > 0
+}
+
+func (c *compoundSelector) match(n *html.Node) bool {
+	if c.tag != "" && c.tag != n.Data {
+		return false
+	}
+
+	if c.id != "" && attr(n, "id") != c.id {
+		return false
+	}
+
+	classes := strings.Fields(attr(n, "class"))
+	for _, class := range c.classes {
+		found := false
+		for _, item := range classes {
+			if item == class {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *selector) match(n *html.Node) bool {
+	last := len(s.parts) - 1
+	if !s.parts[last].match(n) {
+		return false
+	}
+
+	i := last - 1
+	for ancestor := n.Parent; ancestor != nil && i >= 0; ancestor = ancestor.Parent {
+		if ancestor.Type == html.ElementNode && s.parts[i].match(ancestor) {
+			i--
+		}
+	}
+
+	return i < 0
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+// tagDefaults is the user agent stylesheet, following the browser default
+var tagDefaults = map[string]string{
+	"body":       "display: block; margin: 8px",
+	"div":        "display: block",
+	"p":          "display: block; margin: 1em 0",
+	"h1":         "display: block; font-size: 2em; font-weight: bold; margin: 0.67em 0",
+	"h2":         "display: block; font-size: 1.5em; font-weight: bold; margin: 0.83em 0",
+	"h3":         "display: block; font-size: 1.17em; font-weight: bold; margin: 1em 0",
+	"h4":         "display: block; font-weight: bold; margin: 1.33em 0",
+	"h5":         "display: block; font-size: 0.83em; font-weight: bold; margin: 1.67em 0",
+	"h6":         "display: block; font-size: 0.67em; font-weight: bold; margin: 2.33em 0",
+	"ul":         "display: block; margin: 1em 0; padding-left: 40px; list-style-type: disc",
+	"ol":         "display: block; margin: 1em 0; padding-left: 40px; list-style-type: decimal",
+	"li":         "display: list-item",
+	"table":      "display: table",
+	"hr":         "display: block; margin: 0.5em 0; border: 1px solid",
+	"center":     "display: block; text-align: center",
+	"blockquote": "display: block; margin: 1em 40px",
+	"pre":        "display: block; margin: 1em 0; font-family: monospace; white-space: pre",
+	"address":    "display: block; font-style: italic",
+	"section":    "display: block",
+	"article":    "display: block",
+	"header":     "display: block",
+	"footer":     "display: block",
+	"main":       "display: block",
+	"nav":        "display: block",
+	"aside":      "display: block",
+	"figure":     "display: block; margin: 1em 40px",
+	"figcaption": "display: block",
+	"form":       "display: block",
+	"dl":         "display: block; margin: 1em 0",
+	"dt":         "display: block",
+	"dd":         "display: block; margin-left: 40px",
+	"th":         "font-weight: bold; text-align: center",
+	"b":          "font-weight: bold",
+	"strong":     "font-weight: bold",
+	"i":          "font-style: italic",
+	"em":         "font-style: italic",
+	"cite":       "font-style: italic",
+	"var":        "font-style: italic",
+	"u":          "text-decoration: underline",
+	"ins":        "text-decoration: underline",
+	"a":          "text-decoration: underline; color: #0000ee",
+	"small":      "font-size: smaller",
+	"sub":        "font-size: smaller",
+	"sup":        "font-size: smaller",
+	"big":        "font-size: larger",
+	"code":       "font-family: monospace",
+	"kbd":        "font-family: monospace",
+	"samp":       "font-family: monospace",
+	"tt":         "font-family: monospace",
+}
+
+// skippedTags is never rendered
+var skippedTags = map[string]bool{
+	"head": true, "script": true, "style": true, "title": true, "meta": true, "link": true, "noscript": true,
+	"template": true, "iframe": true, "object": true, "embed": true, "svg": true, "canvas": true, "button": true,
+	"input": true, "select": true, "textarea": true, "caption": true, "colgroup": true, "col": true,
+}
+
+// computeStyle apply the user agent default, presentational attribute, matching rule, and inline style in that order
+func computeStyle(parent *style, n *html.Node, rules []cssRule, zoom float64) *style {
+	s := parent.inherit()
+	if n.Data == "html" {
+		s.display = displayBlock
+	}
+
+	declarations := parseDeclarations(tagDefaults[n.Data])
+	declarations = append(declarations, attributeDeclarations(n)...)
+
+	var matched []cssRule
+	for _, rule := range rules {
+		if rule.selector.match(n) {
+			matched = append(matched, rule)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].selector.specificity != matched[j].selector.specificity {
+			return matched[i].selector.specificity < matched[j].selector.specificity
+		}
+		return matched[i].selector.order < matched[j].selector.order
+	})
+	for _, rule := range matched {
+		declarations = append(declarations, rule.declarations...)
+	}
+	declarations = append(declarations, parseDeclarations(attr(n, "style"))...)
+
+	// font size is applied first since the em unit of the other property is relative to it
+	for _, d := range declarations {
+		if d.property == "font-size" {
+			s.size = parseFontSize(d.value, parent.size, zoom)
+		} else if d.property == "font" {
+			for _, token := range strings.Fields(d.value) {
+				if size, ok := parseLength(strings.Split(token, "/")[0], parent.size, zoom); ok && !size.percent {
+					s.size = size.value
+				}
+			}
+		}
+	}
+
+	for _, d := range declarations {
+		s.apply(d, zoom)
+	}
+
+	return s
+}
+
+// attributeDeclarations convert the presentational attribute that is still common in a letter template
+func attributeDeclarations(n *html.Node) []declaration {
+	var declarations []declaration
+	for _, a := range n.Attr {
+		switch a.Key {
+		case "align":
+			declarations = append(declarations, declaration{"text-align", a.Val})
+		case "valign":
+			declarations = append(declarations, declaration{"vertical-align", a.Val})
+		case "width", "height":
+			if n.Data != "table" && n.Data != "td" && n.Data != "th" && n.Data != "img" {
+				continue
+			}
+			value := a.Val
+			if _, err := strconv.ParseFloat(value, 64); err == nil {
+				value += "px"
+			}
+			declarations = append(declarations, declaration{a.Key, value})
+		case "bgcolor":
+			declarations = append(declarations, declaration{"background-color", a.Val})
+		case "color":
+			if n.Data == "font" {
+				declarations = append(declarations, declaration{"color", a.Val})
+			}
+		case "face":
+			if n.Data == "font" {
+				declarations = append(declarations, declaration{"font-family", a.Val})
+			}
+		}
+	}
+
+	return declarations
+}
+
+func (s *style) apply(d declaration, zoom float64) {
+	value := strings.ToLower(d.value)
+	lengthOf := func(v string) float64 {
+		l, _ := parseLength(v, s.size, zoom)
+		return l.value
+	}
+
+	switch d.property {
+	case "display":
+		switch value {
+		case displayNone, displayInline, displayListItem, displayTable:
+			s.display = value
+		case "inline-block", "inline-flex":
+			s.display = displayInline
+		case "table-row", "table-cell", "table-row-group", "table-header-group", "table-footer-group":
+			// the table part is found by its tag
+		default:
+			s.display = displayBlock
+		}
+	case "font-family":
+		s.family = fontFamily(value)
+	case "font-weight":
+		weight, err := strconv.Atoi(value)
+		s.bold = value == "bold" || value == "bolder" || (err == nil && weight >= 600)
+	case "font-style":
+		s.italic = value == "italic" || value == "oblique"
+	case "font":
+		for _, token := range strings.Fields(value) {
+			switch token {
+			case "bold", "bolder", "600", "700", "800", "900":
+				s.bold = true
+			case "italic", "oblique":
+				s.italic = true
+			}
+		}
+		if i := strings.LastIndexAny(value, " "); i >= 0 {
+			s.family = fontFamily(value[i+1:])
+		}
+	case "text-decoration", "text-decoration-line":
+		s.underline = strings.Contains(value, "underline")
+	case "color":
+		if c, ok := parseColor(value); ok {
+			s.color = c
+		}
+	case "background-color", "background":
+		if c, ok := parseColor(strings.Fields(value + " ")[0]); ok {
+			s.background = &c
+		}
+	case "text-align":
+		switch value {
+		case "left", "right", "center", "justify":
+			s.align = value
+		case "start":
+			s.align = "left"
+		case "end":
+			s.align = "right"
+		}
+	case "line-height":
+		if value == "normal" {
+			s.lineHeight, s.lineHeightPt = 1.15, 0
+		} else if factor, err := strconv.ParseFloat(value, 64); err == nil {
+			s.lineHeight, s.lineHeightPt = factor, 0
+		} else if l, ok := parseLength(value, s.size, zoom); ok {
+			if l.percent {
+				s.lineHeight, s.lineHeightPt = l.value/100, 0
+			} else {
+				s.lineHeightPt = l.value
+			}
+		}
+	case "white-space":
+		s.pre = strings.HasPrefix(value, "pre")
+	case "list-style-type", "list-style":
+		s.listType = strings.Fields(value + " none")[0]
+	case "margin":
+		s.marginTop, s.marginRight, s.marginBottom, s.marginLeft = boxLengths(value, lengthOf)
+	case "margin-top":
+		s.marginTop = lengthOf(value)
+	case "margin-right":
+		s.marginRight = lengthOf(value)
+	case "margin-bottom":
+		s.marginBottom = lengthOf(value)
+	case "margin-left":
+		s.marginLeft = lengthOf(value)
+	case "padding":
+		s.paddingSet = true
+		s.paddingTop, s.paddingRight, s.paddingBottom, s.paddingLeft = boxLengths(value, lengthOf)
+	case "padding-top":
+		s.paddingSet = true
+		s.paddingTop = lengthOf(value)
+	case "padding-right":
+		s.paddingSet = true
+		s.paddingRight = lengthOf(value)
+	case "padding-bottom":
+		s.paddingSet = true
+		s.paddingBottom = lengthOf(value)
+	case "padding-left":
+		s.paddingSet = true
+		s.paddingLeft = lengthOf(value)
+	case "border", "border-top", "border-bottom", "border-left", "border-right":
+		s.border, s.borderColor = parseBorder(value, s.size, zoom, s.color)
+	case "border-width":
+		s.border = lengthOf(strings.Fields(value)[0])
+	case "width", "height":
+		l, ok := parseLength(value, s.size, zoom)
+		if !ok {
+			return
+		}
+		if d.property == "width" {
+			s.width = l
+		} else {
+			s.height = l
+		}
+	case "vertical-align":
+		s.verticalAlign = value
+	case "text-indent":
+		s.textIndent = lengthOf(value)
+	case "page-break-before", "break-before":
+		s.pageBreakBefore = value == "always" || value == "page"
+	case "page-break-after", "break-after":
+		s.pageBreakAfter = value == "always" || value == "page"
+	}
+}
+
+// boxLengths expand the one to four value shorthand into top, right, bottom, and left
+func boxLengths(value string, lengthOf func(string) float64) (float64, float64, float64, float64) {
+	var values []float64
+	for _, token := range strings.Fields(value) {
+		values = append(values, lengthOf(token))
+	}
+
+	switch len(values) {
+	case 1:
+		return values[0], values[0], values[0], values[0]
+	case 2:
+		return values[0], values[1], values[0], values[1]
+	case 3:
+		return values[0], values[1], values[2], values[1]
+	case 4:
+		return values[0], values[1], values[2], values[3]
+	default:
+		return 0, 0, 0, 0
+	}
+}
+
+func parseBorder(value string, fontSize float64, zoom float64, current rgb) (float64, rgb) {
+	width, color := 0.0, current
+	visible := false
+	for _, token := range strings.Fields(value) {
+		switch token {
+		case "none", "hidden":
+			return 0, color
+		case "solid", "dashed", "dotted", "double", "groove", "ridge", "inset", "outset":
+			visible = true
+		case "thin":
+			width = pxToPt * zoom
+		case "medium":
+			width = 3 * pxToPt * zoom
+		case "thick":
+			width = 5 * pxToPt * zoom
+		default:
+			if l, ok := parseLength(token, fontSize, zoom); ok && !l.percent {
+				width = l.value
+			} else if c, ok := parseColor(token); ok {
+				color = c
+			}
+		}
+	}
+
+	if visible && width == 0 {
+		width = 3 * pxToPt * zoom
+	}
+
+	return width, color
+}
+
+// parseLength convert the css length into point, unitless number other than zero is rejected like the browser do
+func parseLength(value string, fontSize float64, zoom float64) (length, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "0" {
+		return length{set: true}, true
+	}
+
+	units := []struct {
+		suffix string
+		factor float64
+	}{
+		{"px", pxToPt * zoom},
+		{"pt", zoom},
+		{"mm", 72 / 25.4 * zoom},
+		{"cm", 72 / 2.54 * zoom},
+		{"in", 72 * zoom},
+		{"pc", 12 * zoom},
+		{"rem", 12 * zoom},
+		{"em", fontSize},
+		{"%", 1},
+	}
+
+	for _, unit := range units {
+		if !strings.HasSuffix(value, unit.suffix) {
+			continue
+		}
+
+		number, err := strconv.ParseFloat(strings.TrimSuffix(value, unit.suffix), 64)
+		if err != nil {
+			return length{}, false
+		}
+
+		return length{value: number * unit.factor, percent: unit.suffix == "%", set: true}, true
+	}
+
+	return length{}, false
+}
+
+var fontSizeKeywords = map[string]float64{
+	"xx-small": 7, "x-small": 7.5, "small": 10, "medium": 12, "large": 13.5, "x-large": 18, "xx-large": 24,
+}
+
+func parseFontSize(value string, parentSize float64, zoom float64) float64 {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if size, ok := fontSizeKeywords[value]; ok {
+		return size * zoom
+	}
+
+	switch value {
+	case "smaller":
+		return parentSize * 0.83
+	case "larger":
+		return parentSize * 1.2
+	}
+
+	l, ok := parseLength(value, parentSize, zoom)
+	if !ok {
+		return parentSize
+	}
+
+	if l.percent {
+		return parentSize * l.value / 100
+	}
+
+	return l.value
+}
+
+var namedColors = map[string]rgb{
+	"black":  {0, 0, 0},
+	"white":  {1, 1, 1},
+	"red":    {1, 0, 0},
+	"green":  {0, 0.5, 0},
+	"blue":   {0, 0, 1},
+	"gray":   {0.5, 0.5, 0.5},
+	"grey":   {0.5, 0.5, 0.5},
+	"silver": {0.75, 0.75, 0.75},
+	"maroon": {0.5, 0, 0},
+	"navy":   {0, 0, 0.5},
+	"yellow": {1, 1, 0},
+	"orange": {1, 0.65, 0},
+	"purple": {0.5, 0, 0.5},
+}
+
+func parseColor(value string) (rgb, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if c, ok := namedColors[value]; ok {
+		return c, true
+	}
+
+	if strings.HasPrefix(value, "#") {
+		hex := value[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return rgb{}, false
+		}
+
+		number, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return rgb{}, false
+		}
+
+		return rgb{float64(number>>16&0xFF) / 255, float64(number>>8&0xFF) / 255, float64(number&0xFF) / 255}, true
+	}
+
+	if strings.HasPrefix(value, "rgb(") || strings.HasPrefix(value, "rgba(") {
+		inner := value[strings.IndexByte(value, '(')+1 : len(value)-1]
+		parts := strings.Split(inner, ",")
+		if len(parts) < 3 {
+			return rgb{}, false
+		}
+
+		var channels [3]float64
+		for i := 0; i < 3; i++ {
+			number, err := strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+			if err != nil {
+				return rgb{}, false
+			}
+			channels[i] = number / 255
+		}
+
+		return rgb{channels[0], channels[1], channels[2]}, true
+	}
+
+	return rgb{}, false
+}
@@ -0,0 +1,182 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+)
+
+// Text is drawn as a bar over the x-height of the line, lighter than the text color so the page look like printed
+// text from afar
+const (
+	thumbnailTextTop    = 0.5
+	thumbnailTextHeight = 0.45
+	thumbnailTextAlpha  = 0.55
+)
+
+// NativeThumbnailServiceImpl rasterize the first page laid out by the native renderer, the thumbnail look the same
+// whichever PDF backend is used. The standard PDF font has no glyph outline to draw, so text is drawn as a gray bar
+// which is enough for a thumbnail too small to be read anyway
+type NativeThumbnailServiceImpl struct {
+	timeout time.Duration
+}
+
+func NewNativeThumbnailService(timeout time.Duration) pdf.ThumbnailService {
+	return &NativeThumbnailServiceImpl{
+		timeout: timeout,
+	}
+}
+
+func (p *NativeThumbnailServiceImpl) GenerateThumbnail(ctx context.Context, data *bytes.Buffer, options *pdf.PDFOptions, width int) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	pageWidth, pageHeight, pages, err := layoutDocument(ctx, data, options)
+	if err != nil {
+		return nil, err
+	}
+
+	scale := float64(width) / pageWidth
+	canvas := &thumbnailCanvas{
+		img:       image.NewRGBA(image.Rect(0, 0, width, int(math.Round(pageHeight*scale)))),
+		scale:     scale,
+		grayscale: options.Grayscale,
+	}
+	draw.Draw(canvas.img, canvas.img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	if len(pages) > 0 {
+		for _, op := range pages[0] {
+			canvas.draw(op)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, canvas.img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// thumbnailCanvas draw the page operation scaled from point into pixel
+type thumbnailCanvas struct {
+	img       *image.RGBA
+	scale     float64
+	grayscale bool
+}
+
+func (c *thumbnailCanvas) draw(op drawOp) {
+	switch op.kind {
+	case opText:
+		width := op.face.textWidth(op.text, op.size) + float64(strings.Count(op.text, " "))*op.wordSpacing
+		c.fill(op.x, op.y-thumbnailTextTop*op.size, width, thumbnailTextHeight*op.size, op.color, thumbnailTextAlpha)
+	case opLine:
+		c.line(op)
+	case opRect:
+		c.fill(op.x, op.y, op.w, op.h, op.color, 1)
+	case opImage:
+		c.image(op)
+	}
+}
+
+// rect convert the area into pixel, never thinner than one pixel so hairline border stay visible
+func (c *thumbnailCanvas) rect(x float64, y float64, w float64, h float64) image.Rectangle {
+	x0, y0 := int(math.Floor(x*c.scale)), int(math.Floor(y*c.scale))
+	x1, y1 := int(math.Ceil((x+w)*c.scale)), int(math.Ceil((y+h)*c.scale))
+	if x1 <= x0 {
+		x1 = x0 + 1
+	}
+	if y1 <= y0 {
+		y1 = y0 + 1
+	}
+
+	return image.Rect(x0, y0, x1, y1).Intersect(c.img.Bounds())
+}
+
+func (c *thumbnailCanvas) color(value rgb, alpha float64) color.NRGBA {
+	if c.grayscale {
+		gray := luminance(value)
+		value = rgb{gray, gray, gray}
+	}
+
+	return color.NRGBA{
+		R: uint8(value.r*255 + 0.5),
+		G: uint8(value.g*255 + 0.5),
+		B: uint8(value.b*255 + 0.5),
+		A: uint8(alpha*255 + 0.5),
+	}
+}
+
+func (c *thumbnailCanvas) fill(x float64, y float64, w float64, h float64, value rgb, alpha float64) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	draw.Draw(c.img, c.rect(x, y, w, h), &image.Uniform{C: c.color(value, alpha)}, image.Point{}, draw.Over)
+}
+
+// line draw the border and underline, which are always horizontal or vertical, as a rectangle of the line width
+func (c *thumbnailCanvas) line(op drawOp) {
+	x, y, w, h := op.x, op.y, op.w, op.h
+	if w < 0 {
+		x, w = x+w, -w
+	}
+	if h < 0 {
+		y, h = y+h, -h
+	}
+
+	if h == 0 {
+		y, h = y-op.size/2, op.size
+	} else if w == 0 {
+		x, w = x-op.size/2, op.size
+	}
+
+	draw.Draw(c.img, c.rect(x, y, w, h), &image.Uniform{C: c.color(op.color, 1)}, image.Point{}, draw.Over)
+}
+
+// image scale the decoded pixel into the image area with nearest neighbour sampling
+func (c *thumbnailCanvas) image(op drawOp) {
+	img := op.image
+	if img == nil || img.width == 0 || img.height == 0 {
+		return
+	}
+
+	area := c.rect(op.x, op.y, op.w, op.h)
+	left, top := op.x*c.scale, op.y*c.scale
+	width, height := op.w*c.scale, op.h*c.scale
+	for py := area.Min.Y; py < area.Max.Y; py++ {
+		sy := clampIndex(int((float64(py)+0.5-top)/height*float64(img.height)), img.height)
+		for px := area.Min.X; px < area.Max.X; px++ {
+			sx := clampIndex(int((float64(px)+0.5-left)/width*float64(img.width)), img.width)
+			i := sy*img.width + sx
+
+			alpha := 1.0
+			if img.alpha != nil {
+				alpha = float64(img.alpha[i]) / 255
+			}
+
+			value := rgb{float64(img.pixels[i*3]) / 255, float64(img.pixels[i*3+1]) / 255, float64(img.pixels[i*3+2]) / 255}
+			pixel := c.color(value, alpha)
+			draw.Draw(c.img, image.Rect(px, py, px+1, py+1), &image.Uniform{C: pixel}, image.Point{}, draw.Over)
+		}
+	}
+}
+
+func clampIndex(index int, length int) int {
+	if index < 0 {
+		return 0
+	}
+	if index >= length {
+		return length - 1
+	}
+
+	return index
+}
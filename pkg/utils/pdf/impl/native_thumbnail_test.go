@@ -0,0 +1,59 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+)
+
+func TestNativeThumbnailServiceImpl_GenerateThumbnail(t *testing.T) {
+	service := NewNativeThumbnailService(30 * time.Second)
+	body := `<img src="` + testPNG(t) + `" style="width: 105mm"><p>Surat</p><p style="page-break-before: always">Halaman 2</p>`
+
+	result, err := service.GenerateThumbnail(context.Background(), bytes.NewBufferString(body), &pdf.PDFOptions{}, 210)
+	assert.NoError(t, err)
+
+	thumbnail, err := png.Decode(bytes.NewReader(result))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 210, 297), thumbnail.Bounds())
+
+	// the image fill the top left half of the page after the body margin, the rest of the page is white
+	assert.Equal(t, color.RGBA{R: 0, G: 0, B: 200, A: 255}, color.RGBAModel.Convert(thumbnail.At(5, 5)))
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, color.RGBAModel.Convert(thumbnail.At(200, 290)))
+
+	text := false
+	for x := 0; x < 105 && !text; x++ {
+		for y := 105; y < 140 && !text; y++ {
+			text = color.GrayModel.Convert(thumbnail.At(x, y)).(color.Gray).Y < 200
+		}
+	}
+	assert.True(t, text)
+}
+
+func TestNativeThumbnailServiceImpl_GenerateThumbnail_Grayscale(t *testing.T) {
+	service := NewNativeThumbnailService(30 * time.Second)
+
+	result, err := service.GenerateThumbnail(context.Background(), bytes.NewBufferString(`<img src="`+testPNG(t)+`" style="width: 210mm">`), &pdf.PDFOptions{Grayscale: true}, 100)
+	assert.NoError(t, err)
+
+	thumbnail, err := png.Decode(bytes.NewReader(result))
+	assert.NoError(t, err)
+	r, g, b, _ := thumbnail.At(90, 10).RGBA()
+	assert.Equal(t, r, g)
+	assert.Equal(t, g, b)
+}
+
+func TestNativeThumbnailServiceImpl_GenerateThumbnail_InvalidPageSetup(t *testing.T) {
+	service := NewNativeThumbnailService(30 * time.Second)
+
+	_, err := service.GenerateThumbnail(context.Background(), bytes.NewBufferString(`<p>Hello</p>`), &pdf.PDFOptions{PageSize: "A0"}, 100)
+	assert.Equal(t, utils.ErrInvalidPageSetup, err)
+}
@@ -0,0 +1,364 @@
+package impl
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"  // register gif decoder for the embedded image
+	_ "image/jpeg" // register jpeg decoder for the embedded image
+	_ "image/png"  // register png decoder for the embedded image
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+)
+
+// Kind of the drawing operation on the page
+const (
+	opText = iota
+	opLine
+	opRect
+	opImage
+)
+
+// drawOp is a drawing operation positioned from the top left of the page in point
+type drawOp struct {
+	kind        int
+	x, y        float64
+	w, h        float64
+	text        string
+	face        *fontFace
+	size        float64
+	wordSpacing float64
+	color       rgb
+	image       *pdfImage
+}
+
+// maxImagePixels bound the size of an embedded image once decoded, larger image is skipped
+const maxImagePixels = 4096 * 4096
+
+// pdfImage is the decoded pixel of the embedded image, shared by every op that draw the same source
+type pdfImage struct {
+	name   string
+	width  int
+	height int
+	pixels []byte
+	alpha  []byte
+	object int
+}
+
+// Watermark is written in gray bold sans, never larger than maxWatermarkSize point
+const maxWatermarkSize = 120
+
+var watermarkColor = rgb{0.5, 0.5, 0.5}
+
+// pdfWriter write the document using the standard font and image XObject only, the output is deterministic so the
+// same html always produce the same bytes
+type pdfWriter struct {
+	buf       bytes.Buffer
+	offsets   []int
+	grayscale bool
+	watermark *pdf.Watermark
+}
+
+// round format the number with at most two decimal, enough precision for a position in point
+func round(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+func escapeText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\r", `\r`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+// reserve return the number of the next object so it can be referenced before it is written
+func (w *pdfWriter) reserve() int {
+	w.offsets = append(w.offsets, -1)
+	return len(w.offsets)
+}
+
+func (w *pdfWriter) write(object int, body string) {
+	w.offsets[object-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", object, body)
+}
+
+func (w *pdfWriter) writeStream(object int, dictionary string, content []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(content); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	w.offsets[object-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s /Filter /FlateDecode /Length %d >>\nstream\n", object, dictionary, compressed.Len())
+	w.buf.Write(compressed.Bytes())
+	w.buf.WriteString("\nendstream\nendobj\n")
+
+	return nil
+}
+
+// writeDocument write every page with its operation, pages share a single resource dictionary
+func (w *pdfWriter) writeDocument(width float64, height float64, pages [][]drawOp) ([]byte, error) {
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	catalog := w.reserve()
+	pagesObject := w.reserve()
+	resources := w.reserve()
+
+	fonts := map[*fontFace]string{}
+	var fontOrder []*fontFace
+	images := map[*pdfImage]bool{}
+	var imageOrder []*pdfImage
+	for _, ops := range pages {
+		for _, op := range ops {
+			if op.kind == opText && fonts[op.face] == "" {
+				fonts[op.face] = "F" + strconv.Itoa(len(fonts)+1)
+				fontOrder = append(fontOrder, op.face)
+			}
+			if op.kind == opImage && !images[op.image] {
+				images[op.image] = true
+				op.image.name = "Im" + strconv.Itoa(len(images))
+				imageOrder = append(imageOrder, op.image)
+			}
+		}
+	}
+
+	watermarkFace := fontFaces["sans-bold"]
+	if w.watermark != nil && fonts[watermarkFace] == "" {
+		fonts[watermarkFace] = "F" + strconv.Itoa(len(fonts)+1)
+		fontOrder = append(fontOrder, watermarkFace)
+	}
+
+	var fontDict, imageDict strings.Builder
+	for _, face := range fontOrder {
+		object := w.reserve()
+		w.write(object, "<< /Type /Font /Subtype /Type1 /BaseFont /"+face.name+" /Encoding /WinAnsiEncoding >>")
+		fmt.Fprintf(&fontDict, "/%s %d 0 R ", fonts[face], object)
+	}
+
+	for _, img := range imageOrder {
+		if err := w.writeImage(img); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&imageDict, "/%s %d 0 R ", img.name, img.object)
+	}
+
+	var stateDict, watermark string
+	if w.watermark != nil {
+		stateDict = fmt.Sprintf("/ExtGState << /GSWm << /Type /ExtGState /ca %s >> >> ", round(w.watermark.Opacity))
+		watermark = w.watermarkContent(width, height, watermarkFace, fonts[watermarkFace])
+	}
+
+	w.write(resources, fmt.Sprintf("<< /ProcSet [/PDF /Text /ImageB /ImageC] /Font << %s>> /XObject << %s>> %s>>", fontDict.String(), imageDict.String(), stateDict))
+
+	var kids strings.Builder
+	for _, ops := range pages {
+		page := w.reserve()
+		contents := w.reserve()
+		w.write(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %d 0 R /Contents %d 0 R >>",
+			pagesObject, round(width), round(height), resources, contents))
+		if err := w.writeStream(contents, "", append(w.content(height, ops, fonts), watermark...)); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&kids, "%d 0 R ", page)
+	}
+
+	w.write(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.TrimSpace(kids.String()), len(pages)))
+	w.write(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject))
+	info := w.reserve()
+	w.write(info, "<< /Producer (eAD-System) >>")
+
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, catalog, info, xref)
+
+	return w.buf.Bytes(), nil
+}
+
+func (w *pdfWriter) writeImage(img *pdfImage) error {
+	colorSpace, pixels := "/DeviceRGB", img.pixels
+	if w.grayscale {
+		colorSpace, pixels = "/DeviceGray", toGray(img.pixels)
+	}
+
+	smask := ""
+	if img.alpha != nil {
+		mask := w.reserve()
+		if err := w.writeStream(mask, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8", img.width, img.height), img.alpha); err != nil {
+			return err
+		}
+		smask = fmt.Sprintf(" /SMask %d 0 R", mask)
+	}
+
+	img.object = w.reserve()
+	return w.writeStream(img.object, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8%s", img.width, img.height, colorSpace, smask), pixels)
+}
+
+// content convert the operation into the page content stream, the y axis is flipped since PDF origin is at the bottom
+func (w *pdfWriter) content(height float64, ops []drawOp, fonts map[*fontFace]string) []byte {
+	var sb strings.Builder
+	for _, op := range ops {
+		switch op.kind {
+		case opText:
+			fmt.Fprintf(&sb, "BT %s /%s %s Tf %s Tw 1 0 0 1 %s %s Tm (%s) Tj ET\n",
+				w.fillColor(op.color), fonts[op.face], round(op.size), round(op.wordSpacing), round(op.x), round(height-op.y), escapeText(op.text))
+		case opLine:
+			fmt.Fprintf(&sb, "%s %s w %s %s m %s %s l S\n",
+				w.strokeColor(op.color), round(op.size), round(op.x), round(height-op.y), round(op.x+op.w), round(height-op.y-op.h))
+		case opRect:
+			fmt.Fprintf(&sb, "%s %s %s %s %s re f\n",
+				w.fillColor(op.color), round(op.x), round(height-op.y-op.h), round(op.w), round(op.h))
+		case opImage:
+			fmt.Fprintf(&sb, "q %s 0 0 %s %s %s cm /%s Do Q\n",
+				round(op.w), round(op.h), round(op.x), round(height-op.y-op.h), op.image.name)
+		}
+	}
+
+	return []byte(sb.String())
+}
+
+// watermarkContent draw the watermark text through the page center, sized to fit the page along its angle
+func (w *pdfWriter) watermarkContent(width float64, height float64, face *fontFace, font string) string {
+	text := encodeText(w.watermark.Text)
+	unitWidth := face.textWidth(text, 1)
+	if unitWidth == 0 {
+		return ""
+	}
+
+	angle := w.watermark.Angle * math.Pi / 180
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	length := math.Min(0.8*width/math.Max(math.Abs(cos), 0.01), 0.8*height/math.Max(math.Abs(sin), 0.01))
+	size := math.Min(length/unitWidth, maxWatermarkSize)
+
+	// move the start of the baseline so the middle of the text, not its corner, is at the center
+	textWidth, capHeight := unitWidth*size, 0.7*size
+	x := width/2 - cos*textWidth/2 + sin*capHeight/2
+	y := height/2 - sin*textWidth/2 - cos*capHeight/2
+
+	return fmt.Sprintf("q /GSWm gs BT %s /%s %s Tf %.4f %.4f %.4f %.4f %s %s Tm (%s) Tj ET Q\n",
+		w.fillColor(watermarkColor), font, round(size), cos, sin, -sin, cos, round(x), round(y), escapeText(text))
+}
+
+func (w *pdfWriter) fillColor(c rgb) string {
+	if w.grayscale {
+		return round(luminance(c)) + " g"
+	}
+	return round(c.r) + " " + round(c.g) + " " + round(c.b) + " rg"
+}
+
+func (w *pdfWriter) strokeColor(c rgb) string {
+	if w.grayscale {
+		return round(luminance(c)) + " G"
+	}
+	return round(c.r) + " " + round(c.g) + " " + round(c.b) + " RG"
+}
+
+func luminance(c rgb) float64 {
+	return 0.299*c.r + 0.587*c.g + 0.114*c.b
+}
+
+func toGray(pixels []byte) []byte {
+	gray := make([]byte, len(pixels)/3)
+	for i := range gray {
+		gray[i] = byte(0.299*float64(pixels[i*3]) + 0.587*float64(pixels[i*3+1]) + 0.114*float64(pixels[i*3+2]) + 0.5)
+	}
+	return gray
+}
+
+// imageLoader decode the data uri image once per document, image that is not a data uri is not loaded since the
+// template asset is already inlined and the renderer never reach the network
+type imageLoader struct {
+	images map[[sha256.Size]byte]*pdfImage
+}
+
+func newImageLoader() *imageLoader {
+	return &imageLoader{images: make(map[[sha256.Size]byte]*pdfImage)}
+}
+
+func (l *imageLoader) load(src string) *pdfImage {
+	src = strings.TrimSpace(src)
+	key := sha256.Sum256([]byte(src))
+	if img, ok := l.images[key]; ok {
+		return img
+	}
+
+	img := decodeDataURI(src)
+	l.images[key] = img
+
+	return img
+}
+
+func decodeDataURI(src string) *pdfImage {
+	if !strings.HasPrefix(src, "data:") {
+		return nil
+	}
+
+	header, data, ok := strings.Cut(src[len("data:"):], ",")
+	if !ok {
+		return nil
+	}
+
+	var raw []byte
+	if strings.HasSuffix(header, ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(data), ""))
+		if err != nil {
+			return nil
+		}
+		raw = decoded
+	} else {
+		decoded, err := url.PathUnescape(data)
+		if err != nil {
+			return nil
+		}
+		raw = []byte(decoded)
+	}
+
+	// the header is checked first, a small file can declare dimension that take gigabytes once decoded
+	config, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil || config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return nil
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil
+	}
+
+	bounds := decoded.Bounds()
+	img := &pdfImage{
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+		pixels: make([]byte, 0, bounds.Dx()*bounds.Dy()*3),
+	}
+
+	alpha := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+			img.pixels = append(img.pixels, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			if c.A != 0xFF {
+				opaque = false
+			}
+		}
+	}
+
+	if !opaque {
+		img.alpha = alpha
+	}
+
+	return img
+}
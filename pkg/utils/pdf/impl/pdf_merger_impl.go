@@ -0,0 +1,327 @@
+package impl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf/parser"
+)
+
+// Object of the merged file that is written last but referenced by every copied page
+const (
+	mergedCatalog  = 1
+	mergedPages    = 2
+	mergedOutlines = 3
+)
+
+// Page attribute that the page inherit from its parent node when it didn't have one
+var inheritedPageKeys = []string{"/Resources", "/MediaBox", "/CropBox", "/Rotate"}
+
+// PDFMergerImpl copy the page of every document with the object they use into one file. The object is written as soon
+// as its document is read, only the offset, the page number and the bookmark are kept until the end
+type PDFMergerImpl struct{}
+
+func NewPDFMergerImpl() pdf.PDFMerger {
+	return &PDFMergerImpl{}
+}
+
+func (p *PDFMergerImpl) Merge(w io.Writer, next func() (*pdf.MergeDocument, error)) error {
+	m := &pdfMerge{
+		w: &countingWriter{w: bufio.NewWriter(w)},
+		// the first objects are the catalog, page tree and outline, written after every page is known
+		offsets: make([]int, mergedOutlines),
+	}
+	m.w.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	for {
+		document, err := next()
+		if err != nil {
+			return err
+		}
+		if document == nil {
+			break
+		}
+
+		if err = m.addDocument(document); err != nil {
+			return err
+		}
+	}
+
+	if len(m.pages) == 0 {
+		return utils.ErrUnsupportedPDF
+	}
+
+	return m.finish()
+}
+
+// countingWriter keep the number of written byte, which is the offset of the next object
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int
+	err error
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	n, err := c.w.Write(b)
+	c.n += n
+	c.err = err
+	return n, err
+}
+
+func (c *countingWriter) WriteString(s string) {
+	_, _ = c.Write([]byte(s))
+}
+
+type bookmark struct {
+	title string
+	page  int
+}
+
+type pdfMerge struct {
+	w         *countingWriter
+	offsets   []int
+	pages     []int
+	bookmarks []bookmark
+}
+
+func (m *pdfMerge) reserve() int {
+	m.offsets = append(m.offsets, -1)
+	return len(m.offsets)
+}
+
+func (m *pdfMerge) write(object int, body string) {
+	m.offsets[object-1] = m.w.n
+	fmt.Fprintf(m.w, "%d 0 obj\n%s\nendobj\n", object, body)
+}
+
+// addDocument copy the page of the document and every object it reference, renumbered after the copied object
+func (m *pdfMerge) addDocument(document *pdf.MergeDocument) error {
+	file, err := parser.Parse(document.Content)
+	if err != nil {
+		return err
+	}
+
+	pages, err := file.Pages()
+	if err != nil {
+		return err
+	}
+
+	numbers := make(map[int]int)
+	var queue []int
+	renumber := func(number int) (int, error) {
+		if merged, ok := numbers[number]; ok {
+			return merged, nil
+		}
+
+		numbers[number] = m.reserve()
+		queue = append(queue, number)
+		return numbers[number], nil
+	}
+
+	// reference to the old catalog and page tree point to the merged one, so they are never copied
+	if root, err := file.Root(); err == nil {
+		numbers[root] = mergedCatalog
+	}
+	for _, page := range pages {
+		for parent, ok := page.Dict.Get("/Parent"); ok; {
+			number, isReference := parser.Reference(parent)
+			if !isReference || numbers[number] == mergedPages {
+				break
+			}
+			numbers[number] = mergedPages
+
+			node, err := file.Dictionary(number)
+			if err != nil {
+				return err
+			}
+			parent, ok = node.Get("/Parent")
+		}
+	}
+
+	for i, page := range pages {
+		object, _ := renumber(page.Object)
+		m.pages = append(m.pages, object)
+		if i == 0 {
+			m.bookmarks = append(m.bookmarks, bookmark{title: document.Title, page: object})
+		}
+	}
+
+	for len(queue) > 0 {
+		number := queue[0]
+		queue = queue[1:]
+
+		if err = m.copyObject(file, number, numbers[number], renumber); err != nil {
+			return err
+		}
+	}
+
+	return m.w.err
+}
+
+// inheritPageAttributes copy the attribute inherited from the old page tree into the page itself
+func inheritPageAttributes(file *parser.File, page *parser.Dictionary) error {
+	node := page
+	for depth := 0; depth < 32; depth++ {
+		parent, ok := node.Get("/Parent")
+		if !ok {
+			return nil
+		}
+
+		number, ok := parser.Reference(parent)
+		if !ok {
+			return utils.ErrUnsupportedPDF
+		}
+
+		var err error
+		if node, err = file.Dictionary(number); err != nil {
+			return err
+		}
+
+		for _, key := range inheritedPageKeys {
+			if _, ok := page.Get(key); ok {
+				continue
+			}
+			if value, ok := node.Get(key); ok {
+				page.Set(key, value)
+			}
+		}
+	}
+
+	return utils.ErrUnsupportedPDF
+}
+
+func (m *pdfMerge) copyObject(file *parser.File, number int, merged int, renumber func(int) (int, error)) error {
+	raw, err := file.Object(number)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(raw, "<<") {
+		dict, err := parser.ParseDictionary(raw)
+		if err != nil {
+			return err
+		}
+
+		if dictType, _ := dict.Get("/Type"); dictType == "/Page" {
+			return m.copyPage(file, number, merged, renumber)
+		}
+
+		if streamDict, content, err := file.Stream(number); err == nil {
+			// the length may be an indirect object, it is written directly so the object is not copied
+			streamDict.Set("/Length", strconv.Itoa(len(content)))
+			body, err := parser.RenumberReferences(streamDict.String(), renumber)
+			if err != nil {
+				return err
+			}
+
+			m.offsets[merged-1] = m.w.n
+			fmt.Fprintf(m.w, "%d 0 obj\n%s\nstream\n", merged, body)
+			_, _ = m.w.Write(content)
+			m.w.WriteString("\nendstream\nendobj\n")
+			return nil
+		}
+	}
+
+	body, err := parser.RenumberReferences(raw, renumber)
+	if err != nil {
+		return err
+	}
+
+	m.write(merged, body)
+	return nil
+}
+
+// copyPage write the page under the merged page tree with the attribute it inherited from the old one
+func (m *pdfMerge) copyPage(file *parser.File, number int, merged int, renumber func(int) (int, error)) error {
+	page, err := file.Dictionary(number)
+	if err != nil {
+		return err
+	}
+
+	if err = inheritPageAttributes(file, page); err != nil {
+		return err
+	}
+	page.Set("/Parent", fmt.Sprintf("%d 0 R", mergedPages))
+
+	body, err := parser.RenumberReferences(page.String(), renumber)
+	if err != nil {
+		return err
+	}
+
+	m.write(merged, body)
+	return nil
+}
+
+// finish write the page tree, the bookmark of every document, and the cross reference of the merged file
+func (m *pdfMerge) finish() error {
+	kids := make([]string, 0, len(m.pages))
+	for _, page := range m.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+	m.write(mergedPages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(m.pages)))
+
+	items := make([]int, len(m.bookmarks))
+	for i := range m.bookmarks {
+		items[i] = m.reserve()
+	}
+	for i, item := range m.bookmarks {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "<< /Title %s /Parent %d 0 R /Dest [%d 0 R /Fit]", outlineText(item.title), mergedOutlines, item.page)
+		if i > 0 {
+			fmt.Fprintf(&sb, " /Prev %d 0 R", items[i-1])
+		}
+		if i < len(items)-1 {
+			fmt.Fprintf(&sb, " /Next %d 0 R", items[i+1])
+		}
+		sb.WriteString(" >>")
+		m.write(items[i], sb.String())
+	}
+	m.write(mergedOutlines, fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count %d >>", items[0], items[len(items)-1], len(items)))
+	m.write(mergedCatalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R /Outlines %d 0 R /PageMode /UseOutlines >>", mergedPages, mergedOutlines))
+
+	xref := m.w.n
+	fmt.Fprintf(m.w, "xref\n0 %d\n0000000000 65535 f \n", len(m.offsets)+1)
+	for _, offset := range m.offsets {
+		fmt.Fprintf(m.w, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(m.w, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(m.offsets)+1, mergedCatalog, xref)
+
+	if m.w.err != nil {
+		return m.w.err
+	}
+
+	return m.w.w.Flush()
+}
+
+// outlineText encode the bookmark title, title outside printable ASCII is written as UTF-16 with byte order mark
+func outlineText(text string) string {
+	ascii := true
+	for _, r := range text {
+		if r < 0x20 || r > 0x7E {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return "(" + escapeText(text) + ")"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&sb, "%04X", unit)
+	}
+	sb.WriteString(">")
+
+	return sb.String()
+}
@@ -0,0 +1,118 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf/parser"
+)
+
+// documentIterator return the document one by one like the export does
+func documentIterator(documents ...*pdf.MergeDocument) func() (*pdf.MergeDocument, error) {
+	return func() (*pdf.MergeDocument, error) {
+		if len(documents) == 0 {
+			return nil, nil
+		}
+
+		document := documents[0]
+		documents = documents[1:]
+		return document, nil
+	}
+}
+
+func TestPDFMergerImpl_Merge(t *testing.T) {
+	service := NewNativePDFService(30 * time.Second)
+	first, err := service.GeneratePDF(context.Background(), bytes.NewBufferString(`<p>Surat satu</p><p style="page-break-before: always">Halaman dua</p>`), &pdf.PDFOptions{})
+	assert.NoError(t, err)
+	second, err := service.GeneratePDF(context.Background(), bytes.NewBufferString(`<p>Surat dua</p>`), &pdf.PDFOptions{Orientation: pdf.OrientationLandscape})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = NewPDFMergerImpl().Merge(&buf, documentIterator(
+		&pdf.MergeDocument{Title: "Surat (1)", Content: first},
+		&pdf.MergeDocument{Title: "Surat Keterangan Domisili — 2", Content: second},
+	))
+	assert.NoError(t, err)
+
+	merged := buf.Bytes()
+	file, err := parser.Parse(merged)
+	assert.NoError(t, err)
+
+	pages, err := file.Pages()
+	assert.NoError(t, err)
+	assert.Len(t, pages, 3)
+	assert.Equal(t, [4]float64{0, 0, 595.28, 841.89}, pages[0].MediaBox)
+	assert.Equal(t, [4]float64{0, 0, 841.89, 595.28}, pages[2].MediaBox)
+	assert.Equal(t, []string{"Surat satu", "Halaman dua", "Surat dua"}, pageTexts(t, merged))
+
+	catalog, err := file.Dictionary(1)
+	assert.NoError(t, err)
+	outlines, _ := catalog.Get("/Outlines")
+	outlineNumber, ok := parser.Reference(outlines)
+	assert.True(t, ok)
+
+	outline, err := file.Dictionary(outlineNumber)
+	assert.NoError(t, err)
+	count, _ := outline.Get("/Count")
+	assert.Equal(t, "2", count)
+
+	firstItem, _ := outline.Get("/First")
+	number, _ := parser.Reference(firstItem)
+	item, err := file.Dictionary(number)
+	assert.NoError(t, err)
+	title, _ := item.Get("/Title")
+	assert.Equal(t, `(Surat \(1\))`, title)
+	dest, _ := item.Get("/Dest")
+	assert.Equal(t, "["+pageReference(pages[0])+" /Fit]", dest)
+
+	next, _ := item.Get("/Next")
+	number, _ = parser.Reference(next)
+	item, err = file.Dictionary(number)
+	assert.NoError(t, err)
+	title, _ = item.Get("/Title")
+	assert.True(t, bytes.HasPrefix([]byte(title), []byte("<FEFF")))
+	dest, _ = item.Get("/Dest")
+	assert.Equal(t, "["+pageReference(pages[2])+" /Fit]", dest)
+}
+
+func pageReference(page parser.Page) string {
+	return fmt.Sprintf("%d 0 R", page.Object)
+}
+
+func TestPDFMergerImpl_Merge_Error(t *testing.T) {
+	for _, tc := range []struct {
+		Name     string
+		Next     func() (*pdf.MergeDocument, error)
+		Expected error
+	}{
+		{
+			Name: "Error from next document",
+			Next: func() (*pdf.MergeDocument, error) {
+				return nil, errors.New("render error")
+			},
+			Expected: errors.New("render error"),
+		},
+		{
+			Name:     "Error invalid PDF",
+			Next:     documentIterator(&pdf.MergeDocument{Title: "Surat", Content: []byte("not a pdf")}),
+			Expected: utils.ErrUnsupportedPDF,
+		},
+		{
+			Name:     "Error without document",
+			Next:     documentIterator(),
+			Expected: utils.ErrUnsupportedPDF,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := NewPDFMergerImpl().Merge(new(bytes.Buffer), tc.Next)
+			assert.Equal(t, tc.Expected, err)
+		})
+	}
+}
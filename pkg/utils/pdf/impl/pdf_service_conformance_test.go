@@ -0,0 +1,159 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+)
+
+// The conformance suite is the behaviour every PDF backend must have, it only inspect the structure of the output
+// since each backend lay the page out differently.
+
+var (
+	mediaBoxPattern  = regexp.MustCompile(`/MediaBox\s*\[\s*0\s+0\s+([\d.]+)\s+([\d.]+)\s*\]`)
+	pagePattern      = regexp.MustCompile(`/Type\s*/Page[^s]`)
+	imagePattern     = regexp.MustCompile(`/Subtype\s*/Image`)
+	startXrefPattern = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+)
+
+func TestNativePDFService_Conformance(t *testing.T) {
+	testPDFServiceConformance(t, NewNativePDFService(30*time.Second))
+}
+
+func TestPDFServiceImpl_Conformance(t *testing.T) {
+	if _, err := exec.LookPath("wkhtmltopdf"); err != nil {
+		t.Skip("wkhtmltopdf is not installed")
+	}
+
+	testPDFServiceConformance(t, NewPDFService(30*time.Second, nil))
+}
+
+func testPDFServiceConformance(t *testing.T, service pdf.PDFService) {
+	generate := func(t *testing.T, body string, options *pdf.PDFOptions) []byte {
+		result, err := service.GeneratePDF(context.Background(), bytes.NewBufferString(body), options)
+		assert.NoError(t, err)
+		assertValidPDF(t, result)
+		return result
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		result := generate(t, `<html><body><h1>Surat Keterangan</h1><p>Yang bertanda tangan di bawah ini menerangkan bahwa <b>Budi Santoso</b> adalah benar warga kami.</p></body></html>`, &pdf.PDFOptions{})
+		assert.Equal(t, 1, countPages(result))
+		assertMediaBox(t, result, 595.3, 841.9)
+	})
+
+	t.Run("Table", func(t *testing.T) {
+		result := generate(t, `<table border="1" style="width: 100%"><tr><th>No</th><th>Nama</th></tr><tr><td>1</td><td>Budi</td></tr><tr><td>2</td><td>Ani</td></tr></table>`, &pdf.PDFOptions{})
+		assert.Equal(t, 1, countPages(result))
+	})
+
+	t.Run("Image", func(t *testing.T) {
+		result := generate(t, `<p>Logo</p><img src="`+testPNG(t)+`" style="width: 2cm; height: 2cm">`, &pdf.PDFOptions{})
+		assert.Regexp(t, imagePattern, string(result))
+	})
+
+	t.Run("Page setup", func(t *testing.T) {
+		for _, tc := range []struct {
+			Name    string
+			Options *pdf.PDFOptions
+			Width   float64
+			Height  float64
+		}{
+			{Name: "Letter", Options: &pdf.PDFOptions{PageSize: pdf.PageSizeLetter}, Width: 612, Height: 792},
+			{Name: "A4 landscape", Options: &pdf.PDFOptions{PageSize: pdf.PageSizeA4, Orientation: pdf.OrientationLandscape}, Width: 841.9, Height: 595.3},
+			{Name: "F4", Options: &pdf.PDFOptions{PageSize: pdf.PageSizeF4}, Width: 609.4, Height: 935.4},
+			{Name: "Custom", Options: &pdf.PDFOptions{PageSize: pdf.PageSizeCustom, PageWidth: 100, PageHeight: 150}, Width: 283.5, Height: 425.2},
+		} {
+			t.Run(tc.Name, func(t *testing.T) {
+				assertMediaBox(t, generate(t, `<p>Halaman</p>`, tc.Options), tc.Width, tc.Height)
+			})
+		}
+	})
+
+	t.Run("Margin", func(t *testing.T) {
+		body := strings.Repeat(`<p>Paragraf yang cukup panjang untuk mengisi satu baris penuh pada halaman dokumen.</p>`, 60)
+		narrow := generate(t, body, &pdf.PDFOptions{MarginTop: 10, MarginBottom: 10, MarginLeft: 10, MarginRight: 10})
+		wide := generate(t, body, &pdf.PDFOptions{MarginTop: 60, MarginBottom: 60, MarginLeft: 50, MarginRight: 50})
+		assert.Greater(t, countPages(wide), countPages(narrow))
+	})
+
+	t.Run("Multiple page with running footer", func(t *testing.T) {
+		body := strings.Repeat(`<p>Isi dokumen</p>`, 150)
+		result := generate(t, body, &pdf.PDFOptions{
+			MarginBottom: 20,
+			FooterHTML:   bytes.NewBufferString(`<!DOCTYPE html><html><body><p style="font-size: 8pt">Halaman <span class="page"></span> dari <span class="topage"></span></p></body></html>`),
+		})
+		assert.Greater(t, countPages(result), 1)
+	})
+
+	t.Run("Page break", func(t *testing.T) {
+		result := generate(t, `<p>Halaman pertama</p><div style="page-break-before: always">Halaman kedua</div>`, &pdf.PDFOptions{})
+		assert.Equal(t, 2, countPages(result))
+	})
+
+	t.Run("Invalid page setup", func(t *testing.T) {
+		for _, options := range []*pdf.PDFOptions{
+			{PageSize: pdf.PageSizeCustom},
+			{PageSize: "A9"},
+			{Orientation: "Diagonal"},
+		} {
+			_, err := service.GeneratePDF(context.Background(), bytes.NewBufferString(`<p>Halaman</p>`), options)
+			assert.Equal(t, utils.ErrInvalidPageSetup, err)
+		}
+	})
+}
+
+func assertValidPDF(t *testing.T, result []byte) {
+	assert.True(t, bytes.HasPrefix(result, []byte("%PDF-")), "missing pdf header")
+
+	match := startXrefPattern.FindSubmatch(result)
+	if assert.NotNil(t, match, "missing startxref") {
+		offset, _ := strconv.Atoi(string(match[1]))
+		if assert.Less(t, offset, len(result)) {
+			assert.True(t, bytes.HasPrefix(result[offset:], []byte("xref")), "startxref didn't point to the cross reference")
+		}
+	}
+}
+
+func countPages(result []byte) int {
+	return len(pagePattern.FindAll(result, -1))
+}
+
+func assertMediaBox(t *testing.T, result []byte, width float64, height float64) {
+	match := mediaBoxPattern.FindSubmatch(result)
+	if !assert.NotNil(t, match, "missing media box") {
+		return
+	}
+
+	actualWidth, _ := strconv.ParseFloat(string(match[1]), 64)
+	actualHeight, _ := strconv.ParseFloat(string(match[2]), 64)
+	assert.InDelta(t, width, actualWidth, 2)
+	assert.InDelta(t, height, actualHeight, 2)
+}
+
+func testPNG(t *testing.T) string {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 60), G: uint8(y * 60), B: 200, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
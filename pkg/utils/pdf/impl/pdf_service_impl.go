@@ -2,38 +2,211 @@ package impl
 
 import (
 	"bytes"
+	"context"
+	"fmt"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
 	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+	"html"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/SebastiaanKlippert/go-wkhtmltopdf"
 )
 
+// unreachableProxy is used to block every network request made by wkhtmltopdf,
+// only host listed in allowedHosts bypass it
+const unreachableProxy = "http://127.0.0.1:9"
+
+// defaultDPI is used when the template didn't specify the dpi
+const defaultDPI = 300
+
 type PDFServiceImpl struct {
+	timeout      time.Duration
+	allowedHosts []string
 }
 
-func NewPDFService() pdf.PDFService {
-	return &PDFServiceImpl{}
+func NewPDFService(timeout time.Duration, allowedHosts []string) pdf.PDFService {
+	return &PDFServiceImpl{
+		timeout:      timeout,
+		allowedHosts: allowedHosts,
+	}
 }
 
-func (*PDFServiceImpl) GeneratePDF(data *bytes.Buffer, marginTop uint, marginBottom uint, marginLeft uint, marginRight uint) ([]byte, error) {
+func (p *PDFServiceImpl) GeneratePDF(ctx context.Context, data *bytes.Buffer, options *pdf.PDFOptions) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
 	pdfg, err := wkhtmltopdf.NewPDFGenerator()
 	if err != nil {
 		return nil, err
 	}
 
-	pdfg.MarginTop.Set(marginTop)
-	pdfg.MarginBottom.Set(marginBottom)
-	pdfg.MarginLeft.Set(marginLeft)
-	pdfg.MarginRight.Set(marginRight)
-	pdfg.Dpi.Set(600)
-	pdfg.PageSize.Set(wkhtmltopdf.PageSizeA4)
-	pdfg.Orientation.Set(wkhtmltopdf.OrientationPortrait)
+	if err := p.setPage(pdfg, options); err != nil {
+		return nil, err
+	}
+
+	if options.Watermark != nil {
+		if data, err = WithWatermark(data, options); err != nil {
+			return nil, err
+		}
+	}
 
 	page := wkhtmltopdf.NewPageReader(bytes.NewReader(data.Bytes()))
+	if options.Zoom > 0 {
+		page.Zoom.Set(options.Zoom)
+	}
+	p.restrictPage(&page.PageOptions)
+
+	if options.HeaderHTML != nil || options.FooterHTML != nil {
+		sectionDir, err := p.setPageSection(&page.PageOptions, options)
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(sectionDir)
+	}
+
 	pdfg.AddPage(page)
 
-	if err := pdfg.Create(); err != nil {
+	if err := pdfg.CreateContext(ctx); err != nil {
 		return nil, err
 	}
 
 	return pdfg.Bytes(), nil
 }
+
+// setPage apply page size, orientation, dpi, and margin to the generator, zero value fallback to A4 portrait at defaultDPI
+func (p *PDFServiceImpl) setPage(pdfg *wkhtmltopdf.PDFGenerator, options *pdf.PDFOptions) error {
+	pdfg.MarginTop.Set(options.MarginTop)
+	pdfg.MarginBottom.Set(options.MarginBottom)
+	pdfg.MarginLeft.Set(options.MarginLeft)
+	pdfg.MarginRight.Set(options.MarginRight)
+	pdfg.Grayscale.Set(options.Grayscale)
+
+	dpi := options.DPI
+	if dpi == 0 {
+		dpi = defaultDPI
+	}
+	pdfg.Dpi.Set(dpi)
+
+	orientation := options.Orientation
+	if orientation == "" {
+		orientation = pdf.OrientationPortrait
+	}
+	if orientation != pdf.OrientationPortrait && orientation != pdf.OrientationLandscape {
+		return utils.ErrInvalidPageSetup
+	}
+
+	var width, height uint
+	switch options.PageSize {
+	case "":
+		pdfg.PageSize.Set(pdf.PageSizeA4)
+	case pdf.PageSizeA3, pdf.PageSizeA4, pdf.PageSizeA5, pdf.PageSizeB5, pdf.PageSizeLetter, pdf.PageSizeLegal:
+		pdfg.PageSize.Set(options.PageSize)
+	case pdf.PageSizeF4:
+		width, height = pdf.F4Width, pdf.F4Height
+	case pdf.PageSizeCustom:
+		width, height = options.PageWidth, options.PageHeight
+		if width == 0 || height == 0 {
+			return utils.ErrInvalidPageSetup
+		}
+	default:
+		return utils.ErrInvalidPageSetup
+	}
+
+	if width == 0 {
+		pdfg.Orientation.Set(orientation)
+		return nil
+	}
+
+	// wkhtmltopdf ignore orientation when page dimension is given, so the dimension is swapped here instead
+	if orientation == pdf.OrientationLandscape && width < height {
+		width, height = height, width
+	}
+	pdfg.PageWidth.Set(width)
+	pdfg.PageHeight.Set(height)
+
+	return nil
+}
+
+// setPageSection write the running header and footer into a temporary directory, since wkhtmltopdf only accept them
+// as file. Only the written section file is allowed to be read, and javascript stay disabled for the whole page
+func (p *PDFServiceImpl) setPageSection(options *wkhtmltopdf.PageOptions, pdfOptions *pdf.PDFOptions) (string, error) {
+	dir, err := os.MkdirTemp("", "ead-section-")
+	if err != nil {
+		return "", err
+	}
+
+	for _, section := range []struct {
+		content *bytes.Buffer
+		name    string
+		option  func(string)
+	}{
+		{pdfOptions.HeaderHTML, "header.html", options.HeaderHTML.Set},
+		{pdfOptions.FooterHTML, "footer.html", options.FooterHTML.Set},
+	} {
+		if section.content == nil {
+			continue
+		}
+
+		path := filepath.Join(dir, section.name)
+		if err := os.WriteFile(path, section.content.Bytes(), 0600); err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+		section.option(path)
+		options.Allow.Set(path)
+	}
+
+	return dir, nil
+}
+
+// restrictPage prevent the rendered page from reading local file, running script, or reaching network
+func (p *PDFServiceImpl) restrictPage(options *wkhtmltopdf.PageOptions) {
+	options.DisableLocalFileAccess.Set(true)
+	options.DisableJavascript.Set(true)
+	options.Proxy.Set(unreachableProxy)
+	for _, host := range p.allowedHosts {
+		options.BypassProxyFor.Set(host)
+	}
+}
+
+// WithWatermark add the watermark as fixed element at the end of the body, wkhtmltopdf repeat fixed element on every
+// page and browser keep it over the document. The font size is fitted to the page the same way as the native backend
+func WithWatermark(data *bytes.Buffer, options *pdf.PDFOptions) (*bytes.Buffer, error) {
+	width, height, err := pdf.PageDimension(options)
+	if err != nil {
+		return nil, err
+	}
+
+	watermark := options.Watermark
+	face := fontFaces["sans-bold"]
+	unitWidth := face.textWidth(encodeText(watermark.Text), 1)
+	if unitWidth == 0 {
+		return data, nil
+	}
+
+	angle := watermark.Angle * math.Pi / 180
+	cos, sin := math.Abs(math.Cos(angle)), math.Abs(math.Sin(angle))
+	width -= float64(options.MarginLeft + options.MarginRight)
+	height -= float64(options.MarginTop + options.MarginBottom)
+	length := math.Min(0.8*width/math.Max(cos, 0.01), 0.8*height/math.Max(sin, 0.01)) * mmToPt
+	size := math.Min(length/unitWidth, maxWatermarkSize)
+	textWidth := unitWidth * size
+
+	overlay := fmt.Sprintf(`<div style="position: fixed; top: 50%%; left: 50%%; width: 0; height: 0; z-index: 1000;">`+
+		`<div style="position: absolute; left: -%spt; top: -%spt; width: %spt; line-height: 1; white-space: nowrap; text-align: center; `+
+		`font-family: Helvetica, Arial, sans-serif; font-weight: bold; font-size: %spt; color: #808080; opacity: %s; `+
+		`-webkit-transform: rotate(%sdeg); transform: rotate(%sdeg);">%s</div></div>`,
+		round(textWidth/2), round(size/2), round(textWidth), round(size), round(watermark.Opacity),
+		round(-watermark.Angle), round(-watermark.Angle), html.EscapeString(watermark.Text))
+
+	content := data.String()
+	if index := strings.LastIndex(strings.ToLower(content), "</body>"); index >= 0 {
+		return bytes.NewBufferString(content[:index] + overlay + content[index:]), nil
+	}
+
+	return bytes.NewBufferString(content + overlay), nil
+}
@@ -0,0 +1,49 @@
+package impl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SebastiaanKlippert/go-wkhtmltopdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+)
+
+func TestWithWatermark(t *testing.T) {
+	options := &pdf.PDFOptions{Watermark: &pdf.Watermark{Text: "<DRAFT>", Opacity: 0.15, Angle: 45}}
+
+	result, err := WithWatermark(bytes.NewBufferString(`<html><BODY><p>Isi</p></BODY></html>`), options)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(result.String(), `<html><BODY><p>Isi</p><div style="position: fixed;`))
+	assert.True(t, strings.HasSuffix(result.String(), `&lt;DRAFT&gt;</div></div></BODY></html>`))
+	assert.Contains(t, result.String(), "opacity: 0.15; -webkit-transform: rotate(-45deg);")
+
+	result, err = WithWatermark(bytes.NewBufferString(`<p>Isi</p>`), options)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(result.String(), `<p>Isi</p><div style="position: fixed;`))
+
+	_, err = WithWatermark(bytes.NewBufferString(`<p>Isi</p>`), &pdf.PDFOptions{PageSize: "A0", Watermark: options.Watermark})
+	assert.Equal(t, utils.ErrInvalidPageSetup, err)
+}
+
+func TestPDFServiceImpl_setPageSection(t *testing.T) {
+	service := &PDFServiceImpl{}
+	page := wkhtmltopdf.NewPageReader(bytes.NewReader(nil))
+	service.restrictPage(&page.PageOptions)
+
+	dir, err := service.setPageSection(&page.PageOptions, &pdf.PDFOptions{HeaderHTML: bytes.NewBufferString("<p>Kop</p>")})
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	header := filepath.Join(dir, "header.html")
+	args := strings.Join(page.PageOptions.Args(), " ")
+	assert.Contains(t, args, "--disable-javascript")
+	assert.Contains(t, args, "--allow "+header)
+	assert.NotContains(t, args, "--allow "+dir+" ")
+	assert.Contains(t, args, "--header-html "+header)
+	assert.NotContains(t, args, "--footer-html")
+}
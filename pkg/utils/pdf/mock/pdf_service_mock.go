@@ -2,14 +2,35 @@ package mock
 
 import (
 	"bytes"
+	"context"
 	"github.com/stretchr/testify/mock"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+	"io"
 )
 
 type MockPDFService struct {
 	mock.Mock
 }
 
-func (m *MockPDFService) GeneratePDF(data *bytes.Buffer, marginTop uint, marginBottom uint, marginLeft uint, marginRight uint) ([]byte, error) {
-	args := m.Called(data, marginTop, marginBottom, marginLeft, marginRight)
+func (m *MockPDFService) GeneratePDF(ctx context.Context, data *bytes.Buffer, options *pdf.PDFOptions) ([]byte, error) {
+	args := m.Called(ctx, data, options)
 	return args.Get(0).([]byte), args.Error(1)
 }
+
+type MockThumbnailService struct {
+	mock.Mock
+}
+
+func (m *MockThumbnailService) GenerateThumbnail(ctx context.Context, data *bytes.Buffer, options *pdf.PDFOptions, width int) ([]byte, error) {
+	args := m.Called(ctx, data, options, width)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+type MockPDFMerger struct {
+	mock.Mock
+}
+
+func (m *MockPDFMerger) Merge(w io.Writer, next func() (*pdf.MergeDocument, error)) error {
+	args := m.Called(w, next)
+	return args.Error(0)
+}
@@ -0,0 +1,613 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+// referencePattern match an indirect reference, eg: 12 0 R
+var referencePattern = regexp.MustCompile(`^(\d+)\s+(\d+)\s+R$`)
+
+// File is the cross reference of a PDF produced by the PDF backend. Only PDF with classic cross reference table is
+// supported, which is what both PDF backend produce
+type File struct {
+	Data      []byte
+	Offsets   map[int]int
+	Size      int
+	Startxref int
+	Trailer   *Dictionary
+}
+
+// Page is a page object with the media box inherited from its parent when the page didn't have one
+type Page struct {
+	Object   int
+	Dict     *Dictionary
+	MediaBox [4]float64
+}
+
+func Parse(data []byte) (*File, error) {
+	index := bytes.LastIndex(data, []byte("startxref"))
+	if index < 0 {
+		return nil, utils.ErrUnsupportedPDF
+	}
+
+	fields := strings.Fields(string(data[index+len("startxref"):]))
+	if len(fields) == 0 {
+		return nil, utils.ErrUnsupportedPDF
+	}
+	startxref, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, utils.ErrUnsupportedPDF
+	}
+
+	file := &File{Data: data, Offsets: make(map[int]int), Startxref: startxref}
+	visited := make(map[int]bool)
+	for offset := startxref; ; {
+		if offset < 0 || offset >= len(data) || visited[offset] {
+			return nil, utils.ErrUnsupportedPDF
+		}
+		visited[offset] = true
+
+		trailer, err := file.readXref(offset)
+		if err != nil {
+			return nil, err
+		}
+		if file.Trailer == nil {
+			file.Trailer = trailer
+		}
+
+		prev, ok := trailer.Get("/Prev")
+		if !ok {
+			break
+		}
+		if offset, err = strconv.Atoi(prev); err != nil {
+			return nil, utils.ErrUnsupportedPDF
+		}
+	}
+
+	size, ok := file.Trailer.Get("/Size")
+	if !ok {
+		return nil, utils.ErrUnsupportedPDF
+	}
+	if file.Size, err = strconv.Atoi(size); err != nil {
+		return nil, utils.ErrUnsupportedPDF
+	}
+
+	return file, nil
+}
+
+// readXref read a cross reference section and its trailer, the entry of the newer section take precedence
+func (f *File) readXref(offset int) (*Dictionary, error) {
+	l := &lexer{data: f.Data, pos: offset}
+	if l.token() != "xref" {
+		return nil, utils.ErrUnsupportedPDF
+	}
+
+	for {
+		token := l.token()
+		if token == "trailer" {
+			break
+		}
+
+		start, err := strconv.Atoi(token)
+		if err != nil {
+			return nil, utils.ErrUnsupportedPDF
+		}
+		count, err := strconv.Atoi(l.token())
+		if err != nil {
+			return nil, utils.ErrUnsupportedPDF
+		}
+
+		for i := 0; i < count; i++ {
+			entryOffset, err := strconv.Atoi(l.token())
+			if err != nil {
+				return nil, utils.ErrUnsupportedPDF
+			}
+			l.token()
+			kind := l.token()
+
+			if _, ok := f.Offsets[start+i]; !ok && kind == "n" {
+				f.Offsets[start+i] = entryOffset
+			}
+		}
+	}
+
+	return l.dictionary()
+}
+
+// Object return the raw value of the object, or the dictionary of a stream
+func (f *File) Object(number int) (string, error) {
+	offset, ok := f.Offsets[number]
+	if !ok || offset >= len(f.Data) {
+		return "", utils.ErrUnsupportedPDF
+	}
+
+	l := &lexer{data: f.Data, pos: offset}
+	if l.token() != strconv.Itoa(number) {
+		return "", utils.ErrUnsupportedPDF
+	}
+	l.token()
+	if l.token() != "obj" {
+		return "", utils.ErrUnsupportedPDF
+	}
+
+	return l.value()
+}
+
+// Stream return the dictionary and the raw content of the stream object, the content is left encoded
+func (f *File) Stream(number int) (*Dictionary, []byte, error) {
+	offset, ok := f.Offsets[number]
+	if !ok || offset >= len(f.Data) {
+		return nil, nil, utils.ErrUnsupportedPDF
+	}
+
+	l := &lexer{data: f.Data, pos: offset}
+	if l.token() != strconv.Itoa(number) {
+		return nil, nil, utils.ErrUnsupportedPDF
+	}
+	l.token()
+	if l.token() != "obj" {
+		return nil, nil, utils.ErrUnsupportedPDF
+	}
+
+	dict, err := l.dictionary()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if l.token() != "stream" {
+		return nil, nil, utils.ErrUnsupportedPDF
+	}
+	// the keyword is followed by CRLF or LF only, the content may start with white space
+	if !l.consume("\r\n") && !l.consume("\n") {
+		return nil, nil, utils.ErrUnsupportedPDF
+	}
+
+	value, _ := dict.Get("/Length")
+	if value, err = f.Resolve(value); err != nil {
+		return nil, nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || length < 0 || l.pos+length > len(l.data) {
+		return nil, nil, utils.ErrUnsupportedPDF
+	}
+
+	return dict, l.data[l.pos : l.pos+length], nil
+}
+
+func (f *File) Dictionary(number int) (*Dictionary, error) {
+	raw, err := f.Object(number)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseDictionary(raw)
+}
+
+// Resolve return the value itself, or the referenced object when the value is an indirect reference
+func (f *File) Resolve(value string) (string, error) {
+	if number, ok := Reference(value); ok {
+		return f.Object(number)
+	}
+
+	return value, nil
+}
+
+func (f *File) Root() (int, error) {
+	value, _ := f.Trailer.Get("/Root")
+	number, ok := Reference(value)
+	if !ok {
+		return 0, utils.ErrUnsupportedPDF
+	}
+
+	return number, nil
+}
+
+// Pages walk the page tree in order, the media box is inherited from the parent node
+func (f *File) Pages() ([]Page, error) {
+	root, err := f.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := f.Dictionary(root)
+	if err != nil {
+		return nil, err
+	}
+
+	value, _ := catalog.Get("/Pages")
+	number, ok := Reference(value)
+	if !ok {
+		return nil, utils.ErrUnsupportedPDF
+	}
+
+	var pages []Page
+	visited := make(map[int]bool)
+	var walk func(number int, mediaBox [4]float64) error
+	walk = func(number int, mediaBox [4]float64) error {
+		if visited[number] {
+			return utils.ErrUnsupportedPDF
+		}
+		visited[number] = true
+
+		node, err := f.Dictionary(number)
+		if err != nil {
+			return err
+		}
+
+		if value, ok := node.Get("/MediaBox"); ok {
+			if value, err = f.Resolve(value); err != nil {
+				return err
+			}
+			if mediaBox, err = ParseRectangle(value); err != nil {
+				return err
+			}
+		}
+
+		if nodeType, _ := node.Get("/Type"); nodeType != "/Pages" {
+			pages = append(pages, Page{Object: number, Dict: node, MediaBox: mediaBox})
+			return nil
+		}
+
+		kids, _ := node.Get("/Kids")
+		if kids, err = f.Resolve(kids); err != nil {
+			return err
+		}
+		values, err := ParseArray(kids)
+		if err != nil {
+			return err
+		}
+
+		for _, kid := range values {
+			number, ok := Reference(kid)
+			if !ok {
+				return utils.ErrUnsupportedPDF
+			}
+			if err := walk(number, mediaBox); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(number, [4]float64{0, 0, 612, 792}); err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, utils.ErrUnsupportedPDF
+	}
+
+	return pages, nil
+}
+
+func Reference(value string) (int, bool) {
+	match := referencePattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, false
+	}
+
+	number, err := strconv.Atoi(match[1])
+	return number, err == nil
+}
+
+func ParseRectangle(value string) ([4]float64, error) {
+	var rectangle [4]float64
+	values, err := ParseArray(value)
+	if err != nil || len(values) != 4 {
+		return rectangle, utils.ErrUnsupportedPDF
+	}
+
+	for i, v := range values {
+		if rectangle[i], err = strconv.ParseFloat(v, 64); err != nil {
+			return rectangle, utils.ErrUnsupportedPDF
+		}
+	}
+
+	return rectangle, nil
+}
+
+// Dictionary keep the value of each entry as raw text, so the entry that is not changed is written back as is
+type Dictionary struct {
+	keys   []string
+	values map[string]string
+}
+
+func NewDictionary() *Dictionary {
+	return &Dictionary{values: make(map[string]string)}
+}
+
+func ParseDictionary(raw string) (*Dictionary, error) {
+	return ParseDictionaryAt([]byte(raw), 0)
+}
+
+// ParseDictionaryAt read the dictionary that start at the offset of the data, used when the object is not listed in
+// the cross reference
+func ParseDictionaryAt(data []byte, offset int) (*Dictionary, error) {
+	l := &lexer{data: data, pos: offset}
+	return l.dictionary()
+}
+
+func (d *Dictionary) Get(key string) (string, bool) {
+	value, ok := d.values[key]
+	return value, ok
+}
+
+func (d *Dictionary) Set(key string, value string) {
+	if _, ok := d.values[key]; !ok {
+		d.keys = append(d.keys, key)
+	}
+	d.values[key] = value
+}
+
+func (d *Dictionary) String() string {
+	var sb strings.Builder
+	sb.WriteString("<<")
+	for _, key := range d.keys {
+		fmt.Fprintf(&sb, " %s %s", key, d.values[key])
+	}
+	sb.WriteString(" >>")
+
+	return sb.String()
+}
+
+// ParseArray split the raw array into the raw text of its element, indirect reference is kept as one element
+func ParseArray(raw string) ([]string, error) {
+	l := &lexer{data: []byte(raw)}
+	l.skip()
+	if !l.consume("[") {
+		return nil, utils.ErrUnsupportedPDF
+	}
+
+	var values []string
+	for {
+		l.skip()
+		if l.pos >= len(l.data) {
+			return nil, utils.ErrUnsupportedPDF
+		}
+		if l.consume("]") {
+			return values, nil
+		}
+
+		value, err := l.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+}
+
+// AppendArray add the value at the end of the raw array
+func AppendArray(raw string, value string) (string, error) {
+	values, err := ParseArray(raw)
+	if err != nil {
+		return "", err
+	}
+
+	return "[" + strings.Join(append(values, value), " ") + "]", nil
+}
+
+// RenumberReferences rewrite every indirect reference in the raw value with the number returned by the function,
+// string and name are copied as is so the text that only look like a reference is left alone
+func RenumberReferences(raw string, renumber func(number int) (int, error)) (string, error) {
+	l := &lexer{data: []byte(raw)}
+	var sb strings.Builder
+	for l.pos < len(l.data) {
+		start := l.pos
+		switch c := l.data[l.pos]; {
+		case c == '(':
+			l.pos++
+			if err := l.skipString(); err != nil {
+				return "", err
+			}
+			sb.Write(l.data[start:l.pos])
+		case l.consume("<<"):
+			sb.WriteString("<<")
+		case c == '<':
+			end := bytes.IndexByte(l.data[l.pos:], '>')
+			if end < 0 {
+				return "", utils.ErrUnsupportedPDF
+			}
+			l.pos += end + 1
+			sb.Write(l.data[start:l.pos])
+		case c == '/' || (c >= '0' && c <= '9' && (start == 0 || isWhitespace(l.data[start-1]) || isDelimiter(l.data[start-1]))):
+			token := l.token()
+			number, err := strconv.Atoi(token)
+			if c == '/' || err != nil {
+				sb.WriteString(token)
+				continue
+			}
+
+			// the integer is a reference when it is followed by the generation and R keyword
+			back := l.pos
+			if _, err := strconv.Atoi(l.token()); err != nil || l.token() != "R" {
+				l.pos = back
+				sb.WriteString(token)
+				continue
+			}
+
+			if number, err = renumber(number); err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&sb, "%d 0 R", number)
+		default:
+			sb.WriteByte(c)
+			l.pos++
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// lexer read the PDF object syntax, only far enough to find where each value start and end
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// skip move past whitespace and comment
+func (l *lexer) skip() {
+	for l.pos < len(l.data) {
+		switch c := l.data[l.pos]; {
+		case isWhitespace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) consume(prefix string) bool {
+	if bytes.HasPrefix(l.data[l.pos:], []byte(prefix)) {
+		l.pos += len(prefix)
+		return true
+	}
+
+	return false
+}
+
+// token read a regular token such as number, keyword, or name
+func (l *lexer) token() string {
+	l.skip()
+	start := l.pos
+	if l.pos < len(l.data) && l.data[l.pos] == '/' {
+		l.pos++
+	}
+	for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+
+	return string(l.data[start:l.pos])
+}
+
+func (l *lexer) dictionary() (*Dictionary, error) {
+	l.skip()
+	if !l.consume("<<") {
+		return nil, utils.ErrUnsupportedPDF
+	}
+
+	dict := NewDictionary()
+	for {
+		l.skip()
+		if l.pos >= len(l.data) {
+			return nil, utils.ErrUnsupportedPDF
+		}
+		if l.consume(">>") {
+			return dict, nil
+		}
+
+		key := l.token()
+		if !strings.HasPrefix(key, "/") {
+			return nil, utils.ErrUnsupportedPDF
+		}
+
+		value, err := l.value()
+		if err != nil {
+			return nil, err
+		}
+		dict.Set(key, value)
+	}
+}
+
+// value return the raw text of the next value
+func (l *lexer) value() (string, error) {
+	l.skip()
+	if l.pos >= len(l.data) {
+		return "", utils.ErrUnsupportedPDF
+	}
+
+	start := l.pos
+	switch {
+	case l.consume("<<"):
+		l.pos = start
+		if _, err := l.dictionary(); err != nil {
+			return "", err
+		}
+	case l.consume("<"):
+		end := bytes.IndexByte(l.data[l.pos:], '>')
+		if end < 0 {
+			return "", utils.ErrUnsupportedPDF
+		}
+		l.pos += end + 1
+	case l.consume("["):
+		if err := l.skipArray(); err != nil {
+			return "", err
+		}
+	case l.consume("("):
+		if err := l.skipString(); err != nil {
+			return "", err
+		}
+	default:
+		token := l.token()
+		if token == "" {
+			return "", utils.ErrUnsupportedPDF
+		}
+
+		// an integer may be the start of an indirect reference
+		if _, err := strconv.Atoi(token); err == nil {
+			back := l.pos
+			generation := l.token()
+			if _, err := strconv.Atoi(generation); err == nil && l.token() == "R" {
+				return string(l.data[start:l.pos]), nil
+			}
+			l.pos = back
+		}
+	}
+
+	return string(l.data[start:l.pos]), nil
+}
+
+// skipArray move past the array element, the opening bracket is already consumed
+func (l *lexer) skipArray() error {
+	for {
+		l.skip()
+		if l.pos >= len(l.data) {
+			return utils.ErrUnsupportedPDF
+		}
+		if l.consume("]") {
+			return nil
+		}
+		if _, err := l.value(); err != nil {
+			return err
+		}
+	}
+}
+
+// skipString move past a literal string, which may contain balanced or escaped parenthesis
+func (l *lexer) skipString() error {
+	depth := 1
+	for l.pos < len(l.data) {
+		switch l.data[l.pos] {
+		case '\\':
+			l.pos++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				l.pos++
+				return nil
+			}
+		}
+		l.pos++
+	}
+
+	return utils.ErrUnsupportedPDF
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+// testObjects is a two page PDF with inherited media box and a stream whose length is an indirect object
+var testObjects = []string{
+	"<< /Type /Catalog /Pages 2 0 R >>",
+	"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 200 100] >>",
+	"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+	"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 50 50] >>",
+	"<< /Length 6 0 R >>\nstream\nBT ET\nendstream",
+	"5",
+}
+
+func testFile(t *testing.T) *File {
+	data := "%PDF-1.4\n"
+	xref := fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(testObjects)+1)
+	for i, object := range testObjects {
+		xref += fmt.Sprintf("%010d 00000 n \n", len(data))
+		data += fmt.Sprintf("%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	data += xref + fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(testObjects)+1, len(data))
+
+	file, err := Parse([]byte(data))
+	assert.NoError(t, err)
+
+	return file
+}
+
+func TestFile_Pages(t *testing.T) {
+	pages, err := testFile(t).Pages()
+	assert.NoError(t, err)
+	assert.Len(t, pages, 2)
+	assert.Equal(t, 3, pages[0].Object)
+	assert.Equal(t, [4]float64{0, 0, 200, 100}, pages[0].MediaBox)
+	assert.Equal(t, [4]float64{0, 0, 50, 50}, pages[1].MediaBox)
+}
+
+func TestFile_Stream(t *testing.T) {
+	file := testFile(t)
+
+	dict, content, err := file.Stream(5)
+	assert.NoError(t, err)
+	assert.Equal(t, "BT ET", string(content))
+	length, _ := dict.Get("/Length")
+	assert.Equal(t, "6 0 R", length)
+
+	_, _, err = file.Stream(3)
+	assert.Equal(t, utils.ErrUnsupportedPDF, err)
+}
+
+func TestRenumberReferences(t *testing.T) {
+	for _, tc := range []struct {
+		Name     string
+		Raw      string
+		Expected string
+	}{
+		{
+			Name:     "Dictionary",
+			Raw:      "<< /Type /Page /Parent 2 0 R /Contents [5 0 R 6 0 R] /Rotate 90 >>",
+			Expected: "<< /Type /Page /Parent 102 0 R /Contents [105 0 R 106 0 R] /Rotate 90 >>",
+		},
+		{
+			Name:     "Text that look like reference",
+			Raw:      "<< /T (Signature 1 0 R) /ID <01 0 52> /F1 3 0 R /W [1 2 3] >>",
+			Expected: "<< /T (Signature 1 0 R) /ID <01 0 52> /F1 103 0 R /W [1 2 3] >>",
+		},
+		{
+			Name:     "Plain reference",
+			Raw:      "12 0 R",
+			Expected: "112 0 R",
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			result, err := RenumberReferences(tc.Raw, func(number int) (int, error) {
+				return number + 100, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, result)
+		})
+	}
+}
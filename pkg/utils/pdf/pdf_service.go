@@ -1,7 +1,141 @@
 package pdf
 
-import "bytes"
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+// Supported PDF backend, wkhtmltopdf need the external binary while native is written in go
+const (
+	BackendWkhtmltopdf = "wkhtmltopdf"
+	BackendNative      = "native"
+)
+
+// Supported page size, F4 and Custom are rendered using explicit page dimension
+const (
+	PageSizeA3     = "A3"
+	PageSizeA4     = "A4"
+	PageSizeA5     = "A5"
+	PageSizeB5     = "B5"
+	PageSizeLetter = "Letter"
+	PageSizeLegal  = "Legal"
+	PageSizeF4     = "F4"
+	PageSizeCustom = "Custom"
+)
+
+// Supported page orientation
+const (
+	OrientationPortrait  = "Portrait"
+	OrientationLandscape = "Landscape"
+)
+
+// F4 (Folio) page dimension in millimeter
+const (
+	F4Width  = 215
+	F4Height = 330
+)
+
+// Watermark default when the template didn't set them
+const (
+	DefaultWatermarkOpacity = 0.15
+	DefaultWatermarkAngle   = 45
+)
+
+// Watermark is the text printed diagonally across every page, the angle is in degree counterclockwise from the
+// page bottom edge
+type Watermark struct {
+	Text    string
+	Opacity float64
+	Angle   float64
+}
+
+// PDFOptions hold the page setup of a rendered document. Width, height and margin are in millimeter.
+// HeaderHTML and FooterHTML are standalone html document repeated on every page, Watermark is drawn over the
+// content of every page, they are nil when not used
+type PDFOptions struct {
+	PageSize     string
+	PageWidth    uint
+	PageHeight   uint
+	Orientation  string
+	DPI          uint
+	Grayscale    bool
+	Zoom         float64
+	MarginTop    uint
+	MarginBottom uint
+	MarginLeft   uint
+	MarginRight  uint
+	HeaderHTML   *bytes.Buffer
+	FooterHTML   *bytes.Buffer
+	Watermark    *Watermark
+}
+
+// pageDimensions is the portrait dimension of the named page size in millimeter
+var pageDimensions = map[string][2]float64{
+	PageSizeA3:     {297, 420},
+	PageSizeA4:     {210, 297},
+	PageSizeA5:     {148, 210},
+	PageSizeB5:     {176, 250},
+	PageSizeLetter: {215.9, 279.4},
+	PageSizeLegal:  {215.9, 355.6},
+	PageSizeF4:     {F4Width, F4Height},
+}
+
+// PageDimension return the page width and height in millimeter after the orientation is applied,
+// zero value fallback to A4 portrait
+func PageDimension(options *PDFOptions) (float64, float64, error) {
+	orientation := options.Orientation
+	if orientation == "" {
+		orientation = OrientationPortrait
+	}
+	if orientation != OrientationPortrait && orientation != OrientationLandscape {
+		return 0, 0, utils.ErrInvalidPageSetup
+	}
+
+	pageSize := options.PageSize
+	if pageSize == "" {
+		pageSize = PageSizeA4
+	}
+
+	dimension, ok := pageDimensions[pageSize]
+	if pageSize == PageSizeCustom {
+		if options.PageWidth == 0 || options.PageHeight == 0 {
+			return 0, 0, utils.ErrInvalidPageSetup
+		}
+		dimension, ok = [2]float64{float64(options.PageWidth), float64(options.PageHeight)}, true
+	}
+	if !ok {
+		return 0, 0, utils.ErrInvalidPageSetup
+	}
+
+	width, height := dimension[0], dimension[1]
+	if orientation == OrientationLandscape && width < height {
+		width, height = height, width
+	}
+
+	return width, height, nil
+}
 
 type PDFService interface {
-	GeneratePDF(data *bytes.Buffer, marginTop uint, marginBottom uint, marginLeft uint, marginRight uint) ([]byte, error)
+	GeneratePDF(ctx context.Context, data *bytes.Buffer, options *PDFOptions) ([]byte, error)
+}
+
+// ThumbnailService rasterize the first page of the document into a PNG image, the width is in pixel and the height
+// follow the page aspect ratio
+type ThumbnailService interface {
+	GenerateThumbnail(ctx context.Context, data *bytes.Buffer, options *PDFOptions, width int) ([]byte, error)
+}
+
+// MergeDocument is one document of the merged PDF, the title is the bookmark that point to its first page
+type MergeDocument struct {
+	Title   string
+	Content []byte
+}
+
+// PDFMerger join many PDF into one file with a bookmark for every document. Next return the next document or nil
+// after the last one, so only one document is kept in memory while the merged file is written
+type PDFMerger interface {
+	Merge(w io.Writer, next func() (*MergeDocument, error)) error
 }
@@ -1,25 +1,108 @@
 package impl
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
-	"github.com/suryaadi44/eAD-System/pkg/utils/qr"
+	"encoding/pem"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
 
+	"github.com/golang-jwt/jwt"
 	"github.com/skip2/go-qrcode"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/qr"
 )
 
+type tokenClaims struct {
+	RegisterID uint   `json:"reg,omitempty"`
+	Hash       string `json:"hash"`
+	jwt.StandardClaims
+}
+
+// CodeServiceImpl print the status url with a token signed by the office key, so the QR can't be forged to point to
+// another document and verifier application can check it offline with the published public key
 type CodeServiceImpl struct {
 	basePath string
+	key      ed25519.PrivateKey
+	keyID    string
 }
 
-func NewCodeServiceImpl(basePath string) qr.CodeService {
-	return &CodeServiceImpl{basePath: basePath}
+func NewCodeServiceImpl(basePath string, key ed25519.PrivateKey) qr.CodeService {
+	publicKey := key.Public().(ed25519.PublicKey)
+	sum := sha256.Sum256(publicKey)
+
+	return &CodeServiceImpl{
+		basePath: basePath,
+		key:      key,
+		keyID:    base64.RawURLEncoding.EncodeToString(sum[:8]),
+	}
+}
+
+// LoadSigningKey read the PKCS#8 PEM Ed25519 key of the QR token. The key is never created here, a new key would
+// invalidate every printed QR, so it must be created once with CreateSigningKey and shared by every instance
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, utils.ErrQRSigningKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	key, err := jwt.ParseEdPrivateKeyFromPEM(content)
+	if err != nil {
+		return nil, utils.ErrInvalidQRSigningKey
+	}
+
+	return key.(ed25519.PrivateKey), nil
+}
+
+// CreateSigningKey write a new QR signing key to the path, an existing key is never overwritten
+func CreateSigningKey(path string) (ed25519.PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return nil, utils.ErrQRSigningKeyExists
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err = pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return nil, err
+	}
+
+	return key, file.Close()
 }
 
-func (c *CodeServiceImpl) GenerateQRCode(documentID string) ([]byte, error) {
-	path := c.basePath + documentID + "/status"
+func (c *CodeServiceImpl) GenerateQRCode(document *entity.Document) ([]byte, error) {
+	token, err := c.GenerateToken(document)
+	if err != nil {
+		return nil, err
+	}
+
+	path := c.basePath + document.ID + "/status/?token=" + url.QueryEscape(token)
 
 	var qrCode []byte
-	qrCode, err := qrcode.Encode(path, qrcode.Medium, 256)
+	qrCode, err = qrcode.Encode(path, qrcode.Medium, 256)
 	if err != nil {
 		return nil, err
 	}
@@ -27,8 +110,8 @@ func (c *CodeServiceImpl) GenerateQRCode(documentID string) ([]byte, error) {
 	return qrCode, nil
 }
 
-func (c *CodeServiceImpl) GenerateBase64QRCode(documentID string) (string, error) {
-	qrCode, err := c.GenerateQRCode(documentID)
+func (c *CodeServiceImpl) GenerateBase64QRCode(document *entity.Document) (string, error) {
+	qrCode, err := c.GenerateQRCode(document)
 	if err != nil {
 		return "", err
 	}
@@ -38,3 +121,56 @@ func (c *CodeServiceImpl) GenerateBase64QRCode(documentID string) (string, error
 
 	return base64StringWithHeader, nil
 }
+
+// GenerateToken sign the document id, register, content hash, and signing date as compact JWS. Only signed document
+// get a token, so a draft print can't be passed as the issued letter
+func (c *CodeServiceImpl) GenerateToken(document *entity.Document) (string, error) {
+	if document.SignedAt.IsZero() {
+		return "", utils.ErrNotSignedYet
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, &tokenClaims{
+		RegisterID: document.RegisterID,
+		Hash:       qr.DocumentHash(document),
+		StandardClaims: jwt.StandardClaims{
+			Subject:  document.ID,
+			IssuedAt: document.SignedAt.Unix(),
+		},
+	})
+	token.Header["kid"] = c.keyID
+	delete(token.Header, "typ")
+
+	return token.SignedString(c.key)
+}
+
+func (c *CodeServiceImpl) ParseToken(token string) (*qr.Payload, error) {
+	claims := new(tokenClaims)
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodEd25519); !ok || t.Header["kid"] != c.keyID {
+			return nil, utils.ErrInvalidQRToken
+		}
+
+		return c.key.Public(), nil
+	})
+	if err != nil || claims.Subject == "" {
+		return nil, utils.ErrInvalidQRToken
+	}
+
+	return &qr.Payload{
+		DocumentID: claims.Subject,
+		RegisterID: claims.RegisterID,
+		Hash:       claims.Hash,
+		SignedAt:   claims.IssuedAt,
+	}, nil
+}
+
+func (c *CodeServiceImpl) PublicKey() *qr.PublicKey {
+	return &qr.PublicKey{
+		KeyType:   "OKP",
+		Curve:     "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(c.key.Public().(ed25519.PublicKey)),
+		KeyID:     c.keyID,
+		Algorithm: "EdDSA",
+		Use:       "sig",
+	}
+}
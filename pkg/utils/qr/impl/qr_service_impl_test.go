@@ -0,0 +1,145 @@
+package impl
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/qr"
+)
+
+func newTestDocument() *entity.Document {
+	return &entity.Document{
+		ID:         "1",
+		RegisterID: 123,
+		TemplateID: 1,
+		Fields: entity.DocumentFields{
+			{TemplateFieldID: 2, Value: "value2"},
+			{TemplateFieldID: 1, Rows: entity.TableRows{{"nama": "Budi"}}},
+		},
+		SignedAt: time.Date(2022, 10, 10, 9, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestLoadSigningKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore", "qr.pem")
+
+	_, err := LoadSigningKey(path)
+	assert.Equal(t, utils.ErrQRSigningKeyNotFound, err)
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	created, err := CreateSigningKey(path)
+	assert.NoError(t, err)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := LoadSigningKey(path)
+	assert.NoError(t, err)
+	assert.True(t, created.Equal(loaded))
+
+	_, err = CreateSigningKey(path)
+	assert.Equal(t, utils.ErrQRSigningKeyExists, err)
+	loaded, err = LoadSigningKey(path)
+	assert.NoError(t, err)
+	assert.True(t, created.Equal(loaded))
+
+	assert.NoError(t, os.WriteFile(path, []byte("not a key"), 0600))
+	_, err = LoadSigningKey(path)
+	assert.Equal(t, utils.ErrInvalidQRSigningKey, err)
+}
+
+func TestCodeServiceImpl_Token(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	codeService := NewCodeServiceImpl("http://localhost:8080/v1/documents/", key)
+	document := newTestDocument()
+
+	token, err := codeService.GenerateToken(document)
+	assert.NoError(t, err)
+
+	payload, err := codeService.ParseToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, &qr.Payload{DocumentID: "1", RegisterID: 123, Hash: qr.DocumentHash(document), SignedAt: document.SignedAt.Unix()}, payload)
+
+	// the token can be checked offline with only the published key
+	publicKey := codeService.PublicKey()
+	x, err := base64.RawURLEncoding.DecodeString(publicKey.X)
+	assert.NoError(t, err)
+	parts := strings.Split(token, ".")
+	assert.NoError(t, jwt.SigningMethodEdDSA.Verify(parts[0]+"."+parts[1], parts[2], ed25519.PublicKey(x)))
+	assert.Equal(t, "OKP", publicKey.KeyType)
+	assert.Equal(t, "Ed25519", publicKey.Curve)
+	assert.Equal(t, "EdDSA", publicKey.Algorithm)
+}
+
+func TestCodeServiceImpl_GenerateToken_Unsigned(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	codeService := NewCodeServiceImpl("http://localhost:8080/v1/documents/", key)
+	document := newTestDocument()
+	document.SignedAt = time.Time{}
+
+	_, err := codeService.GenerateToken(document)
+	assert.Equal(t, utils.ErrNotSignedYet, err)
+
+	_, err = codeService.GenerateBase64QRCode(document)
+	assert.Equal(t, utils.ErrNotSignedYet, err)
+}
+
+func TestCodeServiceImpl_ParseToken_Invalid(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	codeService := NewCodeServiceImpl("", key)
+	document := newTestDocument()
+
+	forged, err := NewCodeServiceImpl("", otherKey).GenerateToken(document)
+	assert.NoError(t, err)
+
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1"}).SignedString([]byte("secret"))
+	assert.NoError(t, err)
+
+	valid, err := codeService.GenerateToken(document)
+	assert.NoError(t, err)
+	parts := strings.Split(valid, ".")
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"reg":124,"hash":"x","sub":"1"}`)) + "." + parts[2]
+
+	for name, token := range map[string]string{
+		"signed by another key": forged,
+		"signed with hmac":      hmacToken,
+		"tampered payload":      tampered,
+		"malformed":             "token",
+	} {
+		_, err := codeService.ParseToken(token)
+		assert.Equal(t, utils.ErrInvalidQRToken, err, name)
+	}
+}
+
+func TestDocumentHash(t *testing.T) {
+	document := newTestDocument()
+	hash := qr.DocumentHash(document)
+
+	reordered := newTestDocument()
+	reordered.Fields[0], reordered.Fields[1] = reordered.Fields[1], reordered.Fields[0]
+	assert.Equal(t, hash, qr.DocumentHash(reordered))
+
+	changed := newTestDocument()
+	changed.Fields[0].Value = "changed"
+	assert.NotEqual(t, hash, qr.DocumentHash(changed))
+}
+
+func TestCodeServiceImpl_GenerateBase64QRCode(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	qrCode, err := NewCodeServiceImpl("http://localhost:8080/v1/documents/", key).GenerateBase64QRCode(newTestDocument())
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(qrCode, "data:image/png;base64,"))
+}
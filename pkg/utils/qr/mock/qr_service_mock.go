@@ -0,0 +1,36 @@
+package mock
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+	"github.com/suryaadi44/eAD-System/pkg/utils/qr"
+)
+
+type MockCodeService struct {
+	mock.Mock
+}
+
+func (m *MockCodeService) GenerateQRCode(document *entity.Document) ([]byte, error) {
+	args := m.Called(document)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockCodeService) GenerateBase64QRCode(document *entity.Document) (string, error) {
+	args := m.Called(document)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCodeService) GenerateToken(document *entity.Document) (string, error) {
+	args := m.Called(document)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCodeService) ParseToken(token string) (*qr.Payload, error) {
+	args := m.Called(token)
+	return args.Get(0).(*qr.Payload), args.Error(1)
+}
+
+func (m *MockCodeService) PublicKey() *qr.PublicKey {
+	args := m.Called()
+	return args.Get(0).(*qr.PublicKey)
+}
@@ -1,6 +1,66 @@
 package qr
 
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+
+	"github.com/suryaadi44/eAD-System/pkg/entity"
+)
+
+// Payload is the content of the signed token inside the QR code
+type Payload struct {
+	DocumentID string
+	RegisterID uint
+	Hash       string
+	SignedAt   int64
+}
+
+// PublicKey is the JSON Web Key of the office key that sign the QR token, so verifier application can check the
+// token offline
+type PublicKey struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
 type CodeService interface {
-	GenerateQRCode(documentID string) ([]byte, error)
-	GenerateBase64QRCode(documentID string) (string, error)
+	GenerateQRCode(document *entity.Document) ([]byte, error)
+	GenerateBase64QRCode(document *entity.Document) (string, error)
+	GenerateToken(document *entity.Document) (string, error)
+	ParseToken(token string) (*Payload, error)
+	PublicKey() *PublicKey
+}
+
+type hashedField struct {
+	ID    uint             `json:"id"`
+	Value string           `json:"value"`
+	Rows  entity.TableRows `json:"rows"`
+}
+
+// DocumentHash digest the content of the document that is printed on the letter. The hash of the signed PDF can't
+// be used since the QR is printed inside it
+func DocumentHash(document *entity.Document) string {
+	fields := make([]hashedField, 0, len(document.Fields))
+	for _, field := range document.Fields {
+		fields = append(fields, hashedField{ID: field.TemplateFieldID, Value: field.Value, Rows: field.Rows})
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].ID < fields[j].ID
+	})
+
+	content, _ := json.Marshal(struct {
+		ID              string        `json:"id"`
+		RegisterID      uint          `json:"register"`
+		TemplateID      uint          `json:"template"`
+		TemplateVersion uint          `json:"version"`
+		Fields          []hashedField `json:"fields"`
+	}{document.ID, document.RegisterID, document.TemplateID, document.Template.Version, fields})
+
+	sum := sha256.Sum256(content)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
 }
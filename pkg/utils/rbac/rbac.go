@@ -0,0 +1,66 @@
+package rbac
+
+import (
+	"net/http"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/jwt_service"
+)
+
+// Permission checked by the route, the permission given to each role is stored in the database. The update and delete
+// permission is for any document, the applicant can always change their own document before it is verified
+const (
+	PermissionDocumentCreate  = "document.create"
+	PermissionDocumentReadAll = "document.read_all"
+	PermissionDocumentUpdate  = "document.update"
+	PermissionDocumentDelete  = "document.delete"
+	PermissionDocumentVerify  = "document.verify"
+	PermissionDocumentSign    = "document.sign"
+	PermissionDocumentRevoke  = "document.revoke"
+	PermissionDocumentExport  = "document.export"
+	PermissionTemplateManage  = "template.manage"
+	PermissionUserRead        = "user.read"
+	PermissionUserManage      = "user.manage"
+)
+
+// ClaimPermissions is the token claim holding the permission of the user role, resolved when the token is issued
+const ClaimPermissions = "permissions"
+
+// HasPermission check the permission carried by the token claims
+func HasPermission(claims jwt.MapClaims, permission string) bool {
+	switch permissions := claims[ClaimPermissions].(type) {
+	case []interface{}:
+		for _, p := range permissions {
+			if p == permission {
+				return true
+			}
+		}
+	case []string:
+		for _, p := range permissions {
+			if p == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// RequirePermission reject the request when the token didn't carry every given permission, it must be used after the
+// JWT middleware
+func RequirePermission(jwtService jwt_service.JWTService, permissions ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims := jwtService.GetClaims(&c)
+			for _, permission := range permissions {
+				if !HasPermission(claims, permission) {
+					return echo.NewHTTPError(http.StatusForbidden, utils.ErrDidntHavePermission.Error())
+				}
+			}
+
+			return next(c)
+		}
+	}
+}
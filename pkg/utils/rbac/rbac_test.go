@@ -0,0 +1,81 @@
+package rbac
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	mockJwtServicePkg "github.com/suryaadi44/eAD-System/pkg/utils/jwt_service/mock"
+)
+
+func TestHasPermission(t *testing.T) {
+	for _, tc := range []struct {
+		Name     string
+		Claims   jwt.MapClaims
+		Expected bool
+	}{
+		{
+			Name:     "Parsed token",
+			Claims:   jwt.MapClaims{ClaimPermissions: []interface{}{PermissionDocumentCreate, PermissionDocumentVerify}},
+			Expected: true,
+		},
+		{
+			Name:     "Issued claims",
+			Claims:   jwt.MapClaims{ClaimPermissions: []string{PermissionDocumentVerify}},
+			Expected: true,
+		},
+		{
+			Name:     "Missing permission",
+			Claims:   jwt.MapClaims{ClaimPermissions: []interface{}{PermissionDocumentCreate}},
+			Expected: false,
+		},
+		{
+			Name:     "Token without permission",
+			Claims:   jwt.MapClaims{"role": float64(3)},
+			Expected: false,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, HasPermission(tc.Claims, PermissionDocumentVerify))
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	for _, tc := range []struct {
+		Name          string
+		Permissions   []interface{}
+		ExpectedError error
+	}{
+		{
+			Name:        "Every permission granted",
+			Permissions: []interface{}{PermissionDocumentSign, PermissionDocumentRevoke},
+		},
+		{
+			Name:          "One permission missing",
+			Permissions:   []interface{}{PermissionDocumentSign},
+			ExpectedError: echo.NewHTTPError(http.StatusForbidden, utils.ErrDidntHavePermission.Error()),
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			jwtService := new(mockJwtServicePkg.MockJWTService)
+			jwtService.On("GetClaims", mock.Anything).Return(jwt.MapClaims{ClaimPermissions: tc.Permissions})
+
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodPatch, "/", nil), httptest.NewRecorder())
+			called := false
+			handler := RequirePermission(jwtService, PermissionDocumentSign, PermissionDocumentRevoke)(func(c echo.Context) error {
+				called = true
+				return nil
+			})
+
+			err := handler(c)
+			assert.Equal(t, tc.ExpectedError, err)
+			assert.Equal(t, tc.ExpectedError == nil, called)
+		})
+	}
+}
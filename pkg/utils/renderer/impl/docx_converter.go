@@ -0,0 +1,686 @@
+package impl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"  // register gif decoder for the embedded image
+	_ "image/jpeg" // register jpeg decoder for the embedded image
+	_ "image/png"  // register png decoder for the embedded image
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Unit conversion of the WordprocessingML, length is in twentieth of a point and image extent is in EMU
+const (
+	ptToTwip = 20
+	ptToEMU  = 12700
+	pxToPt   = 0.75
+	mmToPt   = 72 / 25.4
+)
+
+// Font size of the heading in point, the body text is 12 point like the native PDF backend
+var headingSizes = map[string]float64{"h1": 24, "h2": 18, "h3": 14, "h4": 12, "h5": 10, "h6": 8}
+
+// Element that is laid out as its own paragraph
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true, "center": true, "div": true,
+	"footer": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"html": true, "li": true, "main": true, "nav": true, "p": true, "pre": true, "section": true,
+}
+
+// Element whose content is never printed
+var skippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "title": true, "template": true, "noscript": true,
+}
+
+// runStyle is the character formatting inherited by the text of the element
+type runStyle struct {
+	bold      bool
+	italic    bool
+	underline bool
+	strike    bool
+	vertAlign string
+	color     string
+	size      float64
+	pre       bool
+}
+
+func (r runStyle) properties() string {
+	var sb strings.Builder
+	if r.bold {
+		sb.WriteString("<w:b/>")
+	}
+	if r.italic {
+		sb.WriteString("<w:i/>")
+	}
+	if r.strike {
+		sb.WriteString("<w:strike/>")
+	}
+	if r.color != "" {
+		fmt.Fprintf(&sb, `<w:color w:val="%s"/>`, r.color)
+	}
+	if r.size > 0 {
+		fmt.Fprintf(&sb, `<w:sz w:val="%d"/>`, int(math.Round(r.size*2)))
+	}
+	if r.underline {
+		sb.WriteString(`<w:u w:val="single"/>`)
+	}
+	if r.vertAlign != "" {
+		fmt.Fprintf(&sb, `<w:vertAlign w:val="%s"/>`, r.vertAlign)
+	}
+
+	if sb.Len() == 0 {
+		return ""
+	}
+
+	return "<w:rPr>" + sb.String() + "</w:rPr>"
+}
+
+// paragraphStyle is the paragraph formatting of the block element
+type paragraphStyle struct {
+	align  string
+	indent float64
+}
+
+func (p paragraphStyle) properties() string {
+	var sb strings.Builder
+	if p.indent > 0 {
+		fmt.Fprintf(&sb, `<w:ind w:left="%d"/>`, int(math.Round(p.indent*ptToTwip)))
+	}
+	if p.align != "" {
+		fmt.Fprintf(&sb, `<w:jc w:val="%s"/>`, p.align)
+	}
+
+	if sb.Len() == 0 {
+		return ""
+	}
+
+	return "<w:pPr>" + sb.String() + "</w:pPr>"
+}
+
+// docxImage is the embedded image of a part, written into the media folder of the package
+type docxImage struct {
+	id        string
+	name      string
+	content   []byte
+	extension string
+}
+
+// docxPart convert the html into the body of one part, the document, header, or footer, each part has its own image
+// relationship
+type docxPart struct {
+	width  float64
+	images []docxImage
+	media  *int
+}
+
+// docxBlock collect the paragraph of a container, the part body or a table cell
+type docxBlock struct {
+	part  *docxPart
+	out   strings.Builder
+	runs  strings.Builder
+	para  paragraphStyle
+	space bool
+}
+
+func (p *docxPart) convert(doc *html.Node) string {
+	block := &docxBlock{part: p}
+	block.walk(doc, paragraphStyle{}, runStyle{})
+	block.flush()
+
+	return block.out.String()
+}
+
+// flush end the current paragraph, empty paragraph is dropped since block element didn't print blank line in html
+func (b *docxBlock) flush() {
+	if b.runs.Len() == 0 {
+		return
+	}
+
+	fmt.Fprintf(&b.out, "<w:p>%s%s</w:p>", b.para.properties(), b.runs.String())
+	b.runs.Reset()
+	b.space = false
+}
+
+func (b *docxBlock) addRun(content string, ps paragraphStyle) {
+	if b.runs.Len() == 0 {
+		b.para = ps
+	}
+	b.runs.WriteString(content)
+}
+
+func (b *docxBlock) addText(text string, ps paragraphStyle, rs runStyle) {
+	if !rs.pre {
+		text = collapseSpace(text)
+		if b.runs.Len() == 0 || b.space {
+			text = strings.TrimLeft(text, " ")
+		}
+		if text == "" {
+			return
+		}
+		b.space = strings.HasSuffix(text, " ")
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if i > 0 {
+			b.addRun(fmt.Sprintf("<w:r>%s<w:br/></w:r>", rs.properties()), ps)
+		}
+		if line != "" {
+			b.addRun(fmt.Sprintf(`<w:r>%s<w:t xml:space="preserve">%s</w:t></w:r>`, rs.properties(), html.EscapeString(line)), ps)
+		}
+	}
+}
+
+func (b *docxBlock) walk(n *html.Node, ps paragraphStyle, rs runStyle) {
+	switch n.Type {
+	case html.TextNode:
+		b.addText(n.Data, ps, rs)
+		return
+	case html.DocumentNode:
+		b.children(n, ps, rs)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	tag := n.Data
+	if skippedElements[tag] || strings.Contains(strings.ReplaceAll(attr(n, "style"), " ", ""), "display:none") {
+		return
+	}
+
+	rs = elementRunStyle(n, rs)
+	switch tag {
+	case "br":
+		b.addRun(fmt.Sprintf("<w:r>%s<w:br/></w:r>", rs.properties()), ps)
+		b.space = false
+	case "hr":
+		b.flush()
+		b.out.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="auto"/></w:pBdr></w:pPr></w:p>`)
+	case "img":
+		if drawing := b.part.image(n); drawing != "" {
+			b.addRun(drawing, ps)
+			b.space = false
+		}
+	case "table":
+		b.flush()
+		b.out.WriteString(b.part.table(n, rs))
+	case "ul", "ol":
+		b.flush()
+		b.list(n, ps, rs)
+	case "span":
+		if field := pageField(n); field != "" {
+			b.addRun(fmt.Sprintf(`<w:fldSimple w:instr="%s"><w:r>%s<w:t>1</w:t></w:r></w:fldSimple>`, field, rs.properties()), ps)
+			return
+		}
+		b.children(n, ps, rs)
+	default:
+		if !blockElements[tag] {
+			b.children(n, ps, rs)
+			return
+		}
+
+		b.flush()
+		ps = elementParagraphStyle(n, ps)
+		b.children(n, ps, rs)
+		b.flush()
+	}
+}
+
+func (b *docxBlock) children(n *html.Node, ps paragraphStyle, rs runStyle) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.walk(c, ps, rs)
+	}
+}
+
+// list print every item as its own indented paragraph that start with the bullet or number of the item
+func (b *docxBlock) list(n *html.Node, ps paragraphStyle, rs runStyle) {
+	ps.indent += 18
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != "li" {
+			b.walk(c, ps, rs)
+			continue
+		}
+
+		marker := "• "
+		if n.Data == "ol" {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+
+		itemStyle := elementParagraphStyle(c, ps)
+		b.flush()
+		b.addRun(fmt.Sprintf(`<w:r>%s<w:t xml:space="preserve">%s</w:t></w:r>`, rs.properties(), marker), itemStyle)
+		b.space = true
+		b.children(c, itemStyle, elementRunStyle(c, rs))
+		b.flush()
+	}
+}
+
+// table write the table with its border, the column share the part width equally and colspan join them
+func (p *docxPart) table(n *html.Node, rs runStyle) string {
+	var rows [][]*html.Node
+	var collect func(*html.Node)
+	collect = func(node *html.Node) {
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+
+			switch c.Data {
+			case "thead", "tbody", "tfoot":
+				collect(c)
+			case "tr":
+				var cells []*html.Node
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+						cells = append(cells, cell)
+					}
+				}
+				rows = append(rows, cells)
+			}
+		}
+	}
+	collect(n)
+
+	columns := 0
+	for _, row := range rows {
+		span := 0
+		for _, colspan := range rowSpans(row) {
+			span += colspan
+		}
+		if span > columns {
+			columns = span
+		}
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	columnWidth := int(p.width * ptToTwip / float64(columns))
+	border := "nil"
+	if attr(n, "border") != "" && attr(n, "border") != "0" || strings.Contains(attr(n, "style"), "border") {
+		border = "single"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<w:tbl><w:tblPr><w:tblW w:w="0" w:type="auto"/><w:tblBorders>`)
+	for _, side := range []string{"top", "left", "bottom", "right", "insideH", "insideV"} {
+		fmt.Fprintf(&sb, `<w:%s w:val="%s" w:sz="4" w:space="0" w:color="auto"/>`, side, border)
+	}
+	sb.WriteString(`</w:tblBorders><w:tblLayout w:type="fixed"/></w:tblPr><w:tblGrid>`)
+	for i := 0; i < columns; i++ {
+		fmt.Fprintf(&sb, `<w:gridCol w:w="%d"/>`, columnWidth)
+	}
+	sb.WriteString("</w:tblGrid>")
+
+	for _, row := range rows {
+		sb.WriteString("<w:tr>")
+		for i, span := range rowSpans(row) {
+			cell := row[i]
+			cellRun := rs
+			if cell.Data == "th" {
+				cellRun.bold = true
+			}
+
+			cellPart := &docxPart{width: float64(columnWidth*span) / ptToTwip, media: p.media}
+			block := &docxBlock{part: cellPart}
+			block.walk(cell, elementParagraphStyle(cell, paragraphStyle{}), cellRun)
+			block.flush()
+			p.images = append(p.images, cellPart.images...)
+
+			fmt.Fprintf(&sb, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/>`, columnWidth*span)
+			if span > 1 {
+				fmt.Fprintf(&sb, `<w:gridSpan w:val="%d"/>`, span)
+			}
+			// every cell must end with a paragraph even when it is empty
+			fmt.Fprintf(&sb, "</w:tcPr>%s<w:p/></w:tc>", block.out.String())
+		}
+		sb.WriteString("</w:tr>")
+	}
+	sb.WriteString("</w:tbl>")
+
+	return sb.String()
+}
+
+// maxColspan is the largest colspan allowed by the HTML spec, it also bound the number of grid column so a crafted
+// colspan can not make the converter write millions of grid column
+const maxColspan = 1000
+
+// rowSpans return the colspan of each cell in the row, the span is clamped so the row never exceed maxColspan column
+// and the cell past the limit is dropped
+func rowSpans(row []*html.Node) []int {
+	spans := make([]int, 0, len(row))
+	column := 0
+	for _, cell := range row {
+		span, err := strconv.Atoi(attr(cell, "colspan"))
+		if err != nil || span < 1 {
+			span = 1
+		}
+		if span > maxColspan-column {
+			span = maxColspan - column
+		}
+		if span < 1 {
+			break
+		}
+
+		spans = append(spans, span)
+		column += span
+	}
+
+	return spans
+}
+
+// image embed the data uri image as inline picture, other image is dropped since the template asset is already
+// inlined and the renderer never reach the network
+func (p *docxPart) image(n *html.Node) string {
+	content, ok := decodeDataURI(attr(n, "src"))
+	if !ok {
+		return ""
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil || config.Width == 0 || config.Height == 0 {
+		return ""
+	}
+
+	width, height := imageSize(n, float64(config.Width)*pxToPt, float64(config.Height)*pxToPt, p.width)
+
+	*p.media++
+	id := *p.media
+	extension := format
+	if extension == "jpeg" {
+		extension = "jpg"
+	}
+	p.images = append(p.images, docxImage{
+		id:        fmt.Sprint("rIdImage", id),
+		name:      fmt.Sprint("image", id, ".", extension),
+		content:   content,
+		extension: extension,
+	})
+
+	cx, cy := int(math.Round(width*ptToEMU)), int(math.Round(height*ptToEMU))
+	return fmt.Sprintf(`<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%d" cy="%d"/>`+
+		`<wp:docPr id="%d" name="Picture %d"/><a:graphic xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">`+
+		`<a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:pic xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:nvPicPr><pic:cNvPr id="%d" name="image%d.%s"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="rIdImage%d"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`+
+		`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		cx, cy, id, id, id, id, extension, id, cx, cy)
+}
+
+// imageSize keep the image aspect ratio when only one dimension is set, the image never exceed the available width
+func imageSize(n *html.Node, naturalWidth float64, naturalHeight float64, available float64) (float64, float64) {
+	declarations := styleDeclarations(n)
+	width, hasWidth := parseLength(firstValue(declarations["width"], attr(n, "width")), available)
+	height, hasHeight := parseLength(firstValue(declarations["height"], attr(n, "height")), 0)
+
+	switch {
+	case hasWidth && !hasHeight:
+		height = width * naturalHeight / naturalWidth
+	case hasHeight && !hasWidth:
+		width = height * naturalWidth / naturalHeight
+	case !hasWidth && !hasHeight:
+		width, height = naturalWidth, naturalHeight
+	}
+
+	if available > 0 && width > available {
+		height, width = height*available/width, available
+	}
+
+	return width, height
+}
+
+func elementRunStyle(n *html.Node, rs runStyle) runStyle {
+	switch n.Data {
+	case "b", "strong", "th":
+		rs.bold = true
+	case "i", "em", "cite", "var":
+		rs.italic = true
+	case "u", "ins":
+		rs.underline = true
+	case "s", "strike", "del":
+		rs.strike = true
+	case "sup":
+		rs.vertAlign = "superscript"
+	case "sub":
+		rs.vertAlign = "subscript"
+	case "pre", "code":
+		rs.pre = n.Data == "pre"
+	}
+
+	if size, ok := headingSizes[n.Data]; ok {
+		rs.bold = true
+		rs.size = size
+	}
+
+	declarations := styleDeclarations(n)
+	switch declarations["font-weight"] {
+	case "bold", "bolder", "600", "700", "800", "900":
+		rs.bold = true
+	case "normal", "lighter", "100", "200", "300", "400":
+		rs.bold = false
+	}
+
+	switch declarations["font-style"] {
+	case "italic", "oblique":
+		rs.italic = true
+	case "normal":
+		rs.italic = false
+	}
+
+	if decoration := declarations["text-decoration"]; decoration != "" {
+		rs.underline = strings.Contains(decoration, "underline")
+		rs.strike = strings.Contains(decoration, "line-through")
+	}
+
+	if value, ok := declarations["font-size"]; ok {
+		if size, ok := parseLength(value, rs.size); ok && size > 0 {
+			rs.size = size
+		}
+	}
+
+	if value, ok := parseColor(declarations["color"]); ok {
+		rs.color = value
+	}
+
+	return rs
+}
+
+func elementParagraphStyle(n *html.Node, ps paragraphStyle) paragraphStyle {
+	align := firstValue(styleDeclarations(n)["text-align"], attr(n, "align"))
+	if n.Data == "center" {
+		align = "center"
+	}
+
+	switch strings.ToLower(align) {
+	case "left":
+		ps.align = "left"
+	case "center":
+		ps.align = "center"
+	case "right":
+		ps.align = "right"
+	case "justify":
+		ps.align = "both"
+	}
+
+	if n.Data == "blockquote" {
+		ps.indent += 36
+	}
+
+	return ps
+}
+
+// pageField return the field code of the page number placeholder used by the running header and footer
+func pageField(n *html.Node) string {
+	for _, class := range strings.Fields(attr(n, "class")) {
+		switch class {
+		case "page":
+			return "PAGE"
+		case "topage":
+			return "NUMPAGES"
+		}
+	}
+
+	return ""
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func firstValue(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// styleDeclarations parse the inline style of the element, the later declaration win like in the browser
+func styleDeclarations(n *html.Node) map[string]string {
+	declarations := map[string]string{}
+	for _, declaration := range strings.Split(attr(n, "style"), ";") {
+		property, value, ok := strings.Cut(declaration, ":")
+		if !ok {
+			continue
+		}
+
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		declarations[strings.ToLower(strings.TrimSpace(property))] = strings.ToLower(value)
+	}
+
+	return declarations
+}
+
+// parseLength return the css length in point, percentage is relative to the given length and plain number is pixel
+func parseLength(value string, relative float64) (float64, bool) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	units := []struct {
+		suffix string
+		factor float64
+	}{
+		{"px", pxToPt}, {"pt", 1}, {"mm", mmToPt}, {"cm", 10 * mmToPt}, {"in", 72}, {"%", relative / 100}, {"", pxToPt},
+	}
+
+	for _, unit := range units {
+		if !strings.HasSuffix(value, unit.suffix) {
+			continue
+		}
+
+		number, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, unit.suffix)), 64)
+		if err != nil || number < 0 {
+			return 0, false
+		}
+
+		return number * unit.factor, true
+	}
+
+	return 0, false
+}
+
+// Named color used by the letter template, other color is written as hex
+var namedColors = map[string]string{
+	"black": "000000", "white": "FFFFFF", "red": "FF0000", "green": "008000", "blue": "0000FF",
+	"gray": "808080", "grey": "808080", "navy": "000080", "maroon": "800000",
+}
+
+// parseColor return the hex color of the css color value for the WordprocessingML color attribute
+func parseColor(value string) (string, bool) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if hex, ok := namedColors[value]; ok {
+		return hex, true
+	}
+
+	if strings.HasPrefix(value, "#") {
+		hex := value[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if _, err := strconv.ParseUint(hex, 16, 32); err == nil && len(hex) == 6 {
+			return strings.ToUpper(hex), true
+		}
+	}
+
+	if strings.HasPrefix(value, "rgb(") && strings.HasSuffix(value, ")") {
+		parts := strings.Split(value[len("rgb("):len(value)-1], ",")
+		if len(parts) != 3 {
+			return "", false
+		}
+
+		var hex strings.Builder
+		for _, part := range parts {
+			channel, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || channel < 0 || channel > 255 {
+				return "", false
+			}
+			fmt.Fprintf(&hex, "%02X", channel)
+		}
+
+		return hex.String(), true
+	}
+
+	return "", false
+}
+
+// collapseSpace replace every run of white space with a single space like html does
+func collapseSpace(text string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range text {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			if !space {
+				sb.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+
+		sb.WriteRune(r)
+		space = false
+	}
+
+	return sb.String()
+}
+
+func decodeDataURI(src string) ([]byte, bool) {
+	src = strings.TrimSpace(src)
+	if !strings.HasPrefix(src, "data:") {
+		return nil, false
+	}
+
+	header, data, ok := strings.Cut(src[len("data:"):], ",")
+	if !ok {
+		return nil, false
+	}
+
+	if strings.HasSuffix(header, ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(data), ""))
+		return decoded, err == nil
+	}
+
+	decoded, err := url.PathUnescape(data)
+	return []byte(decoded), err == nil
+}
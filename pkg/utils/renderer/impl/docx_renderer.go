@@ -0,0 +1,242 @@
+package impl
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+	"github.com/suryaadi44/eAD-System/pkg/utils/renderer"
+	"golang.org/x/net/html"
+)
+
+// Namespace of the WordprocessingML part, the header and footer use the same one since they can hold image too
+const docxNamespaces = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+	`xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"`
+
+// Relationship type of the package part
+const (
+	relationshipOfficeDocument = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument"
+	relationshipStyles         = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles"
+	relationshipHeader         = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/header"
+	relationshipFooter         = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer"
+	relationshipImage          = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+)
+
+// Distance of the running header and footer from the page edge in millimeter
+const docxHeaderDistance = 5
+
+// docxModified is the modification time of every file in the package, fixed so the same document always produce the
+// same file
+var docxModified = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// docxFile is one file of the package
+type docxFile struct {
+	name    string
+	content string
+}
+
+// DOCXRenderer convert the filled html into an editable Word document. Only the formatting used by the letter template
+// is kept, text, table, list, and embedded image, the layout is left to the word processor
+type DOCXRenderer struct{}
+
+func NewDOCXRenderer() renderer.Renderer {
+	return &DOCXRenderer{}
+}
+
+func (d *DOCXRenderer) Render(ctx context.Context, document *renderer.Document) ([]byte, error) {
+	options := document.Options
+	width, height, err := pdf.PageDimension(options)
+	if err != nil {
+		return nil, err
+	}
+
+	media := 0
+	contentWidth := (width - float64(options.MarginLeft+options.MarginRight)) * mmToPt
+
+	body, err := html.Parse(bytes.NewReader(document.HTML.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	bodyPart := &docxPart{width: contentWidth, media: &media}
+	bodyXML := bodyPart.convert(body)
+
+	var headerPart, footerPart *docxPart
+	var headerXML, footerXML string
+	if options.HeaderHTML != nil || options.Watermark != nil {
+		headerPart = &docxPart{width: contentWidth, media: &media}
+		if options.HeaderHTML != nil {
+			header, err := html.Parse(bytes.NewReader(options.HeaderHTML.Bytes()))
+			if err != nil {
+				return nil, err
+			}
+			headerXML = headerPart.convert(header)
+		}
+		headerXML += watermarkParagraph(options.Watermark)
+	}
+
+	if options.FooterHTML != nil {
+		footer, err := html.Parse(bytes.NewReader(options.FooterHTML.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+		footerPart = &docxPart{width: contentWidth, media: &media}
+		footerXML = footerPart.convert(footer)
+	}
+
+	files := []docxFile{
+		{"[Content_Types].xml", contentTypes(headerPart != nil, footerPart != nil)},
+		{"_rels/.rels", relationships([][3]string{{"rId1", relationshipOfficeDocument, "word/document.xml"}})},
+	}
+
+	documentRels := [][3]string{{"rIdStyles", relationshipStyles, "styles.xml"}}
+	sectionRefs := ""
+	if headerPart != nil {
+		documentRels = append(documentRels, [3]string{"rIdHeader", relationshipHeader, "header1.xml"})
+		sectionRefs += `<w:headerReference w:type="default" r:id="rIdHeader"/>`
+		files = append(files,
+			docxFile{"word/header1.xml", fmt.Sprintf(`<w:hdr %s>%s<w:p/></w:hdr>`, docxNamespaces, headerXML)},
+			docxFile{"word/_rels/header1.xml.rels", relationships(imageRelationships(headerPart.images))},
+		)
+	}
+	if footerPart != nil {
+		documentRels = append(documentRels, [3]string{"rIdFooter", relationshipFooter, "footer1.xml"})
+		sectionRefs += `<w:footerReference w:type="default" r:id="rIdFooter"/>`
+		files = append(files,
+			docxFile{"word/footer1.xml", fmt.Sprintf(`<w:ftr %s>%s<w:p/></w:ftr>`, docxNamespaces, footerXML)},
+			docxFile{"word/_rels/footer1.xml.rels", relationships(imageRelationships(footerPart.images))},
+		)
+	}
+	documentRels = append(documentRels, imageRelationships(bodyPart.images)...)
+
+	files = append(files,
+		docxFile{"word/document.xml", fmt.Sprintf(`<w:document %s><w:body>%s%s</w:body></w:document>`,
+			docxNamespaces, bodyXML, sectionProperties(options, width, height, sectionRefs))},
+		docxFile{"word/_rels/document.xml.rels", relationships(documentRels)},
+		docxFile{"word/styles.xml", docxStyles},
+	)
+
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+	write := func(name string, content []byte) error {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: docxModified})
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(content)
+		return err
+	}
+
+	for _, file := range files {
+		if err := write(file.name, []byte(xmlHeader+file.content)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, part := range []*docxPart{bodyPart, headerPart, footerPart} {
+		if part == nil {
+			continue
+		}
+
+		for _, image := range part.images {
+			if err := write("word/media/"+image.name, image.content); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (d *DOCXRenderer) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+// docxStyles set the default font to match the body text of the native PDF backend
+const docxStyles = `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:docDefaults>` +
+	`<w:rPrDefault><w:rPr><w:rFonts w:ascii="Times New Roman" w:hAnsi="Times New Roman" w:cs="Times New Roman"/>` +
+	`<w:sz w:val="24"/><w:szCs w:val="24"/></w:rPr></w:rPrDefault>` +
+	`<w:pPrDefault><w:pPr><w:spacing w:after="0" w:line="240" w:lineRule="auto"/></w:pPr></w:pPrDefault>` +
+	`</w:docDefaults></w:styles>`
+
+func contentTypes(header bool, footer bool) string {
+	var sb strings.Builder
+	sb.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Default Extension="png" ContentType="image/png"/>` +
+		`<Default Extension="jpg" ContentType="image/jpeg"/>` +
+		`<Default Extension="gif" ContentType="image/gif"/>` +
+		`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+		`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>`)
+	if header {
+		sb.WriteString(`<Override PartName="/word/header1.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.header+xml"/>`)
+	}
+	if footer {
+		sb.WriteString(`<Override PartName="/word/footer1.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.footer+xml"/>`)
+	}
+	sb.WriteString("</Types>")
+
+	return sb.String()
+}
+
+// relationships write the relationship part, each relationship is its id, type, and target
+func relationships(rels [][3]string) string {
+	var sb strings.Builder
+	sb.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for _, rel := range rels {
+		fmt.Fprintf(&sb, `<Relationship Id="%s" Type="%s" Target="%s"/>`, rel[0], rel[1], rel[2])
+	}
+	sb.WriteString("</Relationships>")
+
+	return sb.String()
+}
+
+func imageRelationships(images []docxImage) [][3]string {
+	rels := make([][3]string, 0, len(images))
+	for _, image := range images {
+		rels = append(rels, [3]string{image.id, relationshipImage, "media/" + image.name})
+	}
+
+	return rels
+}
+
+// watermarkParagraph print the watermark text in the running header, so it is repeated on every page like the PDF
+func watermarkParagraph(watermark *pdf.Watermark) string {
+	if watermark == nil || watermark.Text == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(`<w:p><w:pPr><w:jc w:val="center"/></w:pPr><w:r><w:rPr><w:b/><w:color w:val="C0C0C0"/><w:sz w:val="48"/></w:rPr>`+
+		`<w:t xml:space="preserve">%s</w:t></w:r></w:p>`, html.EscapeString(watermark.Text))
+}
+
+// sectionProperties write the page size and margin of the document in twip
+func sectionProperties(options *pdf.PDFOptions, width float64, height float64, refs string) string {
+	twip := func(mm float64) int {
+		return int(math.Round(mm * mmToPt * ptToTwip))
+	}
+
+	orientation := ""
+	if width > height {
+		orientation = ` w:orient="landscape"`
+	}
+
+	return fmt.Sprintf(`<w:sectPr>%s<w:pgSz w:w="%d" w:h="%d"%s/>`+
+		`<w:pgMar w:top="%d" w:right="%d" w:bottom="%d" w:left="%d" w:header="%d" w:footer="%d" w:gutter="0"/></w:sectPr>`,
+		refs, twip(width), twip(height), orientation,
+		twip(float64(options.MarginTop)), twip(float64(options.MarginRight)), twip(float64(options.MarginBottom)),
+		twip(float64(options.MarginLeft)), twip(docxHeaderDistance), twip(docxHeaderDistance))
+}
@@ -0,0 +1,162 @@
+package impl
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+	"github.com/suryaadi44/eAD-System/pkg/utils/renderer"
+)
+
+func TestDOCXRenderer_Render(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.NRGBA{R: 200, A: 255})
+	var imageBuf bytes.Buffer
+	assert.NoError(t, png.Encode(&imageBuf, img))
+	imageSrc := "data:image/png;base64," + base64.StdEncoding.EncodeToString(imageBuf.Bytes())
+
+	body := `<html><head><style>p { color: red; }</style></head><body>` +
+		`<h1 style="text-align: center">Surat   Keterangan</h1>` +
+		`<p>Nama: <b>Budi</b> &amp; <i style="color: #f00">keluarga</i><br>RT 01</p>` +
+		`<ol><li>Satu</li><li>Dua</li></ol>` +
+		`<table border="1"><tr><th colspan="2">Data</th></tr><tr><td>NIK</td><td>123</td></tr></table>` +
+		`<img src="` + imageSrc + `" style="width: 40mm"></body></html>`
+	document := &renderer.Document{
+		HTML: bytes.NewBufferString(body),
+		Options: &pdf.PDFOptions{
+			PageSize:    pdf.PageSizeA4,
+			Orientation: pdf.OrientationLandscape,
+			MarginTop:   10, MarginBottom: 10, MarginLeft: 20, MarginRight: 20,
+			HeaderHTML: bytes.NewBufferString(`<html><body><p>Kop Surat</p></body></html>`),
+			FooterHTML: bytes.NewBufferString(`<html><body>Halaman <span class="page"></span> dari <span class="topage"></span></body></html>`),
+			Watermark:  &pdf.Watermark{Text: "DRAFT"},
+		},
+	}
+
+	docxRenderer := NewDOCXRenderer()
+	result, err := docxRenderer.Render(context.Background(), document)
+	assert.NoError(t, err)
+
+	second, err := docxRenderer.Render(context.Background(), document)
+	assert.NoError(t, err)
+	assert.Equal(t, result, second, "package must be deterministic")
+
+	archive, err := zip.NewReader(bytes.NewReader(result), int64(len(result)))
+	assert.NoError(t, err)
+
+	files := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		assert.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		files[file.Name] = string(content)
+
+		if file.Name != "word/media/image1.png" {
+			assert.NoError(t, checkXML(content), file.Name)
+		}
+	}
+
+	assert.Equal(t, imageBuf.String(), files["word/media/image1.png"])
+	assert.Contains(t, files["[Content_Types].xml"], `PartName="/word/header1.xml"`)
+	assert.Contains(t, files["[Content_Types].xml"], `PartName="/word/footer1.xml"`)
+	assert.Contains(t, files["_rels/.rels"], `Target="word/document.xml"`)
+	assert.Contains(t, files["word/_rels/document.xml.rels"], `Id="rIdImage1"`)
+	assert.Contains(t, files["word/_rels/document.xml.rels"], `Target="media/image1.png"`)
+
+	documentXML := files["word/document.xml"]
+	assert.Contains(t, documentXML, `<w:jc w:val="center"/></w:pPr><w:r><w:rPr><w:b/><w:sz w:val="48"/></w:rPr><w:t xml:space="preserve">Surat Keterangan</w:t>`)
+	assert.Contains(t, documentXML, `<w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">Budi</w:t></w:r>`)
+	assert.Contains(t, documentXML, `<w:t xml:space="preserve"> &amp; </w:t>`)
+	assert.Contains(t, documentXML, `<w:r><w:rPr><w:i/><w:color w:val="FF0000"/></w:rPr><w:t xml:space="preserve">keluarga</w:t></w:r>`)
+	assert.Contains(t, documentXML, `<w:br/>`)
+	assert.Contains(t, documentXML, `<w:t xml:space="preserve">1. </w:t>`)
+	assert.Contains(t, documentXML, `<w:t xml:space="preserve">2. </w:t>`)
+	assert.Contains(t, documentXML, `<w:gridSpan w:val="2"/>`)
+	assert.Contains(t, documentXML, `<w:t xml:space="preserve">NIK</w:t>`)
+	assert.NotContains(t, documentXML, "color: red")
+	// 40mm wide image keep its 2:1 aspect ratio
+	assert.Contains(t, documentXML, `<wp:extent cx="1440000" cy="720000"/>`)
+	assert.Contains(t, documentXML, `<w:pgSz w:w="16838" w:h="11906" w:orient="landscape"/>`)
+	assert.Contains(t, documentXML, `<w:pgMar w:top="567" w:right="1134" w:bottom="567" w:left="1134"`)
+
+	assert.Contains(t, files["word/header1.xml"], "Kop Surat")
+	assert.Contains(t, files["word/header1.xml"], "DRAFT")
+	assert.Contains(t, files["word/footer1.xml"], `<w:fldSimple w:instr="PAGE">`)
+	assert.Contains(t, files["word/footer1.xml"], `<w:fldSimple w:instr="NUMPAGES">`)
+}
+
+func TestDOCXRenderer_Render_InvalidPageSetup(t *testing.T) {
+	document := &renderer.Document{
+		HTML:    bytes.NewBufferString(`<p>Surat</p>`),
+		Options: &pdf.PDFOptions{PageSize: "A0"},
+	}
+
+	_, err := NewDOCXRenderer().Render(context.Background(), document)
+	assert.Equal(t, utils.ErrInvalidPageSetup, err)
+}
+
+func TestDOCXRenderer_Render_ColspanOverLimit(t *testing.T) {
+	document := &renderer.Document{
+		HTML:    bytes.NewBufferString(`<table><tr><td colspan="999999999">1</td><td>2</td></tr></table>`),
+		Options: &pdf.PDFOptions{PageSize: pdf.PageSizeA4},
+	}
+
+	result, err := NewDOCXRenderer().Render(context.Background(), document)
+	assert.NoError(t, err)
+
+	archive, err := zip.NewReader(bytes.NewReader(result), int64(len(result)))
+	assert.NoError(t, err)
+	reader, err := archive.Open("word/document.xml")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+
+	assert.Equal(t, maxColspan, bytes.Count(content, []byte("<w:gridCol ")))
+	assert.Contains(t, string(content), `<w:gridSpan w:val="1000"/>`)
+	assert.NotContains(t, string(content), `<w:t xml:space="preserve">2</w:t>`)
+}
+
+func TestParseLength(t *testing.T) {
+	for _, tc := range []struct {
+		Value    string
+		Expected float64
+		Ok       bool
+	}{
+		{Value: "12pt", Expected: 12, Ok: true},
+		{Value: "16px", Expected: 12, Ok: true},
+		{Value: "16", Expected: 12, Ok: true},
+		{Value: "1in", Expected: 72, Ok: true},
+		{Value: "50%", Expected: 50, Ok: true},
+		{Value: "auto", Ok: false},
+	} {
+		t.Run(tc.Value, func(t *testing.T) {
+			result, ok := parseLength(tc.Value, 100)
+			assert.Equal(t, tc.Ok, ok)
+			assert.InDelta(t, tc.Expected, result, 0.001)
+		})
+	}
+}
+
+// checkXML make sure the part is well formed so the word processor can open the package
+func checkXML(content []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package impl
+
+import (
+	"context"
+
+	pdfPkg "github.com/suryaadi44/eAD-System/pkg/utils/pdf/impl"
+	"github.com/suryaadi44/eAD-System/pkg/utils/renderer"
+)
+
+// HTMLRenderer return the filled html of the document, with the watermark of its state laid over it. The running
+// header and footer are only printed on the paged format
+type HTMLRenderer struct{}
+
+func NewHTMLRenderer() renderer.Renderer {
+	return &HTMLRenderer{}
+}
+
+func (h *HTMLRenderer) Render(ctx context.Context, document *renderer.Document) ([]byte, error) {
+	if document.Options.Watermark == nil {
+		return document.HTML.Bytes(), nil
+	}
+
+	result, err := pdfPkg.WithWatermark(document.HTML, document.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Bytes(), nil
+}
+
+func (h *HTMLRenderer) ContentType() string {
+	return "text/html; charset=utf-8"
+}
@@ -0,0 +1,22 @@
+package impl
+
+import (
+	"context"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils/renderer"
+)
+
+// PDFRenderer return the PDF of the document as it is, signed document keep its stored copy
+type PDFRenderer struct{}
+
+func NewPDFRenderer() renderer.Renderer {
+	return &PDFRenderer{}
+}
+
+func (p *PDFRenderer) Render(ctx context.Context, document *renderer.Document) ([]byte, error) {
+	return document.PDF(ctx)
+}
+
+func (p *PDFRenderer) ContentType() string {
+	return "application/pdf"
+}
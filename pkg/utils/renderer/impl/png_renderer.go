@@ -0,0 +1,29 @@
+package impl
+
+import (
+	"context"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+	"github.com/suryaadi44/eAD-System/pkg/utils/renderer"
+)
+
+// PNGRenderer return the thumbnail of the document first page, used by the document list
+type PNGRenderer struct {
+	thumbnailService pdf.ThumbnailService
+	width            int
+}
+
+func NewPNGRenderer(thumbnailService pdf.ThumbnailService, width int) renderer.Renderer {
+	return &PNGRenderer{
+		thumbnailService: thumbnailService,
+		width:            width,
+	}
+}
+
+func (p *PNGRenderer) Render(ctx context.Context, document *renderer.Document) ([]byte, error) {
+	return p.thumbnailService.GenerateThumbnail(ctx, document.HTML, document.Options, p.width)
+}
+
+func (p *PNGRenderer) ContentType() string {
+	return "image/png"
+}
@@ -0,0 +1,55 @@
+package impl
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/renderer"
+)
+
+type RegistryImpl struct {
+	mu        sync.RWMutex
+	renderers map[string]renderer.Renderer
+}
+
+func NewRegistryImpl() renderer.Registry {
+	return &RegistryImpl{
+		renderers: make(map[string]renderer.Renderer),
+	}
+}
+
+// Register add the renderer of the format, it replace the renderer that is already registered for it
+func (r *RegistryImpl) Register(format string, documentRenderer renderer.Renderer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.renderers[strings.ToLower(format)] = documentRenderer
+}
+
+func (r *RegistryImpl) Get(format string) (renderer.Renderer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	documentRenderer, ok := r.renderers[strings.ToLower(format)]
+	if !ok {
+		return nil, utils.ErrUnsupportedFormat
+	}
+
+	return documentRenderer, nil
+}
+
+// Formats return the registered format in alphabetical order
+func (r *RegistryImpl) Formats() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	formats := make([]string, 0, len(r.renderers))
+	for format := range r.renderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	return formats
+}
@@ -0,0 +1,77 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+	"github.com/suryaadi44/eAD-System/pkg/utils/renderer"
+	mockRenderer "github.com/suryaadi44/eAD-System/pkg/utils/renderer/mock"
+)
+
+func TestRegistryImpl(t *testing.T) {
+	registry := NewRegistryImpl()
+	pdfRenderer := new(mockRenderer.MockRenderer)
+	htmlRenderer := new(mockRenderer.MockRenderer)
+	registry.Register(renderer.FormatPDF, pdfRenderer)
+	registry.Register("HTML", htmlRenderer)
+
+	result, err := registry.Get("PDF")
+	assert.NoError(t, err)
+	assert.Equal(t, pdfRenderer, result)
+
+	result, err = registry.Get(renderer.FormatHTML)
+	assert.NoError(t, err)
+	assert.Equal(t, htmlRenderer, result)
+
+	_, err = registry.Get(renderer.FormatDOCX)
+	assert.Equal(t, utils.ErrUnsupportedFormat, err)
+
+	assert.Equal(t, []string{"html", "pdf"}, registry.Formats())
+}
+
+func TestHTMLRenderer_Render(t *testing.T) {
+	for _, tc := range []struct {
+		Name      string
+		Watermark *pdf.Watermark
+		Expected  []string
+	}{
+		{
+			Name:     "Without watermark",
+			Expected: []string{"<body><p>Surat</p></body>"},
+		},
+		{
+			Name:      "With watermark",
+			Watermark: &pdf.Watermark{Text: "DRAFT", Opacity: 0.15, Angle: 45},
+			Expected:  []string{"<p>Surat</p>", "DRAFT</div></div></body>"},
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			document := &renderer.Document{
+				HTML:    bytes.NewBufferString("<body><p>Surat</p></body>"),
+				Options: &pdf.PDFOptions{Watermark: tc.Watermark},
+			}
+
+			result, err := NewHTMLRenderer().Render(context.Background(), document)
+			assert.NoError(t, err)
+			for _, expected := range tc.Expected {
+				assert.Contains(t, string(result), expected)
+			}
+		})
+	}
+}
+
+func TestPDFRenderer_Render(t *testing.T) {
+	document := &renderer.Document{
+		PDF: func(ctx context.Context) ([]byte, error) {
+			return []byte("%PDF-1.4"), nil
+		},
+	}
+
+	result, err := NewPDFRenderer().Render(context.Background(), document)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("%PDF-1.4"), result)
+}
@@ -0,0 +1,40 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/suryaadi44/eAD-System/pkg/utils/renderer"
+)
+
+type MockRenderer struct {
+	mock.Mock
+}
+
+func (m *MockRenderer) Render(ctx context.Context, document *renderer.Document) ([]byte, error) {
+	args := m.Called(ctx, document)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockRenderer) ContentType() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+type MockRegistry struct {
+	mock.Mock
+}
+
+func (m *MockRegistry) Register(format string, documentRenderer renderer.Renderer) {
+	m.Called(format, documentRenderer)
+}
+
+func (m *MockRegistry) Get(format string) (renderer.Renderer, error) {
+	args := m.Called(format)
+	return args.Get(0).(renderer.Renderer), args.Error(1)
+}
+
+func (m *MockRegistry) Formats() []string {
+	args := m.Called()
+	return args.Get(0).([]string)
+}
@@ -0,0 +1,37 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+)
+
+// Output format of the rendered document
+const (
+	FormatPDF  = "pdf"
+	FormatHTML = "html"
+	FormatDOCX = "docx"
+	FormatPNG  = "png"
+)
+
+// Document is the filled html of the document with its page setup. PDF return the PDF of the document, which is the
+// stored copy for signed document, so the renderer didn't need to know how the document is issued
+type Document struct {
+	HTML    *bytes.Buffer
+	Options *pdf.PDFOptions
+	PDF     func(ctx context.Context) ([]byte, error)
+}
+
+// Renderer convert the document into one output format
+type Renderer interface {
+	Render(ctx context.Context, document *Document) ([]byte, error)
+	ContentType() string
+}
+
+// Registry find the renderer of the requested format, a new format is supported by registering its renderer
+type Registry interface {
+	Register(format string, renderer Renderer)
+	Get(format string) (Renderer, error)
+	Formats() []string
+}
@@ -0,0 +1,236 @@
+package impl
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/sanitizer"
+	"golang.org/x/net/html"
+)
+
+var (
+	// tags that can execute code or pull another document into the page
+	forbiddenTags = map[string]bool{
+		"script": true,
+		"iframe": true,
+		"frame":  true,
+		"object": true,
+		"embed":  true,
+		"applet": true,
+		"base":   true,
+		"portal": true,
+	}
+
+	// attributes which value is loaded as resource by the browser engine
+	urlAttributes = map[string]bool{
+		"src":        true,
+		"href":       true,
+		"data":       true,
+		"action":     true,
+		"formaction": true,
+		"background": true,
+		"poster":     true,
+		"xlink:href": true,
+		"srcset":     true,
+	}
+
+	cssURLPattern    = regexp.MustCompile(`(?i)url\(\s*['"]?([^'")]*)['"]?\s*\)`)
+	cssImportPattern = regexp.MustCompile(`(?i)@import\s+['"]([^'"]*)['"]`)
+
+	// literal part that the value of the action could turn into a scheme, eg: ht{{.rest}}
+	partialSchemePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.\-]*$`)
+)
+
+type TemplateSanitizerImpl struct {
+	allowedHosts map[string]bool
+}
+
+func NewTemplateSanitizerImpl(allowedHosts []string) sanitizer.TemplateSanitizer {
+	hosts := make(map[string]bool)
+	for _, host := range allowedHosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host != "" {
+			hosts[host] = true
+		}
+	}
+
+	return &TemplateSanitizerImpl{
+		allowedHosts: hosts,
+	}
+}
+
+// CheckTemplate reject template that contain script or reference resource outside the template itself,
+// because the template will be rendered on the server by wkhtmltopdf
+func (t *TemplateSanitizerImpl) CheckTemplate(content []byte) error {
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+	inStyle := false
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if tokenizer.Err() == io.EOF {
+				return nil
+			}
+			return tokenizer.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			if err := t.checkTag(&token); err != nil {
+				return err
+			}
+			inStyle = token.Data == "style" && tokenType == html.StartTagToken
+		case html.EndTagToken:
+			inStyle = false
+		case html.TextToken:
+			if inStyle {
+				if err := t.checkCSS(string(tokenizer.Text())); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (t *TemplateSanitizerImpl) checkTag(token *html.Token) error {
+	if forbiddenTags[token.Data] {
+		return utils.ErrTemplateContainsScript
+	}
+
+	for _, attr := range token.Attr {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" {
+			key = attr.Namespace + ":" + key
+		}
+
+		switch {
+		case strings.HasPrefix(key, "on"):
+			return utils.ErrTemplateContainsScript
+		case key == "style":
+			if err := t.checkCSS(attr.Val); err != nil {
+				return err
+			}
+		case key == "http-equiv" && strings.EqualFold(attr.Val, "refresh"):
+			return utils.ErrTemplateExternalResource
+		case key == "srcset":
+			for _, candidate := range strings.Split(attr.Val, ",") {
+				fields := strings.Fields(candidate)
+				if len(fields) == 0 {
+					continue
+				}
+				if err := t.checkURL(fields[0]); err != nil {
+					return err
+				}
+			}
+		case key == "href" && token.Data == "a":
+			// plain hyperlink is not fetched while rendering, only reject script url
+			if scheme := strings.ToLower(strings.TrimSpace(strings.SplitN(attr.Val, ":", 2)[0])); scheme == "javascript" || scheme == "vbscript" {
+				return utils.ErrTemplateContainsScript
+			}
+		case urlAttributes[key]:
+			if err := t.checkURL(attr.Val); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t *TemplateSanitizerImpl) checkCSS(css string) error {
+	for _, match := range cssURLPattern.FindAllStringSubmatch(css, -1) {
+		if err := t.checkURL(match[1]); err != nil {
+			return err
+		}
+	}
+
+	for _, match := range cssImportPattern.FindAllStringSubmatch(css, -1) {
+		if err := t.checkURL(match[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *TemplateSanitizerImpl) checkURL(rawURL string) error {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" || strings.HasPrefix(rawURL, "#") {
+		return nil
+	}
+
+	if index := strings.Index(rawURL, "{{"); index != -1 {
+		return t.checkDynamicURL(rawURL[:index])
+	}
+
+	cleaned := cleanURL(rawURL)
+
+	parsed, err := url.Parse(cleaned)
+	if err != nil {
+		return utils.ErrTemplateExternalResource
+	}
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "":
+		// relative path must stay inside the template directory
+		if strings.HasPrefix(cleaned, "/") || strings.HasPrefix(cleaned, "\\") || parsed.Host != "" {
+			return utils.ErrTemplateExternalResource
+		}
+		for _, segment := range strings.FieldsFunc(parsed.Path, func(r rune) bool { return r == '/' || r == '\\' }) {
+			if segment == ".." {
+				return utils.ErrTemplateExternalResource
+			}
+		}
+		return nil
+	case "data":
+		return nil
+	case "javascript", "vbscript":
+		return utils.ErrTemplateContainsScript
+	case "http", "https":
+		if t.allowedHosts[strings.ToLower(parsed.Hostname())] {
+			return nil
+		}
+		return utils.ErrTemplateExternalResource
+	default:
+		return utils.ErrTemplateExternalResource
+	}
+}
+
+// checkDynamicURL check the literal part before the first action of the URL filled at render time. URL that is
+// entirely filled at render time has its scheme filtered by html/template, otherwise the literal part must already end
+// the scheme and the host so the value of the action can't change where it point to
+func (t *TemplateSanitizerImpl) checkDynamicURL(prefix string) error {
+	cleaned := cleanURL(prefix)
+	if cleaned == "" {
+		return nil
+	}
+
+	if partialSchemePattern.MatchString(cleaned) {
+		return utils.ErrTemplateExternalResource
+	}
+
+	if scheme, rest, found := strings.Cut(cleaned, ":"); found {
+		switch strings.ToLower(scheme) {
+		case "http", "https":
+			authority := strings.TrimLeft(rest, "/\\")
+			if !strings.ContainsAny(authority, "/?#") {
+				return utils.ErrTemplateExternalResource
+			}
+		}
+	}
+
+	return t.checkURL(cleaned)
+}
+
+// cleanURL strip whitespace and control character that browser ignore inside scheme
+func cleanURL(rawURL string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, rawURL)
+}
@@ -0,0 +1,128 @@
+package impl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+func TestTemplateSanitizerImpl_CheckTemplate(t *testing.T) {
+	sanitizer := NewTemplateSanitizerImpl([]string{"assets.example.go.id"})
+
+	for _, tc := range []struct {
+		Name     string
+		Template string
+		Expected error
+	}{
+		{
+			Name:     "plain template with field",
+			Template: `<html><body><p style="color: red">{{.name}}</p><img src="{{.qrImage}}"></body></html>`,
+			Expected: nil,
+		},
+		{
+			Name:     "relative and data asset",
+			Template: `<img src="logo.png"><img src="data:image/png;base64,AAAA"><style>body { background: url('bg.png') }</style>`,
+			Expected: nil,
+		},
+		{
+			Name:     "whitelisted host",
+			Template: `<link rel="stylesheet" href="https://assets.example.go.id/letter.css">`,
+			Expected: nil,
+		},
+		{
+			Name:     "hyperlink to remote page",
+			Template: `<a href="https://example.com">example</a>`,
+			Expected: nil,
+		},
+		{
+			Name:     "script tag",
+			Template: `<p>hello</p><script>alert(1)</script>`,
+			Expected: utils.ErrTemplateContainsScript,
+		},
+		{
+			Name:     "event handler",
+			Template: `<img src="logo.png" onerror="alert(1)">`,
+			Expected: utils.ErrTemplateContainsScript,
+		},
+		{
+			Name:     "javascript url",
+			Template: `<a href=" javascript:alert(1)">x</a>`,
+			Expected: utils.ErrTemplateContainsScript,
+		},
+		{
+			Name:     "iframe",
+			Template: `<iframe src="logo.png"></iframe>`,
+			Expected: utils.ErrTemplateContainsScript,
+		},
+		{
+			Name:     "remote image",
+			Template: `<img src="http://169.254.169.254/latest/meta-data/">`,
+			Expected: utils.ErrTemplateExternalResource,
+		},
+		{
+			Name:     "protocol relative url",
+			Template: `<img src="//example.com/a.png">`,
+			Expected: utils.ErrTemplateExternalResource,
+		},
+		{
+			Name:     "local file url",
+			Template: `<img src="file:///etc/passwd">`,
+			Expected: utils.ErrTemplateExternalResource,
+		},
+		{
+			Name:     "absolute path",
+			Template: `<img src="/etc/passwd">`,
+			Expected: utils.ErrTemplateExternalResource,
+		},
+		{
+			Name:     "path traversal",
+			Template: `<img src="../../etc/passwd">`,
+			Expected: utils.ErrTemplateExternalResource,
+		},
+		{
+			Name:     "remote url in style attribute",
+			Template: `<div style="background-image: url(https://example.com/a.png)"></div>`,
+			Expected: utils.ErrTemplateExternalResource,
+		},
+		{
+			Name:     "remote import in style tag",
+			Template: `<style>@import "https://example.com/a.css";</style>`,
+			Expected: utils.ErrTemplateExternalResource,
+		},
+		{
+			Name:     "whitelisted host with field in path",
+			Template: `<img src="https://assets.example.go.id/{{.name}}.png"><img src="data:image/png;base64,{{.image}}">`,
+			Expected: nil,
+		},
+		{
+			Name:     "remote host with field in path",
+			Template: `<img src="http://example.com/{{.name}}">`,
+			Expected: utils.ErrTemplateExternalResource,
+		},
+		{
+			Name:     "whitelisted host followed by field",
+			Template: `<img src="https://assets.example.go.id{{.name}}">`,
+			Expected: utils.ErrTemplateExternalResource,
+		},
+		{
+			Name:     "host filled by field",
+			Template: `<img src="https://{{.host}}/a.png">`,
+			Expected: utils.ErrTemplateExternalResource,
+		},
+		{
+			Name:     "scheme completed by field",
+			Template: `<div style="background: url(ht{{.rest}})"></div>`,
+			Expected: utils.ErrTemplateExternalResource,
+		},
+		{
+			Name:     "meta refresh",
+			Template: `<meta http-equiv="refresh" content="0;url=file:///etc/passwd">`,
+			Expected: utils.ErrTemplateExternalResource,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, sanitizer.CheckTemplate([]byte(tc.Template)))
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package mock
+
+import "github.com/stretchr/testify/mock"
+
+type MockTemplateSanitizer struct {
+	mock.Mock
+}
+
+func (m *MockTemplateSanitizer) CheckTemplate(content []byte) error {
+	args := m.Called(content)
+	return args.Error(0)
+}
@@ -0,0 +1,5 @@
+package sanitizer
+
+type TemplateSanitizer interface {
+	CheckTemplate(content []byte) error
+}
@@ -0,0 +1,138 @@
+package impl
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"golang.org/x/crypto/pkcs12"
+)
+
+// officeKeystore is the keystore used by signer that didn't have their own keystore
+const officeKeystore = "office"
+
+// signingKey is the private key and certificate chain read from the keystore, the signer certificate come first
+type signingKey struct {
+	signer crypto.Signer
+	chain  []*x509.Certificate
+}
+
+// loadSigningKey read <signer id>.p12 from the keystore directory, or office.p12 when the signer didn't have one
+func loadSigningKey(dir string, password string, signerID string) (*signingKey, error) {
+	names := []string{officeKeystore}
+	if signerID != "" && filepath.Base(signerID) == signerID && !strings.HasPrefix(signerID, ".") {
+		names = []string{signerID, officeKeystore}
+	}
+
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name+".p12"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		return parseKeystore(data, password)
+	}
+
+	return nil, utils.ErrSigningKeyNotFound
+}
+
+// parseKeystore read the PKCS#12 keystore, pkcs12.Decode only accept a single certificate so the chain is read from
+// the PEM conversion instead
+func parseKeystore(data []byte, password string) (*signingKey, error) {
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, utils.ErrInvalidKeystore
+	}
+
+	var signer crypto.Signer
+	var certificates []*x509.Certificate
+	for _, block := range blocks {
+		switch block.Type {
+		case "CERTIFICATE":
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, utils.ErrInvalidKeystore
+			}
+			certificates = append(certificates, certificate)
+		case "PRIVATE KEY":
+			if signer, err = parsePrivateKey(block.Bytes); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if signer == nil {
+		return nil, utils.ErrInvalidKeystore
+	}
+
+	key := &signingKey{signer: signer}
+	for _, certificate := range certificates {
+		if samePublicKey(certificate.PublicKey, signer.Public()) {
+			key.chain = append(key.chain, certificate)
+		}
+	}
+	if len(key.chain) != 1 {
+		return nil, utils.ErrInvalidKeystore
+	}
+
+	for _, certificate := range certificates {
+		if certificate != key.chain[0] && !samePublicKey(certificate.PublicKey, signer.Public()) {
+			key.chain = append(key.chain, certificate)
+		}
+	}
+
+	return key, nil
+}
+
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			return key, nil
+		case *ecdsa.PrivateKey:
+			return key, nil
+		}
+	}
+
+	return nil, utils.ErrInvalidKeystore
+}
+
+func samePublicKey(a crypto.PublicKey, b crypto.PublicKey) bool {
+	ader, err := x509.MarshalPKIXPublicKey(a)
+	if err != nil {
+		return false
+	}
+	bder, err := x509.MarshalPKIXPublicKey(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(ader, bder)
+}
+
+// checkValidity reject a certificate that is not valid at the signing time, a reader application would show the
+// signature as invalid anyway
+func (k *signingKey) checkValidity(at time.Time) error {
+	certificate := k.chain[0]
+	if at.Before(certificate.NotBefore) || at.After(certificate.NotAfter) {
+		return utils.ErrSigningCertificateExpired
+	}
+
+	return nil
+}
@@ -0,0 +1,415 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/indonesian"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf/parser"
+	"github.com/suryaadi44/eAD-System/pkg/utils/signature"
+)
+
+// mmToPt convert millimeter into point
+const mmToPt = 72 / 25.4
+
+// byteRangePlaceholder reserve the space of the byte range, which is only known after the whole update is written
+var byteRangePlaceholder = "/ByteRange [0 " + strings.Repeat("0", 10) + " " + strings.Repeat("0", 10) + " " + strings.Repeat("0", 10) + "]"
+
+// PAdESSignerImpl sign the PDF with the key from a PKCS#12 keystore in the keystore directory, <signer id>.p12 when
+// the signer has their own key, otherwise office.p12. The signature is appended as an incremental update, so the
+// signed byte is exactly the PDF that was rendered
+type PAdESSignerImpl struct {
+	keystorePath string
+	password     string
+}
+
+func NewPAdESSignerImpl(keystorePath string, password string) signature.PDFSigner {
+	return &PAdESSignerImpl{
+		keystorePath: keystorePath,
+		password:     password,
+	}
+}
+
+func (s *PAdESSignerImpl) SignPDF(ctx context.Context, document []byte, options *signature.SignOptions) ([]byte, error) {
+	key, err := loadSigningKey(s.keystorePath, s.password, options.SignerID)
+	if err != nil {
+		return nil, err
+	}
+
+	signingTime := options.SigningTime
+	if signingTime.IsZero() {
+		signingTime = time.Now()
+	}
+	if err = key.checkValidity(signingTime); err != nil {
+		return nil, err
+	}
+
+	file, err := parser.Parse(document)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := file.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	pageIndex := len(pages) - 1
+	if options.Page > 0 {
+		if int(options.Page) > len(pages) {
+			return nil, utils.ErrInvalidSignaturePlacement
+		}
+		pageIndex = int(options.Page) - 1
+	}
+
+	// the certificate chain dominate the signature size, the rest is the signature and attribute
+	capacity := 8192
+	for _, certificate := range key.chain {
+		capacity += len(certificate.Raw)
+	}
+
+	u := &incrementalUpdate{file: file, next: file.Size, objects: make(map[int]string)}
+	signatureObject := u.reserve()
+	u.objects[signatureObject] = signatureDictionary(options, signingTime, capacity)
+
+	if err = u.addSignatureField(signatureObject, pages[pageIndex], options, signingTime); err != nil {
+		return nil, err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	signed := u.write()
+	contentsStart, contentsEnd, err := fillByteRange(signed, signatureObject)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.New()
+	digest.Write(signed[:contentsStart])
+	digest.Write(signed[contentsEnd:])
+
+	cms, err := signCMS(key, digest.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := hex.EncodeToString(cms)
+	if len(encoded) > contentsEnd-contentsStart-2 {
+		return nil, utils.ErrSignatureTooLarge
+	}
+	copy(signed[contentsStart+1:], encoded)
+
+	return signed, nil
+}
+
+func signatureDictionary(options *signature.SignOptions, signingTime time.Time, capacity int) string {
+	dict := parser.NewDictionary()
+	dict.Set("/Type", "/Sig")
+	dict.Set("/Filter", "/Adobe.PPKLite")
+	dict.Set("/SubFilter", "/ETSI.CAdES.detached")
+	dict.Set("/M", pdfDate(signingTime))
+	if options.Name != "" {
+		dict.Set("/Name", pdfText(options.Name))
+	}
+	if options.Reason != "" {
+		dict.Set("/Reason", pdfText(options.Reason))
+	}
+
+	// the byte range and contents is written last, so the byte range is found right before the contents
+	return strings.TrimSuffix(dict.String(), ">>") + byteRangePlaceholder + " /Contents <" + strings.Repeat("0", capacity*2) + "> >>"
+}
+
+// fillByteRange write the byte range that cover the whole file except the signature contents, and return where the
+// contents start and end
+func fillByteRange(signed []byte, signatureObject int) (int, int, error) {
+	objectStart := bytes.LastIndex(signed, []byte(fmt.Sprintf("\n%d 0 obj\n", signatureObject)))
+	if objectStart < 0 {
+		return 0, 0, utils.ErrUnsupportedPDF
+	}
+
+	byteRangeStart := objectStart + bytes.Index(signed[objectStart:], []byte(byteRangePlaceholder))
+	contentsStart := objectStart + bytes.Index(signed[objectStart:], []byte("/Contents <")) + len("/Contents ")
+	contentsEnd := contentsStart + bytes.IndexByte(signed[contentsStart:], '>') + 1
+
+	byteRange := fmt.Sprintf("/ByteRange [0 %d %d %d]", contentsStart, contentsEnd, len(signed)-contentsEnd)
+	byteRange += strings.Repeat(" ", len(byteRangePlaceholder)-len(byteRange))
+	copy(signed[byteRangeStart:], byteRange)
+
+	return contentsStart, contentsEnd, nil
+}
+
+// incrementalUpdate collect the new and changed object, which is appended after the original PDF along with its own
+// cross reference section
+type incrementalUpdate struct {
+	file    *parser.File
+	next    int
+	objects map[int]string
+}
+
+func (u *incrementalUpdate) reserve() int {
+	number := u.next
+	u.next++
+	return number
+}
+
+// dictionary return the dictionary of the object, including the change made earlier in this update
+func (u *incrementalUpdate) dictionary(number int) (*parser.Dictionary, error) {
+	if raw, ok := u.objects[number]; ok {
+		return parser.ParseDictionary(raw)
+	}
+
+	return u.file.Dictionary(number)
+}
+
+// appendReference add the reference into the array entry of the dictionary, the array is written back into its own
+// object when the entry is an indirect reference
+func (u *incrementalUpdate) appendReference(dict *parser.Dictionary, key string, value string) error {
+	raw, ok := dict.Get(key)
+	if !ok {
+		dict.Set(key, "["+value+"]")
+		return nil
+	}
+
+	if number, ok := parser.Reference(raw); ok {
+		array, err := u.file.Object(number)
+		if err != nil {
+			return err
+		}
+		if u.objects[number], err = parser.AppendArray(array, value); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	array, err := parser.AppendArray(raw, value)
+	if err != nil {
+		return err
+	}
+	dict.Set(key, array)
+
+	return nil
+}
+
+// addSignatureField add the signature widget to the page and the document form
+func (u *incrementalUpdate) addSignatureField(signatureObject int, p parser.Page, options *signature.SignOptions, signingTime time.Time) error {
+	field := u.reserve()
+	ref := fmt.Sprintf("%d 0 R", field)
+
+	widget := parser.NewDictionary()
+	widget.Set("/Type", "/Annot")
+	widget.Set("/Subtype", "/Widget")
+	widget.Set("/FT", "/Sig")
+	widget.Set("/T", pdfText(fmt.Sprintf("Signature%d", signatureObject)))
+	widget.Set("/V", fmt.Sprintf("%d 0 R", signatureObject))
+	widget.Set("/F", "132")
+	widget.Set("/P", fmt.Sprintf("%d 0 R", p.Object))
+	widget.Set("/Rect", "[0 0 0 0]")
+
+	if options.Width > 0 && options.Height > 0 {
+		left := p.MediaBox[0] + float64(options.X)*mmToPt
+		top := p.MediaBox[3] - float64(options.Y)*mmToPt
+		width, height := float64(options.Width)*mmToPt, float64(options.Height)*mmToPt
+		if left < p.MediaBox[0] || left+width > p.MediaBox[2] || top-height < p.MediaBox[1] || top > p.MediaBox[3] {
+			return utils.ErrInvalidSignaturePlacement
+		}
+
+		appearance, font := u.reserve(), u.reserve()
+		u.objects[font] = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"
+		u.objects[appearance] = appearanceStream(width, height, font, options.Name, signingTime)
+		widget.Set("/Rect", fmt.Sprintf("[%s %s %s %s]", round(left), round(top-height), round(left+width), round(top)))
+		widget.Set("/AP", fmt.Sprintf("<< /N %d 0 R >>", appearance))
+	}
+	u.objects[field] = widget.String()
+
+	pageDict, err := u.dictionary(p.Object)
+	if err != nil {
+		return err
+	}
+	if err = u.appendReference(pageDict, "/Annots", ref); err != nil {
+		return err
+	}
+	u.objects[p.Object] = pageDict.String()
+
+	root, err := u.file.Root()
+	if err != nil {
+		return err
+	}
+	catalog, err := u.dictionary(root)
+	if err != nil {
+		return err
+	}
+
+	form := parser.NewDictionary()
+	formObject := 0
+	if raw, ok := catalog.Get("/AcroForm"); ok {
+		if number, ok := parser.Reference(raw); ok {
+			formObject = number
+			raw, err = u.file.Object(number)
+			if err != nil {
+				return err
+			}
+		}
+		if form, err = parser.ParseDictionary(raw); err != nil {
+			return err
+		}
+	}
+
+	if err = u.appendReference(form, "/Fields", ref); err != nil {
+		return err
+	}
+	form.Set("/SigFlags", "3")
+
+	if formObject > 0 {
+		u.objects[formObject] = form.String()
+	} else {
+		catalog.Set("/AcroForm", form.String())
+	}
+	u.objects[root] = catalog.String()
+
+	return nil
+}
+
+// write append the object and the cross reference section after the original PDF
+func (u *incrementalUpdate) write() []byte {
+	var buf bytes.Buffer
+	buf.Write(u.file.Data)
+	if !bytes.HasSuffix(u.file.Data, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+
+	numbers := make([]int, 0, len(u.objects))
+	for number := range u.objects {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	offsets := make(map[int]int, len(numbers))
+	for _, number := range numbers {
+		offsets[number] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", number, u.objects[number])
+	}
+
+	xref := buf.Len()
+	buf.WriteString("xref\n")
+	for i := 0; i < len(numbers); {
+		end := i + 1
+		for end < len(numbers) && numbers[end] == numbers[end-1]+1 {
+			end++
+		}
+
+		fmt.Fprintf(&buf, "%d %d\n", numbers[i], end-i)
+		for _, number := range numbers[i:end] {
+			fmt.Fprintf(&buf, "%010d 00000 n \n", offsets[number])
+		}
+		i = end
+	}
+
+	size := u.next
+	if u.file.Size > size {
+		size = u.file.Size
+	}
+
+	trailer := parser.NewDictionary()
+	trailer.Set("/Size", strconv.Itoa(size))
+	for _, key := range []string{"/Root", "/Info", "/ID"} {
+		if value, ok := u.file.Trailer.Get(key); ok {
+			trailer.Set(key, value)
+		}
+	}
+	trailer.Set("/Prev", strconv.Itoa(u.file.Startxref))
+	fmt.Fprintf(&buf, "trailer\n%s\nstartxref\n%d\n%%%%EOF\n", trailer.String(), xref)
+
+	return buf.Bytes()
+}
+
+// appearanceStream draw the visible signature, a border with the signer name and signing date
+func appearanceStream(width float64, height float64, font int, name string, signingTime time.Time) string {
+	lines := []string{"Ditandatangani secara elektronik oleh", name, indonesian.FormatDate(signingTime)}
+	size := math.Min(8, (height-4)/(float64(len(lines))*1.25))
+	if size < 1 {
+		size = 1
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "q 0.2 G 0.5 w 0.25 0.25 %s %s re S Q\n", round(width-0.5), round(height-0.5))
+	fmt.Fprintf(&content, "BT 0 g /F1 %s Tf %s TL 4 %s Td\n", round(size), round(size*1.25), round(height/2+size*1.25*float64(len(lines)-1)/2-size/3))
+	for i, line := range lines {
+		if i > 0 {
+			content.WriteString("T* ")
+		}
+		fmt.Fprintf(&content, "(%s) Tj\n", escapeText(winAnsi(line)))
+	}
+	content.WriteString("ET")
+
+	return fmt.Sprintf("<< /Type /XObject /Subtype /Form /BBox [0 0 %s %s] /Resources << /Font << /F1 %d 0 R >> >> /Length %d >>\nstream\n%s\nendstream",
+		round(width), round(height), font, content.Len(), content.String())
+}
+
+// round format the number with at most two decimal, enough precision for a position in point
+func round(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+func escapeText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\r", `\r`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+// winAnsi convert the text for the standard font, character outside Latin-1 is replaced with question mark
+func winAnsi(text string) string {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r > 0xFF || (r >= 0x80 && r < 0xA0) {
+			r = '?'
+		}
+		encoded = append(encoded, byte(r))
+	}
+
+	return string(encoded)
+}
+
+// pdfText encode the text string, text outside printable ASCII is written as UTF-16 with byte order mark
+func pdfText(text string) string {
+	ascii := true
+	for _, r := range text {
+		if r < 0x20 || r > 0x7E {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return "(" + escapeText(text) + ")"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&sb, "%04X", unit)
+	}
+	sb.WriteString(">")
+
+	return sb.String()
+}
+
+// pdfDate format the time as PDF date, eg: D:20221010093000+07'00'
+func pdfDate(t time.Time) string {
+	_, offset := t.Zone()
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+
+	return fmt.Sprintf("(D:%s%c%02d'%02d')", t.Format("20060102150405"), sign, offset/3600, offset%3600/60)
+}
@@ -0,0 +1,300 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+	pdfImpl "github.com/suryaadi44/eAD-System/pkg/utils/pdf/impl"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf/parser"
+	"github.com/suryaadi44/eAD-System/pkg/utils/signature"
+)
+
+const (
+	testKeystorePath = "testdata"
+	testPassword     = "secret"
+	testSignerID     = "6f1f0a8e-3f2c-4a51-9c8b-2d8e7c1b5a90"
+)
+
+var (
+	byteRangePattern = regexp.MustCompile(`/ByteRange \[0 (\d+) (\d+) (\d+)\s*\]`)
+	contentsPattern  = regexp.MustCompile(`/Contents <([0-9a-fA-F]+)>`)
+)
+
+type TestSuitePAdESSigner struct {
+	suite.Suite
+	signer   signature.PDFSigner
+	document []byte
+	roots    *x509.CertPool
+}
+
+func (s *TestSuitePAdESSigner) SetupSuite() {
+	document, err := pdfImpl.NewNativePDFService(10*time.Second).GeneratePDF(context.Background(), bytes.NewBufferString(
+		`<h1>Surat Keterangan</h1><p>Halaman pertama</p><p style="page-break-before: always">Halaman kedua</p>`,
+	), &pdf.PDFOptions{MarginTop: 20, MarginBottom: 20, MarginLeft: 20, MarginRight: 20})
+	s.Require().NoError(err)
+	s.document = document
+
+	root, err := os.ReadFile("testdata/root.pem")
+	s.Require().NoError(err)
+	s.roots = x509.NewCertPool()
+	s.Require().True(s.roots.AppendCertsFromPEM(root))
+}
+
+func (s *TestSuitePAdESSigner) SetupTest() {
+	s.signer = NewPAdESSignerImpl(testKeystorePath, testPassword)
+}
+
+func (s *TestSuitePAdESSigner) TearDownTest() {
+	s.signer = nil
+}
+
+// verifySignature check the last signature in the PDF the way a reader application would, and return its certificate
+func (s *TestSuitePAdESSigner) verifySignature(signed []byte) *x509.Certificate {
+	ranges := byteRangePattern.FindAllSubmatch(signed, -1)
+	s.Require().NotEmpty(ranges)
+	byteRange := ranges[len(ranges)-1]
+	contentsStart, _ := strconv.Atoi(string(byteRange[1]))
+	contentsEnd, _ := strconv.Atoi(string(byteRange[2]))
+	rest, _ := strconv.Atoi(string(byteRange[3]))
+	s.Equal(len(signed), contentsEnd+rest, "byte range must cover the whole file")
+
+	s.Require().True(contentsPattern.Match(signed[contentsStart-len("/Contents "):contentsEnd]), "byte range must exclude exactly the contents")
+	der, err := hex.DecodeString(string(signed[contentsStart+1 : contentsEnd-1]))
+	s.Require().NoError(err)
+
+	var info contentInfo
+	_, err = asn1.Unmarshal(der, &info)
+	s.Require().NoError(err)
+	s.Equal(oidSignedData, info.ContentType)
+
+	var data signedData
+	_, err = asn1.Unmarshal(info.Content.Bytes, &data)
+	s.Require().NoError(err)
+	s.Require().Len(data.SignerInfos, 1)
+
+	certificates, err := x509.ParseCertificates(data.Certificates.Bytes)
+	s.Require().NoError(err)
+	s.Require().Len(certificates, 3, "signer certificate with the intermediate and root")
+	signer := certificates[0]
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+	_, err = signer.Verify(x509.VerifyOptions{Roots: s.roots, Intermediates: intermediates, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	s.NoError(err)
+
+	info0 := data.SignerInfos[0]
+	s.Equal(signer.SerialNumber, info0.SID.SerialNumber)
+
+	digest := sha256.New()
+	digest.Write(signed[:contentsStart])
+	digest.Write(signed[contentsEnd:])
+
+	attributes := map[string]asn1.RawValue{}
+	for rest := info0.SignedAttributes.Bytes; len(rest) > 0; {
+		var attr attribute
+		rest, err = asn1.Unmarshal(rest, &attr)
+		s.Require().NoError(err)
+		attributes[attr.Type.String()] = attr.Values
+	}
+	s.Contains(attributes, oidContentType.String())
+	s.Contains(attributes, oidSigningCertificateV2.String())
+
+	var messageDigest []byte
+	_, err = asn1.Unmarshal(attributes[oidMessageDigest.String()].Bytes, &messageDigest)
+	s.Require().NoError(err)
+	s.Equal(digest.Sum(nil), messageDigest)
+
+	algorithm := x509.SHA256WithRSA
+	if info0.SignatureAlgorithm.Algorithm.Equal(oidECDSAWithSHA256) {
+		algorithm = x509.ECDSAWithSHA256
+	}
+	signedAttributes := append([]byte{0x31}, info0.SignedAttributes.FullBytes[1:]...)
+	s.NoError(signer.CheckSignature(algorithm, signedAttributes, info0.Signature))
+
+	return signer
+}
+
+func (s *TestSuitePAdESSigner) TestSignPDF() {
+	for _, tc := range []struct {
+		Name           string
+		Options        *signature.SignOptions
+		ExpectedSigner string
+		ExpectedRect   string
+	}{
+		{
+			Name: "Success with signer keystore and visible signature on the first page",
+			Options: &signature.SignOptions{
+				SignerID: testSignerID, Name: "Budi Santoso", Reason: "Kepala Desa", Page: 1, X: 120, Y: 230, Width: 60, Height: 20,
+			},
+			ExpectedSigner: "Budi Santoso",
+			ExpectedRect:   "[340.16 133.23 510.24 189.92]",
+		},
+		{
+			Name:           "Success with office keystore and invisible signature",
+			Options:        &signature.SignOptions{SignerID: "f3e1c0de-0000-4000-8000-000000000000", Name: "Ani"},
+			ExpectedSigner: "Kantor Desa Test",
+			ExpectedRect:   "[0 0 0 0]",
+		},
+		{
+			Name:           "Success with office keystore for signer id that is not a file name",
+			Options:        &signature.SignOptions{SignerID: "../" + testSignerID},
+			ExpectedSigner: "Kantor Desa Test",
+			ExpectedRect:   "[0 0 0 0]",
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			signed, err := s.signer.SignPDF(context.Background(), s.document, tc.Options)
+			s.Require().NoError(err)
+
+			s.True(bytes.HasPrefix(signed, s.document), "signature must be appended as incremental update")
+			s.Equal(tc.ExpectedSigner, s.verifySignature(signed).Subject.CommonName)
+			s.Contains(string(signed), "/Rect "+tc.ExpectedRect)
+			s.Contains(string(signed), "/SubFilter /ETSI.CAdES.detached")
+
+			file, err := parser.Parse(signed)
+			s.Require().NoError(err)
+			pages, err := file.Pages()
+			s.Require().NoError(err)
+			s.Len(pages, 2)
+
+			page := pages[1]
+			if tc.Options.Page == 1 {
+				page = pages[0]
+			}
+			_, ok := page.Dict.Get("/Annots")
+			s.True(ok)
+
+			root, _ := file.Root()
+			catalog, err := file.Dictionary(root)
+			s.Require().NoError(err)
+			form, ok := catalog.Get("/AcroForm")
+			s.True(ok)
+			s.Contains(form, "/SigFlags 3")
+		})
+		s.TearDownTest()
+	}
+}
+
+func (s *TestSuitePAdESSigner) TestSignPDF_Twice() {
+	first, err := s.signer.SignPDF(context.Background(), s.document, &signature.SignOptions{SignerID: testSignerID})
+	s.Require().NoError(err)
+
+	second, err := s.signer.SignPDF(context.Background(), first, &signature.SignOptions{SignerID: "office", Page: 2, X: 20, Y: 20, Width: 50, Height: 15})
+	s.Require().NoError(err)
+	s.Equal("Kantor Desa Test", s.verifySignature(second).Subject.CommonName)
+
+	file, err := parser.Parse(second)
+	s.Require().NoError(err)
+	pages, err := file.Pages()
+	s.Require().NoError(err)
+	annots, _ := pages[1].Dict.Get("/Annots")
+	values, err := parser.ParseArray(annots)
+	s.NoError(err)
+	s.Len(values, 2)
+
+	root, _ := file.Root()
+	catalog, err := file.Dictionary(root)
+	s.Require().NoError(err)
+	form, _ := catalog.Get("/AcroForm")
+	s.Equal(2, strings.Count(form, " 0 R"))
+}
+
+func (s *TestSuitePAdESSigner) TestSignPDF_Error() {
+	for _, tc := range []struct {
+		Name        string
+		Signer      signature.PDFSigner
+		Document    []byte
+		Options     *signature.SignOptions
+		ExpectedErr error
+	}{
+		{
+			Name:        "Error keystore not found",
+			Signer:      NewPAdESSignerImpl(s.T().TempDir(), testPassword),
+			Document:    s.document,
+			Options:     &signature.SignOptions{SignerID: testSignerID},
+			ExpectedErr: utils.ErrSigningKeyNotFound,
+		},
+		{
+			Name:        "Error wrong keystore password",
+			Signer:      NewPAdESSignerImpl(testKeystorePath, "wrong"),
+			Document:    s.document,
+			Options:     &signature.SignOptions{SignerID: testSignerID},
+			ExpectedErr: utils.ErrInvalidKeystore,
+		},
+		{
+			Name:        "Error certificate expired at signing time",
+			Signer:      s.signer,
+			Document:    s.document,
+			Options:     &signature.SignOptions{SignerID: testSignerID, SigningTime: time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC)},
+			ExpectedErr: utils.ErrSigningCertificateExpired,
+		},
+		{
+			Name:        "Error page out of range",
+			Signer:      s.signer,
+			Document:    s.document,
+			Options:     &signature.SignOptions{SignerID: testSignerID, Page: 3},
+			ExpectedErr: utils.ErrInvalidSignaturePlacement,
+		},
+		{
+			Name:        "Error signature field outside the page",
+			Signer:      s.signer,
+			Document:    s.document,
+			Options:     &signature.SignOptions{SignerID: testSignerID, X: 200, Y: 10, Width: 60, Height: 20},
+			ExpectedErr: utils.ErrInvalidSignaturePlacement,
+		},
+		{
+			Name:        "Error not a PDF",
+			Signer:      s.signer,
+			Document:    []byte("not a pdf"),
+			Options:     &signature.SignOptions{SignerID: testSignerID},
+			ExpectedErr: utils.ErrUnsupportedPDF,
+		},
+		{
+			Name:        "Error cross reference stream",
+			Signer:      s.signer,
+			Document:    []byte("%PDF-1.5\n1 0 obj\n<< /Type /XRef >>\nstream\nendstream\nendobj\nstartxref\n9\n%%EOF\n"),
+			Options:     &signature.SignOptions{SignerID: testSignerID},
+			ExpectedErr: utils.ErrUnsupportedPDF,
+		},
+	} {
+		s.Run(tc.Name, func() {
+			_, err := tc.Signer.SignPDF(context.Background(), tc.Document, tc.Options)
+			s.Equal(tc.ExpectedErr, err)
+		})
+	}
+}
+
+func (s *TestSuitePAdESSigner) TestParseKeystore() {
+	data, err := os.ReadFile("testdata/office.p12")
+	s.Require().NoError(err)
+
+	key, err := parseKeystore(data, testPassword)
+	s.Require().NoError(err)
+	s.Len(key.chain, 3)
+	s.Equal("Kantor Desa Test", key.chain[0].Subject.CommonName)
+
+	block, _ := pem.Decode([]byte("-----BEGIN CERTIFICATE-----\nAA==\n-----END CERTIFICATE-----\n"))
+	_, err = parseKeystore(block.Bytes, testPassword)
+	s.Equal(utils.ErrInvalidKeystore, err)
+}
+
+func TestPAdESSigner(t *testing.T) {
+	suite.Run(t, new(TestSuitePAdESSigner))
+}
@@ -0,0 +1,372 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf/parser"
+	"github.com/suryaadi44/eAD-System/pkg/utils/signature"
+)
+
+var (
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+
+	signatureByteRangePattern = regexp.MustCompile(`/ByteRange\s*\[\s*(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s*\]`)
+	pdfDatePattern            = regexp.MustCompile(`^D:(\d{14})(?:([+\-Z])(?:(\d{2})'?(\d{2})?'?)?)?`)
+)
+
+// PAdESVerifierImpl find the signature through their byte range instead of the cross reference, so PDF saved with
+// cross reference stream or compressed object can still be checked. The signer certificate is only trusted when it
+// chain up to one of the roots, without roots no signature is trusted
+type PAdESVerifierImpl struct {
+	roots *x509.CertPool
+	now   func() time.Time
+}
+
+func NewPAdESVerifierImpl(roots *x509.CertPool) signature.PDFVerifier {
+	return &PAdESVerifierImpl{
+		roots: roots,
+		now:   time.Now,
+	}
+}
+
+// LoadTrustAnchor read the PEM certificate bundle used as the root of the signing certificate, empty path mean no
+// certificate is trusted
+func LoadTrustAnchor(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(content) {
+		return nil, utils.ErrInvalidTrustAnchor
+	}
+
+	return roots, nil
+}
+
+func (v *PAdESVerifierImpl) VerifyPDF(ctx context.Context, document []byte) ([]signature.SignatureInfo, error) {
+	if !bytes.HasPrefix(document, []byte("%PDF-")) {
+		return nil, utils.ErrUnsupportedPDF
+	}
+
+	signatures := []signature.SignatureInfo{}
+	seen := make(map[string]bool)
+	for _, match := range signatureByteRangePattern.FindAllSubmatchIndex(document, -1) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// every value must be an offset inside the document, this also keep their sum from overflowing
+		var byteRange [4]int
+		inside := true
+		for i := range byteRange {
+			value, err := strconv.Atoi(string(document[match[2+2*i]:match[3+2*i]]))
+			if err != nil || value < 0 || value > len(document) {
+				inside = false
+				break
+			}
+			byteRange[i] = value
+		}
+		if !inside {
+			continue
+		}
+
+		// the same signature dictionary may be written again by a later incremental update
+		key := string(document[match[0]:match[1]])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		info, chain, ok := verifyByteRange(document, byteRange)
+		if !ok {
+			continue
+		}
+		readSignatureDictionary(document, match[0], &info)
+		trusted, expired := v.trust(chain)
+		info.Trusted = info.Intact && trusted
+		info.Expired = info.Intact && expired
+
+		signatures = append(signatures, info)
+	}
+
+	return signatures, nil
+}
+
+// trust check the signer certificate, the first of the chain, against the roots at the current time. The signing time
+// in the PDF is written by the signer, so it can't be used to accept a certificate that is no longer valid. expired is
+// true when the chain up to the roots is only refused because a certificate is outside its validity period
+func (v *PAdESVerifierImpl) trust(chain []*x509.Certificate) (trusted bool, expired bool) {
+	if v.roots == nil || len(chain) == 0 {
+		return false, false
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range chain[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	options := x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   v.now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	_, err := chain[0].Verify(options)
+	if err == nil {
+		return true, false
+	}
+
+	var invalid x509.CertificateInvalidError
+	if !errors.As(err, &invalid) || invalid.Reason != x509.Expired {
+		return false, false
+	}
+
+	// a certificate from another root is untrusted rather than expired, so the chain is checked again within the
+	// validity of the signer certificate
+	options.CurrentTime = chain[0].NotAfter
+	_, err = chain[0].Verify(options)
+
+	return false, err == nil
+}
+
+// verifyByteRange check the signature in the gap of the byte range against the bytes around it, byte range that
+// didn't point to a signature is ignored. The value of the byte range must already be inside the document
+func verifyByteRange(document []byte, byteRange [4]int) (signature.SignatureInfo, []*x509.Certificate, bool) {
+	contentsStart, contentsEnd := byteRange[1], byteRange[2]
+	signedLength := byteRange[2] + byteRange[3]
+	if byteRange[0] != 0 || contentsStart >= contentsEnd || signedLength > len(document) ||
+		document[contentsStart] != '<' || document[contentsEnd-1] != '>' {
+		return signature.SignatureInfo{}, nil, false
+	}
+
+	info := signature.SignatureInfo{
+		SignedLength:   signedLength,
+		CoversDocument: signedLength == len(document),
+	}
+
+	contents, err := hex.DecodeString(string(document[contentsStart+1 : contentsEnd-1]))
+	if err != nil {
+		return info, nil, true
+	}
+
+	chain, intact := verifyCMS(contents, document[:contentsStart], document[contentsEnd:signedLength])
+	if len(chain) > 0 {
+		info.Signer = chain[0].Subject.CommonName
+	}
+	info.Intact = intact
+
+	return info, chain, true
+}
+
+// verifyCMS check the detached CMS signature of the signed content. It return the embedded certificate starting with
+// the signing certificate when it is found, even if the signature didn't match
+func verifyCMS(der []byte, content ...[]byte) ([]*x509.Certificate, bool) {
+	var info contentInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil || !info.ContentType.Equal(oidSignedData) {
+		return nil, false
+	}
+
+	var data signedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &data); err != nil || len(data.SignerInfos) == 0 {
+		return nil, false
+	}
+
+	certificates, err := x509.ParseCertificates(data.Certificates.Bytes)
+	if err != nil {
+		return nil, false
+	}
+
+	signer := data.SignerInfos[0]
+	var certificate *x509.Certificate
+	var chain []*x509.Certificate
+	for _, c := range certificates {
+		if certificate == nil && c.SerialNumber.Cmp(signer.SID.SerialNumber) == 0 && bytes.Equal(c.RawIssuer, signer.SID.Issuer.FullBytes) {
+			certificate = c
+			chain = append([]*x509.Certificate{c}, chain...)
+		} else {
+			chain = append(chain, c)
+		}
+	}
+	if certificate == nil {
+		return nil, false
+	}
+	if !signer.DigestAlgorithm.Algorithm.Equal(oidSHA256) {
+		return chain, false
+	}
+
+	var algorithm x509.SignatureAlgorithm
+	switch {
+	case signer.SignatureAlgorithm.Algorithm.Equal(oidSHA256WithRSA), signer.SignatureAlgorithm.Algorithm.Equal(oidRSAEncryption):
+		algorithm = x509.SHA256WithRSA
+	case signer.SignatureAlgorithm.Algorithm.Equal(oidECDSAWithSHA256):
+		algorithm = x509.ECDSAWithSHA256
+	default:
+		return chain, false
+	}
+
+	signedContent := bytes.Join(content, nil)
+
+	// without signed attribute the signature is made directly over the content
+	if len(signer.SignedAttributes.FullBytes) == 0 {
+		return chain, certificate.CheckSignature(algorithm, signedContent, signer.Signature) == nil
+	}
+
+	var messageDigest []byte
+	for rest := signer.SignedAttributes.Bytes; len(rest) > 0; {
+		var attr attribute
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			return chain, false
+		}
+		if attr.Type.Equal(oidMessageDigest) {
+			if _, err = asn1.Unmarshal(attr.Values.Bytes, &messageDigest); err != nil {
+				return chain, false
+			}
+		}
+	}
+
+	digest := sha256.Sum256(signedContent)
+	if !bytes.Equal(digest[:], messageDigest) {
+		return chain, false
+	}
+
+	// the signature is computed over the attribute encoded as SET, while it is embedded with implicit [0] tag
+	attributes := append([]byte{0x31}, signer.SignedAttributes.FullBytes[1:]...)
+
+	return chain, certificate.CheckSignature(algorithm, attributes, signer.Signature) == nil
+}
+
+// readSignatureDictionary fill the signer name, reason and signing time from the dictionary that contain the byte
+// range, the dictionary inside compressed object stream can't be read and is left empty
+func readSignatureDictionary(document []byte, byteRangeOffset int, info *signature.SignatureInfo) {
+	start := bytes.LastIndex(document[:byteRangeOffset], []byte("obj"))
+	if start < 0 {
+		return
+	}
+
+	dict, err := parser.ParseDictionaryAt(document, start+len("obj"))
+	if err != nil {
+		return
+	}
+
+	if name, ok := dict.Get("/Name"); ok {
+		info.Name = decodeText(name)
+	}
+	if reason, ok := dict.Get("/Reason"); ok {
+		info.Reason = decodeText(reason)
+	}
+	if date, ok := dict.Get("/M"); ok {
+		info.SigningTime = parsePDFDate(decodeText(date))
+	}
+}
+
+// decodeText decode the literal or hexadecimal text string, text starting with byte order mark is UTF-16
+func decodeText(raw string) string {
+	var decoded []byte
+	switch {
+	case strings.HasPrefix(raw, "(") && strings.HasSuffix(raw, ")"):
+		decoded = unescapeText(raw[1 : len(raw)-1])
+	case strings.HasPrefix(raw, "<") && strings.HasSuffix(raw, ">"):
+		digits := strings.Join(strings.Fields(raw[1:len(raw)-1]), "")
+		if len(digits)%2 == 1 {
+			digits += "0"
+		}
+		decoded, _ = hex.DecodeString(digits)
+	default:
+		return ""
+	}
+
+	if len(decoded) >= 2 && decoded[0] == 0xFE && decoded[1] == 0xFF {
+		units := make([]uint16, 0, len(decoded)/2)
+		for i := 2; i+1 < len(decoded); i += 2 {
+			units = append(units, uint16(decoded[i])<<8|uint16(decoded[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+
+	runes := make([]rune, len(decoded))
+	for i, b := range decoded {
+		runes[i] = rune(b)
+	}
+
+	return string(runes)
+}
+
+// unescapeText resolve the escape sequence of literal string, including octal escape and line continuation
+func unescapeText(text string) []byte {
+	escapes := map[byte]byte{'n': '\n', 'r': '\r', 't': '\t', 'b': '\b', 'f': '\f', '(': '(', ')': ')', '\\': '\\'}
+
+	decoded := make([]byte, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i+1 == len(text) {
+			decoded = append(decoded, text[i])
+			continue
+		}
+
+		i++
+		switch c := text[i]; {
+		case escapes[c] != 0:
+			decoded = append(decoded, escapes[c])
+		case c >= '0' && c <= '7':
+			value := 0
+			for n := 0; n < 3 && i < len(text) && text[i] >= '0' && text[i] <= '7'; n++ {
+				value = value*8 + int(text[i]-'0')
+				i++
+			}
+			i--
+			decoded = append(decoded, byte(value))
+		case c == '\r':
+			if i+1 < len(text) && text[i+1] == '\n' {
+				i++
+			}
+		case c == '\n':
+		default:
+			decoded = append(decoded, c)
+		}
+	}
+
+	return decoded
+}
+
+// parsePDFDate parse the PDF date, eg: D:20221010093000+07'00', date without time zone is read as UTC
+func parsePDFDate(date string) time.Time {
+	match := pdfDatePattern.FindStringSubmatch(date)
+	if match == nil {
+		return time.Time{}
+	}
+
+	offset := 0
+	if match[2] == "+" || match[2] == "-" {
+		hours, _ := strconv.Atoi(match[3])
+		minutes, _ := strconv.Atoi(match[4])
+		offset = hours*3600 + minutes*60
+		if match[2] == "-" {
+			offset = -offset
+		}
+	}
+
+	t, err := time.ParseInLocation("20060102150405", match[1], time.FixedZone("", offset))
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
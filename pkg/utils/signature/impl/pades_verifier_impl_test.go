@@ -0,0 +1,286 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/pdf"
+	pdfImpl "github.com/suryaadi44/eAD-System/pkg/utils/pdf/impl"
+	"github.com/suryaadi44/eAD-System/pkg/utils/signature"
+)
+
+type TestSuitePAdESVerifier struct {
+	suite.Suite
+	verifier    signature.PDFVerifier
+	document    []byte
+	signed      []byte
+	signingTime time.Time
+}
+
+func (s *TestSuitePAdESVerifier) SetupSuite() {
+	document, err := pdfImpl.NewNativePDFService(10*time.Second).GeneratePDF(context.Background(), bytes.NewBufferString(
+		`<h1>Surat Keterangan</h1><p>Halaman pertama</p>`,
+	), &pdf.PDFOptions{MarginTop: 20, MarginBottom: 20, MarginLeft: 20, MarginRight: 20})
+	s.Require().NoError(err)
+	s.document = document
+	s.signingTime = time.Now().In(time.FixedZone("WIB", 7*3600)).Truncate(time.Second)
+
+	s.signed, err = NewPAdESSignerImpl(testKeystorePath, testPassword).SignPDF(context.Background(), document, &signature.SignOptions{
+		SignerID:    testSignerID,
+		Name:        "Budi Śantoso",
+		Reason:      "Surat Keterangan (Domisili)",
+		SigningTime: s.signingTime,
+	})
+	s.Require().NoError(err)
+}
+
+func (s *TestSuitePAdESVerifier) SetupTest() {
+	roots, err := LoadTrustAnchor("testdata/root.pem")
+	s.Require().NoError(err)
+	s.verifier = NewPAdESVerifierImpl(roots)
+}
+
+func (s *TestSuitePAdESVerifier) TearDownTest() {
+	s.verifier = nil
+}
+
+func (s *TestSuitePAdESVerifier) TestVerifyPDF() {
+	tampered := append([]byte(nil), s.signed...)
+	index := bytes.Index(tampered, []byte("/MediaBox"))
+	tampered[index+1] = 'X'
+
+	appended := append(append([]byte(nil), s.signed...), []byte("\n1 0 obj\n<< /Type /Catalog >>\nendobj\n%%EOF\n")...)
+
+	for _, tc := range []struct {
+		Name     string
+		Document []byte
+		Expected []signature.SignatureInfo
+	}{
+		{
+			Name:     "Success without signature",
+			Document: s.document,
+			Expected: []signature.SignatureInfo{},
+		},
+		{
+			Name:     "Success with valid signature",
+			Document: s.signed,
+			Expected: []signature.SignatureInfo{{
+				Signer: "Budi Santoso", Name: "Budi Śantoso", Reason: "Surat Keterangan (Domisili)",
+				SigningTime: s.signingTime, Intact: true, Trusted: true, CoversDocument: true, SignedLength: len(s.signed),
+			}},
+		},
+		{
+			Name:     "Success with content changed after signing",
+			Document: tampered,
+			Expected: []signature.SignatureInfo{{
+				Signer: "Budi Santoso", Name: "Budi Śantoso", Reason: "Surat Keterangan (Domisili)",
+				SigningTime: s.signingTime, Intact: false, Trusted: false, CoversDocument: true, SignedLength: len(s.signed),
+			}},
+		},
+		{
+			Name:     "Success with update appended after signing",
+			Document: appended,
+			Expected: []signature.SignatureInfo{{
+				Signer: "Budi Santoso", Name: "Budi Śantoso", Reason: "Surat Keterangan (Domisili)",
+				SigningTime: s.signingTime, Intact: true, Trusted: true, CoversDocument: false, SignedLength: len(s.signed),
+			}},
+		},
+	} {
+		s.SetupTest()
+		s.Run(tc.Name, func() {
+			signatures, err := s.verifier.VerifyPDF(context.Background(), tc.Document)
+			s.Require().NoError(err)
+			s.Require().Len(signatures, len(tc.Expected))
+			for i, expected := range tc.Expected {
+				s.True(expected.SigningTime.Equal(signatures[i].SigningTime))
+				signatures[i].SigningTime = expected.SigningTime
+				s.Equal(expected, signatures[i])
+			}
+		})
+		s.TearDownTest()
+	}
+}
+
+func (s *TestSuitePAdESVerifier) TestVerifyPDF_SignedTwice() {
+	second, err := NewPAdESSignerImpl(testKeystorePath, testPassword).SignPDF(context.Background(), s.signed, &signature.SignOptions{SignerID: officeKeystore})
+	s.Require().NoError(err)
+
+	signatures, err := s.verifier.VerifyPDF(context.Background(), second)
+	s.Require().NoError(err)
+	s.Require().Len(signatures, 2)
+
+	s.Equal("Budi Santoso", signatures[0].Signer)
+	s.True(signatures[0].Intact)
+	s.True(signatures[0].Trusted)
+	s.False(signatures[0].CoversDocument)
+	s.Equal(len(s.signed), signatures[0].SignedLength)
+
+	s.Equal("Kantor Desa Test", signatures[1].Signer)
+	s.True(signatures[1].Intact)
+	s.True(signatures[1].Trusted)
+	s.True(signatures[1].CoversDocument)
+}
+
+func (s *TestSuitePAdESVerifier) TestVerifyPDF_Untrusted() {
+	for _, tc := range []struct {
+		Name  string
+		Roots *x509.CertPool
+	}{
+		{
+			Name:  "Without trust anchor",
+			Roots: nil,
+		},
+		{
+			Name:  "Signed by another root",
+			Roots: x509.NewCertPool(),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			signatures, err := NewPAdESVerifierImpl(tc.Roots).VerifyPDF(context.Background(), s.signed)
+			s.Require().NoError(err)
+			s.Require().Len(signatures, 1)
+			s.True(signatures[0].Intact)
+			s.False(signatures[0].Trusted)
+		})
+	}
+}
+
+func (s *TestSuitePAdESVerifier) TestVerifyPDF_CheckedNow() {
+	roots, err := LoadTrustAnchor("testdata/root.pem")
+	s.Require().NoError(err)
+
+	// the signing time written in the PDF is inside the validity, only the time of the check decide
+	for _, tc := range []struct {
+		Name            string
+		Roots           *x509.CertPool
+		Now             time.Time
+		ExpectedTrusted bool
+		ExpectedExpired bool
+	}{
+		{
+			Name:            "Success certificate valid now",
+			Roots:           roots,
+			Now:             time.Now(),
+			ExpectedTrusted: true,
+		},
+		{
+			Name:            "Success certificate expired now",
+			Roots:           roots,
+			Now:             time.Now().AddDate(200, 0, 0),
+			ExpectedExpired: true,
+		},
+		{
+			Name:            "Success certificate not valid yet",
+			Roots:           roots,
+			Now:             time.Now().AddDate(-1, 0, 0),
+			ExpectedExpired: true,
+		},
+		{
+			Name:  "Success expired certificate from another root",
+			Roots: x509.NewCertPool(),
+			Now:   time.Now().AddDate(200, 0, 0),
+		},
+	} {
+		s.Run(tc.Name, func() {
+			verifier := NewPAdESVerifierImpl(tc.Roots).(*PAdESVerifierImpl)
+			verifier.now = func() time.Time { return tc.Now }
+
+			signatures, err := verifier.VerifyPDF(context.Background(), s.signed)
+			s.Require().NoError(err)
+			s.Require().Len(signatures, 1)
+			s.True(signatures[0].Intact)
+			s.Equal(tc.ExpectedTrusted, signatures[0].Trusted)
+			s.Equal(tc.ExpectedExpired, signatures[0].Expired)
+		})
+	}
+}
+
+func (s *TestSuitePAdESVerifier) TestVerifyPDF_InvalidByteRange() {
+	for _, tc := range []struct {
+		Name     string
+		Document string
+	}{
+		{
+			Name:     "Overflowing length",
+			Document: "%PDF-1.4\n<ab>\n/ByteRange [0 9 13 99999999999999999999]\n",
+		},
+		{
+			Name:     "Sum overflow",
+			Document: "%PDF-1.4\n<ab>\n/ByteRange [0 9 13 9223372036854775800]\n",
+		},
+		{
+			Name:     "Offset past the document",
+			Document: "%PDF-1.4\n<ab>\n/ByteRange [0 9 100 1]\n",
+		},
+	} {
+		s.Run(tc.Name, func() {
+			signatures, err := s.verifier.VerifyPDF(context.Background(), []byte(tc.Document))
+			s.NoError(err)
+			s.Empty(signatures)
+		})
+	}
+}
+
+func (s *TestSuitePAdESVerifier) TestLoadTrustAnchor() {
+	roots, err := LoadTrustAnchor("")
+	s.NoError(err)
+	s.Nil(roots)
+
+	_, err = LoadTrustAnchor("testdata/missing.pem")
+	s.ErrorIs(err, os.ErrNotExist)
+
+	_, err = LoadTrustAnchor("testdata/generate.sh")
+	s.Equal(utils.ErrInvalidTrustAnchor, err)
+}
+
+func (s *TestSuitePAdESVerifier) TestVerifyPDF_Error() {
+	_, err := s.verifier.VerifyPDF(context.Background(), []byte("not a pdf"))
+	s.Equal(utils.ErrUnsupportedPDF, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = s.verifier.VerifyPDF(ctx, s.signed)
+	s.Equal(context.Canceled, err)
+}
+
+func (s *TestSuitePAdESVerifier) TestDecodeText() {
+	for _, tc := range []struct {
+		Raw      string
+		Expected string
+	}{
+		{Raw: `(Kepala Desa)`, Expected: "Kepala Desa"},
+		{Raw: `(a \(b\) \\ c\nd)`, Expected: "a (b) \\ c\nd"},
+		{Raw: `(caf\351)`, Expected: "café"},
+		{Raw: "(line\\\ncontinued)", Expected: "linecontinued"},
+		{Raw: `<4B 61 6E746F72>`, Expected: "Kantor"},
+		{Raw: `<FEFF0042007500640069>`, Expected: "Budi"},
+		{Raw: `/Name`, Expected: ""},
+	} {
+		s.Equal(tc.Expected, decodeText(tc.Raw), tc.Raw)
+	}
+}
+
+func (s *TestSuitePAdESVerifier) TestParsePDFDate() {
+	for _, tc := range []struct {
+		Date     string
+		Expected time.Time
+	}{
+		{Date: "D:20221010093000+07'00'", Expected: time.Date(2022, 10, 10, 2, 30, 0, 0, time.UTC)},
+		{Date: "D:20221010093000-03'30", Expected: time.Date(2022, 10, 10, 13, 0, 0, 0, time.UTC)},
+		{Date: "D:20221010093000Z", Expected: time.Date(2022, 10, 10, 9, 30, 0, 0, time.UTC)},
+		{Date: "D:20221010093000", Expected: time.Date(2022, 10, 10, 9, 30, 0, 0, time.UTC)},
+		{Date: "10 Oktober 2022", Expected: time.Time{}},
+	} {
+		s.True(tc.Expected.Equal(parsePDFDate(tc.Date)), tc.Date)
+	}
+}
+
+func TestPAdESVerifier(t *testing.T) {
+	suite.Run(t, new(TestSuitePAdESVerifier))
+}
@@ -0,0 +1,44 @@
+#!/bin/sh
+# Generate the keystore used by the signer test: a root and intermediate CA, an RSA signer keystore named after the
+# signer id, and an ECDSA office keystore. The keystore use 3DES since golang.org/x/crypto/pkcs12 only read the legacy
+# algorithm. Password of every keystore is "secret".
+set -e
+cd "$(dirname "$0")"
+work=$(mktemp -d)
+trap 'rm -rf "$work"' EXIT
+
+cat > "$work/ca.ext" <<EXT
+basicConstraints = critical, CA:true
+keyUsage = critical, keyCertSign, cRLSign
+EXT
+cat > "$work/signer.ext" <<EXT
+basicConstraints = critical, CA:false
+keyUsage = critical, digitalSignature, nonRepudiation
+EXT
+
+openssl req -x509 -newkey rsa:2048 -nodes -keyout "$work/root.key" -out root.pem -days 36500 -subj "/CN=eAD Test Root CA" \
+	-addext "basicConstraints=critical,CA:true" -addext "keyUsage=critical,keyCertSign,cRLSign"
+
+openssl req -newkey rsa:2048 -nodes -keyout "$work/intermediate.key" -out "$work/intermediate.csr" -subj "/CN=eAD Test Intermediate CA"
+openssl x509 -req -in "$work/intermediate.csr" -CA root.pem -CAkey "$work/root.key" -CAserial "$work/root.srl" -CAcreateserial -days 36500 \
+	-extfile "$work/ca.ext" -out "$work/intermediate.pem"
+
+openssl req -newkey rsa:2048 -nodes -keyout "$work/signer.key" -out "$work/signer.csr" -subj "/CN=Budi Santoso/O=Desa Test"
+openssl x509 -req -in "$work/signer.csr" -CA "$work/intermediate.pem" -CAkey "$work/intermediate.key" -CAserial "$work/intermediate.srl" -CAcreateserial -days 36500 \
+	-extfile "$work/signer.ext" -out "$work/signer.pem"
+
+openssl ecparam -name prime256v1 -genkey -noout -out "$work/office.key"
+openssl req -new -key "$work/office.key" -out "$work/office.csr" -subj "/CN=Kantor Desa Test/O=Desa Test"
+openssl x509 -req -in "$work/office.csr" -CA "$work/intermediate.pem" -CAkey "$work/intermediate.key" -CAserial "$work/intermediate.srl" -CAcreateserial -days 36500 \
+	-extfile "$work/signer.ext" -out "$work/office.pem"
+
+cat "$work/intermediate.pem" root.pem > "$work/chain.pem"
+for name in signer office; do
+	keystore=$name
+	if [ "$name" = "signer" ]; then
+		keystore=6f1f0a8e-3f2c-4a51-9c8b-2d8e7c1b5a90
+	fi
+	openssl pkcs12 -export -inkey "$work/$name.key" -in "$work/$name.pem" -certfile "$work/chain.pem" \
+		-keypbe PBE-SHA1-3DES -certpbe PBE-SHA1-3DES -macalg sha1 -passout pass:secret -out "$keystore.p12"
+done
+chmod 644 *.p12
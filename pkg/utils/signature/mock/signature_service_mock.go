@@ -0,0 +1,25 @@
+package mock
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"github.com/suryaadi44/eAD-System/pkg/utils/signature"
+)
+
+type MockPDFSigner struct {
+	mock.Mock
+}
+
+func (m *MockPDFSigner) SignPDF(ctx context.Context, document []byte, options *signature.SignOptions) ([]byte, error) {
+	args := m.Called(ctx, document, options)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+type MockPDFVerifier struct {
+	mock.Mock
+}
+
+func (m *MockPDFVerifier) VerifyPDF(ctx context.Context, document []byte) ([]signature.SignatureInfo, error) {
+	args := m.Called(ctx, document)
+	return args.Get(0).([]signature.SignatureInfo), args.Error(1)
+}
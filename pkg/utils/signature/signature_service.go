@@ -0,0 +1,44 @@
+package signature
+
+import (
+	"context"
+	"time"
+)
+
+// SignOptions describe who sign the PDF and where the visible signature is placed. The placement is in millimeter
+// from the top left of the page, a zero width or height create an invisible signature
+type SignOptions struct {
+	SignerID    string
+	Name        string
+	Reason      string
+	SigningTime time.Time
+	Page        uint // page number starting from 1, zero for the last page
+	X           uint
+	Y           uint
+	Width       uint
+	Height      uint
+}
+
+// PDFSigner apply a PAdES signature to the PDF, so a reader application can validate who signed it and that it is
+// not modified afterward
+type PDFSigner interface {
+	SignPDF(ctx context.Context, document []byte, options *SignOptions) ([]byte, error)
+}
+
+// SignatureInfo describe a signature found in the PDF
+type SignatureInfo struct {
+	Signer         string // common name of the signing certificate
+	Name           string
+	Reason         string
+	SigningTime    time.Time
+	Intact         bool // the signed byte range match the signature made with the embedded certificate
+	Trusted        bool // the embedded certificate chain up to the configured trust anchor now
+	Expired        bool // the embedded certificate chain up to the trust anchor but is outside its validity period now
+	CoversDocument bool // nothing was appended to the PDF after it was signed
+	SignedLength   int  // length of the PDF revision covered by the signature
+}
+
+// PDFVerifier check the signature embedded in the PDF, the PDF without signature return no signature
+type PDFVerifier interface {
+	VerifyPDF(ctx context.Context, document []byte) ([]SignatureInfo, error)
+}
@@ -0,0 +1,147 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+	"github.com/suryaadi44/eAD-System/pkg/utils/storage"
+)
+
+type FileStorageImpl struct {
+	basePath string
+}
+
+func NewFileStorageImpl(basePath string) storage.ArtifactStorage {
+	return &FileStorageImpl{basePath: basePath}
+}
+
+// Put write the content atomically, so a reader never see a partially written file
+func (f *FileStorageImpl) Put(ctx context.Context, key string, content []byte) error {
+	path, err := f.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (f *FileStorageImpl) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := f.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, utils.ErrArtifactNotFound
+		}
+
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// DeletePrefix remove the directory of the prefix, eg: cache/<id>/
+func (f *FileStorageImpl) DeletePrefix(ctx context.Context, prefix string) error {
+	path, err := f.resolve(prefix)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(path)
+}
+
+// DeleteOlderThan remove every file under the prefix that was last written before the given time, and the directory
+// left empty by it. Return the number of removed file, missing prefix is not an error
+func (f *FileStorageImpl) DeleteOlderThan(ctx context.Context, prefix string, before time.Time) (int, error) {
+	root, err := f.resolve(prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	var dirs []string
+	removed := 0
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if path != root {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if info.ModTime().Before(before) {
+			if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			removed++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+
+	// deepest directory first, os.Remove fail on the directory that still has file which is what is wanted
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
+	}
+
+	return removed, nil
+}
+
+// resolve convert the key into path inside the base path, key that escape the base path is rejected
+func (f *FileStorageImpl) resolve(key string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", utils.ErrInvalidArtifactKey
+	}
+
+	return filepath.Join(f.basePath, cleaned), nil
+}
@@ -0,0 +1,89 @@
+package impl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/suryaadi44/eAD-System/pkg/utils"
+)
+
+func TestFileStorageImpl(t *testing.T) {
+	basePath := t.TempDir()
+	storage := NewFileStorageImpl(basePath)
+	ctx := context.Background()
+
+	assert.NoError(t, storage.Put(ctx, "cache/1/abc.pdf", []byte("first")))
+	assert.NoError(t, storage.Put(ctx, "cache/1/abc.pdf", []byte("second")))
+	assert.NoError(t, storage.Put(ctx, "signed/1.pdf", []byte("signed")))
+
+	content, err := storage.Get(ctx, "cache/1/abc.pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("second"), content)
+
+	entries, err := os.ReadDir(filepath.Join(basePath, "cache", "1"))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.NoError(t, storage.DeletePrefix(ctx, "cache/1/"))
+	_, err = storage.Get(ctx, "cache/1/abc.pdf")
+	assert.Equal(t, utils.ErrArtifactNotFound, err)
+
+	content, err = storage.Get(ctx, "signed/1.pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("signed"), content)
+}
+
+func TestFileStorageImpl_DeleteOlderThan(t *testing.T) {
+	basePath := t.TempDir()
+	storage := NewFileStorageImpl(basePath)
+	ctx := context.Background()
+
+	assert.NoError(t, storage.Put(ctx, "cache/1/old.pdf", []byte("old")))
+	assert.NoError(t, storage.Put(ctx, "cache/2/old.pdf", []byte("old")))
+	assert.NoError(t, storage.Put(ctx, "cache/2/new.pdf", []byte("new")))
+	assert.NoError(t, storage.Put(ctx, "signed/1.pdf", []byte("signed")))
+
+	past := time.Now().Add(-2 * time.Hour)
+	for _, key := range []string{"cache/1/old.pdf", "cache/2/old.pdf", "signed/1.pdf"} {
+		assert.NoError(t, os.Chtimes(filepath.Join(basePath, filepath.FromSlash(key)), past, past))
+	}
+
+	removed, err := storage.DeleteOlderThan(ctx, "cache/", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	_, err = os.Stat(filepath.Join(basePath, "cache", "1"))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = storage.Get(ctx, "cache/2/old.pdf")
+	assert.Equal(t, utils.ErrArtifactNotFound, err)
+
+	for _, key := range []string{"cache/2/new.pdf", "signed/1.pdf"} {
+		_, err = storage.Get(ctx, key)
+		assert.NoError(t, err)
+	}
+
+	removed, err = storage.DeleteOlderThan(ctx, "missing/", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
+
+func TestFileStorageImpl_InvalidKey(t *testing.T) {
+	storage := NewFileStorageImpl(t.TempDir())
+	ctx := context.Background()
+
+	for _, key := range []string{"", ".", "..", "../secret", "cache/../../secret", "/etc/passwd"} {
+		t.Run(key, func(t *testing.T) {
+			assert.Equal(t, utils.ErrInvalidArtifactKey, storage.Put(ctx, key, []byte("x")))
+			_, err := storage.Get(ctx, key)
+			assert.Equal(t, utils.ErrInvalidArtifactKey, err)
+			assert.Equal(t, utils.ErrInvalidArtifactKey, storage.DeletePrefix(ctx, key))
+			_, err = storage.DeleteOlderThan(ctx, key, time.Now())
+			assert.Equal(t, utils.ErrInvalidArtifactKey, err)
+		})
+	}
+}
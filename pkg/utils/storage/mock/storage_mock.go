@@ -0,0 +1,32 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockArtifactStorage struct {
+	mock.Mock
+}
+
+func (m *MockArtifactStorage) Put(ctx context.Context, key string, content []byte) error {
+	args := m.Called(ctx, key, content)
+	return args.Error(0)
+}
+
+func (m *MockArtifactStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockArtifactStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	args := m.Called(ctx, prefix)
+	return args.Error(0)
+}
+
+func (m *MockArtifactStorage) DeleteOlderThan(ctx context.Context, prefix string, before time.Time) (int, error) {
+	args := m.Called(ctx, prefix, before)
+	return args.Int(0), args.Error(1)
+}
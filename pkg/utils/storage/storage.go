@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// ArtifactStorage store generated file such as the rendered PDF, the key is slash separated path, eg: signed/<id>.pdf
+type ArtifactStorage interface {
+	Put(ctx context.Context, key string, content []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	DeletePrefix(ctx context.Context, prefix string) error
+	DeleteOlderThan(ctx context.Context, prefix string, before time.Time) (int, error)
+}